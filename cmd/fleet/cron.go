@@ -21,6 +21,7 @@ import (
 	"github.com/fleetdm/fleet/v4/server/service/externalsvc"
 	"github.com/fleetdm/fleet/v4/server/service/schedule"
 	"github.com/fleetdm/fleet/v4/server/vulnerabilities/nvd"
+	"github.com/fleetdm/fleet/v4/server/vulnerabilities/osv"
 	"github.com/fleetdm/fleet/v4/server/vulnerabilities/oval"
 	"github.com/fleetdm/fleet/v4/server/webhooks"
 	"github.com/fleetdm/fleet/v4/server/worker"
@@ -169,7 +170,8 @@ func scanVulnerabilities(
 
 	nvdVulns := checkNVDVulnerabilities(ctx, ds, logger, vulnPath, config, vulnAutomationEnabled != "")
 	ovalVulns := checkOvalVulnerabilities(ctx, ds, logger, vulnPath, config, vulnAutomationEnabled != "")
-	vulns, meta := recentVulns(ctx, ds, logger, nvdVulns, ovalVulns, config.RecentVulnerabilityMaxAge)
+	osvVulns := checkOSVVulnerabilities(ctx, ds, logger, vulnPath, config, vulnAutomationEnabled != "")
+	vulns, meta := recentVulns(ctx, ds, logger, nvdVulns, ovalVulns, osvVulns, config.RecentVulnerabilityMaxAge)
 
 	if len(vulns) > 0 {
 		switch vulnAutomationEnabled {
@@ -226,7 +228,7 @@ func scanVulnerabilities(
 	return nil
 }
 
-// recentVulns filters both the vulnerabilities comming from NVD and OVAL based on 'maxAge'
+// recentVulns filters the vulnerabilities comming from NVD, OVAL and OSV based on 'maxAge'
 // (any vulnerability older than 'maxAge' will be excluded). Returns the filtered vulnerabilities
 // and their meta data.
 func recentVulns(
@@ -235,9 +237,10 @@ func recentVulns(
 	logger kitlog.Logger,
 	nvdVulns []fleet.SoftwareVulnerability,
 	ovalVulns []fleet.SoftwareVulnerability,
+	osvVulns []fleet.SoftwareVulnerability,
 	maxAge time.Duration,
 ) ([]fleet.SoftwareVulnerability, map[string]fleet.CVEMeta) {
-	if len(nvdVulns) == 0 && len(ovalVulns) == 0 {
+	if len(nvdVulns) == 0 && len(ovalVulns) == 0 && len(osvVulns) == 0 {
 		return nil, nil
 	}
 
@@ -266,6 +269,12 @@ func recentVulns(
 			vulns = append(vulns, v)
 		}
 	}
+	for _, v := range osvVulns {
+		if _, ok := recent[v.CVE]; ok && !seen[v.Key()] {
+			seen[v.Key()] = true
+			vulns = append(vulns, v)
+		}
+	}
 
 	return vulns, recent
 }
@@ -320,6 +329,39 @@ func checkOvalVulnerabilities(
 	return results
 }
 
+func checkOSVVulnerabilities(
+	ctx context.Context,
+	ds fleet.Datastore,
+	logger kitlog.Logger,
+	vulnPath string,
+	config *config.VulnerabilitiesConfig,
+	collectVulns bool,
+) []fleet.SoftwareVulnerability {
+	if !config.DisableDataSync {
+		// Sync on disk OSV databases of the supported ecosystems.
+		downloaded, err := osv.Refresh(ctx, fleethttp.NewClient(), vulnPath)
+		if err != nil {
+			errHandler(ctx, logger, "updating osv databases", err)
+		}
+		for _, d := range downloaded {
+			level.Debug(logger).Log("osv-sync-downloaded", d)
+		}
+	}
+
+	start := time.Now()
+	r, err := osv.Analyze(ctx, ds, vulnPath, collectVulns)
+	level.Debug(logger).Log(
+		"msg", "osv-analysis-done",
+		"elapsed", time.Since(start),
+		"found new", len(r))
+	if err != nil {
+		errHandler(ctx, logger, "analyzing osv databases", err)
+		return nil
+	}
+
+	return r
+}
+
 func checkNVDVulnerabilities(
 	ctx context.Context,
 	ds fleet.Datastore,
@@ -404,6 +446,14 @@ func startAutomationsSchedule(
 				return triggerFailingPoliciesAutomation(ctx, ds, kitlog.With(logger, "automation", "failing_policies"), failingPoliciesSet)
 			},
 		),
+		schedule.WithJob(
+			"unapproved_software_webhook",
+			func(ctx context.Context) error {
+				return webhooks.TriggerUnapprovedSoftwareWebhook(
+					ctx, ds, kitlog.With(logger, "automation", "unapproved_software"), time.Now(),
+				)
+			},
+		),
 	)
 	s.Start()
 	return s, nil
@@ -478,8 +528,9 @@ func startIntegrationsSchedule(
 
 	// create the worker and register the Jira and Zendesk jobs even if no
 	// integration is enabled, as that config can change live (and if it's not
-	// there won't be any records to process so it will mostly just sleep).
-	w := worker.NewWorker(ds, logger)
+	// there won't be any records to process so it will mostly just sleep). The
+	// activity webhook jobs are processed by their own schedule.
+	w := worker.NewRegisteredOnlyWorker(ds, logger)
 	jira := &worker.Jira{
 		Datastore:     ds,
 		Log:           logger,
@@ -541,6 +592,97 @@ func startIntegrationsSchedule(
 	return s, nil
 }
 
+func startActivityWebhooksSchedule(
+	ctx context.Context,
+	instanceID string,
+	ds fleet.Datastore,
+	logger kitlog.Logger,
+) *schedule.Schedule {
+	const (
+		name            = "activity_webhooks"
+		defaultInterval = 1 * time.Minute
+	)
+
+	logger = kitlog.With(logger, "cron", name)
+
+	// the activity webhook jobs are queued when activities are created, they are
+	// processed by a dedicated worker so that they are delivered shortly after
+	// the activities happen. The host quarantine webhook jobs, queued when hosts
+	// are quarantined, are processed by the same worker for the same reason.
+	w := worker.NewRegisteredOnlyWorker(ds, logger)
+	w.Register(&worker.ActivityWebhook{
+		Datastore: ds,
+		Log:       logger,
+	})
+	w.Register(&worker.HostQuarantineWebhook{
+		Datastore: ds,
+		Log:       logger,
+	})
+
+	s := schedule.New(
+		ctx, name, instanceID, defaultInterval, ds,
+		schedule.WithLogger(logger),
+		schedule.WithJob("activity_webhooks_worker", func(ctx context.Context) error {
+			workCtx, cancel := context.WithTimeout(ctx, defaultInterval)
+			defer cancel()
+
+			if err := w.ProcessJobs(workCtx); err != nil {
+				return fmt.Errorf("processing activity webhooks jobs: %w", err)
+			}
+			return nil
+		}),
+	)
+	s.Start()
+
+	return s
+}
+
+func startScheduledLiveQueriesSchedule(
+	ctx context.Context,
+	instanceID string,
+	ds fleet.Datastore,
+	svc fleet.Service,
+	logger kitlog.Logger,
+) *schedule.Schedule {
+	const (
+		name            = "scheduled_live_queries"
+		defaultInterval = 1 * time.Minute
+	)
+
+	logger = kitlog.With(logger, "cron", name)
+
+	// the webhook jobs are queued when the campaign of a run is completed at
+	// the end of its results window, they are processed by a dedicated worker.
+	w := worker.NewRegisteredOnlyWorker(ds, logger)
+	w.Register(&worker.ScheduledLiveQueryWebhook{
+		Datastore: ds,
+		Log:       logger,
+	})
+
+	s := schedule.New(
+		ctx, name, instanceID, defaultInterval, ds,
+		schedule.WithLogger(logger),
+		schedule.WithJob("run_due_scheduled_live_queries", func(ctx context.Context) error {
+			return svc.RunDueScheduledLiveQueries(ctx, time.Now())
+		}),
+		schedule.WithJob("complete_ended_scheduled_live_query_runs", func(ctx context.Context) error {
+			return svc.CompleteEndedScheduledLiveQueryRuns(ctx, time.Now())
+		}),
+		schedule.WithJob("scheduled_live_queries_worker", func(ctx context.Context) error {
+			workCtx, cancel := context.WithTimeout(ctx, defaultInterval)
+			defer cancel()
+
+			if err := w.ProcessJobs(workCtx); err != nil {
+				return fmt.Errorf("processing scheduled live queries jobs: %w", err)
+			}
+			return nil
+		}),
+	)
+	s.Start()
+
+	return s
+}
+
 func newJiraClient(opts *externalsvc.JiraOptions) (worker.JiraClient, error) {
 	client, err := externalsvc.NewJiraClient(opts)
 	if err != nil {
@@ -596,6 +738,7 @@ func newFailerClient(forcedFailures string) *worker.TestAutomationFailer {
 
 func startCleanupsAndAggregationSchedule(
 	ctx context.Context, instanceID string, ds fleet.Datastore, logger kitlog.Logger, enrollHostLimiter fleet.EnrollHostLimiter,
+	osqueryConfig *config.OsqueryConfig, failingPoliciesSet fleet.FailingPolicySet,
 ) {
 	schedule.New(
 		ctx, "cleanups_then_aggregation", instanceID, 1*time.Hour, ds,
@@ -610,6 +753,15 @@ func startCleanupsAndAggregationSchedule(
 				return err
 			},
 		),
+		schedule.WithJob(
+			"distributed_query_campaign_results",
+			func(ctx context.Context) error {
+				if osqueryConfig.LiveQueryResultsTTL <= 0 {
+					return nil
+				}
+				return ds.CleanupDistributedQueryCampaignResults(ctx, time.Now().Add(-osqueryConfig.LiveQueryResultsTTL))
+			},
+		),
 		schedule.WithJob(
 			"incoming_hosts",
 			func(ctx context.Context) error {
@@ -637,12 +789,73 @@ func startCleanupsAndAggregationSchedule(
 				return ds.CleanupPolicyMembership(ctx, time.Now())
 			},
 		),
+		schedule.WithJob(
+			"policy_membership_history",
+			func(ctx context.Context) error {
+				if osqueryConfig.PolicyHistoryRetention <= 0 {
+					return nil
+				}
+				return ds.CleanupPolicyMembershipHistory(ctx, time.Now().Add(-osqueryConfig.PolicyHistoryRetention))
+			},
+		),
+		schedule.WithJob(
+			"software_changes",
+			func(ctx context.Context) error {
+				if osqueryConfig.SoftwareChangesRetention <= 0 {
+					return nil
+				}
+				return ds.CleanupSoftwareChanges(ctx, time.Now().Add(-osqueryConfig.SoftwareChangesRetention))
+			},
+		),
+		schedule.WithJob(
+			"host_vitals_history",
+			func(ctx context.Context) error {
+				if osqueryConfig.HostVitalsHistoryRetention <= 0 {
+					return nil
+				}
+				return ds.CleanupHostVitalsHistory(ctx, time.Now().Add(-osqueryConfig.HostVitalsHistoryRetention))
+			},
+		),
+		schedule.WithJob(
+			"expired_policy_waivers",
+			func(ctx context.Context) error {
+				return policies.DeleteExpiredPolicyWaivers(
+					ctx, ds, kitlog.With(logger, "automation", "policy_waivers"), failingPoliciesSet, time.Now(),
+				)
+			},
+		),
+		schedule.WithJob(
+			"software_rule_violations",
+			func(ctx context.Context) error {
+				return policies.UpdateSoftwareRuleViolations(
+					ctx, ds, kitlog.With(logger, "automation", "software_rules"), failingPoliciesSet,
+				)
+			},
+		),
 		schedule.WithJob(
 			"sync_enrolled_host_ids",
 			func(ctx context.Context) error {
 				return enrollHostLimiter.SyncEnrolledHostIDs(ctx)
 			},
 		),
+		schedule.WithJob(
+			"activity_webhook_deliveries",
+			func(ctx context.Context) error {
+				return ds.CleanupActivityWebhookDeliveries(ctx, time.Now())
+			},
+		),
+		schedule.WithJob(
+			"scheduled_query_results",
+			func(ctx context.Context) error {
+				// the results are stored only if enabled, but they may have been
+				// stored before it was disabled. A zero retention keeps them
+				// until they are replaced by newer results.
+				if osqueryConfig.ScheduledQueryResultsRetention <= 0 {
+					return nil
+				}
+				return ds.CleanupScheduledQueryResults(ctx, time.Now().Add(-osqueryConfig.ScheduledQueryResultsRetention))
+			},
+		),
 		schedule.WithJob(
 			"cleanup_host_operating_systems",
 			func(ctx context.Context) error {
@@ -12,17 +12,17 @@ import (
 )
 
 func TestFilterRecentVulns(t *testing.T) {
-	t.Run("no NVD nor OVAL vulns", func(t *testing.T) {
+	t.Run("no NVD, OVAL nor OSV vulns", func(t *testing.T) {
 		ctx := context.Background()
 		ds := new(mock.Store)
 		logger := kitlog.NewNopLogger()
 
-		vulns, meta := recentVulns(ctx, ds, logger, nil, nil, 2*time.Hour)
+		vulns, meta := recentVulns(ctx, ds, logger, nil, nil, nil, 2*time.Hour)
 		require.Empty(t, vulns)
 		require.Empty(t, meta)
 	})
 
-	t.Run("filters both NVD and OVAL vulns based on max age", func(t *testing.T) {
+	t.Run("filters NVD, OVAL and OSV vulns based on max age", func(t *testing.T) {
 		ctx := context.Background()
 		ds := new(mock.Store)
 		logger := kitlog.NewNopLogger()
@@ -31,6 +31,7 @@ func TestFilterRecentVulns(t *testing.T) {
 			{CVE: "cve-recent-1"},
 			{CVE: "cve-recent-2"},
 			{CVE: "cve-recent-3"},
+			{CVE: "cve-recent-4"},
 		}
 
 		ds.ListCVEsFunc = func(ctx context.Context, maxAge time.Duration) ([]fleet.CVEMeta, error) {
@@ -51,16 +52,23 @@ func TestFilterRecentVulns(t *testing.T) {
 			{CVE: "cve-outdated-3"},
 		}
 
+		osvVulns := []fleet.SoftwareVulnerability{
+			{CVE: "cve-recent-3"},
+			{CVE: "cve-recent-4"},
+			{CVE: "cve-outdated-4"},
+		}
+
 		maxAge := 30 * 24 * time.Hour
 
 		expected := []string{
 			"cve-recent-1",
 			"cve-recent-2",
 			"cve-recent-3",
+			"cve-recent-4",
 		}
 
 		var actual []string
-		vulns, meta := recentVulns(ctx, ds, logger, nvdVulns, ovalVulns, maxAge)
+		vulns, meta := recentVulns(ctx, ds, logger, nvdVulns, ovalVulns, osvVulns, maxAge)
 		for _, r := range vulns {
 			actual = append(actual, r.CVE)
 		}
@@ -69,6 +77,7 @@ func TestFilterRecentVulns(t *testing.T) {
 			"cve-recent-1": {CVE: "cve-recent-1"},
 			"cve-recent-2": {CVE: "cve-recent-2"},
 			"cve-recent-3": {CVE: "cve-recent-3"},
+			"cve-recent-4": {CVE: "cve-recent-4"},
 		}
 
 		require.Equal(t, len(expected), len(actual))
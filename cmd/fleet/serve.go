@@ -26,6 +26,7 @@ import (
 	"github.com/fleetdm/fleet/v4/server"
 	configpkg "github.com/fleetdm/fleet/v4/server/config"
 	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/datastore/auditlog"
 	"github.com/fleetdm/fleet/v4/server/datastore/cached_mysql"
 	"github.com/fleetdm/fleet/v4/server/datastore/mysql"
 	"github.com/fleetdm/fleet/v4/server/datastore/mysqlredis"
@@ -353,6 +354,9 @@ the way that the Fleet server works.
 			if err != nil {
 				initFatal(err, "initializing osquery logging")
 			}
+			if osqueryLogger.Audit != nil {
+				ds = auditlog.New(ds, osqueryLogger.Audit, logger)
+			}
 
 			failingPolicySet := redis_policy_set.NewFailing(redisPool)
 
@@ -465,7 +469,7 @@ the way that the Fleet server works.
 				initFatal(errors.New("Error generating random instance identifier"), "")
 			}
 
-			startCleanupsAndAggregationSchedule(ctx, instanceID, ds, logger, redisWrapperDS)
+			startCleanupsAndAggregationSchedule(ctx, instanceID, ds, logger, redisWrapperDS, &config.Osquery, failingPolicySet)
 			startSendStatsSchedule(ctx, instanceID, ds, config, license, logger)
 			startVulnerabilitiesSchedule(ctx, instanceID, ds, logger, &config.Vulnerabilities, license)
 			if _, err := startAutomationsSchedule(ctx, instanceID, ds, logger, 5*time.Minute, failingPolicySet); err != nil {
@@ -474,6 +478,8 @@ the way that the Fleet server works.
 			if _, err := startIntegrationsSchedule(ctx, instanceID, ds, logger); err != nil {
 				initFatal(err, "failed to register integrations schedule")
 			}
+			startActivityWebhooksSchedule(ctx, instanceID, ds, logger)
+			startScheduledLiveQueriesSchedule(ctx, instanceID, ds, svc, logger)
 			if config.MDMApple.Enable {
 				startAppleMDMDEPProfileAssigner(ctx, instanceID, config.MDMApple.DEP.SyncPeriodicity, ds, depStorage, logger, config.Logging.Debug)
 			}
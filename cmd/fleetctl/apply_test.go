@@ -492,6 +492,10 @@ spec:
   resolution: "Run the following command in the Terminal app: /usr/sbin/spctl --master-enable"
   platform: darwin
   team: Team1
+  severity: high
+  tags:
+    - cis-2.5.1
+    - cis-level-1
 ---
 apiVersion: v1
 kind: policy
@@ -518,6 +522,10 @@ spec:
 	for _, p := range appliedPolicySpecs {
 		assert.NotEmpty(t, p.Platform)
 	}
+	assert.Equal(t, fleet.PolicySeverityHigh, appliedPolicySpecs[0].Severity)
+	assert.Equal(t, []string{"cis-2.5.1", "cis-level-1"}, appliedPolicySpecs[0].Tags)
+	assert.Empty(t, appliedPolicySpecs[1].Severity)
+	assert.Empty(t, appliedPolicySpecs[1].Tags)
 	assert.True(t, ds.TeamByNameFuncInvoked)
 }
 
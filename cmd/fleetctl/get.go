@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/fleetdm/fleet/v4/pkg/secure"
 	"gopkg.in/guregu/null.v3"
@@ -25,6 +27,9 @@ const (
 	withQueriesFlagName         = "with-queries"
 	expiredFlagName             = "expired"
 	includeServerConfigFlagName = "include-server-config"
+	scheduledQueryFlagName      = "scheduled-query"
+	hostIDFlagName              = "host"
+	sbomFormatFlagName          = "format"
 )
 
 type specGeneric struct {
@@ -273,6 +278,7 @@ func getCommand() *cli.Command {
 			getUserRolesCommand(),
 			getTeamsCommand(),
 			getSoftwareCommand(),
+			getQueryResultsCommand(),
 		},
 	}
 }
@@ -957,6 +963,14 @@ func getSoftwareCommand() *cli.Command {
 				Name:  teamFlagName,
 				Usage: "Only list software of hosts that belong to the specified team",
 			},
+			&cli.UintFlag{
+				Name:  hostIDFlagName,
+				Usage: "Only export the software of the specified host (requires --format)",
+			},
+			&cli.StringFlag{
+				Name:  sbomFormatFlagName,
+				Usage: "Export the software as a software bill of materials (SBOM) in the specified format (cyclonedx, spdx)",
+			},
 			jsonFlag(),
 			yamlFlag(),
 			configFlag(),
@@ -980,6 +994,32 @@ func getSoftwareCommand() *cli.Command {
 				query.Set("team_id", strconv.FormatUint(uint64(teamID), 10))
 			}
 
+			if format := c.String(sbomFormatFlagName); format != "" {
+				if c.Bool(yamlFlagName) || c.Bool(jsonFlagName) {
+					return fmt.Errorf("Can't specify --%s with the yaml or json flags.", sbomFormatFlagName)
+				}
+				if !fleet.SBOMFormat(format).IsValid() {
+					return fmt.Errorf("Invalid --%s %q, must be one of: cyclonedx, spdx.", sbomFormatFlagName, format)
+				}
+				query.Set("format", format)
+				if hostID := c.Uint(hostIDFlagName); hostID != 0 {
+					if teamID != 0 {
+						return fmt.Errorf("Can't specify both --%s and --%s.", teamFlagName, hostIDFlagName)
+					}
+					query.Set("host_id", strconv.FormatUint(uint64(hostID), 10))
+				}
+
+				doc, err := client.GetSBOM(query.Encode())
+				if err != nil {
+					return fmt.Errorf("could not export software: %w", err)
+				}
+				fmt.Fprintln(c.App.Writer, string(doc))
+				return nil
+			}
+			if c.Uint(hostIDFlagName) != 0 {
+				return fmt.Errorf("--%s requires --%s.", hostIDFlagName, sbomFormatFlagName)
+			}
+
 			software, err := client.ListSoftware(query.Encode())
 			if err != nil {
 				return fmt.Errorf("could not list software: %w", err)
@@ -1022,3 +1062,89 @@ func getSoftwareCommand() *cli.Command {
 		},
 	}
 }
+
+func getQueryResultsCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "query-results",
+		Aliases: []string{"query_results", "qr"},
+		Usage:   "List the stored results of scheduled queries",
+		Flags: []cli.Flag{
+			&cli.UintFlag{
+				Name:  scheduledQueryFlagName,
+				Usage: "ID of the scheduled query to list the results of",
+			},
+			&cli.UintFlag{
+				Name:  hostIDFlagName,
+				Usage: "ID of the host to list the results of",
+			},
+			jsonFlag(),
+			yamlFlag(),
+			configFlag(),
+			contextFlag(),
+			debugFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			client, err := clientFromCLI(c)
+			if err != nil {
+				return err
+			}
+
+			if c.Bool(yamlFlagName) && c.Bool(jsonFlagName) {
+				return errors.New("Can't specify both yaml and json flags.")
+			}
+
+			schedQueryID, hostID := c.Uint(scheduledQueryFlagName), c.Uint(hostIDFlagName)
+			var results []*fleet.ScheduledQueryResult
+			switch {
+			case schedQueryID != 0:
+				var hostFilter *uint
+				if hostID != 0 {
+					hostFilter = &hostID
+				}
+				results, err = client.ListScheduledQueryResults(schedQueryID, hostFilter)
+			case hostID != 0:
+				results, err = client.ListHostScheduledQueryResults(hostID)
+			default:
+				return fmt.Errorf("--%s or --%s is required", scheduledQueryFlagName, hostIDFlagName)
+			}
+			if err != nil {
+				return fmt.Errorf("could not list query results: %w", err)
+			}
+
+			if len(results) == 0 {
+				log(c, "No query results found")
+				return nil
+			}
+
+			if c.Bool(jsonFlagName) || c.Bool(yamlFlagName) {
+				spec := specGeneric{
+					Kind:    "query_results",
+					Version: "1",
+					Spec:    results,
+				}
+				return printSpec(c, spec)
+			}
+
+			// Default to printing as table
+			data := [][]string{}
+			for _, r := range results {
+				var rows bytes.Buffer
+				if err := json.Compact(&rows, r.Data); err != nil {
+					return fmt.Errorf("could not compact query results of host %s: %w", r.Hostname, err)
+				}
+				data = append(data, []string{
+					r.Hostname,
+					r.PackName,
+					r.ScheduledQueryName,
+					r.Action,
+					r.LastFetched.Format(time.RFC3339),
+					rows.String(),
+				})
+			}
+			columns := []string{"Host", "Pack", "Query", "Action", "Last fetched", "Data"}
+			printTable(c, columns, data)
+
+			return nil
+		},
+	}
+}
@@ -177,6 +177,10 @@ spec:
       jira: null
       zendesk: null
     name: team1
+    scripts:
+      allow_any: false
+      allowlist: null
+      enabled: false
     user_count: 99
     webhook_settings:
       failing_policies_webhook:
@@ -209,6 +213,10 @@ spec:
       jira: null
       zendesk: null
     name: team2
+    scripts:
+      allow_any: false
+      allowlist: null
+      enabled: false
     user_count: 87
     webhook_settings:
       failing_policies_webhook:
@@ -217,8 +225,8 @@ spec:
         host_batch_size: 0
         policy_ids: null
 `
-			expectedJson := `{"kind":"team","apiVersion":"v1","spec":{"team":{"id":42,"created_at":"1999-03-10T02:45:06.371Z","name":"team1","description":"team1 description","webhook_settings":{"failing_policies_webhook":{"enable_failing_policies_webhook":false,"destination_url":"","policy_ids":null,"host_batch_size":0}},"integrations":{"jira":null,"zendesk":null},"features":{"enable_host_users":true,"enable_software_inventory":true},"user_count":99,"host_count":0}}}
-{"kind":"team","apiVersion":"v1","spec":{"team":{"id":43,"created_at":"1999-03-10T02:45:06.371Z","name":"team2","description":"team2 description","agent_options":{"config":{"foo":"bar"},"overrides":{"platforms":{"darwin":{"foo":"override"}}}},"webhook_settings":{"failing_policies_webhook":{"enable_failing_policies_webhook":false,"destination_url":"","policy_ids":null,"host_batch_size":0}},"integrations":{"jira":null,"zendesk":null},"features":{"enable_host_users":false,"enable_software_inventory":false,"additional_queries":{"foo":"bar"}},"user_count":87,"host_count":0}}}
+			expectedJson := `{"kind":"team","apiVersion":"v1","spec":{"team":{"id":42,"created_at":"1999-03-10T02:45:06.371Z","name":"team1","description":"team1 description","webhook_settings":{"failing_policies_webhook":{"enable_failing_policies_webhook":false,"destination_url":"","policy_ids":null,"host_batch_size":0}},"integrations":{"jira":null,"zendesk":null},"features":{"enable_host_users":true,"enable_software_inventory":true},"scripts":{"enabled":false,"allow_any":false,"allowlist":null},"user_count":99,"host_count":0}}}
+{"kind":"team","apiVersion":"v1","spec":{"team":{"id":43,"created_at":"1999-03-10T02:45:06.371Z","name":"team2","description":"team2 description","agent_options":{"config":{"foo":"bar"},"overrides":{"platforms":{"darwin":{"foo":"override"}}}},"webhook_settings":{"failing_policies_webhook":{"enable_failing_policies_webhook":false,"destination_url":"","policy_ids":null,"host_batch_size":0}},"integrations":{"jira":null,"zendesk":null},"features":{"enable_host_users":false,"enable_software_inventory":false,"additional_queries":{"foo":"bar"}},"scripts":{"enabled":false,"allow_any":false,"allowlist":null},"user_count":87,"host_count":0}}}
 `
 			if tt.shouldHaveExpiredBanner {
 				expectedJson = expiredBanner.String() + expectedJson
@@ -467,6 +475,10 @@ spec:
   host_expiry_settings:
     host_expiry_enabled: false
     host_expiry_window: 0
+  host_deduplication_settings:
+    strategy: ""
+  host_lifecycle_settings:
+    quarantine_team_id: null
   features:
     enable_host_users: true
     enable_software_inventory: false
@@ -481,6 +493,10 @@ spec:
     enable_analytics: false
     live_query_disabled: false
     server_url: ""
+  scripts:
+    allow_any: false
+    allowlist: null
+    enabled: false
   smtp_settings:
     authentication_method: ""
     authentication_type: ""
@@ -513,12 +529,19 @@ spec:
       enable_failing_policies_webhook: false
       host_batch_size: 0
       policy_ids: null
+    host_quarantine_webhook:
+      destination_url: ""
+      enable_host_quarantine_webhook: false
     host_status_webhook:
       days_count: 0
       destination_url: ""
       enable_host_status_webhook: false
       host_percentage: 0
     interval: 0s
+    unapproved_software_webhook:
+      destination_url: ""
+      enable_unapproved_software_webhook: false
+      software: null
     vulnerabilities_webhook:
       destination_url: ""
       enable_vulnerabilities_webhook: false
@@ -590,9 +613,21 @@ spec:
         "destination_url": "",
         "host_batch_size": 0
       },
+      "unapproved_software_webhook": {
+        "enable_unapproved_software_webhook": false,
+        "destination_url": "",
+        "software": null
+      },
+      "host_quarantine_webhook": {
+        "enable_host_quarantine_webhook": false,
+        "destination_url": ""
+      },
       "interval": "0s"
     },
-    "integrations": { "jira": null, "zendesk": null }
+    "integrations": { "jira": null, "zendesk": null },
+    "scripts": { "enabled": false, "allow_any": false, "allowlist": null },
+    "host_deduplication_settings": { "strategy": "" },
+    "host_lifecycle_settings": { "quarantine_team_id": null }
   }
 }
 `
@@ -612,6 +647,10 @@ spec:
   host_expiry_settings:
     host_expiry_enabled: false
     host_expiry_window: 0
+  host_deduplication_settings:
+    strategy: ""
+  host_lifecycle_settings:
+    quarantine_team_id: null
   features:
     enable_host_users: true
     enable_software_inventory: false
@@ -626,6 +665,7 @@ spec:
     json: false
     result:
       config:
+        audit_log_file: ""
         enable_log_compression: false
         enable_log_rotation: false
         result_log_file: /dev/null
@@ -633,6 +673,7 @@ spec:
       plugin: filesystem
     status:
       config:
+        audit_log_file: ""
         enable_log_compression: false
         enable_log_rotation: false
         result_log_file: /dev/null
@@ -646,6 +687,10 @@ spec:
     enable_analytics: false
     live_query_disabled: false
     server_url: ""
+  scripts:
+    allow_any: false
+    allowlist: null
+    enabled: false
   smtp_settings:
     authentication_method: ""
     authentication_type: ""
@@ -691,12 +736,19 @@ spec:
       enable_failing_policies_webhook: false
       host_batch_size: 0
       policy_ids: null
+    host_quarantine_webhook:
+      destination_url: ""
+      enable_host_quarantine_webhook: false
     host_status_webhook:
       days_count: 0
       destination_url: ""
       enable_host_status_webhook: false
       host_percentage: 0
     interval: 0s
+    unapproved_software_webhook:
+      destination_url: ""
+      enable_unapproved_software_webhook: false
+      software: null
     vulnerabilities_webhook:
       destination_url: ""
       enable_vulnerabilities_webhook: false
@@ -775,12 +827,32 @@ spec:
         "destination_url": "",
         "host_batch_size": 0
       },
+      "unapproved_software_webhook": {
+        "enable_unapproved_software_webhook": false,
+        "destination_url": "",
+        "software": null
+      },
+      "host_quarantine_webhook": {
+        "enable_host_quarantine_webhook": false,
+        "destination_url": ""
+      },
       "interval": "0s"
     },
     "integrations": {
       "jira": null,
       "zendesk": null
     },
+    "scripts": {
+      "enabled": false,
+      "allow_any": false,
+      "allowlist": null
+    },
+    "host_deduplication_settings": {
+      "strategy": ""
+    },
+    "host_lifecycle_settings": {
+      "quarantine_team_id": null
+    },
     "update_interval": {
       "osquery_detail": "1h0m0s",
       "osquery_policy": "1h0m0s"
@@ -806,6 +878,7 @@ spec:
       "result": {
         "plugin": "filesystem",
         "config": {
+          "audit_log_file": "",
           "enable_log_compression": false,
           "enable_log_rotation": false,
           "result_log_file": "/dev/null",
@@ -815,6 +888,7 @@ spec:
       "status": {
         "plugin": "filesystem",
         "config": {
+          "audit_log_file": "",
           "enable_log_compression": false,
           "enable_log_rotation": false,
           "result_log_file": "/dev/null",
@@ -961,6 +1035,51 @@ spec:
 	assert.Equal(t, uint(999), *gotTeamID)
 }
 
+func TestGetSoftwareSBOM(t *testing.T) {
+	_, ds := runServerWithMockedDS(t)
+
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{OrgInfo: fleet.OrgInfo{OrgName: "Acme"}}, nil
+	}
+	ds.HostFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		return &fleet.Host{ID: id, Hostname: "host1"}, nil
+	}
+	var gotOpts fleet.SoftwareListOptions
+	ds.ListSoftwareFunc = func(ctx context.Context, opt fleet.SoftwareListOptions) ([]fleet.Software, error) {
+		gotOpts = opt
+		return []fleet.Software{
+			{ID: 1, Name: "foo", Version: "0.0.1", Source: "chrome_extensions", GenerateCPE: "somecpe"},
+		}, nil
+	}
+
+	var bom struct {
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			Name string `json:"name"`
+			CPE  string `json:"cpe"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(runAppForTest(t, []string{"get", "software", "--format", "cyclonedx"})), &bom))
+	require.Equal(t, "CycloneDX", bom.BOMFormat)
+	require.Len(t, bom.Components, 1)
+	require.Equal(t, "somecpe", bom.Components[0].CPE)
+	require.Nil(t, gotOpts.HostID)
+
+	var spdx struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Name        string `json:"name"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(runAppForTest(t, []string{"get", "software", "--format", "spdx", "--host", "42"})), &spdx))
+	require.Equal(t, "SPDX-2.3", spdx.SPDXVersion)
+	require.Equal(t, "host1", spdx.Name)
+	require.NotNil(t, gotOpts.HostID)
+	require.Equal(t, uint(42), *gotOpts.HostID)
+
+	runAppCheckErr(t, []string{"get", "software", "--format", "swid"}, `Invalid --format "swid", must be one of: cyclonedx, spdx.`)
+	runAppCheckErr(t, []string{"get", "software", "--host", "42"}, "--host requires --format.")
+	runAppCheckErr(t, []string{"get", "software", "--format", "spdx", "--json"}, "Can't specify --format with the yaml or json flags.")
+}
+
 func TestGetLabels(t *testing.T) {
 	_, ds := runServerWithMockedDS(t)
 
@@ -1393,6 +1512,11 @@ func TestEnrichedAppConfig(t *testing.T) {
             "destination_url": "",
             "host_batch_size": 0
           },
+          "unapproved_software_webhook": {
+            "enable_unapproved_software_webhook": false,
+            "destination_url": "",
+            "software": null
+          },
           "interval": "24h0m0s"
         },
         "integrations": {
@@ -1451,3 +1575,65 @@ func TestEnrichedAppConfig(t *testing.T) {
 		require.Equal(t, "filesystem", enriched.Logging.Status.Plugin)
 	})
 }
+
+func TestGetQueryResults(t *testing.T) {
+	_, ds := runServerWithMockedDS(t)
+
+	ds.ScheduledQueryFunc = func(ctx context.Context, id uint) (*fleet.ScheduledQuery, error) {
+		return &fleet.ScheduledQuery{ID: id, PackID: 1, Name: "uptime"}, nil
+	}
+	ds.PackFunc = func(ctx context.Context, id uint) (*fleet.Pack, error) {
+		return &fleet.Pack{ID: id, Name: "Global", Type: ptr.String("global")}, nil
+	}
+	ds.HostLiteFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		return &fleet.Host{ID: id}, nil
+	}
+	var gotOpts fleet.ScheduledQueryResultListOptions
+	ds.ListScheduledQueryResultsFunc = func(ctx context.Context, filter fleet.TeamFilter, opts fleet.ScheduledQueryResultListOptions) ([]*fleet.ScheduledQueryResult, error) {
+		gotOpts = opts
+		return []*fleet.ScheduledQueryResult{
+			{
+				ID: 1, ScheduledQueryID: 2, HostID: 3, Hostname: "foo.local", PackName: "Global", ScheduledQueryName: "uptime",
+				Action: "snapshot", Data: json.RawMessage(`[{"days":"1"}]`), LastFetched: time.Date(2022, 10, 12, 14, 0, 0, 0, time.UTC),
+			},
+		}, nil
+	}
+
+	expected := `+-----------+--------+--------+----------+----------------------+----------------+
+|   HOST    |  PACK  | QUERY  |  ACTION  |     LAST FETCHED     |      DATA      |
++-----------+--------+--------+----------+----------------------+----------------+
+| foo.local | Global | uptime | snapshot | 2022-10-12T14:00:00Z | [{"days":"1"}] |
++-----------+--------+--------+----------+----------------------+----------------+
+`
+	assert.Equal(t, expected, runAppForTest(t, []string{"get", "query-results", "--scheduled-query", "2"}))
+	require.NotNil(t, gotOpts.ScheduledQueryID)
+	assert.Equal(t, uint(2), *gotOpts.ScheduledQueryID)
+	assert.Nil(t, gotOpts.HostID)
+
+	expectedJson := `{
+  "kind": "query_results",
+  "apiVersion": "1",
+  "spec": [
+    {
+      "id": 1,
+      "scheduled_query_id": 2,
+      "host_id": 3,
+      "scheduled_query_name": "uptime",
+      "pack_name": "Global",
+      "hostname": "foo.local",
+      "action": "snapshot",
+      "data": [{"days": "1"}],
+      "last_fetched": "2022-10-12T14:00:00Z"
+    }
+  ]
+}`
+	assert.JSONEq(t, expectedJson, runAppForTest(t, []string{"get", "query-results", "--scheduled-query", "2", "--host", "3", "--json"}))
+	require.NotNil(t, gotOpts.HostID)
+	assert.Equal(t, uint(3), *gotOpts.HostID)
+
+	runAppForTest(t, []string{"get", "query-results", "--host", "3"})
+	assert.Nil(t, gotOpts.ScheduledQueryID)
+	require.NotNil(t, gotOpts.HostID)
+
+	runAppCheckErr(t, []string{"get", "query-results"}, "--scheduled-query or --host is required")
+}
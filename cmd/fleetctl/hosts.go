@@ -2,7 +2,10 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/urfave/cli/v2"
 )
 
@@ -11,6 +14,7 @@ const (
 	labelFlagName       = "label"
 	statusFlagName      = "status"
 	searchQueryFlagName = "search_query"
+	stateFlagName       = "state"
 )
 
 func hostsCommand() *cli.Command {
@@ -19,6 +23,7 @@ func hostsCommand() *cli.Command {
 		Usage: "Manage Fleet hosts",
 		Subcommands: []*cli.Command{
 			transferCommand(),
+			lifecycleCommand(),
 		},
 	}
 }
@@ -81,3 +86,43 @@ func transferCommand() *cli.Command {
 		},
 	}
 }
+
+func lifecycleCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "lifecycle",
+		Usage:     "Set the lifecycle state of one or more hosts",
+		UsageText: `This command will set the lifecycle state (active, retired, quarantined or in_repair) of the hosts specified.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     stateFlagName,
+				Usage:    "Lifecycle state of the hosts (active, retired, quarantined or in_repair)",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:     hostsFlagName,
+				Usage:    "Comma separated hostnames of the hosts",
+				Required: true,
+			},
+			configFlag(),
+			contextFlag(),
+			debugFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			client, err := clientFromCLI(c)
+			if err != nil {
+				return err
+			}
+
+			state := fleet.HostLifecycleState(c.String(stateFlagName))
+			if !state.IsValid() {
+				return fmt.Errorf("invalid --state %q, must be one of active, retired, quarantined or in_repair", state)
+			}
+
+			var hosts []string
+			for _, h := range c.StringSlice(hostsFlagName) {
+				hosts = append(hosts, strings.Split(h, ",")...)
+			}
+			return client.SetHostsLifecycleState(hosts, state)
+		},
+	}
+}
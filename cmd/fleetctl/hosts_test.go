@@ -35,7 +35,7 @@ func TestHostsTransferByHosts(t *testing.T) {
 		return &fleet.Team{ID: 99, Name: "team1"}, nil
 	}
 
-	ds.AddHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	ds.TransferHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
 		require.NotNil(t, teamID)
 		require.Equal(t, uint(99), *teamID)
 		require.Equal(t, []uint{42}, hostIDs)
@@ -69,7 +69,7 @@ func TestHostsTransferByLabel(t *testing.T) {
 		return []*fleet.Host{{ID: 32}, {ID: 12}}, nil
 	}
 
-	ds.AddHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	ds.TransferHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
 		require.NotNil(t, teamID)
 		require.Equal(t, uint(99), *teamID)
 		require.Equal(t, []uint{32, 12}, hostIDs)
@@ -102,7 +102,7 @@ func TestHostsTransferByStatus(t *testing.T) {
 		return []*fleet.Host{{ID: 32}, {ID: 12}}, nil
 	}
 
-	ds.AddHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	ds.TransferHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
 		require.NotNil(t, teamID)
 		require.Equal(t, uint(99), *teamID)
 		require.Equal(t, []uint{32, 12}, hostIDs)
@@ -137,7 +137,7 @@ func TestHostsTransferByStatusAndSearchQuery(t *testing.T) {
 		return []*fleet.Host{{ID: 32}, {ID: 12}}, nil
 	}
 
-	ds.AddHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	ds.TransferHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
 		require.NotNil(t, teamID)
 		require.Equal(t, uint(99), *teamID)
 		require.Equal(t, []uint{32, 12}, hostIDs)
@@ -147,3 +147,41 @@ func TestHostsTransferByStatusAndSearchQuery(t *testing.T) {
 	assert.Equal(t, "", runAppForTest(t,
 		[]string{"hosts", "transfer", "--team", "team1", "--status", "online", "--search_query", "somequery"}))
 }
+
+func TestHostsLifecycle(t *testing.T) {
+	_, ds := runServerWithMockedDS(t)
+
+	ds.HostByIdentifierFunc = func(ctx context.Context, identifier string) (*fleet.Host, error) {
+		switch identifier {
+		case "host1":
+			return &fleet.Host{ID: 42}, nil
+		case "host2":
+			return &fleet.Host{ID: 43}, nil
+		}
+		return nil, &notFoundError{}
+	}
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, hostIDs []uint) ([]*fleet.Host, error) {
+		hosts := make([]*fleet.Host, 0, len(hostIDs))
+		for _, id := range hostIDs {
+			hosts = append(hosts, &fleet.Host{ID: id, LifecycleState: fleet.HostLifecycleStateActive})
+		}
+		return hosts, nil
+	}
+	ds.SetHostsLifecycleStateFunc = func(ctx context.Context, hostIDs []uint, state fleet.HostLifecycleState, quarantineTeamID *uint) error {
+		require.Equal(t, []uint{42, 43}, hostIDs)
+		require.Equal(t, fleet.HostLifecycleStateRetired, state)
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.Equal(t, fleet.ActivityTypeChangedHostLifecycleState, activityType)
+		return nil
+	}
+
+	assert.Equal(t, "", runAppForTest(t, []string{"hosts", "lifecycle", "--state", "retired", "--hosts", "host1,host2"}))
+	require.True(t, ds.SetHostsLifecycleStateFuncInvoked)
+
+	runAppCheckErr(t,
+		[]string{"hosts", "lifecycle", "--state", "decommissioned", "--hosts", "host1"},
+		`invalid --state "decommissioned", must be one of active, retired, quarantined or in_repair`,
+	)
+}
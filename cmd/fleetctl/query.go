@@ -17,6 +17,7 @@ func queryCommand() *cli.Command {
 		flHosts, flLabels, flQuery, flQueryName string
 		flQuiet, flExit, flPretty               bool
 		flTimeout                               time.Duration
+		flCampaignID                            uint
 	)
 	return &cli.Command{
 		Name:      "query",
@@ -75,6 +76,12 @@ func queryCommand() *cli.Command {
 				Destination: &flTimeout,
 				Usage:       "How long to run query before exiting (10s, 1h, etc.)",
 			},
+			&cli.UintFlag{
+				Name:        "campaign-id",
+				EnvVars:     []string{"CAMPAIGN_ID"},
+				Destination: &flCampaignID,
+				Usage:       "ID of a past live query campaign to print the stored results of, instead of running a query",
+			},
 			configFlag(),
 			contextFlag(),
 			debugFlag(),
@@ -85,6 +92,30 @@ func queryCommand() *cli.Command {
 				return err
 			}
 
+			var output outputWriter
+			if flPretty {
+				output = newPrettyWriter()
+			} else {
+				output = newJsonWriter(c.App.Writer)
+			}
+
+			if flCampaignID != 0 {
+				if flHosts != "" || flLabels != "" || flQuery != "" || flQueryName != "" {
+					return errors.New("--campaign-id must not be provided with --hosts, --labels, --query or --query-name")
+				}
+
+				results, err := fleet.GetLiveQueryCampaignResults(flCampaignID)
+				if err != nil {
+					return err
+				}
+				for _, res := range results {
+					if err := output.WriteResult(res.DistributedQueryResult()); err != nil {
+						return fmt.Errorf("Error writing result: %w", err)
+					}
+				}
+				return nil
+			}
+
 			if flHosts == "" && flLabels == "" {
 				return errors.New("No hosts or labels targeted. Please provide either --hosts or --labels.")
 			}
@@ -105,13 +136,6 @@ func queryCommand() *cli.Command {
 				return errors.New("Query must be specified with --query or --query-name")
 			}
 
-			var output outputWriter
-			if flPretty {
-				output = newPrettyWriter()
-			} else {
-				output = newJsonWriter(c.App.Writer)
-			}
-
 			hosts := strings.Split(flHosts, ",")
 			labels := strings.Split(flLabels, ",")
 
@@ -117,3 +117,31 @@ func TestLiveQuery(t *testing.T) {
 `
 	assert.Equal(t, expected, runAppForTest(t, []string{"query", "--hosts", "1234", "--query", "select 42, * from time"}))
 }
+
+func TestLiveQueryCampaignResults(t *testing.T) {
+	_, ds := runServerWithMockedDS(t)
+
+	ds.DistributedQueryCampaignFunc = func(ctx context.Context, id uint) (*fleet.DistributedQueryCampaign, error) {
+		return &fleet.DistributedQueryCampaign{ID: id, QueryID: 42, Status: fleet.QueryComplete}, nil
+	}
+	ds.QueryFunc = func(ctx context.Context, id uint) (*fleet.Query, error) {
+		return &fleet.Query{ID: id}, nil
+	}
+	errMsg := "failed"
+	ds.ListDistributedQueryCampaignResultsFunc = func(ctx context.Context, filter fleet.TeamFilter, campaignID uint) ([]*fleet.DistributedQueryCampaignResult, error) {
+		require.Equal(t, uint(321), campaignID)
+		return []*fleet.DistributedQueryCampaignResult{
+			{DistributedQueryCampaignID: campaignID, HostID: 1, Hostname: "host1", Rows: []map[string]string{{"bing": "fds"}}},
+			{DistributedQueryCampaignID: campaignID, HostID: 2, Hostname: "host2", Error: &errMsg},
+		}, nil
+	}
+
+	expected := `{"host":"host1","rows":[{"bing":"fds"}]}
+{"host":"host2","rows":null,"error":"failed"}
+`
+	assert.Equal(t, expected, runAppForTest(t, []string{"query", "--campaign-id", "321"}))
+	assert.True(t, ds.ListDistributedQueryCampaignResultsFuncInvoked)
+
+	runAppCheckErr(t, []string{"query", "--campaign-id", "321", "--hosts", "1234"},
+		"--campaign-id must not be provided with --hosts, --labels, --query or --query-name")
+}
@@ -7,7 +7,15 @@ import (
 )
 
 func (svc *Service) ListDevicePolicies(ctx context.Context, host *fleet.Host) ([]*fleet.HostPolicy, error) {
-	return svc.ds.ListPoliciesForHost(ctx, host)
+	policies, err := svc.ds.ListPoliciesForHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	// the remediations of the policies are not shown to the end users
+	for _, p := range policies {
+		p.Remediation = nil
+	}
+	return policies, nil
 }
 
 func (svc *Service) FailingPoliciesCount(ctx context.Context, host *fleet.Host) (uint, error) {
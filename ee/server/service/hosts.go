@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
 	"github.com/fleetdm/fleet/v4/server/fleet"
 )
 
@@ -19,3 +20,67 @@ func (svc *Service) HostByIdentifier(ctx context.Context, identifier string, opt
 	opts.IncludePolicies = true
 	return svc.Service.HostByIdentifier(ctx, identifier, opts)
 }
+
+// ApplyHostAssignmentRules transfers the host to the team of the first host
+// assignment rule it matches, in order of priority. The host is left on its
+// team if it matches no rule, or if a user transferred it to that team. It is
+// called when the host enrolls and when its details are refreshed, so the host
+// moves automatically if it starts matching the rule of another team.
+func (svc *Service) ApplyHostAssignmentRules(ctx context.Context, host *fleet.Host) error {
+	rules, err := svc.ds.ListHostAssignmentRules(ctx)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list host assignment rules")
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var labels map[string]bool
+	if fleet.HostAssignmentRulesUseLabels(rules) {
+		hostLabels, err := svc.ds.ListLabelsForHost(ctx, host.ID)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "list labels for host")
+		}
+		labels = make(map[string]bool, len(hostLabels))
+		for _, l := range hostLabels {
+			labels[l.Name] = true
+		}
+	}
+
+	rule := fleet.MatchHostAssignmentRules(rules, host, labels)
+	if rule == nil || (host.TeamID != nil && *host.TeamID == rule.TeamID) {
+		return nil
+	}
+	// the transfers done by the users take precedence over the rules
+	manual, err := svc.ds.HostTeamSetManually(ctx, host.ID)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "get host manual team")
+	}
+	if manual {
+		return nil
+	}
+
+	if err := svc.ds.AddHostsToTeam(ctx, &rule.TeamID, []uint{host.ID}); err != nil {
+		return ctxerr.Wrap(ctx, err, "transfer host to team")
+	}
+	fromTeamID := host.TeamID
+	host.TeamID = &rule.TeamID
+
+	// the transfer is done by Fleet, so the activity has no user
+	if err := svc.ds.NewActivity(
+		ctx,
+		nil,
+		fleet.ActivityTypeTransferredHostByRule,
+		&map[string]interface{}{
+			"host_id":           host.ID,
+			"host_display_name": host.DisplayName(),
+			"from_team_id":      fromTeamID,
+			"team_id":           rule.TeamID,
+			"team_name":         rule.TeamName,
+			"rule_index":        rule.Index,
+		},
+	); err != nil {
+		return ctxerr.Wrap(ctx, err, "create activity for host transfer by rule")
+	}
+	return nil
+}
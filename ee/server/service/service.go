@@ -49,7 +49,8 @@ func NewService(
 	// Override methods that can't be easily overriden via
 	// embedding.
 	svc.SetEnterpriseOverrides(fleet.EnterpriseOverrides{
-		HostFeatures: eeservice.HostFeatures,
+		HostFeatures:             eeservice.HostFeatures,
+		ApplyHostAssignmentRules: eeservice.ApplyHostAssignmentRules,
 	})
 
 	return eeservice, nil
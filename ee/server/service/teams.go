@@ -119,6 +119,15 @@ func (svc *Service) ModifyTeam(ctx context.Context, teamID uint, payload fleet.T
 		team.Config.Integrations.Zendesk = payload.Integrations.Zendesk
 	}
 
+	if payload.Scripts != nil {
+		invalid := &fleet.InvalidArgumentError{}
+		fleet.ValidateScriptSettings(*payload.Scripts, invalid)
+		if invalid.HasErrors() {
+			return nil, ctxerr.Wrap(ctx, invalid)
+		}
+		team.Config.Scripts = *payload.Scripts
+	}
+
 	if payload.WebhookSettings != nil || payload.Integrations != nil {
 		// must validate that at most only one automation is enabled for each
 		// supported feature - by now the updated payload has been applied to
@@ -449,6 +458,20 @@ func (svc *Service) ApplyTeamSpecs(ctx context.Context, specs []*fleet.TeamSpec,
 		if len(spec.Secrets) > fleet.MaxEnrollSecretsCount {
 			return ctxerr.Wrap(ctx, fleet.NewInvalidArgumentError("secrets", "too many secrets"), "validate secrets")
 		}
+		if spec.Scripts != nil {
+			invalid := &fleet.InvalidArgumentError{}
+			fleet.ValidateScriptSettings(*spec.Scripts, invalid)
+			if invalid.HasErrors() {
+				return ctxerr.Wrap(ctx, invalid, "validate scripts")
+			}
+		}
+		if spec.HostAssignmentRules != nil {
+			invalid := &fleet.InvalidArgumentError{}
+			fleet.ValidateHostAssignmentRules(*spec.HostAssignmentRules, invalid)
+			if invalid.HasErrors() {
+				return ctxerr.Wrap(ctx, invalid, "validate host assignment rules")
+			}
+		}
 
 		if applyOpts.DryRun {
 			continue
@@ -506,11 +529,22 @@ func (svc Service) createTeamFromSpec(ctx context.Context, spec *fleet.TeamSpec,
 		}
 	}
 
+	var scripts fleet.ScriptSettings
+	if spec.Scripts != nil {
+		scripts = *spec.Scripts
+	}
+	var rules []fleet.HostAssignmentRule
+	if spec.HostAssignmentRules != nil {
+		rules = *spec.HostAssignmentRules
+	}
+
 	return svc.ds.NewTeam(ctx, &fleet.Team{
 		Name: spec.Name,
 		Config: fleet.TeamConfig{
-			AgentOptions: agentOptions,
-			Features:     features,
+			AgentOptions:        agentOptions,
+			Features:            features,
+			Scripts:             scripts,
+			HostAssignmentRules: rules,
 		},
 		Secrets: secrets,
 	})
@@ -528,6 +562,15 @@ func (svc Service) editTeamFromSpec(ctx context.Context, team *fleet.Team, spec
 	}
 	team.Config.Features = features
 
+	// the script settings are left unchanged if not provided.
+	if spec.Scripts != nil {
+		team.Config.Scripts = *spec.Scripts
+	}
+	// the host assignment rules are left unchanged if not provided.
+	if spec.HostAssignmentRules != nil {
+		team.Config.HostAssignmentRules = *spec.HostAssignmentRules
+	}
+
 	if len(secrets) > 0 {
 		team.Secrets = secrets
 	}
@@ -26,6 +26,7 @@ import (
 	"github.com/fleetdm/fleet/v4/orbit/pkg/osquery"
 	"github.com/fleetdm/fleet/v4/orbit/pkg/osservice"
 	"github.com/fleetdm/fleet/v4/orbit/pkg/platform"
+	"github.com/fleetdm/fleet/v4/orbit/pkg/scripts"
 	"github.com/fleetdm/fleet/v4/orbit/pkg/table"
 	"github.com/fleetdm/fleet/v4/orbit/pkg/token"
 	"github.com/fleetdm/fleet/v4/orbit/pkg/update"
@@ -138,6 +139,11 @@ func main() {
 			Usage:   "Launch Fleet Desktop application (flag currently only used on darwin)",
 			EnvVars: []string{"ORBIT_FLEET_DESKTOP"},
 		},
+		&cli.BoolFlag{
+			Name:    "enable-scripts",
+			Usage:   "Run the scripts (policy remediations and ad-hoc scripts) sent by the Fleet server",
+			EnvVars: []string{"ORBIT_ENABLE_SCRIPTS"},
+		},
 	}
 	app.Before = func(c *cli.Context) error {
 		// handle old installations, which had default root dir set to /var/lib/orbit
@@ -513,6 +519,21 @@ func main() {
 		}
 		g.Add(flagRunner.Execute, flagRunner.Interrupt)
 
+		// note: the initial flags fetch above already populated the capabilities
+		// of the server based on the response header. Running the scripts sent
+		// by the server must be explicitly enabled on the host.
+		if c.Bool("enable-scripts") && orbitClient.GetServerCapabilities().Has(fleet.CapabilityScripts) {
+			const (
+				orbitScriptsCheckInterval = 30 * time.Second
+				orbitScriptsTimeout       = 5 * time.Minute
+			)
+			scriptsRunner := scripts.NewRunner(orbitClient, scripts.Options{
+				CheckInterval: orbitScriptsCheckInterval,
+				Timeout:       orbitScriptsTimeout,
+			})
+			g.Add(scriptsRunner.Execute, scriptsRunner.Interrupt)
+		}
+
 		trw := token.NewReadWriter(filepath.Join(c.String("root-dir"), "identifier"))
 
 		if err := trw.LoadOrGenerate(); err != nil {
@@ -957,6 +978,11 @@ func (f *capabilitiesChecker) execute() error {
 				log.Info().Msgf("%s capability changed, restarting", fleet.CapabilityTokenRotation)
 				return nil
 			}
+			if oldCapabilities.Has(fleet.CapabilityScripts) !=
+				newCapabilities.Has(fleet.CapabilityScripts) {
+				log.Info().Msgf("%s capability changed, restarting", fleet.CapabilityScripts)
+				return nil
+			}
 		case <-f.interruptCh:
 			return nil
 
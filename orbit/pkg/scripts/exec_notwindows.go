@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package scripts
+
+import (
+	"context"
+	"os/exec"
+)
+
+// scriptExtension is the extension of the file the script is written to.
+const scriptExtension = ".sh"
+
+// scriptCommand returns the command that runs the script file at path.
+func scriptCommand(ctx context.Context, path string) *exec.Cmd {
+	return exec.CommandContext(ctx, "/bin/sh", path)
+}
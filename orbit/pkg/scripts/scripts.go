@@ -0,0 +1,185 @@
+// Package scripts implements the execution by orbit of the scripts queued by
+// Fleet for the host.
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/rs/zerolog/log"
+)
+
+// Client allows fetching the scripts to run from Fleet and sending their
+// results.
+type Client interface {
+	// GetScripts returns the scripts to run.
+	GetScripts() ([]*fleet.HostScriptExecution, error)
+	// SaveScriptResult sends the result of a script execution.
+	SaveScriptResult(result *fleet.HostScriptExecutionResult) error
+}
+
+// Runner is a specialized runner to periodically fetch scripts from Fleet and
+// run them. It is designed with Execute and Interrupt functions to be
+// compatible with oklog/run.
+type Runner struct {
+	client Client
+	opt    Options
+	cancel chan struct{}
+}
+
+// Options are the options of the scripts runner.
+type Options struct {
+	// CheckInterval is the interval to check for scripts to run.
+	CheckInterval time.Duration
+	// Timeout is the maximum duration of a script execution, the script is
+	// killed after that.
+	Timeout time.Duration
+}
+
+// NewRunner creates a new runner with provided options.
+// The runner must be started with Execute.
+func NewRunner(client Client, opt Options) *Runner {
+	return &Runner{
+		client: client,
+		opt:    opt,
+		cancel: make(chan struct{}),
+	}
+}
+
+// Execute starts the loop checking for scripts to run.
+func (r *Runner) Execute() error {
+	log.Debug().Msg("starting scripts runner")
+
+	ticker := time.NewTicker(r.opt.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.cancel:
+			return nil
+		case <-ticker.C:
+			if err := r.RunScripts(); err != nil {
+				log.Info().Err(err).Msg("running scripts failed")
+			}
+			ticker.Reset(r.opt.CheckInterval)
+		}
+	}
+}
+
+// Interrupt is the oklog/run interrupt method that stops the runner when
+// interrupt is received.
+func (r *Runner) Interrupt(err error) {
+	close(r.cancel)
+	log.Debug().Err(err).Msg("interrupt for scripts runner")
+}
+
+// RunScripts fetches the scripts to run from Fleet, runs them one after the
+// other and sends their results.
+func (r *Runner) RunScripts() error {
+	scripts, err := r.client.GetScripts()
+	if err != nil {
+		return fmt.Errorf("error getting scripts from fleet: %w", err)
+	}
+
+	for _, script := range scripts {
+		log.Info().Uint("execution_id", script.ID).Msg("running script")
+		result := r.runScript(script)
+		if err := r.client.SaveScriptResult(result); err != nil {
+			return fmt.Errorf("error sending script result to fleet: %w", err)
+		}
+	}
+	return nil
+}
+
+// runScript runs the script and returns its result. The exit code of the
+// result is nil if the script could not be run or timed out.
+func (r *Runner) runScript(script *fleet.HostScriptExecution) *fleet.HostScriptExecutionResult {
+	result := &fleet.HostScriptExecutionResult{ExecutionID: script.ID}
+
+	output, exitCode, err := r.execute(script.Contents)
+	switch {
+	case err != nil:
+		log.Info().Err(err).Uint("execution_id", script.ID).Msg("script failed")
+		if output != "" {
+			output += "\n"
+		}
+		result.Output = fleet.TruncateScriptOutput(output + err.Error())
+	default:
+		result.ExitCode = &exitCode
+		result.Output = fleet.TruncateScriptOutput(output)
+	}
+	return result
+}
+
+// execute writes the script contents to a temporary file and runs it with a
+// timeout. It returns the combined output and the exit code of the script,
+// the error is only set if the script could not run to completion.
+func (r *Runner) execute(contents string) (string, int, error) {
+	f, err := os.CreateTemp("", "fleet-script-*"+scriptExtension)
+	if err != nil {
+		return "", 0, fmt.Errorf("create script file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(contents); err != nil {
+		f.Close()
+		return "", 0, fmt.Errorf("write script file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, fmt.Errorf("close script file: %w", err)
+	}
+
+	// the output is written to a file and not to a pipe, so that waiting for
+	// the command does not block on child processes of a killed script that
+	// still hold the pipe.
+	outFile, err := os.CreateTemp("", "fleet-script-output-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("create script output file: %w", err)
+	}
+	defer os.Remove(outFile.Name())
+	defer outFile.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opt.Timeout)
+	defer cancel()
+
+	cmd := scriptCommand(ctx, f.Name())
+	cmd.Stdout = outFile
+	cmd.Stderr = outFile
+	runErr := cmd.Run()
+
+	output, err := readOutput(outFile)
+	if err != nil {
+		return "", 0, fmt.Errorf("read script output: %w", err)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, 0, fmt.Errorf("script timed out after %s", r.opt.Timeout)
+	}
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			return output, exitErr.ExitCode(), nil
+		}
+		return output, 0, fmt.Errorf("run script: %w", runErr)
+	}
+	return output, 0, nil
+}
+
+// readOutput reads at most fleet.ScriptExecutionMaxOutputSize bytes from the
+// start of the output file.
+func readOutput(f *os.File) (string, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	b, err := io.ReadAll(io.LimitReader(f, fleet.ScriptExecutionMaxOutputSize))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,60 @@
+//go:build !windows
+// +build !windows
+
+package scripts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/stretchr/testify/require"
+)
+
+type mockClient struct {
+	scripts []*fleet.HostScriptExecution
+	getErr  error
+	results []*fleet.HostScriptExecutionResult
+}
+
+func (c *mockClient) GetScripts() ([]*fleet.HostScriptExecution, error) {
+	return c.scripts, c.getErr
+}
+
+func (c *mockClient) SaveScriptResult(result *fleet.HostScriptExecutionResult) error {
+	c.results = append(c.results, result)
+	return nil
+}
+
+func TestRunScripts(t *testing.T) {
+	client := &mockClient{
+		scripts: []*fleet.HostScriptExecution{
+			{ID: 1, Contents: "echo hello"},
+			{ID: 2, Contents: "echo oops >&2\nexit 3"},
+			{ID: 3, Contents: "sleep 5"},
+		},
+	}
+	r := NewRunner(client, Options{CheckInterval: time.Minute, Timeout: time.Second})
+
+	require.NoError(t, r.RunScripts())
+	require.Len(t, client.results, 3)
+
+	require.Equal(t, uint(1), client.results[0].ExecutionID)
+	require.NotNil(t, client.results[0].ExitCode)
+	require.Equal(t, 0, *client.results[0].ExitCode)
+	require.Equal(t, "hello\n", client.results[0].Output)
+
+	require.Equal(t, uint(2), client.results[1].ExecutionID)
+	require.NotNil(t, client.results[1].ExitCode)
+	require.Equal(t, 3, *client.results[1].ExitCode)
+	require.Equal(t, "oops\n", client.results[1].Output)
+
+	// the script that times out has no exit code
+	require.Equal(t, uint(3), client.results[2].ExecutionID)
+	require.Nil(t, client.results[2].ExitCode)
+	require.Contains(t, client.results[2].Output, "timed out")
+
+	client.getErr = errors.New("fleet unavailable")
+	require.Error(t, r.RunScripts())
+}
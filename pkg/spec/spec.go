@@ -27,6 +27,7 @@ type Group struct {
 	AppConfig    interface{}
 	EnrollSecret *fleet.EnrollSecretSpec
 	UsersRoles   *fleet.UsersRoleSpec
+	HostTags     []*fleet.HostTagsSpec
 }
 
 // Metadata holds the metadata for a single YAML section/item.
@@ -121,6 +122,13 @@ func GroupFromBytes(b []byte) (*Group, error) {
 			}
 			specs.Teams = append(specs.Teams, teamSpec.Team)
 
+		case fleet.HostTagsKind:
+			var hostTagsSpec *fleet.HostTagsSpec
+			if err := yaml.Unmarshal(s.Spec, &hostTagsSpec); err != nil {
+				return nil, fmt.Errorf("unmarshaling %s spec: %w", kind, err)
+			}
+			specs.HostTags = append(specs.HostTags, hostTagsSpec)
+
 		default:
 			return nil, fmt.Errorf("unknown kind %q", s.Kind)
 		}
@@ -148,6 +148,17 @@ allow {
   action == read
 }
 
+##
+# Activity webhooks
+##
+
+# Global admins can read and write activity webhooks.
+allow {
+  object.type == "activity_webhook"
+  subject.global_role == admin
+  action == [read, write][_]
+}
+
 ##
 # Sessions
 ##
@@ -386,6 +397,17 @@ allow {
   is_null(object.host_targets.teams)
 }
 
+##
+# Scheduled live queries
+##
+
+# Global admins and maintainers can read and write scheduled live queries.
+allow {
+  object.type == "scheduled_live_query"
+  subject.global_role == [admin, maintainer][_]
+  action == [read, write][_]
+}
+
 ##
 # Targets
 ##
@@ -492,6 +514,40 @@ allow {
   action == read
 }
 
+##
+# Host script executions
+##
+
+# Global admins and maintainers can read the script executions of all hosts.
+allow {
+  object.type == "host_script_execution"
+  subject.global_role == [admin, maintainer][_]
+  action == read
+}
+
+# Team admins and maintainers can read the script executions of their teams' hosts.
+allow {
+  not is_null(object.team_id)
+  object.type == "host_script_execution"
+  team_role(subject, object.team_id) == [admin, maintainer][_]
+  action == read
+}
+
+# Global admins can run scripts on all hosts.
+allow {
+  object.type == "host_script_execution"
+  subject.global_role == admin
+  action == write
+}
+
+# Team admins can run scripts on their teams' hosts.
+allow {
+  not is_null(object.team_id)
+  object.type == "host_script_execution"
+  team_role(subject, object.team_id) == admin
+  action == write
+}
+
 ##
 # Software
 ##
@@ -511,6 +567,66 @@ allow {
   action == read
 }
 
+##
+# Host tag keys
+##
+
+# All users can read host tag keys
+allow {
+  object.type == "host_tag_key"
+  not is_null(subject)
+  action == read
+}
+
+# Only global admins and maintainers can write host tag keys
+allow {
+  object.type == "host_tag_key"
+  subject.global_role == [admin, maintainer][_]
+  action == write
+}
+
+##
+# Software rules
+##
+
+# Global admins and maintainers can read and write all software rules.
+allow {
+  object.type == "software_rule"
+  subject.global_role == [admin, maintainer][_]
+  action == [read, write][_]
+}
+
+# Global observers can read all software rules.
+allow {
+  object.type == "software_rule"
+  subject.global_role == observer
+  action == read
+}
+
+# Team admins and maintainers can read and write their teams' software rules.
+allow {
+  not is_null(object.team_id)
+  object.type == "software_rule"
+  team_role(subject, object.team_id) == [admin, maintainer][_]
+  action == [read, write][_]
+}
+
+# Team observers can read their teams' software rules.
+allow {
+  not is_null(object.team_id)
+  object.type == "software_rule"
+  team_role(subject, object.team_id) == observer
+  action == read
+}
+
+# Team users can read the global software rules, as they apply to their teams' hosts.
+allow {
+  is_null(object.team_id)
+  object.type == "software_rule"
+  team_role(subject, subject.teams[_].id) == [admin, maintainer, observer][_]
+  action == read
+}
+
 ##
 # Apple MDM
 ##
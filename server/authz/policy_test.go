@@ -209,6 +209,36 @@ func TestAuthorizeInvite(t *testing.T) {
 	})
 }
 
+func TestAuthorizeActivityWebhook(t *testing.T) {
+	t.Parallel()
+
+	webhook := &fleet.ActivityWebhook{}
+	teamAdmin := &fleet.User{
+		Teams: []fleet.UserTeam{
+			{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin},
+		},
+	}
+	runTestCases(t, []authTestCase{
+		{user: nil, object: webhook, action: read, allow: false},
+		{user: nil, object: webhook, action: write, allow: false},
+
+		{user: test.UserNoRoles, object: webhook, action: read, allow: false},
+		{user: test.UserNoRoles, object: webhook, action: write, allow: false},
+
+		{user: test.UserAdmin, object: webhook, action: read, allow: true},
+		{user: test.UserAdmin, object: webhook, action: write, allow: true},
+
+		{user: test.UserMaintainer, object: webhook, action: read, allow: false},
+		{user: test.UserMaintainer, object: webhook, action: write, allow: false},
+
+		{user: test.UserObserver, object: webhook, action: read, allow: false},
+		{user: test.UserObserver, object: webhook, action: write, allow: false},
+
+		{user: teamAdmin, object: webhook, action: read, allow: false},
+		{user: teamAdmin, object: webhook, action: write, allow: false},
+	})
+}
+
 func TestAuthorizeEnrollSecret(t *testing.T) {
 	t.Parallel()
 
@@ -588,6 +618,36 @@ func TestAuthorizeQuery(t *testing.T) {
 	})
 }
 
+func TestAuthorizeScheduledLiveQuery(t *testing.T) {
+	t.Parallel()
+
+	query := &fleet.ScheduledLiveQuery{}
+	teamMaintainer := &fleet.User{
+		Teams: []fleet.UserTeam{
+			{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer},
+		},
+	}
+	runTestCases(t, []authTestCase{
+		{user: nil, object: query, action: read, allow: false},
+		{user: nil, object: query, action: write, allow: false},
+
+		{user: test.UserNoRoles, object: query, action: read, allow: false},
+		{user: test.UserNoRoles, object: query, action: write, allow: false},
+
+		{user: test.UserAdmin, object: query, action: read, allow: true},
+		{user: test.UserAdmin, object: query, action: write, allow: true},
+
+		{user: test.UserMaintainer, object: query, action: read, allow: true},
+		{user: test.UserMaintainer, object: query, action: write, allow: true},
+
+		{user: test.UserObserver, object: query, action: read, allow: false},
+		{user: test.UserObserver, object: query, action: write, allow: false},
+
+		{user: teamMaintainer, object: query, action: read, allow: false},
+		{user: teamMaintainer, object: query, action: write, allow: false},
+	})
+}
+
 func TestAuthorizeTargets(t *testing.T) {
 	t.Parallel()
 
@@ -767,6 +827,105 @@ func TestAuthorizePolicies(t *testing.T) {
 	})
 }
 
+func TestAuthorizeHostScriptExecutions(t *testing.T) {
+	t.Parallel()
+
+	globalExec := &fleet.HostScriptExecution{}
+	teamExec := &fleet.HostScriptExecution{TeamID: ptr.Uint(1)}
+	runTestCases(t, []authTestCase{
+		{user: nil, object: globalExec, action: read, allow: false},
+		{user: test.UserNoRoles, object: globalExec, action: read, allow: false},
+
+		{user: test.UserAdmin, object: globalExec, action: read, allow: true},
+		{user: test.UserMaintainer, object: globalExec, action: read, allow: true},
+		{user: test.UserObserver, object: globalExec, action: read, allow: false},
+
+		{user: test.UserAdmin, object: teamExec, action: read, allow: true},
+		{user: test.UserObserver, object: teamExec, action: read, allow: false},
+
+		{user: test.UserTeamAdminTeam1, object: teamExec, action: read, allow: true},
+		{user: test.UserTeamAdminTeam2, object: teamExec, action: read, allow: false},
+		{user: test.UserTeamMaintainerTeam1, object: teamExec, action: read, allow: true},
+		{user: test.UserTeamMaintainerTeam2, object: teamExec, action: read, allow: false},
+		{user: test.UserTeamObserverTeam1, object: teamExec, action: read, allow: false},
+
+		// Team users cannot read the executions of hosts without team.
+		{user: test.UserTeamAdminTeam1, object: globalExec, action: read, allow: false},
+
+		// Only admins can run scripts.
+		{user: test.UserAdmin, object: globalExec, action: write, allow: true},
+		{user: test.UserAdmin, object: teamExec, action: write, allow: true},
+		{user: test.UserMaintainer, object: globalExec, action: write, allow: false},
+		{user: test.UserObserver, object: globalExec, action: write, allow: false},
+
+		{user: test.UserTeamAdminTeam1, object: teamExec, action: write, allow: true},
+		{user: test.UserTeamAdminTeam2, object: teamExec, action: write, allow: false},
+		{user: test.UserTeamMaintainerTeam1, object: teamExec, action: write, allow: false},
+		{user: test.UserTeamObserverTeam1, object: teamExec, action: write, allow: false},
+		{user: test.UserTeamAdminTeam1, object: globalExec, action: write, allow: false},
+	})
+}
+
+func TestAuthorizeSoftwareRules(t *testing.T) {
+	t.Parallel()
+
+	globalRule := &fleet.SoftwareRule{}
+	teamRule := &fleet.SoftwareRule{TeamID: ptr.Uint(1)}
+	runTestCases(t, []authTestCase{
+		{user: nil, object: globalRule, action: read, allow: false},
+		{user: test.UserNoRoles, object: globalRule, action: read, allow: false},
+
+		{user: test.UserAdmin, object: globalRule, action: read, allow: true},
+		{user: test.UserAdmin, object: globalRule, action: write, allow: true},
+		{user: test.UserAdmin, object: teamRule, action: write, allow: true},
+		{user: test.UserMaintainer, object: globalRule, action: write, allow: true},
+		{user: test.UserMaintainer, object: teamRule, action: write, allow: true},
+		{user: test.UserObserver, object: globalRule, action: read, allow: true},
+		{user: test.UserObserver, object: teamRule, action: read, allow: true},
+		{user: test.UserObserver, object: globalRule, action: write, allow: false},
+		{user: test.UserObserver, object: teamRule, action: write, allow: false},
+
+		{user: test.UserTeamAdminTeam1, object: teamRule, action: read, allow: true},
+		{user: test.UserTeamAdminTeam1, object: teamRule, action: write, allow: true},
+		{user: test.UserTeamAdminTeam2, object: teamRule, action: read, allow: false},
+		{user: test.UserTeamAdminTeam2, object: teamRule, action: write, allow: false},
+		{user: test.UserTeamMaintainerTeam1, object: teamRule, action: write, allow: true},
+		{user: test.UserTeamMaintainerTeam2, object: teamRule, action: write, allow: false},
+		{user: test.UserTeamObserverTeam1, object: teamRule, action: read, allow: true},
+		{user: test.UserTeamObserverTeam1, object: teamRule, action: write, allow: false},
+
+		// Team users can read but not write the global rules.
+		{user: test.UserTeamAdminTeam1, object: globalRule, action: read, allow: true},
+		{user: test.UserTeamAdminTeam1, object: globalRule, action: write, allow: false},
+		{user: test.UserTeamObserverTeam1, object: globalRule, action: read, allow: true},
+	})
+}
+
+func TestAuthorizeHostTagKey(t *testing.T) {
+	t.Parallel()
+
+	key := &fleet.HostTagKey{}
+	runTestCases(t, []authTestCase{
+		{user: nil, object: key, action: read, allow: false},
+		{user: nil, object: key, action: write, allow: false},
+
+		{user: test.UserNoRoles, object: key, action: read, allow: true},
+		{user: test.UserNoRoles, object: key, action: write, allow: false},
+
+		{user: test.UserAdmin, object: key, action: read, allow: true},
+		{user: test.UserAdmin, object: key, action: write, allow: true},
+
+		{user: test.UserMaintainer, object: key, action: read, allow: true},
+		{user: test.UserMaintainer, object: key, action: write, allow: true},
+
+		{user: test.UserObserver, object: key, action: read, allow: true},
+		{user: test.UserObserver, object: key, action: write, allow: false},
+
+		{user: test.UserTeamAdminTeam1, object: key, action: read, allow: true},
+		{user: test.UserTeamAdminTeam1, object: key, action: write, allow: false},
+	})
+}
+
 func assertAuthorized(t *testing.T, user *fleet.User, object, action interface{}) {
 	t.Helper()
 
@@ -17,9 +17,11 @@ import (
 	"strings"
 	"time"
 
+	ghodssyaml "github.com/ghodss/yaml"
 	"github.com/spf13/cast"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -126,29 +128,57 @@ type SessionConfig struct {
 
 // OsqueryConfig defines configs related to osquery
 type OsqueryConfig struct {
-	NodeKeySize                      int           `yaml:"node_key_size"`
-	HostIdentifier                   string        `yaml:"host_identifier"`
-	EnrollCooldown                   time.Duration `yaml:"enroll_cooldown"`
-	StatusLogPlugin                  string        `yaml:"status_log_plugin"`
-	ResultLogPlugin                  string        `yaml:"result_log_plugin"`
-	LabelUpdateInterval              time.Duration `yaml:"label_update_interval"`
-	PolicyUpdateInterval             time.Duration `yaml:"policy_update_interval"`
-	DetailUpdateInterval             time.Duration `yaml:"detail_update_interval"`
-	StatusLogFile                    string        `yaml:"status_log_file"`
-	ResultLogFile                    string        `yaml:"result_log_file"`
-	EnableLogRotation                bool          `yaml:"enable_log_rotation"`
-	MaxJitterPercent                 int           `yaml:"max_jitter_percent"`
-	EnableAsyncHostProcessing        string        `yaml:"enable_async_host_processing"` // true/false or per-task
-	AsyncHostCollectInterval         string        `yaml:"async_host_collect_interval"`  // duration or per-task
-	AsyncHostCollectMaxJitterPercent int           `yaml:"async_host_collect_max_jitter_percent"`
-	AsyncHostCollectLockTimeout      string        `yaml:"async_host_collect_lock_timeout"` // duration or per-task
-	AsyncHostCollectLogStatsInterval time.Duration `yaml:"async_host_collect_log_stats_interval"`
-	AsyncHostInsertBatch             int           `yaml:"async_host_insert_batch"`
-	AsyncHostDeleteBatch             int           `yaml:"async_host_delete_batch"`
-	AsyncHostUpdateBatch             int           `yaml:"async_host_update_batch"`
-	AsyncHostRedisPopCount           int           `yaml:"async_host_redis_pop_count"`
-	AsyncHostRedisScanKeysCount      int           `yaml:"async_host_redis_scan_keys_count"`
-	MinSoftwareLastOpenedAtDiff      time.Duration `yaml:"min_software_last_opened_at_diff"`
+	NodeKeySize                      int              `yaml:"node_key_size"`
+	HostIdentifier                   string           `yaml:"host_identifier"`
+	EnrollCooldown                   time.Duration    `yaml:"enroll_cooldown"`
+	StatusLogPlugin                  string           `yaml:"status_log_plugin"`
+	ResultLogPlugin                  string           `yaml:"result_log_plugin"`
+	LabelUpdateInterval              time.Duration    `yaml:"label_update_interval"`
+	PolicyUpdateInterval             time.Duration    `yaml:"policy_update_interval"`
+	DetailUpdateInterval             time.Duration    `yaml:"detail_update_interval"`
+	StatusLogFile                    string           `yaml:"status_log_file"`
+	ResultLogFile                    string           `yaml:"result_log_file"`
+	EnableLogRotation                bool             `yaml:"enable_log_rotation"`
+	MaxJitterPercent                 int              `yaml:"max_jitter_percent"`
+	EnableAsyncHostProcessing        string           `yaml:"enable_async_host_processing"` // true/false or per-task
+	AsyncHostCollectInterval         string           `yaml:"async_host_collect_interval"`  // duration or per-task
+	AsyncHostCollectMaxJitterPercent int              `yaml:"async_host_collect_max_jitter_percent"`
+	AsyncHostCollectLockTimeout      string           `yaml:"async_host_collect_lock_timeout"` // duration or per-task
+	AsyncHostCollectLogStatsInterval time.Duration    `yaml:"async_host_collect_log_stats_interval"`
+	AsyncHostInsertBatch             int              `yaml:"async_host_insert_batch"`
+	AsyncHostDeleteBatch             int              `yaml:"async_host_delete_batch"`
+	AsyncHostUpdateBatch             int              `yaml:"async_host_update_batch"`
+	AsyncHostRedisPopCount           int              `yaml:"async_host_redis_pop_count"`
+	AsyncHostRedisScanKeysCount      int              `yaml:"async_host_redis_scan_keys_count"`
+	MinSoftwareLastOpenedAtDiff      time.Duration    `yaml:"min_software_last_opened_at_diff"`
+	ResultLogRoutes                  []ResultLogRoute `yaml:"result_log_routes"`
+	EnableScheduledQueryResults      bool             `yaml:"enable_scheduled_query_results"`
+	ScheduledQueryResultsMaxPerHost  int              `yaml:"scheduled_query_results_max_per_host"`
+	ScheduledQueryResultsRetention   time.Duration    `yaml:"scheduled_query_results_retention"`
+	EnableLiveQueryResults           bool             `yaml:"enable_live_query_results"`
+	LiveQueryResultsMaxRows          int              `yaml:"live_query_results_max_rows"`
+	LiveQueryResultsTTL              time.Duration    `yaml:"live_query_results_ttl"`
+	PolicyHistoryRetention           time.Duration    `yaml:"policy_history_retention"`
+	SoftwareChangesRetention         time.Duration    `yaml:"software_changes_retention"`
+	HostVitalsHistoryInterval        time.Duration    `yaml:"host_vitals_history_interval"`
+	HostVitalsHistoryRetention       time.Duration    `yaml:"host_vitals_history_retention"`
+}
+
+// ResultLogRoute is a destination of the osquery result logs, along with the
+// rules that select the logs written to it. A log matches the route if it
+// matches all the non-empty rules, and a route without rules matches all the
+// logs.
+type ResultLogRoute struct {
+	// Plugin is the log plugin of the destination, configured via the
+	// plugin's config section (e.g. kinesis.result_stream).
+	Plugin string `json:"plugin" yaml:"plugin"`
+	// Queries are the names of the scheduled queries.
+	Queries []string `json:"queries" yaml:"queries"`
+	// Packs are the names of the packs of the scheduled queries.
+	Packs []string `json:"packs" yaml:"packs"`
+	// TeamIDs are the IDs of the teams of the hosts, 0 is for the hosts
+	// without a team.
+	TeamIDs []uint `json:"team_ids" yaml:"team_ids"`
 }
 
 // AsyncTaskName is the type of names that identify tasks supporting
@@ -213,6 +243,12 @@ type LoggingConfig struct {
 	TracingType string `yaml:"tracing_type"`
 }
 
+// ActivityConfig defines configs related to activities
+type ActivityConfig struct {
+	EnableAuditLog bool   `json:"enable_audit_log" yaml:"enable_audit_log"`
+	AuditLogPlugin string `json:"audit_log_plugin" yaml:"audit_log_plugin"`
+}
+
 // FirehoseConfig defines configs for the AWS Firehose logging plugin
 type FirehoseConfig struct {
 	Region           string
@@ -222,6 +258,7 @@ type FirehoseConfig struct {
 	StsAssumeRoleArn string `yaml:"sts_assume_role_arn"`
 	StatusStream     string `yaml:"status_stream"`
 	ResultStream     string `yaml:"result_stream"`
+	AuditStream      string `yaml:"audit_stream"`
 }
 
 // KinesisConfig defines configs for the AWS Kinesis logging plugin
@@ -233,6 +270,7 @@ type KinesisConfig struct {
 	StsAssumeRoleArn string `yaml:"sts_assume_role_arn"`
 	StatusStream     string `yaml:"status_stream"`
 	ResultStream     string `yaml:"result_stream"`
+	AuditStream      string `yaml:"audit_stream"`
 }
 
 // LambdaConfig defines configs for the AWS Lambda logging plugin
@@ -243,6 +281,7 @@ type LambdaConfig struct {
 	StsAssumeRoleArn string `yaml:"sts_assume_role_arn"`
 	StatusFunction   string `yaml:"status_function"`
 	ResultFunction   string `yaml:"result_function"`
+	AuditFunction    string `yaml:"audit_function"`
 }
 
 // S3Config defines config to enable file carving storage to an S3 bucket
@@ -263,6 +302,7 @@ type PubSubConfig struct {
 	Project       string `json:"project"`
 	StatusTopic   string `json:"status_topic" yaml:"status_topic"`
 	ResultTopic   string `json:"result_topic" yaml:"result_topic"`
+	AuditTopic    string `json:"audit_topic" yaml:"audit_topic"`
 	AddAttributes bool   `json:"add_attributes" yaml:"add_attributes"`
 }
 
@@ -270,6 +310,7 @@ type PubSubConfig struct {
 type FilesystemConfig struct {
 	StatusLogFile        string `json:"status_log_file" yaml:"status_log_file"`
 	ResultLogFile        string `json:"result_log_file" yaml:"result_log_file"`
+	AuditLogFile         string `json:"audit_log_file" yaml:"audit_log_file"`
 	EnableLogRotation    bool   `json:"enable_log_rotation" yaml:"enable_log_rotation"`
 	EnableLogCompression bool   `json:"enable_log_compression" yaml:"enable_log_compression"`
 }
@@ -278,11 +319,60 @@ type FilesystemConfig struct {
 type KafkaRESTConfig struct {
 	StatusTopic      string `json:"status_topic" yaml:"status_topic"`
 	ResultTopic      string `json:"result_topic" yaml:"result_topic"`
+	AuditTopic       string `json:"audit_topic" yaml:"audit_topic"`
 	ProxyHost        string `json:"proxyhost" yaml:"proxyhost"`
 	ContentTypeValue string `json:"content_type_value" yaml:"content_type_value"`
 	Timeout          int    `json:"timeout" yaml:"timeout"`
 }
 
+// KafkaConfig defines configs for the Kafka logging plugin, which talks to
+// the Kafka brokers directly.
+type KafkaConfig struct {
+	Brokers       string        `json:"brokers" yaml:"brokers"` // comma-separated list of host:port
+	StatusTopic   string        `json:"status_topic" yaml:"status_topic"`
+	ResultTopic   string        `json:"result_topic" yaml:"result_topic"`
+	AuditTopic    string        `json:"audit_topic" yaml:"audit_topic"`
+	RequiredAcks  int           `json:"required_acks" yaml:"required_acks"`
+	Compression   string        `json:"compression" yaml:"compression"`
+	BatchBytes    int           `json:"batch_bytes" yaml:"batch_bytes"`
+	Timeout       time.Duration `json:"timeout" yaml:"timeout"`
+	SASLMechanism string        `json:"sasl_mechanism" yaml:"sasl_mechanism"`
+	SASLUsername  string        `json:"sasl_username" yaml:"sasl_username"`
+	SASLPassword  string        `json:"sasl_password" yaml:"sasl_password"`
+	UseTLS        bool          `json:"use_tls" yaml:"use_tls"`
+	TLSCert       string        `json:"tls_cert" yaml:"tls_cert"`
+	TLSKey        string        `json:"tls_key" yaml:"tls_key"`
+	TLSCA         string        `json:"tls_ca" yaml:"tls_ca"`
+	TLSServerName string        `json:"tls_server_name" yaml:"tls_server_name"`
+}
+
+// SplunkConfig defines configs for the Splunk HTTP Event Collector logging
+// plugin.
+type SplunkConfig struct {
+	URL               string        `json:"url" yaml:"url"`
+	Token             string        `json:"token" yaml:"token"`
+	StatusIndex       string        `json:"status_index" yaml:"status_index"`
+	ResultIndex       string        `json:"result_index" yaml:"result_index"`
+	AuditIndex        string        `json:"audit_index" yaml:"audit_index"`
+	Source            string        `json:"source" yaml:"source"`
+	SourceType        string        `json:"sourcetype" yaml:"sourcetype"`
+	EnableCompression bool          `json:"enable_compression" yaml:"enable_compression"`
+	Timeout           time.Duration `json:"timeout" yaml:"timeout"`
+}
+
+// ElasticsearchConfig defines configs for the Elasticsearch logging plugin.
+type ElasticsearchConfig struct {
+	URL               string        `json:"url" yaml:"url"`
+	Username          string        `json:"username" yaml:"username"`
+	Password          string        `json:"password" yaml:"password"`
+	APIKey            string        `json:"api_key" yaml:"api_key"`
+	StatusIndex       string        `json:"status_index" yaml:"status_index"`
+	ResultIndex       string        `json:"result_index" yaml:"result_index"`
+	AuditIndex        string        `json:"audit_index" yaml:"audit_index"`
+	EnableCompression bool          `json:"enable_compression" yaml:"enable_compression"`
+	Timeout           time.Duration `json:"timeout" yaml:"timeout"`
+}
+
 // LicenseConfig defines configs related to licensing Fleet.
 type LicenseConfig struct {
 	Key              string `yaml:"key"`
@@ -414,6 +504,7 @@ type FleetConfig struct {
 	Session          SessionConfig
 	Osquery          OsqueryConfig
 	Logging          LoggingConfig
+	Activity         ActivityConfig
 	Firehose         FirehoseConfig
 	Kinesis          KinesisConfig
 	Lambda           LambdaConfig
@@ -421,6 +512,9 @@ type FleetConfig struct {
 	PubSub           PubSubConfig
 	Filesystem       FilesystemConfig
 	KafkaREST        KafkaRESTConfig
+	Kafka            KafkaConfig
+	Splunk           SplunkConfig
+	Elasticsearch    ElasticsearchConfig
 	License          LicenseConfig
 	Vulnerabilities  VulnerabilitiesConfig
 	Upgrades         UpgradesConfig
@@ -626,6 +720,28 @@ func (man Manager) addConfigs() {
 		"Batch size to scan redis keys in async collection")
 	man.addConfigDuration("osquery.min_software_last_opened_at_diff", 1*time.Hour,
 		"Minimum time difference of the software's last opened timestamp (compared to the last one saved) to trigger an update to the database")
+	man.addConfigString("osquery.result_log_routes", "",
+		"Additional destinations of the result logs with their match rules, as a list in the config file or JSON")
+	man.addConfigBool("osquery.enable_scheduled_query_results", false,
+		"Store the latest results of the scheduled queries for each host")
+	man.addConfigInt("osquery.scheduled_query_results_max_per_host", 10,
+		"Maximum number of results stored per scheduled query and host")
+	man.addConfigDuration("osquery.scheduled_query_results_retention", 7*24*time.Hour,
+		"Duration after which the stored scheduled query results are deleted")
+	man.addConfigBool("osquery.enable_live_query_results", false,
+		"Store the results of the live queries so that they can be fetched after the query completed")
+	man.addConfigInt("osquery.live_query_results_max_rows", 10000,
+		"Maximum number of rows stored per live query")
+	man.addConfigDuration("osquery.live_query_results_ttl", 7*24*time.Hour,
+		"Duration after which the stored live query results are deleted")
+	man.addConfigDuration("osquery.policy_history_retention", 90*24*time.Hour,
+		"Duration for which the history of the policy results of the hosts is kept")
+	man.addConfigDuration("osquery.software_changes_retention", 90*24*time.Hour,
+		"Duration for which the changes of the software installed on the hosts are kept")
+	man.addConfigDuration("osquery.host_vitals_history_interval", 1*time.Hour,
+		"Minimum duration between two recorded samples of the vitals of a host (disk space, uptime, versions)")
+	man.addConfigDuration("osquery.host_vitals_history_retention", 90*24*time.Hour,
+		"Duration for which the history of the vitals of the hosts is kept")
 
 	// Logging
 	man.addConfigBool("logging.debug", false,
@@ -641,6 +757,12 @@ func (man Manager) addConfigs() {
 	man.addConfigString("logging.tracing_type", "opentelemetry",
 		"Select the kind of tracing, defaults to opentelemetry, can also be elasticapm")
 
+	// Activity
+	man.addConfigBool("activity.enable_audit_log", false,
+		"Enable audit logs")
+	man.addConfigString("activity.audit_log_plugin", "filesystem",
+		"Log plugin to use for audit logs")
+
 	// Firehose
 	man.addConfigString("firehose.region", "", "AWS Region to use")
 	man.addConfigString("firehose.endpoint_url", "",
@@ -653,6 +775,8 @@ func (man Manager) addConfigs() {
 		"Firehose stream name for status logs")
 	man.addConfigString("firehose.result_stream", "",
 		"Firehose stream name for result logs")
+	man.addConfigString("firehose.audit_stream", "",
+		"Firehose stream name for audit logs")
 
 	// Kinesis
 	man.addConfigString("kinesis.region", "", "AWS Region to use")
@@ -666,6 +790,8 @@ func (man Manager) addConfigs() {
 		"Kinesis stream name for status logs")
 	man.addConfigString("kinesis.result_stream", "",
 		"Kinesis stream name for result logs")
+	man.addConfigString("kinesis.audit_stream", "",
+		"Kinesis stream name for audit logs")
 
 	// Lambda
 	man.addConfigString("lambda.region", "", "AWS Region to use")
@@ -677,6 +803,8 @@ func (man Manager) addConfigs() {
 		"Lambda function name for status logs")
 	man.addConfigString("lambda.result_function", "",
 		"Lambda function name for result logs")
+	man.addConfigString("lambda.audit_function", "",
+		"Lambda function name for audit logs")
 
 	// S3 for file carving
 	man.addConfigString("s3.bucket", "", "Bucket where to store file carves")
@@ -693,6 +821,7 @@ func (man Manager) addConfigs() {
 	man.addConfigString("pubsub.project", "", "Google Cloud Project to use")
 	man.addConfigString("pubsub.status_topic", "", "PubSub topic for status logs")
 	man.addConfigString("pubsub.result_topic", "", "PubSub topic for result logs")
+	man.addConfigString("pubsub.audit_topic", "", "PubSub topic for audit logs")
 	man.addConfigBool("pubsub.add_attributes", false, "Add PubSub attributes in addition to the message body")
 
 	// Filesystem
@@ -700,6 +829,8 @@ func (man Manager) addConfigs() {
 		"Log file path to use for status logs")
 	man.addConfigString("filesystem.result_log_file", filepath.Join(os.TempDir(), "osquery_result"),
 		"Log file path to use for result logs")
+	man.addConfigString("filesystem.audit_log_file", filepath.Join(os.TempDir(), "audit"),
+		"Log file path to use for audit logs")
 	man.addConfigBool("filesystem.enable_log_rotation", false,
 		"Enable automatic rotation for osquery log files")
 	man.addConfigBool("filesystem.enable_log_compression", false,
@@ -708,11 +839,53 @@ func (man Manager) addConfigs() {
 	// KafkaREST
 	man.addConfigString("kafkarest.status_topic", "", "Kafka REST topic for status logs")
 	man.addConfigString("kafkarest.result_topic", "", "Kafka REST topic for result logs")
+	man.addConfigString("kafkarest.audit_topic", "", "Kafka REST topic for audit logs")
 	man.addConfigString("kafkarest.proxyhost", "", "Kafka REST proxy host url")
 	man.addConfigString("kafkarest.content_type_value", "application/vnd.kafka.json.v1+json",
 		"Kafka REST proxy content type header (defaults to \"application/vnd.kafka.json.v1+json\"")
 	man.addConfigInt("kafkarest.timeout", 5, "Kafka REST proxy json post timeout")
 
+	// Kafka
+	man.addConfigString("kafka.brokers", "", "Comma-separated list of Kafka brokers (host:port)")
+	man.addConfigString("kafka.status_topic", "", "Kafka topic for status logs")
+	man.addConfigString("kafka.result_topic", "", "Kafka topic for result logs")
+	man.addConfigString("kafka.audit_topic", "", "Kafka topic for audit logs")
+	man.addConfigInt("kafka.required_acks", -1,
+		"Acknowledgements required from the brokers: -1 (all in-sync replicas), 1 (leader only) or 0 (none)")
+	man.addConfigString("kafka.compression", "snappy", "Compression codec of the messages: none, gzip, snappy, lz4 or zstd")
+	man.addConfigInt("kafka.batch_bytes", 1048576, "Maximum size in bytes of a batch of messages")
+	man.addConfigDuration("kafka.timeout", 10*time.Second, "Kafka write timeout")
+	man.addConfigString("kafka.sasl_mechanism", "", "Kafka SASL mechanism: plain, scram-sha-256 or scram-sha-512 (SASL is disabled if empty)")
+	man.addConfigString("kafka.sasl_username", "", "Kafka SASL username")
+	man.addConfigString("kafka.sasl_password", "", "Kafka SASL password")
+	man.addConfigBool("kafka.use_tls", false, "Use TLS to connect to the Kafka brokers")
+	man.addConfigString("kafka.tls_cert", "", "Kafka TLS client certificate path")
+	man.addConfigString("kafka.tls_key", "", "Kafka TLS client key path")
+	man.addConfigString("kafka.tls_ca", "", "Kafka TLS server CA")
+	man.addConfigString("kafka.tls_server_name", "", "Kafka TLS server name")
+
+	// Splunk
+	man.addConfigString("splunk.url", "", "Splunk HTTP Event Collector URL (e.g. https://splunk.example.com:8088)")
+	man.addConfigString("splunk.token", "", "Splunk HTTP Event Collector token")
+	man.addConfigString("splunk.status_index", "", "Splunk index for status logs (defaults to the token's index)")
+	man.addConfigString("splunk.result_index", "", "Splunk index for result logs (defaults to the token's index)")
+	man.addConfigString("splunk.audit_index", "", "Splunk index for audit logs (defaults to the token's index)")
+	man.addConfigString("splunk.source", "fleet", "Splunk source of the events")
+	man.addConfigString("splunk.sourcetype", "_json", "Splunk sourcetype of the events")
+	man.addConfigBool("splunk.enable_compression", true, "Compress the requests sent to Splunk with gzip")
+	man.addConfigDuration("splunk.timeout", 30*time.Second, "Splunk HTTP Event Collector request timeout")
+
+	// Elasticsearch
+	man.addConfigString("elasticsearch.url", "", "Elasticsearch URL (e.g. https://elasticsearch.example.com:9200)")
+	man.addConfigString("elasticsearch.username", "", "Username for Elasticsearch basic authentication")
+	man.addConfigString("elasticsearch.password", "", "Password for Elasticsearch basic authentication")
+	man.addConfigString("elasticsearch.api_key", "", "Elasticsearch API key (takes precedence over username and password)")
+	man.addConfigString("elasticsearch.status_index", "osquery_status", "Elasticsearch index or data stream for status logs")
+	man.addConfigString("elasticsearch.result_index", "osquery_result", "Elasticsearch index or data stream for result logs")
+	man.addConfigString("elasticsearch.audit_index", "fleet_audit", "Elasticsearch index or data stream for audit logs")
+	man.addConfigBool("elasticsearch.enable_compression", true, "Compress the requests sent to Elasticsearch with gzip")
+	man.addConfigDuration("elasticsearch.timeout", 30*time.Second, "Elasticsearch bulk request timeout")
+
 	// License
 	man.addConfigString("license.key", "", "Fleet license key (to enable Fleet Premium features)")
 	man.addConfigBool("license.enforce_host_limit", false, "Enforce license limit of enrolled hosts")
@@ -878,6 +1051,17 @@ func (man Manager) LoadConfig() FleetConfig {
 			AsyncHostRedisPopCount:           man.getConfigInt("osquery.async_host_redis_pop_count"),
 			AsyncHostRedisScanKeysCount:      man.getConfigInt("osquery.async_host_redis_scan_keys_count"),
 			MinSoftwareLastOpenedAtDiff:      man.getConfigDuration("osquery.min_software_last_opened_at_diff"),
+			ResultLogRoutes:                  man.getConfigResultLogRoutes("osquery.result_log_routes"),
+			EnableScheduledQueryResults:      man.getConfigBool("osquery.enable_scheduled_query_results"),
+			ScheduledQueryResultsMaxPerHost:  man.getConfigInt("osquery.scheduled_query_results_max_per_host"),
+			ScheduledQueryResultsRetention:   man.getConfigDuration("osquery.scheduled_query_results_retention"),
+			EnableLiveQueryResults:           man.getConfigBool("osquery.enable_live_query_results"),
+			LiveQueryResultsMaxRows:          man.getConfigInt("osquery.live_query_results_max_rows"),
+			LiveQueryResultsTTL:              man.getConfigDuration("osquery.live_query_results_ttl"),
+			PolicyHistoryRetention:           man.getConfigDuration("osquery.policy_history_retention"),
+			SoftwareChangesRetention:         man.getConfigDuration("osquery.software_changes_retention"),
+			HostVitalsHistoryInterval:        man.getConfigDuration("osquery.host_vitals_history_interval"),
+			HostVitalsHistoryRetention:       man.getConfigDuration("osquery.host_vitals_history_retention"),
 		},
 		Logging: LoggingConfig{
 			Debug:                man.getConfigBool("logging.debug"),
@@ -887,6 +1071,10 @@ func (man Manager) LoadConfig() FleetConfig {
 			TracingEnabled:       man.getConfigBool("logging.tracing_enabled"),
 			TracingType:          man.getConfigString("logging.tracing_type"),
 		},
+		Activity: ActivityConfig{
+			EnableAuditLog: man.getConfigBool("activity.enable_audit_log"),
+			AuditLogPlugin: man.getConfigString("activity.audit_log_plugin"),
+		},
 		Firehose: FirehoseConfig{
 			Region:           man.getConfigString("firehose.region"),
 			EndpointURL:      man.getConfigString("firehose.endpoint_url"),
@@ -895,6 +1083,7 @@ func (man Manager) LoadConfig() FleetConfig {
 			StsAssumeRoleArn: man.getConfigString("firehose.sts_assume_role_arn"),
 			StatusStream:     man.getConfigString("firehose.status_stream"),
 			ResultStream:     man.getConfigString("firehose.result_stream"),
+			AuditStream:      man.getConfigString("firehose.audit_stream"),
 		},
 		Kinesis: KinesisConfig{
 			Region:           man.getConfigString("kinesis.region"),
@@ -903,6 +1092,7 @@ func (man Manager) LoadConfig() FleetConfig {
 			SecretAccessKey:  man.getConfigString("kinesis.secret_access_key"),
 			StatusStream:     man.getConfigString("kinesis.status_stream"),
 			ResultStream:     man.getConfigString("kinesis.result_stream"),
+			AuditStream:      man.getConfigString("kinesis.audit_stream"),
 			StsAssumeRoleArn: man.getConfigString("kinesis.sts_assume_role_arn"),
 		},
 		Lambda: LambdaConfig{
@@ -911,6 +1101,7 @@ func (man Manager) LoadConfig() FleetConfig {
 			SecretAccessKey:  man.getConfigString("lambda.secret_access_key"),
 			StatusFunction:   man.getConfigString("lambda.status_function"),
 			ResultFunction:   man.getConfigString("lambda.result_function"),
+			AuditFunction:    man.getConfigString("lambda.audit_function"),
 			StsAssumeRoleArn: man.getConfigString("lambda.sts_assume_role_arn"),
 		},
 		S3: S3Config{
@@ -928,21 +1119,64 @@ func (man Manager) LoadConfig() FleetConfig {
 			Project:       man.getConfigString("pubsub.project"),
 			StatusTopic:   man.getConfigString("pubsub.status_topic"),
 			ResultTopic:   man.getConfigString("pubsub.result_topic"),
+			AuditTopic:    man.getConfigString("pubsub.audit_topic"),
 			AddAttributes: man.getConfigBool("pubsub.add_attributes"),
 		},
 		Filesystem: FilesystemConfig{
 			StatusLogFile:        man.getConfigString("filesystem.status_log_file"),
 			ResultLogFile:        man.getConfigString("filesystem.result_log_file"),
+			AuditLogFile:         man.getConfigString("filesystem.audit_log_file"),
 			EnableLogRotation:    man.getConfigBool("filesystem.enable_log_rotation"),
 			EnableLogCompression: man.getConfigBool("filesystem.enable_log_compression"),
 		},
 		KafkaREST: KafkaRESTConfig{
 			StatusTopic:      man.getConfigString("kafkarest.status_topic"),
 			ResultTopic:      man.getConfigString("kafkarest.result_topic"),
+			AuditTopic:       man.getConfigString("kafkarest.audit_topic"),
 			ProxyHost:        man.getConfigString("kafkarest.proxyhost"),
 			ContentTypeValue: man.getConfigString("kafkarest.content_type_value"),
 			Timeout:          man.getConfigInt("kafkarest.timeout"),
 		},
+		Kafka: KafkaConfig{
+			Brokers:       man.getConfigString("kafka.brokers"),
+			StatusTopic:   man.getConfigString("kafka.status_topic"),
+			ResultTopic:   man.getConfigString("kafka.result_topic"),
+			AuditTopic:    man.getConfigString("kafka.audit_topic"),
+			RequiredAcks:  man.getConfigInt("kafka.required_acks"),
+			Compression:   man.getConfigString("kafka.compression"),
+			BatchBytes:    man.getConfigInt("kafka.batch_bytes"),
+			Timeout:       man.getConfigDuration("kafka.timeout"),
+			SASLMechanism: man.getConfigString("kafka.sasl_mechanism"),
+			SASLUsername:  man.getConfigString("kafka.sasl_username"),
+			SASLPassword:  man.getConfigString("kafka.sasl_password"),
+			UseTLS:        man.getConfigBool("kafka.use_tls"),
+			TLSCert:       man.getConfigString("kafka.tls_cert"),
+			TLSKey:        man.getConfigString("kafka.tls_key"),
+			TLSCA:         man.getConfigString("kafka.tls_ca"),
+			TLSServerName: man.getConfigString("kafka.tls_server_name"),
+		},
+		Splunk: SplunkConfig{
+			URL:               man.getConfigString("splunk.url"),
+			Token:             man.getConfigString("splunk.token"),
+			StatusIndex:       man.getConfigString("splunk.status_index"),
+			ResultIndex:       man.getConfigString("splunk.result_index"),
+			AuditIndex:        man.getConfigString("splunk.audit_index"),
+			Source:            man.getConfigString("splunk.source"),
+			SourceType:        man.getConfigString("splunk.sourcetype"),
+			EnableCompression: man.getConfigBool("splunk.enable_compression"),
+			Timeout:           man.getConfigDuration("splunk.timeout"),
+		},
+		Elasticsearch: ElasticsearchConfig{
+			URL:               man.getConfigString("elasticsearch.url"),
+			Username:          man.getConfigString("elasticsearch.username"),
+			Password:          man.getConfigString("elasticsearch.password"),
+			APIKey:            man.getConfigString("elasticsearch.api_key"),
+			StatusIndex:       man.getConfigString("elasticsearch.status_index"),
+			ResultIndex:       man.getConfigString("elasticsearch.result_index"),
+			AuditIndex:        man.getConfigString("elasticsearch.audit_index"),
+			EnableCompression: man.getConfigBool("elasticsearch.enable_compression"),
+			Timeout:           man.getConfigDuration("elasticsearch.timeout"),
+		},
 		License: LicenseConfig{
 			Key:              man.getConfigString("license.key"),
 			EnforceHostLimit: man.getConfigBool("license.enforce_host_limit"),
@@ -1111,6 +1345,42 @@ func (man Manager) getConfigString(key string) string {
 	return stringVal
 }
 
+// getConfigResultLogRoutes retrieves the result log routes, which are a list
+// in the config file or a JSON (or YAML) string in the environment variable
+// and flag.
+func (man Manager) getConfigResultLogRoutes(key string) []ResultLogRoute {
+	interfaceVal := man.getInterfaceVal(key)
+
+	var b []byte
+	switch v := interfaceVal.(type) {
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		b = []byte(v)
+	default:
+		var err error
+		b, err = yaml.Marshal(v)
+		if err != nil {
+			panic("Unable to marshal config for key " + key + ": " + err.Error())
+		}
+	}
+
+	var routes []ResultLogRoute
+	if err := ghodssyaml.Unmarshal(b, &routes); err != nil {
+		panic("Invalid result log routes for key " + key + ": " + err.Error())
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	for _, route := range routes {
+		if route.Plugin == "" {
+			panic("Invalid result log routes for key " + key + ": plugin is required")
+		}
+	}
+	return routes
+}
+
 // Custom handling for TLSProfile which can only accept specific values
 // for the argument
 func (man Manager) getConfigTLSProfile() string {
@@ -482,3 +482,83 @@ b1xn1jGQd/o0xFf9ojpDNy6vNojidQGHh6E3h0GYvxbnQmVNq5U=
 // prevent static analysis tools from raising issues due to detection of private key
 // in code.
 func testingKey(s string) string { return strings.ReplaceAll(s, "TESTING KEY", "PRIVATE KEY") }
+
+func TestConfigResultLogRoutes(t *testing.T) {
+	cases := []struct {
+		desc    string
+		yaml    string
+		envVars []string
+		panics  bool
+		want    []ResultLogRoute
+	}{
+		{
+			desc: "default",
+			want: nil,
+		},
+		{
+			desc: "yaml list",
+			yaml: `
+osquery:
+  result_log_routes:
+    - plugin: splunk
+      packs: [security]
+      team_ids: [1, 2]
+    - plugin: filesystem`,
+			want: []ResultLogRoute{
+				{Plugin: "splunk", Packs: []string{"security"}, TeamIDs: []uint{1, 2}},
+				{Plugin: "filesystem"},
+			},
+		},
+		{
+			desc:    "env var json",
+			envVars: []string{`FLEET_OSQUERY_RESULT_LOG_ROUTES=[{"plugin":"kinesis","queries":["processes"]}]`},
+			want: []ResultLogRoute{
+				{Plugin: "kinesis", Queries: []string{"processes"}},
+			},
+		},
+		{
+			desc:    "invalid json",
+			envVars: []string{`FLEET_OSQUERY_RESULT_LOG_ROUTES=[{"plugin":`},
+			panics:  true,
+		},
+		{
+			desc: "missing plugin",
+			yaml: `
+osquery:
+  result_log_routes:
+    - packs: [security]`,
+			panics: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var cmd cobra.Command
+			// Leaving this flag unset means that no attempt will be made to load
+			// the config file
+			cmd.PersistentFlags().StringP("config", "c", "", "Path to a configuration file")
+			man := NewManager(&cmd)
+
+			man.viper.SetConfigType("yaml")
+			require.NoError(t, man.viper.ReadConfig(strings.NewReader(c.yaml)))
+
+			os.Clearenv()
+			for _, env := range c.envVars {
+				kv := strings.SplitN(env, "=", 2)
+				t.Setenv(kv[0], kv[1])
+			}
+
+			if c.panics {
+				require.Panics(t, func() {
+					man.LoadConfig()
+				})
+				return
+			}
+			var loadedCfg FleetConfig
+			require.NotPanics(t, func() {
+				loadedCfg = man.LoadConfig()
+			})
+			require.Equal(t, c.want, loadedCfg.Osquery.ResultLogRoutes)
+		})
+	}
+}
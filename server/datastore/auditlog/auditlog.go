@@ -0,0 +1,63 @@
+// Package auditlog wraps a Datastore to write every new activity to an audit
+// log, so that a copy of the activities is kept outside of the database.
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Datastore is the auditlog datastore type - it wraps the fleet.Datastore
+// interface to write the activities to the audit logger once they are stored.
+type Datastore struct {
+	fleet.Datastore
+	audit  fleet.JSONLogger
+	logger kitlog.Logger
+}
+
+// New creates a Datastore that wraps ds and writes the new activities to
+// audit.
+func New(ds fleet.Datastore, audit fleet.JSONLogger, logger kitlog.Logger) *Datastore {
+	return &Datastore{
+		Datastore: ds,
+		audit:     audit,
+		logger:    logger,
+	}
+}
+
+// NewActivity stores the activity and writes it to the audit log.
+func (d *Datastore) NewActivity(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+	_, err := d.CreateActivity(ctx, user, activityType, details)
+	return err
+}
+
+// CreateActivity stores the activity and writes it to the audit log, with the
+// same fields as the activities returned by ListActivities. Failing to write
+// to the audit log is logged but does not fail the activity, as it is already
+// stored.
+func (d *Datastore) CreateActivity(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) (*fleet.Activity, error) {
+	activity, err := d.Datastore.CreateActivity(ctx, user, activityType, details)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.write(ctx, activity); err != nil {
+		level.Error(d.logger).Log("msg", "failed to write activity to audit log", "id", activity.ID, "type", activityType, "err", err)
+	}
+	return activity, nil
+}
+
+func (d *Datastore) write(ctx context.Context, activity *fleet.Activity) error {
+	b, err := json.Marshal(activity)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "marshal audit log entry")
+	}
+	if err := d.audit.Write(ctx, []json.RawMessage{b}); err != nil {
+		return ctxerr.Wrap(ctx, err, "write audit log entry")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+type auditLogger struct {
+	logs  []json.RawMessage
+	calls int
+	err   error
+}
+
+func (l *auditLogger) Write(ctx context.Context, logs []json.RawMessage) error {
+	l.calls++
+	if l.err != nil {
+		return l.err
+	}
+	l.logs = append(l.logs, logs...)
+	return nil
+}
+
+func TestNewActivity(t *testing.T) {
+	ctx := context.Background()
+	ms := new(mock.Store)
+	createdAt := time.Date(2022, 10, 12, 14, 0, 0, 0, time.UTC)
+	var dbErr error
+	var nextID uint
+	ms.CreateActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) (*fleet.Activity, error) {
+		if dbErr != nil {
+			return nil, dbErr
+		}
+		nextID++
+		b, err := json.Marshal(details)
+		require.NoError(t, err)
+		raw := json.RawMessage(b)
+		act := &fleet.Activity{
+			ID:      nextID,
+			Type:    activityType,
+			Details: &raw,
+		}
+		act.CreatedAt = createdAt
+		if user != nil {
+			act.ActorFullName = user.Name
+			act.ActorID = &user.ID
+			act.ActorEmail = &user.Email
+		}
+		return act, nil
+	}
+
+	audit := &auditLogger{}
+	ds := New(ms, audit, kitlog.NewNopLogger())
+	user := &fleet.User{ID: 1, Name: "Admin", Email: "admin@example.com"}
+
+	err := ds.NewActivity(ctx, user, fleet.ActivityTypeCreatedPack, &map[string]interface{}{"pack_id": 2, "pack_name": "p"})
+	require.NoError(t, err)
+	require.True(t, ms.CreateActivityFuncInvoked)
+	require.Len(t, audit.logs, 1)
+	require.JSONEq(t, `{
+		"id": 1,
+		"created_at": "2022-10-12T14:00:00Z",
+		"actor_full_name": "Admin",
+		"actor_id": 1,
+		"actor_gravatar": null,
+		"actor_email": "admin@example.com",
+		"type": "created_pack",
+		"details": {"pack_id": 2, "pack_name": "p"}
+	}`, string(audit.logs[0]))
+
+	// failing to write to the audit log does not fail the activity
+	audit.err = errors.New("unavailable")
+	err = ds.NewActivity(ctx, user, fleet.ActivityTypeDeletedPack, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, audit.calls)
+	require.Len(t, audit.logs, 1)
+	audit.err = nil
+
+	// activities that fail to be stored are not written
+	dbErr = errors.New("db error")
+	err = ds.NewActivity(ctx, user, fleet.ActivityTypeDeletedPack, nil)
+	require.ErrorIs(t, err, dbErr)
+	require.Equal(t, 2, audit.calls)
+
+	dbErr = nil
+	act, err := ds.CreateActivity(ctx, nil, fleet.ActivityTypeCreatedPack, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint(3), act.ID)
+	require.Len(t, audit.logs, 2)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(audit.logs[1], &got))
+	require.Equal(t, float64(3), got["id"])
+	require.Equal(t, fleet.ActivityTypeCreatedPack, got["type"])
+	require.Nil(t, got["actor_id"])
+}
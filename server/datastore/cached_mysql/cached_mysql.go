@@ -23,6 +23,8 @@ const (
 	defaultTeamAgentOptionsExpiration = 1 * time.Minute
 	teamFeaturesKey                   = "TeamFeatures:team:%d"
 	defaultTeamFeaturesExpiration     = 1 * time.Minute
+	hostAssignmentRulesKey            = "HostAssignmentRules"
+	defaultAssignmentRulesExpiration  = 1 * time.Minute
 )
 
 // cloner represents any type that can clone itself. Used by types to provide a more efficient clone method.
@@ -106,6 +108,7 @@ type cachedMysql struct {
 	scheduledQueriesExp time.Duration
 	teamAgentOptionsExp time.Duration
 	teamFeaturesExp     time.Duration
+	assignmentRulesExp  time.Duration
 }
 
 type Option func(*cachedMysql)
@@ -134,6 +137,12 @@ func WithTeamFeaturesExpiration(d time.Duration) Option {
 	}
 }
 
+func WithHostAssignmentRulesExpiration(d time.Duration) Option {
+	return func(o *cachedMysql) {
+		o.assignmentRulesExp = d
+	}
+}
+
 func New(ds fleet.Datastore, opts ...Option) fleet.Datastore {
 	c := &cachedMysql{
 		Datastore:           ds,
@@ -142,6 +151,7 @@ func New(ds fleet.Datastore, opts ...Option) fleet.Datastore {
 		scheduledQueriesExp: defaultScheduledQueriesExpiration,
 		teamAgentOptionsExp: defaultTeamAgentOptionsExpiration,
 		teamFeaturesExp:     defaultTeamFeaturesExpiration,
+		assignmentRulesExp:  defaultAssignmentRulesExpiration,
 	}
 	for _, fn := range opts {
 		fn(c)
@@ -263,6 +273,36 @@ func (ds *cachedMysql) TeamFeatures(ctx context.Context, teamID uint) (*fleet.Fe
 	return features, nil
 }
 
+func (ds *cachedMysql) ListHostAssignmentRules(ctx context.Context) (fleet.TeamHostAssignmentRules, error) {
+	if x, found := ds.c.Get(hostAssignmentRulesKey); found {
+		if rules, ok := x.(fleet.TeamHostAssignmentRules); ok {
+			return rules, nil
+		}
+	}
+
+	rules, err := ds.Datastore.ListHostAssignmentRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ds.c.Set(hostAssignmentRulesKey, rules, ds.assignmentRulesExp)
+
+	return rules, nil
+}
+
+func (ds *cachedMysql) NewTeam(ctx context.Context, team *fleet.Team) (*fleet.Team, error) {
+	team, err := ds.Datastore.NewTeam(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rules of all the teams are cached together, they are reloaded on the
+	// next call.
+	ds.c.Delete(hostAssignmentRulesKey)
+
+	return team, nil
+}
+
 func (ds *cachedMysql) SaveTeam(ctx context.Context, team *fleet.Team) (*fleet.Team, error) {
 	team, err := ds.Datastore.SaveTeam(ctx, team)
 	if err != nil {
@@ -274,6 +314,7 @@ func (ds *cachedMysql) SaveTeam(ctx context.Context, team *fleet.Team) (*fleet.T
 
 	ds.c.Set(agentOptionsKey, team.Config.AgentOptions, ds.teamAgentOptionsExp)
 	ds.c.Set(featuresKey, &team.Config.Features, ds.teamFeaturesExp)
+	ds.c.Delete(hostAssignmentRulesKey)
 
 	return team, nil
 }
@@ -289,6 +330,7 @@ func (ds *cachedMysql) DeleteTeam(ctx context.Context, teamID uint) error {
 
 	ds.c.Delete(agentOptionsKey)
 	ds.c.Delete(featuresKey)
+	ds.c.Delete(hostAssignmentRulesKey)
 
 	return nil
 }
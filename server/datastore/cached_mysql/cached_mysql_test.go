@@ -461,3 +461,52 @@ func TestCachedTeamFeatures(t *testing.T) {
 	_, err = ds.TeamFeatures(context.Background(), testTeam.ID)
 	require.Error(t, err)
 }
+
+func TestCachedHostAssignmentRules(t *testing.T) {
+	t.Parallel()
+
+	mockedDS := new(mock.Store)
+	ds := New(mockedDS, WithHostAssignmentRulesExpiration(100*time.Millisecond))
+
+	testRules := fleet.TeamHostAssignmentRules{
+		{HostAssignmentRule: fleet.HostAssignmentRule{Platform: "darwin", HostnameRegex: "^web-"}, TeamID: 1, TeamName: "test"},
+	}
+	require.NoError(t, testRules[0].Compile())
+	mockedDS.ListHostAssignmentRulesFunc = func(ctx context.Context) (fleet.TeamHostAssignmentRules, error) {
+		return testRules, nil
+	}
+	mockedDS.SaveTeamFunc = func(ctx context.Context, team *fleet.Team) (*fleet.Team, error) {
+		return team, nil
+	}
+
+	rules, err := ds.ListHostAssignmentRules(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, testRules, rules)
+
+	// the rules are served from the cache
+	mockedDS.ListHostAssignmentRulesFuncInvoked = false
+	rules, err = ds.ListHostAssignmentRules(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, testRules, rules)
+	require.False(t, mockedDS.ListHostAssignmentRulesFuncInvoked)
+	// the cached rules are still compiled
+	require.True(t, rules[0].Matches(&fleet.Host{Platform: "darwin", Hostname: "web-1"}, nil))
+
+	// saving a team removes the rules from the cache
+	testRules = fleet.TeamHostAssignmentRules{
+		{HostAssignmentRule: fleet.HostAssignmentRule{Platform: "windows"}, TeamID: 1, TeamName: "test"},
+	}
+	_, err = ds.SaveTeam(context.Background(), &fleet.Team{ID: 1, Name: "test"})
+	require.NoError(t, err)
+	rules, err = ds.ListHostAssignmentRules(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, testRules, rules)
+	require.True(t, mockedDS.ListHostAssignmentRulesFuncInvoked)
+
+	// the rules expire from the cache
+	mockedDS.ListHostAssignmentRulesFuncInvoked = false
+	time.Sleep(200 * time.Millisecond)
+	_, err = ds.ListHostAssignmentRules(context.Background())
+	require.NoError(t, err)
+	require.True(t, mockedDS.ListHostAssignmentRulesFuncInvoked)
+}
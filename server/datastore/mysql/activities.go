@@ -10,29 +10,98 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-// NewActivity stores an activity item that the user performed
+// NewActivity stores an activity item that the user performed and queues its
+// delivery to the matching activity webhooks.
 func (ds *Datastore) NewActivity(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+	_, err := ds.CreateActivity(ctx, user, activityType, details)
+	return err
+}
+
+// CreateActivity stores an activity item like NewActivity and returns it with
+// its ID and creation timestamp.
+func (ds *Datastore) CreateActivity(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) (*fleet.Activity, error) {
 	detailsBytes, err := json.Marshal(details)
 	if err != nil {
-		return ctxerr.Wrap(ctx, err, "marshaling activity details")
-	}
-	_, err = ds.writer.ExecContext(ctx,
-		`INSERT INTO activities (user_id, user_name, activity_type, details) VALUES(?,?,?,?)`,
-		user.ID,
-		user.Name,
-		activityType,
-		detailsBytes,
-	)
+		return nil, ctxerr.Wrap(ctx, err, "marshaling activity details")
+	}
+	activity := &fleet.Activity{
+		Type:    activityType,
+		Details: (*json.RawMessage)(&detailsBytes),
+	}
+	// activities performed automatically by Fleet have no user
+	var userID *uint
+	var userName *string
+	if user != nil {
+		userID = &user.ID
+		userName = &user.Name
+		activity.ActorFullName = user.Name
+		activity.ActorID = &user.ID
+		activity.ActorGravatar = &user.GravatarURL
+		activity.ActorEmail = &user.Email
+	}
+	err = ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO activities (user_id, user_name, activity_type, details) VALUES(?,?,?,?)`,
+			userID,
+			userName,
+			activityType,
+			detailsBytes,
+		)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "new activity")
+		}
+		id, _ := res.LastInsertId()
+		activity.ID = uint(id)
+		if err := sqlx.GetContext(ctx, tx, &activity.CreatedAt, `SELECT created_at FROM activities WHERE id = ?`, id); err != nil {
+			return ctxerr.Wrap(ctx, err, "select new activity timestamp")
+		}
+		return queueActivityWebhookJobsDB(ctx, tx, uint(id), activityType, fleet.ActivityTeamID(detailsBytes))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// queueActivityWebhookJobsDB queues a worker job for each enabled activity
+// webhook that matches the activity.
+func queueActivityWebhookJobsDB(ctx context.Context, tx sqlx.ExtContext, activityID uint, activityType string, teamID *uint) error {
+	var webhooks []*fleet.ActivityWebhook
+	err := sqlx.SelectContext(ctx, tx, &webhooks,
+		`SELECT id, activity_types, team_id, enabled FROM activity_webhooks WHERE enabled = 1`)
 	if err != nil {
-		return ctxerr.Wrap(ctx, err, "new activity")
+		return ctxerr.Wrap(ctx, err, "select activity webhooks")
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Matches(activityType, teamID) {
+			continue
+		}
+		args, err := json.Marshal(activityWebhookJobArgs{WebhookID: webhook.ID, ActivityID: activityID})
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "marshal activity webhook job args")
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO jobs (name, args, state) VALUES (?, ?, ?)`,
+			fleet.ActivityWebhookJobName, args, fleet.JobStateQueued,
+		); err != nil {
+			return ctxerr.Wrap(ctx, err, "queue activity webhook job")
+		}
 	}
 	return nil
 }
 
+// activityWebhookJobArgs mirrors the arguments of the activity webhook job
+// of the worker package.
+type activityWebhookJobArgs struct {
+	WebhookID  uint `json:"webhook_id"`
+	ActivityID uint `json:"activity_id"`
+}
+
 // ListActivities returns a slice of activities performed across the organization
 func (ds *Datastore) ListActivities(ctx context.Context, opt fleet.ListOptions) ([]*fleet.Activity, error) {
 	activities := []*fleet.Activity{}
-	query := `SELECT a.id, a.user_id, a.created_at, a.activity_type, a.details, coalesce(u.name, a.user_name) as name, u.gravatar_url, u.email
+	query := `SELECT a.id, a.user_id, a.created_at, a.activity_type, a.details, coalesce(u.name, a.user_name, '') as name, u.gravatar_url, u.email
 	          FROM activities a LEFT JOIN users u ON (a.user_id=u.id)
 			  WHERE true`
 	query = appendListOptionsToSQL(query, opt)
@@ -46,3 +115,18 @@ func (ds *Datastore) ListActivities(ctx context.Context, opt fleet.ListOptions)
 
 	return activities, nil
 }
+
+// Activity returns the activity identified by id.
+func (ds *Datastore) Activity(ctx context.Context, id uint) (*fleet.Activity, error) {
+	var activity fleet.Activity
+	query := `SELECT a.id, a.user_id, a.created_at, a.activity_type, a.details, coalesce(u.name, a.user_name, '') as name, u.gravatar_url, u.email
+	          FROM activities a LEFT JOIN users u ON (a.user_id=u.id)
+			  WHERE a.id = ?`
+	if err := sqlx.GetContext(ctx, ds.reader, &activity, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("Activity").WithID(id))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "select activity")
+	}
+	return &activity, nil
+}
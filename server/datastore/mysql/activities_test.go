@@ -19,6 +19,8 @@ func TestActivity(t *testing.T) {
 	}{
 		{"UsernameChange", testActivityUsernameChange},
 		{"New", testActivityNew},
+		{"NoUser", testActivityNoUser},
+		{"Create", testActivityCreate},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -107,3 +109,45 @@ func testActivityNew(t *testing.T, ds *Datastore) {
 	require.NoError(t, err)
 	assert.Len(t, activities, 2)
 }
+
+func testActivityNoUser(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	require.NoError(t, ds.NewActivity(ctx, nil, "test1", &map[string]interface{}{"detail": 1}))
+
+	activities, err := ds.ListActivities(ctx, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, activities, 1)
+	assert.Nil(t, activities[0].ActorID)
+	assert.Empty(t, activities[0].ActorFullName)
+
+	activity, err := ds.Activity(ctx, activities[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "test1", activity.Type)
+	assert.Nil(t, activity.ActorID)
+}
+
+func testActivityCreate(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	u := &fleet.User{
+		Password:   []byte("asd"),
+		Name:       "fullname",
+		Email:      "email@asd.com",
+		GlobalRole: ptr.String(fleet.RoleObserver),
+	}
+	_, err := ds.NewUser(ctx, u)
+	require.NoError(t, err)
+
+	created, err := ds.CreateActivity(ctx, u, "test1", &map[string]interface{}{"detail": 1})
+	require.NoError(t, err)
+	require.NotZero(t, created.ID)
+	require.False(t, created.CreatedAt.IsZero())
+
+	activity, err := ds.Activity(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, activity.CreatedAt, created.CreatedAt)
+	assert.Equal(t, "test1", created.Type)
+	assert.Equal(t, "fullname", created.ActorFullName)
+	require.NotNil(t, created.ActorID)
+	assert.Equal(t, u.ID, *created.ActorID)
+	assert.JSONEq(t, string(*activity.Details), string(*created.Details))
+}
@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+func (ds *Datastore) NewActivityWebhook(ctx context.Context, webhook *fleet.ActivityWebhook) (*fleet.ActivityWebhook, error) {
+	result, err := ds.writer.ExecContext(ctx, `
+		INSERT INTO activity_webhooks (name, url, secret, activity_types, team_id, enabled)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		webhook.Name, webhook.URL, webhook.Secret, webhook.ActivityTypes, webhook.TeamID, webhook.Enabled,
+	)
+	if err != nil {
+		if isDuplicate(err) {
+			return nil, ctxerr.Wrap(ctx, alreadyExists("ActivityWebhook", webhook.Name))
+		}
+		if isChildForeignKeyError(err) {
+			return nil, ctxerr.Wrap(ctx, foreignKey("activity_webhooks", "team_id"))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "insert activity webhook")
+	}
+
+	id, _ := result.LastInsertId()
+	return ds.activityWebhookDB(ctx, ds.writer, uint(id))
+}
+
+func (ds *Datastore) ActivityWebhook(ctx context.Context, id uint) (*fleet.ActivityWebhook, error) {
+	return ds.activityWebhookDB(ctx, ds.reader, id)
+}
+
+func (ds *Datastore) activityWebhookDB(ctx context.Context, q sqlx.QueryerContext, id uint) (*fleet.ActivityWebhook, error) {
+	var webhook fleet.ActivityWebhook
+	if err := sqlx.GetContext(ctx, q, &webhook, `SELECT * FROM activity_webhooks WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("ActivityWebhook").WithID(id))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "get activity webhook")
+	}
+	return &webhook, nil
+}
+
+func (ds *Datastore) ListActivityWebhooks(ctx context.Context) ([]*fleet.ActivityWebhook, error) {
+	var webhooks []*fleet.ActivityWebhook
+	if err := sqlx.SelectContext(ctx, ds.reader, &webhooks, `SELECT * FROM activity_webhooks ORDER BY id`); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list activity webhooks")
+	}
+	return webhooks, nil
+}
+
+func (ds *Datastore) SaveActivityWebhook(ctx context.Context, webhook *fleet.ActivityWebhook) error {
+	result, err := ds.writer.ExecContext(ctx, `
+		UPDATE activity_webhooks
+		SET name = ?, url = ?, secret = ?, activity_types = ?, team_id = ?, enabled = ?
+		WHERE id = ?`,
+		webhook.Name, webhook.URL, webhook.Secret, webhook.ActivityTypes, webhook.TeamID, webhook.Enabled, webhook.ID,
+	)
+	if err != nil {
+		if isDuplicate(err) {
+			return ctxerr.Wrap(ctx, alreadyExists("ActivityWebhook", webhook.Name))
+		}
+		if isChildForeignKeyError(err) {
+			return ctxerr.Wrap(ctx, foreignKey("activity_webhooks", "team_id"))
+		}
+		return ctxerr.Wrap(ctx, err, "update activity webhook")
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ctxerr.Wrap(ctx, notFound("ActivityWebhook").WithID(webhook.ID))
+	}
+	return nil
+}
+
+func (ds *Datastore) DeleteActivityWebhook(ctx context.Context, id uint) error {
+	return ds.deleteEntity(ctx, activityWebhooksTable, id)
+}
+
+func (ds *Datastore) NewActivityWebhookDelivery(ctx context.Context, delivery *fleet.ActivityWebhookDelivery) error {
+	result, err := ds.writer.ExecContext(ctx, `
+		INSERT INTO activity_webhook_deliveries (webhook_id, activity_id, activity_type, status_code, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		delivery.WebhookID, delivery.ActivityID, delivery.ActivityType, delivery.StatusCode, delivery.Error, delivery.DurationMs,
+	)
+	if err != nil {
+		if isChildForeignKeyError(err) {
+			return ctxerr.Wrap(ctx, notFound("ActivityWebhook").WithID(delivery.WebhookID))
+		}
+		return ctxerr.Wrap(ctx, err, "insert activity webhook delivery")
+	}
+	id, _ := result.LastInsertId()
+	delivery.ID = uint(id)
+	return nil
+}
+
+func (ds *Datastore) ListActivityWebhookDeliveries(ctx context.Context, webhookID uint, opt fleet.ListOptions) ([]*fleet.ActivityWebhookDelivery, error) {
+	if opt.OrderKey == "" {
+		opt.OrderKey = "id"
+		opt.OrderDirection = fleet.OrderDescending
+	}
+	query, args := appendListOptionsWithCursorToSQL(
+		`SELECT id, webhook_id, activity_id, activity_type, status_code, COALESCE(error, '') AS error, duration_ms, created_at
+		FROM activity_webhook_deliveries WHERE webhook_id = ?`,
+		[]interface{}{webhookID}, opt,
+	)
+
+	deliveries := []*fleet.ActivityWebhookDelivery{}
+	if err := sqlx.SelectContext(ctx, ds.reader, &deliveries, query, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list activity webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+func (ds *Datastore) CleanupActivityWebhookDeliveries(ctx context.Context, now time.Time) error {
+	_, err := ds.writer.ExecContext(ctx,
+		`DELETE FROM activity_webhook_deliveries WHERE created_at < ?`,
+		now.Add(-fleet.ActivityWebhookDeliveriesRetention),
+	)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "cleanup activity webhook deliveries")
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityWebhooks(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"CRUD", testActivityWebhooksCRUD},
+		{"QueueJobs", testActivityWebhooksQueueJobs},
+		{"Deliveries", testActivityWebhooksDeliveries},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testActivityWebhooksCRUD(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+
+	webhooks, err := ds.ListActivityWebhooks(ctx)
+	require.NoError(t, err)
+	require.Empty(t, webhooks)
+
+	w1, err := ds.NewActivityWebhook(ctx, &fleet.ActivityWebhook{
+		Name:    "all",
+		URL:     "https://example.com/all",
+		Secret:  "abc",
+		Enabled: true,
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, w1.ID)
+	assert.Empty(t, w1.ActivityTypes)
+	assert.Nil(t, w1.TeamID)
+	assert.True(t, w1.Enabled)
+
+	w2, err := ds.NewActivityWebhook(ctx, &fleet.ActivityWebhook{
+		Name:          "team",
+		URL:           "https://example.com/team",
+		Secret:        "def",
+		ActivityTypes: fleet.ActivityTypeList{fleet.ActivityTypeCreatedPolicy},
+		TeamID:        &team.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, fleet.ActivityTypeList{fleet.ActivityTypeCreatedPolicy}, w2.ActivityTypes)
+	assert.Equal(t, team.ID, *w2.TeamID)
+	assert.False(t, w2.Enabled)
+
+	_, err = ds.NewActivityWebhook(ctx, &fleet.ActivityWebhook{Name: "all", URL: "https://example.com", Secret: "x"})
+	var existsErr fleet.AlreadyExistsError
+	require.ErrorAs(t, err, &existsErr)
+
+	webhooks, err = ds.ListActivityWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+	assert.Equal(t, "all", webhooks[0].Name)
+	assert.Equal(t, "team", webhooks[1].Name)
+
+	w2.Enabled = true
+	w2.TeamID = nil
+	w2.ActivityTypes = fleet.ActivityTypeList{fleet.ActivityTypeCreatedPolicy, fleet.ActivityTypeDeletedPolicy}
+	require.NoError(t, ds.SaveActivityWebhook(ctx, w2))
+	got, err := ds.ActivityWebhook(ctx, w2.ID)
+	require.NoError(t, err)
+	assert.True(t, got.Enabled)
+	assert.Nil(t, got.TeamID)
+	assert.Len(t, got.ActivityTypes, 2)
+
+	w2.Name = "all"
+	err = ds.SaveActivityWebhook(ctx, w2)
+	require.ErrorAs(t, err, &existsErr)
+
+	err = ds.SaveActivityWebhook(ctx, &fleet.ActivityWebhook{ID: 999, Name: "x", URL: "https://example.com", Secret: "x"})
+	require.True(t, fleet.IsNotFound(err))
+
+	require.NoError(t, ds.DeleteActivityWebhook(ctx, w1.ID))
+	_, err = ds.ActivityWebhook(ctx, w1.ID)
+	require.True(t, fleet.IsNotFound(err))
+	err = ds.DeleteActivityWebhook(ctx, w1.ID)
+	require.True(t, fleet.IsNotFound(err))
+}
+
+func testActivityWebhooksQueueJobs(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	u := &fleet.User{
+		Password:   []byte("asd"),
+		Name:       "fullname",
+		Email:      "email@asd.com",
+		GlobalRole: ptr.String(fleet.RoleAdmin),
+	}
+	_, err := ds.NewUser(ctx, u)
+	require.NoError(t, err)
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+
+	all, err := ds.NewActivityWebhook(ctx, &fleet.ActivityWebhook{Name: "all", URL: "https://example.com", Secret: "x", Enabled: true})
+	require.NoError(t, err)
+	packs, err := ds.NewActivityWebhook(ctx, &fleet.ActivityWebhook{
+		Name: "packs", URL: "https://example.com", Secret: "x", Enabled: true,
+		ActivityTypes: fleet.ActivityTypeList{fleet.ActivityTypeCreatedPack},
+	})
+	require.NoError(t, err)
+	teamWebhook, err := ds.NewActivityWebhook(ctx, &fleet.ActivityWebhook{
+		Name: "team", URL: "https://example.com", Secret: "x", Enabled: true, TeamID: &team.ID,
+	})
+	require.NoError(t, err)
+	_, err = ds.NewActivityWebhook(ctx, &fleet.ActivityWebhook{Name: "disabled", URL: "https://example.com", Secret: "x"})
+	require.NoError(t, err)
+
+	require.NoError(t, ds.NewActivity(ctx, u, fleet.ActivityTypeCreatedPack, &map[string]interface{}{"pack_id": 1}))
+	require.NoError(t, ds.NewActivity(ctx, u, fleet.ActivityTypeCreatedTeam, &map[string]interface{}{"team_id": team.ID}))
+
+	activities, err := ds.ListActivities(ctx, fleet.ListOptions{OrderKey: "a.id"})
+	require.NoError(t, err)
+	require.Len(t, activities, 2)
+
+	activity, err := ds.Activity(ctx, activities[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, fleet.ActivityTypeCreatedTeam, activity.Type)
+	assert.Equal(t, "fullname", activity.ActorFullName)
+	_, err = ds.Activity(ctx, activities[1].ID+1)
+	require.True(t, fleet.IsNotFound(err))
+
+	jobs, err := ds.GetQueuedJobs(ctx, 10, []string{fleet.ActivityWebhookJobName})
+	require.NoError(t, err)
+
+	type jobArgs struct {
+		WebhookID  uint `json:"webhook_id"`
+		ActivityID uint `json:"activity_id"`
+	}
+	var got []jobArgs
+	for _, j := range jobs {
+		assert.Equal(t, fleet.ActivityWebhookJobName, j.Name)
+		var args jobArgs
+		require.NoError(t, json.Unmarshal(*j.Args, &args))
+		got = append(got, args)
+	}
+	assert.ElementsMatch(t, []jobArgs{
+		{WebhookID: all.ID, ActivityID: activities[0].ID},
+		{WebhookID: packs.ID, ActivityID: activities[0].ID},
+		{WebhookID: all.ID, ActivityID: activities[1].ID},
+		{WebhookID: teamWebhook.ID, ActivityID: activities[1].ID},
+	}, got)
+
+	// filtering on other job names does not return them
+	jobs, err = ds.GetQueuedJobs(ctx, 10, []string{"jira"})
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+
+	// delayed jobs are not returned until they are ready
+	job := firstQueuedJob(t, ds, fleet.ActivityWebhookJobName)
+	job.Retries = 1
+	job.NotBefore = time.Now().Add(time.Hour)
+	_, err = ds.UpdateJob(ctx, job.ID, job)
+	require.NoError(t, err)
+	jobs, err = ds.GetQueuedJobs(ctx, 10, nil)
+	require.NoError(t, err)
+	require.Len(t, jobs, 3)
+	for _, j := range jobs {
+		require.NotEqual(t, job.ID, j.ID)
+	}
+}
+
+// firstQueuedJob returns the first queued job with that name.
+func firstQueuedJob(t *testing.T, ds *Datastore, name string) *fleet.Job {
+	var job fleet.Job
+	err := sqlx.GetContext(context.Background(), ds.reader, &job,
+		`SELECT id, created_at, updated_at, name, args, state, retries, error, not_before FROM jobs WHERE name = ? ORDER BY id LIMIT 1`, name)
+	require.NoError(t, err)
+	return &job
+}
+
+func testActivityWebhooksDeliveries(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	webhook, err := ds.NewActivityWebhook(ctx, &fleet.ActivityWebhook{Name: "all", URL: "https://example.com", Secret: "x", Enabled: true})
+	require.NoError(t, err)
+
+	require.NoError(t, ds.NewActivityWebhookDelivery(ctx, &fleet.ActivityWebhookDelivery{
+		WebhookID: webhook.ID, ActivityID: 1, ActivityType: fleet.ActivityTypeCreatedPack,
+		Error: "connection refused", DurationMs: 10,
+	}))
+	require.NoError(t, ds.NewActivityWebhookDelivery(ctx, &fleet.ActivityWebhookDelivery{
+		WebhookID: webhook.ID, ActivityID: 1, ActivityType: fleet.ActivityTypeCreatedPack,
+		StatusCode: ptr.Int(200), DurationMs: 20,
+	}))
+	err = ds.NewActivityWebhookDelivery(ctx, &fleet.ActivityWebhookDelivery{WebhookID: 999, ActivityID: 1, ActivityType: "x"})
+	require.True(t, fleet.IsNotFound(err))
+
+	deliveries, err := ds.ListActivityWebhookDeliveries(ctx, webhook.ID, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	// most recent first
+	require.NotNil(t, deliveries[0].StatusCode)
+	assert.Equal(t, 200, *deliveries[0].StatusCode)
+	assert.Empty(t, deliveries[0].Error)
+	assert.Nil(t, deliveries[1].StatusCode)
+	assert.Equal(t, "connection refused", deliveries[1].Error)
+
+	deliveries, err = ds.ListActivityWebhookDeliveries(ctx, webhook.ID, fleet.ListOptions{PerPage: 1, Page: 1})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Nil(t, deliveries[0].StatusCode)
+
+	// the first delivery is older than the retention period
+	_, err = ds.writer.Exec(`UPDATE activity_webhook_deliveries SET created_at = ? WHERE status_code IS NULL`,
+		time.Now().Add(-fleet.ActivityWebhookDeliveriesRetention-time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, ds.CleanupActivityWebhookDeliveries(ctx, time.Now()))
+	deliveries, err = ds.ListActivityWebhookDeliveries(ctx, webhook.ID, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 200, *deliveries[0].StatusCode)
+
+	// deleting the webhook deletes its deliveries
+	require.NoError(t, ds.DeleteActivityWebhook(ctx, webhook.ID))
+	deliveries, err = ds.ListActivityWebhookDeliveries(ctx, webhook.ID, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
@@ -2,6 +2,8 @@ package mysql
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
@@ -143,3 +145,90 @@ func (ds *Datastore) CleanupDistributedQueryCampaigns(ctx context.Context, now t
 
 	return uint(exp), nil
 }
+
+func (ds *Datastore) NewDistributedQueryCampaignResult(ctx context.Context, result *fleet.DistributedQueryCampaignResult) error {
+	rows := result.Rows
+	if rows == nil {
+		rows = []map[string]string{}
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "marshal distributed query campaign result rows")
+	}
+
+	sqlStatement := `
+		INSERT INTO distributed_query_campaign_results (
+			distributed_query_campaign_id,
+			host_id,
+			hostname,
+			host_display_name,
+			data,
+			error
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	res, err := ds.writer.ExecContext(ctx, sqlStatement,
+		result.DistributedQueryCampaignID, result.HostID, result.Hostname, result.HostDisplayName, data, result.Error)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "inserting distributed query campaign result")
+	}
+	id, _ := res.LastInsertId()
+	result.ID = uint(id)
+	return nil
+}
+
+func (ds *Datastore) ListDistributedQueryCampaignResults(ctx context.Context, filter fleet.TeamFilter, campaignID uint) ([]*fleet.DistributedQueryCampaignResult, error) {
+	// LEFT JOIN so that the results of deleted hosts are still returned to the
+	// users that can see all hosts.
+	sqlStatement := fmt.Sprintf(`
+		SELECT
+			dqcr.id,
+			dqcr.distributed_query_campaign_id,
+			dqcr.host_id,
+			dqcr.hostname,
+			dqcr.host_display_name,
+			dqcr.data,
+			dqcr.error,
+			dqcr.created_at
+		FROM distributed_query_campaign_results dqcr
+		LEFT JOIN hosts h ON h.id = dqcr.host_id
+		WHERE dqcr.distributed_query_campaign_id = ? AND %s
+		ORDER BY dqcr.id
+	`, ds.whereFilterHostsByTeams(filter, "h"))
+
+	var rows []struct {
+		fleet.DistributedQueryCampaignResult
+		Data json.RawMessage `db:"data"`
+	}
+	if err := sqlx.SelectContext(ctx, ds.reader, &rows, sqlStatement, campaignID); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "selecting distributed query campaign results")
+	}
+
+	results := make([]*fleet.DistributedQueryCampaignResult, 0, len(rows))
+	for _, row := range rows {
+		result := row.DistributedQueryCampaignResult
+		if err := json.Unmarshal(row.Data, &result.Rows); err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "unmarshal distributed query campaign result rows")
+		}
+		results = append(results, &result)
+	}
+	return results, nil
+}
+
+const distributedQueryCampaignResultsCleanupBatch = 10000
+
+func (ds *Datastore) CleanupDistributedQueryCampaignResults(ctx context.Context, olderThan time.Time) error {
+	// delete in batches to avoid locking the table for too long
+	for {
+		res, err := ds.writer.ExecContext(ctx,
+			`DELETE FROM distributed_query_campaign_results WHERE created_at < ? LIMIT ?`,
+			olderThan, distributedQueryCampaignResultsCleanupBatch,
+		)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "cleanup distributed query campaign results")
+		}
+		if n, _ := res.RowsAffected(); n < distributedQueryCampaignResultsCleanupBatch {
+			return nil
+		}
+	}
+}
@@ -22,6 +22,7 @@ func TestCampaigns(t *testing.T) {
 		{"DistributedQuery", testCampaignsDistributedQuery},
 		{"CleanupDistributedQuery", testCampaignsCleanupDistributedQuery},
 		{"SaveDistributedQuery", testCampaignsSaveDistributedQuery},
+		{"Results", testCampaignsResults},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -173,6 +174,87 @@ func testCampaignsSaveDistributedQuery(t *testing.T, ds *Datastore) {
 	require.Equal(t, fleet.QueryComplete, gotC.Status)
 }
 
+func testCampaignsResults(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Zach", "zwass@fleet.co", true)
+	mockClock := clock.NewMockClock()
+	query := test.NewQuery(t, ds, "test", "select * from time", user.ID, false)
+	c1 := test.NewCampaign(t, ds, query.ID, fleet.QueryComplete, mockClock.Now())
+	c2 := test.NewCampaign(t, ds, query.ID, fleet.QueryComplete, mockClock.Now())
+
+	h1 := test.NewHost(t, ds, "foo.local", "192.168.1.10", "1", "1", mockClock.Now())
+	h2 := test.NewHost(t, ds, "bar.local", "192.168.1.11", "2", "2", mockClock.Now())
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	require.NoError(t, ds.AddHostsToTeam(ctx, &team.ID, []uint{h2.ID}))
+
+	errMsg := "no such table"
+	r1 := &fleet.DistributedQueryCampaignResult{
+		DistributedQueryCampaignID: c1.ID,
+		HostID:                     h1.ID,
+		Hostname:                   h1.Hostname,
+		HostDisplayName:            "foo",
+		Rows:                       []map[string]string{{"a": "1"}, {"a": "2"}},
+	}
+	r2 := &fleet.DistributedQueryCampaignResult{
+		DistributedQueryCampaignID: c1.ID,
+		HostID:                     h2.ID,
+		Hostname:                   h2.Hostname,
+		Error:                      &errMsg,
+	}
+	r3 := &fleet.DistributedQueryCampaignResult{
+		DistributedQueryCampaignID: c2.ID,
+		HostID:                     h1.ID,
+		Hostname:                   h1.Hostname,
+		Rows:                       []map[string]string{{"b": "1"}},
+	}
+	for _, r := range []*fleet.DistributedQueryCampaignResult{r1, r2, r3} {
+		require.NoError(t, ds.NewDistributedQueryCampaignResult(ctx, r))
+		require.NotZero(t, r.ID)
+	}
+
+	globalFilter := fleet.TeamFilter{User: test.UserAdmin}
+	teamFilter := fleet.TeamFilter{User: &fleet.User{Teams: []fleet.UserTeam{{Team: *team, Role: fleet.RoleObserver}}}, IncludeObserver: true}
+
+	results, err := ds.ListDistributedQueryCampaignResults(ctx, globalFilter, c1.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, h1.ID, results[0].HostID)
+	assert.Equal(t, "foo", results[0].HostDisplayName)
+	assert.Equal(t, r1.Rows, results[0].Rows)
+	assert.Nil(t, results[0].Error)
+	assert.Equal(t, h2.ID, results[1].HostID)
+	assert.Empty(t, results[1].Rows)
+	require.NotNil(t, results[1].Error)
+	assert.Equal(t, errMsg, *results[1].Error)
+
+	// team users only see the results of their hosts
+	results, err = ds.ListDistributedQueryCampaignResults(ctx, teamFilter, c1.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, h2.ID, results[0].HostID)
+
+	// the results of deleted hosts are kept for global users
+	require.NoError(t, ds.DeleteHost(ctx, h1.ID))
+	results, err = ds.ListDistributedQueryCampaignResults(ctx, globalFilter, c2.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, h1.Hostname, results[0].Hostname)
+
+	// cleanup with a date before the results keeps them
+	require.NoError(t, ds.CleanupDistributedQueryCampaignResults(ctx, time.Now().Add(-time.Hour)))
+	results, err = ds.ListDistributedQueryCampaignResults(ctx, globalFilter, c1.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.NoError(t, ds.CleanupDistributedQueryCampaignResults(ctx, time.Now().Add(time.Hour)))
+	for _, c := range []*fleet.DistributedQueryCampaign{c1, c2} {
+		results, err = ds.ListDistributedQueryCampaignResults(ctx, globalFilter, c.ID)
+		require.NoError(t, err)
+		require.Empty(t, results)
+	}
+}
+
 func checkTargets(t *testing.T, ds fleet.Datastore, campaignID uint, expectedTargets fleet.HostTargets) {
 	targets, err := ds.DistributedQueryCampaignTargetIDs(context.Background(), campaignID)
 	require.Nil(t, err)
@@ -0,0 +1,185 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+// hostMergeRefs are the tables of the host history that is moved to the host
+// it is merged into. On conflicts, the rows of the host it is merged into are
+// kept and the rows of the merged host are deleted with it.
+//
+// Defined here for testing purposes.
+var hostMergeRefs = []string{
+	"policy_membership",
+	"policy_membership_history",
+	"policy_waiver_hosts",
+	"host_software",
+	"host_software_changes",
+	"host_tags",
+	"host_script_executions",
+	"host_vitals_history",
+	"label_membership",
+}
+
+func (ds *Datastore) FindDuplicateHosts(ctx context.Context, hostID uint, hardwareSerial, uuid string) ([]*fleet.Host, error) {
+	if hardwareSerial == "" && uuid == "" {
+		return nil, nil
+	}
+
+	stmt := `
+		SELECT
+			h.id,
+			h.osquery_host_id,
+			h.created_at,
+			h.hostname,
+			h.computer_name,
+			h.uuid,
+			h.hardware_serial,
+			h.platform,
+			h.team_id,
+			COALESCE(hst.seen_time, h.created_at) AS seen_time
+		FROM
+			hosts h
+			LEFT JOIN host_seen_times hst ON hst.host_id = h.id
+		WHERE
+			h.id != ? AND
+			((? != '' AND h.hardware_serial = ?) OR (? != '' AND h.uuid = ?))
+		ORDER BY
+			h.id`
+	var hosts []*fleet.Host
+	if err := sqlx.SelectContext(ctx, ds.reader, &hosts, stmt, hostID, hardwareSerial, hardwareSerial, uuid, uuid); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "find duplicate hosts")
+	}
+	return hosts, nil
+}
+
+func (ds *Datastore) NewHostDuplicate(ctx context.Context, hostID, duplicateHostID uint, matchedBy string) error {
+	stmt := `
+		INSERT INTO host_duplicates (host_id, duplicate_host_id, matched_by)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE matched_by = VALUES(matched_by)`
+	if _, err := ds.writer.ExecContext(ctx, stmt, hostID, duplicateHostID, matchedBy); err != nil {
+		return ctxerr.Wrap(ctx, err, "insert host duplicate")
+	}
+	return nil
+}
+
+const hostDuplicatesSelect = `
+		SELECT
+			hd.id,
+			hd.created_at,
+			hd.host_id,
+			h.hostname AS host_hostname,
+			hd.duplicate_host_id,
+			dh.hostname AS duplicate_hostname,
+			hd.matched_by
+		FROM
+			host_duplicates hd
+			JOIN hosts h ON h.id = hd.host_id
+			JOIN hosts dh ON dh.id = hd.duplicate_host_id`
+
+func (ds *Datastore) ListHostDuplicates(ctx context.Context, filter fleet.TeamFilter) ([]*fleet.HostDuplicate, error) {
+	stmt := fmt.Sprintf(hostDuplicatesSelect+`
+		WHERE
+			%s AND %s
+		ORDER BY
+			hd.id`, ds.whereFilterHostsByTeams(filter, "h"), ds.whereFilterHostsByTeams(filter, "dh"))
+	var dups []*fleet.HostDuplicate
+	if err := sqlx.SelectContext(ctx, ds.reader, &dups, stmt); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list host duplicates")
+	}
+	return dups, nil
+}
+
+func (ds *Datastore) HostDuplicate(ctx context.Context, id uint) (*fleet.HostDuplicate, error) {
+	var dup fleet.HostDuplicate
+	if err := sqlx.GetContext(ctx, ds.reader, &dup, hostDuplicatesSelect+` WHERE hd.id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("HostDuplicate").WithID(id))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "get host duplicate")
+	}
+	return &dup, nil
+}
+
+func (ds *Datastore) DeleteHostDuplicate(ctx context.Context, id uint) error {
+	res, err := ds.writer.ExecContext(ctx, `DELETE FROM host_duplicates WHERE id = ?`, id)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "delete host duplicate")
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ctxerr.Wrap(ctx, notFound("HostDuplicate").WithID(id))
+	}
+	return nil
+}
+
+func (ds *Datastore) MergeHosts(ctx context.Context, fromHostID, toHostID uint) error {
+	if fromHostID == toHostID {
+		return ctxerr.New(ctx, "cannot merge a host into itself")
+	}
+
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		var count int
+		if err := sqlx.GetContext(ctx, tx, &count, `SELECT COUNT(*) FROM hosts WHERE id IN (?, ?)`, fromHostID, toHostID); err != nil {
+			return ctxerr.Wrap(ctx, err, "check merged hosts")
+		}
+		if count != 2 {
+			return ctxerr.Wrap(ctx, notFound("Host").WithMessage(fmt.Sprintf("hosts %d and %d", fromHostID, toHostID)))
+		}
+
+		// keep the last time the software was opened if only the merged host
+		// has it.
+		_, err := tx.ExecContext(ctx, `
+			UPDATE host_software hs
+			JOIN host_software fhs ON fhs.software_id = hs.software_id AND fhs.host_id = ?
+			SET hs.last_opened_at = fhs.last_opened_at
+			WHERE hs.host_id = ? AND hs.last_opened_at IS NULL`, fromHostID, toHostID)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "merge host software last opened at")
+		}
+
+		for _, table := range hostMergeRefs {
+			// IGNORE skips the rows that conflict with the rows of the host it is
+			// merged into, they are deleted with the merged host.
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE IGNORE %s SET host_id = ? WHERE host_id = ?`, table), toHostID, fromHostID)
+			if err != nil {
+				return ctxerr.Wrapf(ctx, err, "merge %s", table)
+			}
+		}
+
+		// host_emails has no unique key, so the emails the host it is merged into
+		// already has are deleted first.
+		_, err = tx.ExecContext(ctx, `
+			DELETE fhe FROM host_emails fhe
+			JOIN host_emails he ON he.email = fhe.email AND he.source = fhe.source AND he.host_id = ?
+			WHERE fhe.host_id = ?`, toHostID, fromHostID)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "delete duplicate host_emails")
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE host_emails SET host_id = ? WHERE host_id = ?`, toHostID, fromHostID)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "merge host_emails")
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE hosts h
+			JOIN hosts fh ON fh.id = ?
+			SET h.team_id = fh.team_id
+			WHERE h.id = ? AND h.team_id IS NULL`, fromHostID, toHostID)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "merge host team")
+		}
+
+		if err := updateHostTagLabelsMembershipDB(ctx, tx, nil, []uint{toHostID}); err != nil {
+			return err
+		}
+
+		return deleteHostDB(ctx, tx, fromHostID)
+	})
+}
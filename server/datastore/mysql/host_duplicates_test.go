@@ -0,0 +1,193 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostDuplicates(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"FindAndFlag", testHostDuplicatesFindAndFlag},
+		{"Merge", testHostDuplicatesMerge},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testHostDuplicatesFindAndFlag(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+
+	old := newTestHostWithPlatform(t, ds, "old", "darwin", nil)
+	old.HardwareSerial = "C02ABC"
+	require.NoError(t, ds.UpdateHost(ctx, old))
+	other := newTestHostWithPlatform(t, ds, "other", "darwin", &team.ID)
+	other.HardwareSerial = "C02XYZ"
+	require.NoError(t, ds.UpdateHost(ctx, other))
+	host := newTestHostWithPlatform(t, ds, "new", "darwin", &team.ID)
+
+	dups, err := ds.FindDuplicateHosts(ctx, host.ID, "C02ABC", other.UUID)
+	require.NoError(t, err)
+	require.Len(t, dups, 2)
+	require.Equal(t, old.ID, dups[0].ID)
+	require.Equal(t, "C02ABC", dups[0].HardwareSerial)
+	require.Equal(t, other.ID, dups[1].ID)
+	require.Equal(t, team.ID, *dups[1].TeamID)
+	// the last time the hosts were seen is loaded to check if they still run
+	require.WithinDuration(t, time.Now(), dups[0].SeenTime, time.Minute)
+
+	// the host itself and empty identifiers do not match
+	dups, err = ds.FindDuplicateHosts(ctx, old.ID, "C02ABC", "")
+	require.NoError(t, err)
+	require.Empty(t, dups)
+	dups, err = ds.FindDuplicateHosts(ctx, host.ID, "", "")
+	require.NoError(t, err)
+	require.Empty(t, dups)
+
+	require.NoError(t, ds.NewHostDuplicate(ctx, host.ID, old.ID, fleet.HostDuplicateMatchHardwareSerial))
+	require.NoError(t, ds.NewHostDuplicate(ctx, host.ID, other.ID, fleet.HostDuplicateMatchUUID))
+	// flagging a pair again is a no-op
+	require.NoError(t, ds.NewHostDuplicate(ctx, host.ID, old.ID, fleet.HostDuplicateMatchHardwareSerial))
+
+	admin := test.NewUser(t, ds, "Admin", "admin@example.com", true)
+	list, err := ds.ListHostDuplicates(ctx, fleet.TeamFilter{User: admin})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, host.ID, list[0].HostID)
+	require.Equal(t, "new", list[0].HostHostname)
+	require.Equal(t, old.ID, list[0].DuplicateHostID)
+	require.Equal(t, "old", list[0].DuplicateHostname)
+	require.Equal(t, fleet.HostDuplicateMatchHardwareSerial, list[0].MatchedBy)
+
+	// team users only see the pairs of hosts of their teams
+	teamUser := &fleet.User{Teams: []fleet.UserTeam{{Team: *team, Role: fleet.RoleAdmin}}}
+	list, err = ds.ListHostDuplicates(ctx, fleet.TeamFilter{User: teamUser})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, other.ID, list[0].DuplicateHostID)
+
+	dup, err := ds.HostDuplicate(ctx, list[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, list[0], dup)
+
+	require.NoError(t, ds.DeleteHostDuplicate(ctx, dup.ID))
+	var nfe fleet.NotFoundError
+	_, err = ds.HostDuplicate(ctx, dup.ID)
+	require.ErrorAs(t, err, &nfe)
+	err = ds.DeleteHostDuplicate(ctx, dup.ID)
+	require.ErrorAs(t, err, &nfe)
+
+	// deleting a host deletes its flags
+	require.NoError(t, ds.DeleteHost(ctx, old.ID))
+	list, err = ds.ListHostDuplicates(ctx, fleet.TeamFilter{User: admin})
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func testHostDuplicatesMerge(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	old := newTestHostWithPlatform(t, ds, "old", "darwin", &team.ID)
+	host := newTestHostWithPlatform(t, ds, "new", "darwin", nil)
+
+	policy, err := ds.NewGlobalPolicy(ctx, nil, fleet.PolicyPayload{Name: "p1", Query: "SELECT 1;"})
+	require.NoError(t, err)
+	require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, old, map[uint]*bool{policy.ID: ptr.Bool(true)}, time.Now(), false))
+
+	opened := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, ds.UpdateHostSoftware(ctx, old.ID, []fleet.Software{
+		{Name: "foo", Version: "1.0", Source: "apps", LastOpenedAt: &opened},
+		{Name: "bar", Version: "2.0", Source: "apps"},
+	}))
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host.ID, []fleet.Software{
+		{Name: "foo", Version: "1.0", Source: "apps"},
+	}))
+
+	require.NoError(t, ds.ReplaceHostDeviceMapping(ctx, old.ID, []*fleet.HostDeviceMapping{
+		{HostID: old.ID, Email: "alice@example.com", Source: "google_chrome_profiles"},
+		{HostID: old.ID, Email: "bob@example.com", Source: "google_chrome_profiles"},
+	}))
+	require.NoError(t, ds.ReplaceHostDeviceMapping(ctx, host.ID, []*fleet.HostDeviceMapping{
+		{HostID: host.ID, Email: "alice@example.com", Source: "google_chrome_profiles"},
+	}))
+
+	require.NoError(t, ds.ApplyHostTagKeys(ctx, []*fleet.HostTagKey{{Name: "owner", Type: fleet.HostTagTypeString}}))
+	require.NoError(t, ds.SetHostTags(ctx, []uint{old.ID}, fleet.HostTagValues{"owner": ptr.String("alice")}))
+
+	require.NoError(t, ds.RecordHostVitals(ctx, &fleet.HostVitals{HostID: old.ID, OSVersion: "macOS 12.6", RecordedAt: time.Now().Add(-time.Hour)}, time.Hour))
+
+	label, err := ds.NewLabel(ctx, &fleet.Label{Name: "manual", Query: "", LabelMembershipType: fleet.LabelMembershipTypeManual})
+	require.NoError(t, err)
+	require.NoError(t, ds.RecordLabelQueryExecutions(ctx, old, map[uint]*bool{label.ID: ptr.Bool(true)}, time.Now(), false))
+
+	var nfe fleet.NotFoundError
+	err = ds.MergeHosts(ctx, old.ID, host.ID+100)
+	require.ErrorAs(t, err, &nfe)
+	require.Error(t, ds.MergeHosts(ctx, host.ID, host.ID))
+
+	require.NoError(t, ds.MergeHosts(ctx, old.ID, host.ID))
+
+	_, err = ds.Host(ctx, old.ID)
+	require.ErrorAs(t, err, &nfe)
+
+	merged, err := ds.Host(ctx, host.ID)
+	require.NoError(t, err)
+	require.NotNil(t, merged.TeamID)
+	require.Equal(t, team.ID, *merged.TeamID)
+	require.NotNil(t, merged.Tags)
+	require.JSONEq(t, `{"owner": "alice"}`, string(*merged.Tags))
+
+	policies, err := ds.ListPoliciesForHost(ctx, merged)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	require.Equal(t, "pass", policies[0].Response)
+
+	vitals, err := ds.ListHostVitals(ctx, merged.ID, fleet.HostVitalsListOptions{})
+	require.NoError(t, err)
+	require.Len(t, vitals, 1)
+	require.Equal(t, "macOS 12.6", vitals[0].OSVersion)
+
+	require.NoError(t, ds.LoadHostSoftware(ctx, merged, false))
+	require.Len(t, merged.Software, 2)
+	for _, sw := range merged.Software {
+		if sw.Name == "foo" {
+			require.NotNil(t, sw.LastOpenedAt)
+			require.WithinDuration(t, opened, *sw.LastOpenedAt, time.Second)
+		}
+	}
+
+	mappings, err := ds.ListHostDeviceMapping(ctx, host.ID)
+	require.NoError(t, err)
+	require.Len(t, mappings, 2)
+
+	labels, err := ds.ListLabelsForHost(ctx, host.ID)
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	require.Equal(t, label.ID, labels[0].ID)
+
+	// the rows of the merged host are all gone
+	for _, table := range hostRefs {
+		var count int
+		require.NoError(t, ds.writer.Get(&count, `SELECT COUNT(*) FROM `+table+` WHERE host_id = ?`, old.ID), table)
+		require.Zero(t, count, table)
+	}
+}
@@ -0,0 +1,129 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+func (ds *Datastore) SetHostsLifecycleState(ctx context.Context, hostIDs []uint, state fleet.HostLifecycleState, quarantineTeamID *uint) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		if state == fleet.HostLifecycleStateRetired {
+			// the retired hosts do not run the policies anymore, their results are
+			// deleted so that they are not counted.
+			stmt, args, err := sqlx.In(`DELETE FROM policy_membership WHERE host_id IN (?)`, hostIDs)
+			if err != nil {
+				return ctxerr.Wrap(ctx, err, "build delete policy membership")
+			}
+			if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+				return ctxerr.Wrap(ctx, err, "delete policy membership of retired hosts")
+			}
+		} else {
+			// the hosts that are no longer retired run the policies at their next
+			// check in.
+			stmt, args, err := sqlx.In(`
+				UPDATE hosts
+				SET policy_updated_at = timestamp(?)
+				WHERE id IN (?) AND lifecycle_state = ?`,
+				pastDate, hostIDs, fleet.HostLifecycleStateRetired)
+			if err != nil {
+				return ctxerr.Wrap(ctx, err, "build reset policy_updated_at")
+			}
+			if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+				return ctxerr.Wrap(ctx, err, "reset policy_updated_at of unretired hosts")
+			}
+		}
+
+		if state == fleet.HostLifecycleStateQuarantined {
+			if quarantineTeamID != nil {
+				if err := moveHostsToQuarantineTeamDB(ctx, tx, hostIDs, *quarantineTeamID); err != nil {
+					return err
+				}
+			}
+		} else if err := restoreHostsQuarantineTeamDB(ctx, tx, hostIDs); err != nil {
+			return err
+		}
+
+		stmt, args, err := sqlx.In(`UPDATE hosts SET lifecycle_state = ? WHERE id IN (?)`, state, hostIDs)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "build update lifecycle_state")
+		}
+		if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+			return ctxerr.Wrap(ctx, err, "update hosts lifecycle_state")
+		}
+		return nil
+	})
+}
+
+// moveHostsToQuarantineTeamDB moves the hosts to the quarantine team and
+// records the team they were in, so that it is restored when they leave the
+// quarantine. The team of the hosts that are already quarantined is kept.
+func moveHostsToQuarantineTeamDB(ctx context.Context, tx sqlx.ExtContext, hostIDs []uint, teamID uint) error {
+	stmt, args, err := sqlx.In(`
+		INSERT IGNORE INTO host_quarantine_teams (host_id, team_id)
+		SELECT id, team_id FROM hosts WHERE id IN (?) AND lifecycle_state <> ?`,
+		hostIDs, fleet.HostLifecycleStateQuarantined)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build insert host quarantine teams")
+	}
+	if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "insert host quarantine teams")
+	}
+
+	if err := cleanupPolicyMembershipOnTeamChange(ctx, tx, hostIDs); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete policy membership of quarantined hosts")
+	}
+	stmt, args, err = sqlx.In(`UPDATE hosts SET team_id = ? WHERE id IN (?)`, teamID, hostIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build update quarantined hosts team")
+	}
+	if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "update quarantined hosts team")
+	}
+	return nil
+}
+
+// restoreHostsQuarantineTeamDB moves the hosts that leave the quarantine back
+// to the team they were in before they were moved to the quarantine team.
+func restoreHostsQuarantineTeamDB(ctx context.Context, tx sqlx.ExtContext, hostIDs []uint) error {
+	stmt, args, err := sqlx.In(`SELECT host_id FROM host_quarantine_teams WHERE host_id IN (?)`, hostIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build select host quarantine teams")
+	}
+	var restoredIDs []uint
+	if err := sqlx.SelectContext(ctx, tx, &restoredIDs, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "select host quarantine teams")
+	}
+	if len(restoredIDs) == 0 {
+		return nil
+	}
+
+	if err := cleanupPolicyMembershipOnTeamChange(ctx, tx, restoredIDs); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete policy membership of unquarantined hosts")
+	}
+	stmt, args, err = sqlx.In(`
+		UPDATE hosts h
+		JOIN host_quarantine_teams hqt ON hqt.host_id = h.id
+		SET h.team_id = hqt.team_id
+		WHERE h.id IN (?)`, restoredIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build restore hosts team")
+	}
+	if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "restore hosts team")
+	}
+	stmt, args, err = sqlx.In(`DELETE FROM host_quarantine_teams WHERE host_id IN (?)`, restoredIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build delete host quarantine teams")
+	}
+	if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete host quarantine teams")
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostLifecycle(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"SetAndFilter", testHostLifecycleSetAndFilter},
+		{"Summary", testHostLifecycleSummary},
+		{"Policies", testHostLifecyclePolicies},
+		{"QuarantineTeam", testHostLifecycleQuarantineTeam},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testHostLifecycleSetAndFilter(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	filter := fleet.TeamFilter{User: user}
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+	host3 := newTestHostWithPlatform(t, ds, "host3", "windows", nil)
+
+	// the hosts are active when they enroll
+	h, err := ds.Host(ctx, host1.ID)
+	require.NoError(t, err)
+	require.Equal(t, fleet.HostLifecycleStateActive, h.LifecycleState)
+
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, nil, fleet.HostLifecycleStateRetired, nil))
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID, host2.ID}, fleet.HostLifecycleStateQuarantined, nil))
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host2.ID}, fleet.HostLifecycleStateInRepair, nil))
+
+	h, err = ds.Host(ctx, host1.ID)
+	require.NoError(t, err)
+	require.Equal(t, fleet.HostLifecycleStateQuarantined, h.LifecycleState)
+	h, err = ds.HostLite(ctx, host2.ID)
+	require.NoError(t, err)
+	require.Equal(t, fleet.HostLifecycleStateInRepair, h.LifecycleState)
+
+	hosts := listHostsCheckCount(t, ds, filter, fleet.HostListOptions{}, 3)
+	for _, h := range hosts {
+		require.NotEmpty(t, h.LifecycleState)
+	}
+	for state, want := range map[fleet.HostLifecycleState][]uint{
+		fleet.HostLifecycleStateActive:      {host3.ID},
+		fleet.HostLifecycleStateQuarantined: {host1.ID},
+		fleet.HostLifecycleStateInRepair:    {host2.ID},
+		fleet.HostLifecycleStateRetired:     {},
+	} {
+		state := state
+		hosts := listHostsCheckCount(t, ds, filter, fleet.HostListOptions{LifecycleStateFilter: &state}, len(want))
+		got := make([]uint, 0, len(hosts))
+		for _, h := range hosts {
+			got = append(got, h.ID)
+		}
+		require.ElementsMatch(t, want, got, state)
+	}
+}
+
+func testHostLifecycleSummary(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	filter := fleet.TeamFilter{User: user}
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "ubuntu", nil)
+	newTestHostWithPlatform(t, ds, "host3", "ubuntu", nil)
+
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID}, fleet.HostLifecycleStateRetired, nil))
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host2.ID}, fleet.HostLifecycleStateQuarantined, nil))
+
+	// the retired hosts are only counted by lifecycle state
+	summary, err := ds.GenerateHostStatusStatistics(ctx, filter, time.Now(), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint(2), summary.TotalsHostsCount)
+	require.Equal(t, uint(2), summary.OnlineCount)
+	require.Equal(t, uint(1), summary.RetiredCount)
+	require.Equal(t, uint(1), summary.QuarantinedCount)
+	require.Zero(t, summary.InRepairCount)
+	require.Len(t, summary.Platforms, 1)
+	require.Equal(t, "ubuntu", summary.Platforms[0].Platform)
+
+	summary, err = ds.GenerateHostStatusStatistics(ctx, filter, time.Now(), ptr.String("darwin"), nil)
+	require.NoError(t, err)
+	require.Zero(t, summary.TotalsHostsCount)
+	require.Equal(t, uint(1), summary.RetiredCount)
+	require.Zero(t, summary.QuarantinedCount)
+
+	total, _, err := ds.TotalAndUnseenHostsSince(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+}
+
+func testHostLifecyclePolicies(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+
+	policy, err := ds.NewGlobalPolicy(ctx, nil, fleet.PolicyPayload{Name: "p1", Query: "SELECT 1;"})
+	require.NoError(t, err)
+	require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, host1, map[uint]*bool{policy.ID: ptr.Bool(false)}, time.Now(), false))
+	require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, host2, map[uint]*bool{policy.ID: ptr.Bool(false)}, time.Now(), false))
+
+	// the results of the retired hosts are deleted
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID}, fleet.HostLifecycleStateRetired, nil))
+	var count int
+	require.NoError(t, ds.writer.GetContext(ctx, &count, `SELECT COUNT(*) FROM policy_membership WHERE policy_id = ?`, policy.ID))
+	require.Equal(t, 1, count)
+
+	// the hosts that are no longer retired run the policies at their next
+	// check in, the other hosts are not affected
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID, host2.ID}, fleet.HostLifecycleStateInRepair, nil))
+	h, err := ds.Host(ctx, host1.ID)
+	require.NoError(t, err)
+	require.True(t, h.PolicyUpdatedAt.Before(time.Now().Add(-24*time.Hour)), h.PolicyUpdatedAt)
+	h, err = ds.Host(ctx, host2.ID)
+	require.NoError(t, err)
+	require.True(t, h.PolicyUpdatedAt.After(time.Now().Add(-time.Hour)), h.PolicyUpdatedAt)
+}
+
+func testHostLifecycleQuarantineTeam(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	team1, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	quarantine, err := ds.NewTeam(ctx, &fleet.Team{Name: "quarantine"})
+	require.NoError(t, err)
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+	require.NoError(t, ds.AddHostsToTeam(ctx, &team1.ID, []uint{host1.ID}))
+
+	checkTeam := func(hostID uint, want *uint) {
+		h, err := ds.HostLite(ctx, hostID)
+		require.NoError(t, err)
+		require.Equal(t, want, h.TeamID)
+	}
+
+	// the quarantined hosts are moved to the quarantine team
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID, host2.ID}, fleet.HostLifecycleStateQuarantined, &quarantine.ID))
+	checkTeam(host1.ID, &quarantine.ID)
+	checkTeam(host2.ID, &quarantine.ID)
+
+	// quarantining them again does not lose the team they were in
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID}, fleet.HostLifecycleStateQuarantined, &quarantine.ID))
+
+	// the hosts that leave the quarantine are moved back to their team
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID, host2.ID}, fleet.HostLifecycleStateActive, nil))
+	checkTeam(host1.ID, &team1.ID)
+	checkTeam(host2.ID, nil)
+
+	// the hosts that were not quarantined keep their team
+	require.NoError(t, ds.AddHostsToTeam(ctx, &quarantine.ID, []uint{host2.ID}))
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host2.ID}, fleet.HostLifecycleStateInRepair, nil))
+	checkTeam(host2.ID, &quarantine.ID)
+}
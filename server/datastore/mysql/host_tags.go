@@ -0,0 +1,241 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+// hostTagsJSON aggregates the host tags (ht) and their keys (htk) as a JSON
+// object of the values by key name. It is built with GROUP_CONCAT as
+// JSON_OBJECTAGG is not available in all the supported MySQL versions.
+const hostTagsJSON = `CONCAT('{', GROUP_CONCAT(CONCAT(JSON_QUOTE(htk.name), ':', JSON_QUOTE(ht.value))), '}')`
+
+// hostTagsJoin joins the host tags of each host in the htags.tags column (NULL
+// if the host has no tags). The hosts table must be aliased to h.
+const hostTagsJoin = `LEFT JOIN (
+		SELECT
+			ht.host_id,
+			` + hostTagsJSON + ` AS tags
+		FROM
+			host_tags ht
+			JOIN host_tag_keys htk ON htk.id = ht.key_id
+		GROUP BY
+			ht.host_id) htags ON htags.host_id = h.id`
+
+// hostTagsBatchSize is the number of hosts whose tags are set per statement.
+const hostTagsBatchSize = 1000
+
+func filterHostsByTags(sql string, opt fleet.HostListOptions, params []interface{}) (string, []interface{}) {
+	for _, f := range opt.HostTagFilters {
+		if f.Value == nil {
+			sql += ` AND EXISTS (SELECT 1 FROM host_tags ht JOIN host_tag_keys htk ON htk.id = ht.key_id WHERE ht.host_id = h.id AND htk.name = ?)`
+			params = append(params, f.Key)
+		} else {
+			sql += ` AND EXISTS (SELECT 1 FROM host_tags ht JOIN host_tag_keys htk ON htk.id = ht.key_id WHERE ht.host_id = h.id AND htk.name = ? AND ht.value = ?)`
+			params = append(params, f.Key, *f.Value)
+		}
+	}
+	return sql, params
+}
+
+func (ds *Datastore) ListHostTagKeys(ctx context.Context) ([]*fleet.HostTagKey, error) {
+	stmt := `
+		SELECT
+			htk.id,
+			htk.name,
+			htk.type,
+			htk.description,
+			htk.created_at,
+			htk.updated_at,
+			(SELECT COUNT(*) FROM host_tags ht WHERE ht.key_id = htk.id) AS host_count
+		FROM
+			host_tag_keys htk
+		ORDER BY
+			htk.name`
+	var keys []*fleet.HostTagKey
+	if err := sqlx.SelectContext(ctx, ds.reader, &keys, stmt); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list host tag keys")
+	}
+	return keys, nil
+}
+
+func (ds *Datastore) ApplyHostTagKeys(ctx context.Context, keys []*fleet.HostTagKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	stmt := `
+		INSERT INTO host_tag_keys (name, type, description)
+		VALUES %s
+		ON DUPLICATE KEY UPDATE
+			type = VALUES(type),
+			description = VALUES(description)`
+	values := strings.TrimSuffix(strings.Repeat("(?, ?, ?),", len(keys)), ",")
+	args := make([]interface{}, 0, len(keys)*3)
+	for _, k := range keys {
+		args = append(args, k.Name, k.Type, k.Description)
+	}
+	if _, err := ds.writer.ExecContext(ctx, fmt.Sprintf(stmt, values), args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "apply host tag keys")
+	}
+	return nil
+}
+
+func (ds *Datastore) DeleteHostTagKey(ctx context.Context, name string) error {
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		// the tags of the key are deleted by the foreign key cascade
+		res, err := tx.ExecContext(ctx, `DELETE FROM host_tag_keys WHERE name = ?`, name)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "delete host tag key")
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ctxerr.Wrap(ctx, notFound("HostTagKey").WithName(name))
+		}
+
+		var labelIDs []uint
+		if err := sqlx.SelectContext(ctx, tx, &labelIDs,
+			`SELECT id FROM labels WHERE label_membership_type = ? AND host_tag_key = ?`,
+			fleet.LabelMembershipTypeHostTag, name,
+		); err != nil {
+			return ctxerr.Wrap(ctx, err, "select labels of host tag key")
+		}
+		if len(labelIDs) == 0 {
+			return nil
+		}
+		return updateHostTagLabelsMembershipDB(ctx, tx, labelIDs, nil)
+	})
+}
+
+func (ds *Datastore) SetHostTags(ctx context.Context, hostIDs []uint, tags fleet.HostTagValues) error {
+	if len(hostIDs) == 0 || len(tags) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		stmt, args, err := sqlx.In(`SELECT id, name FROM host_tag_keys WHERE name IN (?)`, names)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "build select host tag keys")
+		}
+		var keys []*fleet.HostTagKey
+		if err := sqlx.SelectContext(ctx, tx, &keys, stmt, args...); err != nil {
+			return ctxerr.Wrap(ctx, err, "select host tag keys")
+		}
+		keyIDs := make(map[string]uint, len(keys))
+		for _, k := range keys {
+			keyIDs[k.Name] = k.ID
+		}
+
+		for name, value := range tags {
+			keyID, ok := keyIDs[name]
+			if !ok {
+				return ctxerr.Wrap(ctx, notFound("HostTagKey").WithName(name))
+			}
+
+			for start := 0; start < len(hostIDs); start += hostTagsBatchSize {
+				end := start + hostTagsBatchSize
+				if end > len(hostIDs) {
+					end = len(hostIDs)
+				}
+				batch := hostIDs[start:end]
+
+				if value == nil {
+					stmt, args, err := sqlx.In(`DELETE FROM host_tags WHERE key_id = ? AND host_id IN (?)`, keyID, batch)
+					if err != nil {
+						return ctxerr.Wrap(ctx, err, "build delete host tags")
+					}
+					if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+						return ctxerr.Wrap(ctx, err, "delete host tags")
+					}
+					continue
+				}
+
+				values := strings.TrimSuffix(strings.Repeat("(?, ?, ?),", len(batch)), ",")
+				args := make([]interface{}, 0, len(batch)*3)
+				for _, hostID := range batch {
+					args = append(args, hostID, keyID, *value)
+				}
+				stmt := fmt.Sprintf(`
+					INSERT INTO host_tags (host_id, key_id, value)
+					VALUES %s
+					ON DUPLICATE KEY UPDATE value = VALUES(value)`, values)
+				if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+					return ctxerr.Wrap(ctx, err, "insert host tags")
+				}
+			}
+		}
+
+		return updateHostTagLabelsMembershipDB(ctx, tx, nil, hostIDs)
+	})
+}
+
+// updateHostTagLabelsMembershipDB recomputes the membership of the labels with
+// the host_tag membership type from the host tags. It is restricted to the
+// labels and to the hosts if they are provided, otherwise it applies to all
+// the host_tag labels and to all hosts.
+func updateHostTagLabelsMembershipDB(ctx context.Context, tx sqlx.ExtContext, labelIDs []uint, hostIDs []uint) error {
+	update := func(hostIDs []uint) error {
+		delStmt := `
+			DELETE lm FROM label_membership lm
+			JOIN labels l ON l.id = lm.label_id
+			WHERE l.label_membership_type = ?`
+		insStmt := `
+			INSERT IGNORE INTO label_membership (label_id, host_id)
+			SELECT l.id, ht.host_id
+			FROM labels l
+			JOIN host_tag_keys htk ON htk.name = l.host_tag_key
+			JOIN host_tags ht ON ht.key_id = htk.id AND (l.host_tag_value IS NULL OR ht.value = l.host_tag_value)
+			WHERE l.label_membership_type = ?`
+		args := []interface{}{fleet.LabelMembershipTypeHostTag}
+		if len(labelIDs) > 0 {
+			delStmt += ` AND l.id IN (?)`
+			insStmt += ` AND l.id IN (?)`
+			args = append(args, labelIDs)
+		}
+		if len(hostIDs) > 0 {
+			delStmt += ` AND lm.host_id IN (?)`
+			insStmt += ` AND ht.host_id IN (?)`
+			args = append(args, hostIDs)
+		}
+
+		for _, s := range []struct {
+			stmt string
+			desc string
+		}{
+			{delStmt, "delete host tag labels membership"},
+			{insStmt, "insert host tag labels membership"},
+		} {
+			stmt, stmtArgs, err := sqlx.In(s.stmt, args...)
+			if err != nil {
+				return ctxerr.Wrap(ctx, err, "build "+s.desc)
+			}
+			if _, err := tx.ExecContext(ctx, stmt, stmtArgs...); err != nil {
+				return ctxerr.Wrap(ctx, err, s.desc)
+			}
+		}
+		return nil
+	}
+
+	if len(hostIDs) == 0 {
+		return update(nil)
+	}
+	for start := 0; start < len(hostIDs); start += hostTagsBatchSize {
+		end := start + hostTagsBatchSize
+		if end > len(hostIDs) {
+			end = len(hostIDs)
+		}
+		if err := update(hostIDs[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
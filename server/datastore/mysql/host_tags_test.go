@@ -0,0 +1,237 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostTags(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"Keys", testHostTagsKeys},
+		{"SetAndFilter", testHostTagsSetAndFilter},
+		{"Labels", testHostTagsLabels},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testHostTagsKeys(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	host := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+
+	keys, err := ds.ListHostTagKeys(ctx)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	err = ds.ApplyHostTagKeys(ctx, []*fleet.HostTagKey{
+		{Name: "owner", Type: fleet.HostTagTypeString, Description: "Asset owner"},
+		{Name: "cost_center", Type: fleet.HostTagTypeNumber},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ds.SetHostTags(ctx, []uint{host.ID}, fleet.HostTagValues{"owner": ptr.String("alice")}))
+
+	keys, err = ds.ListHostTagKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, "cost_center", keys[0].Name)
+	require.Equal(t, fleet.HostTagTypeNumber, keys[0].Type)
+	require.Zero(t, keys[0].HostCount)
+	require.Equal(t, "owner", keys[1].Name)
+	require.Equal(t, "Asset owner", keys[1].Description)
+	require.EqualValues(t, 1, keys[1].HostCount)
+
+	// applying again updates the existing keys
+	err = ds.ApplyHostTagKeys(ctx, []*fleet.HostTagKey{
+		{Name: "cost_center", Type: fleet.HostTagTypeString, Description: "Finance code"},
+	})
+	require.NoError(t, err)
+	keys, err = ds.ListHostTagKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, fleet.HostTagTypeString, keys[0].Type)
+	require.Equal(t, "Finance code", keys[0].Description)
+
+	// deleting a key untags its hosts
+	require.NoError(t, ds.DeleteHostTagKey(ctx, "owner"))
+	keys, err = ds.ListHostTagKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	h, err := ds.Host(ctx, host.ID)
+	require.NoError(t, err)
+	require.Nil(t, h.Tags)
+
+	var nfe fleet.NotFoundError
+	err = ds.DeleteHostTagKey(ctx, "owner")
+	require.ErrorAs(t, err, &nfe)
+}
+
+func testHostTagsSetAndFilter(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	filter := fleet.TeamFilter{User: user}
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+	host3 := newTestHostWithPlatform(t, ds, "host3", "darwin", nil)
+
+	err := ds.ApplyHostTagKeys(ctx, []*fleet.HostTagKey{
+		{Name: "owner", Type: fleet.HostTagTypeString},
+		{Name: "critical", Type: fleet.HostTagTypeBoolean},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, ds.SetHostTags(ctx, []uint{host1.ID, host2.ID}, fleet.HostTagValues{
+		"owner":    ptr.String("alice"),
+		"critical": ptr.String("true"),
+	}))
+	require.NoError(t, ds.SetHostTags(ctx, []uint{host2.ID}, fleet.HostTagValues{
+		"owner":    ptr.String("bob"),
+		"critical": nil,
+	}))
+
+	var nfe fleet.NotFoundError
+	err = ds.SetHostTags(ctx, []uint{host3.ID}, fleet.HostTagValues{"env": ptr.String("prod")})
+	require.ErrorAs(t, err, &nfe)
+
+	getTags := func(h *fleet.Host) map[string]string {
+		if h.Tags == nil {
+			return nil
+		}
+		var tags map[string]string
+		require.NoError(t, json.Unmarshal(*h.Tags, &tags))
+		return tags
+	}
+	h, err := ds.Host(ctx, host1.ID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"owner": "alice", "critical": "true"}, getTags(h))
+	h, err = ds.HostByIdentifier(ctx, "host2")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"owner": "bob"}, getTags(h))
+	h, err = ds.Host(ctx, host3.ID)
+	require.NoError(t, err)
+	require.Nil(t, getTags(h))
+
+	hostIDs := func(hosts []*fleet.Host) []uint {
+		ids := make([]uint, 0, len(hosts))
+		for _, h := range hosts {
+			ids = append(ids, h.ID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+	cases := []struct {
+		filters []fleet.HostTagFilter
+		want    []uint
+	}{
+		{nil, []uint{host1.ID, host2.ID, host3.ID}},
+		{[]fleet.HostTagFilter{{Key: "owner"}}, []uint{host1.ID, host2.ID}},
+		{[]fleet.HostTagFilter{{Key: "owner", Value: ptr.String("bob")}}, []uint{host2.ID}},
+		{[]fleet.HostTagFilter{{Key: "owner"}, {Key: "critical", Value: ptr.String("true")}}, []uint{host1.ID}},
+		{[]fleet.HostTagFilter{{Key: "env"}}, []uint{}},
+	}
+	for _, c := range cases {
+		hosts := listHostsCheckCount(t, ds, filter, fleet.HostListOptions{HostTagFilters: c.filters}, len(c.want))
+		require.Equal(t, c.want, hostIDs(hosts), c.filters)
+	}
+
+	// the tags are only joined if requested
+	hosts := listHostsCheckCount(t, ds, filter, fleet.HostListOptions{HostTagFilters: []fleet.HostTagFilter{{Key: "owner", Value: ptr.String("bob")}}}, 1)
+	require.Nil(t, hosts[0].Tags)
+	hosts = listHostsCheckCount(t, ds, filter, fleet.HostListOptions{
+		IncludeTags:    true,
+		HostTagFilters: []fleet.HostTagFilter{{Key: "owner", Value: ptr.String("bob")}},
+	}, 1)
+	require.Equal(t, map[string]string{"owner": "bob"}, getTags(hosts[0]))
+
+	// deleting a host deletes its tags
+	require.NoError(t, ds.DeleteHost(ctx, host1.ID))
+	keys, err := ds.ListHostTagKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, "critical", keys[0].Name)
+	require.Zero(t, keys[0].HostCount)
+}
+
+func testHostTagsLabels(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	filter := fleet.TeamFilter{User: user}
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+
+	require.NoError(t, ds.ApplyHostTagKeys(ctx, []*fleet.HostTagKey{{Name: "owner", Type: fleet.HostTagTypeString}}))
+	require.NoError(t, ds.SetHostTags(ctx, []uint{host1.ID}, fleet.HostTagValues{"owner": ptr.String("alice")}))
+
+	// a label created with NewLabel gets the tagged hosts
+	owned, err := ds.NewLabel(ctx, &fleet.Label{
+		Name:                "owned",
+		LabelMembershipType: fleet.LabelMembershipTypeHostTag,
+		HostTagKey:          ptr.String("owner"),
+	})
+	require.NoError(t, err)
+	// a label applied with a spec gets the hosts tagged with the value
+	err = ds.ApplyLabelSpecs(ctx, []*fleet.LabelSpec{{
+		Name:                "bob's",
+		LabelMembershipType: fleet.LabelMembershipTypeHostTag,
+		HostTagKey:          ptr.String("owner"),
+		HostTagValue:        ptr.String("bob"),
+	}})
+	require.NoError(t, err)
+	bobSpec, err := ds.GetLabelSpec(ctx, "bob's")
+	require.NoError(t, err)
+	require.Equal(t, "owner", *bobSpec.HostTagKey)
+	require.Equal(t, "bob", *bobSpec.HostTagValue)
+	labelIDs, err := ds.LabelIDsByName(ctx, []string{"bob's"})
+	require.NoError(t, err)
+	require.Len(t, labelIDs, 1)
+	bobs := labelIDs[0]
+
+	checkMembers := func(labelID uint, want ...uint) {
+		hosts, err := ds.ListHostsInLabel(ctx, filter, labelID, fleet.HostListOptions{})
+		require.NoError(t, err)
+		got := make([]uint, 0, len(hosts))
+		for _, h := range hosts {
+			got = append(got, h.ID)
+		}
+		require.ElementsMatch(t, want, got)
+	}
+	checkMembers(owned.ID, host1.ID)
+	checkMembers(bobs)
+
+	// the membership follows the tags of the hosts
+	require.NoError(t, ds.SetHostTags(ctx, []uint{host1.ID, host2.ID}, fleet.HostTagValues{"owner": ptr.String("bob")}))
+	checkMembers(owned.ID, host1.ID, host2.ID)
+	checkMembers(bobs, host1.ID, host2.ID)
+	require.NoError(t, ds.SetHostTags(ctx, []uint{host2.ID}, fleet.HostTagValues{"owner": nil}))
+	checkMembers(owned.ID, host1.ID)
+	checkMembers(bobs, host1.ID)
+
+	// the host tag filters apply to the hosts of a label
+	hosts, err := ds.ListHostsInLabel(ctx, filter, owned.ID, fleet.HostListOptions{
+		HostTagFilters: []fleet.HostTagFilter{{Key: "owner", Value: ptr.String("alice")}},
+	})
+	require.NoError(t, err)
+	require.Empty(t, hosts)
+
+	// deleting the key empties its labels
+	require.NoError(t, ds.DeleteHostTagKey(ctx, "owner"))
+	checkMembers(owned.ID)
+	checkMembers(bobs)
+}
@@ -0,0 +1,104 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+func (ds *Datastore) RecordHostVitals(ctx context.Context, vitals *fleet.HostVitals, minInterval time.Duration) error {
+	// the sample is not recorded if the host has a sample more recent than
+	// minInterval, which down-samples the history of the hosts that report
+	// their details more often.
+	_, err := ds.writer.ExecContext(ctx, `
+		INSERT INTO host_vitals_history (
+			host_id,
+			gigs_disk_space_available,
+			percent_disk_space_available,
+			uptime,
+			osquery_version,
+			os_version,
+			recorded_at
+		)
+		SELECT ?, ?, ?, ?, ?, ?, ?
+		FROM DUAL
+		WHERE NOT EXISTS (
+			SELECT 1 FROM host_vitals_history
+			WHERE host_id = ? AND recorded_at > ?
+		)`,
+		vitals.HostID,
+		vitals.GigsDiskSpaceAvailable,
+		vitals.PercentDiskSpaceAvailable,
+		vitals.Uptime,
+		vitals.OsqueryVersion,
+		vitals.OSVersion,
+		vitals.RecordedAt,
+		vitals.HostID,
+		vitals.RecordedAt.Add(-minInterval),
+	)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "insert host vitals history")
+	}
+	return nil
+}
+
+func (ds *Datastore) ListHostVitals(ctx context.Context, hostID uint, opt fleet.HostVitalsListOptions) ([]*fleet.HostVitals, error) {
+	if opt.OrderKey == "" {
+		opt.OrderKey = "recorded_at"
+		opt.OrderDirection = fleet.OrderDescending
+	}
+
+	stmt := `
+		SELECT
+			id,
+			host_id,
+			gigs_disk_space_available,
+			percent_disk_space_available,
+			uptime,
+			osquery_version,
+			os_version,
+			recorded_at
+		FROM host_vitals_history
+		WHERE host_id = ?`
+	args := []interface{}{hostID}
+	if opt.From != nil {
+		stmt += ` AND recorded_at >= ?`
+		args = append(args, *opt.From)
+	}
+	if opt.To != nil {
+		stmt += ` AND recorded_at < ?`
+		args = append(args, *opt.To)
+	}
+	stmt, args = appendListOptionsWithCursorToSQL(stmt, args, opt.ListOptions)
+
+	var vitals []*fleet.HostVitals
+	if err := sqlx.SelectContext(ctx, ds.reader, &vitals, stmt, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list host vitals")
+	}
+	return vitals, nil
+}
+
+const hostVitalsHistoryCleanupBatch = 10000
+
+func (ds *Datastore) CleanupHostVitalsHistory(ctx context.Context, olderThan time.Time) error {
+	return ds.cleanupHostVitalsHistory(ctx, olderThan, hostVitalsHistoryCleanupBatch)
+}
+
+func (ds *Datastore) cleanupHostVitalsHistory(ctx context.Context, olderThan time.Time, batchSize int) error {
+	// delete in batches to avoid locking the table for too long
+	for {
+		res, err := ds.writer.ExecContext(ctx,
+			`DELETE FROM host_vitals_history WHERE recorded_at < ? LIMIT ?`,
+			olderThan, batchSize,
+		)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "cleanup host vitals history")
+		}
+		if n, _ := res.RowsAffected(); n < int64(batchSize) {
+			return nil
+		}
+	}
+}
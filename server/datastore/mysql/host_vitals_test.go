@@ -0,0 +1,134 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostVitals(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"RecordAndList", testHostVitalsRecordAndList},
+		{"Cleanup", testHostVitalsCleanup},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testHostVitalsRecordAndList(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+
+	start := time.Now().UTC().Truncate(time.Second).Add(-24 * time.Hour)
+	record := func(hostID uint, offset time.Duration, gigs float64, osVersion string) {
+		require.NoError(t, ds.RecordHostVitals(ctx, &fleet.HostVitals{
+			HostID:                    hostID,
+			GigsDiskSpaceAvailable:    gigs,
+			PercentDiskSpaceAvailable: gigs / 2,
+			Uptime:                    offset,
+			OsqueryVersion:            "5.5.1",
+			OSVersion:                 osVersion,
+			RecordedAt:                start.Add(offset),
+		}, time.Hour))
+	}
+
+	// the samples recorded less than an hour after the latest one are ignored
+	record(host1.ID, 0, 100, "macOS 12.5")
+	record(host1.ID, 30*time.Minute, 99, "macOS 12.5")
+	record(host1.ID, 90*time.Minute, 98, "macOS 12.6")
+	record(host1.ID, 2*time.Hour, 97, "macOS 12.6")
+	record(host1.ID, 3*time.Hour, 96, "macOS 12.6")
+	record(host2.ID, 10*time.Minute, 50, "Windows 11")
+
+	vitals, err := ds.ListHostVitals(ctx, host1.ID, fleet.HostVitalsListOptions{})
+	require.NoError(t, err)
+	require.Len(t, vitals, 3)
+	// the most recent samples are first
+	require.Equal(t, start.Add(3*time.Hour), vitals[0].RecordedAt.UTC())
+	require.Equal(t, 96.0, vitals[0].GigsDiskSpaceAvailable)
+	require.Equal(t, 48.0, vitals[0].PercentDiskSpaceAvailable)
+	require.Equal(t, 3*time.Hour, vitals[0].Uptime)
+	require.Equal(t, "5.5.1", vitals[0].OsqueryVersion)
+	require.Equal(t, "macOS 12.6", vitals[0].OSVersion)
+	require.Equal(t, start.Add(90*time.Minute), vitals[1].RecordedAt.UTC())
+	require.Equal(t, start, vitals[2].RecordedAt.UTC())
+	require.Equal(t, "macOS 12.5", vitals[2].OSVersion)
+	for _, v := range vitals {
+		require.Equal(t, host1.ID, v.HostID)
+	}
+
+	vitals, err = ds.ListHostVitals(ctx, host1.ID, fleet.HostVitalsListOptions{
+		From: ptr.Time(start.Add(time.Hour)),
+		To:   ptr.Time(start.Add(3 * time.Hour)),
+	})
+	require.NoError(t, err)
+	require.Len(t, vitals, 1)
+	require.Equal(t, 98.0, vitals[0].GigsDiskSpaceAvailable)
+
+	vitals, err = ds.ListHostVitals(ctx, host1.ID, fleet.HostVitalsListOptions{
+		ListOptions: fleet.ListOptions{OrderKey: "recorded_at", OrderDirection: fleet.OrderAscending, PerPage: 2},
+	})
+	require.NoError(t, err)
+	require.Len(t, vitals, 2)
+	require.Equal(t, start, vitals[0].RecordedAt.UTC())
+
+	vitals, err = ds.ListHostVitals(ctx, host2.ID, fleet.HostVitalsListOptions{})
+	require.NoError(t, err)
+	require.Len(t, vitals, 1)
+	require.Equal(t, "Windows 11", vitals[0].OSVersion)
+
+	vitals, err = ds.ListHostVitals(ctx, host2.ID+100, fleet.HostVitalsListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, vitals)
+}
+
+func testHostVitalsCleanup(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	host := newTestHostWithPlatform(t, ds, "host1", "ubuntu", nil)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, ts := range []time.Time{now.Add(-72 * time.Hour), now.Add(-48 * time.Hour), now.Add(-time.Hour)} {
+		require.NoError(t, ds.RecordHostVitals(ctx, &fleet.HostVitals{HostID: host.ID, RecordedAt: ts}, time.Hour))
+	}
+
+	require.NoError(t, ds.CleanupHostVitalsHistory(ctx, now.Add(-24*time.Hour)))
+
+	vitals, err := ds.ListHostVitals(ctx, host.ID, fleet.HostVitalsListOptions{})
+	require.NoError(t, err)
+	require.Len(t, vitals, 1)
+	require.Equal(t, now.Add(-time.Hour), vitals[0].RecordedAt.UTC())
+
+	// the samples are deleted in batches until none is left
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		for i := 0; i < 5; i++ {
+			if _, err := q.ExecContext(ctx, `INSERT INTO host_vitals_history (host_id, recorded_at) VALUES (?, ?)`,
+				host.ID, now.Add(-time.Duration(48+i)*time.Hour)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, ds.cleanupHostVitalsHistory(ctx, now.Add(-24*time.Hour), 2))
+
+	vitals, err = ds.ListHostVitals(ctx, host.ID, fleet.HostVitalsListOptions{})
+	require.NoError(t, err)
+	require.Len(t, vitals, 1)
+	require.Equal(t, now.Add(-time.Hour), vitals[0].RecordedAt.UTC())
+}
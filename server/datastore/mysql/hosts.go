@@ -297,12 +297,17 @@ func loadHostUsersDB(ctx context.Context, db sqlx.QueryerContext, hostID uint) (
 var hostRefs = []string{
 	"host_seen_times",
 	"host_software",
+	"host_software_changes",
+	"host_software_rule_violations",
 	"host_users",
 	"host_emails",
 	"host_additional",
 	"scheduled_query_stats",
+	"scheduled_query_results",
 	"label_membership",
 	"policy_membership",
+	"policy_membership_history",
+	"policy_waiver_hosts",
 	"host_mdm",
 	"host_munki_info",
 	"host_device_auth",
@@ -312,37 +317,43 @@ var hostRefs = []string{
 	"host_display_names",
 	"windows_updates",
 	"host_disks",
+	"host_script_executions",
+	"host_quarantine_teams",
+	"host_manual_teams",
+	"host_tags",
+	"host_vitals_history",
 }
 
 func (ds *Datastore) DeleteHost(ctx context.Context, hid uint) error {
-	delHostRef := func(tx sqlx.ExtContext, table string) error {
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		return deleteHostDB(ctx, tx, hid)
+	})
+}
+
+func deleteHostDB(ctx context.Context, tx sqlx.ExtContext, hid uint) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM hosts WHERE id = ?`, hid)
+	if err != nil {
+		return ctxerr.Wrapf(ctx, err, "delete host")
+	}
+
+	for _, table := range hostRefs {
 		_, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE host_id=?`, table), hid)
 		if err != nil {
 			return ctxerr.Wrapf(ctx, err, "deleting %s for host %d", table, hid)
 		}
-		return nil
 	}
 
-	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
-		_, err := tx.ExecContext(ctx, `DELETE FROM hosts WHERE id = ?`, hid)
-		if err != nil {
-			return ctxerr.Wrapf(ctx, err, "delete host")
-		}
-
-		for _, table := range hostRefs {
-			err := delHostRef(tx, table)
-			if err != nil {
-				return err
-			}
-		}
+	_, err = tx.ExecContext(ctx, `DELETE FROM pack_targets WHERE type = ? AND target_id = ?`, fleet.TargetHost, hid)
+	if err != nil {
+		return ctxerr.Wrapf(ctx, err, "deleting pack_targets for host %d", hid)
+	}
 
-		_, err = tx.ExecContext(ctx, `DELETE FROM pack_targets WHERE type = ? AND target_id = ?`, fleet.TargetHost, hid)
-		if err != nil {
-			return ctxerr.Wrapf(ctx, err, "deleting pack_targets for host %d", hid)
-		}
+	_, err = tx.ExecContext(ctx, `DELETE FROM host_duplicates WHERE host_id = ? OR duplicate_host_id = ?`, hid, hid)
+	if err != nil {
+		return ctxerr.Wrapf(ctx, err, "deleting host_duplicates for host %d", hid)
+	}
 
-		return nil
-	})
+	return nil
 }
 
 func (ds *Datastore) Host(ctx context.Context, id uint) (*fleet.Host, error) {
@@ -386,6 +397,7 @@ SELECT
   h.team_id,
   h.policy_updated_at,
   h.public_ip,
+  h.lifecycle_state,
   COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
   COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available,
   COALESCE(hst.seen_time, h.created_at) AS seen_time,
@@ -398,6 +410,15 @@ SELECT
     WHERE
       host_id = h.id
   ) AS additional,
+  (
+    SELECT
+      ` + hostTagsJSON + `
+    FROM
+      host_tags ht
+      JOIN host_tag_keys htk ON htk.id = ht.key_id
+    WHERE
+      ht.host_id = h.id
+  ) AS tags,
   coalesce(failing_policies.count, 0) as failing_policies_count,
   coalesce(failing_policies.count, 0) as total_issues_count
 FROM
@@ -409,10 +430,11 @@ FROM
     SELECT
       count(*) as count
     FROM
-      policy_membership
+      policy_membership pm
     WHERE
-      passes = 0
-      AND host_id = ?
+      pm.passes = 0
+      AND pm.host_id = ?
+      AND NOT ` + policyWaivedCond("pm.policy_id", "pm.host_id") + `
   ) failing_policies
 WHERE
   h.id = ?
@@ -514,6 +536,7 @@ func (ds *Datastore) ListHosts(ctx context.Context, filter fleet.TeamFilter, opt
     h.team_id,
     h.policy_updated_at,
     h.public_ip,
+    h.lifecycle_state,
 	h.orbit_node_key,
     COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
     COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available,
@@ -527,6 +550,12 @@ func (ds *Datastore) ListHosts(ctx context.Context, filter fleet.TeamFilter, opt
 		`
 	}
 
+	if opt.IncludeTags {
+		sql += `,
+    htags.tags
+		`
+	}
+
 	failingPoliciesSelect := `,
     coalesce(failing_policies.count, 0) as failing_policies_count,
     coalesce(failing_policies.count, 0) as total_issues_count
@@ -595,8 +624,9 @@ func (ds *Datastore) applyHostFilters(opt fleet.HostListOptions, sql string, fil
 	}
 
 	failingPoliciesJoin := `LEFT JOIN (
-		    SELECT host_id, count(*) as count FROM policy_membership WHERE passes = 0
-		    GROUP BY host_id
+		    SELECT pm.host_id, count(*) as count FROM policy_membership pm
+		    WHERE pm.passes = 0 AND NOT ` + policyWaivedCond("pm.policy_id", "pm.host_id") + `
+		    GROUP BY pm.host_id
 		) as failing_policies ON (h.id=failing_policies.host_id)`
 	if opt.DisableFailingPolicies {
 		failingPoliciesJoin = ""
@@ -625,6 +655,11 @@ func (ds *Datastore) applyHostFilters(opt fleet.HostListOptions, sql string, fil
 		displayNameJoin = ` JOIN host_display_names hdn ON h.id = hdn.host_id `
 	}
 
+	tagsJoin := ""
+	if opt.IncludeTags {
+		tagsJoin = hostTagsJoin
+	}
+
 	lowDiskSpaceFilter := "TRUE"
 	if opt.LowDiskSpaceFilter != nil {
 		lowDiskSpaceFilter = `hd.gigs_disk_space_available < ?`
@@ -641,9 +676,10 @@ func (ds *Datastore) applyHostFilters(opt fleet.HostListOptions, sql string, fil
     %s
     %s
     %s
+    %s
     %s
 		WHERE TRUE AND %s AND %s AND %s AND %s
-    `, deviceMappingJoin, policyMembershipJoin, failingPoliciesJoin, mdmJoin, operatingSystemJoin, munkiJoin, displayNameJoin, ds.whereFilterHostsByTeams(filter, "h"),
+    `, deviceMappingJoin, policyMembershipJoin, failingPoliciesJoin, mdmJoin, operatingSystemJoin, munkiJoin, displayNameJoin, tagsJoin, ds.whereFilterHostsByTeams(filter, "h"),
 		softwareFilter, munkiFilter, lowDiskSpaceFilter,
 	)
 
@@ -653,6 +689,9 @@ func (ds *Datastore) applyHostFilters(opt fleet.HostListOptions, sql string, fil
 	sql, params = filterHostsByPolicy(sql, opt, params)
 	sql, params = filterHostsByMDM(sql, opt, params)
 	sql, params = filterHostsByOS(sql, opt, params)
+	sql, params = filterHostsBySoftwareRules(sql, opt, params)
+	sql, params = filterHostsByTags(sql, opt, params)
+	sql, params = filterHostsByLifecycleState(sql, opt, params)
 	sql, params = hostSearchLike(sql, params, opt.MatchQuery, hostSearchColumns...)
 	sql, params = appendListOptionsWithCursorToSQL(sql, params, opt.ListOptions)
 
@@ -667,6 +706,14 @@ func filterHostsByTeam(sql string, opt fleet.HostListOptions, params []interface
 	return sql, params
 }
 
+func filterHostsByLifecycleState(sql string, opt fleet.HostListOptions, params []interface{}) (string, []interface{}) {
+	if opt.LifecycleStateFilter != nil {
+		sql += ` AND h.lifecycle_state = ?`
+		params = append(params, *opt.LifecycleStateFilter)
+	}
+	return sql, params
+}
+
 func filterHostsByMDM(sql string, opt fleet.HostListOptions, params []interface{}) (string, []interface{}) {
 	if opt.MDMIDFilter != nil {
 		sql += ` AND hmdm.mdm_id = ?`
@@ -685,6 +732,20 @@ func filterHostsByMDM(sql string, opt fleet.HostListOptions, params []interface{
 	return sql, params
 }
 
+func filterHostsBySoftwareRules(sql string, opt fleet.HostListOptions, params []interface{}) (string, []interface{}) {
+	switch opt.SoftwareStatusFilter {
+	case fleet.HostSoftwareStatusDenied:
+		sql += ` AND EXISTS (SELECT 1 FROM host_software_rule_violations hsrv WHERE hsrv.host_id = h.id)`
+	case fleet.HostSoftwareStatusCompliant:
+		sql += ` AND NOT EXISTS (SELECT 1 FROM host_software_rule_violations hsrv WHERE hsrv.host_id = h.id)`
+	}
+	if opt.SoftwareRuleIDFilter != nil {
+		sql += ` AND EXISTS (SELECT 1 FROM host_software_rule_violations hsrv WHERE hsrv.host_id = h.id AND hsrv.rule_id = ?)`
+		params = append(params, *opt.SoftwareRuleIDFilter)
+	}
+	return sql, params
+}
+
 func filterHostsByOS(sql string, opt fleet.HostListOptions, params []interface{}) (string, []interface{}) {
 	if opt.OSIDFilter != nil {
 		sql += ` AND hos.os_id = ?`
@@ -803,6 +864,9 @@ func (ds *Datastore) GenerateHostStatusStatistics(ctx context.Context, filter fl
 		whereClause += " AND h.platform IN (?) "
 		args = append(args, fleet.ExpandPlatform(*platform))
 	}
+	// the retired hosts are only counted by lifecycle state
+	allStatesWhereClause := whereClause
+	whereClause += fmt.Sprintf(" AND h.lifecycle_state <> '%s' ", fleet.HostLifecycleStateRetired)
 
 	sqlStatement := fmt.Sprintf(`
 			SELECT
@@ -860,6 +924,43 @@ func (ds *Datastore) GenerateHostStatusStatistics(ctx context.Context, filter fl
 	}
 	summary.Platforms = platforms
 
+	// get the counts per lifecycle state, including the retired hosts.
+	args = []interface{}{}
+	if platform != nil {
+		args = append(args, fleet.ExpandPlatform(*platform))
+	}
+	sqlStatement = fmt.Sprintf(`
+			SELECT
+			  COUNT(*) total,
+			  h.lifecycle_state
+			FROM hosts h
+			WHERE %s
+			GROUP BY h.lifecycle_state
+		`, allStatesWhereClause)
+
+	var states []struct {
+		Total          uint                     `db:"total"`
+		LifecycleState fleet.HostLifecycleState `db:"lifecycle_state"`
+	}
+	stmt, args, err = sqlx.In(sqlStatement, args...)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "generating host lifecycle states statement")
+	}
+	err = sqlx.SelectContext(ctx, ds.reader, &states, stmt, args...)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "generating host lifecycle states statistics")
+	}
+	for _, st := range states {
+		switch st.LifecycleState {
+		case fleet.HostLifecycleStateRetired:
+			summary.RetiredCount = st.Total
+		case fleet.HostLifecycleStateQuarantined:
+			summary.QuarantinedCount = st.Total
+		case fleet.HostLifecycleStateInRepair:
+			summary.InRepairCount = st.Total
+		}
+	}
+
 	return &summary, nil
 }
 
@@ -1043,6 +1144,7 @@ func (ds *Datastore) EnrollHost(ctx context.Context, osqueryHostID, nodeKey stri
         h.team_id,
         h.policy_updated_at,
         h.public_ip,
+        h.lifecycle_state,
 		h.orbit_node_key,
         COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
         COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available
@@ -1124,6 +1226,7 @@ func (ds *Datastore) LoadHostByNodeKey(ctx context.Context, nodeKey string) (*fl
       h.team_id,
       h.policy_updated_at,
       h.public_ip,
+      h.lifecycle_state,
       h.orbit_node_key,
       COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
       COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available
@@ -1203,6 +1306,7 @@ func (ds *Datastore) LoadHostByDeviceAuthToken(ctx context.Context, authToken st
       h.team_id,
       h.policy_updated_at,
       h.public_ip,
+      h.lifecycle_state,
       COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
       COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available
     FROM
@@ -1324,6 +1428,7 @@ func (ds *Datastore) SearchHosts(ctx context.Context, filter fleet.TeamFilter, m
     h.team_id,
     h.policy_updated_at,
     h.public_ip,
+    h.lifecycle_state,
 	h.orbit_node_key,
     COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
     COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available,
@@ -1426,10 +1531,16 @@ func (ds *Datastore) HostByIdentifier(ctx context.Context, identifier string) (*
       h.team_id,
       h.policy_updated_at,
       h.public_ip,
+      h.lifecycle_state,
 	  h.orbit_node_key,
       COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
       COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available,
-      COALESCE(hst.seen_time, h.created_at) AS seen_time
+      COALESCE(hst.seen_time, h.created_at) AS seen_time,
+      (
+        SELECT ` + hostTagsJSON + `
+        FROM host_tags ht JOIN host_tag_keys htk ON htk.id = ht.key_id
+        WHERE ht.host_id = h.id
+      ) AS tags
     FROM hosts h
     LEFT JOIN host_seen_times hst ON (h.id = hst.host_id)
     LEFT JOIN host_disks hd ON hd.host_id = h.id
@@ -1460,23 +1571,67 @@ func (ds *Datastore) AddHostsToTeam(ctx context.Context, teamID *uint, hostIDs [
 	}
 
 	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
-		if err := cleanupPolicyMembershipOnTeamChange(ctx, tx, hostIDs); err != nil {
-			return ctxerr.Wrap(ctx, err, "AddHostsToTeam delete policy membership")
-		}
+		return addHostsToTeamDB(ctx, tx, teamID, hostIDs)
+	})
+}
 
-		query, args, err := sqlx.In(`UPDATE hosts SET team_id = ? WHERE id IN (?)`, teamID, hostIDs)
-		if err != nil {
-			return ctxerr.Wrap(ctx, err, "sqlx.In AddHostsToTeam")
-		}
+func addHostsToTeamDB(ctx context.Context, tx sqlx.ExtContext, teamID *uint, hostIDs []uint) error {
+	if err := cleanupPolicyMembershipOnTeamChange(ctx, tx, hostIDs); err != nil {
+		return ctxerr.Wrap(ctx, err, "AddHostsToTeam delete policy membership")
+	}
+
+	query, args, err := sqlx.In(`UPDATE hosts SET team_id = ? WHERE id IN (?)`, teamID, hostIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "sqlx.In AddHostsToTeam")
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "exec AddHostsToTeam")
+	}
+
+	return nil
+}
 
-		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
-			return ctxerr.Wrap(ctx, err, "exec AddHostsToTeam")
+func (ds *Datastore) TransferHostsToTeam(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		if err := addHostsToTeamDB(ctx, tx, teamID, hostIDs); err != nil {
+			return err
 		}
 
+		vals := make([]interface{}, 0, len(hostIDs)*2)
+		for _, hostID := range hostIDs {
+			vals = append(vals, hostID, teamID)
+		}
+		stmt := `INSERT INTO host_manual_teams (host_id, team_id) VALUES ` +
+			strings.TrimSuffix(strings.Repeat(`(?, ?),`, len(hostIDs)), ",") +
+			` ON DUPLICATE KEY UPDATE team_id = VALUES(team_id), created_at = CURRENT_TIMESTAMP`
+		if _, err := tx.ExecContext(ctx, stmt, vals...); err != nil {
+			return ctxerr.Wrap(ctx, err, "insert host manual teams")
+		}
 		return nil
 	})
 }
 
+func (ds *Datastore) HostTeamSetManually(ctx context.Context, hostID uint) (bool, error) {
+	// the team of the host is the one it was transferred to as long as it did
+	// not move since, e.g. to the quarantine team.
+	var manual bool
+	err := sqlx.GetContext(ctx, ds.reader, &manual, `
+		SELECT EXISTS (
+			SELECT 1 FROM host_manual_teams hmt
+			JOIN hosts h ON h.id = hmt.host_id
+			WHERE hmt.host_id = ? AND hmt.team_id <=> h.team_id
+		)`, hostID)
+	if err != nil {
+		return false, ctxerr.Wrap(ctx, err, "select host manual team")
+	}
+	return manual, nil
+}
+
 func (ds *Datastore) SaveHostAdditional(ctx context.Context, hostID uint, additional *json.RawMessage) error {
 	return saveHostAdditionalDB(ctx, ds.writer, hostID, additional)
 }
@@ -1565,8 +1720,9 @@ func (ds *Datastore) TotalAndUnseenHostsSince(ctx context.Context, daysCount int
 			SUM(IF(TIMESTAMPDIFF(SECOND, COALESCE(hst.seen_time, h.created_at), CURRENT_TIMESTAMP) >= ?, 1, 0)) as unseen
 		FROM hosts h
 		LEFT JOIN host_seen_times hst
-		ON h.id = hst.host_id`,
-		unseenSeconds,
+		ON h.id = hst.host_id
+		WHERE h.lifecycle_state <> ?`,
+		unseenSeconds, fleet.HostLifecycleStateRetired,
 	)
 
 	if err != nil {
@@ -1594,7 +1750,7 @@ func (ds *Datastore) FailingPoliciesCount(ctx context.Context, host *fleet.Host)
 	query := `
 		SELECT SUM(1 - pm.passes) AS n_failed
 		FROM policy_membership pm
-		WHERE pm.host_id = ?
+		WHERE pm.host_id = ? AND NOT (pm.passes = 0 AND ` + policyWaivedCond("pm.policy_id", "pm.host_id") + `)
 		GROUP BY host_id
 	`
 
@@ -1626,10 +1782,11 @@ func (ds *Datastore) ListPoliciesForHost(ctx context.Context, host *fleet.Host)
 	LEFT JOIN policy_membership pm ON (p.id=pm.policy_id AND host_id=?)
 	LEFT JOIN users u ON p.author_id = u.id
 	WHERE (p.team_id IS NULL OR p.team_id = (select team_id from hosts WHERE id = ?))
-	AND (p.platforms IS NULL OR p.platforms = '' OR FIND_IN_SET(?, p.platforms) != 0)`
+	AND (p.platforms IS NULL OR p.platforms = '' OR FIND_IN_SET(?, p.platforms) != 0)
+	AND ` + policyLabelScopeCond("p.id", "?")
 
 	var policies []*fleet.HostPolicy
-	if err := sqlx.SelectContext(ctx, ds.reader, &policies, query, host.ID, host.ID, host.FleetPlatform()); err != nil {
+	if err := sqlx.SelectContext(ctx, ds.reader, &policies, query, host.ID, host.ID, host.FleetPlatform(), host.ID, host.ID); err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "get host policies")
 	}
 	return policies, nil
@@ -2574,31 +2731,35 @@ ON DUPLICATE KEY UPDATE
 	return nil
 }
 
+// hostLiteColumns are the columns loaded by HostLite and ListHostsLiteByIDs.
+var hostLiteColumns = []interface{}{
+	"id",
+	"created_at",
+	"updated_at",
+	"osquery_host_id",
+	"node_key",
+	"hostname",
+	"uuid",
+	"platform",
+	"team_id",
+	"distributed_interval",
+	"logger_tls_period",
+	"config_tls_refresh",
+	"detail_updated_at",
+	"label_updated_at",
+	"last_enrolled_at",
+	"policy_updated_at",
+	"refetch_requested",
+	"lifecycle_state",
+}
+
 // HostLite will load the primary data of the host with the given id.
 // We define "primary data" as all host information except the
 // details (like cpu, memory, gigs_disk_space_available, etc.).
 //
 // If the host doesn't exist, a NotFoundError is returned.
 func (ds *Datastore) HostLite(ctx context.Context, id uint) (*fleet.Host, error) {
-	query, args, err := dialect.From(goqu.I("hosts")).Select(
-		"id",
-		"created_at",
-		"updated_at",
-		"osquery_host_id",
-		"node_key",
-		"hostname",
-		"uuid",
-		"platform",
-		"team_id",
-		"distributed_interval",
-		"logger_tls_period",
-		"config_tls_refresh",
-		"detail_updated_at",
-		"label_updated_at",
-		"last_enrolled_at",
-		"policy_updated_at",
-		"refetch_requested",
-	).Where(goqu.I("id").Eq(id)).ToSQL()
+	query, args, err := dialect.From(goqu.I("hosts")).Select(hostLiteColumns...).Where(goqu.I("id").Eq(id)).ToSQL()
 	if err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "sql build")
 	}
@@ -2612,6 +2773,21 @@ func (ds *Datastore) HostLite(ctx context.Context, id uint) (*fleet.Host, error)
 	return &host, nil
 }
 
+func (ds *Datastore) ListHostsLiteByIDs(ctx context.Context, ids []uint) ([]*fleet.Host, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query, args, err := dialect.From(goqu.I("hosts")).Select(hostLiteColumns...).Where(goqu.I("id").In(ids)).ToSQL()
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "sql build")
+	}
+	var hosts []*fleet.Host
+	if err := sqlx.SelectContext(ctx, ds.reader, &hosts, query, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list hosts lite by ids")
+	}
+	return hosts, nil
+}
+
 // UpdateHostOsqueryIntervals updates the osquery intervals of a host.
 func (ds *Datastore) UpdateHostOsqueryIntervals(ctx context.Context, id uint, intervals fleet.HostOsqueryIntervals) error {
 	sqlStatement := `
@@ -92,6 +92,7 @@ func TestHosts(t *testing.T) {
 		{"Additional", testHostsAdditional},
 		{"ByIdentifier", testHostsByIdentifier},
 		{"AddToTeam", testHostsAddToTeam},
+		{"TransferToTeam", testHostsTransferToTeam},
 		{"SaveUsers", testHostsSaveUsers},
 		{"SaveHostUsers", testHostsSaveHostUsers},
 		{"SaveUsersWithoutUid", testHostsSaveUsersWithoutUid},
@@ -1860,6 +1861,50 @@ func testHostsAddToTeam(t *testing.T, ds *Datastore) {
 	}
 }
 
+func testHostsTransferToTeam(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	team1, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	team2, err := ds.NewTeam(ctx, &fleet.Team{Name: "team2"})
+	require.NoError(t, err)
+
+	host1 := test.NewHost(t, ds, "1", "", "key1", "uuid1", time.Now())
+	host2 := test.NewHost(t, ds, "2", "", "key2", "uuid2", time.Now())
+	host3 := test.NewHost(t, ds, "3", "", "key3", "uuid3", time.Now())
+
+	checkManual := func(hostID uint, want bool) {
+		manual, err := ds.HostTeamSetManually(ctx, hostID)
+		require.NoError(t, err)
+		assert.Equal(t, want, manual, hostID)
+	}
+
+	// the hosts added by the host assignment rules are not transferred manually
+	require.NoError(t, ds.AddHostsToTeam(ctx, &team1.ID, []uint{host1.ID}))
+	checkManual(host1.ID, false)
+
+	require.NoError(t, ds.TransferHostsToTeam(ctx, &team1.ID, []uint{host1.ID, host2.ID}))
+	require.NoError(t, ds.TransferHostsToTeam(ctx, nil, []uint{host3.ID}))
+	for _, id := range []uint{host1.ID, host2.ID} {
+		host, err := ds.Host(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, &team1.ID, host.TeamID)
+	}
+	checkManual(host1.ID, true)
+	checkManual(host2.ID, true)
+	checkManual(host3.ID, true)
+
+	// the transfer no longer applies once the host moved to another team
+	require.NoError(t, ds.AddHostsToTeam(ctx, &team2.ID, []uint{host2.ID}))
+	checkManual(host2.ID, false)
+	require.NoError(t, ds.TransferHostsToTeam(ctx, &team2.ID, []uint{host2.ID}))
+	checkManual(host2.ID, true)
+
+	// or once the team is deleted
+	require.NoError(t, ds.DeleteTeam(ctx, team1.ID))
+	checkManual(host1.ID, false)
+	checkManual(host2.ID, true)
+}
+
 func testHostsSaveUsers(t *testing.T, ds *Datastore) {
 	host, err := ds.NewHost(context.Background(), &fleet.Host{
 		DetailUpdatedAt: time.Now(),
@@ -4320,6 +4365,15 @@ func testHostsLite(t *testing.T, ds *Datastore) {
 	require.WithinDuration(t, now.UTC(), h.PolicyUpdatedAt, 1*time.Second)
 	require.WithinDuration(t, now.UTC(), h.LastEnrolledAt, 1*time.Second)
 	require.True(t, h.RefetchRequested)
+
+	hosts, err := ds.ListHostsLiteByIDs(context.Background(), []uint{h.ID, 999})
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+	require.Equal(t, h, hosts[0])
+
+	hosts, err = ds.ListHostsLiteByIDs(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, hosts)
 }
 
 func testUpdateOsqueryIntervals(t *testing.T, ds *Datastore) {
@@ -4785,6 +4839,15 @@ func testHostsDeleteHosts(t *testing.T, ds *Datastore) {
 	}
 	err = ds.UpdateHostSoftware(context.Background(), host.ID, software)
 	require.NoError(t, err)
+	// Updates host_software_changes.
+	software = append(software, fleet.Software{Name: "baz", Version: "2.0.0", Source: "deb_packages"})
+	err = ds.UpdateHostSoftware(context.Background(), host.ID, software)
+	require.NoError(t, err)
+	// Updates host_software_rule_violations.
+	_, err = ds.NewSoftwareRule(context.Background(), &fleet.SoftwareRule{Name: "no baz", Action: fleet.SoftwareRuleDeny, SoftwareName: "baz"})
+	require.NoError(t, err)
+	_, err = ds.UpdateSoftwareRuleViolations(context.Background())
+	require.NoError(t, err)
 	// Updates host_users.
 	users := []fleet.HostUser{
 		{
@@ -4891,6 +4954,18 @@ func testHostsDeleteHosts(t *testing.T, ds *Datastore) {
 	// set host' disk space
 	err = ds.SetOrUpdateHostDisksSpace(context.Background(), host.ID, 12, 25)
 	require.NoError(t, err)
+	// Queue a script execution for the host
+	_, err = ds.NewHostScriptExecution(context.Background(), &fleet.HostScriptExecution{HostID: host.ID, Type: fleet.ScriptExecutionTypeScript, Contents: "echo 1"})
+	require.NoError(t, err)
+	// Waive the policy for the host
+	_, err = ds.NewPolicyWaiver(context.Background(), &fleet.PolicyWaiver{
+		PolicyID: policy.ID, HostIDs: []uint{host.ID}, Reason: "foo", Approver: "bar", ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// Record a sample of the host's vitals
+	err = ds.RecordHostVitals(context.Background(), fleet.HostVitalsFromHost(host, time.Now()), time.Hour)
+	require.NoError(t, err)
 
 	// Check there's an entry for the host in all the associated tables.
 	for _, hostRef := range hostRefs {
@@ -2,6 +2,8 @@ package mysql
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/jmoiron/sqlx"
@@ -29,21 +31,35 @@ VALUES (?, ?, ?, ?, ?)
 	return job, nil
 }
 
-func (ds *Datastore) GetQueuedJobs(ctx context.Context, maxNumJobs int) ([]*fleet.Job, error) {
+func (ds *Datastore) GetQueuedJobs(ctx context.Context, maxNumJobs int, names []string) ([]*fleet.Job, error) {
 	query := `
 SELECT
-    id, created_at, updated_at, name, args, state, retries, error
+    id, created_at, updated_at, name, args, state, retries, error, not_before
 FROM
     jobs
 WHERE
-    state = ?
+    state = ? AND
+    not_before <= NOW()
+    %s
 ORDER BY
     updated_at ASC
 LIMIT ?
 `
+	args := []interface{}{fleet.JobStateQueued}
+	var namesFilter string
+	if len(names) > 0 {
+		namesFilter = "AND name IN (?)"
+		args = append(args, names)
+	}
+	args = append(args, maxNumJobs)
+
+	query, args, err := sqlx.In(fmt.Sprintf(query, namesFilter), args...)
+	if err != nil {
+		return nil, err
+	}
 
 	var jobs []*fleet.Job
-	err := sqlx.SelectContext(ctx, ds.reader, &jobs, query, fleet.JobStateQueued, maxNumJobs)
+	err = sqlx.SelectContext(ctx, ds.reader, &jobs, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -57,11 +73,16 @@ UPDATE jobs
 SET
     state = ?,
     retries = ?,
-    error = ?
+    error = ?,
+    not_before = COALESCE(?, not_before)
 WHERE
     id = ?
 `
-	_, err := ds.writer.ExecContext(ctx, query, job.State, job.Retries, job.Error, job.ID)
+	var notBefore *time.Time
+	if !job.NotBefore.IsZero() {
+		notBefore = &job.NotBefore
+	}
+	_, err := ds.writer.ExecContext(ctx, query, job.State, job.Retries, job.Error, notBefore, job.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,15 +22,19 @@ func (ds *Datastore) ApplyLabelSpecs(ctx context.Context, specs []*fleet.LabelSp
 			query,
 			platform,
 			label_type,
-			label_membership_type
-		) VALUES ( ?, ?, ?, ?, ?, ?)
+			label_membership_type,
+			host_tag_key,
+			host_tag_value
+		) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
 			name = VALUES(name),
 			description = VALUES(description),
 			query = VALUES(query),
 			platform = VALUES(platform),
 			label_type = VALUES(label_type),
-			label_membership_type = VALUES(label_membership_type)
+			label_membership_type = VALUES(label_membership_type),
+			host_tag_key = VALUES(host_tag_key),
+			host_tag_value = VALUES(host_tag_value)
 	`
 
 		prepTx, ok := tx.(sqlx.PreparerContext)
@@ -47,13 +51,17 @@ func (ds *Datastore) ApplyLabelSpecs(ctx context.Context, specs []*fleet.LabelSp
 			if s.Name == "" {
 				return ctxerr.New(ctx, "label name must not be empty")
 			}
-			_, err := stmt.ExecContext(ctx, s.Name, s.Description, s.Query, s.Platform, s.LabelType, s.LabelMembershipType)
+			var hostTagKey, hostTagValue *string
+			if s.LabelMembershipType == fleet.LabelMembershipTypeHostTag {
+				hostTagKey, hostTagValue = s.HostTagKey, s.HostTagValue
+			}
+			_, err := stmt.ExecContext(ctx, s.Name, s.Description, s.Query, s.Platform, s.LabelType, s.LabelMembershipType, hostTagKey, hostTagValue)
 			if err != nil {
 				return ctxerr.Wrap(ctx, err, "exec ApplyLabelSpecs insert")
 			}
 
 			if s.LabelType == fleet.LabelTypeBuiltIn ||
-				s.LabelMembershipType != fleet.LabelMembershipTypeManual {
+				s.LabelMembershipType == fleet.LabelMembershipTypeDynamic {
 				// No need to update membership
 				continue
 			}
@@ -66,6 +74,13 @@ SELECT id from labels WHERE name = ?
 				return ctxerr.Wrap(ctx, err, "get label ID")
 			}
 
+			if s.LabelMembershipType == fleet.LabelMembershipTypeHostTag {
+				if err := updateHostTagLabelsMembershipDB(ctx, tx, []uint{labelID}, nil); err != nil {
+					return err
+				}
+				continue
+			}
+
 			sql = `
 DELETE FROM label_membership WHERE label_id = ?
 `
@@ -120,7 +135,7 @@ func batchHostnames(hostnames []string) [][]string {
 func (ds *Datastore) GetLabelSpecs(ctx context.Context) ([]*fleet.LabelSpec, error) {
 	var specs []*fleet.LabelSpec
 	// Get basic specs
-	query := "SELECT id, name, description, query, platform, label_type, label_membership_type FROM labels"
+	query := "SELECT id, name, description, query, platform, label_type, label_membership_type, host_tag_key, host_tag_value FROM labels"
 	if err := sqlx.SelectContext(ctx, ds.reader, &specs, query); err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "get labels")
 	}
@@ -140,7 +155,7 @@ func (ds *Datastore) GetLabelSpecs(ctx context.Context) ([]*fleet.LabelSpec, err
 func (ds *Datastore) GetLabelSpec(ctx context.Context, name string) (*fleet.LabelSpec, error) {
 	var specs []*fleet.LabelSpec
 	query := `
-SELECT name, description, query, platform, label_type, label_membership_type
+SELECT name, description, query, platform, label_type, label_membership_type, host_tag_key, host_tag_value
 FROM labels
 WHERE name = ?
 `
@@ -193,8 +208,10 @@ func (ds *Datastore) NewLabel(ctx context.Context, label *fleet.Label, opts ...f
 		query,
 		platform,
 		label_type,
-		label_membership_type
-	) VALUES ( ?, ?, ?, ?, ?, ?)
+		label_membership_type,
+		host_tag_key,
+		host_tag_value
+	) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	result, err := ds.writer.ExecContext(
 		ctx,
@@ -205,6 +222,8 @@ func (ds *Datastore) NewLabel(ctx context.Context, label *fleet.Label, opts ...f
 		label.Platform,
 		label.LabelType,
 		label.LabelMembershipType,
+		label.HostTagKey,
+		label.HostTagValue,
 	)
 	if err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "inserting label")
@@ -212,6 +231,12 @@ func (ds *Datastore) NewLabel(ctx context.Context, label *fleet.Label, opts ...f
 
 	id, _ := result.LastInsertId()
 	label.ID = uint(id)
+
+	if label.LabelMembershipType == fleet.LabelMembershipTypeHostTag {
+		if err := updateHostTagLabelsMembershipDB(ctx, ds.writer, []uint{label.ID}, nil); err != nil {
+			return nil, err
+		}
+	}
 	return label, nil
 }
 
@@ -500,16 +525,19 @@ func (ds *Datastore) ListHostsInLabel(ctx context.Context, filter fleet.TeamFilt
       h.team_id,
       h.policy_updated_at,
       h.public_ip,
+      h.lifecycle_state,
       COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
       COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available,
       COALESCE(hst.seen_time, h.created_at) as seen_time,
       (SELECT name FROM teams t WHERE t.id = h.team_id) AS team_name
       %s
+      %s
     FROM label_membership lm
     JOIN hosts h ON (lm.host_id = h.id)
     LEFT JOIN host_seen_times hst ON (h.id=hst.host_id)
     LEFT JOIN host_disks hd ON (h.id=hd.host_id)
     %s
+    %s
     %s
 	`
 	failingPoliciesSelect := `,
@@ -517,8 +545,9 @@ func (ds *Datastore) ListHostsInLabel(ctx context.Context, filter fleet.TeamFilt
 		coalesce(failing_policies.count, 0) as total_issues_count
 	`
 	failingPoliciesJoin := `LEFT JOIN (
-		SELECT host_id, count(*) as count FROM policy_membership WHERE passes = 0
-		GROUP BY host_id
+		SELECT pm.host_id, count(*) as count FROM policy_membership pm
+		WHERE pm.passes = 0 AND NOT ` + policyWaivedCond("pm.policy_id", "pm.host_id") + `
+		GROUP BY pm.host_id
 	) as failing_policies ON (h.id=failing_policies.host_id)`
 
 	if opt.DisableFailingPolicies {
@@ -531,7 +560,13 @@ func (ds *Datastore) ListHostsInLabel(ctx context.Context, filter fleet.TeamFilt
 		displayNameJoin = ` JOIN host_display_names hdn ON h.id = hdn.host_id `
 	}
 
-	query := fmt.Sprintf(queryFmt, failingPoliciesSelect, failingPoliciesJoin, displayNameJoin)
+	tagsSelect, tagsJoin := "", ""
+	if opt.IncludeTags {
+		tagsSelect = `, htags.tags`
+		tagsJoin = hostTagsJoin
+	}
+
+	query := fmt.Sprintf(queryFmt, failingPoliciesSelect, tagsSelect, failingPoliciesJoin, displayNameJoin, tagsJoin)
 
 	query, params := ds.applyHostLabelFilters(filter, lid, query, opt)
 
@@ -550,6 +585,8 @@ func (ds *Datastore) applyHostLabelFilters(filter fleet.TeamFilter, lid uint, qu
 	query += fmt.Sprintf(` WHERE lm.label_id = ? AND %s `, ds.whereFilterHostsByTeams(filter, "h"))
 	query, params = filterHostsByStatus(ds.clock.Now(), query, opt, params)
 	query, params = filterHostsByTeam(query, opt, params)
+	query, params = filterHostsByTags(query, opt, params)
+	query, params = filterHostsByLifecycleState(query, opt, params)
 	query, params = searchLike(query, params, opt.MatchQuery, hostSearchColumns...)
 
 	query = appendListOptionsToSQL(query, opt.ListOptions)
@@ -623,6 +660,7 @@ func (ds *Datastore) ListUniqueHostsInLabels(ctx context.Context, filter fleet.T
         h.team_id,
         h.policy_updated_at,
         h.public_ip,
+        h.lifecycle_state,
         COALESCE(hd.gigs_disk_space_available, 0) as gigs_disk_space_available,
         COALESCE(hd.percent_disk_space_available, 0) as percent_disk_space_available,
         (SELECT name FROM teams t WHERE t.id = h.team_id) AS team_name
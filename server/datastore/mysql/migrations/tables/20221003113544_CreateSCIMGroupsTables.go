@@ -0,0 +1,47 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221003113544, Down_20221003113544)
+}
+
+func Up_20221003113544(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+    CREATE TABLE scim_groups (
+        id           INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+        display_name VARCHAR(255) NOT NULL,
+        external_id  VARCHAR(255) NOT NULL DEFAULT '',
+        created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        UNIQUE KEY idx_scim_groups_display_name (display_name)
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create scim_groups table")
+	}
+
+	_, err = tx.Exec(`
+    CREATE TABLE scim_group_users (
+        group_id INT(10) UNSIGNED NOT NULL,
+        user_id  INT(10) UNSIGNED NOT NULL,
+
+        PRIMARY KEY (group_id, user_id),
+        KEY idx_scim_group_users_user_id (user_id),
+        CONSTRAINT fk_scim_group_users_group_id FOREIGN KEY (group_id) REFERENCES scim_groups (id) ON DELETE CASCADE,
+        CONSTRAINT fk_scim_group_users_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create scim_group_users table")
+	}
+	return nil
+}
+
+func Down_20221003113544(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,35 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221003113544(t *testing.T) {
+	db := applyUpToPrev(t)
+	applyNext(t, db)
+
+	res, err := db.Exec(`INSERT INTO users (password, salt, email) VALUES ('', '', 'a@b.c')`)
+	require.NoError(t, err)
+	userID, _ := res.LastInsertId()
+
+	res, err = db.Exec(`INSERT INTO scim_groups (display_name) VALUES ('admins')`)
+	require.NoError(t, err)
+	groupID, _ := res.LastInsertId()
+
+	_, err = db.Exec(`INSERT INTO scim_groups (display_name) VALUES ('admins')`)
+	require.Error(t, err)
+
+	_, err = db.Exec(`INSERT INTO scim_group_users (group_id, user_id) VALUES (?, ?)`, groupID, userID)
+	require.NoError(t, err)
+
+	// deleting the user removes its group memberships
+	_, err = db.Exec(`DELETE FROM users WHERE id = ?`, userID)
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM scim_group_users WHERE group_id = ?`, groupID).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,40 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221003120000, Down_20221003120000)
+}
+
+func Up_20221003120000(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE users
+			ADD COLUMN disabled TINYINT(1) NOT NULL DEFAULT 0`)
+	if err != nil {
+		return errors.Wrap(err, "add disabled to users")
+	}
+
+	// the users deleted via SCIM are kept (disabled) in Fleet, so that their
+	// activities and the queries they authored are preserved, but they are
+	// not returned by the SCIM API anymore.
+	_, err = tx.Exec(`
+    CREATE TABLE scim_deleted_users (
+        user_id    INT(10) UNSIGNED NOT NULL,
+        deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (user_id),
+        CONSTRAINT fk_scim_deleted_users_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create scim_deleted_users table")
+	}
+	return nil
+}
+
+func Down_20221003120000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221003120000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	sqlInsert := `INSERT INTO users (password, salt, name, email) VALUES (?, ?, ?, ?)`
+	_, err := db.Exec(sqlInsert, "pwd", "salt", "user1", "user1@example.com")
+	require.NoError(t, err)
+
+	applyNext(t, db)
+
+	// the existing users are enabled
+	var disabled bool
+	err = db.Get(&disabled, `SELECT disabled FROM users WHERE email = ?`, "user1@example.com")
+	require.NoError(t, err)
+	require.False(t, disabled)
+
+	var userID uint
+	err = db.Get(&userID, `SELECT id FROM users WHERE email = ?`, "user1@example.com")
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO scim_deleted_users (user_id) VALUES (?)`, userID)
+	require.NoError(t, err)
+
+	// the deletion is removed with the user
+	_, err = db.Exec(`DELETE FROM users WHERE id = ?`, userID)
+	require.NoError(t, err)
+	var count int
+	err = db.Get(&count, `SELECT COUNT(*) FROM scim_deleted_users`)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
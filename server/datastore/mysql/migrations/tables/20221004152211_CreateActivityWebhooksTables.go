@@ -0,0 +1,64 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221004152211, Down_20221004152211)
+}
+
+func Up_20221004152211(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+    CREATE TABLE activity_webhooks (
+        id             INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+        name           VARCHAR(255) NOT NULL,
+        url            TEXT NOT NULL,
+        secret         VARCHAR(255) NOT NULL,
+        activity_types JSON NOT NULL,
+        team_id        INT(10) UNSIGNED NULL,
+        enabled        TINYINT(1) NOT NULL DEFAULT 1,
+        created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        UNIQUE KEY idx_activity_webhooks_name (name),
+        CONSTRAINT fk_activity_webhooks_team_id FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create activity_webhooks table")
+	}
+
+	_, err = tx.Exec(`
+    CREATE TABLE activity_webhook_deliveries (
+        id            INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+        webhook_id    INT(10) UNSIGNED NOT NULL,
+        activity_id   INT(10) UNSIGNED NOT NULL,
+        activity_type VARCHAR(255) NOT NULL,
+        status_code   INT(10) NULL,
+        error         TEXT,
+        duration_ms   INT(10) UNSIGNED NOT NULL DEFAULT 0,
+        created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        KEY idx_activity_webhook_deliveries_webhook_id_created_at (webhook_id, created_at),
+        KEY idx_activity_webhook_deliveries_created_at (created_at),
+        CONSTRAINT fk_activity_webhook_deliveries_webhook_id FOREIGN KEY (webhook_id) REFERENCES activity_webhooks (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create activity_webhook_deliveries table")
+	}
+
+	// not_before is used to delay the retries of failed jobs.
+	_, err = tx.Exec(`ALTER TABLE jobs ADD COLUMN not_before TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP`)
+	if err != nil {
+		return errors.Wrap(err, "add not_before to jobs")
+	}
+	return nil
+}
+
+func Down_20221004152211(tx *sql.Tx) error {
+	return nil
+}
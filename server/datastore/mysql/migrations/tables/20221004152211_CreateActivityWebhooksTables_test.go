@@ -0,0 +1,45 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221004152211(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	_, err := db.Exec(`INSERT INTO jobs (name, state) VALUES ('jira', 'queued')`)
+	require.NoError(t, err)
+
+	applyNext(t, db)
+
+	// existing jobs can be processed immediately
+	var ready int
+	err = db.QueryRow(`SELECT COUNT(*) FROM jobs WHERE not_before <= NOW()`).Scan(&ready)
+	require.NoError(t, err)
+	require.Equal(t, 1, ready)
+
+	res, err := db.Exec(`INSERT INTO teams (name) VALUES ('team1')`)
+	require.NoError(t, err)
+	teamID, _ := res.LastInsertId()
+
+	res, err = db.Exec(`INSERT INTO activity_webhooks (name, url, secret, activity_types, team_id) VALUES ('siem', 'https://example.com', 'abc', '[]', ?)`, teamID)
+	require.NoError(t, err)
+	webhookID, _ := res.LastInsertId()
+
+	_, err = db.Exec(`INSERT INTO activity_webhooks (name, url, secret, activity_types) VALUES ('siem', 'https://example.com', 'abc', '[]')`)
+	require.Error(t, err)
+
+	_, err = db.Exec(`INSERT INTO activity_webhook_deliveries (webhook_id, activity_id, activity_type, status_code) VALUES (?, 1, 'created_pack', 200)`, webhookID)
+	require.NoError(t, err)
+
+	// deleting the team deletes its webhooks and their deliveries
+	_, err = db.Exec(`DELETE FROM teams WHERE id = ?`, teamID)
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM activity_webhook_deliveries`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,37 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221012140000, Down_20221012140000)
+}
+
+func Up_20221012140000(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+    CREATE TABLE scheduled_query_results (
+        id                 BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+        scheduled_query_id INT(10) UNSIGNED NOT NULL,
+        host_id            INT(10) UNSIGNED NOT NULL,
+        action             VARCHAR(20) NOT NULL,
+        data               JSON NOT NULL,
+        last_fetched       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        KEY idx_scheduled_query_results_scheduled_query_id_host_id (scheduled_query_id, host_id),
+        KEY idx_scheduled_query_results_host_id (host_id),
+        KEY idx_scheduled_query_results_last_fetched (last_fetched),
+        CONSTRAINT fk_scheduled_query_results_scheduled_query_id FOREIGN KEY (scheduled_query_id) REFERENCES scheduled_queries (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create scheduled_query_results table")
+	}
+	return nil
+}
+
+func Down_20221012140000(tx *sql.Tx) error {
+	return nil
+}
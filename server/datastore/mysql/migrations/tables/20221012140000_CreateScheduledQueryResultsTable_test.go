@@ -0,0 +1,35 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221012140000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO queries (name, description, query) VALUES ('q1', '', 'select 1')`)
+	require.NoError(t, err)
+	queryID, _ := res.LastInsertId()
+	res, err = db.Exec(`INSERT INTO packs (name) VALUES ('p1')`)
+	require.NoError(t, err)
+	packID, _ := res.LastInsertId()
+	res, err = db.Exec(`INSERT INTO scheduled_queries (pack_id, query_id, query_name, name) VALUES (?, ?, 'q1', 'q1')`, packID, queryID)
+	require.NoError(t, err)
+	sqID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	_, err = db.Exec(`INSERT INTO scheduled_query_results (scheduled_query_id, host_id, action, data) VALUES (?, 1, 'snapshot', '[{"a":"b"}]')`, sqID)
+	require.NoError(t, err)
+
+	// deleting the scheduled query deletes its results
+	_, err = db.Exec(`DELETE FROM scheduled_queries WHERE id = ?`, sqID)
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM scheduled_query_results`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,40 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221013100000, Down_20221013100000)
+}
+
+func Up_20221013100000(tx *sql.Tx) error {
+	// hostname and host_display_name are stored with the results so that
+	// they can still be reviewed after the host is deleted.
+	_, err := tx.Exec(`
+    CREATE TABLE distributed_query_campaign_results (
+        id                            BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+        distributed_query_campaign_id INT(10) UNSIGNED NOT NULL,
+        host_id                       INT(10) UNSIGNED NOT NULL,
+        hostname                      VARCHAR(255) NOT NULL DEFAULT '',
+        host_display_name             VARCHAR(255) NOT NULL DEFAULT '',
+        data                          JSON NOT NULL,
+        error                         TEXT NULL,
+        created_at                    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        KEY idx_distributed_query_campaign_results_campaign_id (distributed_query_campaign_id),
+        KEY idx_distributed_query_campaign_results_created_at (created_at),
+        CONSTRAINT fk_distributed_query_campaign_results_campaign_id FOREIGN KEY (distributed_query_campaign_id) REFERENCES distributed_query_campaigns (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create distributed_query_campaign_results table")
+	}
+	return nil
+}
+
+func Down_20221013100000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221013100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO distributed_query_campaigns (query_id, status, user_id) VALUES (1, 2, 1)`)
+	require.NoError(t, err)
+	campaignID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	_, err = db.Exec(`INSERT INTO distributed_query_campaign_results (distributed_query_campaign_id, host_id, hostname, data) VALUES (?, 1, 'h1', '[{"a":"b"}]')`, campaignID)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO distributed_query_campaign_results (distributed_query_campaign_id, host_id, data, error) VALUES (?, 2, '[]', 'failed')`, campaignID)
+	require.NoError(t, err)
+
+	// deleting the campaign deletes its results
+	_, err = db.Exec(`DELETE FROM distributed_query_campaigns WHERE id = ?`, campaignID)
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM distributed_query_campaign_results`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,46 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221014090000, Down_20221014090000)
+}
+
+func Up_20221014090000(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+    CREATE TABLE scheduled_live_queries (
+        id               INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+        name             VARCHAR(255) NOT NULL,
+        query_id         INT(10) UNSIGNED NOT NULL,
+        targets          JSON NOT NULL,
+        run_at           TIMESTAMP NULL,
+        cron             VARCHAR(255) NOT NULL DEFAULT '',
+        results_window   INT(10) UNSIGNED NOT NULL DEFAULT 300,
+        webhook_url      TEXT NOT NULL,
+        enabled          TINYINT(1) NOT NULL DEFAULT 1,
+        author_id        INT(10) UNSIGNED NULL,
+        next_run_at      TIMESTAMP NULL,
+        last_run_at      TIMESTAMP NULL,
+        last_campaign_id INT(10) UNSIGNED NULL,
+        created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        UNIQUE KEY idx_scheduled_live_queries_name (name),
+        KEY idx_scheduled_live_queries_enabled_next_run_at (enabled, next_run_at),
+        CONSTRAINT fk_scheduled_live_queries_query_id FOREIGN KEY (query_id) REFERENCES queries (id) ON DELETE CASCADE,
+        CONSTRAINT fk_scheduled_live_queries_author_id FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create scheduled_live_queries table")
+	}
+	return nil
+}
+
+func Down_20221014090000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221014090000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO users (name, email, password, salt) VALUES ('u1', 'u1@example.com', 'pass', 'salt')`)
+	require.NoError(t, err)
+	userID, _ := res.LastInsertId()
+	res, err = db.Exec(`INSERT INTO queries (name, description, query) VALUES ('q1', '', 'SELECT 1')`)
+	require.NoError(t, err)
+	queryID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	_, err = db.Exec(`INSERT INTO scheduled_live_queries (name, query_id, targets, cron, webhook_url, author_id) VALUES ('s1', ?, '{"hosts":[1]}', '0 * * * *', '', ?)`, queryID, userID)
+	require.NoError(t, err)
+
+	// deleting the author keeps the scheduled live query
+	_, err = db.Exec(`DELETE FROM users WHERE id = ?`, userID)
+	require.NoError(t, err)
+	var authorID *uint
+	err = db.QueryRow(`SELECT author_id FROM scheduled_live_queries WHERE name = 's1'`).Scan(&authorID)
+	require.NoError(t, err)
+	require.Nil(t, authorID)
+
+	// deleting the query deletes the scheduled live query
+	_, err = db.Exec(`DELETE FROM queries WHERE id = ?`, queryID)
+	require.NoError(t, err)
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM scheduled_live_queries`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
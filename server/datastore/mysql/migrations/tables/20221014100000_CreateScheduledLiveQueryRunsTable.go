@@ -0,0 +1,33 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221014100000, Down_20221014100000)
+}
+
+func Up_20221014100000(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+    CREATE TABLE scheduled_live_query_runs (
+        campaign_id             INT(10) UNSIGNED NOT NULL,
+        scheduled_live_query_id INT(10) UNSIGNED NOT NULL,
+        ends_at                 TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        created_at              TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (campaign_id),
+        KEY idx_scheduled_live_query_runs_ends_at (ends_at),
+        CONSTRAINT fk_scheduled_live_query_runs_campaign_id FOREIGN KEY (campaign_id) REFERENCES distributed_query_campaigns (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create scheduled_live_query_runs table")
+	}
+	return nil
+}
+
+func Down_20221014100000(tx *sql.Tx) error {
+	return nil
+}
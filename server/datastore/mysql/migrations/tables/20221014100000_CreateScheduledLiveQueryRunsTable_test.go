@@ -0,0 +1,28 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221014100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO distributed_query_campaigns (query_id, status, user_id) VALUES (1, 1, 1)`)
+	require.NoError(t, err)
+	campaignID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	_, err = db.Exec(`INSERT INTO scheduled_live_query_runs (campaign_id, scheduled_live_query_id, ends_at) VALUES (?, 1, NOW())`, campaignID)
+	require.NoError(t, err)
+
+	// the run is removed with its campaign
+	_, err = db.Exec(`DELETE FROM distributed_query_campaigns WHERE id = ?`, campaignID)
+	require.NoError(t, err)
+	var count int
+	err = db.Get(&count, `SELECT COUNT(*) FROM scheduled_live_query_runs`)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,47 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221017100000, Down_20221017100000)
+}
+
+func Up_20221017100000(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+    ALTER TABLE policies
+        ADD COLUMN remediation_type VARCHAR(20) NOT NULL DEFAULT '',
+        ADD COLUMN remediation MEDIUMTEXT NULL`)
+	if err != nil {
+		return errors.Wrap(err, "add remediation columns to policies")
+	}
+
+	_, err = tx.Exec(`
+    CREATE TABLE host_script_executions (
+        id         INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+        host_id    INT(10) UNSIGNED NOT NULL,
+        policy_id  INT(10) UNSIGNED NULL,
+        type       VARCHAR(20) NOT NULL,
+        contents   MEDIUMTEXT NOT NULL,
+        status     VARCHAR(20) NOT NULL DEFAULT 'pending',
+        exit_code  INT NULL,
+        output     TEXT NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        KEY idx_host_script_executions_host_id_status (host_id, status),
+        CONSTRAINT fk_host_script_executions_policy_id FOREIGN KEY (policy_id) REFERENCES policies (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create host_script_executions table")
+	}
+	return nil
+}
+
+func Down_20221017100000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,45 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221017100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO policies (name, query, description) VALUES ('p1', 'SELECT 1', '')`)
+	require.NoError(t, err)
+	policyID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	// existing policies have no remediation
+	var remediationType string
+	var remediation *string
+	err = db.QueryRow(`SELECT remediation_type, remediation FROM policies WHERE id = ?`, policyID).Scan(&remediationType, &remediation)
+	require.NoError(t, err)
+	require.Empty(t, remediationType)
+	require.Nil(t, remediation)
+
+	_, err = db.Exec(`UPDATE policies SET remediation_type = 'script', remediation = 'echo fix' WHERE id = ?`, policyID)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO host_script_executions (host_id, policy_id, type, contents, output) VALUES (1, ?, 'script', 'echo fix', '')`, policyID)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO host_script_executions (host_id, type, contents, output) VALUES (1, 'script', 'echo hello', '')`)
+	require.NoError(t, err)
+
+	var status string
+	err = db.QueryRow(`SELECT status FROM host_script_executions WHERE policy_id = ?`, policyID).Scan(&status)
+	require.NoError(t, err)
+	require.Equal(t, "pending", status)
+
+	// deleting the policy deletes its executions
+	_, err = db.Exec(`DELETE FROM policies WHERE id = ?`, policyID)
+	require.NoError(t, err)
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM host_script_executions`).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221018100000, Down_20221018100000)
+}
+
+func Up_20221018100000(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+    ALTER TABLE policies
+        ADD COLUMN severity VARCHAR(20) NOT NULL DEFAULT '',
+        ADD COLUMN tags JSON NULL`)
+	if err != nil {
+		return errors.Wrap(err, "add severity and tags columns to policies")
+	}
+
+	return nil
+}
+
+func Down_20221018100000(tx *sql.Tx) error {
+	return nil
+}
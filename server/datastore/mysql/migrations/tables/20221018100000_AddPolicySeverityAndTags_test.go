@@ -0,0 +1,34 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221018100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO policies (name, query, description) VALUES ('p1', 'SELECT 1', '')`)
+	require.NoError(t, err)
+	policyID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	// existing policies have no severity and no tags
+	var severity string
+	var tags *string
+	err = db.QueryRow(`SELECT severity, tags FROM policies WHERE id = ?`, policyID).Scan(&severity, &tags)
+	require.NoError(t, err)
+	require.Empty(t, severity)
+	require.Nil(t, tags)
+
+	res, err = db.Exec(`INSERT INTO policies (name, query, description, severity, tags) VALUES ('p2', 'SELECT 1', '', 'high', '["cis-1.1"]')`)
+	require.NoError(t, err)
+	policyID, _ = res.LastInsertId()
+	err = db.QueryRow(`SELECT severity, tags FROM policies WHERE id = ?`, policyID).Scan(&severity, &tags)
+	require.NoError(t, err)
+	require.Equal(t, "high", severity)
+	require.NotNil(t, tags)
+	require.JSONEq(t, `["cis-1.1"]`, *tags)
+}
@@ -0,0 +1,39 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221019100000, Down_20221019100000)
+}
+
+func Up_20221019100000(tx *sql.Tx) error {
+	// only the transitions of the policy results are stored (when a host starts
+	// passing or failing a policy), not every result reported by the hosts.
+	_, err := tx.Exec(`
+    CREATE TABLE policy_membership_history (
+        id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+        policy_id  INT UNSIGNED NOT NULL,
+        host_id    INT UNSIGNED NOT NULL,
+        passes     TINYINT(1) NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        KEY idx_policy_membership_history_host_id_policy_id (host_id, policy_id),
+        KEY idx_policy_membership_history_policy_id_created_at (policy_id, created_at),
+        CONSTRAINT fk_policy_membership_history_policy_id
+            FOREIGN KEY (policy_id) REFERENCES policies (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create policy_membership_history table")
+	}
+
+	return nil
+}
+
+func Down_20221019100000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221019100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO policies (name, query, description) VALUES ('p1', 'SELECT 1', '')`)
+	require.NoError(t, err)
+	policyID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	_, err = db.Exec(`INSERT INTO policy_membership_history (policy_id, host_id, passes) VALUES (?, 1, 0), (?, 1, 1)`, policyID, policyID)
+	require.NoError(t, err)
+
+	// the result is required
+	_, err = db.Exec(`INSERT INTO policy_membership_history (policy_id, host_id, passes) VALUES (?, 2, NULL)`, policyID)
+	require.Error(t, err)
+
+	// deleting the policy deletes its history
+	_, err = db.Exec(`DELETE FROM policies WHERE id = ?`, policyID)
+	require.NoError(t, err)
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM policy_membership_history`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
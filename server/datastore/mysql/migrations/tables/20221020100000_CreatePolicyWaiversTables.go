@@ -0,0 +1,61 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221020100000, Down_20221020100000)
+}
+
+func Up_20221020100000(tx *sql.Tx) error {
+	// a waiver applies either to the hosts listed in policy_waiver_hosts or to
+	// the members of its label.
+	_, err := tx.Exec(`
+    CREATE TABLE policy_waivers (
+        id         INT UNSIGNED NOT NULL AUTO_INCREMENT,
+        policy_id  INT UNSIGNED NOT NULL,
+        label_id   INT UNSIGNED NULL,
+        reason     TEXT NOT NULL,
+        approver   VARCHAR(255) NOT NULL DEFAULT '',
+        expires_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        author_id  INT UNSIGNED NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        KEY idx_policy_waivers_policy_id_expires_at (policy_id, expires_at),
+        KEY idx_policy_waivers_expires_at (expires_at),
+        CONSTRAINT fk_policy_waivers_policy_id
+            FOREIGN KEY (policy_id) REFERENCES policies (id) ON DELETE CASCADE,
+        CONSTRAINT fk_policy_waivers_label_id
+            FOREIGN KEY (label_id) REFERENCES labels (id) ON DELETE CASCADE,
+        CONSTRAINT fk_policy_waivers_author_id
+            FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create policy_waivers table")
+	}
+
+	_, err = tx.Exec(`
+    CREATE TABLE policy_waiver_hosts (
+        waiver_id INT UNSIGNED NOT NULL,
+        host_id   INT UNSIGNED NOT NULL,
+
+        PRIMARY KEY (waiver_id, host_id),
+        KEY idx_policy_waiver_hosts_host_id (host_id),
+        CONSTRAINT fk_policy_waiver_hosts_waiver_id
+            FOREIGN KEY (waiver_id) REFERENCES policy_waivers (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create policy_waiver_hosts table")
+	}
+
+	return nil
+}
+
+func Down_20221020100000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,38 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221020100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO policies (name, query, description) VALUES ('p1', 'SELECT 1', '')`)
+	require.NoError(t, err)
+	policyID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	res, err = db.Exec(`INSERT INTO policy_waivers (policy_id, reason, approver, expires_at) VALUES (?, 'build server', 'alice', NOW() + INTERVAL 1 DAY)`, policyID)
+	require.NoError(t, err)
+	waiverID, _ := res.LastInsertId()
+	_, err = db.Exec(`INSERT INTO policy_waiver_hosts (waiver_id, host_id) VALUES (?, 1), (?, 2)`, waiverID, waiverID)
+	require.NoError(t, err)
+
+	// the same host cannot be added twice to a waiver
+	_, err = db.Exec(`INSERT INTO policy_waiver_hosts (waiver_id, host_id) VALUES (?, 1)`, waiverID)
+	require.Error(t, err)
+
+	// deleting the policy deletes its waivers and their hosts
+	_, err = db.Exec(`DELETE FROM policies WHERE id = ?`, policyID)
+	require.NoError(t, err)
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM policy_waivers`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+	err = db.QueryRow(`SELECT COUNT(*) FROM policy_waiver_hosts`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
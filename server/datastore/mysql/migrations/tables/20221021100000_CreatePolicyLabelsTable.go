@@ -0,0 +1,40 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221021100000, Down_20221021100000)
+}
+
+func Up_20221021100000(tx *sql.Tx) error {
+	// a policy without rows in policy_labels applies to all hosts (of its
+	// team and platforms), otherwise it only applies to the hosts that are
+	// members of any of its included labels (if any) and of none of its
+	// excluded labels.
+	_, err := tx.Exec(`
+    CREATE TABLE policy_labels (
+        policy_id INT UNSIGNED NOT NULL,
+        label_id  INT UNSIGNED NOT NULL,
+        exclude   TINYINT(1) NOT NULL DEFAULT 0,
+
+        PRIMARY KEY (policy_id, label_id),
+        KEY idx_policy_labels_label_id (label_id),
+        CONSTRAINT fk_policy_labels_policy_id
+            FOREIGN KEY (policy_id) REFERENCES policies (id) ON DELETE CASCADE,
+        CONSTRAINT fk_policy_labels_label_id
+            FOREIGN KEY (label_id) REFERENCES labels (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create policy_labels table")
+	}
+
+	return nil
+}
+
+func Down_20221021100000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,35 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221021100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO policies (name, query, description) VALUES ('p1', 'SELECT 1', '')`)
+	require.NoError(t, err)
+	policyID, _ := res.LastInsertId()
+	res, err = db.Exec(`INSERT INTO labels (name, description, query, platform, label_type, label_membership_type) VALUES ('l1', '', 'SELECT 1', '', 1, 0)`)
+	require.NoError(t, err)
+	labelID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	_, err = db.Exec(`INSERT INTO policy_labels (policy_id, label_id) VALUES (?, ?)`, policyID, labelID)
+	require.NoError(t, err)
+	var exclude bool
+	err = db.QueryRow(`SELECT exclude FROM policy_labels WHERE policy_id = ?`, policyID).Scan(&exclude)
+	require.NoError(t, err)
+	require.False(t, exclude)
+
+	// deleting the label deletes it from the policies
+	_, err = db.Exec(`DELETE FROM labels WHERE id = ?`, labelID)
+	require.NoError(t, err)
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM policy_labels`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
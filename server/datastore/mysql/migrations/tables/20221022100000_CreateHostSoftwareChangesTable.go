@@ -0,0 +1,42 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221022100000, Down_20221022100000)
+}
+
+func Up_20221022100000(tx *sql.Tx) error {
+	// the software fields are copied in the changes as the software entries
+	// get deleted when no host has them installed anymore.
+	_, err := tx.Exec(`
+    CREATE TABLE host_software_changes (
+        id                BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+        host_id           INT UNSIGNED NOT NULL,
+        software_id       BIGINT UNSIGNED NULL,
+        name              VARCHAR(255) NOT NULL,
+        version           VARCHAR(255) NOT NULL DEFAULT '',
+        source            VARCHAR(64) NOT NULL,
+        bundle_identifier VARCHAR(255) NOT NULL DEFAULT '',
+        vendor            VARCHAR(114) NOT NULL DEFAULT '',
+        action            VARCHAR(20) NOT NULL,
+        created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        KEY idx_host_software_changes_host_id_created_at (host_id, created_at),
+        KEY idx_host_software_changes_created_at (created_at)
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create host_software_changes table")
+	}
+
+	return nil
+}
+
+func Down_20221022100000(tx *sql.Tx) error {
+	return nil
+}
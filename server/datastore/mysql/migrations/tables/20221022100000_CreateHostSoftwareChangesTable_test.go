@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221022100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	applyNext(t, db)
+
+	_, err := db.Exec(`
+		INSERT INTO host_software_changes (host_id, software_id, name, version, source, action)
+		VALUES (1, 1, 'zoom', '1.0', 'apps', 'installed'), (1, NULL, 'slack', '', 'apps', 'uninstalled')`)
+	require.NoError(t, err)
+
+	// the name is required
+	_, err = db.Exec(`INSERT INTO host_software_changes (host_id, source, action) VALUES (1, 'apps', 'installed')`)
+	require.Error(t, err)
+
+	var createdAtSet int
+	err = db.QueryRow(`SELECT COUNT(*) FROM host_software_changes WHERE created_at IS NOT NULL`).Scan(&createdAtSet)
+	require.NoError(t, err)
+	require.Equal(t, 2, createdAtSet)
+}
@@ -0,0 +1,66 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221023100000, Down_20221023100000)
+}
+
+func Up_20221023100000(tx *sql.Tx) error {
+	// a rule with a NULL team_id is a global rule, it applies to all hosts.
+	_, err := tx.Exec(`
+    CREATE TABLE software_rules (
+        id                  INT UNSIGNED NOT NULL AUTO_INCREMENT,
+        team_id             INT UNSIGNED NULL,
+        name                VARCHAR(255) NOT NULL,
+        description         TEXT NOT NULL,
+        action              VARCHAR(20) NOT NULL,
+        software_name       VARCHAR(255) NOT NULL DEFAULT '',
+        software_name_regex VARCHAR(255) NOT NULL DEFAULT '',
+        bundle_identifier   VARCHAR(255) NOT NULL DEFAULT '',
+        version_range       VARCHAR(255) NOT NULL DEFAULT '',
+        policy_id           INT UNSIGNED NULL,
+        author_id           INT UNSIGNED NULL,
+        created_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        CONSTRAINT fk_software_rules_team_id
+            FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE,
+        CONSTRAINT fk_software_rules_policy_id
+            FOREIGN KEY (policy_id) REFERENCES policies (id) ON DELETE SET NULL,
+        CONSTRAINT fk_software_rules_author_id
+            FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create software_rules table")
+	}
+
+	// the violations are recomputed periodically from the software rules and
+	// the software installed on the hosts.
+	_, err = tx.Exec(`
+    CREATE TABLE host_software_rule_violations (
+        host_id     INT UNSIGNED NOT NULL,
+        rule_id     INT UNSIGNED NOT NULL,
+        software_id BIGINT UNSIGNED NOT NULL,
+        created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (host_id, rule_id, software_id),
+        KEY idx_host_software_rule_violations_rule_id (rule_id),
+        CONSTRAINT fk_host_software_rule_violations_rule_id
+            FOREIGN KEY (rule_id) REFERENCES software_rules (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create host_software_rule_violations table")
+	}
+
+	return nil
+}
+
+func Down_20221023100000(tx *sql.Tx) error {
+	return nil
+}
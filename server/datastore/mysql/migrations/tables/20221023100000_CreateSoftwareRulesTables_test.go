@@ -0,0 +1,34 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221023100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	applyNext(t, db)
+
+	res, err := db.Exec(`
+		INSERT INTO software_rules (name, description, action, software_name)
+		VALUES ('no torrents', '', 'deny', 'uTorrent')`)
+	require.NoError(t, err)
+	ruleID, _ := res.LastInsertId()
+
+	_, err = db.Exec(`INSERT INTO host_software_rule_violations (host_id, rule_id, software_id) VALUES (1, ?, 1)`, ruleID)
+	require.NoError(t, err)
+
+	// the rule must exist
+	_, err = db.Exec(`INSERT INTO host_software_rule_violations (host_id, rule_id, software_id) VALUES (1, ?, 1)`, ruleID+1)
+	require.Error(t, err)
+
+	// deleting the rule deletes its violations
+	_, err = db.Exec(`DELETE FROM software_rules WHERE id = ?`, ruleID)
+	require.NoError(t, err)
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM host_software_rule_violations`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
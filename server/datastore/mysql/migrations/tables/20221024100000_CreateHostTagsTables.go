@@ -0,0 +1,64 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221024100000, Down_20221024100000)
+}
+
+func Up_20221024100000(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+    CREATE TABLE host_tag_keys (
+        id          INT UNSIGNED NOT NULL AUTO_INCREMENT,
+        name        VARCHAR(255) NOT NULL,
+        type        VARCHAR(20) NOT NULL,
+        description TEXT NOT NULL,
+        created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        UNIQUE KEY idx_host_tag_keys_name (name)
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create host_tag_keys table")
+	}
+
+	// the values are stored in their canonical string form, validated against
+	// the type of their key.
+	_, err = tx.Exec(`
+    CREATE TABLE host_tags (
+        host_id    INT UNSIGNED NOT NULL,
+        key_id     INT UNSIGNED NOT NULL,
+        value      VARCHAR(255) NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (host_id, key_id),
+        KEY idx_host_tags_key_id_value (key_id, value),
+        CONSTRAINT fk_host_tags_key_id
+            FOREIGN KEY (key_id) REFERENCES host_tag_keys (id) ON DELETE CASCADE
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create host_tags table")
+	}
+
+	// labels with the host_tag membership type are made of the hosts with the
+	// tag set (to the value, if not NULL).
+	_, err = tx.Exec(`
+    ALTER TABLE labels
+        ADD COLUMN host_tag_key VARCHAR(255) NULL,
+        ADD COLUMN host_tag_value VARCHAR(255) NULL`)
+	if err != nil {
+		return errors.Wrap(err, "add host tag columns to labels")
+	}
+
+	return nil
+}
+
+func Down_20221024100000(tx *sql.Tx) error {
+	return nil
+}
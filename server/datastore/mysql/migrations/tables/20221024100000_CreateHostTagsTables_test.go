@@ -0,0 +1,41 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221024100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	applyNext(t, db)
+
+	res, err := db.Exec(`INSERT INTO host_tag_keys (name, type, description) VALUES ('owner', 'string', '')`)
+	require.NoError(t, err)
+	keyID, _ := res.LastInsertId()
+
+	_, err = db.Exec(`INSERT INTO host_tags (host_id, key_id, value) VALUES (1, ?, 'alice')`, keyID)
+	require.NoError(t, err)
+
+	// the key must exist
+	_, err = db.Exec(`INSERT INTO host_tags (host_id, key_id, value) VALUES (1, ?, 'alice')`, keyID+1)
+	require.Error(t, err)
+
+	// the key names are unique
+	_, err = db.Exec(`INSERT INTO host_tag_keys (name, type, description) VALUES ('owner', 'number', '')`)
+	require.Error(t, err)
+
+	_, err = db.Exec(`
+		INSERT INTO labels (name, query, label_membership_type, host_tag_key, host_tag_value)
+		VALUES ('alice hosts', '', 2, 'owner', 'alice')`)
+	require.NoError(t, err)
+
+	// deleting the key deletes its values
+	_, err = db.Exec(`DELETE FROM host_tag_keys WHERE id = ?`, keyID)
+	require.NoError(t, err)
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM host_tags`).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,28 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221024110000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO teams (name) VALUES ('team1')`)
+	require.NoError(t, err)
+	teamID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	_, err = db.Exec(`INSERT INTO host_manual_teams (host_id, team_id) VALUES (1, ?), (2, NULL)`, teamID)
+	require.NoError(t, err)
+
+	// the transfers to a team are forgotten when the team is deleted
+	_, err = db.Exec(`DELETE FROM teams WHERE id = ?`, teamID)
+	require.NoError(t, err)
+	var hostIDs []uint
+	err = db.Select(&hostIDs, `SELECT host_id FROM host_manual_teams`)
+	require.NoError(t, err)
+	require.Equal(t, []uint{2}, hostIDs)
+}
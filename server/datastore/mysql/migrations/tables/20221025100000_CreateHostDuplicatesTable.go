@@ -0,0 +1,36 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221025100000, Down_20221025100000)
+}
+
+func Up_20221025100000(tx *sql.Tx) error {
+	// host_id is the host that enrolled most recently, duplicate_host_id the
+	// older host with the same hardware serial or UUID.
+	_, err := tx.Exec(`
+    CREATE TABLE host_duplicates (
+        id                INT UNSIGNED NOT NULL AUTO_INCREMENT,
+        host_id           INT UNSIGNED NOT NULL,
+        duplicate_host_id INT UNSIGNED NOT NULL,
+        matched_by        VARCHAR(20) NOT NULL,
+        created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        UNIQUE KEY idx_host_duplicates_host_id_duplicate_host_id (host_id, duplicate_host_id),
+        KEY idx_host_duplicates_duplicate_host_id (duplicate_host_id)
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create host_duplicates table")
+	}
+	return nil
+}
+
+func Down_20221025100000(tx *sql.Tx) error {
+	return nil
+}
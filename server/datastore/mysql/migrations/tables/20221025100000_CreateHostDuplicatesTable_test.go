@@ -0,0 +1,23 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221025100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	applyNext(t, db)
+
+	_, err := db.Exec(`INSERT INTO host_duplicates (host_id, duplicate_host_id, matched_by) VALUES (2, 1, 'hardware_serial')`)
+	require.NoError(t, err)
+
+	// a pair of hosts is only flagged once
+	_, err = db.Exec(`INSERT INTO host_duplicates (host_id, duplicate_host_id, matched_by) VALUES (2, 1, 'uuid')`)
+	require.Error(t, err)
+
+	_, err = db.Exec(`INSERT INTO host_duplicates (host_id, duplicate_host_id, matched_by) VALUES (3, 1, 'uuid')`)
+	require.NoError(t, err)
+}
@@ -0,0 +1,26 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221026100000, Down_20221026100000)
+}
+
+func Up_20221026100000(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE hosts
+			ADD COLUMN lifecycle_state VARCHAR(20) NOT NULL DEFAULT 'active',
+			ADD KEY idx_hosts_lifecycle_state (lifecycle_state)`)
+	if err != nil {
+		return errors.Wrap(err, "add lifecycle_state to hosts")
+	}
+	return nil
+}
+
+func Down_20221026100000(tx *sql.Tx) error {
+	return nil
+}
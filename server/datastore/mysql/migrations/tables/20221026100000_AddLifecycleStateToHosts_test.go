@@ -0,0 +1,42 @@
+package tables
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221026100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	zeroTime := time.Unix(0, 0).Add(24 * time.Hour)
+	sqlInsert := `
+		INSERT INTO hosts (
+			detail_updated_at,
+			label_updated_at,
+			policy_updated_at,
+			osquery_host_id,
+			node_key
+		) VALUES (?, ?, ?, ?, ?)`
+	_, err := db.Exec(sqlInsert, zeroTime, zeroTime, zeroTime, "host1", "key1")
+	require.NoError(t, err)
+
+	applyNext(t, db)
+
+	// the existing hosts are active
+	var state string
+	err = db.Get(&state, `SELECT lifecycle_state FROM hosts WHERE osquery_host_id = ?`, "host1")
+	require.NoError(t, err)
+	require.Equal(t, "active", state)
+
+	_, err = db.Exec(sqlInsert, zeroTime, zeroTime, zeroTime, "host2", "key2")
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE hosts SET lifecycle_state = 'retired' WHERE osquery_host_id = ?`, "host2")
+	require.NoError(t, err)
+
+	var count int
+	err = db.Get(&count, `SELECT COUNT(*) FROM hosts WHERE lifecycle_state = 'active'`)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
@@ -0,0 +1,33 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221026110000, Down_20221026110000)
+}
+
+func Up_20221026110000(tx *sql.Tx) error {
+	// team_id is the team of the host before it was moved to the quarantine
+	// team, NULL if it had no team.
+	_, err := tx.Exec(`
+    CREATE TABLE host_quarantine_teams (
+        host_id    INT(10) UNSIGNED NOT NULL,
+        team_id    INT(10) UNSIGNED NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (host_id),
+        CONSTRAINT fk_host_quarantine_teams_team_id FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE SET NULL
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create host_quarantine_teams table")
+	}
+	return nil
+}
+
+func Down_20221026110000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,28 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221026110000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	res, err := db.Exec(`INSERT INTO teams (name) VALUES ('team1')`)
+	require.NoError(t, err)
+	teamID, _ := res.LastInsertId()
+
+	applyNext(t, db)
+
+	_, err = db.Exec(`INSERT INTO host_quarantine_teams (host_id, team_id) VALUES (1, ?), (2, NULL)`, teamID)
+	require.NoError(t, err)
+
+	// the hosts have no team to go back to if the team is deleted
+	_, err = db.Exec(`DELETE FROM teams WHERE id = ?`, teamID)
+	require.NoError(t, err)
+	var count int
+	err = db.Get(&count, `SELECT COUNT(*) FROM host_quarantine_teams WHERE team_id IS NULL`)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
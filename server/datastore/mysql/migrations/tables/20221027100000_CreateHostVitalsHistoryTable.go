@@ -0,0 +1,40 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up_20221027100000, Down_20221027100000)
+}
+
+func Up_20221027100000(tx *sql.Tx) error {
+	// the samples are down-sampled when they are recorded, so that a host has
+	// at most one sample per configured interval.
+	_, err := tx.Exec(`
+    CREATE TABLE host_vitals_history (
+        id                           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+        host_id                      INT UNSIGNED NOT NULL,
+        gigs_disk_space_available    DECIMAL(10,2) NOT NULL DEFAULT 0,
+        percent_disk_space_available DECIMAL(10,2) NOT NULL DEFAULT 0,
+        uptime                       BIGINT NOT NULL DEFAULT 0,
+        osquery_version              VARCHAR(255) NOT NULL DEFAULT '',
+        os_version                   VARCHAR(255) NOT NULL DEFAULT '',
+        recorded_at                  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+        PRIMARY KEY (id),
+        KEY idx_host_vitals_history_host_id_recorded_at (host_id, recorded_at),
+        KEY idx_host_vitals_history_recorded_at (recorded_at)
+    ) DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return errors.Wrap(err, "create host_vitals_history table")
+	}
+
+	return nil
+}
+
+func Down_20221027100000(tx *sql.Tx) error {
+	return nil
+}
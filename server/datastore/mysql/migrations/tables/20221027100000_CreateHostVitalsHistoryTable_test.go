@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_20221027100000(t *testing.T) {
+	db := applyUpToPrev(t)
+
+	applyNext(t, db)
+
+	_, err := db.Exec(`
+		INSERT INTO host_vitals_history (host_id, gigs_disk_space_available, percent_disk_space_available, uptime, osquery_version, os_version)
+		VALUES (1, 12.5, 25, 3600000000000, '5.5.1', 'macOS 12.6')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO host_vitals_history (host_id) VALUES (1)`)
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM host_vitals_history WHERE host_id = 1`).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
@@ -221,12 +221,15 @@ type entity struct {
 }
 
 var (
-	hostsTable    = entity{"hosts"}
-	invitesTable  = entity{"invites"}
-	packsTable    = entity{"packs"}
-	queriesTable  = entity{"queries"}
-	sessionsTable = entity{"sessions"}
-	usersTable    = entity{"users"}
+	activityWebhooksTable     = entity{"activity_webhooks"}
+	hostsTable                = entity{"hosts"}
+	invitesTable              = entity{"invites"}
+	packsTable                = entity{"packs"}
+	queriesTable              = entity{"queries"}
+	scheduledLiveQueriesTable = entity{"scheduled_live_queries"}
+	scimGroupsTable           = entity{"scim_groups"}
+	sessionsTable             = entity{"sessions"}
+	usersTable                = entity{"users"}
 )
 
 var doRetryErr = errors.New("fleet datastore retry")
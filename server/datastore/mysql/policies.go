@@ -26,23 +26,31 @@ func (ds *Datastore) NewGlobalPolicy(ctx context.Context, authorID *uint, args f
 		args.Query = q.Query
 		args.Description = q.Description
 	}
-	res, err := ds.writer.ExecContext(ctx,
-		`INSERT INTO policies (name, query, description, resolution, author_id, platforms) VALUES (?, ?, ?, ?, ?, ?)`,
-		args.Name, args.Query, args.Description, args.Resolution, authorID, args.Platform,
-	)
-	switch {
-	case err == nil:
-		// OK
-	case isDuplicate(err):
-		return nil, ctxerr.Wrap(ctx, alreadyExists("Policy", args.Name))
-	default:
-		return nil, ctxerr.Wrap(ctx, err, "inserting new policy")
-	}
-	lastIdInt64, err := res.LastInsertId()
+	var policyID uint
+	err := ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO policies (name, query, description, resolution, author_id, platforms, remediation_type, remediation, severity, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			args.Name, args.Query, args.Description, args.Resolution, authorID, args.Platform, args.RemediationType, args.Remediation, args.Severity, fleet.PolicyTags(args.Tags),
+		)
+		switch {
+		case err == nil:
+			// OK
+		case isDuplicate(err):
+			return ctxerr.Wrap(ctx, alreadyExists("Policy", args.Name))
+		default:
+			return ctxerr.Wrap(ctx, err, "inserting new policy")
+		}
+		lastIdInt64, err := res.LastInsertId()
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "getting last id after inserting policy")
+		}
+		policyID = uint(lastIdInt64)
+		return setPolicyLabelsDB(ctx, tx, policyID, args.LabelsIncludeAny, args.LabelsExcludeAny)
+	})
 	if err != nil {
-		return nil, ctxerr.Wrap(ctx, err, "getting last id after inserting policy")
+		return nil, err
 	}
-	return policyDB(ctx, ds.writer, uint(lastIdInt64), nil)
+	return policyDB(ctx, ds.writer, policyID, nil)
 }
 
 func (ds *Datastore) Policy(ctx context.Context, id uint) (*fleet.Policy, error) {
@@ -63,10 +71,10 @@ func policyDB(ctx context.Context, q sqlx.QueryerContext, id uint, teamID *uint)
 		    COALESCE(u.name, '<deleted>') AS author_name,
 			COALESCE(u.email, '') AS author_email,
        		(select count(*) from policy_membership where policy_id=p.id and passes=true) as passing_host_count,
-       		(select count(*) from policy_membership where policy_id=p.id and passes=false) as failing_host_count
+       		(select count(*) from policy_membership pm where pm.policy_id=p.id and pm.passes=false and not %s) as failing_host_count
 		FROM policies p
 		LEFT JOIN users u ON p.author_id = u.id
-		WHERE p.id=? AND %s`, teamWhere),
+		WHERE p.id=? AND %s`, policyWaivedCond("pm.policy_id", "pm.host_id"), teamWhere),
 		args...)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -74,6 +82,9 @@ func policyDB(ctx context.Context, q sqlx.QueryerContext, id uint, teamID *uint)
 		}
 		return nil, ctxerr.Wrap(ctx, err, "getting policy")
 	}
+	if err := loadPoliciesLabelsDB(ctx, q, []*fleet.Policy{&policy}); err != nil {
+		return nil, err
+	}
 	return &policy, nil
 }
 
@@ -83,22 +94,30 @@ func policyDB(ctx context.Context, q sqlx.QueryerContext, id uint, teamID *uint)
 func (ds *Datastore) SavePolicy(ctx context.Context, p *fleet.Policy) error {
 	sql := `
 		UPDATE policies
-			SET name = ?, query = ?, description = ?, resolution = ?, platforms = ?
+			SET name = ?, query = ?, description = ?, resolution = ?, platforms = ?, remediation_type = ?, remediation = ?, severity = ?, tags = ?
 			WHERE id = ?
 	`
-	result, err := ds.writer.ExecContext(ctx, sql, p.Name, p.Query, p.Description, p.Resolution, p.Platform, p.ID)
-	if err != nil {
-		return ctxerr.Wrap(ctx, err, "updating policy")
-	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return ctxerr.Wrap(ctx, err, "rows affected updating policy")
-	}
-	if rows == 0 {
-		return ctxerr.Wrap(ctx, notFound("Policy").WithID(p.ID))
-	}
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		result, err := tx.ExecContext(ctx, sql, p.Name, p.Query, p.Description, p.Resolution, p.Platform, p.RemediationType, p.Remediation, p.Severity, p.Tags, p.ID)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "updating policy")
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "rows affected updating policy")
+		}
+		if rows == 0 {
+			return ctxerr.Wrap(ctx, notFound("Policy").WithID(p.ID))
+		}
 
-	return cleanupPolicyMembershipOnPolicyUpdate(ctx, ds.writer, p.ID, p.Platform)
+		if err := setPolicyLabelsDB(ctx, tx, p.ID, p.LabelsIncludeAny, p.LabelsExcludeAny); err != nil {
+			return err
+		}
+		if err := cleanupPolicyMembershipOnPolicyUpdate(ctx, tx, p.ID, p.Platform); err != nil {
+			return err
+		}
+		return cleanupPolicyMembershipOutOfLabelScopeDB(ctx, tx, &p.ID)
+	})
 }
 
 // FlippingPoliciesForHost fetches previous policy membership results and returns:
@@ -154,6 +173,21 @@ func (ds *Datastore) FlippingPoliciesForHost(
 		prevPolicyResults[result.ID] = result.Passes
 	}
 	newFailing, newPassing = flipping(prevPolicyResults, filteredIncomingResults)
+
+	// the host does not start failing the policies it is exempted from.
+	waived, err := waivedPoliciesForHostDB(ctx, ds.reader, hostID, newFailing)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(waived) > 0 {
+		notWaived := newFailing[:0]
+		for _, policyID := range newFailing {
+			if !waived[policyID] {
+				notWaived = append(notWaived, policyID)
+			}
+		}
+		newFailing = notWaived
+	}
 	return newFailing, newPassing, nil
 }
 
@@ -197,10 +231,12 @@ func (ds *Datastore) RecordPolicyQueryExecutions(ctx context.Context, host *flee
 	// Loop through results, collecting which labels we need to insert/update
 	vals := []interface{}{}
 	bindvars := []string{}
+	memberships := make([]fleet.PolicyMembershipResult, 0, len(orderedIDs))
 	for _, policyID := range orderedIDs {
 		matches := results[policyID]
 		bindvars = append(bindvars, "(?,?,?,?)")
 		vals = append(vals, updated, policyID, host.ID, matches)
+		memberships = append(memberships, fleet.PolicyMembershipResult{HostID: host.ID, PolicyID: policyID, Passes: matches, ReportedAt: updated})
 	}
 
 	// NOTE: the insert of policy membership that follows must be kept in sync
@@ -217,6 +253,10 @@ func (ds *Datastore) RecordPolicyQueryExecutions(ctx context.Context, host *flee
 	)
 
 	err := ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		if err := recordPolicyMembershipTransitionsDB(ctx, tx, memberships); err != nil {
+			return err
+		}
+
 		_, err := tx.ExecContext(ctx, query, vals...)
 		if err != nil {
 			return ctxerr.Wrapf(ctx, err, "insert policy_membership (%v)", vals)
@@ -271,12 +311,12 @@ func listPoliciesDB(ctx context.Context, q sqlx.QueryerContext, teamID, countsFo
 
 	counts := `
     (select count(*) from policy_membership where policy_id=p.id and passes=true) as passing_host_count,
-    (select count(*) from policy_membership where policy_id=p.id and passes=false) as failing_host_count
+    (select count(*) from policy_membership pm where pm.policy_id=p.id and pm.passes=false and not ` + policyWaivedCond("pm.policy_id", "pm.host_id") + `) as failing_host_count
 `
 	if countsForTeamID != nil {
 		counts = `
         (select count(*) from policy_membership pm inner join hosts h on pm.host_id = h.id where pm.policy_id=p.id and pm.passes=true and h.team_id = ?) as passing_host_count,
-        (select count(*) from policy_membership pm inner join hosts h on pm.host_id = h.id where pm.policy_id=p.id and pm.passes=false and h.team_id = ?) as failing_host_count
+        (select count(*) from policy_membership pm inner join hosts h on pm.host_id = h.id where pm.policy_id=p.id and pm.passes=false and h.team_id = ? and not ` + policyWaivedCond("pm.policy_id", "pm.host_id") + `) as failing_host_count
 `
 		args = append(args, *countsForTeamID, *countsForTeamID)
 	}
@@ -300,6 +340,10 @@ func listPoliciesDB(ctx context.Context, q sqlx.QueryerContext, teamID, countsFo
       p.description,
       p.author_id,
       p.platforms,
+      p.remediation_type,
+      p.remediation,
+      p.severity,
+      p.tags,
       p.created_at,
       p.updated_at,
       COALESCE(u.name, '<deleted>') AS author_name,
@@ -312,6 +356,9 @@ func listPoliciesDB(ctx context.Context, q sqlx.QueryerContext, teamID, countsFo
 	if err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "listing policies")
 	}
+	if err := loadPoliciesLabelsDB(ctx, q, policies); err != nil {
+		return nil, err
+	}
 	return policies, nil
 }
 
@@ -324,12 +371,16 @@ func (ds *Datastore) PoliciesByID(ctx context.Context, ids []uint) (map[uint]*fl
       p.description,
       p.author_id,
       p.platforms,
+      p.remediation_type,
+      p.remediation,
+      p.severity,
+      p.tags,
       p.created_at,
       p.updated_at,
       COALESCE(u.name, '<deleted>') AS author_name,
       COALESCE(u.email, '') AS author_email,
       (select count(*) from policy_membership where policy_id=p.id and passes=true) as passing_host_count,
-      (select count(*) from policy_membership where policy_id=p.id and passes=false) as failing_host_count
+      (select count(*) from policy_membership pm where pm.policy_id=p.id and pm.passes=false and not ` + policyWaivedCond("pm.policy_id", "pm.host_id") + `) as failing_host_count
       FROM policies p
       LEFT JOIN users u ON p.author_id = u.id
       WHERE p.id IN (?)`
@@ -348,6 +399,9 @@ func (ds *Datastore) PoliciesByID(ctx context.Context, ids []uint) (map[uint]*fl
 	if err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "getting policies by ID")
 	}
+	if err := loadPoliciesLabelsDB(ctx, ds.reader, policies); err != nil {
+		return nil, err
+	}
 
 	policiesByID := make(map[uint]*fleet.Policy, len(ids))
 	for _, p := range policies {
@@ -413,6 +467,7 @@ func (ds *Datastore) PolicyQueriesForHost(ctx context.Context, host *fleet.Host)
 				goqu.I("team_id").IsNull(),        // global policies
 				goqu.I("team_id").Eq(host.TeamID), // team policies
 			),
+			goqu.L(policyLabelScopeCond("policies.id", "?"), host.ID, host.ID),
 		),
 	)
 	sql, args, err := q.ToSQL()
@@ -429,6 +484,59 @@ func (ds *Datastore) PolicyQueriesForHost(ctx context.Context, host *fleet.Host)
 	return results, nil
 }
 
+// PolicyRemediationsForHost returns the policies that apply to the given host
+// and have a remediation, keyed by policy ID.
+func (ds *Datastore) PolicyRemediationsForHost(ctx context.Context, host *fleet.Host) (map[uint]*fleet.Policy, error) {
+	stmt := `
+		SELECT id, team_id, name, remediation_type, remediation
+		FROM policies
+		WHERE remediation_type != '' AND
+			(platforms = '' OR FIND_IN_SET(?, platforms) != 0) AND
+			(team_id IS NULL OR team_id = ?) AND
+			` + policyLabelScopeCond("policies.id", "?")
+
+	var policies []*fleet.Policy
+	if err := sqlx.SelectContext(ctx, ds.reader, &policies, stmt, host.FleetPlatform(), host.TeamID, host.ID, host.ID); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "selecting policy remediations for host")
+	}
+	results := make(map[uint]*fleet.Policy, len(policies))
+	for _, p := range policies {
+		results[p.ID] = p
+	}
+	return results, nil
+}
+
+func (ds *Datastore) PolicyResultsBySeverity(ctx context.Context, filter fleet.TeamFilter, platform *string) ([]*fleet.PolicySeverityResults, error) {
+	var args []interface{}
+	whereClause := ds.whereFilterHostsByTeams(filter, "h")
+	if platform != nil {
+		whereClause += " AND h.platform IN (?) "
+		args = append(args, fleet.ExpandPlatform(*platform))
+	}
+
+	stmt := fmt.Sprintf(`
+		SELECT
+			p.severity,
+			COALESCE(SUM(pm.passes = 1), 0) AS passing_count,
+			COALESCE(SUM(pm.passes = 0), 0) AS failing_count
+		FROM policy_membership pm
+		JOIN policies p ON p.id = pm.policy_id
+		JOIN hosts h ON h.id = pm.host_id
+		WHERE %s
+		GROUP BY p.severity
+		ORDER BY p.severity`, whereClause)
+	stmt, args, err := sqlx.In(stmt, args...)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "build policy results by severity query")
+	}
+
+	var results []*fleet.PolicySeverityResults
+	if err := sqlx.SelectContext(ctx, ds.reader, &results, stmt, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "select policy results by severity")
+	}
+	return results, nil
+}
+
 func (ds *Datastore) NewTeamPolicy(ctx context.Context, teamID uint, authorID *uint, args fleet.PolicyPayload) (*fleet.Policy, error) {
 	if args.QueryID != nil {
 		q, err := ds.Query(ctx, *args.QueryID)
@@ -439,22 +547,30 @@ func (ds *Datastore) NewTeamPolicy(ctx context.Context, teamID uint, authorID *u
 		args.Query = q.Query
 		args.Description = q.Description
 	}
-	res, err := ds.writer.ExecContext(ctx,
-		`INSERT INTO policies (name, query, description, team_id, resolution, author_id, platforms) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		args.Name, args.Query, args.Description, teamID, args.Resolution, authorID, args.Platform)
-	switch {
-	case err == nil:
-		// OK
-	case isDuplicate(err):
-		return nil, ctxerr.Wrap(ctx, alreadyExists("Policy", args.Name))
-	default:
-		return nil, ctxerr.Wrap(ctx, err, "inserting new policy")
-	}
-	lastIdInt64, err := res.LastInsertId()
+	var policyID uint
+	err := ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO policies (name, query, description, team_id, resolution, author_id, platforms, remediation_type, remediation, severity, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			args.Name, args.Query, args.Description, teamID, args.Resolution, authorID, args.Platform, args.RemediationType, args.Remediation, args.Severity, fleet.PolicyTags(args.Tags))
+		switch {
+		case err == nil:
+			// OK
+		case isDuplicate(err):
+			return ctxerr.Wrap(ctx, alreadyExists("Policy", args.Name))
+		default:
+			return ctxerr.Wrap(ctx, err, "inserting new policy")
+		}
+		lastIdInt64, err := res.LastInsertId()
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "getting last id after inserting policy")
+		}
+		policyID = uint(lastIdInt64)
+		return setPolicyLabelsDB(ctx, tx, policyID, args.LabelsIncludeAny, args.LabelsExcludeAny)
+	})
 	if err != nil {
-		return nil, ctxerr.Wrap(ctx, err, "getting last id after inserting policy")
+		return nil, err
 	}
-	return policyDB(ctx, ds.writer, uint(lastIdInt64), &teamID)
+	return policyDB(ctx, ds.writer, policyID, &teamID)
 }
 
 func (ds *Datastore) ListTeamPolicies(ctx context.Context, teamID uint) (teamPolicies, inheritedPolicies []*fleet.Policy, err error) {
@@ -495,19 +611,27 @@ func (ds *Datastore) ApplyPolicySpecs(ctx context.Context, authorID uint, specs
 			author_id,
 			resolution,
 			team_id,
-			platforms
-		) VALUES ( ?, ?, ?, ?, ?, (SELECT IFNULL(MIN(id), NULL) FROM teams WHERE name = ?), ? )
+			platforms,
+			remediation_type,
+			remediation,
+			severity,
+			tags
+		) VALUES ( ?, ?, ?, ?, ?, (SELECT IFNULL(MIN(id), NULL) FROM teams WHERE name = ?), ?, ?, ?, ?, ? )
 		ON DUPLICATE KEY UPDATE
 			name = VALUES(name),
 			query = VALUES(query),
 			description = VALUES(description),
 			author_id = VALUES(author_id),
 			resolution = VALUES(resolution),
-			platforms = VALUES(platforms)
+			platforms = VALUES(platforms),
+			remediation_type = VALUES(remediation_type),
+			remediation = VALUES(remediation),
+			severity = VALUES(severity),
+			tags = VALUES(tags)
 		`
 		for _, spec := range specs {
 			res, err := tx.ExecContext(ctx,
-				sql, spec.Name, spec.Query, spec.Description, authorID, spec.Resolution, spec.Team, spec.Platform,
+				sql, spec.Name, spec.Query, spec.Description, authorID, spec.Resolution, spec.Team, spec.Platform, spec.RemediationType, spec.Remediation, spec.Severity, fleet.PolicyTags(spec.Tags),
 			)
 			if err != nil {
 				return ctxerr.Wrap(ctx, err, "exec ApplyPolicySpecs insert")
@@ -522,6 +646,18 @@ func (ds *Datastore) ApplyPolicySpecs(ctx context.Context, authorID uint, specs
 					}
 				}
 			}
+
+			// the labels are not part of the upsert, so they are always replaced.
+			var policyID uint
+			if err := sqlx.GetContext(ctx, tx, &policyID, `SELECT id FROM policies WHERE name = ?`, spec.Name); err != nil {
+				return ctxerr.Wrap(ctx, err, "select applied policy id")
+			}
+			if err := setPolicyLabelsDB(ctx, tx, policyID, spec.LabelsIncludeAny, spec.LabelsExcludeAny); err != nil {
+				return err
+			}
+			if err := cleanupPolicyMembershipOutOfLabelScopeDB(ctx, tx, &policyID); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
@@ -554,6 +690,9 @@ func (ds *Datastore) AsyncBatchInsertPolicyMembership(ctx context.Context, batch
 		vals = append(vals, tup.PolicyID, tup.HostID, tup.Passes)
 	}
 	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		if err := recordPolicyMembershipTransitionsDB(ctx, tx, batch); err != nil {
+			return err
+		}
 		_, err := tx.ExecContext(ctx, sql, vals...)
 		return ctxerr.Wrap(ctx, err, "insert into policy_membership")
 	})
@@ -635,11 +774,138 @@ func cleanupPolicyMembershipOnPolicyUpdate(ctx context.Context, db sqlx.ExecerCo
 	return ctxerr.Wrap(ctx, err, "cleanup policy membership")
 }
 
+// policyLabelScopeCond returns the SQL condition that is true if the host is in
+// the labels scope of the policy, that is if the policy has no included labels
+// or the host is a member of any of them, and the host is not a member of any
+// of the excluded labels of the policy. policyIDExpr and hostIDExpr are the SQL
+// expressions of the policy and host IDs to check.
+func policyLabelScopeCond(policyIDExpr, hostIDExpr string) string {
+	return fmt.Sprintf(`(
+		(
+			NOT EXISTS (SELECT 1 FROM policy_labels pl WHERE pl.policy_id = %[1]s AND pl.exclude = 0) OR
+			EXISTS (
+				SELECT 1 FROM policy_labels pl
+				JOIN label_membership lm ON lm.label_id = pl.label_id
+				WHERE pl.policy_id = %[1]s AND pl.exclude = 0 AND lm.host_id = %[2]s
+			)
+		) AND NOT EXISTS (
+			SELECT 1 FROM policy_labels pl
+			JOIN label_membership lm ON lm.label_id = pl.label_id
+			WHERE pl.policy_id = %[1]s AND pl.exclude = 1 AND lm.host_id = %[2]s
+		)
+	)`, policyIDExpr, hostIDExpr)
+}
+
+// setPolicyLabelsDB replaces the labels the policy is scoped to, the labels
+// are identified by name.
+func setPolicyLabelsDB(ctx context.Context, tx sqlx.ExtContext, policyID uint, include, exclude []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM policy_labels WHERE policy_id = ?`, policyID); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete policy labels")
+	}
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+
+	names := append(append([]string{}, include...), exclude...)
+	stmt, args, err := sqlx.In(`SELECT id, name FROM labels WHERE name IN (?)`, names)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build labels by name query")
+	}
+	var labels []struct {
+		ID   uint   `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := sqlx.SelectContext(ctx, tx, &labels, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "select labels by name")
+	}
+	labelIDs := make(map[string]uint, len(labels))
+	for _, l := range labels {
+		labelIDs[l.Name] = l.ID
+	}
+
+	vals := make([]interface{}, 0, len(names)*3)
+	for i, name := range names {
+		labelID, ok := labelIDs[name]
+		if !ok {
+			return ctxerr.Wrap(ctx, notFound("Label").WithName(name))
+		}
+		vals = append(vals, policyID, labelID, i >= len(include))
+	}
+	insertStmt := `INSERT INTO policy_labels (policy_id, label_id, exclude) VALUES ` +
+		strings.TrimSuffix(strings.Repeat(`(?, ?, ?),`, len(names)), ",")
+	if _, err := tx.ExecContext(ctx, insertStmt, vals...); err != nil {
+		return ctxerr.Wrap(ctx, err, "insert policy labels")
+	}
+	return nil
+}
+
+// loadPoliciesLabelsDB sets the names of the included and excluded labels of
+// the policies.
+func loadPoliciesLabelsDB(ctx context.Context, q sqlx.QueryerContext, policies []*fleet.Policy) error {
+	if len(policies) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*fleet.Policy, len(policies))
+	ids := make([]uint, 0, len(policies))
+	for _, p := range policies {
+		byID[p.ID] = p
+		ids = append(ids, p.ID)
+	}
+
+	stmt, args, err := sqlx.In(`
+		SELECT pl.policy_id, pl.exclude, l.name
+		FROM policy_labels pl
+		JOIN labels l ON l.id = pl.label_id
+		WHERE pl.policy_id IN (?)
+		ORDER BY l.name`, ids)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build policy labels query")
+	}
+	var rows []struct {
+		PolicyID uint   `db:"policy_id"`
+		Exclude  bool   `db:"exclude"`
+		Name     string `db:"name"`
+	}
+	if err := sqlx.SelectContext(ctx, q, &rows, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "select policy labels")
+	}
+	for _, r := range rows {
+		p := byID[r.PolicyID]
+		if r.Exclude {
+			p.LabelsExcludeAny = append(p.LabelsExcludeAny, r.Name)
+		} else {
+			p.LabelsIncludeAny = append(p.LabelsIncludeAny, r.Name)
+		}
+	}
+	return nil
+}
+
+// cleanupPolicyMembershipOutOfLabelScopeDB deletes the results of the policy
+// on the hosts that are not in its labels scope, or the results of all the
+// label-scoped policies if policyID is nil.
+func cleanupPolicyMembershipOutOfLabelScopeDB(ctx context.Context, db sqlx.ExecerContext, policyID *uint) error {
+	policyWhere := `pm.policy_id IN (SELECT DISTINCT policy_id FROM policy_labels)`
+	var args []interface{}
+	if policyID != nil {
+		policyWhere = `pm.policy_id = ?`
+		args = append(args, *policyID)
+	}
+	delStmt := fmt.Sprintf(`
+		DELETE pm
+		FROM policy_membership pm
+		WHERE %s AND NOT %s`, policyWhere, policyLabelScopeCond("pm.policy_id", "pm.host_id"))
+	if _, err := db.ExecContext(ctx, delStmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "cleanup policy membership out of labels scope")
+	}
+	return nil
+}
+
 // CleanupPolicyMembership deletes the host's membership from policies that
 // have been updated recently if those hosts don't meet the policy's criteria
 // anymore (e.g. if the policy's platforms has been updated from "any" - the
 // empty string - to "windows", this would delete that policy's membership rows
-// for any non-windows host).
+// for any non-windows host). It also deletes the membership of the hosts that
+// are not in the labels scope of their policies anymore.
 func (ds *Datastore) CleanupPolicyMembership(ctx context.Context, now time.Time) error {
 	const (
 		recentlyUpdatedPoliciesInterval = 24 * time.Hour
@@ -689,7 +955,9 @@ func (ds *Datastore) CleanupPolicyMembership(ctx context.Context, now time.Time)
 		}
 	}
 
-	return nil
+	// the members of the labels change over time, so the label-scoped policies
+	// are always cleaned up, regardless of when they were updated.
+	return cleanupPolicyMembershipOutOfLabelScopeDB(ctx, ds.writer, nil)
 }
 
 // PolicyViolationDays is a structure used for aggregate counts of policy violation days.
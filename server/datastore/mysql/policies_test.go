@@ -45,6 +45,11 @@ func TestPolicies(t *testing.T) {
 		{"CleanupPolicyMembership", testPolicyCleanupPolicyMembership},
 		{"DeleteAllPolicyMemberships", testDeleteAllPolicyMemberships},
 		{"PolicyViolationDays", testPolicyViolationDays},
+		{"PolicyRemediationsForHost", testPolicyRemediationsForHost},
+		{"PolicySeverityAndTags", testPolicySeverityAndTags},
+		{"PolicyResultsBySeverity", testPolicyResultsBySeverity},
+		{"PolicyLabels", testPolicyLabels},
+		{"PolicyLabelsScope", testPolicyLabelsScope},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -2070,3 +2075,347 @@ func testDeleteAllPolicyMemberships(t *testing.T, ds *Datastore) {
 	require.NoError(t, err)
 	require.Equal(t, 0, count)
 }
+
+func testPolicyRemediationsForHost(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user1 := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	team1, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	team2, err := ds.NewTeam(ctx, &fleet.Team{Name: "team2"})
+	require.NoError(t, err)
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", &team1.ID)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "windows", nil)
+
+	gp, err := ds.NewGlobalPolicy(ctx, &user1.ID, fleet.PolicyPayload{
+		Name:            "global script",
+		Query:           "select 1;",
+		RemediationType: fleet.ScriptExecutionTypeScript,
+		Remediation:     "echo fix",
+	})
+	require.NoError(t, err)
+	require.Equal(t, fleet.ScriptExecutionTypeScript, gp.RemediationType)
+	require.Equal(t, "echo fix", *gp.Remediation)
+
+	tp1, err := ds.NewTeamPolicy(ctx, team1.ID, &user1.ID, fleet.PolicyPayload{
+		Name:            "team1 osquery",
+		Query:           "select 1;",
+		Platform:        "darwin",
+		RemediationType: fleet.ScriptExecutionTypeOsquery,
+		Remediation:     "select * from fix;",
+	})
+	require.NoError(t, err)
+	_, err = ds.NewTeamPolicy(ctx, team2.ID, &user1.ID, fleet.PolicyPayload{
+		Name:            "team2 script",
+		Query:           "select 1;",
+		RemediationType: fleet.ScriptExecutionTypeScript,
+		Remediation:     "echo team2",
+	})
+	require.NoError(t, err)
+	// policies without remediation are not returned
+	newTestPolicy(t, ds, user1, "no remediation", "", nil)
+
+	remediations, err := ds.PolicyRemediationsForHost(ctx, host1)
+	require.NoError(t, err)
+	require.Len(t, remediations, 2)
+	require.Equal(t, "echo fix", *remediations[gp.ID].Remediation)
+	require.Equal(t, fleet.ScriptExecutionTypeOsquery, remediations[tp1.ID].RemediationType)
+	require.Equal(t, "select * from fix;", *remediations[tp1.ID].Remediation)
+
+	remediations, err = ds.PolicyRemediationsForHost(ctx, host2)
+	require.NoError(t, err)
+	require.Len(t, remediations, 1)
+	require.Contains(t, remediations, gp.ID)
+
+	// removing the remediation of the global policy
+	gp.RemediationType = ""
+	gp.Remediation = nil
+	require.NoError(t, ds.SavePolicy(ctx, gp))
+	remediations, err = ds.PolicyRemediationsForHost(ctx, host2)
+	require.NoError(t, err)
+	require.Empty(t, remediations)
+}
+
+func testPolicySeverityAndTags(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user1 := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	team1, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+
+	gp, err := ds.NewGlobalPolicy(ctx, &user1.ID, fleet.PolicyPayload{
+		Name:     "global",
+		Query:    "select 1;",
+		Severity: fleet.PolicySeverityCritical,
+		Tags:     []string{"cis-1.1", "cis-level-1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, fleet.PolicySeverityCritical, gp.Severity)
+	require.Equal(t, fleet.PolicyTags{"cis-1.1", "cis-level-1"}, gp.Tags)
+
+	tp, err := ds.NewTeamPolicy(ctx, team1.ID, &user1.ID, fleet.PolicyPayload{
+		Name:  "team",
+		Query: "select 1;",
+	})
+	require.NoError(t, err)
+	require.Empty(t, tp.Severity)
+	require.Empty(t, tp.Tags)
+
+	tp.Severity = fleet.PolicySeverityLow
+	tp.Tags = fleet.PolicyTags{"internal"}
+	require.NoError(t, ds.SavePolicy(ctx, tp))
+	tp2, err := ds.TeamPolicy(ctx, team1.ID, tp.ID)
+	require.NoError(t, err)
+	require.Equal(t, fleet.PolicySeverityLow, tp2.Severity)
+	require.Equal(t, fleet.PolicyTags{"internal"}, tp2.Tags)
+
+	policies, err := ds.ListGlobalPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	require.Equal(t, fleet.PolicySeverityCritical, policies[0].Severity)
+	require.Equal(t, fleet.PolicyTags{"cis-1.1", "cis-level-1"}, policies[0].Tags)
+
+	byID, err := ds.PoliciesByID(ctx, []uint{gp.ID, tp.ID})
+	require.NoError(t, err)
+	require.Equal(t, fleet.PolicyTags{"cis-1.1", "cis-level-1"}, byID[gp.ID].Tags)
+	require.Equal(t, fleet.PolicyTags{"internal"}, byID[tp.ID].Tags)
+
+	// apply specs updates the severity and tags
+	require.NoError(t, ds.ApplyPolicySpecs(ctx, user1.ID, []*fleet.PolicySpec{
+		{Name: "global", Query: "select 1;", Severity: fleet.PolicySeverityHigh, Tags: []string{"cis-1.2"}},
+		{Name: "new", Query: "select 2;", Severity: fleet.PolicySeverityMedium},
+	}))
+	policies, err = ds.ListGlobalPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	sort.Slice(policies, func(i, j int) bool { return policies[i].ID < policies[j].ID })
+	require.Equal(t, fleet.PolicySeverityHigh, policies[0].Severity)
+	require.Equal(t, fleet.PolicyTags{"cis-1.2"}, policies[0].Tags)
+	require.Equal(t, fleet.PolicySeverityMedium, policies[1].Severity)
+	require.Empty(t, policies[1].Tags)
+}
+
+func testPolicyResultsBySeverity(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user1 := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	team1, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", &team1.ID)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "windows", nil)
+
+	filter := fleet.TeamFilter{User: test.UserAdmin}
+	results, err := ds.PolicyResultsBySeverity(ctx, filter, nil)
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	critical, err := ds.NewGlobalPolicy(ctx, &user1.ID, fleet.PolicyPayload{
+		Name: "critical", Query: "select 1;", Severity: fleet.PolicySeverityCritical,
+	})
+	require.NoError(t, err)
+	low, err := ds.NewGlobalPolicy(ctx, &user1.ID, fleet.PolicyPayload{
+		Name: "low", Query: "select 1;", Severity: fleet.PolicySeverityLow,
+	})
+	require.NoError(t, err)
+	unset, err := ds.NewTeamPolicy(ctx, team1.ID, &user1.ID, fleet.PolicyPayload{
+		Name: "unset", Query: "select 1;",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, host1, map[uint]*bool{
+		critical.ID: ptr.Bool(false),
+		low.ID:      ptr.Bool(true),
+		unset.ID:    ptr.Bool(true),
+	}, time.Now(), false))
+	require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, host2, map[uint]*bool{
+		critical.ID: ptr.Bool(true),
+		low.ID:      ptr.Bool(true),
+	}, time.Now(), false))
+
+	results, err = ds.PolicyResultsBySeverity(ctx, filter, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*fleet.PolicySeverityResults{
+		{Severity: "", PassingCount: 1, FailingCount: 0},
+		{Severity: fleet.PolicySeverityCritical, PassingCount: 1, FailingCount: 1},
+		{Severity: fleet.PolicySeverityLow, PassingCount: 2, FailingCount: 0},
+	}, results)
+
+	// filter by team
+	results, err = ds.PolicyResultsBySeverity(ctx, fleet.TeamFilter{User: test.UserAdmin, TeamID: &team1.ID}, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*fleet.PolicySeverityResults{
+		{Severity: "", PassingCount: 1, FailingCount: 0},
+		{Severity: fleet.PolicySeverityCritical, PassingCount: 0, FailingCount: 1},
+		{Severity: fleet.PolicySeverityLow, PassingCount: 1, FailingCount: 0},
+	}, results)
+
+	// filter by platform
+	results, err = ds.PolicyResultsBySeverity(ctx, filter, ptr.String("windows"))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*fleet.PolicySeverityResults{
+		{Severity: fleet.PolicySeverityCritical, PassingCount: 1, FailingCount: 0},
+		{Severity: fleet.PolicySeverityLow, PassingCount: 1, FailingCount: 0},
+	}, results)
+}
+
+func testPolicyLabels(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user1 := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	team1, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	for _, name := range []string{"docker", "servers", "laptops"} {
+		_, err := ds.NewLabel(ctx, &fleet.Label{Name: name, Query: "select 1"})
+		require.NoError(t, err)
+	}
+
+	gp, err := ds.NewGlobalPolicy(ctx, &user1.ID, fleet.PolicyPayload{
+		Name:             "global",
+		Query:            "select 1;",
+		LabelsIncludeAny: []string{"servers", "docker"},
+		LabelsExcludeAny: []string{"laptops"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"docker", "servers"}, gp.LabelsIncludeAny)
+	require.Equal(t, []string{"laptops"}, gp.LabelsExcludeAny)
+
+	// unknown labels are rejected and the policy is not created
+	_, err = ds.NewTeamPolicy(ctx, team1.ID, &user1.ID, fleet.PolicyPayload{
+		Name:             "team",
+		Query:            "select 1;",
+		LabelsIncludeAny: []string{"unknown"},
+	})
+	var nfe fleet.NotFoundError
+	require.ErrorAs(t, err, &nfe)
+	_, err = ds.NewTeamPolicy(ctx, team1.ID, &user1.ID, fleet.PolicyPayload{
+		Name:  "team",
+		Query: "select 1;",
+	})
+	require.NoError(t, err)
+
+	gp.LabelsIncludeAny = []string{"docker"}
+	gp.LabelsExcludeAny = nil
+	require.NoError(t, ds.SavePolicy(ctx, gp))
+	gp, err = ds.Policy(ctx, gp.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"docker"}, gp.LabelsIncludeAny)
+	require.Empty(t, gp.LabelsExcludeAny)
+
+	policies, err := ds.ListGlobalPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	require.Equal(t, []string{"docker"}, policies[0].LabelsIncludeAny)
+
+	byID, err := ds.PoliciesByID(ctx, []uint{gp.ID})
+	require.NoError(t, err)
+	require.Equal(t, []string{"docker"}, byID[gp.ID].LabelsIncludeAny)
+
+	// apply specs replaces the labels
+	require.NoError(t, ds.ApplyPolicySpecs(ctx, user1.ID, []*fleet.PolicySpec{
+		{Name: "global", Query: "select 1;", LabelsExcludeAny: []string{"laptops"}},
+		{Name: "new", Query: "select 2;", LabelsIncludeAny: []string{"servers"}},
+	}))
+	policies, err = ds.ListGlobalPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	sort.Slice(policies, func(i, j int) bool { return policies[i].ID < policies[j].ID })
+	require.Empty(t, policies[0].LabelsIncludeAny)
+	require.Equal(t, []string{"laptops"}, policies[0].LabelsExcludeAny)
+	require.Equal(t, []string{"servers"}, policies[1].LabelsIncludeAny)
+	require.Empty(t, policies[1].LabelsExcludeAny)
+
+	// deleting a label removes it from the policies
+	require.NoError(t, ds.DeleteLabel(ctx, "laptops"))
+	gp, err = ds.Policy(ctx, gp.ID)
+	require.NoError(t, err)
+	require.Empty(t, gp.LabelsExcludeAny)
+}
+
+func testPolicyLabelsScope(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user1 := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+
+	docker, err := ds.NewLabel(ctx, &fleet.Label{Name: "docker", Query: "select 1"})
+	require.NoError(t, err)
+	laptops, err := ds.NewLabel(ctx, &fleet.Label{Name: "laptops", Query: "select 1"})
+	require.NoError(t, err)
+
+	// host1 has docker, host2 has docker and is a laptop, host3 has nothing
+	host1 := newTestHostWithPlatform(t, ds, "host1", "linux", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "linux", nil)
+	host3 := newTestHostWithPlatform(t, ds, "host3", "linux", nil)
+	require.NoError(t, ds.RecordLabelQueryExecutions(ctx, host1, map[uint]*bool{docker.ID: ptr.Bool(true)}, time.Now(), false))
+	require.NoError(t, ds.RecordLabelQueryExecutions(ctx, host2, map[uint]*bool{docker.ID: ptr.Bool(true), laptops.ID: ptr.Bool(true)}, time.Now(), false))
+
+	all := newTestPolicy(t, ds, user1, "all", "", nil)
+	dockerPolicy, err := ds.NewGlobalPolicy(ctx, &user1.ID, fleet.PolicyPayload{
+		Name:             "docker hardened config",
+		Query:            "select 1;",
+		LabelsIncludeAny: []string{"docker"},
+		LabelsExcludeAny: []string{"laptops"},
+		RemediationType:  fleet.ScriptExecutionTypeScript,
+		Remediation:      "echo fix",
+	})
+	require.NoError(t, err)
+	notLaptops, err := ds.NewGlobalPolicy(ctx, &user1.ID, fleet.PolicyPayload{
+		Name:             "not laptops",
+		Query:            "select 2;",
+		LabelsExcludeAny: []string{"laptops"},
+	})
+	require.NoError(t, err)
+
+	checkScope := func(host *fleet.Host, expected ...*fleet.Policy) {
+		var expectedIDs []string
+		for _, p := range expected {
+			expectedIDs = append(expectedIDs, fmt.Sprint(p.ID))
+		}
+
+		queries, err := ds.PolicyQueriesForHost(ctx, host)
+		require.NoError(t, err)
+		var ids []string
+		for id := range queries {
+			ids = append(ids, id)
+		}
+		require.ElementsMatch(t, expectedIDs, ids, host.Hostname)
+
+		hostPolicies, err := ds.ListPoliciesForHost(ctx, host)
+		require.NoError(t, err)
+		ids = nil
+		for _, p := range hostPolicies {
+			ids = append(ids, fmt.Sprint(p.ID))
+		}
+		require.ElementsMatch(t, expectedIDs, ids, host.Hostname)
+	}
+	checkScope(host1, all, dockerPolicy, notLaptops)
+	checkScope(host2, all)
+	checkScope(host3, all, notLaptops)
+
+	remediations, err := ds.PolicyRemediationsForHost(ctx, host1)
+	require.NoError(t, err)
+	require.Contains(t, remediations, dockerPolicy.ID)
+	remediations, err = ds.PolicyRemediationsForHost(ctx, host2)
+	require.NoError(t, err)
+	require.NotContains(t, remediations, dockerPolicy.ID)
+
+	// all hosts fail the docker policy (e.g. results from before the policy was
+	// scoped), the hosts out of scope are cleaned up.
+	for _, h := range []*fleet.Host{host1, host2, host3} {
+		require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, h, map[uint]*bool{dockerPolicy.ID: ptr.Bool(false)}, time.Now(), false))
+	}
+	dockerPolicy, err = ds.Policy(ctx, dockerPolicy.ID)
+	require.NoError(t, err)
+	require.Equal(t, uint(3), dockerPolicy.FailingHostCount)
+
+	require.NoError(t, ds.CleanupPolicyMembership(ctx, time.Now()))
+	dockerPolicy, err = ds.Policy(ctx, dockerPolicy.ID)
+	require.NoError(t, err)
+	require.Equal(t, uint(1), dockerPolicy.FailingHostCount)
+
+	// updating the scope of the policy cleans up its results right away
+	dockerPolicy.LabelsIncludeAny = nil
+	dockerPolicy.LabelsExcludeAny = []string{"docker"}
+	require.NoError(t, ds.SavePolicy(ctx, dockerPolicy))
+	dockerPolicy, err = ds.Policy(ctx, dockerPolicy.ID)
+	require.NoError(t, err)
+	require.Zero(t, dockerPolicy.FailingHostCount)
+	checkScope(host1, all, notLaptops)
+	checkScope(host3, all, dockerPolicy, notLaptops)
+}
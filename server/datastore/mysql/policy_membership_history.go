@@ -0,0 +1,223 @@
+package mysql
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+// recordPolicyMembershipTransitionsDB stores in the policy results history the
+// results that differ from the latest result recorded for the host and policy
+// (or that are the first result of the policy on the host). Results of
+// policies that did not run (nil) are ignored. If the same host and policy
+// appear multiple times in the results, the last one is used, as it is the
+// one that ends up stored in policy_membership. The transitions are recorded
+// at the time the results were reported.
+func recordPolicyMembershipTransitionsDB(ctx context.Context, tx sqlx.ExtContext, results []fleet.PolicyMembershipResult) error {
+	type hostPolicy struct {
+		hostID   uint
+		policyID uint
+	}
+
+	incoming := make(map[hostPolicy]fleet.PolicyMembershipResult, len(results))
+	for _, r := range results {
+		incoming[hostPolicy{hostID: r.HostID, policyID: r.PolicyID}] = r
+	}
+
+	var hostIDs, policyIDs []uint
+	seenHosts, seenPolicies := make(map[uint]bool), make(map[uint]bool)
+	for k, r := range incoming {
+		if r.Passes == nil {
+			delete(incoming, k)
+			continue
+		}
+		if !seenHosts[k.hostID] {
+			seenHosts[k.hostID] = true
+			hostIDs = append(hostIDs, k.hostID)
+		}
+		if !seenPolicies[k.policyID] {
+			seenPolicies[k.policyID] = true
+			policyIDs = append(policyIDs, k.policyID)
+		}
+	}
+	if len(incoming) == 0 {
+		return nil
+	}
+
+	// this may load the latest results of more host and policy pairs than
+	// needed, but it uses the host_id, policy_id index.
+	stmt, args, err := sqlx.In(`
+		SELECT pmh.host_id, pmh.policy_id, pmh.passes
+		FROM policy_membership_history pmh
+		JOIN (
+			SELECT MAX(id) AS id
+			FROM policy_membership_history
+			WHERE host_id IN (?) AND policy_id IN (?)
+			GROUP BY host_id, policy_id
+		) latest ON latest.id = pmh.id`, hostIDs, policyIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build latest policy membership history query")
+	}
+	var latest []struct {
+		HostID   uint `db:"host_id"`
+		PolicyID uint `db:"policy_id"`
+		Passes   bool `db:"passes"`
+	}
+	if err := sqlx.SelectContext(ctx, tx, &latest, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "select latest policy membership history")
+	}
+	for _, l := range latest {
+		k := hostPolicy{hostID: l.HostID, policyID: l.PolicyID}
+		if r, ok := incoming[k]; ok && *r.Passes == l.Passes {
+			delete(incoming, k)
+		}
+	}
+	if len(incoming) == 0 {
+		return nil
+	}
+
+	// Sort the transitions to have generated SQL queries ordered to minimize
+	// deadlocks.
+	transitions := make([]hostPolicy, 0, len(incoming))
+	for k := range incoming {
+		transitions = append(transitions, k)
+	}
+	sort.Slice(transitions, func(i, j int) bool {
+		if transitions[i].hostID == transitions[j].hostID {
+			return transitions[i].policyID < transitions[j].policyID
+		}
+		return transitions[i].hostID < transitions[j].hostID
+	})
+
+	vals := make([]interface{}, 0, len(transitions)*4)
+	for _, k := range transitions {
+		r := incoming[k]
+		vals = append(vals, k.policyID, k.hostID, *r.Passes, r.ReportedAt)
+	}
+	// INSERT IGNORE, to avoid failing if the policy was deleted in between
+	// (which may happen with the async processing of the results).
+	insertStmt := `INSERT IGNORE INTO policy_membership_history (policy_id, host_id, passes, created_at) VALUES ` +
+		strings.TrimSuffix(strings.Repeat(`(?, ?, ?, ?),`, len(transitions)), ",")
+	if _, err := tx.ExecContext(ctx, insertStmt, vals...); err != nil {
+		return ctxerr.Wrap(ctx, err, "insert policy membership history")
+	}
+	return nil
+}
+
+func (ds *Datastore) ListHostPolicyTransitions(ctx context.Context, hostID uint, policyID *uint, opt fleet.ListOptions) ([]*fleet.PolicyMembershipTransition, error) {
+	if opt.OrderKey == "" {
+		opt.OrderKey = "id"
+		opt.OrderDirection = fleet.OrderDescending
+	}
+
+	stmt := `
+		SELECT
+			pmh.id,
+			pmh.policy_id,
+			COALESCE((SELECT p.name FROM policies p WHERE p.id = pmh.policy_id), '') AS policy_name,
+			pmh.host_id,
+			pmh.passes,
+			pmh.created_at
+		FROM policy_membership_history pmh
+		WHERE pmh.host_id = ?`
+	args := []interface{}{hostID}
+	if policyID != nil {
+		stmt += ` AND pmh.policy_id = ?`
+		args = append(args, *policyID)
+	}
+	stmt, args = appendListOptionsWithCursorToSQL(stmt, args, opt)
+
+	var transitions []*fleet.PolicyMembershipTransition
+	if err := sqlx.SelectContext(ctx, ds.reader, &transitions, stmt, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list host policy transitions")
+	}
+	return transitions, nil
+}
+
+func (ds *Datastore) PolicyDailyCounts(ctx context.Context, policyID uint, from, to time.Time) ([]*fleet.PolicyDailyCount, error) {
+	from, to = from.UTC(), to.UTC()
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	if to.Before(from) {
+		return nil, ctxerr.New(ctx, "invalid range of days, to is before from")
+	}
+
+	// the state of the hosts at the start of the range is their latest
+	// transition before the range.
+	var initial []struct {
+		HostID uint `db:"host_id"`
+		Passes bool `db:"passes"`
+	}
+	if err := sqlx.SelectContext(ctx, ds.reader, &initial, `
+		SELECT pmh.host_id, pmh.passes
+		FROM policy_membership_history pmh
+		JOIN (
+			SELECT MAX(id) AS id
+			FROM policy_membership_history
+			WHERE policy_id = ? AND created_at < ?
+			GROUP BY host_id
+		) latest ON latest.id = pmh.id`, policyID, from); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "select initial policy results")
+	}
+
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	var transitions []struct {
+		HostID    uint      `db:"host_id"`
+		Passes    bool      `db:"passes"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	if err := sqlx.SelectContext(ctx, ds.reader, &transitions, `
+		SELECT host_id, passes, created_at
+		FROM policy_membership_history
+		WHERE policy_id = ? AND created_at >= ? AND created_at < ?
+		ORDER BY created_at, id`, policyID, from, end); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "select policy transitions")
+	}
+
+	state := make(map[uint]bool, len(initial))
+	for _, i := range initial {
+		state[i.HostID] = i.Passes
+	}
+
+	var counts []*fleet.PolicyDailyCount
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		for len(transitions) > 0 && transitions[0].CreatedAt.Before(dayEnd) {
+			state[transitions[0].HostID] = transitions[0].Passes
+			transitions = transitions[1:]
+		}
+
+		count := &fleet.PolicyDailyCount{Date: day.Format("2006-01-02")}
+		for _, passes := range state {
+			if passes {
+				count.PassingHostCount++
+			} else {
+				count.FailingHostCount++
+			}
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+func (ds *Datastore) CleanupPolicyMembershipHistory(ctx context.Context, olderThan time.Time) error {
+	// keep the latest transition before olderThan for each host and policy, so
+	// that the state of the hosts is still known for the retained period.
+	_, err := ds.writer.ExecContext(ctx, `
+		DELETE pmh
+		FROM policy_membership_history pmh
+		JOIN policy_membership_history newer ON
+			newer.host_id = pmh.host_id AND
+			newer.policy_id = pmh.policy_id AND
+			newer.id > pmh.id AND
+			newer.created_at < ?
+		WHERE pmh.created_at < ?`, olderThan, olderThan)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "cleanup policy membership history")
+	}
+	return nil
+}
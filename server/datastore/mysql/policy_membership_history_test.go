@@ -0,0 +1,202 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyMembershipHistory(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"RecordTransitions", testPolicyMembershipHistoryRecordTransitions},
+		{"DailyCounts", testPolicyMembershipHistoryDailyCounts},
+		{"Cleanup", testPolicyMembershipHistoryCleanup},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func insertPolicyTransition(t *testing.T, ds *Datastore, policyID, hostID uint, passes bool, ts time.Time) {
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		_, err := q.ExecContext(context.Background(),
+			`INSERT INTO policy_membership_history (policy_id, host_id, passes, created_at) VALUES (?, ?, ?, ?)`,
+			policyID, hostID, passes, ts)
+		return err
+	})
+}
+
+func testPolicyMembershipHistoryRecordTransitions(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+	p1 := newTestPolicy(t, ds, user, "p1", "darwin", nil)
+	p2 := newTestPolicy(t, ds, user, "p2", "darwin", nil)
+
+	transitions, err := ds.ListHostPolicyTransitions(ctx, host1.ID, nil, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, transitions)
+
+	ts := time.Now().UTC().Truncate(time.Second)
+	record := func(host *fleet.Host, results map[uint]*bool) {
+		ts = ts.Add(time.Minute)
+		require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, host, results, ts, false))
+	}
+
+	record(host1, map[uint]*bool{p1.ID: ptr.Bool(true), p2.ID: nil})
+	record(host1, map[uint]*bool{p1.ID: ptr.Bool(true), p2.ID: ptr.Bool(false)})
+	record(host1, map[uint]*bool{p1.ID: ptr.Bool(false), p2.ID: ptr.Bool(false)})
+	record(host1, map[uint]*bool{p1.ID: nil, p2.ID: ptr.Bool(false)})
+	record(host1, map[uint]*bool{p1.ID: ptr.Bool(true), p2.ID: ptr.Bool(true)})
+	record(host2, map[uint]*bool{p1.ID: ptr.Bool(false)})
+
+	// most recent first by default
+	transitions, err = ds.ListHostPolicyTransitions(ctx, host1.ID, nil, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, transitions, 5)
+	type result struct {
+		policyID uint
+		passes   bool
+	}
+	var got []result
+	for _, tr := range transitions {
+		require.Equal(t, host1.ID, tr.HostID)
+		got = append(got, result{tr.PolicyID, tr.Passes})
+	}
+	require.Equal(t, []result{
+		{p2.ID, true},
+		{p1.ID, true},
+		{p1.ID, false},
+		{p2.ID, false},
+		{p1.ID, true},
+	}, got)
+	require.Equal(t, "p2", transitions[0].PolicyName)
+	require.Equal(t, "p1", transitions[1].PolicyName)
+	require.True(t, transitions[0].CreatedAt.Equal(ts.Add(-time.Minute)))
+
+	// filter by policy and paginate
+	transitions, err = ds.ListHostPolicyTransitions(ctx, host1.ID, &p1.ID, fleet.ListOptions{PerPage: 2})
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	require.True(t, transitions[0].Passes)
+	require.False(t, transitions[1].Passes)
+	transitions, err = ds.ListHostPolicyTransitions(ctx, host1.ID, &p1.ID, fleet.ListOptions{PerPage: 2, Page: 1})
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	require.True(t, transitions[0].Passes)
+
+	// the async batch insert records the transitions too, at the time the
+	// results were reported
+	reportedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, ds.AsyncBatchInsertPolicyMembership(ctx, []fleet.PolicyMembershipResult{
+		{HostID: host2.ID, PolicyID: p1.ID, Passes: ptr.Bool(false), ReportedAt: reportedAt},
+		{HostID: host2.ID, PolicyID: p2.ID, Passes: ptr.Bool(true), ReportedAt: reportedAt},
+		{HostID: host1.ID, PolicyID: p1.ID, Passes: ptr.Bool(false), ReportedAt: reportedAt},
+	}))
+	transitions, err = ds.ListHostPolicyTransitions(ctx, host2.ID, nil, fleet.ListOptions{OrderKey: "id"})
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	require.Equal(t, p1.ID, transitions[0].PolicyID)
+	require.False(t, transitions[0].Passes)
+	require.Equal(t, reportedAt, transitions[0].CreatedAt.UTC())
+	require.Equal(t, p2.ID, transitions[1].PolicyID)
+	require.True(t, transitions[1].Passes)
+	require.Equal(t, reportedAt, transitions[1].CreatedAt.UTC())
+	transitions, err = ds.ListHostPolicyTransitions(ctx, host1.ID, &p1.ID, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, transitions, 4)
+	require.False(t, transitions[0].Passes)
+
+	// the history is deleted with the host
+	require.NoError(t, ds.DeleteHost(ctx, host1.ID))
+	transitions, err = ds.ListHostPolicyTransitions(ctx, host1.ID, nil, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, transitions)
+}
+
+func testPolicyMembershipHistoryDailyCounts(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	p1 := newTestPolicy(t, ds, user, "p1", "darwin", nil)
+	p2 := newTestPolicy(t, ds, user, "p2", "darwin", nil)
+
+	day := func(d int, hour int) time.Time {
+		return time.Date(2022, 10, d, hour, 0, 0, 0, time.UTC)
+	}
+
+	// host 1 fails before the range and starts passing on the 3rd
+	insertPolicyTransition(t, ds, p1.ID, 1, false, day(1, 10))
+	insertPolicyTransition(t, ds, p1.ID, 1, true, day(3, 23))
+	// host 2 passes on the 2nd, fails on the 4th
+	insertPolicyTransition(t, ds, p1.ID, 2, true, day(2, 0))
+	insertPolicyTransition(t, ds, p1.ID, 2, false, day(4, 8))
+	// host 3 flips multiple times the same day
+	insertPolicyTransition(t, ds, p1.ID, 3, true, day(3, 1))
+	insertPolicyTransition(t, ds, p1.ID, 3, false, day(3, 2))
+	// other policy
+	insertPolicyTransition(t, ds, p2.ID, 1, true, day(1, 0))
+
+	counts, err := ds.PolicyDailyCounts(ctx, p1.ID, day(2, 12), day(5, 12))
+	require.NoError(t, err)
+	require.Equal(t, []*fleet.PolicyDailyCount{
+		{Date: "2022-10-02", PassingHostCount: 1, FailingHostCount: 1},
+		{Date: "2022-10-03", PassingHostCount: 2, FailingHostCount: 1},
+		{Date: "2022-10-04", PassingHostCount: 1, FailingHostCount: 2},
+		{Date: "2022-10-05", PassingHostCount: 1, FailingHostCount: 2},
+	}, counts)
+
+	counts, err = ds.PolicyDailyCounts(ctx, p2.ID, day(5, 0), day(5, 0))
+	require.NoError(t, err)
+	require.Equal(t, []*fleet.PolicyDailyCount{
+		{Date: "2022-10-05", PassingHostCount: 1, FailingHostCount: 0},
+	}, counts)
+
+	_, err = ds.PolicyDailyCounts(ctx, p2.ID, day(5, 0), day(4, 0))
+	require.Error(t, err)
+}
+
+func testPolicyMembershipHistoryCleanup(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	p1 := newTestPolicy(t, ds, user, "p1", "darwin", nil)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	insertPolicyTransition(t, ds, p1.ID, 1, false, now.Add(-72*time.Hour))
+	insertPolicyTransition(t, ds, p1.ID, 1, true, now.Add(-48*time.Hour))
+	insertPolicyTransition(t, ds, p1.ID, 1, false, now.Add(-1*time.Hour))
+	insertPolicyTransition(t, ds, p1.ID, 2, true, now.Add(-72*time.Hour))
+
+	require.NoError(t, ds.CleanupPolicyMembershipHistory(ctx, now.Add(-24*time.Hour)))
+
+	// the latest transition before the cutoff is kept for each host
+	transitions, err := ds.ListHostPolicyTransitions(ctx, 1, nil, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	require.False(t, transitions[0].Passes)
+	require.True(t, transitions[1].Passes)
+	require.True(t, transitions[1].CreatedAt.Equal(now.Add(-48*time.Hour)))
+
+	transitions, err = ds.ListHostPolicyTransitions(ctx, 2, nil, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+
+	counts, err := ds.PolicyDailyCounts(ctx, p1.ID, now.Add(-24*time.Hour), now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	require.Equal(t, uint(2), counts[0].PassingHostCount)
+}
@@ -0,0 +1,185 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+// policyWaivedCond returns the SQL condition that is true if the host is
+// exempted from the policy by a waiver that did not expire. policyIDExpr and
+// hostIDExpr are the SQL expressions of the policy and host IDs to check.
+func policyWaivedCond(policyIDExpr, hostIDExpr string) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM policy_waivers pw
+		WHERE pw.policy_id = %[1]s AND pw.expires_at > CURRENT_TIMESTAMP AND (
+			EXISTS (SELECT 1 FROM policy_waiver_hosts pwh WHERE pwh.waiver_id = pw.id AND pwh.host_id = %[2]s) OR
+			EXISTS (SELECT 1 FROM label_membership lm WHERE lm.label_id = pw.label_id AND lm.host_id = %[2]s)
+		)
+	)`, policyIDExpr, hostIDExpr)
+}
+
+func (ds *Datastore) NewPolicyWaiver(ctx context.Context, waiver *fleet.PolicyWaiver) (*fleet.PolicyWaiver, error) {
+	var id int64
+	err := ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO policy_waivers (policy_id, label_id, reason, approver, expires_at, author_id)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			waiver.PolicyID, waiver.LabelID, waiver.Reason, waiver.Approver, waiver.ExpiresAt, waiver.AuthorID,
+		)
+		if err != nil {
+			if isChildForeignKeyError(err) {
+				return ctxerr.Wrap(ctx, foreignKey("policy_waivers", "policy_id or label_id"))
+			}
+			return ctxerr.Wrap(ctx, err, "insert policy waiver")
+		}
+		id, _ = result.LastInsertId()
+
+		if len(waiver.HostIDs) == 0 {
+			return nil
+		}
+		vals := make([]interface{}, 0, len(waiver.HostIDs)*2)
+		for _, hostID := range waiver.HostIDs {
+			vals = append(vals, id, hostID)
+		}
+		stmt := `INSERT IGNORE INTO policy_waiver_hosts (waiver_id, host_id) VALUES ` +
+			strings.TrimSuffix(strings.Repeat(`(?, ?),`, len(waiver.HostIDs)), ",")
+		if _, err := tx.ExecContext(ctx, stmt, vals...); err != nil {
+			return ctxerr.Wrap(ctx, err, "insert policy waiver hosts")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var created fleet.PolicyWaiver
+	if err := sqlx.GetContext(ctx, ds.writer, &created, `SELECT * FROM policy_waivers WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("PolicyWaiver").WithID(uint(id)))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "get policy waiver")
+	}
+	waivers := []*fleet.PolicyWaiver{&created}
+	if err := loadPolicyWaiverHostsDB(ctx, ds.writer, waivers); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (ds *Datastore) ListPolicyWaivers(ctx context.Context, policyID uint) ([]*fleet.PolicyWaiver, error) {
+	var waivers []*fleet.PolicyWaiver
+	if err := sqlx.SelectContext(ctx, ds.reader, &waivers, `
+		SELECT * FROM policy_waivers
+		WHERE policy_id = ? AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY id`, policyID); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list policy waivers")
+	}
+	if err := loadPolicyWaiverHostsDB(ctx, ds.reader, waivers); err != nil {
+		return nil, err
+	}
+	return waivers, nil
+}
+
+func loadPolicyWaiverHostsDB(ctx context.Context, q sqlx.QueryerContext, waivers []*fleet.PolicyWaiver) error {
+	if len(waivers) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*fleet.PolicyWaiver, len(waivers))
+	ids := make([]uint, 0, len(waivers))
+	for _, w := range waivers {
+		w.HostIDs = []uint{}
+		byID[w.ID] = w
+		ids = append(ids, w.ID)
+	}
+
+	stmt, args, err := sqlx.In(`
+		SELECT waiver_id, host_id FROM policy_waiver_hosts
+		WHERE waiver_id IN (?)
+		ORDER BY waiver_id, host_id`, ids)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build policy waiver hosts query")
+	}
+	var rows []struct {
+		WaiverID uint `db:"waiver_id"`
+		HostID   uint `db:"host_id"`
+	}
+	if err := sqlx.SelectContext(ctx, q, &rows, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "select policy waiver hosts")
+	}
+	for _, r := range rows {
+		w := byID[r.WaiverID]
+		w.HostIDs = append(w.HostIDs, r.HostID)
+	}
+	return nil
+}
+
+func (ds *Datastore) DeletePolicyWaiver(ctx context.Context, policyID, waiverID uint) error {
+	result, err := ds.writer.ExecContext(ctx, `DELETE FROM policy_waivers WHERE id = ? AND policy_id = ?`, waiverID, policyID)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "delete policy waiver")
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ctxerr.Wrap(ctx, notFound("PolicyWaiver").WithID(waiverID))
+	}
+	return nil
+}
+
+func (ds *Datastore) ListExpiredPolicyWaiverFailingHosts(ctx context.Context, now time.Time) ([]*fleet.PolicyWaiverFailingHost, error) {
+	stmt := fmt.Sprintf(`
+		SELECT DISTINCT
+			pm.policy_id,
+			h.id AS host_id,
+			h.hostname,
+			COALESCE(NULLIF(h.computer_name, ''), h.hostname) AS display_name
+		FROM policy_waivers pw
+		JOIN policy_membership pm ON pm.policy_id = pw.policy_id AND pm.passes = 0
+		JOIN hosts h ON h.id = pm.host_id
+		WHERE pw.expires_at <= ? AND h.lifecycle_state <> ? AND (
+			EXISTS (SELECT 1 FROM policy_waiver_hosts pwh WHERE pwh.waiver_id = pw.id AND pwh.host_id = h.id) OR
+			EXISTS (SELECT 1 FROM label_membership lm WHERE lm.label_id = pw.label_id AND lm.host_id = h.id)
+		) AND NOT %s`, policyWaivedCond("pm.policy_id", "h.id"))
+
+	var hosts []*fleet.PolicyWaiverFailingHost
+	if err := sqlx.SelectContext(ctx, ds.reader, &hosts, stmt, now, fleet.HostLifecycleStateRetired); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list failing hosts of expired policy waivers")
+	}
+	return hosts, nil
+}
+
+func (ds *Datastore) DeleteExpiredPolicyWaivers(ctx context.Context, now time.Time) error {
+	if _, err := ds.writer.ExecContext(ctx, `DELETE FROM policy_waivers WHERE expires_at <= ?`, now); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete expired policy waivers")
+	}
+	return nil
+}
+
+// waivedPoliciesForHostDB returns the IDs of the policies, among policyIDs,
+// from which the host is exempted by a waiver that did not expire.
+func waivedPoliciesForHostDB(ctx context.Context, q sqlx.QueryerContext, hostID uint, policyIDs []uint) (map[uint]bool, error) {
+	if len(policyIDs) == 0 {
+		return nil, nil
+	}
+	stmt, args, err := sqlx.In(
+		fmt.Sprintf(`SELECT p.id FROM policies p WHERE p.id IN (?) AND %s`, policyWaivedCond("p.id", "?")),
+		policyIDs, hostID, hostID,
+	)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "build waived policies query")
+	}
+	var ids []uint
+	if err := sqlx.SelectContext(ctx, q, &ids, stmt, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "select waived policies")
+	}
+	waived := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		waived[id] = true
+	}
+	return waived, nil
+}
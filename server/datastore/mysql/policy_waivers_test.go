@@ -0,0 +1,300 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyWaivers(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"CRUD", testPolicyWaiversCRUD},
+		{"FailingCounts", testPolicyWaiversFailingCounts},
+		{"FlippingPolicies", testPolicyWaiversFlippingPolicies},
+		{"ExpiredFailingHosts", testPolicyWaiversExpiredFailingHosts},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testPolicyWaiversCRUD(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	p1 := newTestPolicy(t, ds, user, "p1", "darwin", nil)
+	p2 := newTestPolicy(t, ds, user, "p2", "darwin", nil)
+	label, err := ds.NewLabel(ctx, &fleet.Label{Name: "build servers", Query: "select 1"})
+	require.NoError(t, err)
+
+	waivers, err := ds.ListPolicyWaivers(ctx, p1.ID)
+	require.NoError(t, err)
+	require.Empty(t, waivers)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	w1, err := ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID:  p1.ID,
+		HostIDs:   []uint{3, 1, 3},
+		Reason:    "build servers",
+		Approver:  "bob",
+		ExpiresAt: now.Add(24 * time.Hour),
+		AuthorID:  &user.ID,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, w1.ID)
+	require.Equal(t, []uint{1, 3}, w1.HostIDs)
+	require.Nil(t, w1.LabelID)
+	require.Equal(t, "bob", w1.Approver)
+	require.True(t, w1.ExpiresAt.Equal(now.Add(24*time.Hour)))
+
+	w2, err := ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID:  p1.ID,
+		LabelID:   &label.ID,
+		Reason:    "lab machines",
+		Approver:  "bob",
+		ExpiresAt: now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Empty(t, w2.HostIDs)
+	require.Equal(t, label.ID, *w2.LabelID)
+
+	_, err = ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID:  p2.ID,
+		HostIDs:   []uint{1},
+		Reason:    "expired",
+		Approver:  "bob",
+		ExpiresAt: now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// unknown label
+	_, err = ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID:  p1.ID,
+		LabelID:   ptr.Uint(label.ID + 1000),
+		Reason:    "unknown",
+		Approver:  "bob",
+		ExpiresAt: now.Add(time.Hour),
+	})
+	require.Error(t, err)
+
+	waivers, err = ds.ListPolicyWaivers(ctx, p1.ID)
+	require.NoError(t, err)
+	require.Len(t, waivers, 2)
+	require.Equal(t, w1.ID, waivers[0].ID)
+	require.Equal(t, []uint{1, 3}, waivers[0].HostIDs)
+	require.Equal(t, w2.ID, waivers[1].ID)
+	require.Empty(t, waivers[1].HostIDs)
+
+	// the waiver must belong to the policy to be deleted
+	err = ds.DeletePolicyWaiver(ctx, p2.ID, w1.ID)
+	var nfe fleet.NotFoundError
+	require.ErrorAs(t, err, &nfe)
+	require.NoError(t, ds.DeletePolicyWaiver(ctx, p1.ID, w1.ID))
+	waivers, err = ds.ListPolicyWaivers(ctx, p1.ID)
+	require.NoError(t, err)
+	require.Len(t, waivers, 1)
+	require.Equal(t, w2.ID, waivers[0].ID)
+
+	// expired waivers are not listed and get deleted
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		_, err := q.ExecContext(ctx, `UPDATE policy_waivers SET expires_at = ? WHERE policy_id = ?`, now.Add(-time.Minute), p2.ID)
+		return err
+	})
+	waivers, err = ds.ListPolicyWaivers(ctx, p2.ID)
+	require.NoError(t, err)
+	require.Empty(t, waivers)
+
+	require.NoError(t, ds.DeleteExpiredPolicyWaivers(ctx, now))
+	var count int
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		return sqlx.GetContext(ctx, q, &count, `SELECT COUNT(*) FROM policy_waivers`)
+	})
+	require.Equal(t, 1, count)
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		return sqlx.GetContext(ctx, q, &count, `SELECT COUNT(*) FROM policy_waiver_hosts`)
+	})
+	require.Zero(t, count)
+
+	// deleting the label deletes its waivers
+	require.NoError(t, ds.DeleteLabel(ctx, label.Name))
+	waivers, err = ds.ListPolicyWaivers(ctx, p1.ID)
+	require.NoError(t, err)
+	require.Empty(t, waivers)
+}
+
+func testPolicyWaiversFailingCounts(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+	host3 := newTestHostWithPlatform(t, ds, "host3", "darwin", nil)
+	p1 := newTestPolicy(t, ds, user, "p1", "darwin", nil)
+	p2 := newTestPolicy(t, ds, user, "p2", "darwin", nil)
+	label, err := ds.NewLabel(ctx, &fleet.Label{Name: "build servers", Query: "select 1"})
+	require.NoError(t, err)
+	require.NoError(t, ds.RecordLabelQueryExecutions(ctx, host3, map[uint]*bool{label.ID: ptr.Bool(true)}, time.Now(), false))
+
+	for _, h := range []*fleet.Host{host1, host2, host3} {
+		require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, h, map[uint]*bool{p1.ID: ptr.Bool(false), p2.ID: ptr.Bool(false)}, time.Now(), false))
+	}
+
+	checkCounts := func(p1Failing, p2Failing uint, hostFailing map[uint]int) {
+		policy, err := ds.Policy(ctx, p1.ID)
+		require.NoError(t, err)
+		require.Equal(t, p1Failing, policy.FailingHostCount)
+
+		policies, err := ds.ListGlobalPolicies(ctx)
+		require.NoError(t, err)
+		require.Len(t, policies, 2)
+		for _, p := range policies {
+			if p.ID == p1.ID {
+				require.Equal(t, p1Failing, p.FailingHostCount)
+			} else {
+				require.Equal(t, p2Failing, p.FailingHostCount)
+			}
+		}
+
+		byID, err := ds.PoliciesByID(ctx, []uint{p1.ID})
+		require.NoError(t, err)
+		require.Equal(t, p1Failing, byID[p1.ID].FailingHostCount)
+
+		hosts, err := ds.ListHosts(ctx, fleet.TeamFilter{User: user}, fleet.HostListOptions{})
+		require.NoError(t, err)
+		require.Len(t, hosts, 3)
+		for _, h := range hosts {
+			require.Equal(t, hostFailing[h.ID], h.HostIssues.FailingPoliciesCount, h.Hostname)
+
+			host, err := ds.Host(ctx, h.ID)
+			require.NoError(t, err)
+			require.Equal(t, hostFailing[h.ID], host.HostIssues.FailingPoliciesCount, h.Hostname)
+
+			n, err := ds.FailingPoliciesCount(ctx, h)
+			require.NoError(t, err)
+			require.Equal(t, uint(hostFailing[h.ID]), n, h.Hostname)
+		}
+
+		hosts, err = ds.ListHostsInLabel(ctx, fleet.TeamFilter{User: user}, label.ID, fleet.HostListOptions{})
+		require.NoError(t, err)
+		require.Len(t, hosts, 1)
+		require.Equal(t, hostFailing[host3.ID], hosts[0].HostIssues.FailingPoliciesCount)
+	}
+	checkCounts(3, 3, map[uint]int{host1.ID: 2, host2.ID: 2, host3.ID: 2})
+
+	expiresAt := time.Now().Add(time.Hour)
+	_, err = ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID: p1.ID, HostIDs: []uint{host1.ID}, Reason: "r", Approver: "a", ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	_, err = ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID: p1.ID, LabelID: &label.ID, Reason: "r", Approver: "a", ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	checkCounts(1, 3, map[uint]int{host1.ID: 1, host2.ID: 2, host3.ID: 1})
+
+	// expired waivers do not apply
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		_, err := q.ExecContext(ctx, `UPDATE policy_waivers SET expires_at = ?`, time.Now().Add(-time.Minute))
+		return err
+	})
+	checkCounts(3, 3, map[uint]int{host1.ID: 2, host2.ID: 2, host3.ID: 2})
+}
+
+func testPolicyWaiversFlippingPolicies(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+	p1 := newTestPolicy(t, ds, user, "p1", "darwin", nil)
+	p2 := newTestPolicy(t, ds, user, "p2", "darwin", nil)
+
+	_, err := ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID: p1.ID, HostIDs: []uint{host1.ID}, Reason: "r", Approver: "a", ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	results := map[uint]*bool{p1.ID: ptr.Bool(false), p2.ID: ptr.Bool(false)}
+	newFailing, newPassing, err := ds.FlippingPoliciesForHost(ctx, host1.ID, results)
+	require.NoError(t, err)
+	require.Equal(t, []uint{p2.ID}, newFailing)
+	require.Empty(t, newPassing)
+
+	newFailing, _, err = ds.FlippingPoliciesForHost(ctx, host2.ID, results)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uint{p1.ID, p2.ID}, newFailing)
+
+	// a waived host that starts passing is still reported as passing
+	require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, host1, results, time.Now(), false))
+	newFailing, newPassing, err = ds.FlippingPoliciesForHost(ctx, host1.ID, map[uint]*bool{p1.ID: ptr.Bool(true)})
+	require.NoError(t, err)
+	require.Empty(t, newFailing)
+	require.Equal(t, []uint{p1.ID}, newPassing)
+}
+
+func testPolicyWaiversExpiredFailingHosts(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", nil)
+	host3 := newTestHostWithPlatform(t, ds, "host3", "darwin", nil)
+	host4 := newTestHostWithPlatform(t, ds, "host4", "darwin", nil)
+	p1 := newTestPolicy(t, ds, user, "p1", "darwin", nil)
+	label, err := ds.NewLabel(ctx, &fleet.Label{Name: "build servers", Query: "select 1"})
+	require.NoError(t, err)
+	require.NoError(t, ds.RecordLabelQueryExecutions(ctx, host2, map[uint]*bool{label.ID: ptr.Bool(true)}, time.Now(), false))
+
+	// host4 passes the policy, the others fail it
+	for _, h := range []*fleet.Host{host1, host2, host3} {
+		require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, h, map[uint]*bool{p1.ID: ptr.Bool(false)}, time.Now(), false))
+	}
+	require.NoError(t, ds.RecordPolicyQueryExecutions(ctx, host4, map[uint]*bool{p1.ID: ptr.Bool(true)}, time.Now(), false))
+
+	expiresAt := time.Now().Add(time.Hour)
+	expiring, err := ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID: p1.ID, HostIDs: []uint{host1.ID, host3.ID, host4.ID}, Reason: "r", Approver: "a", ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	expiringLabel, err := ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID: p1.ID, LabelID: &label.ID, Reason: "r", Approver: "a", ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	// host3 is still exempted by this waiver
+	_, err = ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID: p1.ID, HostIDs: []uint{host3.ID}, Reason: "r", Approver: "a", ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+
+	hosts, err := ds.ListExpiredPolicyWaiverFailingHosts(ctx, time.Now())
+	require.NoError(t, err)
+	require.Empty(t, hosts)
+
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		_, err := q.ExecContext(ctx, `UPDATE policy_waivers SET expires_at = ? WHERE id IN (?, ?)`, time.Now().Add(-time.Minute), expiring.ID, expiringLabel.ID)
+		return err
+	})
+
+	hosts, err = ds.ListExpiredPolicyWaiverFailingHosts(ctx, time.Now())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*fleet.PolicyWaiverFailingHost{
+		{PolicyID: p1.ID, HostID: host1.ID, Hostname: host1.Hostname, DisplayName: host1.Hostname},
+		{PolicyID: p1.ID, HostID: host2.ID, Hostname: host2.Hostname, DisplayName: host2.Hostname},
+	}, hosts)
+
+	// the failing hosts are no longer listed once the waivers are deleted
+	require.NoError(t, ds.DeleteExpiredPolicyWaivers(ctx, time.Now()))
+	hosts, err = ds.ListExpiredPolicyWaiverFailingHosts(ctx, time.Now())
+	require.NoError(t, err)
+	require.Empty(t, hosts)
+}
@@ -0,0 +1,190 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+// scheduledLiveQueryRow is a scheduled_live_queries row, the targets are
+// stored as JSON.
+type scheduledLiveQueryRow struct {
+	fleet.ScheduledLiveQuery
+	TargetsJSON json.RawMessage `db:"targets"`
+}
+
+func (r *scheduledLiveQueryRow) toScheduledLiveQuery() (*fleet.ScheduledLiveQuery, error) {
+	q := r.ScheduledLiveQuery
+	if err := json.Unmarshal(r.TargetsJSON, &q.Targets); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (ds *Datastore) NewScheduledLiveQuery(ctx context.Context, query *fleet.ScheduledLiveQuery) (*fleet.ScheduledLiveQuery, error) {
+	targets, err := json.Marshal(query.Targets)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "marshal scheduled live query targets")
+	}
+
+	result, err := ds.writer.ExecContext(ctx, `
+		INSERT INTO scheduled_live_queries (name, query_id, targets, run_at, cron, results_window, webhook_url, enabled, author_id, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		query.Name, query.QueryID, targets, query.RunAt, query.Cron, query.ResultsWindow, query.WebhookURL, query.Enabled, query.AuthorID, query.NextRunAt,
+	)
+	if err != nil {
+		if isDuplicate(err) {
+			return nil, ctxerr.Wrap(ctx, alreadyExists("ScheduledLiveQuery", query.Name))
+		}
+		if isChildForeignKeyError(err) {
+			return nil, ctxerr.Wrap(ctx, foreignKey("scheduled_live_queries", "query_id"))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "insert scheduled live query")
+	}
+
+	id, _ := result.LastInsertId()
+	return ds.scheduledLiveQueryDB(ctx, ds.writer, uint(id))
+}
+
+func (ds *Datastore) ScheduledLiveQuery(ctx context.Context, id uint) (*fleet.ScheduledLiveQuery, error) {
+	return ds.scheduledLiveQueryDB(ctx, ds.reader, id)
+}
+
+func (ds *Datastore) scheduledLiveQueryDB(ctx context.Context, q sqlx.QueryerContext, id uint) (*fleet.ScheduledLiveQuery, error) {
+	var row scheduledLiveQueryRow
+	if err := sqlx.GetContext(ctx, q, &row, `SELECT * FROM scheduled_live_queries WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("ScheduledLiveQuery").WithID(id))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "get scheduled live query")
+	}
+	query, err := row.toScheduledLiveQuery()
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "unmarshal scheduled live query targets")
+	}
+	return query, nil
+}
+
+func (ds *Datastore) ListScheduledLiveQueries(ctx context.Context, opt fleet.ListOptions) ([]*fleet.ScheduledLiveQuery, error) {
+	if opt.OrderKey == "" {
+		opt.OrderKey = "id"
+	}
+	return ds.listScheduledLiveQueries(ctx, appendListOptionsToSQL(`SELECT * FROM scheduled_live_queries`, opt))
+}
+
+func (ds *Datastore) ListDueScheduledLiveQueries(ctx context.Context, now time.Time) ([]*fleet.ScheduledLiveQuery, error) {
+	return ds.listScheduledLiveQueries(ctx, `
+		SELECT * FROM scheduled_live_queries
+		WHERE enabled = 1 AND next_run_at IS NOT NULL AND next_run_at <= ?
+		ORDER BY next_run_at, id`, now)
+}
+
+func (ds *Datastore) listScheduledLiveQueries(ctx context.Context, stmt string, args ...interface{}) ([]*fleet.ScheduledLiveQuery, error) {
+	var rows []*scheduledLiveQueryRow
+	if err := sqlx.SelectContext(ctx, ds.reader, &rows, stmt, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list scheduled live queries")
+	}
+
+	queries := make([]*fleet.ScheduledLiveQuery, 0, len(rows))
+	for _, row := range rows {
+		query, err := row.toScheduledLiveQuery()
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "unmarshal scheduled live query targets")
+		}
+		queries = append(queries, query)
+	}
+	return queries, nil
+}
+
+func (ds *Datastore) SaveScheduledLiveQuery(ctx context.Context, query *fleet.ScheduledLiveQuery) error {
+	targets, err := json.Marshal(query.Targets)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "marshal scheduled live query targets")
+	}
+
+	result, err := ds.writer.ExecContext(ctx, `
+		UPDATE scheduled_live_queries
+		SET name = ?, query_id = ?, targets = ?, run_at = ?, cron = ?, results_window = ?, webhook_url = ?, enabled = ?,
+			next_run_at = ?, last_run_at = ?, last_campaign_id = ?
+		WHERE id = ?`,
+		query.Name, query.QueryID, targets, query.RunAt, query.Cron, query.ResultsWindow, query.WebhookURL, query.Enabled,
+		query.NextRunAt, query.LastRunAt, query.LastCampaignID, query.ID,
+	)
+	if err != nil {
+		if isDuplicate(err) {
+			return ctxerr.Wrap(ctx, alreadyExists("ScheduledLiveQuery", query.Name))
+		}
+		if isChildForeignKeyError(err) {
+			return ctxerr.Wrap(ctx, foreignKey("scheduled_live_queries", "query_id"))
+		}
+		return ctxerr.Wrap(ctx, err, "update scheduled live query")
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ctxerr.Wrap(ctx, notFound("ScheduledLiveQuery").WithID(query.ID))
+	}
+	return nil
+}
+
+func (ds *Datastore) DeleteScheduledLiveQuery(ctx context.Context, id uint) error {
+	return ds.deleteEntity(ctx, scheduledLiveQueriesTable, id)
+}
+
+func (ds *Datastore) NewScheduledLiveQueryRun(ctx context.Context, run *fleet.ScheduledLiveQueryRun) error {
+	_, err := ds.writer.ExecContext(ctx, `
+		INSERT INTO scheduled_live_query_runs (campaign_id, scheduled_live_query_id, ends_at)
+		VALUES (?, ?, ?)`,
+		run.CampaignID, run.ScheduledLiveQueryID, run.EndsAt,
+	)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "insert scheduled live query run")
+	}
+	return nil
+}
+
+func (ds *Datastore) ScheduledLiveQueryRunByCampaign(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error) {
+	var run fleet.ScheduledLiveQueryRun
+	// read from the primary, the hosts may send results right after the run
+	// is created.
+	err := sqlx.GetContext(ctx, ds.writer, &run, `
+		SELECT campaign_id, scheduled_live_query_id, ends_at
+		FROM scheduled_live_query_runs
+		WHERE campaign_id = ?`,
+		campaignID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("ScheduledLiveQueryRun").WithID(campaignID))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "get scheduled live query run")
+	}
+	return &run, nil
+}
+
+func (ds *Datastore) ListEndedScheduledLiveQueryRuns(ctx context.Context, now time.Time) ([]*fleet.ScheduledLiveQueryRun, error) {
+	var runs []*fleet.ScheduledLiveQueryRun
+	err := sqlx.SelectContext(ctx, ds.reader, &runs, `
+		SELECT campaign_id, scheduled_live_query_id, ends_at
+		FROM scheduled_live_query_runs
+		WHERE ends_at <= ?
+		ORDER BY ends_at, campaign_id`,
+		now,
+	)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list ended scheduled live query runs")
+	}
+	return runs, nil
+}
+
+func (ds *Datastore) DeleteScheduledLiveQueryRun(ctx context.Context, campaignID uint) error {
+	_, err := ds.writer.ExecContext(ctx, `DELETE FROM scheduled_live_query_runs WHERE campaign_id = ?`, campaignID)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "delete scheduled live query run")
+	}
+	return nil
+}
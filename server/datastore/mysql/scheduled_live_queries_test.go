@@ -0,0 +1,201 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledLiveQueries(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"CRUD", testScheduledLiveQueriesCRUD},
+		{"ListDue", testScheduledLiveQueriesListDue},
+		{"Runs", testScheduledLiveQueriesRuns},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testScheduledLiveQueriesCRUD(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	user := test.NewUser(t, ds, "Zach", "zwass@fleet.co", true)
+	query := test.NewQuery(t, ds, "q1", "select 1", user.ID, true)
+
+	queries, err := ds.ListScheduledLiveQueries(ctx, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, queries)
+
+	runAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	q1, err := ds.NewScheduledLiveQuery(ctx, &fleet.ScheduledLiveQuery{
+		Name:          "once",
+		QueryID:       query.ID,
+		Targets:       fleet.HostTargets{HostIDs: []uint{1, 2}, LabelIDs: []uint{3}},
+		RunAt:         &runAt,
+		ResultsWindow: 60,
+		WebhookURL:    "https://example.com/results",
+		Enabled:       true,
+		AuthorID:      &user.ID,
+		NextRunAt:     &runAt,
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, q1.ID)
+	assert.Equal(t, fleet.HostTargets{HostIDs: []uint{1, 2}, LabelIDs: []uint{3}}, q1.Targets)
+	assert.True(t, runAt.Equal(*q1.RunAt))
+	assert.True(t, runAt.Equal(*q1.NextRunAt))
+	assert.Nil(t, q1.LastRunAt)
+	assert.Nil(t, q1.LastCampaignID)
+
+	q2, err := ds.NewScheduledLiveQuery(ctx, &fleet.ScheduledLiveQuery{
+		Name:          "hourly",
+		QueryID:       query.ID,
+		Targets:       fleet.HostTargets{TeamIDs: []uint{1}},
+		Cron:          "0 * * * *",
+		ResultsWindow: 300,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "0 * * * *", q2.Cron)
+	assert.Nil(t, q2.RunAt)
+	assert.False(t, q2.Enabled)
+
+	_, err = ds.NewScheduledLiveQuery(ctx, &fleet.ScheduledLiveQuery{Name: "once", QueryID: query.ID})
+	var existsErr fleet.AlreadyExistsError
+	require.ErrorAs(t, err, &existsErr)
+
+	_, err = ds.NewScheduledLiveQuery(ctx, &fleet.ScheduledLiveQuery{Name: "no query", QueryID: query.ID + 1000})
+	require.Error(t, err)
+
+	queries, err = ds.ListScheduledLiveQueries(ctx, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+	assert.Equal(t, "once", queries[0].Name)
+	assert.Equal(t, "hourly", queries[1].Name)
+
+	lastRun := time.Now().UTC().Truncate(time.Second)
+	q2.Enabled = true
+	q2.LastRunAt = &lastRun
+	q2.LastCampaignID = ptr.Uint(42)
+	q2.Targets = fleet.HostTargets{HostIDs: []uint{4}}
+	require.NoError(t, ds.SaveScheduledLiveQuery(ctx, q2))
+	q2, err = ds.ScheduledLiveQuery(ctx, q2.ID)
+	require.NoError(t, err)
+	assert.True(t, q2.Enabled)
+	assert.True(t, lastRun.Equal(*q2.LastRunAt))
+	assert.Equal(t, uint(42), *q2.LastCampaignID)
+	assert.Equal(t, fleet.HostTargets{HostIDs: []uint{4}}, q2.Targets)
+
+	q2.Name = "once"
+	err = ds.SaveScheduledLiveQuery(ctx, q2)
+	require.ErrorAs(t, err, &existsErr)
+
+	require.NoError(t, ds.DeleteScheduledLiveQuery(ctx, q1.ID))
+	_, err = ds.ScheduledLiveQuery(ctx, q1.ID)
+	require.True(t, fleet.IsNotFound(err))
+	require.True(t, fleet.IsNotFound(ds.DeleteScheduledLiveQuery(ctx, q1.ID)))
+	require.True(t, fleet.IsNotFound(ds.SaveScheduledLiveQuery(ctx, q1)))
+
+	// deleting the query deletes its scheduled live queries
+	require.NoError(t, ds.DeleteQuery(ctx, query.Name))
+	queries, err = ds.ListScheduledLiveQueries(ctx, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, queries)
+}
+
+func testScheduledLiveQueriesListDue(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	user := test.NewUser(t, ds, "Zach", "zwass@fleet.co", true)
+	query := test.NewQuery(t, ds, "q1", "select 1", user.ID, true)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	newQuery := func(name string, enabled bool, next *time.Time) *fleet.ScheduledLiveQuery {
+		q, err := ds.NewScheduledLiveQuery(ctx, &fleet.ScheduledLiveQuery{
+			Name:      name,
+			QueryID:   query.ID,
+			Cron:      "* * * * *",
+			Enabled:   enabled,
+			NextRunAt: next,
+		})
+		require.NoError(t, err)
+		return q
+	}
+	late := newQuery("late", true, ptr.Time(now.Add(-time.Hour)))
+	due := newQuery("due", true, &now)
+	newQuery("future", true, ptr.Time(now.Add(time.Minute)))
+	newQuery("disabled", false, ptr.Time(now.Add(-time.Minute)))
+	newQuery("done", true, nil)
+
+	queries, err := ds.ListDueScheduledLiveQueries(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+	assert.Equal(t, late.ID, queries[0].ID)
+	assert.Equal(t, due.ID, queries[1].ID)
+
+	queries, err = ds.ListDueScheduledLiveQueries(ctx, now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, queries)
+}
+
+func testScheduledLiveQueriesRuns(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	user := test.NewUser(t, ds, "Zach", "zwass@fleet.co", true)
+	query := test.NewQuery(t, ds, "q1", "select 1", user.ID, true)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	newRun := func(sqID uint, endsAt time.Time) uint {
+		campaign, err := ds.NewDistributedQueryCampaign(ctx, &fleet.DistributedQueryCampaign{
+			QueryID: query.ID,
+			Status:  fleet.QueryRunning,
+			UserID:  user.ID,
+		})
+		require.NoError(t, err)
+		require.NoError(t, ds.NewScheduledLiveQueryRun(ctx, &fleet.ScheduledLiveQueryRun{
+			CampaignID:           campaign.ID,
+			ScheduledLiveQueryID: sqID,
+			EndsAt:               endsAt,
+		}))
+		return campaign.ID
+	}
+	ended := newRun(1, now.Add(-time.Minute))
+	endsNow := newRun(2, now)
+	running := newRun(1, now.Add(time.Minute))
+
+	run, err := ds.ScheduledLiveQueryRunByCampaign(ctx, running)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), run.ScheduledLiveQueryID)
+	assert.Equal(t, now.Add(time.Minute), run.EndsAt.UTC())
+
+	_, err = ds.ScheduledLiveQueryRunByCampaign(ctx, running+100)
+	require.True(t, fleet.IsNotFound(err))
+
+	runs, err := ds.ListEndedScheduledLiveQueryRuns(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, ended, runs[0].CampaignID)
+	assert.Equal(t, endsNow, runs[1].CampaignID)
+	assert.Equal(t, uint(2), runs[1].ScheduledLiveQueryID)
+
+	require.NoError(t, ds.DeleteScheduledLiveQueryRun(ctx, ended))
+	_, err = ds.ScheduledLiveQueryRunByCampaign(ctx, ended)
+	require.True(t, fleet.IsNotFound(err))
+	runs, err = ds.ListEndedScheduledLiveQueryRuns(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, endsNow, runs[0].CampaignID)
+}
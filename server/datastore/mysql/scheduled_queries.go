@@ -3,9 +3,11 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -360,3 +362,133 @@ func (ds *Datastore) AsyncBatchSaveHostsScheduledQueryStats(ctx context.Context,
 
 	return countExecs, nil
 }
+
+const (
+	scheduledQueryResultsInsertBatch  = 100
+	scheduledQueryResultsCleanupBatch = 10000
+)
+
+func (ds *Datastore) SaveScheduledQueryResults(ctx context.Context, results []*fleet.ScheduledQueryResult, maxPerHost int) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	const insertStmt = `INSERT INTO scheduled_query_results (scheduled_query_id, host_id, action, data, last_fetched) VALUES %s`
+	// keep the latest maxPerHost results (in insertion order) of the scheduled
+	// query for the host, the derived table is required by MySQL to select from
+	// the table being deleted from.
+	const trimStmt = `
+		DELETE FROM scheduled_query_results
+		WHERE scheduled_query_id = ? AND host_id = ? AND id <= (
+			SELECT id FROM (
+				SELECT id FROM scheduled_query_results
+				WHERE scheduled_query_id = ? AND host_id = ?
+				ORDER BY id DESC
+				LIMIT 1 OFFSET ?
+			) AS oldest
+		)`
+
+	type hostQuery struct {
+		hostID, scheduledQueryID uint
+	}
+
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		updated := make(map[hostQuery]struct{})
+		for batch := results; len(batch) > 0; {
+			n := len(batch)
+			if n > scheduledQueryResultsInsertBatch {
+				n = scheduledQueryResultsInsertBatch
+			}
+
+			args := make([]interface{}, 0, n*5)
+			for _, r := range batch[:n] {
+				data := r.Data
+				if len(data) == 0 {
+					data = json.RawMessage(`null`)
+				}
+				args = append(args, r.ScheduledQueryID, r.HostID, r.Action, data, r.LastFetched)
+				updated[hostQuery{r.HostID, r.ScheduledQueryID}] = struct{}{}
+			}
+			values := strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?),", n), ",")
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(insertStmt, values), args...); err != nil {
+				return ctxerr.Wrap(ctx, err, "insert scheduled query results")
+			}
+			batch = batch[n:]
+		}
+
+		if maxPerHost <= 0 {
+			return nil
+		}
+		for hq := range updated {
+			if _, err := tx.ExecContext(ctx, trimStmt,
+				hq.scheduledQueryID, hq.hostID, hq.scheduledQueryID, hq.hostID, maxPerHost,
+			); err != nil {
+				return ctxerr.Wrap(ctx, err, "delete older scheduled query results")
+			}
+		}
+		return nil
+	})
+}
+
+func (ds *Datastore) ListScheduledQueryResults(ctx context.Context, filter fleet.TeamFilter, opts fleet.ScheduledQueryResultListOptions) ([]*fleet.ScheduledQueryResult, error) {
+	if opts.OrderKey == "" {
+		opts.OrderKey = "id"
+		opts.OrderDirection = fleet.OrderDescending
+	}
+
+	var where []string
+	var args []interface{}
+	if opts.ScheduledQueryID != nil {
+		where = append(where, "sqr.scheduled_query_id = ?")
+		args = append(args, *opts.ScheduledQueryID)
+	}
+	if opts.HostID != nil {
+		where = append(where, "sqr.host_id = ?")
+		args = append(args, *opts.HostID)
+	}
+	where = append(where, ds.whereFilterHostsByTeams(filter, "h"))
+
+	// the derived table allows ordering and paginating with the columns of
+	// the results without ambiguity
+	query := fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT
+				sqr.id,
+				sqr.scheduled_query_id,
+				sqr.host_id,
+				sq.name AS scheduled_query_name,
+				p.name AS pack_name,
+				h.hostname,
+				sqr.action,
+				sqr.data,
+				sqr.last_fetched
+			FROM scheduled_query_results sqr
+			INNER JOIN scheduled_queries sq ON sq.id = sqr.scheduled_query_id
+			INNER JOIN packs p ON p.id = sq.pack_id
+			INNER JOIN hosts h ON h.id = sqr.host_id
+			WHERE %s
+		) AS results`, strings.Join(where, " AND "))
+	query, args = appendListOptionsWithCursorToSQL(query, args, opts.ListOptions)
+
+	results := []*fleet.ScheduledQueryResult{}
+	if err := sqlx.SelectContext(ctx, ds.reader, &results, query, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list scheduled query results")
+	}
+	return results, nil
+}
+
+func (ds *Datastore) CleanupScheduledQueryResults(ctx context.Context, olderThan time.Time) error {
+	// delete in batches to avoid locking the table for too long
+	for {
+		res, err := ds.writer.ExecContext(ctx,
+			`DELETE FROM scheduled_query_results WHERE last_fetched < ? LIMIT ?`,
+			olderThan, scheduledQueryResultsCleanupBatch,
+		)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "cleanup scheduled query results")
+		}
+		if n, _ := res.RowsAffected(); n < scheduledQueryResultsCleanupBatch {
+			return nil
+		}
+	}
+}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"encoding/json"
 	"sort"
 	"testing"
 	"time"
@@ -29,6 +30,7 @@ func TestScheduledQueries(t *testing.T) {
 		{"CascadingDelete", testScheduledQueriesCascadingDelete},
 		{"ScheduledQueryIDsByName", testScheduledQueriesIDsByName},
 		{"AsyncBatchSaveHostsScheduledQueryStats", testScheduledQueriesAsyncBatchSaveStats},
+		{"Results", testScheduledQueriesResults},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -612,3 +614,95 @@ func testScheduledQueriesAsyncBatchSaveStats(t *testing.T, ds *Datastore) {
 	require.Equal(t, 4, execs)
 	assertStats(m)
 }
+
+func testScheduledQueriesResults(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "user", "user@example.com", true)
+	p1 := test.NewPack(t, ds, "p1")
+	q1 := test.NewQuery(t, ds, "q1", "select 1", user.ID, true)
+	q2 := test.NewQuery(t, ds, "q2", "select 2", user.ID, true)
+	sq1 := test.NewScheduledQuery(t, ds, p1.ID, q1.ID, 60, false, false, "sq1")
+	sq2 := test.NewScheduledQuery(t, ds, p1.ID, q2.ID, 60, true, false, "sq2")
+
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	h1 := test.NewHost(t, ds, "h1", "10.0.0.1", "1", "1", time.Now())
+	h2 := test.NewHost(t, ds, "h2", "10.0.0.2", "2", "2", time.Now())
+	require.NoError(t, ds.AddHostsToTeam(ctx, &team.ID, []uint{h2.ID}))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	newResult := func(sqID, hostID uint, value string, fetched time.Time) *fleet.ScheduledQueryResult {
+		return &fleet.ScheduledQueryResult{
+			ScheduledQueryID: sqID,
+			HostID:           hostID,
+			Action:           "added",
+			Data:             json.RawMessage(`{"value":"` + value + `"}`),
+			LastFetched:      fetched,
+		}
+	}
+
+	// only the latest 2 results per scheduled query and host are kept
+	require.NoError(t, ds.SaveScheduledQueryResults(ctx, []*fleet.ScheduledQueryResult{
+		newResult(sq1.ID, h1.ID, "a", now.Add(-3*time.Hour)),
+		newResult(sq1.ID, h1.ID, "b", now.Add(-2*time.Hour)),
+		newResult(sq1.ID, h1.ID, "c", now.Add(-time.Hour)),
+		newResult(sq2.ID, h1.ID, "d", now.Add(-48*time.Hour)),
+		newResult(sq1.ID, h2.ID, "e", now),
+	}, 2))
+	require.NoError(t, ds.SaveScheduledQueryResults(ctx, []*fleet.ScheduledQueryResult{
+		newResult(sq1.ID, h1.ID, "f", now),
+	}, 2))
+
+	values := func(results []*fleet.ScheduledQueryResult) []string {
+		var vals []string
+		for _, r := range results {
+			var data struct {
+				Value string `json:"value"`
+			}
+			require.NoError(t, json.Unmarshal(r.Data, &data))
+			vals = append(vals, data.Value)
+		}
+		return vals
+	}
+
+	adminFilter := fleet.TeamFilter{User: test.UserAdmin}
+	results, err := ds.ListScheduledQueryResults(ctx, adminFilter, fleet.ScheduledQueryResultListOptions{})
+	require.NoError(t, err)
+	// most recent first
+	assert.Equal(t, []string{"f", "e", "d", "c"}, values(results))
+	assert.Equal(t, "sq1", results[0].ScheduledQueryName)
+	assert.Equal(t, "p1", results[0].PackName)
+	assert.Equal(t, "h1", results[0].Hostname)
+	assert.Equal(t, now, results[0].LastFetched.UTC())
+
+	results, err = ds.ListScheduledQueryResults(ctx, adminFilter, fleet.ScheduledQueryResultListOptions{
+		ScheduledQueryID: &sq1.ID,
+		HostID:           &h1.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f", "c"}, values(results))
+
+	results, err = ds.ListScheduledQueryResults(ctx, adminFilter, fleet.ScheduledQueryResultListOptions{
+		ScheduledQueryID: &sq1.ID,
+		ListOptions:      fleet.ListOptions{PerPage: 1, Page: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e"}, values(results))
+
+	// team users only see the results of the hosts of their teams
+	teamUser := &fleet.User{Teams: []fleet.UserTeam{{Team: *team, Role: fleet.RoleObserver}}}
+	results, err = ds.ListScheduledQueryResults(ctx, fleet.TeamFilter{User: teamUser}, fleet.ScheduledQueryResultListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e"}, values(results))
+
+	require.NoError(t, ds.CleanupScheduledQueryResults(ctx, now.Add(-24*time.Hour)))
+	results, err = ds.ListScheduledQueryResults(ctx, adminFilter, fleet.ScheduledQueryResultListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f", "e", "c"}, values(results))
+
+	// results are deleted with the host
+	require.NoError(t, ds.DeleteHost(ctx, h1.ID))
+	results, err = ds.ListScheduledQueryResults(ctx, adminFilter, fleet.ScheduledQueryResultListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e"}, values(results))
+}
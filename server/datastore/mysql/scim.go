@@ -0,0 +1,231 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+func (ds *Datastore) ListSCIMUsers(ctx context.Context, opt fleet.SCIMUserListOptions) ([]*fleet.User, int, error) {
+	whereClause := `WHERE NOT EXISTS (SELECT 1 FROM scim_deleted_users sdu WHERE sdu.user_id = u.id)`
+	var args []interface{}
+	if opt.UserName != "" {
+		whereClause += ` AND u.email = ?`
+		args = append(args, opt.UserName)
+	}
+
+	var total int
+	if err := sqlx.GetContext(ctx, ds.reader, &total, `SELECT COUNT(*) FROM users u `+whereClause, args...); err != nil {
+		return nil, 0, ctxerr.Wrap(ctx, err, "count scim users")
+	}
+
+	stmt := `SELECT u.* FROM users u ` + whereClause + ` ORDER BY u.id`
+	if opt.Limit > 0 {
+		stmt += ` LIMIT ? OFFSET ?`
+		args = append(args, opt.Limit, opt.Offset)
+	} else if opt.Offset > 0 {
+		// MySQL does not support OFFSET without LIMIT, use the largest
+		// possible LIMIT as recommended in its documentation.
+		stmt += ` LIMIT 18446744073709551615 OFFSET ?`
+		args = append(args, opt.Offset)
+	}
+	users := []*fleet.User{}
+	if err := sqlx.SelectContext(ctx, ds.reader, &users, stmt, args...); err != nil {
+		return nil, 0, ctxerr.Wrap(ctx, err, "list scim users")
+	}
+	if err := ds.loadTeamsForUsers(ctx, users); err != nil {
+		return nil, 0, ctxerr.Wrap(ctx, err, "load teams")
+	}
+	return users, total, nil
+}
+
+func (ds *Datastore) SCIMUser(ctx context.Context, id uint) (*fleet.User, error) {
+	var deleted bool
+	err := sqlx.GetContext(ctx, ds.reader, &deleted, `SELECT EXISTS (SELECT 1 FROM scim_deleted_users WHERE user_id = ?)`, id)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "check scim deleted user")
+	}
+	if deleted {
+		return nil, ctxerr.Wrap(ctx, notFound("User").WithID(id))
+	}
+	return ds.UserByID(ctx, id)
+}
+
+func (ds *Datastore) DeleteSCIMUser(ctx context.Context, id uint) error {
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		res, err := tx.ExecContext(ctx, `UPDATE users SET disabled = 1 WHERE id = ?`, id)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "disable scim user")
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ctxerr.Wrap(ctx, notFound("User").WithID(id))
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT IGNORE INTO scim_deleted_users (user_id) VALUES (?)`, id); err != nil {
+			return ctxerr.Wrap(ctx, err, "insert scim deleted user")
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM scim_group_users WHERE user_id = ?`, id); err != nil {
+			return ctxerr.Wrap(ctx, err, "delete scim user groups")
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, id); err != nil {
+			return ctxerr.Wrap(ctx, err, "delete scim user sessions")
+		}
+		return nil
+	})
+}
+
+func (ds *Datastore) RestoreSCIMUser(ctx context.Context, id uint) error {
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM scim_deleted_users WHERE user_id = ?`, id); err != nil {
+			return ctxerr.Wrap(ctx, err, "delete scim deleted user")
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET disabled = 0 WHERE id = ?`, id); err != nil {
+			return ctxerr.Wrap(ctx, err, "enable scim user")
+		}
+		return nil
+	})
+}
+
+func (ds *Datastore) NewSCIMGroup(ctx context.Context, group *fleet.SCIMGroup) (*fleet.SCIMGroup, error) {
+	err := ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO scim_groups (display_name, external_id) VALUES (?, ?)`,
+			group.DisplayName, group.ExternalID,
+		)
+		if err != nil {
+			if isDuplicate(err) {
+				return ctxerr.Wrap(ctx, alreadyExists("SCIMGroup", group.DisplayName))
+			}
+			return ctxerr.Wrap(ctx, err, "insert scim group")
+		}
+
+		id, _ := result.LastInsertId()
+		group.ID = uint(id)
+		return saveSCIMGroupUsersDB(ctx, tx, group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ds.SCIMGroup(ctx, group.ID)
+}
+
+func (ds *Datastore) SCIMGroup(ctx context.Context, id uint) (*fleet.SCIMGroup, error) {
+	var group fleet.SCIMGroup
+	err := sqlx.GetContext(ctx, ds.writer, &group, `SELECT * FROM scim_groups WHERE id = ?`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("SCIMGroup").WithID(id))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "get scim group")
+	}
+
+	if err := loadUsersForSCIMGroupsDB(ctx, ds.writer, []*fleet.SCIMGroup{&group}); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (ds *Datastore) ListSCIMGroups(ctx context.Context) ([]*fleet.SCIMGroup, error) {
+	var groups []*fleet.SCIMGroup
+	if err := sqlx.SelectContext(ctx, ds.reader, &groups, `SELECT * FROM scim_groups ORDER BY id`); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list scim groups")
+	}
+
+	if err := loadUsersForSCIMGroupsDB(ctx, ds.reader, groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (ds *Datastore) ListSCIMGroupsForUser(ctx context.Context, userID uint) ([]*fleet.SCIMGroup, error) {
+	stmt := `
+		SELECT g.* FROM scim_groups g
+		INNER JOIN scim_group_users gu ON g.id = gu.group_id
+		WHERE gu.user_id = ?
+		ORDER BY g.id
+	`
+	var groups []*fleet.SCIMGroup
+	if err := sqlx.SelectContext(ctx, ds.reader, &groups, stmt, userID); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list scim groups for user")
+	}
+	return groups, nil
+}
+
+func (ds *Datastore) SaveSCIMGroup(ctx context.Context, group *fleet.SCIMGroup) error {
+	return ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE scim_groups SET display_name = ?, external_id = ? WHERE id = ?`,
+			group.DisplayName, group.ExternalID, group.ID,
+		)
+		if err != nil {
+			if isDuplicate(err) {
+				return ctxerr.Wrap(ctx, alreadyExists("SCIMGroup", group.DisplayName))
+			}
+			return ctxerr.Wrap(ctx, err, "update scim group")
+		}
+		return saveSCIMGroupUsersDB(ctx, tx, group)
+	})
+}
+
+func (ds *Datastore) DeleteSCIMGroup(ctx context.Context, id uint) error {
+	return ds.deleteEntity(ctx, scimGroupsTable, id)
+}
+
+func saveSCIMGroupUsersDB(ctx context.Context, tx sqlx.ExtContext, group *fleet.SCIMGroup) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scim_group_users WHERE group_id = ?`, group.ID); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete scim group users")
+	}
+	if len(group.UserIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(group.UserIDs)*2)
+	for _, userID := range group.UserIDs {
+		args = append(args, group.ID, userID)
+	}
+	stmt := `INSERT INTO scim_group_users (group_id, user_id) VALUES ` +
+		strings.TrimSuffix(strings.Repeat("(?,?),", len(group.UserIDs)), ",")
+	if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+		if isChildForeignKeyError(err) {
+			return ctxerr.Wrap(ctx, notFound("User"), "insert scim group users")
+		}
+		return ctxerr.Wrap(ctx, err, "insert scim group users")
+	}
+	return nil
+}
+
+func loadUsersForSCIMGroupsDB(ctx context.Context, q sqlx.QueryerContext, groups []*fleet.SCIMGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	groupIDs := make([]uint, 0, len(groups))
+	byID := make(map[uint]*fleet.SCIMGroup, len(groups))
+	for _, g := range groups {
+		// initialize the slice so that groups without members are serialized
+		// as an empty JSON array.
+		g.UserIDs = []uint{}
+		groupIDs = append(groupIDs, g.ID)
+		byID[g.ID] = g
+	}
+
+	stmt, args, err := sqlx.In(`SELECT group_id, user_id FROM scim_group_users WHERE group_id IN (?) ORDER BY group_id, user_id`, groupIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build scim group users query")
+	}
+	var rows []struct {
+		GroupID uint `db:"group_id"`
+		UserID  uint `db:"user_id"`
+	}
+	if err := sqlx.SelectContext(ctx, q, &rows, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "load scim group users")
+	}
+	for _, r := range rows {
+		g := byID[r.GroupID]
+		g.UserIDs = append(g.UserIDs, r.UserID)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSCIM(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"Groups", testSCIMGroups},
+		{"GroupsForUser", testSCIMGroupsForUser},
+		{"Users", testSCIMUsers},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testSCIMGroups(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	users := createTestUsers(t, ds)
+
+	groups, err := ds.ListSCIMGroups(ctx)
+	require.NoError(t, err)
+	require.Empty(t, groups)
+
+	admins, err := ds.NewSCIMGroup(ctx, &fleet.SCIMGroup{
+		DisplayName: "admins",
+		ExternalID:  "ext-admins",
+		UserIDs:     []uint{users[0].ID, users[1].ID},
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, admins.ID)
+	assert.Equal(t, "ext-admins", admins.ExternalID)
+	assert.ElementsMatch(t, []uint{users[0].ID, users[1].ID}, admins.UserIDs)
+
+	_, err = ds.NewSCIMGroup(ctx, &fleet.SCIMGroup{DisplayName: "admins"})
+	var existsErr fleet.AlreadyExistsError
+	require.ErrorAs(t, err, &existsErr)
+
+	_, err = ds.NewSCIMGroup(ctx, &fleet.SCIMGroup{DisplayName: "nobody", UserIDs: []uint{999}})
+	require.True(t, fleet.IsNotFound(err))
+
+	observers, err := ds.NewSCIMGroup(ctx, &fleet.SCIMGroup{DisplayName: "observers"})
+	require.NoError(t, err)
+	assert.Empty(t, observers.UserIDs)
+
+	groups, err = ds.ListSCIMGroups(ctx)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "admins", groups[0].DisplayName)
+	assert.Len(t, groups[0].UserIDs, 2)
+	assert.Equal(t, "observers", groups[1].DisplayName)
+	assert.Empty(t, groups[1].UserIDs)
+
+	observers.DisplayName = "admins"
+	err = ds.SaveSCIMGroup(ctx, observers)
+	require.ErrorAs(t, err, &existsErr)
+
+	observers.DisplayName = "new observers"
+	observers.UserIDs = []uint{users[1].ID}
+	require.NoError(t, ds.SaveSCIMGroup(ctx, observers))
+
+	got, err := ds.SCIMGroup(ctx, observers.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new observers", got.DisplayName)
+	assert.Equal(t, []uint{users[1].ID}, got.UserIDs)
+
+	// deleting a user removes it from its groups
+	require.NoError(t, ds.DeleteUser(ctx, users[1].ID))
+	got, err = ds.SCIMGroup(ctx, admins.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{users[0].ID}, got.UserIDs)
+
+	require.NoError(t, ds.DeleteSCIMGroup(ctx, admins.ID))
+	_, err = ds.SCIMGroup(ctx, admins.ID)
+	require.True(t, fleet.IsNotFound(err))
+	err = ds.DeleteSCIMGroup(ctx, admins.ID)
+	require.True(t, fleet.IsNotFound(err))
+}
+
+func testSCIMGroupsForUser(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	users := createTestUsers(t, ds)
+
+	g1, err := ds.NewSCIMGroup(ctx, &fleet.SCIMGroup{DisplayName: "g1", UserIDs: []uint{users[0].ID}})
+	require.NoError(t, err)
+	g2, err := ds.NewSCIMGroup(ctx, &fleet.SCIMGroup{DisplayName: "g2", UserIDs: []uint{users[0].ID, users[1].ID}})
+	require.NoError(t, err)
+
+	groups, err := ds.ListSCIMGroupsForUser(ctx, users[0].ID)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, g1.ID, groups[0].ID)
+	assert.Equal(t, g2.ID, groups[1].ID)
+
+	groups, err = ds.ListSCIMGroupsForUser(ctx, users[1].ID)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "g2", groups[0].DisplayName)
+
+	groups, err = ds.ListSCIMGroupsForUser(ctx, 999)
+	require.NoError(t, err)
+	require.Empty(t, groups)
+}
+
+func testSCIMUsers(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	users := createTestUsers(t, ds)
+	third, err := ds.NewUser(ctx, &fleet.User{
+		Name:       "third",
+		Email:      "third@fleet.co",
+		Password:   []byte("foobar"),
+		GlobalRole: ptr.String(fleet.RoleObserver),
+	})
+	require.NoError(t, err)
+	users = append(users, third)
+
+	list, total, err := ds.ListSCIMUsers(ctx, fleet.SCIMUserListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Len(t, list, 3)
+
+	// the page is selected in the datastore, the total counts all users
+	list, total, err = ds.ListSCIMUsers(ctx, fleet.SCIMUserListOptions{Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, users[1].ID, list[0].ID)
+
+	list, total, err = ds.ListSCIMUsers(ctx, fleet.SCIMUserListOptions{Offset: 2})
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, users[2].ID, list[0].ID)
+
+	list, total, err = ds.ListSCIMUsers(ctx, fleet.SCIMUserListOptions{UserName: "jason@fleet.co"})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, users[1].ID, list[0].ID)
+
+	g, err := ds.NewSCIMGroup(ctx, &fleet.SCIMGroup{DisplayName: "g", UserIDs: []uint{users[0].ID, users[1].ID}})
+	require.NoError(t, err)
+	_, err = ds.NewSession(ctx, users[1].ID, "session-key")
+	require.NoError(t, err)
+
+	// the deleted user is disabled and hidden from SCIM, but kept in Fleet
+	require.NoError(t, ds.DeleteSCIMUser(ctx, users[1].ID))
+	_, err = ds.SCIMUser(ctx, users[1].ID)
+	require.True(t, fleet.IsNotFound(err))
+	user, err := ds.UserByID(ctx, users[1].ID)
+	require.NoError(t, err)
+	assert.True(t, user.Disabled)
+	_, err = ds.SessionByKey(ctx, "session-key")
+	require.True(t, fleet.IsNotFound(err))
+	g, err = ds.SCIMGroup(ctx, g.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{users[0].ID}, g.UserIDs)
+
+	list, total, err = ds.ListSCIMUsers(ctx, fleet.SCIMUserListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	require.Len(t, list, 2)
+	list, total, err = ds.ListSCIMUsers(ctx, fleet.SCIMUserListOptions{UserName: "jason@fleet.co"})
+	require.NoError(t, err)
+	require.Zero(t, total)
+	require.Empty(t, list)
+
+	err = ds.DeleteSCIMUser(ctx, 999)
+	require.True(t, fleet.IsNotFound(err))
+
+	require.NoError(t, ds.RestoreSCIMUser(ctx, users[1].ID))
+	user, err = ds.SCIMUser(ctx, users[1].ID)
+	require.NoError(t, err)
+	assert.False(t, user.Disabled)
+}
@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+func (ds *Datastore) NewHostScriptExecution(ctx context.Context, exec *fleet.HostScriptExecution) (*fleet.HostScriptExecution, error) {
+	return newHostScriptExecutionDB(ctx, ds.writer, exec)
+}
+
+func (ds *Datastore) NewHostScriptExecutions(ctx context.Context, execs []*fleet.HostScriptExecution) ([]*fleet.HostScriptExecution, error) {
+	created := make([]*fleet.HostScriptExecution, 0, len(execs))
+	err := ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		// reset in case the transaction is retried
+		created = created[:0]
+		for _, exec := range execs {
+			newExec, err := newHostScriptExecutionDB(ctx, tx, exec)
+			if err != nil {
+				return err
+			}
+			created = append(created, newExec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "insert host script executions")
+	}
+	return created, nil
+}
+
+func newHostScriptExecutionDB(ctx context.Context, tx sqlx.ExtContext, exec *fleet.HostScriptExecution) (*fleet.HostScriptExecution, error) {
+	status := exec.Status
+	if status == "" {
+		status = fleet.ScriptExecutionPending
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO host_script_executions (host_id, policy_id, type, contents, status, output)
+		VALUES (?, ?, ?, ?, ?, '')`,
+		exec.HostID, exec.PolicyID, exec.Type, exec.Contents, status,
+	)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "insert host script execution")
+	}
+
+	id, _ := result.LastInsertId()
+	return hostScriptExecutionDB(ctx, tx, uint(id))
+}
+
+func (ds *Datastore) HostScriptExecution(ctx context.Context, id uint) (*fleet.HostScriptExecution, error) {
+	return hostScriptExecutionDB(ctx, ds.reader, id)
+}
+
+func hostScriptExecutionDB(ctx context.Context, q sqlx.QueryerContext, id uint) (*fleet.HostScriptExecution, error) {
+	var exec fleet.HostScriptExecution
+	if err := sqlx.GetContext(ctx, q, &exec, `SELECT * FROM host_script_executions WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("HostScriptExecution").WithID(id))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "get host script execution")
+	}
+	return &exec, nil
+}
+
+func (ds *Datastore) ListHostScriptExecutions(ctx context.Context, hostID uint, opt fleet.ListOptions) ([]*fleet.HostScriptExecution, error) {
+	if opt.OrderKey == "" {
+		opt.OrderKey = "id"
+		opt.OrderDirection = fleet.OrderDescending
+	}
+	stmt := appendListOptionsToSQL(`SELECT * FROM host_script_executions WHERE host_id = ?`, opt)
+
+	var execs []*fleet.HostScriptExecution
+	if err := sqlx.SelectContext(ctx, ds.reader, &execs, stmt, hostID); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list host script executions")
+	}
+	return execs, nil
+}
+
+func (ds *Datastore) ListPendingHostScriptExecutions(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+	var execs []*fleet.HostScriptExecution
+	if err := sqlx.SelectContext(ctx, ds.reader, &execs,
+		`SELECT * FROM host_script_executions WHERE host_id = ? AND type = ? AND status = ? ORDER BY id`,
+		hostID, execType, fleet.ScriptExecutionPending,
+	); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list pending host script executions")
+	}
+	return execs, nil
+}
+
+func (ds *Datastore) MarkHostScriptExecutionsSent(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	stmt, args, err := sqlx.In(
+		`UPDATE host_script_executions SET status = ? WHERE id IN (?) AND status = ?`,
+		fleet.ScriptExecutionSent, ids, fleet.ScriptExecutionPending,
+	)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "build mark host script executions sent query")
+	}
+	if _, err := ds.writer.ExecContext(ctx, stmt, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "mark host script executions sent")
+	}
+	return nil
+}
+
+func (ds *Datastore) SetHostScriptExecutionResult(ctx context.Context, res *fleet.HostScriptExecutionResult) (*fleet.HostScriptExecution, error) {
+	// the host ID is part of the condition so that a host cannot report the
+	// result of another host's script execution.
+	result, err := ds.writer.ExecContext(ctx,
+		`UPDATE host_script_executions SET status = ?, exit_code = ?, output = ? WHERE id = ? AND host_id = ?`,
+		res.Status, res.ExitCode, fleet.TruncateScriptOutput(res.Output), res.ExecutionID, res.HostID,
+	)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "set host script execution result")
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return nil, ctxerr.Wrap(ctx, notFound("HostScriptExecution").WithID(res.ExecutionID))
+	}
+	return hostScriptExecutionDB(ctx, ds.writer, res.ExecutionID)
+}
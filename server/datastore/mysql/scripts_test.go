@@ -0,0 +1,151 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"HostScriptExecutions", testHostScriptExecutions},
+		{"DeletePolicy", testHostScriptExecutionsDeletePolicy},
+		{"Batch", testHostScriptExecutionsBatch},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testHostScriptExecutions(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "windows", nil)
+
+	execs, err := ds.ListHostScriptExecutions(ctx, host1.ID, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, execs)
+
+	e1, err := ds.NewHostScriptExecution(ctx, &fleet.HostScriptExecution{HostID: host1.ID, Type: fleet.ScriptExecutionTypeScript, Contents: "echo 1"})
+	require.NoError(t, err)
+	require.NotZero(t, e1.ID)
+	require.Equal(t, fleet.ScriptExecutionPending, e1.Status)
+	require.Nil(t, e1.ExitCode)
+	require.Nil(t, e1.PolicyID)
+	e2, err := ds.NewHostScriptExecution(ctx, &fleet.HostScriptExecution{HostID: host1.ID, Type: fleet.ScriptExecutionTypeOsquery, Contents: "select 1"})
+	require.NoError(t, err)
+	e3, err := ds.NewHostScriptExecution(ctx, &fleet.HostScriptExecution{HostID: host2.ID, Type: fleet.ScriptExecutionTypeScript, Contents: "echo 3"})
+	require.NoError(t, err)
+
+	pending, err := ds.ListPendingHostScriptExecutions(ctx, host1.ID, fleet.ScriptExecutionTypeScript)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, e1.ID, pending[0].ID)
+	pending, err = ds.ListPendingHostScriptExecutions(ctx, host1.ID, fleet.ScriptExecutionTypeOsquery)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, e2.ID, pending[0].ID)
+
+	require.NoError(t, ds.MarkHostScriptExecutionsSent(ctx, []uint{e1.ID}))
+	require.NoError(t, ds.MarkHostScriptExecutionsSent(ctx, nil))
+	pending, err = ds.ListPendingHostScriptExecutions(ctx, host1.ID, fleet.ScriptExecutionTypeScript)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// a host cannot set the result of another host's execution
+	_, err = ds.SetHostScriptExecutionResult(ctx, &fleet.HostScriptExecutionResult{HostID: host1.ID, ExecutionID: e3.ID, Status: fleet.ScriptExecutionCompleted, ExitCode: ptr.Int(0)})
+	require.Error(t, err)
+	require.True(t, fleet.IsNotFound(err))
+
+	// the output is truncated
+	exec, err := ds.SetHostScriptExecutionResult(ctx, &fleet.HostScriptExecutionResult{
+		HostID:      host1.ID,
+		ExecutionID: e1.ID,
+		Status:      fleet.ScriptExecutionFailed,
+		ExitCode:    ptr.Int(2),
+		Output:      strings.Repeat("a", fleet.ScriptExecutionMaxOutputSize+10),
+	})
+	require.NoError(t, err)
+	require.Equal(t, fleet.ScriptExecutionFailed, exec.Status)
+	require.Equal(t, 2, *exec.ExitCode)
+	require.Len(t, exec.Output, fleet.ScriptExecutionMaxOutputSize)
+
+	exec, err = ds.HostScriptExecution(ctx, e1.ID)
+	require.NoError(t, err)
+	require.Equal(t, fleet.ScriptExecutionFailed, exec.Status)
+
+	// most recent first by default
+	execs, err = ds.ListHostScriptExecutions(ctx, host1.ID, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, execs, 2)
+	require.Equal(t, e2.ID, execs[0].ID)
+	require.Equal(t, e1.ID, execs[1].ID)
+
+	_, err = ds.HostScriptExecution(ctx, 999)
+	require.True(t, fleet.IsNotFound(err))
+}
+
+func testHostScriptExecutionsDeletePolicy(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	host := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	policy := newTestPolicy(t, ds, user, "p1", "", nil)
+
+	exec, err := ds.NewHostScriptExecution(ctx, &fleet.HostScriptExecution{HostID: host.ID, PolicyID: &policy.ID, Type: fleet.ScriptExecutionTypeScript, Contents: "echo fix"})
+	require.NoError(t, err)
+	require.Equal(t, policy.ID, *exec.PolicyID)
+
+	_, err = ds.DeleteGlobalPolicies(ctx, []uint{policy.ID})
+	require.NoError(t, err)
+
+	execs, err := ds.ListHostScriptExecutions(ctx, host.ID, fleet.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, execs)
+}
+
+func testHostScriptExecutionsBatch(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "windows", nil)
+
+	execs, err := ds.NewHostScriptExecutions(ctx, []*fleet.HostScriptExecution{
+		{HostID: host1.ID, Type: fleet.ScriptExecutionTypeScript, Contents: "echo 1"},
+		{HostID: host2.ID, Type: fleet.ScriptExecutionTypeScript, Contents: "echo 1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, execs, 2)
+	require.Equal(t, host1.ID, execs[0].HostID)
+	require.Equal(t, host2.ID, execs[1].HostID)
+	for _, exec := range execs {
+		require.NotZero(t, exec.ID)
+		require.Equal(t, fleet.ScriptExecutionPending, exec.Status)
+	}
+
+	// nothing is queued if one of the executions cannot be inserted
+	_, err = ds.NewHostScriptExecutions(ctx, []*fleet.HostScriptExecution{
+		{HostID: host1.ID, Type: fleet.ScriptExecutionTypeScript, Contents: "echo 2"},
+		{HostID: host2.ID, PolicyID: ptr.Uint(999), Type: fleet.ScriptExecutionTypeScript, Contents: "echo 2"},
+	})
+	require.Error(t, err)
+
+	pending, err := ds.ListPendingHostScriptExecutions(ctx, host1.ID, fleet.ScriptExecutionTypeScript)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, execs[0].ID, pending[0].ID)
+}
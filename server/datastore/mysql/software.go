@@ -185,6 +185,13 @@ func applyChangesForNewSoftwareDB(
 		return err
 	}
 
+	// the initial software inventory of the host is not recorded as changes.
+	if len(currentSoftware) > 0 {
+		if err = recordHostSoftwareChangesDB(ctx, tx, hostID, current, incoming); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -961,12 +968,13 @@ func (ds *Datastore) HostsBySoftwareIDs(ctx context.Context, softwareIDs []uint)
     ON
       h.id = hs.host_id
     WHERE
-      hs.software_id IN (?)
+      hs.software_id IN (?) AND
+      h.lifecycle_state <> ?
 	GROUP BY h.id, h.hostname
     ORDER BY
       h.id`
 
-	stmt, args, err := sqlx.In(queryStmt, softwareIDs)
+	stmt, args, err := sqlx.In(queryStmt, softwareIDs, fleet.HostLifecycleStateRetired)
 	if err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "building query args")
 	}
@@ -988,13 +996,14 @@ FROM
     INNER JOIN host_software hs ON h.id = hs.host_id
     INNER JOIN software_cve scv ON scv.software_id = hs.software_id
 WHERE
-    scv.cve = ?
+    scv.cve = ? AND
+    h.lifecycle_state <> ?
 ORDER BY
     h.id
 `
 
 	var hosts []*fleet.HostShort
-	if err := sqlx.SelectContext(ctx, ds.reader, &hosts, query, cve); err != nil {
+	if err := sqlx.SelectContext(ctx, ds.reader, &hosts, query, cve, fleet.HostLifecycleStateRetired); err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "select hosts by cves")
 	}
 	return hosts, nil
@@ -1106,6 +1115,47 @@ func (ds *Datastore) ListSoftwareVulnerabilities(
 	return result, nil
 }
 
+func (ds *Datastore) ListSoftwareBySources(ctx context.Context, sources []string) ([]fleet.Software, error) {
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	stmt := dialect.
+		From(goqu.T("software").As("s")).
+		Select(
+			goqu.I("s.id"),
+			goqu.I("s.name"),
+			goqu.I("s.version"),
+			goqu.I("s.source"),
+		).
+		Where(
+			goqu.I("s.source").In(sources),
+			goqu.L("EXISTS (SELECT 1 FROM host_software hs WHERE hs.software_id = s.id)"),
+		).
+		Order(goqu.I("s.id").Asc())
+
+	sql, args, err := stmt.ToSQL()
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "error generating SQL statement")
+	}
+
+	var result []fleet.Software
+	if err := sqlx.SelectContext(ctx, ds.reader, &result, sql, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "error executing SQL statement")
+	}
+	return result, nil
+}
+
+func (ds *Datastore) ListSoftwareVulnerabilitiesBySource(ctx context.Context, source fleet.VulnerabilitySource) ([]fleet.SoftwareVulnerability, error) {
+	var result []fleet.SoftwareVulnerability
+	if err := sqlx.SelectContext(ctx, ds.reader, &result,
+		`SELECT software_id, cve FROM software_cve WHERE source = ? AND software_id IS NOT NULL`, source,
+	); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list software vulnerabilities by source")
+	}
+	return result, nil
+}
+
 func (ds *Datastore) ListSoftwareForVulnDetection(
 	ctx context.Context,
 	hostID uint,
@@ -0,0 +1,227 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+// recordHostSoftwareChangesDB stores the software that got installed on and
+// uninstalled from the host, that is the software in the incoming map that is
+// not in the current map and vice versa. It must be called after the host's
+// software was updated, as the IDs of the installed software are read from
+// the host_software table.
+func recordHostSoftwareChangesDB(
+	ctx context.Context,
+	tx sqlx.ExtContext,
+	hostID uint,
+	currentMap map[string]fleet.Software,
+	incomingMap map[string]fleet.Software,
+) error {
+	type change struct {
+		key      string
+		software fleet.Software
+		action   fleet.SoftwareChangeAction
+	}
+
+	var changes []change
+	for key, sw := range currentMap {
+		if _, ok := incomingMap[key]; !ok {
+			changes = append(changes, change{key: key, software: sw, action: fleet.SoftwareChangeUninstalled})
+		}
+	}
+	var installedKeys []string
+	for key := range incomingMap {
+		if _, ok := currentMap[key]; !ok {
+			installedKeys = append(installedKeys, key)
+		}
+	}
+
+	if len(installedKeys) > 0 {
+		installed, err := listSoftwareByHostIDShort(ctx, tx, hostID)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "loading installed software for host")
+		}
+		byKey := softwareSliceToMap(installed)
+		for _, key := range installedKeys {
+			// the software stored is truncated to the maximum lengths of the
+			// columns, as done when inserting it.
+			sw := uniqueStringToSoftware(key)
+			if stored, ok := byKey[softwareToUniqueString(sw)]; ok {
+				sw = stored
+			}
+			changes = append(changes, change{key: key, software: sw, action: fleet.SoftwareChangeInstalled})
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Sort the changes to have the uninstalled software recorded before the
+	// installed one, and in a consistent order.
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].action == changes[j].action {
+			return changes[i].key < changes[j].key
+		}
+		return changes[i].action == fleet.SoftwareChangeUninstalled
+	})
+
+	vals := make([]interface{}, 0, len(changes)*8)
+	for _, c := range changes {
+		var softwareID *uint
+		if c.software.ID != 0 {
+			id := c.software.ID
+			softwareID = &id
+		}
+		vals = append(vals,
+			hostID,
+			softwareID,
+			c.software.Name,
+			c.software.Version,
+			c.software.Source,
+			c.software.BundleIdentifier,
+			c.software.Vendor,
+			c.action,
+		)
+	}
+	stmt := `INSERT INTO host_software_changes (host_id, software_id, name, version, source, bundle_identifier, vendor, action) VALUES ` +
+		strings.TrimSuffix(strings.Repeat(`(?, ?, ?, ?, ?, ?, ?, ?),`, len(changes)), ",")
+	if _, err := tx.ExecContext(ctx, stmt, vals...); err != nil {
+		return ctxerr.Wrap(ctx, err, "insert host software changes")
+	}
+	return nil
+}
+
+func (ds *Datastore) ListSoftwareChanges(ctx context.Context, opt fleet.SoftwareChangeListOptions) ([]*fleet.SoftwareChange, error) {
+	if opt.OrderKey == "" {
+		opt.OrderKey = "id"
+		opt.OrderDirection = fleet.OrderDescending
+	}
+
+	stmt := `
+		SELECT
+			hsc.id,
+			hsc.host_id,
+			COALESCE((SELECT h.hostname FROM hosts h WHERE h.id = hsc.host_id), '') AS hostname,
+			hsc.software_id,
+			hsc.name,
+			hsc.version,
+			hsc.source,
+			hsc.bundle_identifier,
+			hsc.vendor,
+			hsc.action,
+			hsc.created_at
+		FROM host_software_changes hsc
+		WHERE TRUE`
+	var args []interface{}
+	if opt.TeamID != nil {
+		stmt += ` AND EXISTS (SELECT 1 FROM hosts h WHERE h.id = hsc.host_id AND h.team_id = ?)`
+		args = append(args, *opt.TeamID)
+	}
+	if opt.HostID != nil {
+		stmt += ` AND hsc.host_id = ?`
+		args = append(args, *opt.HostID)
+	}
+	if opt.ExcludeRetiredHosts {
+		stmt += ` AND NOT EXISTS (SELECT 1 FROM hosts h WHERE h.id = hsc.host_id AND h.lifecycle_state = ?)`
+		args = append(args, fleet.HostLifecycleStateRetired)
+	}
+	if opt.Action != "" {
+		stmt += ` AND hsc.action = ?`
+		args = append(args, opt.Action)
+	}
+	if opt.From != nil {
+		stmt += ` AND hsc.created_at >= ?`
+		args = append(args, *opt.From)
+	}
+	if opt.To != nil {
+		stmt += ` AND hsc.created_at < ?`
+		args = append(args, *opt.To)
+	}
+	if len(opt.MatchRules) > 0 {
+		// the columns use a case-insensitive collation.
+		var conds []string
+		for _, rule := range opt.MatchRules {
+			if rule.IsEmpty() {
+				continue
+			}
+			var ruleConds []string
+			if rule.Name != "" {
+				ruleConds = append(ruleConds, `hsc.name = ?`)
+				args = append(args, rule.Name)
+			}
+			if rule.BundleIdentifier != "" {
+				ruleConds = append(ruleConds, `hsc.bundle_identifier = ?`)
+				args = append(args, rule.BundleIdentifier)
+			}
+			if rule.Vendor != "" {
+				ruleConds = append(ruleConds, `hsc.vendor = ?`)
+				args = append(args, rule.Vendor)
+			}
+			conds = append(conds, "("+strings.Join(ruleConds, " AND ")+")")
+		}
+		if len(conds) == 0 {
+			return nil, nil
+		}
+		stmt += ` AND (` + strings.Join(conds, " OR ") + `)`
+	}
+	stmt, args = appendListOptionsWithCursorToSQL(stmt, args, opt.ListOptions)
+
+	var changes []*fleet.SoftwareChange
+	if err := sqlx.SelectContext(ctx, ds.reader, &changes, stmt, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list software changes")
+	}
+	return changes, nil
+}
+
+func (ds *Datastore) CleanupSoftwareChanges(ctx context.Context, olderThan time.Time) error {
+	if _, err := ds.writer.ExecContext(ctx, `DELETE FROM host_software_changes WHERE created_at < ?`, olderThan); err != nil {
+		return ctxerr.Wrap(ctx, err, "cleanup software changes")
+	}
+	return nil
+}
+
+// unapprovedSoftwareWebhookCursorType is the type of the aggregated_stats row
+// that stores the cursor of the unapproved software webhook.
+const unapprovedSoftwareWebhookCursorType = "unapproved_software_webhook_cursor"
+
+func (ds *Datastore) UnapprovedSoftwareWebhookCursor(ctx context.Context) (*uint, error) {
+	var cursorJSON []byte
+	// the cursor is read from the primary, as it is updated by the previous run
+	// of the webhook.
+	err := sqlx.GetContext(ctx, ds.writer, &cursorJSON,
+		`SELECT json_value FROM aggregated_stats WHERE id = 0 AND type = ?`, unapprovedSoftwareWebhookCursorType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, ctxerr.Wrap(ctx, err, "select unapproved software webhook cursor")
+	}
+	var cursor uint
+	if err := json.Unmarshal(cursorJSON, &cursor); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "unmarshal unapproved software webhook cursor")
+	}
+	return &cursor, nil
+}
+
+func (ds *Datastore) SetUnapprovedSoftwareWebhookCursor(ctx context.Context, id uint) error {
+	cursorJSON, err := json.Marshal(id)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "marshal unapproved software webhook cursor")
+	}
+	_, err = ds.writer.ExecContext(ctx,
+		`INSERT INTO aggregated_stats (id, type, json_value) VALUES (0, ?, ?) ON DUPLICATE KEY UPDATE json_value = VALUES(json_value)`,
+		unapprovedSoftwareWebhookCursorType, cursorJSON)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "update unapproved software webhook cursor")
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftwareChanges(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"Record", testSoftwareChangesRecord},
+		{"List", testSoftwareChangesList},
+		{"Cleanup", testSoftwareChangesCleanup},
+		{"UnapprovedSoftwareWebhookCursor", testUnapprovedSoftwareWebhookCursor},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testSoftwareChangesRecord(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	host := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+
+	// the initial inventory is not recorded
+	software := []fleet.Software{
+		{Name: "foo", Version: "1.0", Source: "apps"},
+		{Name: "bar", Version: "1.0", Source: "apps", BundleIdentifier: "com.bar"},
+	}
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host.ID, software))
+	changes, err := ds.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	// bar is upgraded and baz is installed
+	software = []fleet.Software{
+		{Name: "foo", Version: "1.0", Source: "apps"},
+		{Name: "bar", Version: "2.0", Source: "apps", BundleIdentifier: "com.bar"},
+		{Name: "baz", Version: "1.0", Source: "apps", Vendor: "Baz Inc."},
+	}
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host.ID, software))
+	changes, err = ds.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{ListOptions: fleet.ListOptions{OrderKey: "id"}})
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	current, err := ds.ListSoftwareByHostIDShort(ctx, host.ID)
+	require.NoError(t, err)
+	idsByName := make(map[string]uint)
+	for _, sw := range current {
+		idsByName[sw.Name] = sw.ID
+	}
+
+	require.Equal(t, fleet.SoftwareChangeUninstalled, changes[0].Action)
+	require.Equal(t, "bar", changes[0].Name)
+	require.Equal(t, "1.0", changes[0].Version)
+	require.Equal(t, "com.bar", changes[0].BundleIdentifier)
+	require.NotNil(t, changes[0].SoftwareID)
+	require.Equal(t, fleet.SoftwareChangeInstalled, changes[1].Action)
+	require.Equal(t, "bar", changes[1].Name)
+	require.Equal(t, "2.0", changes[1].Version)
+	require.Equal(t, idsByName["bar"], *changes[1].SoftwareID)
+	require.Equal(t, fleet.SoftwareChangeInstalled, changes[2].Action)
+	require.Equal(t, "baz", changes[2].Name)
+	require.Equal(t, "Baz Inc.", changes[2].Vendor)
+	require.Equal(t, idsByName["baz"], *changes[2].SoftwareID)
+	for _, c := range changes {
+		require.Equal(t, host.ID, c.HostID)
+		require.Equal(t, host.Hostname, c.Hostname)
+		require.False(t, c.CreatedAt.IsZero())
+	}
+
+	// reporting the same software records nothing
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host.ID, software))
+	changes, err = ds.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{})
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+}
+
+func testSoftwareChangesList(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", &team.ID)
+
+	initial := []fleet.Software{{Name: "foo", Version: "1.0", Source: "apps"}}
+	for _, h := range []*fleet.Host{host1, host2} {
+		require.NoError(t, ds.UpdateHostSoftware(ctx, h.ID, initial))
+	}
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host1.ID, []fleet.Software{
+		{Name: "foo", Version: "1.0", Source: "apps"},
+		{Name: "Dropbox", Version: "1.0", Source: "apps", BundleIdentifier: "com.getdropbox.dropbox"},
+	}))
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host2.ID, []fleet.Software{
+		{Name: "torrent", Version: "1.0", Source: "programs", Vendor: "BitTorrent Inc."},
+	}))
+
+	// set the time of the changes of host1 in the past
+	past := time.Now().Add(-24 * time.Hour)
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		_, err := q.ExecContext(ctx, `UPDATE host_software_changes SET created_at = ? WHERE host_id = ?`, past, host1.ID)
+		return err
+	})
+
+	names := func(opt fleet.SoftwareChangeListOptions) []string {
+		changes, err := ds.ListSoftwareChanges(ctx, opt)
+		require.NoError(t, err)
+		var names []string
+		for _, c := range changes {
+			names = append(names, string(c.Action)+":"+c.Name)
+		}
+		return names
+	}
+
+	// most recent first by default
+	require.Equal(t, []string{"installed:torrent", "uninstalled:foo", "installed:Dropbox"}, names(fleet.SoftwareChangeListOptions{}))
+	require.Equal(t, []string{"installed:Dropbox"}, names(fleet.SoftwareChangeListOptions{HostID: &host1.ID}))
+	require.Equal(t, []string{"installed:torrent", "uninstalled:foo"}, names(fleet.SoftwareChangeListOptions{TeamID: &team.ID}))
+	require.Equal(t, []string{"installed:torrent", "installed:Dropbox"}, names(fleet.SoftwareChangeListOptions{Action: fleet.SoftwareChangeInstalled}))
+
+	from := past.Add(time.Hour)
+	require.Equal(t, []string{"installed:torrent", "uninstalled:foo"}, names(fleet.SoftwareChangeListOptions{From: &from}))
+	require.Equal(t, []string{"installed:Dropbox"}, names(fleet.SoftwareChangeListOptions{To: &from}))
+
+	// rules match case-insensitively on all their fields
+	require.Equal(t, []string{"installed:torrent", "installed:Dropbox"}, names(fleet.SoftwareChangeListOptions{
+		MatchRules: []fleet.SoftwareMatchRule{
+			{BundleIdentifier: "COM.GETDROPBOX.DROPBOX"},
+			{Vendor: "bittorrent inc."},
+		},
+	}))
+	require.Equal(t, []string{"installed:Dropbox"}, names(fleet.SoftwareChangeListOptions{
+		MatchRules: []fleet.SoftwareMatchRule{
+			{Name: "dropbox", BundleIdentifier: "com.getdropbox.dropbox"},
+			{Name: "torrent", Vendor: "other"},
+		},
+	}))
+	require.Empty(t, names(fleet.SoftwareChangeListOptions{MatchRules: []fleet.SoftwareMatchRule{{}}}))
+
+	// paginate with a cursor
+	changes, err := ds.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{
+		ListOptions: fleet.ListOptions{OrderKey: "id", PerPage: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, []string{"uninstalled:foo", "installed:torrent"}, names(fleet.SoftwareChangeListOptions{
+		ListOptions: fleet.ListOptions{OrderKey: "id", After: fmt.Sprint(changes[0].ID)},
+	}))
+}
+
+func testSoftwareChangesCleanup(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	host := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host.ID, []fleet.Software{{Name: "foo", Version: "1.0", Source: "apps"}}))
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host.ID, []fleet.Software{{Name: "bar", Version: "1.0", Source: "apps"}}))
+	ExecAdhocSQL(t, ds, func(q sqlx.ExtContext) error {
+		_, err := q.ExecContext(ctx, `UPDATE host_software_changes SET created_at = ? WHERE action = ?`,
+			time.Now().Add(-48*time.Hour), fleet.SoftwareChangeUninstalled)
+		return err
+	})
+
+	require.NoError(t, ds.CleanupSoftwareChanges(ctx, time.Now().Add(-24*time.Hour)))
+	changes, err := ds.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, fleet.SoftwareChangeInstalled, changes[0].Action)
+	require.Equal(t, "bar", changes[0].Name)
+}
+
+func testUnapprovedSoftwareWebhookCursor(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	cursor, err := ds.UnapprovedSoftwareWebhookCursor(ctx)
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+
+	require.NoError(t, ds.SetUnapprovedSoftwareWebhookCursor(ctx, 0))
+	cursor, err = ds.UnapprovedSoftwareWebhookCursor(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	require.Zero(t, *cursor)
+
+	require.NoError(t, ds.SetUnapprovedSoftwareWebhookCursor(ctx, 42))
+	cursor, err = ds.UnapprovedSoftwareWebhookCursor(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	require.Equal(t, uint(42), *cursor)
+}
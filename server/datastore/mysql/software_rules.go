@@ -0,0 +1,347 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/jmoiron/sqlx"
+)
+
+// softwareRuleViolationsBatchSize is the number of software entries loaded
+// and the number of violations inserted or deleted per statement when
+// updating the software rule violations.
+const softwareRuleViolationsBatchSize = 1000
+
+const selectSoftwareRulesStmt = `
+	SELECT sr.*,
+		(SELECT COUNT(DISTINCT v.host_id) FROM host_software_rule_violations v WHERE v.rule_id = sr.id) AS violating_host_count
+	FROM software_rules sr`
+
+func (ds *Datastore) NewSoftwareRule(ctx context.Context, rule *fleet.SoftwareRule) (*fleet.SoftwareRule, error) {
+	result, err := ds.writer.ExecContext(ctx, `
+		INSERT INTO software_rules (
+			team_id, name, description, action, software_name, software_name_regex,
+			bundle_identifier, version_range, policy_id, author_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rule.TeamID, rule.Name, rule.Description, rule.Action, rule.SoftwareName, rule.SoftwareNameRegex,
+		rule.BundleIdentifier, rule.VersionRange, rule.PolicyID, rule.AuthorID,
+	)
+	if err != nil {
+		if isChildForeignKeyError(err) {
+			return nil, ctxerr.Wrap(ctx, foreignKey("software_rules", "team_id or policy_id"))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "insert software rule")
+	}
+	id, _ := result.LastInsertId()
+	return softwareRuleDB(ctx, ds.writer, uint(id))
+}
+
+func (ds *Datastore) SoftwareRule(ctx context.Context, id uint) (*fleet.SoftwareRule, error) {
+	return softwareRuleDB(ctx, ds.reader, id)
+}
+
+func softwareRuleDB(ctx context.Context, q sqlx.QueryerContext, id uint) (*fleet.SoftwareRule, error) {
+	var rule fleet.SoftwareRule
+	if err := sqlx.GetContext(ctx, q, &rule, selectSoftwareRulesStmt+` WHERE sr.id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ctxerr.Wrap(ctx, notFound("SoftwareRule").WithID(id))
+		}
+		return nil, ctxerr.Wrap(ctx, err, "get software rule")
+	}
+	return &rule, nil
+}
+
+func (ds *Datastore) ListSoftwareRules(ctx context.Context, teamID *uint) ([]*fleet.SoftwareRule, error) {
+	stmt := selectSoftwareRulesStmt + ` WHERE sr.team_id IS NULL ORDER BY sr.id`
+	var args []interface{}
+	if teamID != nil {
+		stmt = selectSoftwareRulesStmt + ` WHERE sr.team_id = ? ORDER BY sr.id`
+		args = append(args, *teamID)
+	}
+	var rules []*fleet.SoftwareRule
+	if err := sqlx.SelectContext(ctx, ds.reader, &rules, stmt, args...); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list software rules")
+	}
+	return rules, nil
+}
+
+func (ds *Datastore) SaveSoftwareRule(ctx context.Context, rule *fleet.SoftwareRule) error {
+	result, err := ds.writer.ExecContext(ctx, `
+		UPDATE software_rules
+		SET name = ?, description = ?, action = ?, software_name = ?, software_name_regex = ?,
+			bundle_identifier = ?, version_range = ?, policy_id = ?
+		WHERE id = ?`,
+		rule.Name, rule.Description, rule.Action, rule.SoftwareName, rule.SoftwareNameRegex,
+		rule.BundleIdentifier, rule.VersionRange, rule.PolicyID, rule.ID,
+	)
+	if err != nil {
+		if isChildForeignKeyError(err) {
+			return ctxerr.Wrap(ctx, foreignKey("software_rules", "policy_id"))
+		}
+		return ctxerr.Wrap(ctx, err, "update software rule")
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ctxerr.Wrap(ctx, notFound("SoftwareRule").WithID(rule.ID))
+	}
+	return nil
+}
+
+func (ds *Datastore) DeleteSoftwareRule(ctx context.Context, id uint) error {
+	result, err := ds.writer.ExecContext(ctx, `DELETE FROM software_rules WHERE id = ?`, id)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "delete software rule")
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ctxerr.Wrap(ctx, notFound("SoftwareRule").WithID(id))
+	}
+	return nil
+}
+
+type softwareRuleViolationKey struct {
+	HostID     uint `db:"host_id"`
+	RuleID     uint `db:"rule_id"`
+	SoftwareID uint `db:"software_id"`
+}
+
+func (ds *Datastore) UpdateSoftwareRuleViolations(ctx context.Context) ([]*fleet.SoftwareRuleViolation, error) {
+	var rules []*fleet.SoftwareRule
+	if err := sqlx.SelectContext(ctx, ds.writer, &rules, `SELECT * FROM software_rules ORDER BY id`); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "load software rules")
+	}
+	rulesByID := make(map[uint]*fleet.SoftwareRule, len(rules))
+	var globalRules []*fleet.SoftwareRule
+	teamRules := make(map[uint][]*fleet.SoftwareRule)
+	for _, r := range rules {
+		rulesByID[r.ID] = r
+		if r.TeamID == nil {
+			globalRules = append(globalRules, r)
+		} else {
+			teamRules[*r.TeamID] = append(teamRules[*r.TeamID], r)
+		}
+	}
+
+	// The hosts of a team with rules are subject to the global rules and the
+	// team's rules, the other hosts only to the global rules.
+	globalSet, err := fleet.NewSoftwareRuleSet(globalRules)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "compile global software rules")
+	}
+	teamSets := make(map[uint]*fleet.SoftwareRuleSet, len(teamRules))
+	teamIDs := make([]uint, 0, len(teamRules))
+	for teamID, trules := range teamRules {
+		set, err := fleet.NewSoftwareRuleSet(append(trules, globalRules...))
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "compile team software rules")
+		}
+		teamSets[teamID] = set
+		teamIDs = append(teamIDs, teamID)
+	}
+	sort.Slice(teamIDs, func(i, j int) bool { return teamIDs[i] < teamIDs[j] })
+
+	// deniedBy maps the denied software IDs to the rules denying them, per
+	// team (0 is used for the hosts subject to the global rules only).
+	deniedBy := make(map[uint]map[uint][]uint)
+	deny := func(scope uint, softwareID uint, denying []*fleet.SoftwareRule) {
+		if len(denying) == 0 {
+			return
+		}
+		if deniedBy[scope] == nil {
+			deniedBy[scope] = make(map[uint][]uint)
+		}
+		for _, r := range denying {
+			deniedBy[scope][softwareID] = append(deniedBy[scope][softwareID], r.ID)
+		}
+	}
+	if len(rules) > 0 {
+		var lastID uint
+		for {
+			var batch []fleet.Software
+			if err := sqlx.SelectContext(ctx, ds.writer, &batch, `
+				SELECT id, name, version, bundle_identifier FROM software
+				WHERE id > ? ORDER BY id LIMIT ?`, lastID, softwareRuleViolationsBatchSize); err != nil {
+				return nil, ctxerr.Wrap(ctx, err, "load software batch")
+			}
+			for i := range batch {
+				sw := &batch[i]
+				deny(0, sw.ID, globalSet.DenyingRules(sw))
+				for _, teamID := range teamIDs {
+					deny(teamID, sw.ID, teamSets[teamID].DenyingRules(sw))
+				}
+			}
+			if len(batch) < softwareRuleViolationsBatchSize {
+				break
+			}
+			lastID = batch[len(batch)-1].ID
+		}
+	}
+
+	// compute the violations from the hosts that have the denied software
+	// installed.
+	target := make(map[softwareRuleViolationKey]bool)
+	for scope, denied := range deniedBy {
+		scopeCond := `h.team_id = ?`
+		scopeArgs := []interface{}{scope}
+		if scope == 0 {
+			scopeCond = `TRUE`
+			scopeArgs = nil
+			if len(teamIDs) > 0 {
+				scopeCond = `(h.team_id IS NULL OR h.team_id NOT IN (?))`
+				scopeArgs = []interface{}{teamIDs}
+			}
+		}
+		softwareIDs := make([]uint, 0, len(denied))
+		for id := range denied {
+			softwareIDs = append(softwareIDs, id)
+		}
+		for start := 0; start < len(softwareIDs); start += softwareRuleViolationsBatchSize {
+			end := start + softwareRuleViolationsBatchSize
+			if end > len(softwareIDs) {
+				end = len(softwareIDs)
+			}
+			stmt, args, err := sqlx.In(`
+				SELECT hs.host_id, hs.software_id FROM host_software hs
+				JOIN hosts h ON h.id = hs.host_id
+				WHERE hs.software_id IN (?) AND h.lifecycle_state <> ? AND `+scopeCond,
+				append([]interface{}{softwareIDs[start:end], fleet.HostLifecycleStateRetired}, scopeArgs...)...)
+			if err != nil {
+				return nil, ctxerr.Wrap(ctx, err, "build denied host software query")
+			}
+			var rows []softwareRuleViolationKey
+			if err := sqlx.SelectContext(ctx, ds.writer, &rows, stmt, args...); err != nil {
+				return nil, ctxerr.Wrap(ctx, err, "select denied host software")
+			}
+			for _, row := range rows {
+				for _, ruleID := range denied[row.SoftwareID] {
+					target[softwareRuleViolationKey{HostID: row.HostID, RuleID: ruleID, SoftwareID: row.SoftwareID}] = true
+				}
+			}
+		}
+	}
+
+	var existing []softwareRuleViolationKey
+	if err := sqlx.SelectContext(ctx, ds.writer, &existing,
+		`SELECT host_id, rule_id, software_id FROM host_software_rule_violations`); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "select software rule violations")
+	}
+	existingPairs := make(map[[2]uint]bool, len(existing))
+	var toDelete []softwareRuleViolationKey
+	for _, v := range existing {
+		existingPairs[[2]uint{v.HostID, v.RuleID}] = true
+		if !target[v] {
+			toDelete = append(toDelete, v)
+		} else {
+			delete(target, v)
+		}
+	}
+	toInsert := make([]softwareRuleViolationKey, 0, len(target))
+	for v := range target {
+		toInsert = append(toInsert, v)
+	}
+	sort.Slice(toInsert, func(i, j int) bool {
+		if toInsert[i].HostID != toInsert[j].HostID {
+			return toInsert[i].HostID < toInsert[j].HostID
+		}
+		if toInsert[i].RuleID != toInsert[j].RuleID {
+			return toInsert[i].RuleID < toInsert[j].RuleID
+		}
+		return toInsert[i].SoftwareID < toInsert[j].SoftwareID
+	})
+
+	err = ds.withRetryTxx(ctx, func(tx sqlx.ExtContext) error {
+		for start := 0; start < len(toDelete); start += softwareRuleViolationsBatchSize {
+			end := start + softwareRuleViolationsBatchSize
+			if end > len(toDelete) {
+				end = len(toDelete)
+			}
+			batch := toDelete[start:end]
+			args := make([]interface{}, 0, len(batch)*3)
+			for _, v := range batch {
+				args = append(args, v.HostID, v.RuleID, v.SoftwareID)
+			}
+			stmt := `DELETE FROM host_software_rule_violations WHERE (host_id, rule_id, software_id) IN (` +
+				strings.TrimSuffix(strings.Repeat(`(?, ?, ?),`, len(batch)), ",") + `)`
+			if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+				return ctxerr.Wrap(ctx, err, "delete software rule violations")
+			}
+		}
+		for start := 0; start < len(toInsert); start += softwareRuleViolationsBatchSize {
+			end := start + softwareRuleViolationsBatchSize
+			if end > len(toInsert) {
+				end = len(toInsert)
+			}
+			batch := toInsert[start:end]
+			args := make([]interface{}, 0, len(batch)*3)
+			for _, v := range batch {
+				args = append(args, v.HostID, v.RuleID, v.SoftwareID)
+			}
+			stmt := `INSERT IGNORE INTO host_software_rule_violations (host_id, rule_id, software_id) VALUES ` +
+				strings.TrimSuffix(strings.Repeat(`(?, ?, ?),`, len(batch)), ",")
+			if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+				return ctxerr.Wrap(ctx, err, "insert software rule violations")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a host starts violating a rule if it was not violating it with any
+	// software before.
+	var newViolations []*fleet.SoftwareRuleViolation
+	newPairs := make(map[[2]uint]bool)
+	var hostIDs []uint
+	for _, v := range toInsert {
+		pair := [2]uint{v.HostID, v.RuleID}
+		if existingPairs[pair] || newPairs[pair] {
+			continue
+		}
+		newPairs[pair] = true
+		if len(hostIDs) == 0 || hostIDs[len(hostIDs)-1] != v.HostID {
+			hostIDs = append(hostIDs, v.HostID)
+		}
+		newViolations = append(newViolations, &fleet.SoftwareRuleViolation{
+			RuleID:   v.RuleID,
+			PolicyID: rulesByID[v.RuleID].PolicyID,
+			HostID:   v.HostID,
+		})
+	}
+	if len(newViolations) == 0 {
+		return nil, nil
+	}
+
+	type hostNames struct {
+		ID          uint   `db:"id"`
+		Hostname    string `db:"hostname"`
+		DisplayName string `db:"display_name"`
+	}
+	namesByID := make(map[uint]hostNames, len(hostIDs))
+	for start := 0; start < len(hostIDs); start += softwareRuleViolationsBatchSize {
+		end := start + softwareRuleViolationsBatchSize
+		if end > len(hostIDs) {
+			end = len(hostIDs)
+		}
+		stmt, args, err := sqlx.In(`
+			SELECT id, hostname, COALESCE(NULLIF(computer_name, ''), hostname) AS display_name
+			FROM hosts WHERE id IN (?)`, hostIDs[start:end])
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "build violating hosts query")
+		}
+		var names []hostNames
+		if err := sqlx.SelectContext(ctx, ds.writer, &names, stmt, args...); err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "select violating hosts")
+		}
+		for _, n := range names {
+			namesByID[n.ID] = n
+		}
+	}
+	for _, v := range newViolations {
+		v.Hostname = namesByID[v.HostID].Hostname
+		v.DisplayName = namesByID[v.HostID].DisplayName
+	}
+	return newViolations, nil
+}
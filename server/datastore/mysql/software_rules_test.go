@@ -0,0 +1,218 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftwareRules(t *testing.T) {
+	ds := CreateMySQLDS(t)
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, ds *Datastore)
+	}{
+		{"CRUD", testSoftwareRulesCRUD},
+		{"Violations", testSoftwareRulesViolations},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer TruncateTables(t, ds)
+			c.fn(t, ds)
+		})
+	}
+}
+
+func testSoftwareRulesCRUD(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+
+	rules, err := ds.ListSoftwareRules(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, rules)
+
+	r1, err := ds.NewSoftwareRule(ctx, &fleet.SoftwareRule{
+		Name:              "no torrents",
+		Action:            fleet.SoftwareRuleDeny,
+		SoftwareNameRegex: "(?i)torrent",
+		AuthorID:          &user.ID,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, r1.ID)
+	require.Nil(t, r1.TeamID)
+	require.Equal(t, user.ID, *r1.AuthorID)
+	require.Zero(t, r1.ViolatingHostCount)
+
+	r2, err := ds.NewSoftwareRule(ctx, &fleet.SoftwareRule{
+		TeamID:           &team.ID,
+		Name:             "old zoom",
+		Action:           fleet.SoftwareRuleDeny,
+		BundleIdentifier: "us.zoom.xos",
+		VersionRange:     "< 5.0",
+	})
+	require.NoError(t, err)
+	require.Equal(t, team.ID, *r2.TeamID)
+
+	// unknown team
+	_, err = ds.NewSoftwareRule(ctx, &fleet.SoftwareRule{
+		TeamID:       ptr.Uint(team.ID + 1000),
+		Name:         "unknown",
+		Action:       fleet.SoftwareRuleDeny,
+		SoftwareName: "foo",
+	})
+	require.Error(t, err)
+
+	rules, err = ds.ListSoftwareRules(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, r1.ID, rules[0].ID)
+	rules, err = ds.ListSoftwareRules(ctx, &team.ID)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, r2.ID, rules[0].ID)
+
+	r2.Name = "very old zoom"
+	r2.VersionRange = "< 4.0"
+	require.NoError(t, ds.SaveSoftwareRule(ctx, r2))
+	got, err := ds.SoftwareRule(ctx, r2.ID)
+	require.NoError(t, err)
+	require.Equal(t, "very old zoom", got.Name)
+	require.Equal(t, "< 4.0", got.VersionRange)
+	require.Equal(t, team.ID, *got.TeamID)
+
+	require.NoError(t, ds.DeleteSoftwareRule(ctx, r1.ID))
+	var nfe fleet.NotFoundError
+	_, err = ds.SoftwareRule(ctx, r1.ID)
+	require.ErrorAs(t, err, &nfe)
+	err = ds.DeleteSoftwareRule(ctx, r1.ID)
+	require.ErrorAs(t, err, &nfe)
+
+	// deleting the team deletes its rules
+	require.NoError(t, ds.DeleteTeam(ctx, team.ID))
+	_, err = ds.SoftwareRule(ctx, r2.ID)
+	require.ErrorAs(t, err, &nfe)
+}
+
+func testSoftwareRulesViolations(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+	user := test.NewUser(t, ds, "Alice", "alice@example.com", true)
+	team1, err := ds.NewTeam(ctx, &fleet.Team{Name: "team1"})
+	require.NoError(t, err)
+	team2, err := ds.NewTeam(ctx, &fleet.Team{Name: "team2"})
+	require.NoError(t, err)
+	policy := newTestPolicy(t, ds, user, "p1", "darwin", nil)
+
+	host1 := newTestHostWithPlatform(t, ds, "host1", "darwin", nil)
+	host2 := newTestHostWithPlatform(t, ds, "host2", "darwin", &team1.ID)
+	host3 := newTestHostWithPlatform(t, ds, "host3", "darwin", &team2.ID)
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host1.ID, []fleet.Software{
+		{Name: "uTorrent", Version: "3.5", Source: "apps"},
+		{Name: "zoom.us", Version: "5.0.1", Source: "apps", BundleIdentifier: "us.zoom.xos"},
+	}))
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host2.ID, []fleet.Software{
+		{Name: "uTorrent", Version: "3.5", Source: "apps"},
+		{Name: "zoom.us", Version: "4.0.0", Source: "apps", BundleIdentifier: "us.zoom.xos"},
+	}))
+	host3Software := []fleet.Software{
+		{Name: "uTorrent", Version: "3.5", Source: "apps"},
+		{Name: "zoom.us", Version: "4.0.0", Source: "apps", BundleIdentifier: "us.zoom.xos"},
+	}
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host3.ID, host3Software))
+
+	// no rules, no violations
+	violations, err := ds.UpdateSoftwareRuleViolations(ctx)
+	require.NoError(t, err)
+	require.Empty(t, violations)
+
+	torrents, err := ds.NewSoftwareRule(ctx, &fleet.SoftwareRule{
+		Name: "no torrents", Action: fleet.SoftwareRuleDeny, SoftwareNameRegex: "(?i)torrent", PolicyID: &policy.ID,
+	})
+	require.NoError(t, err)
+	// team1 allows uTorrent as an exception to the global rule
+	_, err = ds.NewSoftwareRule(ctx, &fleet.SoftwareRule{
+		TeamID: &team1.ID, Name: "utorrent ok", Action: fleet.SoftwareRuleAllow, SoftwareName: "utorrent",
+	})
+	require.NoError(t, err)
+	oldZoom, err := ds.NewSoftwareRule(ctx, &fleet.SoftwareRule{
+		TeamID: &team2.ID, Name: "old zoom", Action: fleet.SoftwareRuleDeny, BundleIdentifier: "us.zoom.xos", VersionRange: "< 5.0",
+	})
+	require.NoError(t, err)
+
+	violations, err = ds.UpdateSoftwareRuleViolations(ctx)
+	require.NoError(t, err)
+	require.Len(t, violations, 3)
+	require.Equal(t, &fleet.SoftwareRuleViolation{
+		RuleID: torrents.ID, PolicyID: &policy.ID, HostID: host1.ID, Hostname: "host1", DisplayName: "host1",
+	}, violations[0])
+	require.Equal(t, torrents.ID, violations[1].RuleID)
+	require.Equal(t, host3.ID, violations[1].HostID)
+	require.Equal(t, oldZoom.ID, violations[2].RuleID)
+	require.Equal(t, host3.ID, violations[2].HostID)
+	require.Nil(t, violations[2].PolicyID)
+
+	// the violations are only returned when they start
+	violations, err = ds.UpdateSoftwareRuleViolations(ctx)
+	require.NoError(t, err)
+	require.Empty(t, violations)
+
+	checkRuleCounts := func(torrentsCount, oldZoomCount uint) {
+		rule, err := ds.SoftwareRule(ctx, torrents.ID)
+		require.NoError(t, err)
+		require.Equal(t, torrentsCount, rule.ViolatingHostCount)
+		rules, err := ds.ListSoftwareRules(ctx, &team2.ID)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		require.Equal(t, oldZoomCount, rules[0].ViolatingHostCount)
+	}
+	checkRuleCounts(2, 1)
+
+	listHostIDs := func(opt fleet.HostListOptions) []uint {
+		hosts, err := ds.ListHosts(ctx, fleet.TeamFilter{User: user}, opt)
+		require.NoError(t, err)
+		ids := make([]uint, 0, len(hosts))
+		for _, h := range hosts {
+			ids = append(ids, h.ID)
+		}
+		count, err := ds.CountHosts(ctx, fleet.TeamFilter{User: user}, opt)
+		require.NoError(t, err)
+		require.Equal(t, len(ids), count)
+		return ids
+	}
+	require.ElementsMatch(t, []uint{host1.ID, host3.ID}, listHostIDs(fleet.HostListOptions{SoftwareStatusFilter: fleet.HostSoftwareStatusDenied}))
+	require.ElementsMatch(t, []uint{host2.ID}, listHostIDs(fleet.HostListOptions{SoftwareStatusFilter: fleet.HostSoftwareStatusCompliant}))
+	require.ElementsMatch(t, []uint{host3.ID}, listHostIDs(fleet.HostListOptions{SoftwareRuleIDFilter: &oldZoom.ID}))
+
+	// upgrading zoom resolves the violation
+	host3Software[1].Version = "5.1.0"
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host3.ID, host3Software))
+	violations, err = ds.UpdateSoftwareRuleViolations(ctx)
+	require.NoError(t, err)
+	require.Empty(t, violations)
+	checkRuleCounts(2, 0)
+	require.Empty(t, listHostIDs(fleet.HostListOptions{SoftwareRuleIDFilter: &oldZoom.ID}))
+
+	// the retired hosts have no violations
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID}, fleet.HostLifecycleStateRetired, nil))
+	violations, err = ds.UpdateSoftwareRuleViolations(ctx)
+	require.NoError(t, err)
+	require.Empty(t, violations)
+	checkRuleCounts(1, 0)
+	require.NoError(t, ds.SetHostsLifecycleState(ctx, []uint{host1.ID}, fleet.HostLifecycleStateActive, nil))
+	violations, err = ds.UpdateSoftwareRuleViolations(ctx)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	require.Equal(t, host1.ID, violations[0].HostID)
+	checkRuleCounts(2, 0)
+
+	// deleting the rule deletes its violations
+	require.NoError(t, ds.DeleteSoftwareRule(ctx, torrents.ID))
+	require.Empty(t, listHostIDs(fleet.HostListOptions{SoftwareStatusFilter: fleet.HostSoftwareStatusDenied}))
+	require.Len(t, listHostIDs(fleet.HostListOptions{SoftwareStatusFilter: fleet.HostSoftwareStatusCompliant}), 3)
+}
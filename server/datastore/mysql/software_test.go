@@ -42,6 +42,7 @@ func TestSoftware(t *testing.T) {
 		{"InsertVulnerabilities", testInsertVulnerabilities},
 		{"ListCVEs", testListCVEs},
 		{"ListSoftwareForVulnDetection", testListSoftwareForVulnDetection},
+		{"ListSoftwareBySources", testListSoftwareBySources},
 		{"SoftwareByID", testSoftwareByID},
 	}
 	for _, c := range cases {
@@ -1627,6 +1628,62 @@ func testListSoftwareForVulnDetection(t *testing.T, ds *Datastore) {
 	})
 }
 
+func testListSoftwareBySources(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	host := test.NewHost(t, ds, "host1", "", "host1key", "host1uuid", time.Now())
+	software := []fleet.Software{
+		{Name: "requests", Version: "2.19.0", Source: "python_packages"},
+		{Name: "lodash", Version: "4.17.15", Source: "npm_packages"},
+		{Name: "bar", Version: "0.0.3", Source: "apps"},
+	}
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host.ID, software))
+	require.NoError(t, ds.LoadHostSoftware(ctx, host, false))
+	sort.Slice(host.Software, func(i, j int) bool { return host.Software[i].ID < host.Software[j].ID })
+
+	result, err := ds.ListSoftwareBySources(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, result)
+
+	result, err = ds.ListSoftwareBySources(ctx, []string{"python_packages", "npm_packages"})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	for _, r := range result {
+		require.NotEqual(t, "apps", r.Source)
+		require.NotZero(t, r.ID)
+		require.NotEmpty(t, r.Version)
+	}
+
+	// vulnerabilities are listed by the source that detected them
+	byName := make(map[string]fleet.Software)
+	for _, s := range host.Software {
+		byName[s.Name] = s
+	}
+	_, err = ds.InsertVulnerabilities(ctx, []fleet.SoftwareVulnerability{
+		{SoftwareID: byName["requests"].ID, CVE: "CVE-2018-18074"},
+		{SoftwareID: byName["lodash"].ID, CVE: "CVE-2020-8203"},
+	}, fleet.OSVSource)
+	require.NoError(t, err)
+	_, err = ds.InsertVulnerabilities(ctx, []fleet.SoftwareVulnerability{
+		{SoftwareID: byName["bar"].ID, CVE: "CVE-2022-0001"},
+	}, fleet.NVDSource)
+	require.NoError(t, err)
+
+	vulns, err := ds.ListSoftwareVulnerabilitiesBySource(ctx, fleet.OSVSource)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []fleet.SoftwareVulnerability{
+		{SoftwareID: byName["requests"].ID, CVE: "CVE-2018-18074"},
+		{SoftwareID: byName["lodash"].ID, CVE: "CVE-2020-8203"},
+	}, vulns)
+
+	// software that is not installed on any host anymore is not listed
+	require.NoError(t, ds.UpdateHostSoftware(ctx, host.ID, software[1:]))
+	result, err = ds.ListSoftwareBySources(ctx, []string{"python_packages", "npm_packages"})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	require.Equal(t, "lodash", result[0].Name)
+}
+
 func testSoftwareByID(t *testing.T, ds *Datastore) {
 	t.Run("software installed in multiple hosts does not have duplicated vulnerabilities", func(t *testing.T) {
 		ctx := context.Background()
@@ -358,6 +358,63 @@ func teamFeaturesDB(ctx context.Context, q sqlx.QueryerContext, tid uint) (*flee
 	return &features, nil
 }
 
+func (ds *Datastore) TeamScriptSettings(ctx context.Context, tid uint) (*fleet.ScriptSettings, error) {
+	sql := `SELECT config->'$.scripts' as scripts FROM teams WHERE id = ?`
+	var raw *json.RawMessage
+	if err := sqlx.GetContext(ctx, ds.reader, &raw, sql, tid); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get team config scripts")
+	}
+
+	var settings fleet.ScriptSettings
+	if raw != nil {
+		if err := json.Unmarshal(*raw, &settings); err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "unmarshal team config scripts")
+		}
+	}
+	return &settings, nil
+}
+
+func (ds *Datastore) ListHostAssignmentRules(ctx context.Context) (fleet.TeamHostAssignmentRules, error) {
+	sql := `
+		SELECT
+			id,
+			name,
+			config->'$.host_assignment_rules' as rules
+		FROM teams
+		WHERE JSON_LENGTH(config, '$.host_assignment_rules') > 0`
+	var teams []struct {
+		ID    uint            `db:"id"`
+		Name  string          `db:"name"`
+		Rules json.RawMessage `db:"rules"`
+	}
+	if err := sqlx.SelectContext(ctx, ds.reader, &teams, sql); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "select team host assignment rules")
+	}
+
+	var rules fleet.TeamHostAssignmentRules
+	for _, team := range teams {
+		var teamRules []fleet.HostAssignmentRule
+		if err := json.Unmarshal(team.Rules, &teamRules); err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "unmarshal team host assignment rules")
+		}
+		for i, r := range teamRules {
+			// the rules are compiled once when they are loaded, not every time they
+			// are matched against a host.
+			if err := r.Compile(); err != nil {
+				return nil, ctxerr.Wrapf(ctx, err, "compile host assignment rule %d of team %d", i, team.ID)
+			}
+			rules = append(rules, &fleet.TeamHostAssignmentRule{
+				HostAssignmentRule: r,
+				TeamID:             team.ID,
+				TeamName:           team.Name,
+				Index:              i,
+			})
+		}
+	}
+	fleet.SortTeamHostAssignmentRules(rules)
+	return rules, nil
+}
+
 // DeleteIntegrationsFromTeams removes the deleted integrations from any team
 // that uses it.
 func (ds *Datastore) DeleteIntegrationsFromTeams(ctx context.Context, deletedIntgs fleet.Integrations) error {
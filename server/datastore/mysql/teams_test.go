@@ -32,6 +32,8 @@ func TestTeams(t *testing.T) {
 		{"TeamsDeleteRename", testTeamsDeleteRename},
 		{"DeleteIntegrationsFromTeams", testTeamsDeleteIntegrationsFromTeams},
 		{"TeamsFeatures", testTeamsFeatures},
+		{"TeamScriptSettings", testTeamScriptSettings},
+		{"ListHostAssignmentRules", testTeamsListHostAssignmentRules},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -518,3 +520,91 @@ func testTeamsFeatures(t *testing.T, ds *Datastore) {
 		}, features)
 	})
 }
+
+func testTeamScriptSettings(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	team, err := ds.NewTeam(ctx, &fleet.Team{Name: "team_no_scripts"})
+	require.NoError(t, err)
+	ExecAdhocSQL(t, ds, func(tx sqlx.ExtContext) error {
+		_, err = tx.ExecContext(ctx, "UPDATE teams SET config = '{}' WHERE id = ?", team.ID)
+		return err
+	})
+	settings, err := ds.TeamScriptSettings(ctx, team.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &fleet.ScriptSettings{}, settings)
+
+	hash := fleet.ScriptHash("echo hello")
+	team, err = ds.NewTeam(ctx, &fleet.Team{
+		Name: "team_scripts",
+		Config: fleet.TeamConfig{
+			Scripts: fleet.ScriptSettings{Enabled: true, Allowlist: []string{hash}},
+		},
+	})
+	require.NoError(t, err)
+	settings, err = ds.TeamScriptSettings(ctx, team.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &fleet.ScriptSettings{Enabled: true, Allowlist: []string{hash}}, settings)
+
+	team, err = ds.Team(ctx, team.ID)
+	require.NoError(t, err)
+	assert.Equal(t, settings, &team.Config.Scripts)
+}
+
+func testTeamsListHostAssignmentRules(t *testing.T, ds *Datastore) {
+	ctx := context.Background()
+
+	_, err := ds.NewTeam(ctx, &fleet.Team{Name: "team_no_rules"})
+	require.NoError(t, err)
+	rules, err := ds.ListHostAssignmentRules(ctx)
+	require.NoError(t, err)
+	require.Empty(t, rules)
+
+	team1, err := ds.NewTeam(ctx, &fleet.Team{
+		Name: "team1",
+		Config: fleet.TeamConfig{
+			HostAssignmentRules: []fleet.HostAssignmentRule{
+				{Priority: 10, Platform: "darwin"},
+				{Priority: 0, HostnameRegex: "^web-"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	team2, err := ds.NewTeam(ctx, &fleet.Team{
+		Name: "team2",
+		Config: fleet.TeamConfig{
+			HostAssignmentRules: []fleet.HostAssignmentRule{
+				{Priority: 10, Label: "Servers"},
+				{Priority: 5, PublicIPCIDR: "203.0.113.0/24"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	rules, err = ds.ListHostAssignmentRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+	// the rules are compiled
+	wantRule := &fleet.TeamHostAssignmentRule{
+		HostAssignmentRule: fleet.HostAssignmentRule{HostnameRegex: "^web-"},
+		TeamID:             team1.ID,
+		TeamName:           "team1",
+		Index:              1,
+	}
+	require.NoError(t, wantRule.Compile())
+	assert.Equal(t, wantRule, rules[0])
+	assert.True(t, rules[0].Matches(&fleet.Host{Hostname: "web-1"}, nil))
+	assert.Equal(t, team2.ID, rules[1].TeamID)
+	assert.Equal(t, "203.0.113.0/24", rules[1].PublicIPCIDR)
+	assert.True(t, rules[1].Matches(&fleet.Host{PublicIP: "203.0.113.7"}, nil))
+	assert.Equal(t, team1.ID, rules[2].TeamID)
+	assert.Equal(t, "darwin", rules[2].Platform)
+	assert.Equal(t, team2.ID, rules[3].TeamID)
+	assert.Equal(t, "Servers", rules[3].Label)
+
+	// the rules of a deleted team are deleted
+	require.NoError(t, ds.DeleteTeam(ctx, team2.ID))
+	rules, err = ds.ListHostAssignmentRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+}
@@ -32,8 +32,9 @@ func (ds *Datastore) NewUser(ctx context.Context, user *fleet.User) (*fleet.User
       	position,
         sso_enabled,
 		api_only,
-		global_role
-      ) VALUES (?,?,?,?,?,?,?,?,?,?)
+		global_role,
+		disabled
+      ) VALUES (?,?,?,?,?,?,?,?,?,?,?)
       `
 		result, err := tx.ExecContext(ctx, sqlStatement,
 			user.Password,
@@ -45,7 +46,8 @@ func (ds *Datastore) NewUser(ctx context.Context, user *fleet.User) (*fleet.User
 			user.Position,
 			user.SSOEnabled,
 			user.APIOnly,
-			user.GlobalRole)
+			user.GlobalRole,
+			user.Disabled)
 		if err != nil {
 			return ctxerr.Wrap(ctx, err, "create new user")
 		}
@@ -164,7 +166,8 @@ func saveUserDB(ctx context.Context, tx sqlx.ExtContext, user *fleet.User) error
       	position = ?,
         sso_enabled = ?,
         api_only = ?,
-		global_role = ?
+		global_role = ?,
+		disabled = ?
       WHERE id = ?
       `
 	result, err := tx.ExecContext(ctx, sqlStatement,
@@ -178,6 +181,7 @@ func saveUserDB(ctx context.Context, tx sqlx.ExtContext, user *fleet.User) error
 		user.SSOEnabled,
 		user.APIOnly,
 		user.GlobalRole,
+		user.Disabled,
 		user.ID)
 	if err != nil {
 		return ctxerr.Wrap(ctx, err, "save user")
@@ -45,6 +45,39 @@ const (
 	ActivityTypeEditedAgentOptions = "edited_agent_options"
 	// ActivityTypeAppliedSpecTeam is the activity type for a team spec applied
 	ActivityTypeAppliedSpecTeam = "applied_spec_team"
+	// ActivityTypeRanScript is the activity type for scripts queued to run on
+	// hosts
+	ActivityTypeRanScript = "ran_script"
+	// ActivityTypeCreatedPolicyWaiver is the activity type for created policy
+	// waivers
+	ActivityTypeCreatedPolicyWaiver = "created_policy_waiver"
+	// ActivityTypeDeletedPolicyWaiver is the activity type for deleted policy
+	// waivers
+	ActivityTypeDeletedPolicyWaiver = "deleted_policy_waiver"
+	// ActivityTypeCreatedSoftwareRule is the activity type for created
+	// software rules
+	ActivityTypeCreatedSoftwareRule = "created_software_rule"
+	// ActivityTypeEditedSoftwareRule is the activity type for edited software
+	// rules
+	ActivityTypeEditedSoftwareRule = "edited_software_rule"
+	// ActivityTypeDeletedSoftwareRule is the activity type for deleted
+	// software rules
+	ActivityTypeDeletedSoftwareRule = "deleted_software_rule"
+	// ActivityTypeAppliedSpecHostTags is the activity type for a host tags spec
+	// applied
+	ActivityTypeAppliedSpecHostTags = "applied_spec_host_tags"
+	// ActivityTypeDeletedHostTagKey is the activity type for deleted host tag
+	// keys
+	ActivityTypeDeletedHostTagKey = "deleted_host_tag_key"
+	// ActivityTypeTransferredHostByRule is the activity type for hosts
+	// transferred to a team by a host assignment rule
+	ActivityTypeTransferredHostByRule = "transferred_host_by_rule"
+	// ActivityTypeMergedHosts is the activity type for duplicate hosts merged
+	// into another host
+	ActivityTypeMergedHosts = "merged_hosts"
+	// ActivityTypeChangedHostLifecycleState is the activity type for changes
+	// of the lifecycle state of a host
+	ActivityTypeChangedHostLifecycleState = "changed_host_lifecycle_state"
 )
 
 type Activity struct {
@@ -0,0 +1,154 @@
+package fleet
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// ActivityWebhookJobName is the name of the worker job that delivers an
+	// activity to an activity webhook.
+	ActivityWebhookJobName = "activity_webhook"
+
+	// ActivityWebhookSignatureHeader is the HTTP header that contains the
+	// HMAC-SHA256 signature of the body of an activity webhook request, in the
+	// "sha256=<hex digest>" format.
+	ActivityWebhookSignatureHeader = "X-Fleet-Signature"
+
+	// ActivityWebhookEventHeader is the HTTP header that contains the type of
+	// the activity delivered by an activity webhook request.
+	ActivityWebhookEventHeader = "X-Fleet-Event"
+
+	// ActivityWebhookDeliveriesRetention is how long the delivery attempts of
+	// activity webhooks are kept.
+	ActivityWebhookDeliveriesRetention = 30 * 24 * time.Hour
+)
+
+// ActivityWebhook is a subscription to the activities of Fleet: the
+// activities that match its filters are sent to its URL.
+type ActivityWebhook struct {
+	UpdateCreateTimestamps
+	ID   uint   `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	URL  string `json:"url" db:"url"`
+	// Secret is the key used to sign the requests sent to the webhook.
+	Secret string `json:"secret" db:"secret"`
+	// ActivityTypes is the list of activity types sent to the webhook, all
+	// activities are sent if it is empty.
+	ActivityTypes ActivityTypeList `json:"activity_types" db:"activity_types"`
+	// TeamID restricts the activities sent to the webhook to those of the
+	// team. All activities are sent if it is nil.
+	TeamID  *uint `json:"team_id" db:"team_id"`
+	Enabled bool  `json:"enabled" db:"enabled"`
+}
+
+// AuthzType implements authz.AuthzTyper.
+func (w *ActivityWebhook) AuthzType() string {
+	return "activity_webhook"
+}
+
+// Matches returns true if an activity of that type, related to that team
+// (nil if the activity is not related to a team), must be sent to the
+// webhook.
+func (w *ActivityWebhook) Matches(activityType string, teamID *uint) bool {
+	if !w.Enabled {
+		return false
+	}
+	if w.TeamID != nil && (teamID == nil || *teamID != *w.TeamID) {
+		return false
+	}
+	if len(w.ActivityTypes) == 0 {
+		return true
+	}
+	for _, typ := range w.ActivityTypes {
+		if typ == activityType {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the value of the ActivityWebhookSignatureHeader for that body.
+func (w *ActivityWebhook) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ActivityTypeList is a list of activity types stored as a JSON array.
+type ActivityTypeList []string
+
+// Scan implements the sql.Scanner interface
+func (l *ActivityTypeList) Scan(val interface{}) error {
+	switch v := val.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case nil: // sql NULL
+		return nil
+	default:
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+}
+
+// Value implements the sql.Valuer interface
+func (l ActivityTypeList) Value() (driver.Value, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(l)
+}
+
+// ActivityWebhookPayload contains the fields used to create or modify an
+// activity webhook. Nil fields are left unchanged on modification.
+type ActivityWebhookPayload struct {
+	Name          *string   `json:"name"`
+	URL           *string   `json:"url"`
+	Secret        *string   `json:"secret"`
+	ActivityTypes *[]string `json:"activity_types"`
+	// TeamID is the team of the activities sent to the webhook. On
+	// modification, a zero value removes the team filter.
+	TeamID  *uint `json:"team_id"`
+	Enabled *bool `json:"enabled"`
+}
+
+// ActivityWebhookDelivery is an attempt to deliver an activity to an
+// activity webhook.
+type ActivityWebhookDelivery struct {
+	CreateTimestamp
+	ID           uint   `json:"id" db:"id"`
+	WebhookID    uint   `json:"webhook_id" db:"webhook_id"`
+	ActivityID   uint   `json:"activity_id" db:"activity_id"`
+	ActivityType string `json:"activity_type" db:"activity_type"`
+	// StatusCode is the HTTP status code of the response, nil if no response
+	// was received.
+	StatusCode *int   `json:"status_code" db:"status_code"`
+	Error      string `json:"error" db:"error"`
+	DurationMs uint   `json:"duration_ms" db:"duration_ms"`
+}
+
+// ActivityWebhookMessage is the body of the requests sent to activity
+// webhooks.
+type ActivityWebhookMessage struct {
+	Timestamp time.Time `json:"timestamp"`
+	Activity  *Activity `json:"activity"`
+}
+
+// ActivityTeamID returns the team an activity is related to, based on the
+// team_id field of its details. It returns nil if the activity is not related
+// to a team.
+func ActivityTeamID(details []byte) *uint {
+	var d struct {
+		TeamID *uint `json:"team_id"`
+	}
+	if len(details) == 0 || json.Unmarshal(details, &d) != nil {
+		return nil
+	}
+	return d.TeamID
+}
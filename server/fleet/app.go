@@ -32,8 +32,19 @@ const (
 	MaskedPassword = "********"
 )
 
+const (
+	// SSOProtocolSAML is the default SSO protocol, used when SSOSettings.Protocol
+	// is empty.
+	SSOProtocolSAML = "saml"
+	// SSOProtocolOIDC uses the OpenID Connect authorization code flow with PKCE.
+	SSOProtocolOIDC = "oidc"
+)
+
 // SSOSettings wire format for SSO settings
 type SSOSettings struct {
+	// Protocol is the SSO protocol used to authenticate users, either "saml"
+	// (the default if empty) or "oidc".
+	Protocol string `json:"protocol,omitempty"`
 	// EntityID is a uri that identifies this service provider
 	EntityID string `json:"entity_id"`
 	// IssuerURI is the uri that identifies the identity provider
@@ -55,6 +66,27 @@ type SSOSettings struct {
 	// EnableJITProvisioning allows user accounts to be created the first time
 	// users try to log in
 	EnableJITProvisioning bool `json:"enable_jit_provisioning"`
+	// OIDCIssuerURL is the issuer of the OpenID provider, its configuration is
+	// discovered from the issuer's /.well-known/openid-configuration
+	// document.
+	OIDCIssuerURL string `json:"oidc_issuer_url,omitempty"`
+	// OIDCClientID is the client ID of Fleet as registered with the OpenID
+	// provider.
+	OIDCClientID string `json:"oidc_client_id,omitempty"`
+	// OIDCClientSecret is the client secret of Fleet as registered with the
+	// OpenID provider.
+	OIDCClientSecret string `json:"oidc_client_secret,omitempty"`
+	// OIDCScopes are the scopes requested to the OpenID provider, if empty the
+	// "openid", "email" and "profile" scopes are requested.
+	OIDCScopes []string `json:"oidc_scopes,omitempty"`
+	// SCIMGroupMappings maps the groups provisioned via SCIM to the roles of
+	// their members.
+	SCIMGroupMappings []SCIMGroupMapping `json:"scim_group_mappings,omitempty"`
+}
+
+// IsOIDC returns true if SSO is configured to use OpenID Connect.
+func (s SSOSettings) IsOIDC() bool {
+	return s.Protocol == SSOProtocolOIDC
 }
 
 // SMTPSettings is part of the AppConfig which defines the wire representation
@@ -125,6 +157,18 @@ type AppConfig struct {
 	WebhookSettings WebhookSettings `json:"webhook_settings"`
 	Integrations    Integrations    `json:"integrations"`
 
+	// Scripts defines the scripts that can be run on the hosts that are not
+	// assigned to a team.
+	Scripts ScriptSettings `json:"scripts"`
+
+	// HostDeduplicationSettings defines how the hosts that enroll with the
+	// hardware serial or UUID of another host are handled.
+	HostDeduplicationSettings HostDeduplicationSettings `json:"host_deduplication_settings"`
+
+	// HostLifecycleSettings defines what Fleet does when the lifecycle state of
+	// a host changes.
+	HostLifecycleSettings HostLifecycleSettings `json:"host_lifecycle_settings"`
+
 	// when true, strictDecoding causes the UnmarshalJSON method to return an
 	// error if there are unknown fields in the raw JSON.
 	strictDecoding bool
@@ -212,6 +256,12 @@ type WebhookSettings struct {
 	HostStatusWebhook      HostStatusWebhookSettings      `json:"host_status_webhook"`
 	FailingPoliciesWebhook FailingPoliciesWebhookSettings `json:"failing_policies_webhook"`
 	VulnerabilitiesWebhook VulnerabilitiesWebhookSettings `json:"vulnerabilities_webhook"`
+	// UnapprovedSoftwareWebhook configures the webhook sent when unapproved
+	// software gets installed on hosts.
+	UnapprovedSoftwareWebhook UnapprovedSoftwareWebhookSettings `json:"unapproved_software_webhook"`
+	// HostQuarantineWebhook configures the webhook sent when a host is
+	// quarantined.
+	HostQuarantineWebhook HostQuarantineWebhookSettings `json:"host_quarantine_webhook"`
 	// Interval is the interval for running the webhooks.
 	//
 	// This value currently configures both the host status and failing policies webhooks.
@@ -249,6 +299,17 @@ type VulnerabilitiesWebhookSettings struct {
 	HostBatchSize int `json:"host_batch_size"`
 }
 
+// UnapprovedSoftwareWebhookSettings holds the settings for the unapproved
+// software webhook.
+type UnapprovedSoftwareWebhookSettings struct {
+	// Enable indicates whether the webhook for unapproved software is enabled.
+	Enable bool `json:"enable_unapproved_software_webhook"`
+	// DestinationURL is the webhook's URL.
+	DestinationURL string `json:"destination_url"`
+	// Software is the list of rules that identify the unapproved software.
+	Software []SoftwareMatchRule `json:"software"`
+}
+
 func (c *AppConfig) ApplyDefaultsForNewInstalls() {
 	c.ServerSettings.EnableAnalytics = true
 
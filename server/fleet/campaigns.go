@@ -1,5 +1,7 @@
 package fleet
 
+import "time"
+
 // DistributedQueryStatus is the lifecycle status of a distributed query
 // campaign.
 type DistributedQueryStatus int
@@ -44,6 +46,36 @@ type DistributedQueryResult struct {
 	Error *string `json:"error"`
 }
 
+// DistributedQueryCampaignResult is the stored result of a distributed query
+// campaign for a single host, kept after the campaign completed so that it
+// can be reviewed later.
+type DistributedQueryCampaignResult struct {
+	ID                         uint   `json:"-" db:"id"`
+	DistributedQueryCampaignID uint   `json:"campaign_id" db:"distributed_query_campaign_id"`
+	HostID                     uint   `json:"host_id" db:"host_id"`
+	Hostname                   string `json:"hostname" db:"hostname"`
+	HostDisplayName            string `json:"host_display_name" db:"host_display_name"`
+	// Rows is stored as a JSON column, it is marshaled and unmarshaled by the
+	// datastore.
+	Rows      []map[string]string `json:"rows" db:"-"`
+	Error     *string             `json:"error" db:"error"`
+	CreatedAt time.Time           `json:"created_at" db:"created_at"`
+}
+
+// DistributedQueryResult returns the stored result as it was streamed when the
+// campaign ran.
+func (r *DistributedQueryCampaignResult) DistributedQueryResult() DistributedQueryResult {
+	return DistributedQueryResult{
+		DistributedQueryCampaignID: r.DistributedQueryCampaignID,
+		Host: &HostResponse{
+			Host:        &Host{ID: r.HostID, Hostname: r.Hostname},
+			DisplayName: r.HostDisplayName,
+		},
+		Rows:  r.Rows,
+		Error: r.Error,
+	}
+}
+
 type QueryResult struct {
 	HostID uint                `json:"host_id"`
 	Rows   []map[string]string `json:"rows"`
@@ -52,6 +52,9 @@ const (
 	// CapabilityTokenRotation denotes the ability of the server to support
 	// periodic rotation of device tokens
 	CapabilityTokenRotation Capability = "token_rotation"
+	// CapabilityScripts denotes the ability of the server to send scripts to
+	// Orbit for execution, and to receive their results.
+	CapabilityScripts Capability = "scripts"
 )
 
 // ServerOrbitCapabilities is a set of capabilities that server-side,
@@ -60,6 +63,7 @@ const (
 var ServerOrbitCapabilities = CapabilityMap{
 	CapabilityOrbitEndpoints: {},
 	CapabilityTokenRotation:  {},
+	CapabilityScripts:        {},
 }
 
 // ServerDeviceCapabilities is a set of capabilities that server-side,
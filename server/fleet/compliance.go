@@ -0,0 +1,81 @@
+package fleet
+
+import "math"
+
+// PolicySeverityResults are the results of the policies of a severity on a
+// set of hosts.
+type PolicySeverityResults struct {
+	// Severity is the severity of the policies, empty string for the policies
+	// without a severity.
+	Severity string `json:"severity" db:"severity"`
+	// PassingCount is the number of passing policy results.
+	PassingCount uint `json:"passing_count" db:"passing_count"`
+	// FailingCount is the number of failing policy results.
+	FailingCount uint `json:"failing_count" db:"failing_count"`
+}
+
+// ComplianceSummary is the compliance score of a set of hosts, either all the
+// hosts the user can see or the hosts of a team.
+type ComplianceSummary struct {
+	TeamID *uint `json:"team_id"`
+	// Score is the compliance score of the hosts, see ComplianceScore.
+	Score *float64 `json:"score"`
+	// Severities are the policy results of the hosts by severity.
+	Severities []*PolicySeverityResults `json:"severities"`
+}
+
+// PolicySeverityWeight returns the weight of the results of the policies of
+// the given severity in the compliance scores. Policies without a severity
+// have the same weight as low severity policies.
+func PolicySeverityWeight(severity string) uint {
+	switch severity {
+	case PolicySeverityCritical:
+		return 8
+	case PolicySeverityHigh:
+		return 4
+	case PolicySeverityMedium:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ComplianceScore computes the compliance score from the policy results by
+// severity. The score is the percentage (from 0 to 100, rounded to one
+// decimal) of passing results, each result being weighted by the severity of
+// its policy. It returns nil if there are no results.
+func ComplianceScore(results []*PolicySeverityResults) *float64 {
+	var passing, total uint
+	for _, r := range results {
+		weight := PolicySeverityWeight(r.Severity)
+		passing += r.PassingCount * weight
+		total += (r.PassingCount + r.FailingCount) * weight
+	}
+	if total == 0 {
+		return nil
+	}
+	score := math.Round(float64(passing)/float64(total)*1000) / 10
+	return &score
+}
+
+// HostComplianceScore computes the compliance score of a host from its
+// policies, the policies that did not run yet on the host are ignored.
+func HostComplianceScore(policies []*HostPolicy) *float64 {
+	bySeverity := make(map[string]*PolicySeverityResults)
+	results := make([]*PolicySeverityResults, 0, len(policies))
+	for _, p := range policies {
+		r := bySeverity[p.Severity]
+		if r == nil {
+			r = &PolicySeverityResults{Severity: p.Severity}
+			bySeverity[p.Severity] = r
+			results = append(results, r)
+		}
+		switch p.Response {
+		case "pass":
+			r.PassingCount++
+		case "fail":
+			r.FailingCount++
+		}
+	}
+	return ComplianceScore(results)
+}
@@ -0,0 +1,42 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplianceScore(t *testing.T) {
+	require.Nil(t, ComplianceScore(nil))
+	require.Nil(t, ComplianceScore([]*PolicySeverityResults{{Severity: PolicySeverityHigh}}))
+
+	score := ComplianceScore([]*PolicySeverityResults{
+		{Severity: PolicySeverityCritical, PassingCount: 1, FailingCount: 1},
+		{Severity: PolicySeverityLow, PassingCount: 0, FailingCount: 2},
+	})
+	require.NotNil(t, score)
+	// passing: 8, total: 8*2 + 1*2 = 18
+	require.Equal(t, 44.4, *score)
+
+	score = ComplianceScore([]*PolicySeverityResults{{PassingCount: 3}})
+	require.NotNil(t, score)
+	require.Equal(t, 100.0, *score)
+}
+
+func TestHostComplianceScore(t *testing.T) {
+	require.Nil(t, HostComplianceScore(nil))
+
+	policies := []*HostPolicy{
+		{PolicyData: PolicyData{ID: 1, Severity: PolicySeverityHigh}, Response: "pass"},
+		{PolicyData: PolicyData{ID: 2, Severity: PolicySeverityMedium}, Response: "fail"},
+		{PolicyData: PolicyData{ID: 3, Severity: PolicySeverityMedium}, Response: "pass"},
+		// not run yet, ignored
+		{PolicyData: PolicyData{ID: 4, Severity: PolicySeverityCritical}, Response: ""},
+	}
+	score := HostComplianceScore(policies)
+	require.NotNil(t, score)
+	// passing: 4 + 2 = 6, total: 4 + 2 + 2 = 8
+	require.Equal(t, 75.0, *score)
+
+	require.Nil(t, HostComplianceScore(policies[3:]))
+}
@@ -57,6 +57,40 @@ type Datastore interface {
 	// written to user record. userID is the ID of the user whose e-mail is being changed.
 	ConfirmPendingEmailChange(ctx context.Context, userID uint, token string) (string, error)
 
+	///////////////////////////////////////////////////////////////////////////////
+	// SCIMStore contains methods for managing the users and groups provisioned
+	// via SCIM.
+
+	// ListSCIMUsers returns the page of users requested by the options, along
+	// with the total number of matching users. The users deleted via SCIM are
+	// not returned.
+	ListSCIMUsers(ctx context.Context, opt SCIMUserListOptions) (users []*User, total int, err error)
+	// SCIMUser returns the user identified by id, or a not found error if the
+	// user was deleted via SCIM.
+	SCIMUser(ctx context.Context, id uint) (*User, error)
+	// DeleteSCIMUser disables the user identified by id, destroys its sessions
+	// and removes it from its SCIM groups. The user is kept so that its
+	// activities and the entities it authored are preserved, but it is not
+	// returned by ListSCIMUsers and SCIMUser anymore.
+	DeleteSCIMUser(ctx context.Context, id uint) error
+	// RestoreSCIMUser enables the user identified by id, which was deleted via
+	// SCIM, when the identity provider provisions it again.
+	RestoreSCIMUser(ctx context.Context, id uint) error
+
+	// NewSCIMGroup creates a new SCIM group with the provided members.
+	NewSCIMGroup(ctx context.Context, group *SCIMGroup) (*SCIMGroup, error)
+	// SCIMGroup returns the SCIM group identified by id, with its members.
+	SCIMGroup(ctx context.Context, id uint) (*SCIMGroup, error)
+	// ListSCIMGroups returns all SCIM groups with their members.
+	ListSCIMGroups(ctx context.Context) ([]*SCIMGroup, error)
+	// ListSCIMGroupsForUser returns the SCIM groups that the user identified by
+	// userID is a member of. The members of the groups are not loaded.
+	ListSCIMGroupsForUser(ctx context.Context, userID uint) ([]*SCIMGroup, error)
+	// SaveSCIMGroup updates the SCIM group and replaces its members.
+	SaveSCIMGroup(ctx context.Context, group *SCIMGroup) error
+	// DeleteSCIMGroup deletes the SCIM group identified by id.
+	DeleteSCIMGroup(ctx context.Context, id uint) error
+
 	///////////////////////////////////////////////////////////////////////////////
 	// QueryStore
 
@@ -107,6 +141,37 @@ type Datastore interface {
 
 	DistributedQueryCampaignsForQuery(ctx context.Context, queryID uint) ([]*DistributedQueryCampaign, error)
 
+	// NewDistributedQueryCampaignResult stores the result of a distributed query campaign for a host.
+	NewDistributedQueryCampaignResult(ctx context.Context, result *DistributedQueryCampaignResult) error
+	// ListDistributedQueryCampaignResults returns the stored results of the distributed query campaign, restricted
+	// to the hosts visible by the team filter (the results of deleted hosts are only visible to global users).
+	ListDistributedQueryCampaignResults(ctx context.Context, filter TeamFilter, campaignID uint) ([]*DistributedQueryCampaignResult, error)
+	// CleanupDistributedQueryCampaignResults deletes the stored results of distributed query campaigns that
+	// were created before olderThan.
+	CleanupDistributedQueryCampaignResults(ctx context.Context, olderThan time.Time) error
+
+	///////////////////////////////////////////////////////////////////////////////
+	// ScheduledLiveQueryStore
+
+	NewScheduledLiveQuery(ctx context.Context, query *ScheduledLiveQuery) (*ScheduledLiveQuery, error)
+	ScheduledLiveQuery(ctx context.Context, id uint) (*ScheduledLiveQuery, error)
+	ListScheduledLiveQueries(ctx context.Context, opt ListOptions) ([]*ScheduledLiveQuery, error)
+	SaveScheduledLiveQuery(ctx context.Context, query *ScheduledLiveQuery) error
+	DeleteScheduledLiveQuery(ctx context.Context, id uint) error
+	// ListDueScheduledLiveQueries returns the enabled scheduled live queries with a next run time before or at
+	// now.
+	ListDueScheduledLiveQueries(ctx context.Context, now time.Time) ([]*ScheduledLiveQuery, error)
+	// NewScheduledLiveQueryRun records the run of a scheduled live query whose campaign collects results.
+	NewScheduledLiveQueryRun(ctx context.Context, run *ScheduledLiveQueryRun) error
+	// ScheduledLiveQueryRunByCampaign returns the run of a scheduled live query for the campaign, it returns a
+	// not found error if the campaign is not the campaign of a run being collected.
+	ScheduledLiveQueryRunByCampaign(ctx context.Context, campaignID uint) (*ScheduledLiveQueryRun, error)
+	// ListEndedScheduledLiveQueryRuns returns the runs of scheduled live queries with a results window ending
+	// before or at now.
+	ListEndedScheduledLiveQueryRuns(ctx context.Context, now time.Time) ([]*ScheduledLiveQueryRun, error)
+	// DeleteScheduledLiveQueryRun deletes the run of a scheduled live query once its campaign is completed.
+	DeleteScheduledLiveQueryRun(ctx context.Context, campaignID uint) error
+
 	///////////////////////////////////////////////////////////////////////////////
 	// PackStore is the datastore interface for managing query packs.
 
@@ -216,6 +281,13 @@ type Datastore interface {
 	HostByIdentifier(ctx context.Context, identifier string) (*Host, error)
 	// AddHostsToTeam adds hosts to an existing team, clearing their team settings if teamID is nil.
 	AddHostsToTeam(ctx context.Context, teamID *uint, hostIDs []uint) error
+	// TransferHostsToTeam adds hosts to a team like AddHostsToTeam, on behalf of
+	// a user. The host assignment rules do not move the hosts while they stay
+	// on that team.
+	TransferHostsToTeam(ctx context.Context, teamID *uint, hostIDs []uint) error
+	// HostTeamSetManually returns true if the host is on the team a user
+	// transferred it to with TransferHostsToTeam.
+	HostTeamSetManually(ctx context.Context, hostID uint) (bool, error)
 
 	TotalAndUnseenHostsSince(ctx context.Context, daysCount int) (total int, unseen int, err error)
 
@@ -352,6 +424,17 @@ type Datastore interface {
 	// scheduled query did not exist.
 	ScheduledQueryIDsByName(ctx context.Context, batchSize int, packAndSchedQueryNames ...[2]string) ([]uint, error)
 
+	// SaveScheduledQueryResults stores the results of scheduled queries, the
+	// ScheduledQueryID and HostID fields of the results must be set. Only the
+	// latest maxPerHost results of each scheduled query are kept for each host.
+	SaveScheduledQueryResults(ctx context.Context, results []*ScheduledQueryResult, maxPerHost int) error
+	// ListScheduledQueryResults lists the stored scheduled query results of the
+	// hosts visible with the team filter, most recent first by default.
+	ListScheduledQueryResults(ctx context.Context, filter TeamFilter, opts ScheduledQueryResultListOptions) ([]*ScheduledQueryResult, error)
+	// CleanupScheduledQueryResults deletes the scheduled query results fetched
+	// before olderThan.
+	CleanupScheduledQueryResults(ctx context.Context, olderThan time.Time) error
+
 	///////////////////////////////////////////////////////////////////////////////
 	// TeamStore
 
@@ -383,6 +466,13 @@ type Datastore interface {
 	// used for vulnerability detection populated (id, name, version, cpe_id, cpe)
 	ListSoftwareForVulnDetection(ctx context.Context, hostID uint) ([]Software, error)
 	ListSoftwareVulnerabilities(ctx context.Context, hostIDs []uint) (map[uint][]SoftwareVulnerability, error)
+	// ListSoftwareBySources returns the software of the given sources that is
+	// installed on at least one host, with only the fields used for vulnerability
+	// detection populated (id, name, version, source).
+	ListSoftwareBySources(ctx context.Context, sources []string) ([]Software, error)
+	// ListSoftwareVulnerabilitiesBySource returns all the software vulnerabilities
+	// detected by the given source.
+	ListSoftwareVulnerabilitiesBySource(ctx context.Context, source VulnerabilitySource) ([]SoftwareVulnerability, error)
 	LoadHostSoftware(ctx context.Context, host *Host, includeCVEScores bool) error
 	AllSoftwareWithoutCPEIterator(ctx context.Context, excludedPlatforms []string) (SoftwareIterator, error)
 	AddCPEForSoftware(ctx context.Context, software Software, cpe string) error
@@ -405,6 +495,100 @@ type Datastore interface {
 	HostsByCVE(ctx context.Context, cve string) ([]*HostShort, error)
 	InsertCVEMeta(ctx context.Context, cveMeta []CVEMeta) error
 	ListCVEs(ctx context.Context, maxAge time.Duration) ([]CVEMeta, error)
+	// ListSoftwareChanges returns the software installed on and uninstalled
+	// from the hosts, as detected when the hosts report their software
+	// inventory. It returns the most recent changes first by default.
+	ListSoftwareChanges(ctx context.Context, opt SoftwareChangeListOptions) ([]*SoftwareChange, error)
+	// CleanupSoftwareChanges deletes the software changes recorded before
+	// olderThan.
+	CleanupSoftwareChanges(ctx context.Context, olderThan time.Time) error
+	// UnapprovedSoftwareWebhookCursor returns the ID of the last software change
+	// delivered to the unapproved software webhook, or nil if none was
+	// delivered yet.
+	UnapprovedSoftwareWebhookCursor(ctx context.Context) (*uint, error)
+	// SetUnapprovedSoftwareWebhookCursor stores the ID of the last software
+	// change delivered to the unapproved software webhook.
+	SetUnapprovedSoftwareWebhookCursor(ctx context.Context, id uint) error
+
+	// NewSoftwareRule creates a software rule.
+	NewSoftwareRule(ctx context.Context, rule *SoftwareRule) (*SoftwareRule, error)
+	// SoftwareRule returns the software rule with the given ID.
+	SoftwareRule(ctx context.Context, id uint) (*SoftwareRule, error)
+	// ListSoftwareRules returns the software rules of the team, or the global
+	// rules if teamID is nil.
+	ListSoftwareRules(ctx context.Context, teamID *uint) ([]*SoftwareRule, error)
+	// SaveSoftwareRule updates the software rule, its team cannot be changed.
+	SaveSoftwareRule(ctx context.Context, rule *SoftwareRule) error
+	// DeleteSoftwareRule deletes the software rule and its violations.
+	DeleteSoftwareRule(ctx context.Context, id uint) error
+	// UpdateSoftwareRuleViolations recomputes the hosts violating the software
+	// rules from the software installed on the hosts, and returns the hosts
+	// that started violating a rule since the last computation.
+	UpdateSoftwareRuleViolations(ctx context.Context) ([]*SoftwareRuleViolation, error)
+
+	///////////////////////////////////////////////////////////////////////////////
+	// HostTagsStore
+
+	// ListHostTagKeys returns the host tag keys sorted by name, with the number
+	// of hosts tagged with each.
+	ListHostTagKeys(ctx context.Context) ([]*HostTagKey, error)
+	// ApplyHostTagKeys creates the host tag keys, or updates the type and
+	// description of the existing keys with the same name.
+	ApplyHostTagKeys(ctx context.Context, keys []*HostTagKey) error
+	// DeleteHostTagKey deletes the host tag key with the name and untags its
+	// hosts.
+	DeleteHostTagKey(ctx context.Context, name string) error
+	// SetHostTags sets the host tags of the hosts, unsetting the tags with a
+	// nil value. The values must be normalized. The membership of the host_tag
+	// labels is updated accordingly.
+	SetHostTags(ctx context.Context, hostIDs []uint, tags HostTagValues) error
+
+	///////////////////////////////////////////////////////////////////////////////
+	// HostDuplicatesStore
+
+	// FindDuplicateHosts returns the hosts, other than the host with hostID,
+	// with the same non-empty hardware serial or UUID.
+	FindDuplicateHosts(ctx context.Context, hostID uint, hardwareSerial, uuid string) ([]*Host, error)
+	// NewHostDuplicate flags the duplicate host as a duplicate of the host, it
+	// does nothing if the pair is already flagged.
+	NewHostDuplicate(ctx context.Context, hostID, duplicateHostID uint, matchedBy string) error
+	// ListHostDuplicates returns the flagged duplicate hosts, only the pairs
+	// where both hosts are visible with the filter are returned.
+	ListHostDuplicates(ctx context.Context, filter TeamFilter) ([]*HostDuplicate, error)
+	// HostDuplicate returns the flagged duplicate hosts with the ID.
+	HostDuplicate(ctx context.Context, id uint) (*HostDuplicate, error)
+	// DeleteHostDuplicate unflags the duplicate hosts with the ID.
+	DeleteHostDuplicate(ctx context.Context, id uint) error
+	// MergeHosts moves the history of the host with fromHostID (policy
+	// results, software, device mapping, tags, script executions and vitals)
+	// to the host with toHostID, which also gets its team if it has none, and
+	// deletes it. The data of the host with toHostID wins over conflicting
+	// data.
+	MergeHosts(ctx context.Context, fromHostID, toHostID uint) error
+
+	///////////////////////////////////////////////////////////////////////////////
+	// HostLifecycleStore
+
+	// SetHostsLifecycleState sets the lifecycle state of the hosts. The policy
+	// results of the hosts that are retired are deleted, and the hosts that are
+	// no longer retired run the policies at their next check in. The
+	// quarantined hosts are moved to the quarantine team if it is not nil, in
+	// the same transaction, and they are moved back to their previous team when
+	// they leave the quarantine.
+	SetHostsLifecycleState(ctx context.Context, hostIDs []uint, state HostLifecycleState, quarantineTeamID *uint) error
+
+	///////////////////////////////////////////////////////////////////////////////
+	// HostVitalsStore
+
+	// RecordHostVitals stores the sample of the vitals of the host, unless the
+	// latest sample of the host was recorded less than minInterval before it.
+	RecordHostVitals(ctx context.Context, vitals *HostVitals, minInterval time.Duration) error
+	// ListHostVitals returns the samples of the vitals of the host that match
+	// the options. It returns the most recent samples first by default.
+	ListHostVitals(ctx context.Context, hostID uint, opt HostVitalsListOptions) ([]*HostVitals, error)
+	// CleanupHostVitalsHistory deletes the samples of the vitals of the hosts
+	// recorded before olderThan.
+	CleanupHostVitalsHistory(ctx context.Context, olderThan time.Time) error
 
 	///////////////////////////////////////////////////////////////////////////////
 	// OperatingSystemsStore
@@ -429,8 +613,34 @@ type Datastore interface {
 	///////////////////////////////////////////////////////////////////////////////
 	// ActivitiesStore
 
+	// NewActivity stores an activity item that the user performed. It also
+	// queues the delivery of the activity to the matching activity webhooks.
 	NewActivity(ctx context.Context, user *User, activityType string, details *map[string]interface{}) error
+	// CreateActivity stores an activity item like NewActivity and returns it
+	// with its ID and creation timestamp.
+	CreateActivity(ctx context.Context, user *User, activityType string, details *map[string]interface{}) (*Activity, error)
 	ListActivities(ctx context.Context, opt ListOptions) ([]*Activity, error)
+	// Activity returns the activity identified by id.
+	Activity(ctx context.Context, id uint) (*Activity, error)
+
+	///////////////////////////////////////////////////////////////////////////////
+	// ActivityWebhookStore
+
+	NewActivityWebhook(ctx context.Context, webhook *ActivityWebhook) (*ActivityWebhook, error)
+	ActivityWebhook(ctx context.Context, id uint) (*ActivityWebhook, error)
+	ListActivityWebhooks(ctx context.Context) ([]*ActivityWebhook, error)
+	SaveActivityWebhook(ctx context.Context, webhook *ActivityWebhook) error
+	DeleteActivityWebhook(ctx context.Context, id uint) error
+
+	// NewActivityWebhookDelivery records an attempt to deliver an activity to
+	// a webhook.
+	NewActivityWebhookDelivery(ctx context.Context, delivery *ActivityWebhookDelivery) error
+	// ListActivityWebhookDeliveries returns the delivery attempts of the
+	// webhook, most recent first by default.
+	ListActivityWebhookDeliveries(ctx context.Context, webhookID uint, opt ListOptions) ([]*ActivityWebhookDelivery, error)
+	// CleanupActivityWebhookDeliveries deletes the delivery attempts older
+	// than ActivityWebhookDeliveriesRetention.
+	CleanupActivityWebhookDeliveries(ctx context.Context, now time.Time) error
 
 	///////////////////////////////////////////////////////////////////////////////
 	// StatisticsStore
@@ -460,6 +670,42 @@ type Datastore interface {
 	DeleteGlobalPolicies(ctx context.Context, ids []uint) ([]uint, error)
 
 	PolicyQueriesForHost(ctx context.Context, host *Host) (map[string]string, error)
+	// PolicyRemediationsForHost returns the policies that apply to the host and
+	// have a remediation, keyed by policy ID.
+	PolicyRemediationsForHost(ctx context.Context, host *Host) (map[uint]*Policy, error)
+	// PolicyResultsBySeverity returns the number of passing and failing policy
+	// results of the hosts that match the filter (and platform, if provided),
+	// grouped by severity of the policy.
+	PolicyResultsBySeverity(ctx context.Context, filter TeamFilter, platform *string) ([]*PolicySeverityResults, error)
+
+	// ListHostPolicyTransitions returns the history of the results of the
+	// policies on the host, that is the times the host started passing or
+	// failing a policy, optionally only for the policy with policyID. It
+	// returns the most recent transitions first by default.
+	ListHostPolicyTransitions(ctx context.Context, hostID uint, policyID *uint, opt ListOptions) ([]*PolicyMembershipTransition, error)
+	// PolicyDailyCounts returns the number of hosts passing and failing the
+	// policy at the end of each day (in UTC) from the day of "from" to the day
+	// of "to", included.
+	PolicyDailyCounts(ctx context.Context, policyID uint, from, to time.Time) ([]*PolicyDailyCount, error)
+	// CleanupPolicyMembershipHistory deletes the policy result transitions
+	// created before olderThan, except the latest of those transitions for each
+	// host and policy, which is the state of the host for the policy at
+	// olderThan.
+	CleanupPolicyMembershipHistory(ctx context.Context, olderThan time.Time) error
+
+	// NewPolicyWaiver creates a waiver of the policy for the hosts or the
+	// label of the waiver.
+	NewPolicyWaiver(ctx context.Context, waiver *PolicyWaiver) (*PolicyWaiver, error)
+	// ListPolicyWaivers returns the waivers of the policy that did not expire.
+	ListPolicyWaivers(ctx context.Context, policyID uint) ([]*PolicyWaiver, error)
+	// DeletePolicyWaiver deletes the waiver with the given ID of the policy.
+	DeletePolicyWaiver(ctx context.Context, policyID, waiverID uint) error
+	// ListExpiredPolicyWaiverFailingHosts returns the hosts that fail a policy
+	// they were exempted from by a waiver that expired at now, and that are
+	// not exempted from it by another waiver.
+	ListExpiredPolicyWaiverFailingHosts(ctx context.Context, now time.Time) ([]*PolicyWaiverFailingHost, error)
+	// DeleteExpiredPolicyWaivers deletes the waivers that expired at now.
+	DeleteExpiredPolicyWaivers(ctx context.Context, now time.Time) error
 
 	// Methods used for async processing of host policy query results.
 	AsyncBatchInsertPolicyMembership(ctx context.Context, batch []PolicyMembershipResult) error
@@ -495,6 +741,25 @@ type Datastore interface {
 	// a record of the count already exists, its `created_at` timestamp is updated to the current timestamp.
 	InitializePolicyViolationDays(ctx context.Context) error
 
+	///////////////////////////////////////////////////////////////////////////////
+	// Host Script Executions
+
+	NewHostScriptExecution(ctx context.Context, exec *HostScriptExecution) (*HostScriptExecution, error)
+	// NewHostScriptExecutions queues the script executions in a single
+	// transaction, either all of them are queued or none is.
+	NewHostScriptExecutions(ctx context.Context, execs []*HostScriptExecution) ([]*HostScriptExecution, error)
+	HostScriptExecution(ctx context.Context, id uint) (*HostScriptExecution, error)
+	ListHostScriptExecutions(ctx context.Context, hostID uint, opt ListOptions) ([]*HostScriptExecution, error)
+	// ListPendingHostScriptExecutions returns the script executions of the given
+	// type that were not sent to the host yet.
+	ListPendingHostScriptExecutions(ctx context.Context, hostID uint, execType string) ([]*HostScriptExecution, error)
+	// MarkHostScriptExecutionsSent sets the status of the pending script
+	// executions to sent.
+	MarkHostScriptExecutionsSent(ctx context.Context, ids []uint) error
+	// SetHostScriptExecutionResult stores the result of a script execution of
+	// the host and returns the updated execution.
+	SetHostScriptExecutionResult(ctx context.Context, res *HostScriptExecutionResult) (*HostScriptExecution, error)
+
 	///////////////////////////////////////////////////////////////////////////////
 	// Locking
 
@@ -541,6 +806,10 @@ type Datastore interface {
 	// If the host doesn't exist, a NotFoundError is returned.
 	HostLite(ctx context.Context, hostID uint) (*Host, error)
 
+	// ListHostsLiteByIDs loads the primary data (as defined by HostLite) of the
+	// hosts with the given ids. Ids that do not exist are ignored.
+	ListHostsLiteByIDs(ctx context.Context, hostIDs []uint) ([]*Host, error)
+
 	// UpdateHostOsqueryIntervals updates the osquery intervals of a host.
 	UpdateHostOsqueryIntervals(ctx context.Context, hostID uint, intervals HostOsqueryIntervals) error
 
@@ -550,6 +819,14 @@ type Datastore interface {
 	// TeamFeatures loads the features enabled for a team.
 	TeamFeatures(ctx context.Context, teamID uint) (*Features, error)
 
+	// TeamScriptSettings loads the settings of the scripts that can be run on
+	// the hosts of a team.
+	TeamScriptSettings(ctx context.Context, teamID uint) (*ScriptSettings, error)
+
+	// ListHostAssignmentRules returns the host assignment rules of all the
+	// teams, sorted in evaluation order and compiled.
+	ListHostAssignmentRules(ctx context.Context) (TeamHostAssignmentRules, error)
+
 	// SaveHostPackStats stores (and updates) the pack's scheduled queries stats of a host.
 	SaveHostPackStats(ctx context.Context, hostID uint, stats []PackStats) error
 	// AsyncBatchSaveHostsScheduledQueryStats efficiently saves a batch of hosts'
@@ -580,6 +857,9 @@ type Datastore interface {
 	//
 	// "Failure" here means the policy query executed successfully but didn't return any rows,
 	// so policies that did not execute (incomingResults with nil bool) are ignored.
+	//
+	// The policies the host is exempted from by a policy waiver are never
+	// returned as failing.
 	FlippingPoliciesForHost(ctx context.Context, hostID uint, incomingResults map[uint]*bool) (newFailing []uint, newPassing []uint, err error)
 
 	// RecordPolicyQueryExecutions records the execution results of the policies for the given host.
@@ -626,8 +906,10 @@ type Datastore interface {
 	// NewJob inserts a new job into the jobs table (queue).
 	NewJob(ctx context.Context, job *Job) (*Job, error)
 
-	// GetQueuedJobs gets queued jobs from the jobs table (queue).
-	GetQueuedJobs(ctx context.Context, maxNumJobs int) ([]*Job, error)
+	// GetQueuedJobs gets queued jobs that are ready to be processed from the
+	// jobs table (queue). If names is not empty, only the jobs with one of those
+	// names are returned.
+	GetQueuedJobs(ctx context.Context, maxNumJobs int, names []string) ([]*Job, error)
 
 	// UpdateJobs updates an existing job. Call this after processing a job.
 	UpdateJob(ctx context.Context, id uint, job *Job) (*Job, error)
@@ -0,0 +1,167 @@
+package fleet
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HostAssignmentRule is a rule of a team that automatically assigns the hosts
+// it matches to the team. A host matches the rule if it matches all the
+// conditions that are set, at least one condition must be set.
+type HostAssignmentRule struct {
+	// Priority orders the rules of all the teams, the rules with the lowest
+	// priority are evaluated first. The rules with the same priority are
+	// evaluated in order of team ID and then in the order they are defined in
+	// the team.
+	Priority int `json:"priority"`
+	// Platform matches the platform of the host (e.g. "ubuntu") or its
+	// platform family ("darwin", "windows" or "linux").
+	Platform string `json:"platform,omitempty"`
+	// HostnameRegex is a regular expression matched against the hostname of the
+	// host.
+	HostnameRegex string `json:"hostname_regex,omitempty"`
+	// HardwareModel matches the hardware model of the host, case-insensitively.
+	HardwareModel string `json:"hardware_model,omitempty"`
+	// Label is the name of a label the host must be a member of.
+	Label string `json:"label,omitempty"`
+	// PublicIPCIDR is a CIDR range (e.g. "203.0.113.0/24") that must contain
+	// the public IP address of the host.
+	PublicIPCIDR string `json:"public_ip_cidr,omitempty"`
+
+	// hostnameRegex and publicIPNet are the compiled conditions, set by
+	// Compile.
+	hostnameRegex *regexp.Regexp
+	publicIPNet   *net.IPNet
+}
+
+// Validate verifies the rule is valid. The label is not verified, as labels
+// may be created after the rule.
+func (r HostAssignmentRule) Validate() error {
+	if r.Platform == "" && r.HostnameRegex == "" && r.HardwareModel == "" && r.Label == "" && r.PublicIPCIDR == "" {
+		return fmt.Errorf("at least one of platform, hostname_regex, hardware_model, label or public_ip_cidr must be set")
+	}
+	return r.Compile()
+}
+
+// Compile compiles the hostname regular expression and the CIDR range of the
+// rule, it must be called before the rule is matched against hosts.
+func (r *HostAssignmentRule) Compile() error {
+	r.hostnameRegex, r.publicIPNet = nil, nil
+	if r.HostnameRegex != "" {
+		re, err := regexp.Compile(r.HostnameRegex)
+		if err != nil {
+			return fmt.Errorf("invalid hostname_regex %q: %w", r.HostnameRegex, err)
+		}
+		r.hostnameRegex = re
+	}
+	if r.PublicIPCIDR != "" {
+		_, ipNet, err := net.ParseCIDR(r.PublicIPCIDR)
+		if err != nil {
+			return fmt.Errorf("invalid public_ip_cidr %q: %w", r.PublicIPCIDR, err)
+		}
+		r.publicIPNet = ipNet
+	}
+	return nil
+}
+
+// Matches returns true if the host matches the rule. labels are the names of
+// the labels the host is a member of, they are only needed if the rule has a
+// label condition. The rule must have been compiled with Compile, a rule that
+// was not does not match the hosts on its hostname or CIDR condition.
+func (r HostAssignmentRule) Matches(host *Host, labels map[string]bool) bool {
+	if r.Platform != "" && r.Platform != host.Platform && r.Platform != PlatformFromHost(host.Platform) {
+		return false
+	}
+	if r.HardwareModel != "" && !strings.EqualFold(r.HardwareModel, host.HardwareModel) {
+		return false
+	}
+	if r.Label != "" && !labels[r.Label] {
+		return false
+	}
+	if r.HostnameRegex != "" && (r.hostnameRegex == nil || !r.hostnameRegex.MatchString(host.Hostname)) {
+		return false
+	}
+	if r.PublicIPCIDR != "" {
+		ip := net.ParseIP(host.PublicIP)
+		if r.publicIPNet == nil || ip == nil || !r.publicIPNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateHostAssignmentRules verifies the host assignment rules of a team.
+func ValidateHostAssignmentRules(rules []HostAssignmentRule, invalid *InvalidArgumentError) {
+	for i, r := range rules {
+		if err := r.Validate(); err != nil {
+			invalid.Append(fmt.Sprintf("host_assignment_rules[%d]", i), err.Error())
+		}
+	}
+}
+
+// TeamHostAssignmentRule is a host assignment rule with the team it assigns
+// the hosts to.
+type TeamHostAssignmentRule struct {
+	HostAssignmentRule
+	TeamID   uint   `json:"team_id"`
+	TeamName string `json:"team_name"`
+	// Index is the position of the rule in the rules of the team.
+	Index int `json:"index"`
+}
+
+// TeamHostAssignmentRules are the host assignment rules of all the teams.
+type TeamHostAssignmentRules []*TeamHostAssignmentRule
+
+// Clone returns a copy of the rules for the cached datastore. The copies share
+// the compiled conditions of the rules, which are not modified once compiled.
+func (rules TeamHostAssignmentRules) Clone() (interface{}, error) {
+	if rules == nil {
+		return rules, nil
+	}
+	clone := make(TeamHostAssignmentRules, len(rules))
+	for i, r := range rules {
+		rc := *r
+		clone[i] = &rc
+	}
+	return clone, nil
+}
+
+// SortTeamHostAssignmentRules sorts the rules in evaluation order.
+func SortTeamHostAssignmentRules(rules []*TeamHostAssignmentRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		ri, rj := rules[i], rules[j]
+		if ri.Priority != rj.Priority {
+			return ri.Priority < rj.Priority
+		}
+		if ri.TeamID != rj.TeamID {
+			return ri.TeamID < rj.TeamID
+		}
+		return ri.Index < rj.Index
+	})
+}
+
+// MatchHostAssignmentRules returns the first rule, in evaluation order, that
+// matches the host, or nil if no rule matches it. The rules must be sorted
+// with SortTeamHostAssignmentRules.
+func MatchHostAssignmentRules(rules []*TeamHostAssignmentRule, host *Host, labels map[string]bool) *TeamHostAssignmentRule {
+	for _, r := range rules {
+		if r.Matches(host, labels) {
+			return r
+		}
+	}
+	return nil
+}
+
+// HostAssignmentRulesUseLabels returns true if any of the rules has a label
+// condition.
+func HostAssignmentRulesUseLabels(rules []*TeamHostAssignmentRule) bool {
+	for _, r := range rules {
+		if r.Label != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostAssignmentRuleValidate(t *testing.T) {
+	cases := []struct {
+		rule    HostAssignmentRule
+		wantErr string
+	}{
+		{HostAssignmentRule{}, "at least one of"},
+		{HostAssignmentRule{Priority: 1}, "at least one of"},
+		{HostAssignmentRule{Platform: "darwin"}, ""},
+		{HostAssignmentRule{HostnameRegex: `^web-\d+`}, ""},
+		{HostAssignmentRule{HostnameRegex: `^web-(`}, "invalid hostname_regex"},
+		{HostAssignmentRule{PublicIPCIDR: "203.0.113.0/24"}, ""},
+		{HostAssignmentRule{PublicIPCIDR: "2001:db8::/32"}, ""},
+		{HostAssignmentRule{PublicIPCIDR: "203.0.113.1"}, "invalid public_ip_cidr"},
+		{HostAssignmentRule{Label: "Servers", HardwareModel: "MacBookPro18,1"}, ""},
+	}
+	for _, c := range cases {
+		err := c.rule.Validate()
+		if c.wantErr != "" {
+			require.ErrorContains(t, err, c.wantErr, "%+v", c.rule)
+		} else {
+			require.NoError(t, err, "%+v", c.rule)
+		}
+	}
+
+	invalid := &InvalidArgumentError{}
+	ValidateHostAssignmentRules([]HostAssignmentRule{{Platform: "darwin"}, {}}, invalid)
+	require.True(t, invalid.HasErrors())
+	require.ErrorContains(t, invalid, "host_assignment_rules[1]")
+}
+
+func TestHostAssignmentRuleMatches(t *testing.T) {
+	host := &Host{
+		Platform:      "ubuntu",
+		Hostname:      "web-12.example.com",
+		HardwareModel: "ThinkPad X1",
+		PublicIP:      "203.0.113.42",
+	}
+	labels := map[string]bool{"Servers": true}
+
+	cases := []struct {
+		rule HostAssignmentRule
+		want bool
+	}{
+		{HostAssignmentRule{Platform: "ubuntu"}, true},
+		{HostAssignmentRule{Platform: "linux"}, true},
+		{HostAssignmentRule{Platform: "darwin"}, false},
+		{HostAssignmentRule{HostnameRegex: `^web-\d+\.`}, true},
+		{HostAssignmentRule{HostnameRegex: `^db-`}, false},
+		{HostAssignmentRule{HardwareModel: "thinkpad x1"}, true},
+		{HostAssignmentRule{HardwareModel: "ThinkPad"}, false},
+		{HostAssignmentRule{Label: "Servers"}, true},
+		{HostAssignmentRule{Label: "Laptops"}, false},
+		{HostAssignmentRule{PublicIPCIDR: "203.0.113.0/24"}, true},
+		{HostAssignmentRule{PublicIPCIDR: "198.51.100.0/24"}, false},
+		{HostAssignmentRule{Platform: "linux", Label: "Servers", PublicIPCIDR: "203.0.113.0/24"}, true},
+		{HostAssignmentRule{Platform: "linux", Label: "Laptops"}, false},
+	}
+	for _, c := range cases {
+		require.NoError(t, c.rule.Compile())
+		require.Equal(t, c.want, c.rule.Matches(host, labels), "%+v", c.rule)
+	}
+
+	// a host without a public IP does not match a CIDR
+	rule := HostAssignmentRule{PublicIPCIDR: "0.0.0.0/0"}
+	require.NoError(t, rule.Compile())
+	require.False(t, rule.Matches(&Host{}, nil))
+
+	// a rule that was not compiled does not match on its hostname or CIDR
+	require.False(t, HostAssignmentRule{HostnameRegex: `^web-`}.Matches(host, labels))
+	require.False(t, HostAssignmentRule{PublicIPCIDR: "203.0.113.0/24"}.Matches(host, labels))
+}
+
+func TestMatchHostAssignmentRules(t *testing.T) {
+	rules := []*TeamHostAssignmentRule{
+		{HostAssignmentRule: HostAssignmentRule{Priority: 10, Platform: "darwin"}, TeamID: 1, Index: 0},
+		{HostAssignmentRule: HostAssignmentRule{Priority: 10, Label: "Servers"}, TeamID: 2, Index: 0},
+		{HostAssignmentRule: HostAssignmentRule{Priority: 0, HostnameRegex: "^web-"}, TeamID: 2, Index: 1},
+		{HostAssignmentRule: HostAssignmentRule{Priority: 10, Platform: "windows"}, TeamID: 1, Index: 1},
+	}
+	for _, r := range rules {
+		require.NoError(t, r.Compile())
+	}
+	SortTeamHostAssignmentRules(rules)
+	require.Equal(t, "^web-", rules[0].HostnameRegex)
+	require.Equal(t, "darwin", rules[1].Platform)
+	require.Equal(t, "windows", rules[2].Platform)
+	require.Equal(t, "Servers", rules[3].Label)
+	require.True(t, HostAssignmentRulesUseLabels(rules))
+	require.False(t, HostAssignmentRulesUseLabels(rules[:3]))
+
+	// the first matching rule wins
+	match := MatchHostAssignmentRules(rules, &Host{Platform: "darwin", Hostname: "web-1"}, nil)
+	require.NotNil(t, match)
+	require.Equal(t, uint(2), match.TeamID)
+	require.Equal(t, 1, match.Index)
+
+	match = MatchHostAssignmentRules(rules, &Host{Platform: "darwin", Hostname: "mbp"}, map[string]bool{"Servers": true})
+	require.NotNil(t, match)
+	require.Equal(t, uint(1), match.TeamID)
+
+	match = MatchHostAssignmentRules(rules, &Host{Platform: "ubuntu", Hostname: "db-1"}, map[string]bool{"Servers": true})
+	require.NotNil(t, match)
+	require.Equal(t, uint(2), match.TeamID)
+	require.Equal(t, 0, match.Index)
+
+	require.Nil(t, MatchHostAssignmentRules(rules, &Host{Platform: "ubuntu", Hostname: "db-1"}, nil))
+}
@@ -0,0 +1,117 @@
+package fleet
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HostDeduplicationStrategy is the action Fleet takes when a host enrolls with
+// the hardware serial or UUID of another host, e.g. after a laptop is
+// re-imaged or the osquery host identifier changes.
+type HostDeduplicationStrategy string
+
+const (
+	// HostDeduplicationStrategyDisabled does not look for duplicate hosts, the
+	// old host remains until it expires.
+	HostDeduplicationStrategyDisabled HostDeduplicationStrategy = ""
+	// HostDeduplicationStrategyMerge merges the duplicate hosts into the host
+	// that enrolled and deletes them. The duplicate hosts that were seen since
+	// the host enrolled are flagged instead, as they are still running.
+	HostDeduplicationStrategyMerge HostDeduplicationStrategy = "merge"
+	// HostDeduplicationStrategyFlag records the duplicate hosts so that an admin
+	// can merge them via the API.
+	HostDeduplicationStrategyFlag HostDeduplicationStrategy = "flag"
+)
+
+// IsValid returns true if the strategy is one of the supported strategies.
+func (s HostDeduplicationStrategy) IsValid() bool {
+	switch s {
+	case HostDeduplicationStrategyDisabled, HostDeduplicationStrategyMerge, HostDeduplicationStrategyFlag:
+		return true
+	}
+	return false
+}
+
+// HostDeduplicationSettings defines how Fleet handles the duplicate hosts.
+type HostDeduplicationSettings struct {
+	// Strategy is the action taken when a duplicate host is detected at
+	// enrollment, the detection is disabled if it is empty.
+	Strategy HostDeduplicationStrategy `json:"strategy"`
+}
+
+// ValidateHostDeduplicationSettings checks that the strategy of the settings is
+// supported. It adds any error it finds to the invalid argument error, that
+// can then be checked after the call for errors using invalid.HasErrors.
+func ValidateHostDeduplicationSettings(settings HostDeduplicationSettings, invalid *InvalidArgumentError) {
+	if !settings.Strategy.IsValid() {
+		invalid.Append("host_deduplication_settings.strategy",
+			fmt.Sprintf("invalid strategy %q, must be one of %q or %q", settings.Strategy, HostDeduplicationStrategyMerge, HostDeduplicationStrategyFlag))
+	}
+}
+
+const (
+	// HostDuplicateMatchHardwareSerial is the match of hosts with the same
+	// hardware serial.
+	HostDuplicateMatchHardwareSerial = "hardware_serial"
+	// HostDuplicateMatchUUID is the match of hosts with the same UUID.
+	HostDuplicateMatchUUID = "uuid"
+)
+
+// placeholderHostIdentifiers are the hardware serials and UUIDs reported by
+// the machines whose firmware was not filled in by the manufacturer, they are
+// shared by unrelated machines. The values are lowercase.
+var placeholderHostIdentifiers = map[string]bool{
+	"0":                                    true,
+	"none":                                 true,
+	"n/a":                                  true,
+	"not applicable":                       true,
+	"not available":                        true,
+	"not specified":                        true,
+	"default string":                       true,
+	"to be filled by o.e.m.":               true,
+	"system serial number":                 true,
+	"chassis serial number":                true,
+	"serial number":                        true,
+	"0123456789":                           true,
+	"123456789":                            true,
+	"00000000-0000-0000-0000-000000000000": true,
+	"03000200-0400-0500-0006-000700080009": true,
+	"ffffffff-ffff-ffff-ffff-ffffffffffff": true,
+}
+
+// IsPlaceholderHostIdentifier returns true if the hardware serial or UUID is
+// empty or a known placeholder value, which cannot be used to detect
+// duplicate hosts.
+func IsPlaceholderHostIdentifier(id string) bool {
+	id = strings.ToLower(strings.TrimSpace(id))
+	return id == "" || placeholderHostIdentifiers[id]
+}
+
+// HostDuplicateMatch returns how the duplicate host matches the host, the
+// hardware serial is checked before the UUID. It returns an empty string if
+// the hosts do not match. The placeholder identifiers never match.
+func HostDuplicateMatch(host, duplicate *Host) string {
+	if !IsPlaceholderHostIdentifier(host.HardwareSerial) && host.HardwareSerial == duplicate.HardwareSerial {
+		return HostDuplicateMatchHardwareSerial
+	}
+	if !IsPlaceholderHostIdentifier(host.UUID) && host.UUID == duplicate.UUID {
+		return HostDuplicateMatchUUID
+	}
+	return ""
+}
+
+// HostDuplicate is a pair of hosts flagged as the same machine, to be merged by
+// an admin. HostID is the host that enrolled most recently, which is kept
+// when the hosts are merged.
+type HostDuplicate struct {
+	ID                uint      `json:"id" db:"id"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	HostID            uint      `json:"host_id" db:"host_id"`
+	HostHostname      string    `json:"host_hostname" db:"host_hostname"`
+	DuplicateHostID   uint      `json:"duplicate_host_id" db:"duplicate_host_id"`
+	DuplicateHostname string    `json:"duplicate_hostname" db:"duplicate_hostname"`
+	// MatchedBy is how the hosts were matched, either "hardware_serial" or
+	// "uuid".
+	MatchedBy string `json:"matched_by" db:"matched_by"`
+}
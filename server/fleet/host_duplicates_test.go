@@ -0,0 +1,40 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateHostDeduplicationSettings(t *testing.T) {
+	for _, s := range []HostDeduplicationStrategy{"", "merge", "flag"} {
+		invalid := &InvalidArgumentError{}
+		ValidateHostDeduplicationSettings(HostDeduplicationSettings{Strategy: s}, invalid)
+		require.False(t, invalid.HasErrors(), s)
+	}
+
+	invalid := &InvalidArgumentError{}
+	ValidateHostDeduplicationSettings(HostDeduplicationSettings{Strategy: "delete"}, invalid)
+	require.ErrorContains(t, invalid, "host_deduplication_settings.strategy")
+}
+
+func TestHostDuplicateMatch(t *testing.T) {
+	host := &Host{HardwareSerial: "C02ABC", UUID: "uuid-1"}
+
+	require.Equal(t, HostDuplicateMatchHardwareSerial, HostDuplicateMatch(host, &Host{HardwareSerial: "C02ABC", UUID: "uuid-1"}))
+	require.Equal(t, HostDuplicateMatchUUID, HostDuplicateMatch(host, &Host{HardwareSerial: "C02XYZ", UUID: "uuid-1"}))
+	require.Empty(t, HostDuplicateMatch(host, &Host{HardwareSerial: "C02XYZ", UUID: "uuid-2"}))
+	// empty and placeholder identifiers never match
+	require.Empty(t, HostDuplicateMatch(&Host{}, &Host{}))
+	placeholder := &Host{HardwareSerial: "To Be Filled By O.E.M.", UUID: "03000200-0400-0500-0006-000700080009"}
+	require.Empty(t, HostDuplicateMatch(placeholder, placeholder))
+}
+
+func TestIsPlaceholderHostIdentifier(t *testing.T) {
+	for _, id := range []string{"", " ", "0", "To Be Filled By O.E.M.", "System Serial Number", "Not Specified", "00000000-0000-0000-0000-000000000000"} {
+		require.True(t, IsPlaceholderHostIdentifier(id), id)
+	}
+	for _, id := range []string{"C02ABC", "0F3A", "a6f1b9e2-3c4d-4e5f-8a9b-0c1d2e3f4a5b"} {
+		require.False(t, IsPlaceholderHostIdentifier(id), id)
+	}
+}
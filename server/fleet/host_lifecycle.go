@@ -0,0 +1,77 @@
+package fleet
+
+import "fmt"
+
+// HostQuarantineWebhookJobName is the name of the worker job that notifies the
+// host quarantine webhook when a host is quarantined.
+const HostQuarantineWebhookJobName = "host_quarantine_webhook"
+
+// HostLifecycleState is the lifecycle state of a host, set by an admin. Unlike
+// the HostStatus, which is derived from the last time the host was seen, it
+// reflects the state of the device in the organization.
+type HostLifecycleState string
+
+const (
+	// HostLifecycleStateActive is the state of the hosts in use, it is the
+	// state of the hosts when they enroll.
+	HostLifecycleStateActive HostLifecycleState = "active"
+	// HostLifecycleStateRetired is the state of the hosts that were
+	// decommissioned. They are excluded from the host counts, the policies and
+	// the webhooks.
+	HostLifecycleStateRetired HostLifecycleState = "retired"
+	// HostLifecycleStateQuarantined is the state of the hosts that were
+	// isolated, e.g. after a security incident. They are moved to the
+	// quarantine team, if any, and the host quarantine webhook is notified.
+	HostLifecycleStateQuarantined HostLifecycleState = "quarantined"
+	// HostLifecycleStateInRepair is the state of the hosts that are
+	// temporarily out of service.
+	HostLifecycleStateInRepair HostLifecycleState = "in_repair"
+)
+
+// IsValid returns true if the state is one of the supported lifecycle states.
+func (s HostLifecycleState) IsValid() bool {
+	switch s {
+	case HostLifecycleStateActive, HostLifecycleStateRetired, HostLifecycleStateQuarantined, HostLifecycleStateInRepair:
+		return true
+	}
+	return false
+}
+
+// ValidateHostLifecycleState returns an invalid argument error if the state is
+// not a supported lifecycle state.
+func ValidateHostLifecycleState(state HostLifecycleState) error {
+	if !state.IsValid() {
+		return NewInvalidArgumentError("lifecycle_state",
+			fmt.Sprintf("invalid lifecycle state %q, must be one of %q, %q, %q or %q", state,
+				HostLifecycleStateActive, HostLifecycleStateRetired, HostLifecycleStateQuarantined, HostLifecycleStateInRepair))
+	}
+	return nil
+}
+
+// HostLifecycleSettings defines what Fleet does when the lifecycle state of a
+// host changes.
+type HostLifecycleSettings struct {
+	// QuarantineTeamID is the team the hosts are moved to when they are
+	// quarantined, they keep their team if it is nil. This is a Fleet Premium
+	// feature.
+	QuarantineTeamID *uint `json:"quarantine_team_id"`
+}
+
+// HostQuarantineWebhookSettings holds the settings for the webhook sent when
+// a host is quarantined.
+type HostQuarantineWebhookSettings struct {
+	// Enable indicates whether the webhook for quarantined hosts is enabled.
+	Enable bool `json:"enable_host_quarantine_webhook"`
+	// DestinationURL is the webhook's URL.
+	DestinationURL string `json:"destination_url"`
+}
+
+// ValidateEnabledHostQuarantineIntegrations checks that the host quarantine
+// webhook is properly configured if enabled. It adds any error it finds to the
+// invalid argument error, that can then be checked after the call for errors
+// using invalid.HasErrors.
+func ValidateEnabledHostQuarantineIntegrations(webhook HostQuarantineWebhookSettings, invalid *InvalidArgumentError) {
+	if webhook.Enable && webhook.DestinationURL == "" {
+		invalid.Append("destination_url", "destination_url is required to enable the host quarantine webhook")
+	}
+}
@@ -0,0 +1,37 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateHostLifecycleState(t *testing.T) {
+	for _, state := range []HostLifecycleState{
+		HostLifecycleStateActive,
+		HostLifecycleStateRetired,
+		HostLifecycleStateQuarantined,
+		HostLifecycleStateInRepair,
+	} {
+		require.NoError(t, ValidateHostLifecycleState(state), state)
+	}
+
+	for _, state := range []HostLifecycleState{"", "Retired", "decommissioned"} {
+		err := ValidateHostLifecycleState(state)
+		var iae *InvalidArgumentError
+		require.ErrorAs(t, err, &iae, state)
+		require.ErrorContains(t, err, "invalid lifecycle state")
+	}
+}
+
+func TestValidateEnabledHostQuarantineIntegrations(t *testing.T) {
+	invalid := &InvalidArgumentError{}
+	ValidateEnabledHostQuarantineIntegrations(HostQuarantineWebhookSettings{DestinationURL: "https://example.com"}, invalid)
+	ValidateEnabledHostQuarantineIntegrations(HostQuarantineWebhookSettings{Enable: true, DestinationURL: "https://example.com"}, invalid)
+	ValidateEnabledHostQuarantineIntegrations(HostQuarantineWebhookSettings{}, invalid)
+	require.False(t, invalid.HasErrors())
+
+	ValidateEnabledHostQuarantineIntegrations(HostQuarantineWebhookSettings{Enable: true}, invalid)
+	require.True(t, invalid.HasErrors())
+	require.ErrorContains(t, invalid, "destination_url is required")
+}
@@ -0,0 +1,244 @@
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HostTagType is the type of the values of a host tag key.
+type HostTagType string
+
+// List of host tag types.
+const (
+	HostTagTypeString  HostTagType = "string"
+	HostTagTypeNumber  HostTagType = "number"
+	HostTagTypeBoolean HostTagType = "boolean"
+)
+
+// IsValid returns true if the type is a known host tag type.
+func (t HostTagType) IsValid() bool {
+	switch t {
+	case HostTagTypeString, HostTagTypeNumber, HostTagTypeBoolean:
+		return true
+	default:
+		return false
+	}
+}
+
+// maxHostTagLength is the maximum length of the name of a host tag key and of
+// the value of a host tag.
+const maxHostTagLength = 255
+
+// NormalizeValue verifies the value is of the type and returns its canonical
+// form, which is how it is stored and matched (e.g. "1.50" is stored as "1.5"
+// and "True" as "true").
+func (t HostTagType) NormalizeValue(v string) (string, error) {
+	switch t {
+	case HostTagTypeString:
+		if v == "" {
+			return "", errors.New("value cannot be empty")
+		}
+	case HostTagTypeNumber:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", fmt.Errorf("value %q is not a number", v)
+		}
+		v = strconv.FormatFloat(f, 'f', -1, 64)
+	case HostTagTypeBoolean:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return "", fmt.Errorf("value %q is not a boolean", v)
+		}
+		v = strconv.FormatBool(b)
+	default:
+		return "", fmt.Errorf("invalid host tag type %q", t)
+	}
+	if len(v) > maxHostTagLength {
+		return "", fmt.Errorf("value cannot be longer than %d characters", maxHostTagLength)
+	}
+	return v, nil
+}
+
+// HostTagKey is the definition of a host tag, it names the tag and types its
+// values.
+type HostTagKey struct {
+	UpdateCreateTimestamps
+	ID          uint        `json:"id" db:"id"`
+	Name        string      `json:"name" db:"name"`
+	Type        HostTagType `json:"type" db:"type"`
+	Description string      `json:"description" db:"description"`
+	// HostCount is the number of hosts tagged with the key.
+	HostCount uint `json:"host_count" db:"host_count"`
+}
+
+// AuthzType implements authz.AuthzTyper.
+func (k HostTagKey) AuthzType() string {
+	return "host_tag_key"
+}
+
+// hostTagKeyNameRegexp matches the valid host tag key names, they cannot
+// contain ':' as it separates the key from the value in the host tag filters.
+var hostTagKeyNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// Verify verifies the host tag key is valid.
+func (k *HostTagKey) Verify() error {
+	if !hostTagKeyNameRegexp.MatchString(k.Name) {
+		return fmt.Errorf("invalid host tag key name %q: must only contain letters, digits, '_', '.' and '-'", k.Name)
+	}
+	if len(k.Name) > maxHostTagLength {
+		return fmt.Errorf("host tag key name cannot be longer than %d characters", maxHostTagLength)
+	}
+	if !k.Type.IsValid() {
+		return fmt.Errorf("invalid type %q for host tag key %q: must be one of string, number or boolean", k.Type, k.Name)
+	}
+	return nil
+}
+
+// HostTagValues are host tag values by key name. A nil value unsets the tag.
+// When unmarshaled from JSON, the values can be strings, numbers or booleans.
+type HostTagValues map[string]*string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *HostTagValues) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*v = nil
+		return nil
+	}
+
+	values := make(HostTagValues, len(raw))
+	for k, rv := range raw {
+		switch rv := rv.(type) {
+		case nil:
+			values[k] = nil
+		case string:
+			values[k] = &rv
+		case json.Number:
+			s := rv.String()
+			values[k] = &s
+		case bool:
+			s := strconv.FormatBool(rv)
+			values[k] = &s
+		default:
+			return fmt.Errorf("invalid value for host tag %q: must be a string, number, boolean or null", k)
+		}
+	}
+	*v = values
+	return nil
+}
+
+// Normalize verifies the values against the type of their key and returns
+// them in their canonical form. Unset (nil) values are kept as is.
+func (v HostTagValues) Normalize(keys []*HostTagKey) (HostTagValues, error) {
+	keysByName := make(map[string]*HostTagKey, len(keys))
+	for _, k := range keys {
+		keysByName[k.Name] = k
+	}
+
+	normalized := make(HostTagValues, len(v))
+	for name, value := range v {
+		key, ok := keysByName[name]
+		if !ok {
+			return nil, NewInvalidArgumentError("tags", fmt.Sprintf("unknown host tag key %q", name))
+		}
+		if value == nil {
+			normalized[name] = nil
+			continue
+		}
+		nv, err := key.Type.NormalizeValue(*value)
+		if err != nil {
+			return nil, NewInvalidArgumentError("tags", fmt.Sprintf("host tag %q: %s", name, err))
+		}
+		normalized[name] = &nv
+	}
+	return normalized, nil
+}
+
+// FormatHostTags formats the host tags (a JSON object of the values by key
+// name) as a comma-separated list of key=value pairs sorted by key, as done
+// in the CSV export of the hosts.
+func FormatHostTags(tags *json.RawMessage) (string, error) {
+	if tags == nil {
+		return "", nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal(*tags, &values); err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(k + "=" + values[k])
+	}
+	return sb.String(), nil
+}
+
+// HostTagFilter filters the hosts by host tag. The hosts must be tagged with
+// the key, with the value if it is not nil.
+type HostTagFilter struct {
+	Key   string
+	Value *string
+}
+
+// ParseHostTagFilter parses a host tag filter of the form "key" or
+// "key:value".
+func ParseHostTagFilter(s string) (HostTagFilter, error) {
+	key, value, hasValue := strings.Cut(s, ":")
+	if !hostTagKeyNameRegexp.MatchString(key) {
+		return HostTagFilter{}, fmt.Errorf("invalid host tag filter %q: must be of the form key or key:value", s)
+	}
+	f := HostTagFilter{Key: key}
+	if hasValue {
+		f.Value = &value
+	}
+	return f, nil
+}
+
+const (
+	HostTagsKind = "host_tags"
+)
+
+// HostTagsSpec is the spec of the host tag keys and of the tags of hosts,
+// applied with fleetctl apply.
+type HostTagsSpec struct {
+	// Keys are created, or updated if a key with the same name exists.
+	Keys []*HostTagKeySpec `json:"keys,omitempty"`
+	// Hosts are the tags to set on hosts, the other tags of the hosts are left
+	// unchanged.
+	Hosts []*HostTagsHostSpec `json:"hosts,omitempty"`
+}
+
+// HostTagKeySpec is the spec of a host tag key.
+type HostTagKeySpec struct {
+	Name        string      `json:"name"`
+	Type        HostTagType `json:"type"`
+	Description string      `json:"description,omitempty"`
+}
+
+// HostTagsHostSpec is the spec of the tags of a host.
+type HostTagsHostSpec struct {
+	// Host is the identifier of the host, its hostname, UUID or osquery host
+	// identifier.
+	Host string        `json:"host"`
+	Tags HostTagValues `json:"tags"`
+}
@@ -0,0 +1,119 @@
+package fleet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostTagTypeNormalizeValue(t *testing.T) {
+	cases := []struct {
+		typ     HostTagType
+		value   string
+		want    string
+		wantErr string
+	}{
+		{HostTagTypeString, "alice", "alice", ""},
+		{HostTagTypeString, " Alice ", " Alice ", ""},
+		{HostTagTypeString, "", "", "cannot be empty"},
+		{HostTagTypeNumber, "42", "42", ""},
+		{HostTagTypeNumber, "1.50", "1.5", ""},
+		{HostTagTypeNumber, " -3e2 ", "-300", ""},
+		{HostTagTypeNumber, "forty-two", "", "not a number"},
+		{HostTagTypeNumber, "NaN", "", "not a number"},
+		{HostTagTypeBoolean, "True", "true", ""},
+		{HostTagTypeBoolean, "0", "false", ""},
+		{HostTagTypeBoolean, "yes", "", "not a boolean"},
+		{"date", "2022-10-24", "", "invalid host tag type"},
+	}
+	for _, c := range cases {
+		t.Run(string(c.typ)+"/"+c.value, func(t *testing.T) {
+			got, err := c.typ.NormalizeValue(c.value)
+			if c.wantErr != "" {
+				require.ErrorContains(t, err, c.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestHostTagKeyVerify(t *testing.T) {
+	require.NoError(t, (&HostTagKey{Name: "cost_center", Type: HostTagTypeNumber}).Verify())
+	require.NoError(t, (&HostTagKey{Name: "env.tier-1", Type: HostTagTypeString}).Verify())
+	require.ErrorContains(t, (&HostTagKey{Name: "", Type: HostTagTypeString}).Verify(), "invalid host tag key name")
+	require.ErrorContains(t, (&HostTagKey{Name: "a:b", Type: HostTagTypeString}).Verify(), "invalid host tag key name")
+	require.ErrorContains(t, (&HostTagKey{Name: "owner", Type: "email"}).Verify(), "invalid type")
+}
+
+func TestHostTagValuesUnmarshalJSON(t *testing.T) {
+	var v HostTagValues
+	err := json.Unmarshal([]byte(`{"owner": "alice", "cost_center": 1234.50, "critical": true, "env": null}`), &v)
+	require.NoError(t, err)
+	require.Equal(t, HostTagValues{
+		"owner":       ptr.String("alice"),
+		"cost_center": ptr.String("1234.50"),
+		"critical":    ptr.String("true"),
+		"env":         nil,
+	}, v)
+
+	err = json.Unmarshal([]byte(`{"owner": ["alice"]}`), &v)
+	require.ErrorContains(t, err, `invalid value for host tag "owner"`)
+}
+
+func TestHostTagValuesNormalize(t *testing.T) {
+	keys := []*HostTagKey{
+		{Name: "owner", Type: HostTagTypeString},
+		{Name: "cost_center", Type: HostTagTypeNumber},
+		{Name: "critical", Type: HostTagTypeBoolean},
+	}
+
+	got, err := HostTagValues{
+		"owner":       ptr.String("alice"),
+		"cost_center": ptr.String("1234.50"),
+		"critical":    nil,
+	}.Normalize(keys)
+	require.NoError(t, err)
+	require.Equal(t, HostTagValues{
+		"owner":       ptr.String("alice"),
+		"cost_center": ptr.String("1234.5"),
+		"critical":    nil,
+	}, got)
+
+	_, err = HostTagValues{"critical": ptr.String("maybe")}.Normalize(keys)
+	require.ErrorContains(t, err, `host tag "critical"`)
+
+	_, err = HostTagValues{"env": ptr.String("prod")}.Normalize(keys)
+	require.ErrorContains(t, err, `unknown host tag key "env"`)
+}
+
+func TestParseHostTagFilter(t *testing.T) {
+	f, err := ParseHostTagFilter("owner")
+	require.NoError(t, err)
+	require.Equal(t, HostTagFilter{Key: "owner"}, f)
+
+	f, err = ParseHostTagFilter("owner:alice:bob")
+	require.NoError(t, err)
+	require.Equal(t, HostTagFilter{Key: "owner", Value: ptr.String("alice:bob")}, f)
+
+	f, err = ParseHostTagFilter("owner:")
+	require.NoError(t, err)
+	require.Equal(t, HostTagFilter{Key: "owner", Value: ptr.String("")}, f)
+
+	_, err = ParseHostTagFilter(":alice")
+	require.Error(t, err)
+}
+
+func TestFormatHostTags(t *testing.T) {
+	s, err := FormatHostTags(nil)
+	require.NoError(t, err)
+	require.Empty(t, s)
+
+	raw := json.RawMessage(`{"owner": "alice", "cost_center": "1234", "critical": "true"}`)
+	s, err = FormatHostTags(&raw)
+	require.NoError(t, err)
+	require.Equal(t, "cost_center=1234,critical=true,owner=alice", s)
+}
@@ -0,0 +1,47 @@
+package fleet
+
+import "time"
+
+// HostVitals is a sample of the vitals of a host, that is the details that
+// are overwritten each time the host reports them. The samples are recorded
+// when the host reports its details, at most once per configured interval, so
+// that their history shows the disk filling up, the upgrades and the reboots
+// of the host.
+type HostVitals struct {
+	ID                        uint    `json:"id" db:"id"`
+	HostID                    uint    `json:"host_id" db:"host_id"`
+	GigsDiskSpaceAvailable    float64 `json:"gigs_disk_space_available" db:"gigs_disk_space_available"`
+	PercentDiskSpaceAvailable float64 `json:"percent_disk_space_available" db:"percent_disk_space_available"`
+	// Uptime is the uptime of the host when the sample was recorded, the host
+	// rebooted between two samples if the uptime of the latest is shorter than
+	// the time between the samples.
+	Uptime         time.Duration `json:"uptime" db:"uptime"`
+	OsqueryVersion string        `json:"osquery_version" db:"osquery_version"`
+	OSVersion      string        `json:"os_version" db:"os_version"`
+	RecordedAt     time.Time     `json:"recorded_at" db:"recorded_at"`
+}
+
+// HostVitalsListOptions are the options to list the samples of the vitals of
+// a host.
+type HostVitalsListOptions struct {
+	ListOptions
+
+	// From and To filter the samples to those recorded at or after From and
+	// before To, if not nil.
+	From *time.Time
+	To   *time.Time
+}
+
+// HostVitalsFromHost returns the current vitals of the host, as a sample
+// recorded at ts.
+func HostVitalsFromHost(host *Host, ts time.Time) *HostVitals {
+	return &HostVitals{
+		HostID:                    host.ID,
+		GigsDiskSpaceAvailable:    host.GigsDiskSpaceAvailable,
+		PercentDiskSpaceAvailable: host.PercentDiskSpaceAvailable,
+		Uptime:                    host.Uptime,
+		OsqueryVersion:            host.OsqueryVersion,
+		OSVersion:                 host.OSVersion,
+		RecordedAt:                ts,
+	}
+}
@@ -16,6 +16,7 @@ type HostResponse struct {
 	Labels           []Label      `json:"labels,omitempty" csv:"-"`
 	Geolocation      *GeoLocation `json:"geolocation,omitempty" csv:"-"`
 	CSVDeviceMapping string       `json:"-" db:"-" csv:"device_mapping"`
+	CSVTags          string       `json:"-" db:"-" csv:"tags"`
 }
 
 // HostResponseForHost returns a HostResponse from Host with Geolocation.
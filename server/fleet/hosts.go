@@ -84,6 +84,21 @@ type HostListOptions struct {
 	// Premium feature, Fleet Free ignores the setting (it forces it to nil to
 	// disable it).
 	LowDiskSpaceFilter *int
+
+	// SoftwareStatusFilter filters the hosts by their compliance with the
+	// software rules that apply to them.
+	SoftwareStatusFilter HostSoftwareStatus
+	// SoftwareRuleIDFilter filters the hosts violating the software rule.
+	SoftwareRuleIDFilter *uint
+
+	// IncludeTags joins the host tags of each host.
+	IncludeTags bool
+	// HostTagFilters filters the hosts by host tags, the hosts must match all
+	// the filters.
+	HostTagFilters []HostTagFilter
+
+	// LifecycleStateFilter filters the hosts by lifecycle state.
+	LifecycleStateFilter *HostLifecycleState
 }
 
 func (h HostListOptions) Empty() bool {
@@ -102,7 +117,12 @@ func (h HostListOptions) Empty() bool {
 		h.MDMIDFilter == nil &&
 		h.MDMEnrollmentStatusFilter == "" &&
 		h.MunkiIssueIDFilter == nil &&
-		h.LowDiskSpaceFilter == nil
+		h.LowDiskSpaceFilter == nil &&
+		h.SoftwareStatusFilter == "" &&
+		h.SoftwareRuleIDFilter == nil &&
+		h.IncludeTags == false &&
+		len(h.HostTagFilters) == 0 &&
+		h.LifecycleStateFilter == nil
 }
 
 type HostUser struct {
@@ -162,6 +182,9 @@ type Host struct {
 	ConfigTLSRefresh          uint                `json:"config_tls_refresh" db:"config_tls_refresh" csv:"config_tls_refresh"`
 	LoggerTLSPeriod           uint                `json:"logger_tls_period" db:"logger_tls_period" csv:"logger_tls_period"`
 	TeamID                    *uint               `json:"team_id" db:"team_id" csv:"team_id"`
+	// LifecycleState is the lifecycle state of the host set by an admin, see
+	// HostLifecycleState.
+	LifecycleState HostLifecycleState `json:"lifecycle_state,omitempty" db:"lifecycle_state" csv:"lifecycle_state"`
 
 	// Loaded via JOIN in DB
 	PackStats []PackStats `json:"pack_stats" csv:"-"`
@@ -183,6 +206,10 @@ type Host struct {
 	// encoded from this column, it is processed before marshaling, hence why the
 	// struct tag here has csv:"-".
 	DeviceMapping *json.RawMessage `json:"device_mapping,omitempty" db:"device_mapping" csv:"-"`
+
+	// Tags are the host tags of the host, as a JSON object of the values by key
+	// name. Like DeviceMapping, they are processed before the CSV export.
+	Tags *json.RawMessage `json:"tags,omitempty" db:"tags" csv:"-"`
 }
 
 // DisplayName returns ComputerName if it isn't empty or HostName otherwise.
@@ -217,6 +244,10 @@ type HostDetail struct {
 	// but when unset, it doesn't get marshaled (e.g. we don't return that
 	// information for the List Hosts endpoint).
 	Batteries *[]*HostBattery `json:"batteries,omitempty"`
+	// ComplianceScore is the compliance score of the host computed from its
+	// policies, see ComplianceScore. It is only set if the policies are loaded
+	// and at least one ran on the host.
+	ComplianceScore *float64 `json:"compliance_score,omitempty"`
 }
 
 const (
@@ -238,6 +269,15 @@ type HostSummary struct {
 	LowDiskSpaceCount  *uint                  `json:"low_disk_space_count,omitempty" db:"low_disk_space"`
 	BuiltinLabels      []*LabelSummary        `json:"builtin_labels" db:"-"`
 	Platforms          []*HostSummaryPlatform `json:"platforms" db:"-"`
+	// ComplianceScore is the compliance score of the hosts, see
+	// ComplianceScore.
+	ComplianceScore *float64 `json:"compliance_score" db:"-"`
+	// RetiredCount, QuarantinedCount and InRepairCount are the number of hosts
+	// in those lifecycle states. The retired hosts are not included in the
+	// other counts.
+	RetiredCount     uint `json:"retired_count" db:"-"`
+	QuarantinedCount uint `json:"quarantined_count" db:"-"`
+	InRepairCount    uint `json:"in_repair_count" db:"-"`
 }
 
 // HostSummaryPlatform represents the hosts statistics for a given platform,
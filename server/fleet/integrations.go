@@ -359,6 +359,27 @@ func ValidateEnabledHostStatusIntegrations(webhook HostStatusWebhookSettings, in
 	}
 }
 
+// ValidateEnabledUnapprovedSoftwareIntegrations checks that the unapproved
+// software webhook is properly configured if enabled. It adds any error it
+// finds to the invalid argument error, that can then be checked after the call
+// for errors using invalid.HasErrors.
+func ValidateEnabledUnapprovedSoftwareIntegrations(webhook UnapprovedSoftwareWebhookSettings, invalid *InvalidArgumentError) {
+	for _, rule := range webhook.Software {
+		if rule.IsEmpty() {
+			invalid.Append("software", "each unapproved software must have a name, bundle_identifier or vendor")
+			break
+		}
+	}
+	if webhook.Enable {
+		if webhook.DestinationURL == "" {
+			invalid.Append("destination_url", "destination_url is required to enable the unapproved software webhook")
+		}
+		if len(webhook.Software) == 0 {
+			invalid.Append("software", "software is required to enable the unapproved software webhook")
+		}
+	}
+}
+
 // ValidateEnabledVulnerabilitiesIntegrations checks that a single integration
 // is enabled for vulnerabilities. It adds any error it finds to the invalid
 // argument error, that can then be checked after the call for errors using
@@ -29,4 +29,7 @@ type Job struct {
 	State     JobState         `json:"state" db:"state"`
 	Retries   int              `json:"retries" db:"retries"`
 	Error     string           `json:"error" db:"error"`
+	// NotBefore is the time before which the job should not be processed, it
+	// is used to delay retries of failed jobs.
+	NotBefore time.Time `json:"not_before" db:"not_before"`
 }
@@ -16,6 +16,11 @@ type LabelPayload struct {
 	Query       *string `json:"query"`
 	Platform    *string `json:"platform"`
 	Description *string `json:"description"`
+	// HostTagKey and HostTagValue are set to create a label made of the hosts
+	// tagged with the host tag (with the value, if set) instead of the hosts
+	// matching the query.
+	HostTagKey   *string `json:"host_tag_key"`
+	HostTagValue *string `json:"host_tag_value"`
 }
 
 // LabelType is used to catagorize the kind of label
@@ -61,6 +66,9 @@ const (
 	LabelMembershipTypeDynamic LabelMembershipType = iota
 	// LabelTypeManual indicates that the label is populated manually.
 	LabelMembershipTypeManual
+	// LabelMembershipTypeHostTag indicates that the label is populated with the
+	// hosts tagged with its host tag.
+	LabelMembershipTypeHostTag
 )
 
 func (t LabelMembershipType) MarshalJSON() ([]byte, error) {
@@ -69,6 +77,8 @@ func (t LabelMembershipType) MarshalJSON() ([]byte, error) {
 		return []byte(`"dynamic"`), nil
 	case LabelMembershipTypeManual:
 		return []byte(`"manual"`), nil
+	case LabelMembershipTypeHostTag:
+		return []byte(`"host_tag"`), nil
 	default:
 		return nil, fmt.Errorf("invalid LabelMembershipType: %d", t)
 	}
@@ -80,6 +90,8 @@ func (t *LabelMembershipType) UnmarshalJSON(b []byte) error {
 		*t = LabelMembershipTypeDynamic
 	case `"manual"`:
 		*t = LabelMembershipTypeManual
+	case `"host_tag"`:
+		*t = LabelMembershipTypeHostTag
 	default:
 		return fmt.Errorf("invalid LabelMembershipType: %s", string(b))
 	}
@@ -95,6 +107,8 @@ type Label struct {
 	Platform            string              `json:"platform"`
 	LabelType           LabelType           `json:"label_type" db:"label_type"`
 	LabelMembershipType LabelMembershipType `json:"label_membership_type" db:"label_membership_type"`
+	HostTagKey          *string             `json:"host_tag_key,omitempty" db:"host_tag_key"`
+	HostTagValue        *string             `json:"host_tag_value,omitempty" db:"host_tag_value"`
 	HostCount           int                 `json:"host_count,omitempty" db:"host_count"`
 }
 
@@ -130,4 +144,8 @@ type LabelSpec struct {
 	LabelType           LabelType           `json:"label_type,omitempty" db:"label_type"`
 	LabelMembershipType LabelMembershipType `json:"label_membership_type" db:"label_membership_type"`
 	Hosts               []string            `json:"hosts,omitempty"`
+	// HostTagKey is the host tag of the hosts of a label with the host_tag
+	// membership type, HostTagValue restricts them to the hosts with that value.
+	HostTagKey   *string `json:"host_tag_key,omitempty" db:"host_tag_key"`
+	HostTagValue *string `json:"host_tag_value,omitempty" db:"host_tag_value"`
 }
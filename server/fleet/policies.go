@@ -1,8 +1,20 @@
 package fleet
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
+)
+
+// List of severities of a policy.
+const (
+	PolicySeverityCritical = "critical"
+	PolicySeverityHigh     = "high"
+	PolicySeverityMedium   = "medium"
+	PolicySeverityLow      = "low"
 )
 
 // PolicyPayload holds data for policy creation.
@@ -27,6 +39,30 @@ type PolicyPayload struct {
 	//
 	// Empty string targets all platforms.
 	Platform string
+	// RemediationType is the type of the remediation that runs on hosts that
+	// start failing the policy, one of ScriptExecutionTypeScript or
+	// ScriptExecutionTypeOsquery.
+	//
+	// Empty string disables the remediation.
+	RemediationType string
+	// Remediation is the script or osquery query of the remediation.
+	Remediation string
+	// Severity is the severity of the policy, one of PolicySeverityCritical,
+	// PolicySeverityHigh, PolicySeverityMedium or PolicySeverityLow.
+	//
+	// Empty string means the policy has no severity.
+	Severity string
+	// Tags are free-form tags of the policy, e.g. to map it to a compliance
+	// framework such as a CIS benchmark.
+	Tags []string
+	// LabelsIncludeAny are the names of the labels the policy is scoped to, the
+	// policy only applies to the hosts that are members of any of them.
+	//
+	// Empty means the policy applies to all hosts.
+	LabelsIncludeAny []string
+	// LabelsExcludeAny are the names of the labels whose members the policy
+	// does not apply to.
+	LabelsExcludeAny []string
 }
 
 var (
@@ -35,6 +71,13 @@ var (
 	errPolicyIDAndQuerySet   = errors.New("both fields \"queryID\" and \"query\" cannot be set")
 	errPolicyInvalidQuery    = errors.New("invalid policy query")
 	errPolicyInvalidPlatform = errors.New("invalid policy platform")
+	errPolicyInvalidRemType  = errors.New("invalid policy remediation type")
+	errPolicyEmptyRem        = errors.New("policy remediation cannot be empty")
+	errPolicyInvalidRemQuery = errors.New("invalid policy remediation query")
+	errPolicyInvalidSeverity = errors.New("invalid policy severity")
+	errPolicyEmptyTag        = errors.New("policy tags cannot be empty")
+	errPolicyEmptyLabel      = errors.New("policy label names cannot be empty")
+	errPolicyDuplicateLabel  = errors.New("policy labels cannot be included or excluded more than once")
 )
 
 // Verify verifies the policy payload is valid.
@@ -54,6 +97,18 @@ func (p PolicyPayload) Verify() error {
 	if err := verifyPolicyPlatforms(p.Platform); err != nil {
 		return err
 	}
+	if err := verifyPolicyRemediation(p.RemediationType, p.Remediation); err != nil {
+		return err
+	}
+	if err := verifyPolicySeverity(p.Severity); err != nil {
+		return err
+	}
+	if err := verifyPolicyTags(p.Tags); err != nil {
+		return err
+	}
+	if err := verifyPolicyLabels(p.LabelsIncludeAny, p.LabelsExcludeAny); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -93,6 +148,59 @@ func verifyPolicyPlatforms(platforms string) error {
 	return nil
 }
 
+func verifyPolicyRemediation(remediationType, remediation string) error {
+	switch remediationType {
+	case "":
+		return nil
+	case ScriptExecutionTypeScript:
+		if emptyString(remediation) {
+			return errPolicyEmptyRem
+		}
+	case ScriptExecutionTypeOsquery:
+		if emptyString(remediation) {
+			return errPolicyEmptyRem
+		}
+		if validateSQLRegexp.MatchString(remediation) {
+			return errPolicyInvalidRemQuery
+		}
+	default:
+		return errPolicyInvalidRemType
+	}
+	return nil
+}
+
+func verifyPolicySeverity(severity string) error {
+	switch severity {
+	case "", PolicySeverityCritical, PolicySeverityHigh, PolicySeverityMedium, PolicySeverityLow:
+		return nil
+	default:
+		return errPolicyInvalidSeverity
+	}
+}
+
+func verifyPolicyTags(tags []string) error {
+	for _, tag := range tags {
+		if emptyString(tag) {
+			return errPolicyEmptyTag
+		}
+	}
+	return nil
+}
+
+func verifyPolicyLabels(include, exclude []string) error {
+	seen := make(map[string]bool, len(include)+len(exclude))
+	for _, name := range append(append([]string{}, include...), exclude...) {
+		if emptyString(name) {
+			return errPolicyEmptyLabel
+		}
+		if seen[name] {
+			return errPolicyDuplicateLabel
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
 // ModifyPolicyPayload holds data for policy modification.
 type ModifyPolicyPayload struct {
 	// Name is the name of the policy.
@@ -106,6 +214,23 @@ type ModifyPolicyPayload struct {
 	// Platform is a comma-separated string to indicate the target platforms.
 	// If non-nil, empty string targets all platforms.
 	Platform *string `json:"platform"`
+	// RemediationType is the type of the remediation of the policy.
+	// If non-nil, empty string disables the remediation.
+	RemediationType *string `json:"remediation_type"`
+	// Remediation is the script or osquery query of the remediation.
+	Remediation *string `json:"remediation"`
+	// Severity is the severity of the policy.
+	// If non-nil, empty string removes the severity.
+	Severity *string `json:"severity"`
+	// Tags are the tags of the policy, they replace the existing tags if
+	// non-nil.
+	Tags *[]string `json:"tags"`
+	// LabelsIncludeAny are the names of the labels the policy is scoped to,
+	// they replace the existing ones if non-nil.
+	LabelsIncludeAny *[]string `json:"labels_include_any"`
+	// LabelsExcludeAny are the names of the labels whose members the policy
+	// does not apply to, they replace the existing ones if non-nil.
+	LabelsExcludeAny *[]string `json:"labels_exclude_any"`
 }
 
 // Verify verifies the policy payload is valid.
@@ -125,6 +250,26 @@ func (p ModifyPolicyPayload) Verify() error {
 			return err
 		}
 	}
+	if p.Severity != nil {
+		if err := verifyPolicySeverity(*p.Severity); err != nil {
+			return err
+		}
+	}
+	if p.Tags != nil {
+		if err := verifyPolicyTags(*p.Tags); err != nil {
+			return err
+		}
+	}
+	if p.LabelsIncludeAny != nil {
+		if err := verifyPolicyLabels(*p.LabelsIncludeAny, nil); err != nil {
+			return err
+		}
+	}
+	if p.LabelsExcludeAny != nil {
+		if err := verifyPolicyLabels(nil, *p.LabelsExcludeAny); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -155,10 +300,76 @@ type PolicyData struct {
 	//
 	// Empty string targets all platforms.
 	Platform string `json:"platform" db:"platforms"`
+	// RemediationType is the type of the remediation that runs on hosts that
+	// start failing the policy, one of ScriptExecutionTypeScript or
+	// ScriptExecutionTypeOsquery.
+	//
+	// Empty string means the policy has no remediation.
+	RemediationType string `json:"remediation_type" db:"remediation_type"`
+	// Remediation is the script or osquery query of the remediation.
+	Remediation *string `json:"remediation,omitempty" db:"remediation"`
+	// Severity is the severity of the policy, used to weight its results in
+	// the compliance scores.
+	//
+	// Empty string means the policy has no severity.
+	Severity string `json:"severity" db:"severity"`
+	// Tags are free-form tags of the policy.
+	Tags PolicyTags `json:"tags" db:"tags"`
+	// LabelsIncludeAny are the names of the labels the policy is scoped to, the
+	// policy only applies to the hosts that are members of any of them.
+	//
+	// Empty means the policy applies to all hosts.
+	LabelsIncludeAny []string `json:"labels_include_any,omitempty" db:"-"`
+	// LabelsExcludeAny are the names of the labels whose members the policy
+	// does not apply to.
+	LabelsExcludeAny []string `json:"labels_exclude_any,omitempty" db:"-"`
 
 	UpdateCreateTimestamps
 }
 
+// VerifyRemediation verifies the remediation of the policy is valid. It is
+// verified once a ModifyPolicyPayload is applied, as the remediation type and
+// contents can be modified separately.
+func (p PolicyData) VerifyRemediation() error {
+	var remediation string
+	if p.Remediation != nil {
+		remediation = *p.Remediation
+	}
+	return verifyPolicyRemediation(p.RemediationType, remediation)
+}
+
+// VerifyLabels verifies the labels the policy is scoped to are valid. It is
+// verified once a ModifyPolicyPayload is applied, as the included and excluded
+// labels can be modified separately.
+func (p PolicyData) VerifyLabels() error {
+	return verifyPolicyLabels(p.LabelsIncludeAny, p.LabelsExcludeAny)
+}
+
+// PolicyTags is a list of policy tags stored as a JSON array.
+type PolicyTags []string
+
+// Scan implements the sql.Scanner interface
+func (t *PolicyTags) Scan(val interface{}) error {
+	switch v := val.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case nil: // sql NULL
+		return nil
+	default:
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+}
+
+// Value implements the sql.Valuer interface
+func (t PolicyTags) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t)
+}
+
 // Policy is a fleet's policy query.
 type Policy struct {
 	PolicyData
@@ -206,6 +417,25 @@ type PolicySpec struct {
 	//
 	// Empty string targets all platforms.
 	Platform string `json:"platform,omitempty"`
+	// RemediationType is the type of the remediation of the policy.
+	//
+	// Empty string means the policy has no remediation.
+	RemediationType string `json:"remediation_type,omitempty"`
+	// Remediation is the script or osquery query of the remediation.
+	Remediation string `json:"remediation,omitempty"`
+	// Severity is the severity of the policy.
+	//
+	// Empty string means the policy has no severity.
+	Severity string `json:"severity,omitempty"`
+	// Tags are free-form tags of the policy.
+	Tags []string `json:"tags,omitempty"`
+	// LabelsIncludeAny are the names of the labels the policy is scoped to.
+	//
+	// Empty means the policy applies to all hosts.
+	LabelsIncludeAny []string `json:"labels_include_any,omitempty"`
+	// LabelsExcludeAny are the names of the labels whose members the policy
+	// does not apply to.
+	LabelsExcludeAny []string `json:"labels_exclude_any,omitempty"`
 }
 
 // Verify verifies the policy data is valid.
@@ -219,6 +449,18 @@ func (p PolicySpec) Verify() error {
 	if err := verifyPolicyPlatforms(p.Platform); err != nil {
 		return err
 	}
+	if err := verifyPolicyRemediation(p.RemediationType, p.Remediation); err != nil {
+		return err
+	}
+	if err := verifyPolicySeverity(p.Severity); err != nil {
+		return err
+	}
+	if err := verifyPolicyTags(p.Tags); err != nil {
+		return err
+	}
+	if err := verifyPolicyLabels(p.LabelsIncludeAny, p.LabelsExcludeAny); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -250,4 +492,95 @@ type PolicyMembershipResult struct {
 	HostID   uint
 	PolicyID uint
 	Passes   *bool
+	// ReportedAt is the time the host reported the result, it is the time
+	// recorded in the policy results history if the result is a transition.
+	ReportedAt time.Time
+}
+
+// PolicyMembershipTransition is a change of the result of a policy on a host,
+// recorded when the host starts passing or failing the policy (including the
+// first result of the policy on the host).
+type PolicyMembershipTransition struct {
+	ID         uint      `json:"id" db:"id"`
+	PolicyID   uint      `json:"policy_id" db:"policy_id"`
+	PolicyName string    `json:"policy_name" db:"policy_name"`
+	HostID     uint      `json:"host_id" db:"host_id"`
+	Passes     bool      `json:"passes" db:"passes"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// PolicyDailyCount is the number of hosts passing and failing a policy at the
+// end of a day.
+type PolicyDailyCount struct {
+	// Date is the day (in UTC) of the counts, in the YYYY-MM-DD format.
+	Date             string `json:"date"`
+	PassingHostCount uint   `json:"passing_host_count"`
+	FailingHostCount uint   `json:"failing_host_count"`
+}
+
+// PolicyWaiver exempts hosts from a policy until it expires. Hosts failing a
+// waived policy are not counted as failing it and do not trigger the failing
+// policies automations.
+type PolicyWaiver struct {
+	UpdateCreateTimestamps
+	ID       uint `json:"id" db:"id"`
+	PolicyID uint `json:"policy_id" db:"policy_id"`
+	// HostIDs are the hosts the waiver applies to, it is empty if the waiver
+	// applies to the members of a label.
+	HostIDs []uint `json:"host_ids" db:"-"`
+	// LabelID is the label whose members the waiver applies to, it is nil if
+	// the waiver applies to a list of hosts.
+	LabelID *uint `json:"label_id" db:"label_id"`
+	// Reason explains why the hosts are exempted from the policy.
+	Reason string `json:"reason" db:"reason"`
+	// Approver is the person who approved the waiver.
+	Approver string `json:"approver" db:"approver"`
+	// ExpiresAt is the time at which the waiver stops applying, expired
+	// waivers are deleted periodically.
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	// AuthorID is the ID of the user who created the waiver, it is nil if the
+	// user was deleted.
+	AuthorID *uint `json:"author_id" db:"author_id"`
+}
+
+// PolicyWaiverFailingHost is a host that fails a policy it was exempted from
+// by a waiver.
+type PolicyWaiverFailingHost struct {
+	PolicyID    uint   `db:"policy_id"`
+	HostID      uint   `db:"host_id"`
+	Hostname    string `db:"hostname"`
+	DisplayName string `db:"display_name"`
+}
+
+// PolicyWaiverPayload holds the data to create a policy waiver.
+type PolicyWaiverPayload struct {
+	HostIDs   []uint    `json:"host_ids"`
+	LabelID   *uint     `json:"label_id"`
+	Reason    string    `json:"reason"`
+	Approver  string    `json:"approver"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var (
+	errPolicyWaiverTargets   = errors.New("policy waiver must target either a list of hosts or a label")
+	errPolicyWaiverNoReason  = errors.New("policy waiver reason cannot be empty")
+	errPolicyWaiverNoApprove = errors.New("policy waiver approver cannot be empty")
+	errPolicyWaiverExpired   = errors.New("policy waiver expiration must be in the future")
+)
+
+// Verify verifies the policy waiver payload is valid at the provided time.
+func (p PolicyWaiverPayload) Verify(now time.Time) error {
+	if (len(p.HostIDs) == 0) == (p.LabelID == nil) {
+		return errPolicyWaiverTargets
+	}
+	if emptyString(p.Reason) {
+		return errPolicyWaiverNoReason
+	}
+	if emptyString(p.Approver) {
+		return errPolicyWaiverNoApprove
+	}
+	if !p.ExpiresAt.After(now) {
+		return errPolicyWaiverExpired
+	}
+	return nil
 }
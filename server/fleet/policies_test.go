@@ -0,0 +1,81 @@
+package fleet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicySeverityAndTagsVerify(t *testing.T) {
+	payload := PolicyPayload{Name: "p", Query: "select 1;", Severity: PolicySeverityHigh, Tags: []string{"cis-1.1"}}
+	require.NoError(t, payload.Verify())
+
+	payload.Severity = "urgent"
+	require.ErrorIs(t, payload.Verify(), errPolicyInvalidSeverity)
+	payload.Severity = ""
+	payload.Tags = []string{"cis-1.1", " "}
+	require.ErrorIs(t, payload.Verify(), errPolicyEmptyTag)
+
+	severity := PolicySeverityCritical
+	modify := ModifyPolicyPayload{Severity: &severity}
+	require.NoError(t, modify.Verify())
+	severity = "CRITICAL"
+	require.ErrorIs(t, modify.Verify(), errPolicyInvalidSeverity)
+
+	spec := PolicySpec{Name: "p", Query: "select 1;", Severity: PolicySeverityLow, Tags: []string{"a", ""}}
+	require.ErrorIs(t, spec.Verify(), errPolicyEmptyTag)
+}
+
+func TestPolicyWaiverPayloadVerify(t *testing.T) {
+	now := time.Now()
+	valid := PolicyWaiverPayload{
+		HostIDs:   []uint{1},
+		Reason:    "build server",
+		Approver:  "alice",
+		ExpiresAt: now.Add(time.Hour),
+	}
+	require.NoError(t, valid.Verify(now))
+
+	p := valid
+	p.HostIDs = nil
+	require.ErrorIs(t, p.Verify(now), errPolicyWaiverTargets)
+	p.LabelID = ptr.Uint(1)
+	require.NoError(t, p.Verify(now))
+	p.HostIDs = []uint{1}
+	require.ErrorIs(t, p.Verify(now), errPolicyWaiverTargets)
+
+	p = valid
+	p.Reason = " "
+	require.ErrorIs(t, p.Verify(now), errPolicyWaiverNoReason)
+
+	p = valid
+	p.Approver = ""
+	require.ErrorIs(t, p.Verify(now), errPolicyWaiverNoApprove)
+
+	p = valid
+	p.ExpiresAt = now
+	require.ErrorIs(t, p.Verify(now), errPolicyWaiverExpired)
+}
+
+func TestPolicyLabelsVerify(t *testing.T) {
+	payload := PolicyPayload{Name: "p", Query: "select 1;", LabelsIncludeAny: []string{"a", "b"}, LabelsExcludeAny: []string{"c"}}
+	require.NoError(t, payload.Verify())
+	payload.LabelsExcludeAny = []string{"a"}
+	require.ErrorIs(t, payload.Verify(), errPolicyDuplicateLabel)
+	payload.LabelsExcludeAny = []string{" "}
+	require.ErrorIs(t, payload.Verify(), errPolicyEmptyLabel)
+
+	// the included and excluded labels of a modification are verified once
+	// applied to the policy.
+	modify := ModifyPolicyPayload{LabelsIncludeAny: &[]string{"a"}, LabelsExcludeAny: &[]string{"a"}}
+	require.NoError(t, modify.Verify())
+	modify.LabelsIncludeAny = &[]string{"a", "a"}
+	require.ErrorIs(t, modify.Verify(), errPolicyDuplicateLabel)
+	policy := PolicyData{LabelsIncludeAny: []string{"a"}, LabelsExcludeAny: []string{"a"}}
+	require.ErrorIs(t, policy.VerifyLabels(), errPolicyDuplicateLabel)
+
+	spec := PolicySpec{Name: "p", Query: "select 1;", LabelsIncludeAny: []string{""}}
+	require.ErrorIs(t, spec.Verify(), errPolicyEmptyLabel)
+}
@@ -0,0 +1,93 @@
+package fleet
+
+import (
+	"time"
+)
+
+const (
+	// ScheduledLiveQueryWebhookJobName is the name of the worker job that
+	// delivers the results of a scheduled live query run to its webhook.
+	ScheduledLiveQueryWebhookJobName = "scheduled_live_query_webhook"
+
+	// ScheduledLiveQueryDefaultResultsWindow is the default duration during
+	// which the results of a scheduled live query run are collected.
+	ScheduledLiveQueryDefaultResultsWindow = 5 * time.Minute
+
+	// ScheduledLiveQueryMaxResultsWindow is the maximum duration during which
+	// the results of a scheduled live query run can be collected.
+	ScheduledLiveQueryMaxResultsWindow = time.Hour
+)
+
+// ScheduledLiveQuery is a live query (distributed query campaign) that runs
+// once at a given time or periodically on a cron schedule. The results
+// received during the results window of a run are stored with the campaign
+// and are sent to the webhook URL, if any.
+type ScheduledLiveQuery struct {
+	UpdateCreateTimestamps
+	ID      uint   `json:"id" db:"id"`
+	Name    string `json:"name" db:"name"`
+	QueryID uint   `json:"query_id" db:"query_id"`
+	// Targets is stored as a JSON column, it is marshaled and unmarshaled by
+	// the datastore.
+	Targets HostTargets `json:"targets" db:"-"`
+	// RunAt is the time of a one-shot scheduled live query, it is nil for a
+	// recurring one.
+	RunAt *time.Time `json:"run_at" db:"run_at"`
+	// Cron is the cron expression (evaluated in UTC) of a recurring scheduled
+	// live query, it is empty for a one-shot one.
+	Cron string `json:"cron" db:"cron"`
+	// ResultsWindow is the number of seconds during which the results of a
+	// run are collected.
+	ResultsWindow uint   `json:"results_window" db:"results_window"`
+	WebhookURL    string `json:"webhook_url" db:"webhook_url"`
+	Enabled       bool   `json:"enabled" db:"enabled"`
+	// AuthorID is the user that created the scheduled live query, the
+	// queries run with the author's permissions.
+	AuthorID *uint `json:"author_id" db:"author_id"`
+	// NextRunAt is the time of the next run, nil if the scheduled live query
+	// will not run anymore.
+	NextRunAt *time.Time `json:"next_run_at" db:"next_run_at"`
+	// LastRunAt is the last time the scheduled live query was due, the run
+	// is skipped if live queries are disabled.
+	LastRunAt      *time.Time `json:"last_run_at" db:"last_run_at"`
+	LastCampaignID *uint      `json:"last_campaign_id" db:"last_campaign_id"`
+}
+
+// AuthzType implements authz.AuthzTyper.
+func (q *ScheduledLiveQuery) AuthzType() string {
+	return "scheduled_live_query"
+}
+
+// ScheduledLiveQueryRun is a run of a scheduled live query whose campaign
+// collects results until the end of the results window. The results received
+// for the campaign are stored as they arrive, and the campaign is completed
+// once the window ends.
+type ScheduledLiveQueryRun struct {
+	CampaignID           uint      `db:"campaign_id"`
+	ScheduledLiveQueryID uint      `db:"scheduled_live_query_id"`
+	EndsAt               time.Time `db:"ends_at"`
+}
+
+// ScheduledLiveQueryPayload contains the fields used to create or modify a
+// scheduled live query. Nil fields are left unchanged on modification.
+type ScheduledLiveQueryPayload struct {
+	Name    *string      `json:"name"`
+	QueryID *uint        `json:"query_id"`
+	Targets *HostTargets `json:"targets"`
+	RunAt   *time.Time   `json:"run_at"`
+	Cron    *string      `json:"cron"`
+	// ResultsWindow is in seconds, the default results window is used if it
+	// is zero.
+	ResultsWindow *uint   `json:"results_window"`
+	WebhookURL    *string `json:"webhook_url"`
+	Enabled       *bool   `json:"enabled"`
+}
+
+// ScheduledLiveQueryWebhookMessage is the body of the requests sent to the
+// webhook of a scheduled live query after a run.
+type ScheduledLiveQueryWebhookMessage struct {
+	Timestamp          time.Time                         `json:"timestamp"`
+	ScheduledLiveQuery *ScheduledLiveQuery               `json:"scheduled_live_query"`
+	CampaignID         uint                              `json:"campaign_id"`
+	Results            []*DistributedQueryCampaignResult `json:"results"`
+}
@@ -1,6 +1,8 @@
 package fleet
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"gopkg.in/guregu/null.v3"
@@ -92,3 +94,60 @@ type ScheduledQueryStats struct {
 	UserTime     int       `json:"user_time" db:"user_time"`
 	WallTime     int       `json:"wall_time" db:"wall_time"`
 }
+
+// SplitScheduledQueryLogName returns the pack and scheduled query names of a
+// scheduled query from its name in the osquery logs. Fleet schedules the
+// queries in packs, so the name is
+// "pack<delimiter><pack name><delimiter><query name>", the delimiter being the
+// pack_delimiter agent option ("/" by default). Only single-character
+// delimiters are supported.
+func SplitScheduledQueryLogName(name string) (packName, schedQueryName string, ok bool) {
+	const prefix = "pack"
+	if !strings.HasPrefix(name, prefix) || len(name) <= len(prefix) {
+		return "", "", false
+	}
+	delimiter := name[len(prefix) : len(prefix)+1]
+	// Split with a limit of 2 in case the query name includes the delimiter.
+	// Not much we can do if the pack name includes the delimiter.
+	parts := strings.SplitN(name[len(prefix)+1:], delimiter, 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// ScheduledQueryResult is a result of a scheduled query reported by a host in
+// its result logs. Only the latest results of each scheduled query are kept
+// for each host.
+type ScheduledQueryResult struct {
+	ID               uint `json:"id" db:"id"`
+	ScheduledQueryID uint `json:"scheduled_query_id" db:"scheduled_query_id"`
+	HostID           uint `json:"host_id" db:"host_id"`
+
+	// ScheduledQueryName and PackName identify the scheduled query in the
+	// result logs.
+	ScheduledQueryName string `json:"scheduled_query_name,omitempty" db:"scheduled_query_name"`
+	PackName           string `json:"pack_name,omitempty" db:"pack_name"`
+	Hostname           string `json:"hostname,omitempty" db:"hostname"`
+
+	// Action is the action of the result log: "added" or "removed" for
+	// differential results, "snapshot" for snapshot results and "batch" for
+	// batched differential results.
+	Action string `json:"action" db:"action"`
+	// Data holds the columns of a differential result, the rows of a snapshot
+	// result or the "added" and "removed" rows of a batched result.
+	Data json.RawMessage `json:"data" db:"data"`
+	// LastFetched is the time at which the query ran on the host.
+	LastFetched time.Time `json:"last_fetched" db:"last_fetched"`
+}
+
+// ScheduledQueryResultListOptions are the options to list the stored scheduled
+// query results.
+type ScheduledQueryResultListOptions struct {
+	ListOptions
+
+	// ScheduledQueryID filters the results of a scheduled query.
+	ScheduledQueryID *uint
+	// HostID filters the results of a host.
+	HostID *uint
+}
@@ -0,0 +1,33 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitScheduledQueryLogName(t *testing.T) {
+	cases := []struct {
+		name  string
+		pack  string
+		query string
+		ok    bool
+	}{
+		{"pack/Global/uptime", "Global", "uptime", true},
+		{"pack/Team: Servers/uptime", "Team: Servers", "uptime", true},
+		{"pack/Global/disk/usage", "Global", "disk/usage", true},
+		{"pack_Global_uptime", "Global", "uptime", true},
+		{"pack/Global", "", "", false},
+		{"pack", "", "", false},
+		{"uptime", "", "", false},
+		{"", "", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pack, query, ok := SplitScheduledQueryLogName(c.name)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.pack, pack)
+			assert.Equal(t, c.query, query)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package fleet
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// SCIMSchemaUser is the schema URN of the SCIM core User resource.
+	SCIMSchemaUser = "urn:ietf:params:scim:schemas:core:2.0:User"
+	// SCIMSchemaGroup is the schema URN of the SCIM core Group resource.
+	SCIMSchemaGroup = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	// SCIMSchemaListResponse is the schema URN of SCIM list responses.
+	SCIMSchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	// SCIMSchemaPatchOp is the schema URN of SCIM PATCH requests.
+	SCIMSchemaPatchOp = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	// SCIMSchemaError is the schema URN of SCIM error responses.
+	SCIMSchemaError = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// SCIMGroup is a group provisioned by an identity provider via SCIM. The
+// roles of its members are determined by the SSOSettings.SCIMGroupMappings
+// that match its display name.
+type SCIMGroup struct {
+	UpdateCreateTimestamps
+	ID          uint   `json:"id" db:"id"`
+	DisplayName string `json:"display_name" db:"display_name"`
+	ExternalID  string `json:"external_id" db:"external_id"`
+	// UserIDs is the list of IDs of the users that are members of the group.
+	UserIDs []uint `json:"user_ids" db:"-"`
+}
+
+// SCIMGroupMapping maps the members of the SCIM group with the given display
+// name to a role. The role is a global role if Team is empty, otherwise it
+// is the role on the team with that name.
+type SCIMGroupMapping struct {
+	Group string `json:"group"`
+	Team  string `json:"team,omitempty"`
+	Role  string `json:"role"`
+}
+
+// SCIMMeta is the meta attribute of SCIM resources.
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// SCIMName is the name attribute of SCIM users.
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMMultiValue is an element of a multi-valued SCIM attribute, such as the
+// emails of a user.
+type SCIMMultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMMember is a reference to a user in a group or to a group in a user.
+type SCIMMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+// SCIMUserResource is the SCIM representation of a Fleet user. The SCIM
+// userName is the email of the Fleet user.
+type SCIMUserResource struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id,omitempty"`
+	UserName    string           `json:"userName"`
+	Name        *SCIMName        `json:"name,omitempty"`
+	DisplayName string           `json:"displayName,omitempty"`
+	Emails      []SCIMMultiValue `json:"emails,omitempty"`
+	Active      *bool            `json:"active,omitempty"`
+	// Groups is read-only, group membership is managed via the Groups
+	// resource.
+	Groups []SCIMMember `json:"groups,omitempty"`
+	Meta   *SCIMMeta    `json:"meta,omitempty"`
+}
+
+// SCIMGroupResource is the SCIM representation of a SCIMGroup.
+type SCIMGroupResource struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []SCIMMember `json:"members"`
+	Meta        *SCIMMeta    `json:"meta,omitempty"`
+}
+
+// SCIMPatchOperation is an operation of a SCIM PATCH request.
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// SCIMListOptions are the options of SCIM list requests.
+type SCIMListOptions struct {
+	// Filter is a SCIM filter expression, only equality filters on a single
+	// attribute are supported (e.g. `userName eq "user@example.com"`).
+	Filter string
+	// StartIndex is the 1-based index of the first result to return.
+	StartIndex int
+	// Count is the maximum number of results to return, all results are
+	// returned if it is 0.
+	Count int
+}
+
+// SCIMUserListOptions are the options to list the users in the datastore for
+// the SCIM API.
+type SCIMUserListOptions struct {
+	// UserName, if set, only returns the user with this email.
+	UserName string
+	// Offset is the number of users to skip, and Limit the maximum number of
+	// users to return (all users are returned if it is 0).
+	Offset int
+	Limit  int
+}
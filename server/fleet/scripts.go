@@ -0,0 +1,162 @@
+package fleet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Types of script executions.
+const (
+	// ScriptExecutionTypeScript is a shell (or PowerShell on Windows) script
+	// executed by orbit.
+	ScriptExecutionTypeScript = "script"
+	// ScriptExecutionTypeOsquery is an osquery query sent to the host as a
+	// distributed query.
+	ScriptExecutionTypeOsquery = "osquery"
+)
+
+// ScriptExecutionStatus is the status of a script execution on a host.
+type ScriptExecutionStatus string
+
+// List of statuses of a script execution.
+const (
+	// ScriptExecutionPending is the status of a script execution that was not
+	// sent to the host yet.
+	ScriptExecutionPending ScriptExecutionStatus = "pending"
+	// ScriptExecutionSent is the status of a script execution that was sent to
+	// the host and for which no result was received yet.
+	ScriptExecutionSent ScriptExecutionStatus = "sent"
+	// ScriptExecutionCompleted is the status of a script execution that ran
+	// successfully (exit code 0 for a script).
+	ScriptExecutionCompleted ScriptExecutionStatus = "completed"
+	// ScriptExecutionFailed is the status of a script execution that failed to
+	// run or returned a non-zero exit code.
+	ScriptExecutionFailed ScriptExecutionStatus = "failed"
+)
+
+// ScriptExecutionMaxOutputSize is the maximum number of bytes of the output of
+// a script execution that is stored, the output is truncated past that size.
+const ScriptExecutionMaxOutputSize = 10000
+
+// HostScriptExecution is the execution of a script (or osquery action) on a
+// host.
+type HostScriptExecution struct {
+	UpdateCreateTimestamps
+	ID     uint `json:"id" db:"id"`
+	HostID uint `json:"host_id" db:"host_id"`
+	// PolicyID is the ID of the policy for which the script execution is the
+	// remediation, it is nil if the script was not queued by a policy.
+	PolicyID *uint `json:"policy_id" db:"policy_id"`
+	// Type is the type of the script execution, one of
+	// ScriptExecutionTypeScript or ScriptExecutionTypeOsquery.
+	Type     string                `json:"type" db:"type"`
+	Contents string                `json:"contents" db:"contents"`
+	Status   ScriptExecutionStatus `json:"status" db:"status"`
+	// ExitCode is the exit code of a script, it is nil if the script did not
+	// complete or for an osquery action.
+	ExitCode *int `json:"exit_code" db:"exit_code"`
+	// Output is the (possibly truncated) output of the script, or the rows
+	// returned by the osquery action encoded in JSON.
+	Output string `json:"output" db:"output"`
+
+	// TeamID is the team of the host, it is only set for authorization.
+	TeamID *uint `json:"team_id,omitempty" db:"-"`
+}
+
+// AuthzType implements authz.AuthzTyper.
+func (e *HostScriptExecution) AuthzType() string {
+	return "host_script_execution"
+}
+
+// HostScriptExecutionResult is the result of a script execution reported by a
+// host.
+type HostScriptExecutionResult struct {
+	HostID      uint `json:"-"`
+	ExecutionID uint `json:"execution_id"`
+	// ExitCode is nil if the script could not be run or timed out, the output
+	// then contains the error.
+	ExitCode *int   `json:"exit_code"`
+	Output   string `json:"output"`
+	// Status is computed by the server from the reported result.
+	Status ScriptExecutionStatus `json:"-"`
+}
+
+// TruncateScriptOutput truncates the output of a script execution to
+// ScriptExecutionMaxOutputSize bytes.
+func TruncateScriptOutput(output string) string {
+	if len(output) > ScriptExecutionMaxOutputSize {
+		return output[:ScriptExecutionMaxOutputSize]
+	}
+	return output
+}
+
+// RunScriptPayload is the payload to queue the execution of a script on a set
+// of hosts.
+type RunScriptPayload struct {
+	// Contents is the shell script to run, it is run with PowerShell on
+	// Windows hosts.
+	Contents string `json:"contents"`
+	// HostIDs and LabelIDs are the targets of the script, the script is
+	// queued for the hosts explicitly targeted and the members of the labels.
+	HostIDs  []uint `json:"host_ids"`
+	LabelIDs []uint `json:"label_ids"`
+}
+
+// ScriptSettings are the settings of the scripts that can be run on the hosts
+// without a team (in the AppConfig) or on the hosts of a team (in the
+// TeamConfig). The zero value does not allow any script to run.
+type ScriptSettings struct {
+	// Enabled allows scripts to be run on the hosts, including the script
+	// remediations of the policies.
+	Enabled bool `json:"enabled"`
+	// AllowAny allows any script to be run on the hosts instead of only the
+	// scripts of the Allowlist.
+	AllowAny bool `json:"allow_any"`
+	// Allowlist is the list of SHA-256 hashes (hex-encoded) of the scripts that
+	// can be run on the hosts. If empty, no script can be run unless AllowAny
+	// is set.
+	Allowlist []string `json:"allowlist"`
+}
+
+var errScriptsDisabled = errors.New("scripts are disabled")
+
+// Allows returns nil if the script with the provided contents can be run
+// according to the settings, otherwise it returns an error describing why it
+// cannot.
+func (s ScriptSettings) Allows(contents string) error {
+	if !s.Enabled {
+		return errScriptsDisabled
+	}
+	if s.AllowAny {
+		return nil
+	}
+	hash := ScriptHash(contents)
+	for _, allowed := range s.Allowlist {
+		if strings.EqualFold(allowed, hash) {
+			return nil
+		}
+	}
+	return fmt.Errorf("script with hash %s is not in the allowlist", hash)
+}
+
+// ValidateScriptSettings checks that the allowlist of the script settings only
+// contains valid SHA-256 hashes. It adds any error it finds to the invalid
+// argument error, that can then be checked after the call for errors using
+// invalid.HasErrors.
+func ValidateScriptSettings(settings ScriptSettings, invalid *InvalidArgumentError) {
+	for _, hash := range settings.Allowlist {
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			invalid.Append("scripts.allowlist", fmt.Sprintf("invalid SHA-256 hash: %q", hash))
+		}
+	}
+}
+
+// ScriptHash returns the hex-encoded SHA-256 hash of the contents of a script,
+// as used in the allowlist of the ScriptSettings.
+func ScriptHash(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,35 @@
+package fleet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptSettingsAllows(t *testing.T) {
+	hash := ScriptHash("echo hello")
+	require.Len(t, hash, 64)
+
+	require.ErrorIs(t, ScriptSettings{}.Allows("echo hello"), errScriptsDisabled)
+	require.ErrorIs(t, ScriptSettings{AllowAny: true}.Allows("echo hello"), errScriptsDisabled)
+	require.ErrorIs(t, ScriptSettings{Allowlist: []string{hash}}.Allows("echo hello"), errScriptsDisabled)
+
+	// an empty allowlist does not allow any script
+	require.ErrorContains(t, ScriptSettings{Enabled: true}.Allows("echo hello"), "not in the allowlist")
+	require.NoError(t, ScriptSettings{Enabled: true, AllowAny: true}.Allows("echo hello"))
+
+	allowlist := ScriptSettings{Enabled: true, Allowlist: []string{ScriptHash("echo other"), strings.ToUpper(hash)}}
+	require.NoError(t, allowlist.Allows("echo hello"))
+	require.ErrorContains(t, allowlist.Allows("echo hello "), "not in the allowlist")
+}
+
+func TestValidateScriptSettings(t *testing.T) {
+	invalid := &InvalidArgumentError{}
+	ValidateScriptSettings(ScriptSettings{Allowlist: []string{ScriptHash("echo hello")}}, invalid)
+	require.False(t, invalid.HasErrors())
+
+	invalid = &InvalidArgumentError{}
+	ValidateScriptSettings(ScriptSettings{Allowlist: []string{"abc", "zz" + ScriptHash("echo hello")[2:]}}, invalid)
+	require.Len(t, *invalid, 2)
+}
@@ -16,6 +16,9 @@ import (
 // TODO: find if there's a better way to accomplish this and standardize.
 type EnterpriseOverrides struct {
 	HostFeatures func(context context.Context, host *Host) (*Features, error)
+	// ApplyHostAssignmentRules transfers the host to the team of the first host
+	// assignment rule it matches, if any. It updates the team of the host.
+	ApplyHostAssignmentRules func(ctx context.Context, host *Host) error
 }
 
 type OsqueryService interface {
@@ -60,6 +63,13 @@ type Service interface {
 	// SetOrUpdateDeviceAuthToken creates or updates a device auth token for the given host.
 	SetOrUpdateDeviceAuthToken(ctx context.Context, authToken string) error
 
+	// GetOrbitScripts returns the pending script executions of the host and
+	// marks them as sent.
+	GetOrbitScripts(ctx context.Context) ([]*HostScriptExecution, error)
+	// SaveOrbitScriptResult stores the result of a script execution reported by
+	// the host.
+	SaveOrbitScriptResult(ctx context.Context, result HostScriptExecutionResult) error
+
 	// SetEnterpriseOverrides allows the enterprise service to override specific methods
 	// that can't be easily overridden via embedding.
 	//
@@ -154,6 +164,42 @@ type Service interface {
 	GetSessionByKey(ctx context.Context, key string) (session *Session, err error)
 	DeleteSession(ctx context.Context, id uint) (err error)
 
+	///////////////////////////////////////////////////////////////////////////////
+	// SCIMService provisions users and their roles via the SCIM 2.0 protocol.
+
+	// ListSCIMUsers returns the requested page of users matching the filter of
+	// the options, along with the total number of matching users.
+	ListSCIMUsers(ctx context.Context, opts SCIMListOptions) (users []*SCIMUserResource, total int, err error)
+	GetSCIMUser(ctx context.Context, id uint) (*SCIMUserResource, error)
+	// CreateSCIMUser creates an SSO user with the global observer role, or
+	// restores the user with the same email if it was deleted via SCIM.
+	CreateSCIMUser(ctx context.Context, user SCIMUserResource) (*SCIMUserResource, error)
+	// ReplaceSCIMUser updates the user, and disables or enables it according
+	// to its active attribute.
+	ReplaceSCIMUser(ctx context.Context, id uint, user SCIMUserResource) (*SCIMUserResource, error)
+	// PatchSCIMUser applies the PATCH operations to the user, the user is
+	// disabled if it becomes inactive.
+	PatchSCIMUser(ctx context.Context, id uint, ops []SCIMPatchOperation) (*SCIMUserResource, error)
+	// DeleteSCIMUser disables the user and removes it from the SCIM API, the
+	// user is not deleted from Fleet.
+	DeleteSCIMUser(ctx context.Context, id uint) error
+
+	// ListSCIMGroups returns the requested page of groups matching the filter
+	// of the options, along with the total number of matching groups.
+	ListSCIMGroups(ctx context.Context, opts SCIMListOptions) (groups []*SCIMGroupResource, total int, err error)
+	GetSCIMGroup(ctx context.Context, id uint) (*SCIMGroupResource, error)
+	// CreateSCIMGroup creates a group and updates the roles of its members
+	// according to the SCIM group mappings.
+	CreateSCIMGroup(ctx context.Context, group SCIMGroupResource) (*SCIMGroupResource, error)
+	// ReplaceSCIMGroup updates a group and the roles of its previous and new
+	// members according to the SCIM group mappings.
+	ReplaceSCIMGroup(ctx context.Context, id uint, group SCIMGroupResource) (*SCIMGroupResource, error)
+	// PatchSCIMGroup applies the PATCH operations to the group and updates the
+	// roles of its previous and new members.
+	PatchSCIMGroup(ctx context.Context, id uint, ops []SCIMPatchOperation) (*SCIMGroupResource, error)
+	// DeleteSCIMGroup deletes a group and updates the roles of its members.
+	DeleteSCIMGroup(ctx context.Context, id uint) error
+
 	///////////////////////////////////////////////////////////////////////////////
 	// PackService is the service interface for managing query packs.
 
@@ -257,6 +303,29 @@ type Service interface {
 	CompleteCampaign(ctx context.Context, campaign *DistributedQueryCampaign) error
 	RunLiveQueryDeadline(ctx context.Context, queryIDs []uint, hostIDs []uint, deadline time.Duration) ([]QueryCampaignResult, int)
 
+	// GetDistributedQueryCampaignResults returns the campaign and its stored results, restricted to the hosts
+	// visible by the user. The results are stored only if the live query results are enabled in the
+	// configuration.
+	GetDistributedQueryCampaignResults(ctx context.Context, campaignID uint) (*DistributedQueryCampaign, []*DistributedQueryCampaignResult, error)
+
+	///////////////////////////////////////////////////////////////////////////////
+	// ScheduledLiveQueryService
+
+	NewScheduledLiveQuery(ctx context.Context, p ScheduledLiveQueryPayload) (*ScheduledLiveQuery, error)
+	ListScheduledLiveQueries(ctx context.Context, opt ListOptions) ([]*ScheduledLiveQuery, error)
+	GetScheduledLiveQuery(ctx context.Context, id uint) (*ScheduledLiveQuery, error)
+	ModifyScheduledLiveQuery(ctx context.Context, id uint, p ScheduledLiveQueryPayload) (*ScheduledLiveQuery, error)
+	DeleteScheduledLiveQuery(ctx context.Context, id uint) error
+
+	// RunDueScheduledLiveQueries starts the campaigns of the scheduled live queries that are due at now. The
+	// results of each campaign are stored as the hosts send them during the results window of the scheduled
+	// live query. It is called by the scheduled live queries cron job, without a user in the context.
+	RunDueScheduledLiveQueries(ctx context.Context, now time.Time) error
+	// CompleteEndedScheduledLiveQueryRuns completes the campaigns of the scheduled live query runs with a
+	// results window ended at now, and queues the delivery of their results to the webhooks. It is called by
+	// the scheduled live queries cron job, without a user in the context.
+	CompleteEndedScheduledLiveQueryRuns(ctx context.Context, now time.Time) error
+
 	///////////////////////////////////////////////////////////////////////////////
 	// AgentOptionsService
 
@@ -402,6 +471,9 @@ type Service interface {
 	ScheduleQuery(ctx context.Context, sq *ScheduledQuery) (query *ScheduledQuery, err error)
 	DeleteScheduledQuery(ctx context.Context, id uint) (err error)
 	ModifyScheduledQuery(ctx context.Context, id uint, p ScheduledQueryPayload) (query *ScheduledQuery, err error)
+	// ListScheduledQueryResults lists the stored results of a scheduled query
+	// and/or a host, at least one of them must be set in the options.
+	ListScheduledQueryResults(ctx context.Context, opts ScheduledQueryResultListOptions) ([]*ScheduledQueryResult, error)
 
 	///////////////////////////////////////////////////////////////////////////////
 	// StatusService
@@ -457,6 +529,20 @@ type Service interface {
 
 	ListActivities(ctx context.Context, opt ListOptions) ([]*Activity, error)
 
+	///////////////////////////////////////////////////////////////////////////////
+	// ActivityWebhookService
+
+	// NewActivityWebhook creates an activity webhook. A secret is generated if
+	// none is provided.
+	NewActivityWebhook(ctx context.Context, p ActivityWebhookPayload) (*ActivityWebhook, error)
+	ListActivityWebhooks(ctx context.Context) ([]*ActivityWebhook, error)
+	GetActivityWebhook(ctx context.Context, id uint) (*ActivityWebhook, error)
+	ModifyActivityWebhook(ctx context.Context, id uint, p ActivityWebhookPayload) (*ActivityWebhook, error)
+	DeleteActivityWebhook(ctx context.Context, id uint) error
+	// ListActivityWebhookDeliveries returns the delivery attempts of an
+	// activity webhook.
+	ListActivityWebhookDeliveries(ctx context.Context, id uint, opt ListOptions) ([]*ActivityWebhookDelivery, error)
+
 	///////////////////////////////////////////////////////////////////////////////
 	// UserRolesService
 
@@ -502,6 +588,62 @@ type Service interface {
 	ListSoftware(ctx context.Context, opt SoftwareListOptions) ([]Software, error)
 	SoftwareByID(ctx context.Context, id uint, includeCVEScores bool) (*Software, error)
 	CountSoftware(ctx context.Context, opt SoftwareListOptions) (int, error)
+	// ListSoftwareChanges returns the software installed on and uninstalled
+	// from the hosts, optionally filtered by team, host, action and time range.
+	ListSoftwareChanges(ctx context.Context, opt SoftwareChangeListOptions) ([]*SoftwareChange, error)
+	// GenerateSBOM returns the software bill of materials document of the
+	// software inventory of a host, a team or the whole fleet, in the format.
+	GenerateSBOM(ctx context.Context, format SBOMFormat, opt SBOMOptions) ([]byte, error)
+
+	// NewSoftwareRule creates a global software rule, or a team software rule
+	// if the payload has a team.
+	NewSoftwareRule(ctx context.Context, p SoftwareRulePayload) (*SoftwareRule, error)
+	// ListSoftwareRules returns the software rules of the team, or the global
+	// rules if teamID is nil.
+	ListSoftwareRules(ctx context.Context, teamID *uint) ([]*SoftwareRule, error)
+	// ModifySoftwareRule modifies the software rule.
+	ModifySoftwareRule(ctx context.Context, id uint, p ModifySoftwareRulePayload) (*SoftwareRule, error)
+	// DeleteSoftwareRule deletes the software rule.
+	DeleteSoftwareRule(ctx context.Context, id uint) error
+
+	///////////////////////////////////////////////////////////////////////////////
+	// Host tags
+
+	// ListHostTagKeys returns the host tag keys.
+	ListHostTagKeys(ctx context.Context) ([]*HostTagKey, error)
+	// DeleteHostTagKey deletes the host tag key with the name, untagging its
+	// hosts.
+	DeleteHostTagKey(ctx context.Context, name string) error
+	// ApplyHostTagsSpec applies the host tag keys and the host tags of the spec.
+	ApplyHostTagsSpec(ctx context.Context, spec *HostTagsSpec) error
+	// SetHostTags sets the host tags of the host, unsetting the tags with a nil
+	// value, and returns the resulting tags of the host.
+	SetHostTags(ctx context.Context, hostID uint, tags HostTagValues) (map[string]string, error)
+	// SetHostTagsByFilter sets the host tags of the hosts matching the filters,
+	// unsetting the tags with a nil value.
+	SetHostTagsByFilter(ctx context.Context, tags HostTagValues, opt HostListOptions, lid *uint) error
+
+	///////////////////////////////////////////////////////////////////////////////
+	// Host duplicates
+
+	// ListHostDuplicates returns the hosts flagged as duplicates of other hosts.
+	ListHostDuplicates(ctx context.Context) ([]*HostDuplicate, error)
+	// DeleteHostDuplicate dismisses the flagged duplicate hosts, the hosts are
+	// not modified.
+	DeleteHostDuplicate(ctx context.Context, id uint) error
+	// MergeHosts merges the history of the duplicate host into the host and
+	// deletes the duplicate host.
+	MergeHosts(ctx context.Context, hostID, duplicateHostID uint) error
+
+	// SetHostsLifecycleState sets the lifecycle state of the hosts. The
+	// quarantined hosts are moved to the quarantine team, if any, and notified
+	// to the host quarantine webhook, if enabled.
+	SetHostsLifecycleState(ctx context.Context, hostIDs []uint, state HostLifecycleState) error
+
+	// ListHostVitalsTimeline returns the samples of the vitals of the host
+	// (disk space, uptime, osquery and operating system versions) that match
+	// the options.
+	ListHostVitalsTimeline(ctx context.Context, hostID uint, opt HostVitalsListOptions) ([]*HostVitals, error)
 
 	///////////////////////////////////////////////////////////////////////////////
 	// Team Policies
@@ -512,6 +654,47 @@ type Service interface {
 	ModifyTeamPolicy(ctx context.Context, teamID uint, id uint, p ModifyPolicyPayload) (*Policy, error)
 	GetTeamPolicyByIDQueries(ctx context.Context, teamID uint, policyID uint) (*Policy, error)
 
+	///////////////////////////////////////////////////////////////////////////////
+	// Compliance
+
+	// GetComplianceSummary returns the compliance score of the hosts of the
+	// team, or of all the hosts the user can see if teamID is nil. If platform
+	// is not nil, only the hosts of that platform are included.
+	GetComplianceSummary(ctx context.Context, teamID *uint, platform *string) (*ComplianceSummary, error)
+
+	///////////////////////////////////////////////////////////////////////////////
+	// Policy results history
+
+	// ListHostPolicyTimeline returns the times the host started passing or
+	// failing its policies, optionally only for the policy with policyID.
+	ListHostPolicyTimeline(ctx context.Context, hostID uint, policyID *uint, opt ListOptions) ([]*PolicyMembershipTransition, error)
+	// GetPolicyDailyCounts returns the number of hosts passing and failing the
+	// policy at the end of each of the last days, today included.
+	GetPolicyDailyCounts(ctx context.Context, policyID uint, days int) ([]*PolicyDailyCount, error)
+
+	// NewPolicyWaiver creates a waiver that exempts the hosts or the members of
+	// the label of the payload from the policy until the waiver expires.
+	NewPolicyWaiver(ctx context.Context, policyID uint, p PolicyWaiverPayload) (*PolicyWaiver, error)
+	// ListPolicyWaivers returns the waivers of the policy that did not expire.
+	ListPolicyWaivers(ctx context.Context, policyID uint) ([]*PolicyWaiver, error)
+	// DeletePolicyWaiver deletes the waiver of the policy.
+	DeletePolicyWaiver(ctx context.Context, policyID, waiverID uint) error
+
+	///////////////////////////////////////////////////////////////////////////////
+	// Host Script Executions
+
+	// ListHostScriptExecutions returns the script executions of the host, which
+	// include the executions of the remediations of failing policies.
+	ListHostScriptExecutions(ctx context.Context, hostID uint, opt ListOptions) ([]*HostScriptExecution, error)
+
+	// RunScript queues the execution of a script on the targeted hosts. The
+	// script is queued only if it is allowed on the teams of all the targeted
+	// hosts.
+	RunScript(ctx context.Context, payload RunScriptPayload) ([]*HostScriptExecution, error)
+
+	// GetScriptExecution returns the script execution with the given id.
+	GetScriptExecution(ctx context.Context, id uint) (*HostScriptExecution, error)
+
 	///////////////////////////////////////////////////////////////////////////////
 	// Geolocation
 
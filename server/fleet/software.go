@@ -2,6 +2,7 @@ package fleet
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -126,6 +127,111 @@ type SoftwareListOptions struct {
 	WithHostCounts bool
 }
 
+// SoftwareChangeAction is the type of change of the software installed on a
+// host.
+type SoftwareChangeAction string
+
+const (
+	SoftwareChangeInstalled   SoftwareChangeAction = "installed"
+	SoftwareChangeUninstalled SoftwareChangeAction = "uninstalled"
+)
+
+// IsValid returns true if the action is a known software change action.
+func (a SoftwareChangeAction) IsValid() bool {
+	return a == SoftwareChangeInstalled || a == SoftwareChangeUninstalled
+}
+
+// SoftwareChange is a change of the software installed on a host, detected
+// when the host reports its software inventory.
+type SoftwareChange struct {
+	ID     uint `json:"id" db:"id"`
+	HostID uint `json:"host_id" db:"host_id"`
+	// Hostname is the display name of the host.
+	Hostname string `json:"hostname" db:"hostname"`
+	// SoftwareID is the ID of the software, nil if it could not be resolved
+	// when the change was recorded. The software may not exist anymore if no
+	// host has it installed.
+	SoftwareID       *uint                `json:"software_id" db:"software_id"`
+	Name             string               `json:"name" db:"name"`
+	Version          string               `json:"version" db:"version"`
+	Source           string               `json:"source" db:"source"`
+	BundleIdentifier string               `json:"bundle_identifier,omitempty" db:"bundle_identifier"`
+	Vendor           string               `json:"vendor,omitempty" db:"vendor"`
+	Action           SoftwareChangeAction `json:"action" db:"action"`
+	CreatedAt        time.Time            `json:"created_at" db:"created_at"`
+}
+
+// SoftwareChangeListOptions defines the options to list the software changes.
+type SoftwareChangeListOptions struct {
+	ListOptions
+
+	// TeamID filters the changes to the hosts of the team if not nil.
+	TeamID *uint
+	// HostID filters the changes to the host if not nil.
+	HostID *uint
+	// Action filters the changes to that action if not empty.
+	Action SoftwareChangeAction
+	// From and To filter the changes to those recorded at or after From and
+	// before To, if not nil.
+	From *time.Time
+	To   *time.Time
+	// MatchRules filters the changes to the software that matches at least one
+	// of the rules, if not empty.
+	MatchRules []SoftwareMatchRule
+	// ExcludeRetiredHosts filters out the changes of the retired hosts.
+	ExcludeRetiredHosts bool
+}
+
+// SoftwareMatchRule identifies software by its name, bundle identifier or
+// vendor. Software matches the rule if it matches all the non-empty fields of
+// the rule, compared case-insensitively.
+type SoftwareMatchRule struct {
+	Name             string `json:"name,omitempty"`
+	BundleIdentifier string `json:"bundle_identifier,omitempty"`
+	Vendor           string `json:"vendor,omitempty"`
+}
+
+// IsEmpty returns true if none of the fields of the rule is set, such a rule
+// matches nothing.
+func (r SoftwareMatchRule) IsEmpty() bool {
+	return r.Name == "" && r.BundleIdentifier == "" && r.Vendor == ""
+}
+
+// Matches returns true if the software matches the rule.
+func (r SoftwareMatchRule) Matches(name, bundleIdentifier, vendor string) bool {
+	if r.IsEmpty() {
+		return false
+	}
+	return (r.Name == "" || strings.EqualFold(r.Name, name)) &&
+		(r.BundleIdentifier == "" || strings.EqualFold(r.BundleIdentifier, bundleIdentifier)) &&
+		(r.Vendor == "" || strings.EqualFold(r.Vendor, vendor))
+}
+
+// SBOMFormat is the format of a software bill of materials (SBOM) document.
+type SBOMFormat string
+
+const (
+	// SBOMFormatCycloneDX is the CycloneDX JSON format.
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx"
+	// SBOMFormatSPDX is the SPDX 2.3 JSON format.
+	SBOMFormatSPDX SBOMFormat = "spdx"
+)
+
+// IsValid returns true if the format is a known SBOM format.
+func (f SBOMFormat) IsValid() bool {
+	return f == SBOMFormatCycloneDX || f == SBOMFormatSPDX
+}
+
+// SBOMOptions defines the software inventory described by an SBOM document.
+// The inventory of the whole fleet is described if neither TeamID nor HostID
+// is set.
+type SBOMOptions struct {
+	// TeamID restricts the inventory to the hosts of the team if not nil.
+	TeamID *uint `query:"team_id,optional"`
+	// HostID restricts the inventory to the host if not nil.
+	HostID *uint `query:"host_id,optional"`
+}
+
 // SoftwareCPE represents an entry in the `software_cpe` table
 type SoftwareCPE struct {
 	ID         uint   `db:"id"`
@@ -155,4 +261,7 @@ const (
 	NVDSource VulnerabilitySource = iota
 	UbuntuOVALSource
 	RHELOVALSource
+	// OSVSource is the source of the vulnerabilities of language packages
+	// (e.g. Python and npm packages) detected with the OSV advisories.
+	OSVSource
 )
@@ -0,0 +1,259 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// SoftwareRuleAction is the action of a software rule on the software it
+// matches.
+type SoftwareRuleAction string
+
+// List of software rule actions.
+const (
+	// SoftwareRuleAllow allows the matching software, it is an exception to
+	// the deny rules of the same scope or of the global scope.
+	SoftwareRuleAllow SoftwareRuleAction = "allow"
+	// SoftwareRuleDeny denies the matching software, hosts that have it
+	// installed violate the rule.
+	SoftwareRuleDeny SoftwareRuleAction = "deny"
+)
+
+// IsValid returns true if the action is a known software rule action.
+func (a SoftwareRuleAction) IsValid() bool {
+	switch a {
+	case SoftwareRuleAllow, SoftwareRuleDeny:
+		return true
+	default:
+		return false
+	}
+}
+
+// HostSoftwareStatus is the compliance status of a host with the software
+// rules that apply to it.
+type HostSoftwareStatus string
+
+// List of host software statuses.
+const (
+	// HostSoftwareStatusDenied is the status of a host that has denied
+	// software installed.
+	HostSoftwareStatusDenied HostSoftwareStatus = "denied"
+	// HostSoftwareStatusCompliant is the status of a host that has no denied
+	// software installed.
+	HostSoftwareStatusCompliant HostSoftwareStatus = "compliant"
+)
+
+// IsValid returns true if the status is a known host software status.
+func (s HostSoftwareStatus) IsValid() bool {
+	switch s {
+	case HostSoftwareStatusDenied, HostSoftwareStatusCompliant:
+		return true
+	default:
+		return false
+	}
+}
+
+// SoftwareRule allows or denies the software that matches all its non-empty
+// criteria. A global rule (without team) applies to all hosts, a team rule
+// applies to the hosts of the team.
+type SoftwareRule struct {
+	UpdateCreateTimestamps
+	ID uint `json:"id" db:"id"`
+	// TeamID is the team the rule applies to, it is nil for a global rule.
+	TeamID      *uint              `json:"team_id" db:"team_id"`
+	Name        string             `json:"name" db:"name"`
+	Description string             `json:"description" db:"description"`
+	Action      SoftwareRuleAction `json:"action" db:"action"`
+	// SoftwareName matches the name of the software exactly (case
+	// insensitive).
+	SoftwareName string `json:"software_name" db:"software_name"`
+	// SoftwareNameRegex is a regular expression (RE2 syntax) that matches the
+	// name of the software.
+	SoftwareNameRegex string `json:"software_name_regex" db:"software_name_regex"`
+	// BundleIdentifier matches the bundle identifier of the software exactly
+	// (case insensitive).
+	BundleIdentifier string `json:"bundle_identifier" db:"bundle_identifier"`
+	// VersionRange is a semantic version constraint (e.g. ">= 1.2, < 2.0")
+	// that the version of the software must satisfy. Software whose version is
+	// not a semantic version never matches a rule with a version range.
+	VersionRange string `json:"version_range" db:"version_range"`
+	// PolicyID is the policy whose failing policies automations are triggered
+	// for the hosts that start violating a deny rule, it is nil if the rule
+	// does not trigger automations.
+	PolicyID *uint `json:"policy_id" db:"policy_id"`
+	// AuthorID is the ID of the user who created the rule, it is nil if the
+	// user was deleted.
+	AuthorID *uint `json:"author_id" db:"author_id"`
+	// ViolatingHostCount is the number of hosts violating the rule as of the
+	// last computation of the violations.
+	ViolatingHostCount uint `json:"violating_host_count" db:"violating_host_count"`
+}
+
+// AuthzType implements authz.AuthzTyper.
+func (r SoftwareRule) AuthzType() string {
+	return "software_rule"
+}
+
+var (
+	errSoftwareRuleEmptyName       = errors.New("software rule name cannot be empty")
+	errSoftwareRuleInvalidAction   = errors.New("software rule action must be either allow or deny")
+	errSoftwareRuleNoCriteria      = errors.New("software rule must match by software name, name regex or bundle identifier")
+	errSoftwareRulePolicyWithAllow = errors.New("only deny software rules can trigger a policy's automations")
+)
+
+// Verify verifies the software rule is valid.
+func (r *SoftwareRule) Verify() error {
+	if emptyString(r.Name) {
+		return errSoftwareRuleEmptyName
+	}
+	if !r.Action.IsValid() {
+		return errSoftwareRuleInvalidAction
+	}
+	if emptyString(r.SoftwareName) && emptyString(r.SoftwareNameRegex) && emptyString(r.BundleIdentifier) {
+		return errSoftwareRuleNoCriteria
+	}
+	if r.PolicyID != nil && r.Action != SoftwareRuleDeny {
+		return errSoftwareRulePolicyWithAllow
+	}
+	if _, err := NewSoftwareRuleMatcher(r); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SoftwareRulePayload holds the data to create a software rule.
+type SoftwareRulePayload struct {
+	TeamID            *uint              `json:"team_id"`
+	Name              string             `json:"name"`
+	Description       string             `json:"description"`
+	Action            SoftwareRuleAction `json:"action"`
+	SoftwareName      string             `json:"software_name"`
+	SoftwareNameRegex string             `json:"software_name_regex"`
+	BundleIdentifier  string             `json:"bundle_identifier"`
+	VersionRange      string             `json:"version_range"`
+	PolicyID          *uint              `json:"policy_id"`
+}
+
+// ModifySoftwareRulePayload holds the data to modify a software rule, only
+// the non-nil fields are modified. The team of a rule cannot be modified.
+type ModifySoftwareRulePayload struct {
+	Name              *string             `json:"name"`
+	Description       *string             `json:"description"`
+	Action            *SoftwareRuleAction `json:"action"`
+	SoftwareName      *string             `json:"software_name"`
+	SoftwareNameRegex *string             `json:"software_name_regex"`
+	BundleIdentifier  *string             `json:"bundle_identifier"`
+	VersionRange      *string             `json:"version_range"`
+	// PolicyID is the new policy of the rule, a value of 0 removes the policy.
+	PolicyID *uint `json:"policy_id"`
+}
+
+// SoftwareRuleMatcher matches software against the criteria of a software
+// rule.
+type SoftwareRuleMatcher struct {
+	Rule *SoftwareRule
+
+	nameRegex *regexp.Regexp
+	versions  *semver.Constraints
+}
+
+// NewSoftwareRuleMatcher compiles the regular expression and version range of
+// the rule, it returns an error if either is invalid.
+func NewSoftwareRuleMatcher(rule *SoftwareRule) (*SoftwareRuleMatcher, error) {
+	m := &SoftwareRuleMatcher{Rule: rule}
+	if rule.SoftwareNameRegex != "" {
+		re, err := regexp.Compile(rule.SoftwareNameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid software name regex: %w", err)
+		}
+		m.nameRegex = re
+	}
+	if rule.VersionRange != "" {
+		c, err := semver.NewConstraint(rule.VersionRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range: %w", err)
+		}
+		m.versions = c
+	}
+	return m, nil
+}
+
+// Matches returns true if the software matches all the non-empty criteria of
+// the rule.
+func (m *SoftwareRuleMatcher) Matches(s *Software) bool {
+	if m.Rule.SoftwareName != "" && !strings.EqualFold(m.Rule.SoftwareName, s.Name) {
+		return false
+	}
+	if m.Rule.BundleIdentifier != "" && !strings.EqualFold(m.Rule.BundleIdentifier, s.BundleIdentifier) {
+		return false
+	}
+	if m.nameRegex != nil && !m.nameRegex.MatchString(s.Name) {
+		return false
+	}
+	if m.versions != nil {
+		v, err := semver.NewVersion(s.Version)
+		if err != nil || !m.versions.Check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// SoftwareRuleSet is the set of software rules that apply to a group of
+// hosts, i.e. the global rules and the rules of the hosts' team.
+type SoftwareRuleSet struct {
+	allow []*SoftwareRuleMatcher
+	deny  []*SoftwareRuleMatcher
+}
+
+// NewSoftwareRuleSet compiles the rules into a rule set.
+func NewSoftwareRuleSet(rules []*SoftwareRule) (*SoftwareRuleSet, error) {
+	var set SoftwareRuleSet
+	for _, r := range rules {
+		m, err := NewSoftwareRuleMatcher(r)
+		if err != nil {
+			return nil, fmt.Errorf("software rule %d: %w", r.ID, err)
+		}
+		if r.Action == SoftwareRuleAllow {
+			set.allow = append(set.allow, m)
+		} else {
+			set.deny = append(set.deny, m)
+		}
+	}
+	return &set, nil
+}
+
+// DenyingRules returns the deny rules that match the software. It returns nil
+// if an allow rule matches the software, as allow rules are exceptions to the
+// deny rules.
+func (s *SoftwareRuleSet) DenyingRules(sw *Software) []*SoftwareRule {
+	var denying []*SoftwareRule
+	for _, m := range s.deny {
+		if m.Matches(sw) {
+			denying = append(denying, m.Rule)
+		}
+	}
+	if len(denying) == 0 {
+		return nil
+	}
+	for _, m := range s.allow {
+		if m.Matches(sw) {
+			return nil
+		}
+	}
+	return denying
+}
+
+// SoftwareRuleViolation is a host that started violating a software rule.
+type SoftwareRuleViolation struct {
+	RuleID uint `db:"rule_id"`
+	// PolicyID is the policy linked to the rule, if any.
+	PolicyID    *uint  `db:"policy_id"`
+	HostID      uint   `db:"host_id"`
+	Hostname    string `db:"hostname"`
+	DisplayName string `db:"display_name"`
+}
@@ -0,0 +1,61 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftwareRuleVerify(t *testing.T) {
+	cases := []struct {
+		name    string
+		rule    SoftwareRule
+		wantErr string
+	}{
+		{"valid name", SoftwareRule{Name: "r", Action: SoftwareRuleDeny, SoftwareName: "zoom.us"}, ""},
+		{"valid regex and range", SoftwareRule{Name: "r", Action: SoftwareRuleAllow, SoftwareNameRegex: "^zoom", VersionRange: ">= 5.0, < 6"}, ""},
+		{"valid policy", SoftwareRule{Name: "r", Action: SoftwareRuleDeny, BundleIdentifier: "us.zoom.xos", PolicyID: ptr.Uint(1)}, ""},
+		{"empty name", SoftwareRule{Name: " ", Action: SoftwareRuleDeny, SoftwareName: "zoom.us"}, "name cannot be empty"},
+		{"invalid action", SoftwareRule{Name: "r", Action: "block", SoftwareName: "zoom.us"}, "action must be"},
+		{"no criteria", SoftwareRule{Name: "r", Action: SoftwareRuleDeny, VersionRange: "< 5"}, "must match by"},
+		{"invalid regex", SoftwareRule{Name: "r", Action: SoftwareRuleDeny, SoftwareNameRegex: "zoom("}, "invalid software name regex"},
+		{"invalid range", SoftwareRule{Name: "r", Action: SoftwareRuleDeny, SoftwareName: "zoom.us", VersionRange: "about 5"}, "invalid version range"},
+		{"policy on allow", SoftwareRule{Name: "r", Action: SoftwareRuleAllow, SoftwareName: "zoom.us", PolicyID: ptr.Uint(1)}, "only deny"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.rule.Verify()
+			if c.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestSoftwareRuleSet(t *testing.T) {
+	torrents := &SoftwareRule{ID: 1, Action: SoftwareRuleDeny, SoftwareNameRegex: "(?i)torrent"}
+	oldZoom := &SoftwareRule{ID: 2, Action: SoftwareRuleDeny, BundleIdentifier: "us.zoom.xos", VersionRange: "< 5.0"}
+	utorrent := &SoftwareRule{ID: 3, Action: SoftwareRuleAllow, SoftwareName: "utorrent"}
+
+	set, err := NewSoftwareRuleSet([]*SoftwareRule{torrents, oldZoom})
+	require.NoError(t, err)
+	require.Equal(t, []*SoftwareRule{torrents}, set.DenyingRules(&Software{Name: "uTorrent", Version: "3.5"}))
+	require.Equal(t, []*SoftwareRule{torrents}, set.DenyingRules(&Software{Name: "qBittorrent"}))
+	require.Equal(t, []*SoftwareRule{oldZoom}, set.DenyingRules(&Software{Name: "zoom.us", Version: "4.9.1", BundleIdentifier: "US.ZOOM.XOS"}))
+	require.Nil(t, set.DenyingRules(&Software{Name: "zoom.us", Version: "5.0.0", BundleIdentifier: "us.zoom.xos"}))
+	// versions that are not semantic versions never match a version range
+	require.Nil(t, set.DenyingRules(&Software{Name: "zoom.us", Version: "4.9.1.2", BundleIdentifier: "us.zoom.xos"}))
+	require.Nil(t, set.DenyingRules(&Software{Name: "slack"}))
+
+	// allow rules are exceptions to the deny rules
+	set, err = NewSoftwareRuleSet([]*SoftwareRule{torrents, oldZoom, utorrent})
+	require.NoError(t, err)
+	require.Nil(t, set.DenyingRules(&Software{Name: "uTorrent", Version: "3.5"}))
+	require.Equal(t, []*SoftwareRule{torrents}, set.DenyingRules(&Software{Name: "qBittorrent"}))
+
+	_, err = NewSoftwareRuleSet([]*SoftwareRule{{ID: 4, Action: SoftwareRuleDeny, SoftwareNameRegex: "("}})
+	require.ErrorContains(t, err, "software rule 4")
+}
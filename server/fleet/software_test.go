@@ -0,0 +1,28 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftwareMatchRule(t *testing.T) {
+	cases := []struct {
+		rule    SoftwareMatchRule
+		name    string
+		bundle  string
+		vendor  string
+		matches bool
+	}{
+		{SoftwareMatchRule{}, "Dropbox", "", "", false},
+		{SoftwareMatchRule{Name: "dropbox"}, "Dropbox", "", "", true},
+		{SoftwareMatchRule{Name: "dropbox"}, "Dropbox Beta", "", "", false},
+		{SoftwareMatchRule{BundleIdentifier: "com.getdropbox.dropbox"}, "Dropbox", "com.getdropbox.dropbox", "", true},
+		{SoftwareMatchRule{Name: "Dropbox", BundleIdentifier: "com.getdropbox.dropbox"}, "Dropbox", "", "", false},
+		{SoftwareMatchRule{Vendor: "BitTorrent Inc."}, "torrent", "", "bittorrent inc.", true},
+		{SoftwareMatchRule{Vendor: "BitTorrent Inc."}, "torrent", "", "", false},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.matches, c.rule.Matches(c.name, c.bundle, c.vendor), "%+v", c)
+	}
+}
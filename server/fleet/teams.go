@@ -19,6 +19,7 @@ type TeamPayload struct {
 	Secrets         []*EnrollSecret      `json:"secrets"`
 	WebhookSettings *TeamWebhookSettings `json:"webhook_settings"`
 	Integrations    *TeamIntegrations    `json:"integrations"`
+	Scripts         *ScriptSettings      `json:"scripts"`
 	// Note AgentOptions must be set by a separate endpoint.
 }
 
@@ -123,6 +124,10 @@ type TeamConfig struct {
 	WebhookSettings TeamWebhookSettings `json:"webhook_settings"`
 	Integrations    TeamIntegrations    `json:"integrations"`
 	Features        Features            `json:"features"`
+	Scripts         ScriptSettings      `json:"scripts"`
+	// HostAssignmentRules are the rules that automatically assign hosts to the
+	// team, see HostAssignmentRule.
+	HostAssignmentRules []HostAssignmentRule `json:"host_assignment_rules,omitempty"`
 }
 
 type TeamWebhookSettings struct {
@@ -254,4 +259,8 @@ type TeamSpec struct {
 	AgentOptions *json.RawMessage `json:"agent_options"`
 	Secrets      []EnrollSecret   `json:"secrets"`
 	Features     *json.RawMessage `json:"features"`
+	Scripts      *ScriptSettings  `json:"scripts"`
+	// HostAssignmentRules replace the host assignment rules of the team if
+	// provided, they are left unchanged otherwise.
+	HostAssignmentRules *[]HostAssignmentRule `json:"host_assignment_rules"`
 }
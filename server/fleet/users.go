@@ -27,6 +27,9 @@ type User struct {
 	SSOEnabled bool    `json:"sso_enabled" db:"sso_enabled"`
 	GlobalRole *string `json:"global_role" db:"global_role"`
 	APIOnly    bool    `json:"api_only" db:"api_only"`
+	// Disabled if true, the user cannot log in. Users are disabled when they
+	// are deactivated or deleted via SCIM.
+	Disabled bool `json:"disabled" db:"disabled"`
 
 	// Teams is the teams this user has roles in. For users with a global role, Teams is expected to be empty.
 	Teams []UserTeam `json:"teams"`
@@ -0,0 +1,243 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/pkg/fleethttp"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	// See
+	// https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-bulk.html
+	// for documentation on sizing bulk requests, the default http.max_content_length
+	// is 100MB.
+	elasticsearchMaxRecordsInBatch = 1000
+	elasticsearchMaxSizeOfBatch    = 5 * 1000 * 1000 // 5 MB
+)
+
+type ElasticsearchParams struct {
+	URL               string
+	Username          string
+	Password          string
+	APIKey            string
+	Index             string
+	EnableCompression bool
+	Timeout           time.Duration
+}
+
+type elasticsearchLogWriter struct {
+	client   *http.Client
+	baseURL  string
+	username string
+	password string
+	apiKey   string
+	index    string
+	compress bool
+	logger   log.Logger
+
+	maxRecordsInBatch int
+	maxSizeOfBatch    int
+	maxRetries        int
+	backoff           time.Duration
+}
+
+// elasticsearchBulkResponse is the subset of the _bulk API response used to
+// find the documents that failed to be indexed.
+type elasticsearchBulkResponse struct {
+	Errors bool `json:"errors"`
+	// Items contains one object per document, keyed by the action name.
+	Items []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// NewElasticsearchLogWriter creates a writer that indexes the logs in
+// Elasticsearch using the _bulk API.
+func NewElasticsearchLogWriter(p *ElasticsearchParams, logger log.Logger) (*elasticsearchLogWriter, error) {
+	if p.URL == "" || p.Index == "" {
+		return nil, fmt.Errorf("elasticsearch url and index are required")
+	}
+	e := &elasticsearchLogWriter{
+		client:            fleethttp.NewClient(fleethttp.WithTimeout(p.Timeout)),
+		baseURL:           strings.TrimSuffix(p.URL, "/"),
+		username:          p.Username,
+		password:          p.Password,
+		apiKey:            p.APIKey,
+		index:             p.Index,
+		compress:          p.EnableCompression,
+		logger:            logger,
+		maxRecordsInBatch: elasticsearchMaxRecordsInBatch,
+		maxSizeOfBatch:    elasticsearchMaxSizeOfBatch,
+		maxRetries:        httpLogMaxRetries,
+		backoff:           httpLogBackoff,
+	}
+	if err := e.checkCluster(); err != nil {
+		return nil, fmt.Errorf("create Elasticsearch writer: %w", err)
+	}
+	return e, nil
+}
+
+func (e *elasticsearchLogWriter) checkCluster() error {
+	req, err := http.NewRequest(http.MethodGet, e.baseURL+"/", nil)
+	if err != nil {
+		return fmt.Errorf("elasticsearch new request: %w", err)
+	}
+	e.authenticate(req)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("elasticsearch cluster check: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+func (e *elasticsearchLogWriter) authenticate(req *http.Request) {
+	switch {
+	case e.apiKey != "":
+		req.Header.Set("Authorization", "ApiKey "+e.apiKey)
+	case e.username != "":
+		req.SetBasicAuth(e.username, e.password)
+	}
+}
+
+func (e *elasticsearchLogWriter) Write(ctx context.Context, logs []json.RawMessage) error {
+	action, err := json.Marshal(map[string]interface{}{
+		// create works for both regular indices and data streams
+		"create": map[string]string{"_index": e.index},
+	})
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "marshal elasticsearch action")
+	}
+
+	var records [][]byte
+	totalBytes := 0
+	for _, log := range logs {
+		// Each document must be on a single line of the request.
+		var doc bytes.Buffer
+		if err := json.Compact(&doc, log); err != nil {
+			level.Info(e.logger).Log("msg", "dropping invalid JSON log", "err", err)
+			continue
+		}
+
+		record := make([]byte, 0, len(action)+doc.Len()+2)
+		record = append(record, action...)
+		record = append(record, '\n')
+		record = append(record, doc.Bytes()...)
+		record = append(record, '\n')
+
+		if len(record) > e.maxSizeOfBatch {
+			level.Info(e.logger).Log(
+				"msg", "dropping log over Elasticsearch batch size limit",
+				"size", len(record),
+				"log", string(log[:100])+"...",
+			)
+			continue
+		}
+
+		if len(records) >= e.maxRecordsInBatch ||
+			totalBytes+len(record) > e.maxSizeOfBatch {
+			if err := e.bulk(ctx, records); err != nil {
+				return ctxerr.Wrap(ctx, err, "bulk index")
+			}
+			totalBytes = 0
+			records = nil
+		}
+
+		records = append(records, record)
+		totalBytes += len(record)
+	}
+
+	// Push the final batch
+	if len(records) > 0 {
+		if err := e.bulk(ctx, records); err != nil {
+			return ctxerr.Wrap(ctx, err, "bulk index")
+		}
+	}
+
+	return nil
+}
+
+// bulk indexes the records, retrying the ones rejected because Elasticsearch
+// is overloaded (429 or 5xx status), it returns an error if some of them are
+// still rejected once the retries are exhausted. Documents rejected for other
+// reasons (e.g. a mapping error) would be rejected again, they are logged and
+// dropped.
+func (e *elasticsearchLogWriter) bulk(ctx context.Context, records [][]byte) error {
+	var dropped int
+	var firstErr string
+	err := retryWithBackoff(ctx, e.maxRetries, e.backoff, func() error {
+		res, err := e.sendBulk(ctx, records)
+		if err != nil {
+			return err
+		}
+		if !res.Errors {
+			return nil
+		}
+		if len(res.Items) != len(records) {
+			return fmt.Errorf("unexpected number of items in bulk response: %d, expected %d", len(res.Items), len(records))
+		}
+
+		var retry [][]byte
+		for i, item := range res.Items {
+			for _, result := range item {
+				switch {
+				case result.Status < http.StatusMultipleChoices:
+				case isRetryableStatus(result.Status):
+					retry = append(retry, records[i])
+				default:
+					dropped++
+					if firstErr == "" && result.Error != nil {
+						firstErr = result.Error.Type + ": " + result.Error.Reason
+					}
+				}
+			}
+		}
+		records = retry
+		if len(retry) > 0 {
+			return &retryableError{err: fmt.Errorf("%d documents rejected, retries exhausted", len(retry))}
+		}
+		return nil
+	})
+	if dropped > 0 {
+		level.Info(e.logger).Log(
+			"msg", "dropping logs rejected by Elasticsearch",
+			"count", dropped,
+			"first_err", firstErr,
+		)
+	}
+	return err
+}
+
+func (e *elasticsearchLogWriter) sendBulk(ctx context.Context, records [][]byte) (*elasticsearchBulkResponse, error) {
+	req, err := newLogsRequest(ctx, e.baseURL+"/_bulk", "application/x-ndjson", bytes.Join(records, nil), e.compress)
+	if err != nil {
+		return nil, err
+	}
+	e.authenticate(req)
+
+	body, err := sendLogsRequest(e.client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res elasticsearchBulkResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("unmarshal bulk response: %w", err)
+	}
+	return &res, nil
+}
@@ -0,0 +1,209 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeElasticsearchWriter(url string) *elasticsearchLogWriter {
+	return &elasticsearchLogWriter{
+		client:            http.DefaultClient,
+		baseURL:           url,
+		apiKey:            "key",
+		index:             "osquery_result",
+		compress:          true,
+		logger:            log.NewNopLogger(),
+		maxRecordsInBatch: elasticsearchMaxRecordsInBatch,
+		maxSizeOfBatch:    elasticsearchMaxSizeOfBatch,
+		maxRetries:        httpLogMaxRetries,
+		backoff:           time.Millisecond,
+	}
+}
+
+// readElasticsearchDocs decodes the bulk request body, checking the actions and
+// returning the documents.
+func readElasticsearchDocs(t *testing.T, r *http.Request) []string {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body = zr
+	}
+
+	var docs []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		assert.Equal(t, `{"create":{"_index":"osquery_result"}}`, scanner.Text())
+		require.True(t, scanner.Scan())
+		docs = append(docs, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	return docs
+}
+
+// bulkResponse returns a _bulk API response with one item per status.
+func bulkResponse(statuses ...int) string {
+	var items []string
+	hasErrors := false
+	for _, status := range statuses {
+		if status >= 300 {
+			hasErrors = true
+			items = append(items, fmt.Sprintf(`{"create":{"status":%d,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}`, status))
+			continue
+		}
+		items = append(items, fmt.Sprintf(`{"create":{"status":%d}}`, status))
+	}
+	return fmt.Sprintf(`{"took":1,"errors":%t,"items":[%s]}`, hasErrors, strings.Join(items, ","))
+}
+
+func TestElasticsearchWrite(t *testing.T) {
+	ctx := context.Background()
+
+	var docs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, "ApiKey key", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		got := readElasticsearchDocs(t, r)
+		docs = append(docs, got...)
+		statuses := make([]int, len(got))
+		for i := range statuses {
+			statuses[i] = http.StatusCreated
+		}
+		w.Write([]byte(bulkResponse(statuses...))) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	writer := makeElasticsearchWriter(srv.URL)
+	// documents are compacted to fit on a single line
+	require.NoError(t, writer.Write(ctx, append(logs, json.RawMessage("{\n  \"a\": 1\n}"))))
+	require.Equal(t, []string{`{"foo":"bar"}`, `{"flim":"flam"}`, `{"jim":"jom"}`, `{"a":1}`}, docs)
+
+	// batches are limited by number of records
+	docs = nil
+	writer.maxRecordsInBatch = 2
+	require.NoError(t, writer.Write(ctx, logs))
+	require.Len(t, docs, len(logs))
+}
+
+func TestElasticsearchRetryRejectedDocuments(t *testing.T) {
+	ctx := context.Background()
+
+	var requests [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		docs := readElasticsearchDocs(t, r)
+		requests = append(requests, docs)
+		switch len(requests) {
+		case 1:
+			// the whole request is rejected
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			// the second document is rejected
+			w.Write([]byte(bulkResponse(http.StatusCreated, http.StatusTooManyRequests, http.StatusCreated))) //nolint:errcheck
+		default:
+			w.Write([]byte(bulkResponse(http.StatusCreated))) //nolint:errcheck
+		}
+	}))
+	defer srv.Close()
+
+	writer := makeElasticsearchWriter(srv.URL)
+	writer.compress = false
+	require.NoError(t, writer.Write(ctx, logs))
+	require.Len(t, requests, 3)
+	assert.Len(t, requests[0], 3)
+	assert.Len(t, requests[1], 3)
+	assert.Equal(t, []string{`{"flim":"flam"}`}, requests[2])
+}
+
+func TestElasticsearchNonRetryableFailure(t *testing.T) {
+	ctx := context.Background()
+
+	callCount := 0
+	unauthorized := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		if unauthorized {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(bulkResponse(http.StatusCreated, http.StatusBadRequest, http.StatusCreated))) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	// the documents rejected with a non-retryable status are dropped
+	var logBuf bytes.Buffer
+	writer := makeElasticsearchWriter(srv.URL)
+	writer.logger = log.NewLogfmtLogger(&logBuf)
+	require.NoError(t, writer.Write(ctx, logs))
+	assert.Equal(t, 1, callCount)
+	assert.Contains(t, logBuf.String(), "dropping logs rejected by Elasticsearch")
+	assert.Contains(t, logBuf.String(), "count=1")
+	assert.Contains(t, logBuf.String(), "mapper_parsing_exception")
+
+	callCount = 0
+	unauthorized = true
+	err := writer.Write(ctx, logs)
+	require.Error(t, err)
+	assert.Equal(t, 1, callCount)
+}
+
+func TestElasticsearchRetryableFailure(t *testing.T) {
+	ctx := context.Background()
+
+	callCount := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		// the first document is always rejected
+		statuses := []int{http.StatusServiceUnavailable}
+		for range readElasticsearchDocs(t, r)[1:] {
+			statuses = append(statuses, http.StatusCreated)
+		}
+		w.Write([]byte(bulkResponse(statuses...))) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	writer := makeElasticsearchWriter(srv.URL)
+	writer.maxRetries = 2
+	err := writer.Write(ctx, logs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 documents rejected, retries exhausted")
+	assert.Equal(t, 3, callCount)
+}
+
+func TestElasticsearchClusterCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "elastic" || pass != "changeme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"tagline":"You Know, for Search"}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	params := &ElasticsearchParams{URL: srv.URL, Username: "elastic", Password: "changeme", Index: "osquery_result", Timeout: time.Second}
+	_, err := NewElasticsearchLogWriter(params, log.NewNopLogger())
+	require.NoError(t, err)
+
+	params.Password = "wrong"
+	_, err = NewElasticsearchLogWriter(params, log.NewNopLogger())
+	require.Error(t, err)
+
+	_, err = NewElasticsearchLogWriter(&ElasticsearchParams{URL: srv.URL}, log.NewNopLogger())
+	require.Error(t, err)
+}
@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry and backoff settings shared by the HTTP-based log writers (splunk and
+// elasticsearch).
+const (
+	httpLogMaxRetries = 8
+	httpLogBackoff    = 100 * time.Millisecond
+	// httpLogMaxRetryAfter caps the delay requested by the destination via the
+	// Retry-After header.
+	httpLogMaxRetryAfter = time.Minute
+)
+
+// retryableError is returned by the HTTP-based log writers when the request
+// failed but can be retried, typically because the destination is
+// unavailable or overloaded.
+type retryableError struct {
+	err error
+	// retryAfter is the delay requested by the destination before retrying,
+	// if any.
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// retryWithBackoff calls fn until it succeeds, it returns a non-retryable error
+// or maxRetries retries have been done. It sleeps with exponential backoff
+// between attempts, so that a slow destination slows down the writes of the
+// logs instead of having them pile up in memory.
+func retryWithBackoff(ctx context.Context, maxRetries int, backoff time.Duration, fn func() error) error {
+	for try := 0; ; try++ {
+		err := fn()
+		var rerr *retryableError
+		if err == nil || !errors.As(err, &rerr) || try >= maxRetries {
+			return err
+		}
+
+		wait := backoff * time.Duration(math.Pow(2.0, float64(try)))
+		if rerr.retryAfter > wait {
+			wait = rerr.retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// newLogsRequest creates the POST request that sends body to url, compressing
+// it with gzip if compress is true.
+func newLogsRequest(ctx context.Context, url, contentType string, body []byte, compress bool) (*http.Request, error) {
+	var r io.Reader = bytes.NewReader(body)
+	if compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return nil, fmt.Errorf("gzip logs: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("gzip logs: %w", err)
+		}
+		r = &buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, r)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req, nil
+}
+
+// sendLogsRequest sends the request and returns the body of the response. Errors
+// that can be retried (network errors, 429 and 5xx status codes) are returned
+// as retryableError.
+func sendLogsRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		return nil, &retryableError{err: fmt.Errorf("send logs: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &retryableError{err: fmt.Errorf("read response: %w", err)}
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		err := fmt.Errorf("status %d: %s", resp.StatusCode, truncate(body, 512))
+		if isRetryableStatus(resp.StatusCode) {
+			return nil, &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+		}
+		return nil, err
+	}
+	return body, nil
+}
+
+// isRetryableStatus returns true if a request that failed with this status
+// code can be retried.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// parseRetryAfter parses the Retry-After header value in seconds, it returns 0
+// if it is not set or invalid.
+func parseRetryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	d := time.Duration(secs) * time.Second
+	if d > httpLogMaxRetryAfter {
+		d = httpLogMaxRetryAfter
+	}
+	return d
+}
+
+func truncate(b []byte, n int) string {
+	if len(b) > n {
+		return string(b[:n]) + "..."
+	}
+	return string(b)
+}
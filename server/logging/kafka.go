@@ -0,0 +1,242 @@
+package logging
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/config"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const (
+	kafkaMaxRecordsInBatch = 1000
+	// kafkaBatchTimeout is the time the producer waits for more messages
+	// before sending a batch. The writes are synchronous and the batches are
+	// built by Write, so there is no point in waiting long.
+	kafkaBatchTimeout = 10 * time.Millisecond
+)
+
+type KafkaParams struct {
+	// Brokers is a comma-separated list of host:port.
+	Brokers       string
+	Topic         string
+	RequiredAcks  int
+	Compression   string
+	BatchBytes    int
+	Timeout       time.Duration
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+	UseTLS        bool
+	TLSCert       string
+	TLSKey        string
+	TLSCA         string
+	TLSServerName string
+}
+
+// kafkaMessageWriter is the subset of the kafka.Writer methods used by the
+// log writer.
+type kafkaMessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type kafkaLogWriter struct {
+	writer kafkaMessageWriter
+	topic  string
+	logger log.Logger
+
+	maxRecordsInBatch int
+	maxSizeOfBatch    int
+}
+
+// NewKafkaLogWriter creates a writer that produces the logs to a Kafka topic,
+// talking to the brokers directly. Each log is a message keyed by the host
+// identifier of the log (if any), so that the logs of a host go to the same
+// partition.
+func NewKafkaLogWriter(p *KafkaParams, logger log.Logger) (*kafkaLogWriter, error) {
+	var brokers []string
+	for _, b := range strings.Split(p.Brokers, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	if len(brokers) == 0 || p.Topic == "" {
+		return nil, fmt.Errorf("kafka brokers and topic are required")
+	}
+
+	compression, err := kafkaCompression(p.Compression)
+	if err != nil {
+		return nil, err
+	}
+	switch kafka.RequiredAcks(p.RequiredAcks) {
+	case kafka.RequireNone, kafka.RequireOne, kafka.RequireAll:
+	default:
+		return nil, fmt.Errorf("invalid kafka required acks: %d", p.RequiredAcks)
+	}
+	mechanism, err := kafkaSASLMechanism(p.SASLMechanism, p.SASLUsername, p.SASLPassword)
+	if err != nil {
+		return nil, err
+	}
+	if p.BatchBytes <= 0 {
+		return nil, fmt.Errorf("invalid kafka batch bytes: %d", p.BatchBytes)
+	}
+
+	var tlsConfig *tls.Config
+	if p.UseTLS {
+		tlsConf := config.TLS{
+			TLSCert:       p.TLSCert,
+			TLSKey:        p.TLSKey,
+			TLSCA:         p.TLSCA,
+			TLSServerName: p.TLSServerName,
+		}
+		tlsConfig, err = tlsConf.ToTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("kafka tls config: %w", err)
+		}
+	}
+
+	transport := &kafka.Transport{
+		TLS:  tlsConfig,
+		SASL: mechanism,
+	}
+	if err := checkKafkaTopic(brokers, p.Topic, transport, p.Timeout); err != nil {
+		return nil, fmt.Errorf("create Kafka writer: %w", err)
+	}
+
+	return &kafkaLogWriter{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        p.Topic,
+			Balancer:     &kafka.Hash{},
+			BatchSize:    kafkaMaxRecordsInBatch,
+			BatchBytes:   int64(p.BatchBytes),
+			BatchTimeout: kafkaBatchTimeout,
+			WriteTimeout: p.Timeout,
+			RequiredAcks: kafka.RequiredAcks(p.RequiredAcks),
+			Compression:  compression,
+			Transport:    transport,
+		},
+		topic:             p.Topic,
+		logger:            logger,
+		maxRecordsInBatch: kafkaMaxRecordsInBatch,
+		maxSizeOfBatch:    p.BatchBytes,
+	}, nil
+}
+
+func kafkaCompression(name string) (kafka.Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unknown kafka compression: %s", name)
+	}
+}
+
+func kafkaSASLMechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unknown kafka sasl mechanism: %s", name)
+	}
+}
+
+func checkKafkaTopic(brokers []string, topic string, transport *kafka.Transport, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := &kafka.Client{
+		Addr:      kafka.TCP(brokers...),
+		Transport: transport,
+	}
+	resp, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return fmt.Errorf("kafka topic check: %w", err)
+	}
+	for _, t := range resp.Topics {
+		if t.Name == topic {
+			if t.Error != nil {
+				return fmt.Errorf("kafka topic check %s: %w", topic, t.Error)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("kafka topic %s not found", topic)
+}
+
+// kafkaLogHost is used to extract the host identifier of the osquery logs.
+type kafkaLogHost struct {
+	HostIdentifier string `json:"hostIdentifier"`
+}
+
+func (k *kafkaLogWriter) Write(ctx context.Context, logs []json.RawMessage) error {
+	var msgs []kafka.Message
+	totalBytes := 0
+	for _, log := range logs {
+		// Logs that are too big for a batch would be rejected by the
+		// brokers, the beginning bytes of the log should help the Fleet admin
+		// diagnose the query generating huge results.
+		if len(log) > k.maxSizeOfBatch {
+			preview := log
+			if len(preview) > 100 {
+				preview = preview[:100]
+			}
+			level.Info(k.logger).Log(
+				"msg", "dropping log over Kafka batch size limit",
+				"size", len(log),
+				"log", string(preview)+"...",
+			)
+			continue
+		}
+
+		if len(msgs) >= k.maxRecordsInBatch ||
+			totalBytes+len(log) > k.maxSizeOfBatch {
+			if err := k.writer.WriteMessages(ctx, msgs...); err != nil {
+				return ctxerr.Wrap(ctx, err, "write kafka messages")
+			}
+			totalBytes = 0
+			msgs = nil
+		}
+
+		msg := kafka.Message{Value: log}
+		var host kafkaLogHost
+		if err := json.Unmarshal(log, &host); err == nil && host.HostIdentifier != "" {
+			msg.Key = []byte(host.HostIdentifier)
+		}
+		msgs = append(msgs, msg)
+		totalBytes += len(log)
+	}
+
+	// Push the final batch
+	if len(msgs) > 0 {
+		if err := k.writer.WriteMessages(ctx, msgs...); err != nil {
+			return ctxerr.Wrap(ctx, err, "write kafka messages")
+		}
+	}
+
+	return nil
+}
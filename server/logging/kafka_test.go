@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockKafkaWriter struct {
+	batches [][]kafka.Message
+	err     error
+}
+
+func (m *mockKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	m.batches = append(m.batches, msgs)
+	return m.err
+}
+
+func makeKafkaWriterWithMock(w kafkaMessageWriter) *kafkaLogWriter {
+	return &kafkaLogWriter{
+		writer:            w,
+		topic:             "osquery_result",
+		logger:            log.NewNopLogger(),
+		maxRecordsInBatch: kafkaMaxRecordsInBatch,
+		maxSizeOfBatch:    1000,
+	}
+}
+
+func TestKafkaWrite(t *testing.T) {
+	ctx := context.Background()
+	m := &mockKafkaWriter{}
+	writer := makeKafkaWriterWithMock(m)
+
+	hostLogs := []json.RawMessage{
+		json.RawMessage(`{"name":"q1","hostIdentifier":"host-1","columns":{"a":"b"}}`),
+		json.RawMessage(`{"name":"q1","hostIdentifier":"host-2","columns":{"a":"c"}}`),
+		json.RawMessage(`{"name":"q2","hostIdentifier":"host-1","columns":{"a":"d"}}`),
+	}
+	require.NoError(t, writer.Write(ctx, append(hostLogs, logs...)))
+
+	require.Len(t, m.batches, 1)
+	msgs := m.batches[0]
+	require.Len(t, msgs, 6)
+	// the partition key is the host identifier of the log, if any
+	assert.Equal(t, []byte("host-1"), msgs[0].Key)
+	assert.Equal(t, []byte("host-2"), msgs[1].Key)
+	assert.Equal(t, []byte("host-1"), msgs[2].Key)
+	for i, msg := range msgs[3:] {
+		assert.Nil(t, msg.Key)
+		assert.Equal(t, []byte(logs[i]), msg.Value)
+	}
+}
+
+func TestKafkaBatches(t *testing.T) {
+	ctx := context.Background()
+	m := &mockKafkaWriter{}
+	writer := makeKafkaWriterWithMock(m)
+	// fits two of the test logs
+	writer.maxSizeOfBatch = 30
+
+	tooBig := json.RawMessage(`{"data":"` + string(bytes.Repeat([]byte("a"), 200)) + `"}`)
+	require.NoError(t, writer.Write(ctx, append(logs, tooBig)))
+	require.Len(t, m.batches, 2)
+	assert.Len(t, m.batches[0], 2)
+	assert.Len(t, m.batches[1], 1)
+
+	// logs over the batch size limit that are shorter than the logged
+	// preview are dropped too
+	m.batches = nil
+	writer.maxSizeOfBatch = 10
+	require.NoError(t, writer.Write(ctx, []json.RawMessage{json.RawMessage(`{"data":"aaaa"}`)}))
+	require.Empty(t, m.batches)
+
+	m.batches = nil
+	writer.maxSizeOfBatch = 1000
+	writer.maxRecordsInBatch = 1
+	require.NoError(t, writer.Write(ctx, logs))
+	require.Len(t, m.batches, 3)
+}
+
+func TestKafkaWriteFailure(t *testing.T) {
+	ctx := context.Background()
+	m := &mockKafkaWriter{err: errors.New("not enough replicas")}
+	writer := makeKafkaWriterWithMock(m)
+	writer.maxRecordsInBatch = 1
+
+	err := writer.Write(ctx, logs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not enough replicas")
+	// the remaining batches are not written
+	require.Len(t, m.batches, 1)
+}
+
+func TestKafkaParams(t *testing.T) {
+	_, err := NewKafkaLogWriter(&KafkaParams{Topic: "t"}, log.NewNopLogger())
+	require.ErrorContains(t, err, "brokers and topic are required")
+
+	_, err = NewKafkaLogWriter(&KafkaParams{Brokers: "localhost:9092", Topic: "t", Compression: "brotli"}, log.NewNopLogger())
+	require.ErrorContains(t, err, "unknown kafka compression")
+
+	_, err = NewKafkaLogWriter(&KafkaParams{Brokers: "localhost:9092", Topic: "t", RequiredAcks: 2}, log.NewNopLogger())
+	require.ErrorContains(t, err, "invalid kafka required acks")
+
+	_, err = NewKafkaLogWriter(&KafkaParams{Brokers: "localhost:9092", Topic: "t", SASLMechanism: "gssapi"}, log.NewNopLogger())
+	require.ErrorContains(t, err, "unknown kafka sasl mechanism")
+
+	_, err = NewKafkaLogWriter(&KafkaParams{Brokers: "localhost:9092", Topic: "t"}, log.NewNopLogger())
+	require.ErrorContains(t, err, "invalid kafka batch bytes: 0")
+
+	for _, name := range []string{"plain", "scram-sha-256", "SCRAM-SHA-512"} {
+		mechanism, err := kafkaSASLMechanism(name, "user", "pass")
+		require.NoError(t, err)
+		require.NotNil(t, mechanism)
+	}
+}
@@ -1,5 +1,5 @@
 // Package logging provides logger "plugins" for writing osquery status and
-// result logs to various destinations.
+// result logs, and Fleet audit logs, to various destinations.
 package logging
 
 import (
@@ -14,178 +14,203 @@ import (
 type OsqueryLogger struct {
 	Status fleet.JSONLogger
 	Result fleet.JSONLogger
+	// Audit receives the Fleet activities, it is nil if the audit log is
+	// disabled.
+	Audit fleet.JSONLogger
 }
 
 func New(config config.FleetConfig, logger log.Logger) (*OsqueryLogger, error) {
-	var status, result fleet.JSONLogger
-	var err error
+	status, err := newLogWriter(config.Osquery.StatusLogPlugin, statusLogKind, config, logger)
+	if err != nil {
+		return nil, err
+	}
 
-	switch config.Osquery.StatusLogPlugin {
-	case "":
-		// Allow "" to mean filesystem for backwards compatibility
-		level.Info(logger).Log("msg", "osquery_status_log_plugin not explicitly specified. Assuming 'filesystem'")
-		fallthrough
-	case "filesystem":
-		status, err = NewFilesystemLogWriter(
-			config.Filesystem.StatusLogFile,
-			logger,
-			config.Filesystem.EnableLogRotation,
-			config.Filesystem.EnableLogCompression,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("create filesystem status logger: %w", err)
-		}
-	case "firehose":
-		status, err = NewFirehoseLogWriter(
-			config.Firehose.Region,
-			config.Firehose.EndpointURL,
-			config.Firehose.AccessKeyID,
-			config.Firehose.SecretAccessKey,
-			config.Firehose.StsAssumeRoleArn,
-			config.Firehose.StatusStream,
-			logger,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("create firehose status logger: %w", err)
-		}
-	case "kinesis":
-		status, err = NewKinesisLogWriter(
-			config.Kinesis.Region,
-			config.Kinesis.EndpointURL,
-			config.Kinesis.AccessKeyID,
-			config.Kinesis.SecretAccessKey,
-			config.Kinesis.StsAssumeRoleArn,
-			config.Kinesis.StatusStream,
-			logger,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("create kinesis status logger: %w", err)
-		}
-	case "lambda":
-		status, err = NewLambdaLogWriter(
-			config.Lambda.Region,
-			config.Lambda.AccessKeyID,
-			config.Lambda.SecretAccessKey,
-			config.Lambda.StsAssumeRoleArn,
-			config.Lambda.StatusFunction,
-			logger,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("create lambda status logger: %w", err)
-		}
-	case "pubsub":
-		status, err = NewPubSubLogWriter(
-			config.PubSub.Project,
-			config.PubSub.StatusTopic,
-			false,
-			logger,
-		)
+	result, err := newLogWriter(config.Osquery.ResultLogPlugin, resultLogKind, config, logger)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Osquery.ResultLogRoutes) > 0 {
+		result, err = newRoutingLogWriter(result, config, logger)
 		if err != nil {
-			return nil, fmt.Errorf("create pubsub status logger: %w", err)
+			return nil, err
 		}
-	case "stdout":
-		status, err = NewStdoutLogWriter()
-		if err != nil {
-			return nil, fmt.Errorf("create stdout status logger: %w", err)
+	}
+
+	var audit fleet.JSONLogger
+	if config.Activity.EnableAuditLog {
+		if config.Activity.AuditLogPlugin == "" {
+			return nil, fmt.Errorf("unknown audit log plugin: %s", config.Activity.AuditLogPlugin)
 		}
-	case "kafkarest":
-		status, err = NewKafkaRESTWriter(&KafkaRESTParams{
-			KafkaProxyHost:        config.KafkaREST.ProxyHost,
-			KafkaTopic:            config.KafkaREST.StatusTopic,
-			KafkaContentTypeValue: config.KafkaREST.ContentTypeValue,
-			KafkaTimeout:          config.KafkaREST.Timeout,
-		})
+		audit, err = newLogWriter(config.Activity.AuditLogPlugin, auditLogKind, config, logger)
 		if err != nil {
-			return nil, fmt.Errorf("create kafka rest status logger: %w", err)
+			return nil, err
 		}
+	}
+
+	return &OsqueryLogger{Status: status, Result: result, Audit: audit}, nil
+}
+
+// logKind is the kind of logs written by a log writer, it selects the
+// destination of the logs in the configuration of the plugins.
+type logKind string
+
+const (
+	statusLogKind logKind = "status"
+	resultLogKind logKind = "result"
+	auditLogKind  logKind = "audit"
+)
+
+// destination returns the destination setting of the plugin for the kind of
+// logs.
+func (k logKind) destination(status, result, audit string) string {
+	switch k {
+	case statusLogKind:
+		return status
+	case resultLogKind:
+		return result
 	default:
-		return nil, fmt.Errorf(
-			"unknown status log plugin: %s", config.Osquery.StatusLogPlugin,
-		)
+		return audit
 	}
+}
 
-	switch config.Osquery.ResultLogPlugin {
+// newLogWriter creates the writer of the plugin for the kind of logs, using
+// the destination of that kind of logs in the plugin configuration.
+func newLogWriter(plugin string, kind logKind, config config.FleetConfig, logger log.Logger) (fleet.JSONLogger, error) {
+	var writer fleet.JSONLogger
+	var err error
+
+	name := plugin
+	switch plugin {
 	case "":
 		// Allow "" to mean filesystem for backwards compatibility
-		level.Info(logger).Log("msg", "osquery_result_log_plugin not explicitly specified. Assuming 'filesystem'")
+		level.Info(logger).Log("msg", fmt.Sprintf("osquery_%s_log_plugin not explicitly specified. Assuming 'filesystem'", kind))
+		name = "filesystem"
 		fallthrough
 	case "filesystem":
-		result, err = NewFilesystemLogWriter(
-			config.Filesystem.ResultLogFile,
+		writer, err = NewFilesystemLogWriter(
+			kind.destination(config.Filesystem.StatusLogFile, config.Filesystem.ResultLogFile, config.Filesystem.AuditLogFile),
 			logger,
 			config.Filesystem.EnableLogRotation,
 			config.Filesystem.EnableLogCompression,
 		)
-		if err != nil {
-			return nil, fmt.Errorf("create filesystem result logger: %w", err)
-		}
 	case "firehose":
-		result, err = NewFirehoseLogWriter(
+		writer, err = NewFirehoseLogWriter(
 			config.Firehose.Region,
 			config.Firehose.EndpointURL,
 			config.Firehose.AccessKeyID,
 			config.Firehose.SecretAccessKey,
 			config.Firehose.StsAssumeRoleArn,
-			config.Firehose.ResultStream,
+			kind.destination(config.Firehose.StatusStream, config.Firehose.ResultStream, config.Firehose.AuditStream),
 			logger,
 		)
-		if err != nil {
-			return nil, fmt.Errorf("create firehose result logger: %w", err)
-		}
 	case "kinesis":
-		result, err = NewKinesisLogWriter(
+		writer, err = NewKinesisLogWriter(
 			config.Kinesis.Region,
 			config.Kinesis.EndpointURL,
 			config.Kinesis.AccessKeyID,
 			config.Kinesis.SecretAccessKey,
 			config.Kinesis.StsAssumeRoleArn,
-			config.Kinesis.ResultStream,
+			kind.destination(config.Kinesis.StatusStream, config.Kinesis.ResultStream, config.Kinesis.AuditStream),
 			logger,
 		)
-		if err != nil {
-			return nil, fmt.Errorf("create kinesis result logger: %w", err)
-		}
 	case "lambda":
-		result, err = NewLambdaLogWriter(
+		writer, err = NewLambdaLogWriter(
 			config.Lambda.Region,
 			config.Lambda.AccessKeyID,
 			config.Lambda.SecretAccessKey,
 			config.Lambda.StsAssumeRoleArn,
-			config.Lambda.ResultFunction,
+			kind.destination(config.Lambda.StatusFunction, config.Lambda.ResultFunction, config.Lambda.AuditFunction),
 			logger,
 		)
-		if err != nil {
-			return nil, fmt.Errorf("create lambda result logger: %w", err)
-		}
 	case "pubsub":
-		result, err = NewPubSubLogWriter(
+		writer, err = NewPubSubLogWriter(
 			config.PubSub.Project,
-			config.PubSub.ResultTopic,
-			config.PubSub.AddAttributes,
+			kind.destination(config.PubSub.StatusTopic, config.PubSub.ResultTopic, config.PubSub.AuditTopic),
+			// the attributes are only added to the result logs
+			kind == resultLogKind && config.PubSub.AddAttributes,
 			logger,
 		)
-		if err != nil {
-			return nil, fmt.Errorf("create pubsub result logger: %w", err)
-		}
 	case "stdout":
-		result, err = NewStdoutLogWriter()
-		if err != nil {
-			return nil, fmt.Errorf("create stdout result logger: %w", err)
-		}
+		writer, err = NewStdoutLogWriter()
 	case "kafkarest":
-		result, err = NewKafkaRESTWriter(&KafkaRESTParams{
+		name = "kafka rest"
+		writer, err = NewKafkaRESTWriter(&KafkaRESTParams{
 			KafkaProxyHost:        config.KafkaREST.ProxyHost,
-			KafkaTopic:            config.KafkaREST.ResultTopic,
+			KafkaTopic:            kind.destination(config.KafkaREST.StatusTopic, config.KafkaREST.ResultTopic, config.KafkaREST.AuditTopic),
 			KafkaContentTypeValue: config.KafkaREST.ContentTypeValue,
 			KafkaTimeout:          config.KafkaREST.Timeout,
 		})
-		if err != nil {
-			return nil, fmt.Errorf("create kafka rest result logger: %w", err)
-		}
+	case "kafka":
+		writer, err = NewKafkaLogWriter(&KafkaParams{
+			Brokers:       config.Kafka.Brokers,
+			Topic:         kind.destination(config.Kafka.StatusTopic, config.Kafka.ResultTopic, config.Kafka.AuditTopic),
+			RequiredAcks:  config.Kafka.RequiredAcks,
+			Compression:   config.Kafka.Compression,
+			BatchBytes:    config.Kafka.BatchBytes,
+			Timeout:       config.Kafka.Timeout,
+			SASLMechanism: config.Kafka.SASLMechanism,
+			SASLUsername:  config.Kafka.SASLUsername,
+			SASLPassword:  config.Kafka.SASLPassword,
+			UseTLS:        config.Kafka.UseTLS,
+			TLSCert:       config.Kafka.TLSCert,
+			TLSKey:        config.Kafka.TLSKey,
+			TLSCA:         config.Kafka.TLSCA,
+			TLSServerName: config.Kafka.TLSServerName,
+		}, logger)
+	case "splunk":
+		writer, err = NewSplunkLogWriter(&SplunkParams{
+			URL:               config.Splunk.URL,
+			Token:             config.Splunk.Token,
+			Index:             kind.destination(config.Splunk.StatusIndex, config.Splunk.ResultIndex, config.Splunk.AuditIndex),
+			Source:            config.Splunk.Source,
+			SourceType:        config.Splunk.SourceType,
+			EnableCompression: config.Splunk.EnableCompression,
+			Timeout:           config.Splunk.Timeout,
+		}, logger)
+	case "elasticsearch":
+		writer, err = NewElasticsearchLogWriter(&ElasticsearchParams{
+			URL:               config.Elasticsearch.URL,
+			Username:          config.Elasticsearch.Username,
+			Password:          config.Elasticsearch.Password,
+			APIKey:            config.Elasticsearch.APIKey,
+			Index:             kind.destination(config.Elasticsearch.StatusIndex, config.Elasticsearch.ResultIndex, config.Elasticsearch.AuditIndex),
+			EnableCompression: config.Elasticsearch.EnableCompression,
+			Timeout:           config.Elasticsearch.Timeout,
+		}, logger)
 	default:
-		return nil, fmt.Errorf(
-			"unknown result log plugin: %s", config.Osquery.ResultLogPlugin,
-		)
+		return nil, fmt.Errorf("unknown %s log plugin: %s", kind, plugin)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s %s logger: %w", name, kind, err)
+	}
+
+	return writer, nil
+}
+
+// newRoutingLogWriter creates the writer that routes the result logs to the
+// destinations of the result log routes, falling back to the default writer
+// for the logs matching none of them. Routes with the same plugin share a
+// writer.
+func newRoutingLogWriter(fallback fleet.JSONLogger, config config.FleetConfig, logger log.Logger) (fleet.JSONLogger, error) {
+	defaultPlugin := config.Osquery.ResultLogPlugin
+	if defaultPlugin == "" {
+		defaultPlugin = "filesystem"
+	}
+
+	router := &routingLogWriter{writers: []fleet.JSONLogger{fallback}, fallback: 0}
+	writerIdx := map[string]int{defaultPlugin: 0}
+	for _, route := range config.Osquery.ResultLogRoutes {
+		idx, ok := writerIdx[route.Plugin]
+		if !ok {
+			writer, err := newLogWriter(route.Plugin, resultLogKind, config, logger)
+			if err != nil {
+				return nil, fmt.Errorf("result log route: %w", err)
+			}
+			router.writers = append(router.writers, writer)
+			idx = len(router.writers) - 1
+			writerIdx[route.Plugin] = idx
+		}
+		router.routes = append(router.routes, newLogRoute(idx, route.Queries, route.Packs, route.TeamIDs))
 	}
-	return &OsqueryLogger{Status: status, Result: result}, nil
+	return router, nil
 }
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/config"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuditLogger(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.TestConfig()
+	cfg.Filesystem.StatusLogFile = filepath.Join(dir, "status")
+	cfg.Filesystem.ResultLogFile = filepath.Join(dir, "result")
+	cfg.Filesystem.AuditLogFile = filepath.Join(dir, "audit")
+
+	// disabled by default
+	lgr, err := New(cfg, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NotNil(t, lgr.Status)
+	require.NotNil(t, lgr.Result)
+	require.Nil(t, lgr.Audit)
+
+	cfg.Activity.EnableAuditLog = true
+	cfg.Activity.AuditLogPlugin = "filesystem"
+	lgr, err = New(cfg, log.NewNopLogger())
+	require.NoError(t, err)
+	require.IsType(t, &filesystemLogWriter{}, lgr.Audit)
+
+	cfg.Activity.AuditLogPlugin = "stdout"
+	lgr, err = New(cfg, log.NewNopLogger())
+	require.NoError(t, err)
+	require.IsType(t, &stdoutLogWriter{}, lgr.Audit)
+
+	cfg.Activity.AuditLogPlugin = "unknown"
+	_, err = New(cfg, log.NewNopLogger())
+	require.ErrorContains(t, err, "unknown audit log plugin")
+}
+
+func TestNewResultLogRoutes(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.TestConfig()
+	cfg.Filesystem.StatusLogFile = filepath.Join(dir, "status")
+	cfg.Filesystem.ResultLogFile = filepath.Join(dir, "result")
+	cfg.Osquery.ResultLogPlugin = "filesystem"
+	cfg.Osquery.ResultLogRoutes = []config.ResultLogRoute{
+		{Plugin: "stdout", Queries: []string{"processes"}},
+		{Plugin: "stdout", Packs: []string{"Security"}},
+		{Plugin: "filesystem", TeamIDs: []uint{1}},
+	}
+
+	lgr, err := New(cfg, log.NewNopLogger())
+	require.NoError(t, err)
+	router, ok := lgr.Result.(*routingLogWriter)
+	require.True(t, ok)
+	// the routes with the same plugin share the writer, the default plugin
+	// is shared with the fallback
+	require.Len(t, router.writers, 2)
+	require.IsType(t, &filesystemLogWriter{}, router.writers[router.fallback])
+	require.Len(t, router.routes, 3)
+	require.Equal(t, router.routes[0].writer, router.routes[1].writer)
+	require.Equal(t, router.fallback, router.routes[2].writer)
+
+	cfg.Osquery.ResultLogRoutes = []config.ResultLogRoute{{Plugin: "unknown"}}
+	_, err = New(cfg, log.NewNopLogger())
+	require.ErrorContains(t, err, "unknown result log plugin")
+}
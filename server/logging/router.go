@@ -0,0 +1,231 @@
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+
+	hostctx "github.com/fleetdm/fleet/v4/server/contexts/host"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+// logRoute is a destination of the logs along with the rules that select
+// the logs written to it. A nil rule set matches everything.
+type logRoute struct {
+	// writer is the index of the destination in the writers of the router.
+	writer  int
+	queries map[string]struct{}
+	packs   map[string]struct{}
+	teamIDs map[uint]struct{}
+}
+
+func newLogRoute(writer int, queries, packs []string, teamIDs []uint) logRoute {
+	r := logRoute{writer: writer}
+	if len(queries) > 0 {
+		r.queries = make(map[string]struct{}, len(queries))
+		for _, q := range queries {
+			r.queries[q] = struct{}{}
+		}
+	}
+	if len(packs) > 0 {
+		r.packs = make(map[string]struct{}, len(packs))
+		for _, p := range packs {
+			r.packs[p] = struct{}{}
+		}
+	}
+	if len(teamIDs) > 0 {
+		r.teamIDs = make(map[uint]struct{}, len(teamIDs))
+		for _, id := range teamIDs {
+			r.teamIDs[id] = struct{}{}
+		}
+	}
+	return r
+}
+
+func (r logRoute) matches(query, pack string, teamID uint) bool {
+	if r.queries != nil {
+		if _, ok := r.queries[query]; !ok {
+			return false
+		}
+	}
+	if r.packs != nil {
+		if _, ok := r.packs[pack]; !ok {
+			return false
+		}
+	}
+	if r.teamIDs != nil {
+		if _, ok := r.teamIDs[teamID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	// deliveredLogsTTL is how long the logs delivered to a destination during a
+	// partially failed write are remembered, it covers the retries of osquery.
+	deliveredLogsTTL = time.Hour
+	// maxDeliveredLogs is the maximum number of delivered logs remembered, the
+	// logs delivered once it is reached may be written again on retry.
+	maxDeliveredLogs = 100000
+)
+
+type deliveredLog struct {
+	writer int
+	sum    [sha256.Size]byte
+}
+
+// deliveredLogs remembers the logs written to a destination during a write
+// that failed for another destination. osquery retries all the logs of a
+// failed write, so those logs are skipped for that destination on retry. The
+// logs are remembered by the Fleet instance that received them, a retry
+// served by another instance may write them again.
+type deliveredLogs struct {
+	mu   sync.Mutex
+	logs map[deliveredLog]time.Time
+}
+
+func (d *deliveredLogs) add(writer int, logs []json.RawMessage, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.logs == nil {
+		d.logs = make(map[deliveredLog]time.Time)
+	}
+	if len(d.logs)+len(logs) > maxDeliveredLogs {
+		d.removeExpiredLocked(now)
+	}
+	for _, log := range logs {
+		if len(d.logs) >= maxDeliveredLogs {
+			return
+		}
+		d.logs[deliveredLog{writer: writer, sum: sha256.Sum256(log)}] = now.Add(deliveredLogsTTL)
+	}
+}
+
+// filter returns the logs that were not delivered to the writer.
+func (d *deliveredLogs) filter(writer int, logs []json.RawMessage, now time.Time) []json.RawMessage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.logs) == 0 {
+		return logs
+	}
+	d.removeExpiredLocked(now)
+
+	filtered := logs[:0:0]
+	for _, log := range logs {
+		if _, ok := d.logs[deliveredLog{writer: writer, sum: sha256.Sum256(log)}]; ok {
+			continue
+		}
+		filtered = append(filtered, log)
+	}
+	return filtered
+}
+
+func (d *deliveredLogs) removeExpiredLocked(now time.Time) {
+	for k, expires := range d.logs {
+		if !now.Before(expires) {
+			delete(d.logs, k)
+		}
+	}
+}
+
+// routingLogWriter writes each result log to the destinations of all the routes
+// it matches, and to the fallback destination if it matches none. Each log is
+// written at most once to a destination, including when the logs are retried
+// after a write that failed only for some destinations.
+type routingLogWriter struct {
+	writers []fleet.JSONLogger
+	routes  []logRoute
+	// fallback is the index of the default destination in writers.
+	fallback int
+
+	delivered deliveredLogs
+}
+
+// resultLogName is used to extract the name of the scheduled query of the
+// result logs.
+type resultLogName struct {
+	Name string `json:"name"`
+}
+
+func (r *routingLogWriter) Write(ctx context.Context, logs []json.RawMessage) error {
+	// all the logs of a request come from the same host
+	var teamID uint
+	if host, ok := hostctx.FromContext(ctx); ok && host.TeamID != nil {
+		teamID = *host.TeamID
+	}
+
+	batches := make([][]json.RawMessage, len(r.writers))
+	matched := make([]bool, len(r.writers))
+	for _, log := range logs {
+		var name resultLogName
+		// logs that cannot be parsed only match the routes without query and
+		// pack rules
+		_ = json.Unmarshal(log, &name)
+		pack, query, ok := fleet.SplitScheduledQueryLogName(name.Name)
+		if !ok {
+			pack, query = "", name.Name
+		}
+
+		for i := range matched {
+			matched[i] = false
+		}
+		routed := false
+		for _, route := range r.routes {
+			if !route.matches(query, pack, teamID) {
+				continue
+			}
+			routed = true
+			if !matched[route.writer] {
+				matched[route.writer] = true
+				batches[route.writer] = append(batches[route.writer], log)
+			}
+		}
+		if !routed {
+			batches[r.fallback] = append(batches[r.fallback], log)
+		}
+	}
+
+	now := time.Now()
+	for i := range batches {
+		batches[i] = r.delivered.filter(i, batches[i], now)
+	}
+
+	// write to the destinations concurrently so that a slow destination does
+	// not delay the others
+	var wg sync.WaitGroup
+	errs := make([]error, len(batches))
+	for i, batch := range batches {
+		if len(batch) == 0 {
+			continue
+		}
+		i, writer, batch := i, r.writers[i], batch
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = writer.Write(ctx, batch)
+		}()
+	}
+	wg.Wait()
+
+	var err error
+	for _, e := range errs {
+		if e != nil {
+			err = e
+			break
+		}
+	}
+	if err != nil {
+		// the logs will be retried, remember the destinations that succeeded
+		for i, batch := range batches {
+			if errs[i] == nil && len(batch) > 0 {
+				r.delivered.add(i, batch, now)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	hostctx "github.com/fleetdm/fleet/v4/server/contexts/host"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLogWriter struct {
+	mu   sync.Mutex
+	logs []string
+	err  error
+}
+
+func (m *mockLogWriter) Write(ctx context.Context, logs []json.RawMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, log := range logs {
+		m.logs = append(m.logs, string(log))
+	}
+	return m.err
+}
+
+func TestRoutingLogWriter(t *testing.T) {
+	fallback, security, team := &mockLogWriter{}, &mockLogWriter{}, &mockLogWriter{}
+	router := &routingLogWriter{
+		writers:  []fleet.JSONLogger{fallback, security, team},
+		fallback: 0,
+		routes: []logRoute{
+			newLogRoute(1, []string{"processes", "listening_ports"}, nil, nil),
+			newLogRoute(1, nil, []string{"Security"}, nil),
+			newLogRoute(2, []string{"uptime"}, nil, []uint{1}),
+		},
+	}
+
+	processes := json.RawMessage(`{"name":"pack/Global/processes"}`)
+	security1 := json.RawMessage(`{"name":"pack/Security/processes"}`)
+	security2 := json.RawMessage(`{"name":"pack/Security/users"}`)
+	uptime := json.RawMessage(`{"name":"pack/Global/uptime"}`)
+	invalid := json.RawMessage(`not json`)
+	all := []json.RawMessage{processes, security1, security2, uptime, invalid}
+
+	// host without team
+	ctx := hostctx.NewContext(context.Background(), &fleet.Host{ID: 1})
+	require.NoError(t, router.Write(ctx, all))
+	// a log matching multiple routes of the same destination is written once
+	assert.Equal(t, []string{string(processes), string(security1), string(security2)}, security.logs)
+	assert.Empty(t, team.logs)
+	assert.Equal(t, []string{string(uptime), string(invalid)}, fallback.logs)
+
+	fallback.logs, security.logs = nil, nil
+	ctx = hostctx.NewContext(context.Background(), &fleet.Host{ID: 2, TeamID: ptr.Uint(1)})
+	require.NoError(t, router.Write(ctx, all))
+	assert.Equal(t, []string{string(processes), string(security1), string(security2)}, security.logs)
+	assert.Equal(t, []string{string(uptime)}, team.logs)
+	assert.Equal(t, []string{string(invalid)}, fallback.logs)
+
+	// route by team only, hosts without team are in team 0
+	fallback.logs, security.logs, team.logs = nil, nil, nil
+	router.routes = []logRoute{newLogRoute(2, nil, nil, []uint{0})}
+	require.NoError(t, router.Write(context.Background(), all))
+	assert.Len(t, team.logs, len(all))
+	assert.Empty(t, fallback.logs)
+}
+
+func TestRoutingLogWriterFailure(t *testing.T) {
+	fallback, failing := &mockLogWriter{}, &mockLogWriter{err: errors.New("destination down")}
+	router := &routingLogWriter{
+		writers:  []fleet.JSONLogger{fallback, failing},
+		fallback: 0,
+		routes:   []logRoute{newLogRoute(1, []string{"processes"}, nil, nil)},
+	}
+
+	err := router.Write(context.Background(), []json.RawMessage{
+		json.RawMessage(`{"name":"pack/Global/processes"}`),
+		json.RawMessage(`{"name":"pack/Global/uptime"}`),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "destination down")
+	// the other destinations are still written
+	assert.Len(t, fallback.logs, 1)
+}
+
+func TestRoutingLogWriterPartialFailureRetry(t *testing.T) {
+	fallback, failing := &mockLogWriter{}, &mockLogWriter{err: errors.New("destination down")}
+	router := &routingLogWriter{
+		writers:  []fleet.JSONLogger{fallback, failing},
+		fallback: 0,
+		routes: []logRoute{
+			newLogRoute(0, []string{"processes", "uptime"}, nil, nil),
+			newLogRoute(1, []string{"processes"}, nil, nil),
+		},
+	}
+
+	processes := json.RawMessage(`{"name":"pack/Global/processes"}`)
+	uptime := json.RawMessage(`{"name":"pack/Global/uptime"}`)
+	users := json.RawMessage(`{"name":"pack/Global/users"}`)
+
+	require.Error(t, router.Write(context.Background(), []json.RawMessage{processes, uptime}))
+	assert.Equal(t, []string{string(processes), string(uptime)}, fallback.logs)
+
+	// osquery retries the logs of the failed write along with new logs, the
+	// destination that succeeded only receives the new logs
+	failing.logs, failing.err = nil, nil
+	require.NoError(t, router.Write(context.Background(), []json.RawMessage{processes, uptime, users}))
+	assert.Equal(t, []string{string(processes), string(uptime), string(users)}, fallback.logs)
+	assert.Equal(t, []string{string(processes)}, failing.logs)
+
+	// the delivered logs expire
+	fallback.logs = nil
+	router.delivered.removeExpiredLocked(time.Now().Add(deliveredLogsTTL))
+	require.NoError(t, router.Write(context.Background(), []json.RawMessage{uptime}))
+	assert.Equal(t, []string{string(uptime)}, fallback.logs)
+}
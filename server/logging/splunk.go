@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/pkg/fleethttp"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	splunkEventPath  = "/services/collector/event"
+	splunkHealthPath = "/services/collector/health"
+
+	// The default max_content_length of the HTTP Event Collector is 800MB, but
+	// smaller batches keep the memory usage and the cost of a retry low.
+	splunkMaxSizeOfBatch = 1000 * 1000 // 1 MB
+)
+
+type SplunkParams struct {
+	URL               string
+	Token             string
+	Index             string
+	Source            string
+	SourceType        string
+	EnableCompression bool
+	Timeout           time.Duration
+}
+
+type splunkLogWriter struct {
+	client     *http.Client
+	url        string
+	healthURL  string
+	token      string
+	index      string
+	source     string
+	sourceType string
+	compress   bool
+	logger     log.Logger
+
+	maxSizeOfBatch int
+	maxRetries     int
+	backoff        time.Duration
+}
+
+// splunkEvent is the HTTP Event Collector event format, see
+// https://docs.splunk.com/Documentation/Splunk/latest/Data/FormateventsforHTTPEventCollector.
+type splunkEvent struct {
+	Index      string          `json:"index,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	SourceType string          `json:"sourcetype,omitempty"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// NewSplunkLogWriter creates a writer that sends the logs to the Splunk HTTP
+// Event Collector.
+func NewSplunkLogWriter(p *SplunkParams, logger log.Logger) (*splunkLogWriter, error) {
+	if p.URL == "" || p.Token == "" {
+		return nil, fmt.Errorf("splunk url and token are required")
+	}
+	baseURL := strings.TrimSuffix(p.URL, "/")
+	s := &splunkLogWriter{
+		client:         fleethttp.NewClient(fleethttp.WithTimeout(p.Timeout)),
+		url:            baseURL + splunkEventPath,
+		healthURL:      baseURL + splunkHealthPath,
+		token:          p.Token,
+		index:          p.Index,
+		source:         p.Source,
+		sourceType:     p.SourceType,
+		compress:       p.EnableCompression,
+		logger:         logger,
+		maxSizeOfBatch: splunkMaxSizeOfBatch,
+		maxRetries:     httpLogMaxRetries,
+		backoff:        httpLogBackoff,
+	}
+	if err := s.checkHealth(); err != nil {
+		return nil, fmt.Errorf("create Splunk writer: %w", err)
+	}
+	return s, nil
+}
+
+func (s *splunkLogWriter) checkHealth() error {
+	resp, err := s.client.Get(s.healthURL)
+	if err != nil {
+		return fmt.Errorf("splunk health check: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+func (s *splunkLogWriter) Write(ctx context.Context, logs []json.RawMessage) error {
+	var batch []byte
+	for _, log := range logs {
+		event, err := json.Marshal(splunkEvent{
+			Index:      s.index,
+			Source:     s.source,
+			SourceType: s.sourceType,
+			Event:      log,
+		})
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "marshal splunk event")
+		}
+
+		// Logs that can never fit in a batch are dropped, the beginning bytes
+		// of the log should help the Fleet admin diagnose the query generating
+		// huge results.
+		if len(event) > s.maxSizeOfBatch {
+			level.Info(s.logger).Log(
+				"msg", "dropping log over Splunk batch size limit",
+				"size", len(event),
+				"log", string(log[:100])+"...",
+			)
+			continue
+		}
+
+		if len(batch)+len(event) > s.maxSizeOfBatch {
+			if err := s.sendBatch(ctx, batch); err != nil {
+				return ctxerr.Wrap(ctx, err, "send splunk events")
+			}
+			batch = nil
+		}
+		// events are simply concatenated in a batch
+		batch = append(batch, event...)
+	}
+
+	// Push the final batch
+	if len(batch) > 0 {
+		if err := s.sendBatch(ctx, batch); err != nil {
+			return ctxerr.Wrap(ctx, err, "send splunk events")
+		}
+	}
+
+	return nil
+}
+
+func (s *splunkLogWriter) sendBatch(ctx context.Context, batch []byte) error {
+	return retryWithBackoff(ctx, s.maxRetries, s.backoff, func() error {
+		req, err := newLogsRequest(ctx, s.url, "application/json", batch, s.compress)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Splunk "+s.token)
+		_, err = sendLogsRequest(s.client, req)
+		return err
+	})
+}
@@ -0,0 +1,171 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSplunkWriter(url string) *splunkLogWriter {
+	return &splunkLogWriter{
+		client:         http.DefaultClient,
+		url:            url + splunkEventPath,
+		healthURL:      url + splunkHealthPath,
+		token:          "tok",
+		index:          "osquery",
+		source:         "fleet",
+		sourceType:     "_json",
+		compress:       true,
+		logger:         log.NewNopLogger(),
+		maxSizeOfBatch: splunkMaxSizeOfBatch,
+		maxRetries:     httpLogMaxRetries,
+		backoff:        time.Millisecond,
+	}
+}
+
+// readSplunkEvents decodes the concatenated events of the request body.
+func readSplunkEvents(t *testing.T, r *http.Request) []splunkEvent {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body = zr
+	}
+	b, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var events []splunkEvent
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for dec.More() {
+		var event splunkEvent
+		require.NoError(t, dec.Decode(&event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestSplunkWrite(t *testing.T) {
+	ctx := context.Background()
+
+	var events []splunkEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, splunkEventPath, r.URL.Path)
+		assert.Equal(t, "Splunk tok", r.Header.Get("Authorization"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		events = append(events, readSplunkEvents(t, r)...)
+		w.Write([]byte(`{"text":"Success","code":0}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	writer := makeSplunkWriter(srv.URL)
+	require.NoError(t, writer.Write(ctx, logs))
+
+	require.Len(t, events, len(logs))
+	for i, event := range events {
+		assert.Equal(t, "osquery", event.Index)
+		assert.Equal(t, "fleet", event.Source)
+		assert.Equal(t, "_json", event.SourceType)
+		assert.JSONEq(t, string(logs[i]), string(event.Event))
+	}
+}
+
+func TestSplunkBatches(t *testing.T) {
+	ctx := context.Background()
+
+	var requests, count int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		count += len(readSplunkEvents(t, r))
+	}))
+	defer srv.Close()
+
+	writer := makeSplunkWriter(srv.URL)
+	writer.compress = false
+	// fits two of the test logs
+	writer.maxSizeOfBatch = 170
+
+	tooBig := json.RawMessage(`{"data":"` + string(bytes.Repeat([]byte("a"), 200)) + `"}`)
+	require.NoError(t, writer.Write(ctx, append(logs, tooBig)))
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, len(logs), count)
+}
+
+func TestSplunkRetryableFailure(t *testing.T) {
+	ctx := context.Background()
+
+	callCount := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		if callCount < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"text":"Server is busy","code":9}`)) //nolint:errcheck
+			return
+		}
+		assert.Len(t, readSplunkEvents(t, r), len(logs))
+	}))
+	defer srv.Close()
+
+	writer := makeSplunkWriter(srv.URL)
+	require.NoError(t, writer.Write(ctx, logs))
+	assert.Equal(t, 3, callCount)
+
+	// retries are exhausted
+	callCount = 0
+	writer.maxRetries = 1
+	err := writer.Write(ctx, logs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Server is busy")
+	assert.Equal(t, 2, callCount)
+}
+
+func TestSplunkNonRetryableFailure(t *testing.T) {
+	ctx := context.Background()
+
+	callCount := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"text":"Invalid token","code":4}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	writer := makeSplunkWriter(srv.URL)
+	err := writer.Write(ctx, logs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid token")
+	assert.Equal(t, 1, callCount)
+}
+
+func TestSplunkHealthCheck(t *testing.T) {
+	healthy := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, splunkHealthPath, r.URL.Path)
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	params := &SplunkParams{URL: srv.URL + "/", Token: "tok", Timeout: time.Second}
+	writer, err := NewSplunkLogWriter(params, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL+splunkEventPath, writer.url)
+
+	healthy = false
+	_, err = NewSplunkLogWriter(params, log.NewNopLogger())
+	require.Error(t, err)
+
+	_, err = NewSplunkLogWriter(&SplunkParams{URL: srv.URL}, log.NewNopLogger())
+	require.Error(t, err)
+}
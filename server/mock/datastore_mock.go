@@ -51,6 +51,26 @@ type PendingEmailChangeFunc func(ctx context.Context, userID uint, newEmail stri
 
 type ConfirmPendingEmailChangeFunc func(ctx context.Context, userID uint, token string) (string, error)
 
+type ListSCIMUsersFunc func(ctx context.Context, opt fleet.SCIMUserListOptions) (users []*fleet.User, total int, err error)
+
+type SCIMUserFunc func(ctx context.Context, id uint) (*fleet.User, error)
+
+type DeleteSCIMUserFunc func(ctx context.Context, id uint) error
+
+type RestoreSCIMUserFunc func(ctx context.Context, id uint) error
+
+type NewSCIMGroupFunc func(ctx context.Context, group *fleet.SCIMGroup) (*fleet.SCIMGroup, error)
+
+type SCIMGroupFunc func(ctx context.Context, id uint) (*fleet.SCIMGroup, error)
+
+type ListSCIMGroupsFunc func(ctx context.Context) ([]*fleet.SCIMGroup, error)
+
+type ListSCIMGroupsForUserFunc func(ctx context.Context, userID uint) ([]*fleet.SCIMGroup, error)
+
+type SaveSCIMGroupFunc func(ctx context.Context, group *fleet.SCIMGroup) error
+
+type DeleteSCIMGroupFunc func(ctx context.Context, id uint) error
+
 type ApplyQueriesFunc func(ctx context.Context, authorID uint, queries []*fleet.Query) error
 
 type NewQueryFunc func(ctx context.Context, query *fleet.Query, opts ...fleet.OptionalArg) (*fleet.Query, error)
@@ -83,6 +103,32 @@ type CleanupDistributedQueryCampaignsFunc func(ctx context.Context, now time.Tim
 
 type DistributedQueryCampaignsForQueryFunc func(ctx context.Context, queryID uint) ([]*fleet.DistributedQueryCampaign, error)
 
+type NewDistributedQueryCampaignResultFunc func(ctx context.Context, result *fleet.DistributedQueryCampaignResult) error
+
+type ListDistributedQueryCampaignResultsFunc func(ctx context.Context, filter fleet.TeamFilter, campaignID uint) ([]*fleet.DistributedQueryCampaignResult, error)
+
+type CleanupDistributedQueryCampaignResultsFunc func(ctx context.Context, olderThan time.Time) error
+
+type NewScheduledLiveQueryFunc func(ctx context.Context, query *fleet.ScheduledLiveQuery) (*fleet.ScheduledLiveQuery, error)
+
+type ScheduledLiveQueryFunc func(ctx context.Context, id uint) (*fleet.ScheduledLiveQuery, error)
+
+type ListScheduledLiveQueriesFunc func(ctx context.Context, opt fleet.ListOptions) ([]*fleet.ScheduledLiveQuery, error)
+
+type SaveScheduledLiveQueryFunc func(ctx context.Context, query *fleet.ScheduledLiveQuery) error
+
+type DeleteScheduledLiveQueryFunc func(ctx context.Context, id uint) error
+
+type ListDueScheduledLiveQueriesFunc func(ctx context.Context, now time.Time) ([]*fleet.ScheduledLiveQuery, error)
+
+type NewScheduledLiveQueryRunFunc func(ctx context.Context, run *fleet.ScheduledLiveQueryRun) error
+
+type ScheduledLiveQueryRunByCampaignFunc func(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error)
+
+type ListEndedScheduledLiveQueryRunsFunc func(ctx context.Context, now time.Time) ([]*fleet.ScheduledLiveQueryRun, error)
+
+type DeleteScheduledLiveQueryRunFunc func(ctx context.Context, campaignID uint) error
+
 type ApplyPackSpecsFunc func(ctx context.Context, specs []*fleet.PackSpec) error
 
 type GetPackSpecsFunc func(ctx context.Context) ([]*fleet.PackSpec, error)
@@ -171,6 +217,10 @@ type HostByIdentifierFunc func(ctx context.Context, identifier string) (*fleet.H
 
 type AddHostsToTeamFunc func(ctx context.Context, teamID *uint, hostIDs []uint) error
 
+type TransferHostsToTeamFunc func(ctx context.Context, teamID *uint, hostIDs []uint) error
+
+type HostTeamSetManuallyFunc func(ctx context.Context, hostID uint) (bool, error)
+
 type TotalAndUnseenHostsSinceFunc func(ctx context.Context, daysCount int) (total int, unseen int, err error)
 
 type DeleteHostsFunc func(ctx context.Context, ids []uint) error
@@ -277,6 +327,12 @@ type CleanupExpiredHostsFunc func(ctx context.Context) ([]uint, error)
 
 type ScheduledQueryIDsByNameFunc func(ctx context.Context, batchSize int, packAndSchedQueryNames ...[2]string) ([]uint, error)
 
+type SaveScheduledQueryResultsFunc func(ctx context.Context, results []*fleet.ScheduledQueryResult, maxPerHost int) error
+
+type ListScheduledQueryResultsFunc func(ctx context.Context, filter fleet.TeamFilter, opts fleet.ScheduledQueryResultListOptions) ([]*fleet.ScheduledQueryResult, error)
+
+type CleanupScheduledQueryResultsFunc func(ctx context.Context, olderThan time.Time) error
+
 type NewTeamFunc func(ctx context.Context, team *fleet.Team) (*fleet.Team, error)
 
 type SaveTeamFunc func(ctx context.Context, team *fleet.Team) (*fleet.Team, error)
@@ -301,6 +357,10 @@ type ListSoftwareForVulnDetectionFunc func(ctx context.Context, hostID uint) ([]
 
 type ListSoftwareVulnerabilitiesFunc func(ctx context.Context, hostIDs []uint) (map[uint][]fleet.SoftwareVulnerability, error)
 
+type ListSoftwareBySourcesFunc func(ctx context.Context, sources []string) ([]fleet.Software, error)
+
+type ListSoftwareVulnerabilitiesBySourceFunc func(ctx context.Context, source fleet.VulnerabilitySource) ([]fleet.SoftwareVulnerability, error)
+
 type LoadHostSoftwareFunc func(ctx context.Context, host *fleet.Host, includeCVEScores bool) error
 
 type AllSoftwareWithoutCPEIteratorFunc func(ctx context.Context, excludedPlatforms []string) (fleet.SoftwareIterator, error)
@@ -325,6 +385,54 @@ type InsertCVEMetaFunc func(ctx context.Context, cveMeta []fleet.CVEMeta) error
 
 type ListCVEsFunc func(ctx context.Context, maxAge time.Duration) ([]fleet.CVEMeta, error)
 
+type ListSoftwareChangesFunc func(ctx context.Context, opt fleet.SoftwareChangeListOptions) ([]*fleet.SoftwareChange, error)
+
+type CleanupSoftwareChangesFunc func(ctx context.Context, olderThan time.Time) error
+
+type UnapprovedSoftwareWebhookCursorFunc func(ctx context.Context) (*uint, error)
+
+type SetUnapprovedSoftwareWebhookCursorFunc func(ctx context.Context, id uint) error
+
+type NewSoftwareRuleFunc func(ctx context.Context, rule *fleet.SoftwareRule) (*fleet.SoftwareRule, error)
+
+type SoftwareRuleFunc func(ctx context.Context, id uint) (*fleet.SoftwareRule, error)
+
+type ListSoftwareRulesFunc func(ctx context.Context, teamID *uint) ([]*fleet.SoftwareRule, error)
+
+type SaveSoftwareRuleFunc func(ctx context.Context, rule *fleet.SoftwareRule) error
+
+type DeleteSoftwareRuleFunc func(ctx context.Context, id uint) error
+
+type UpdateSoftwareRuleViolationsFunc func(ctx context.Context) ([]*fleet.SoftwareRuleViolation, error)
+
+type ListHostTagKeysFunc func(ctx context.Context) ([]*fleet.HostTagKey, error)
+
+type ApplyHostTagKeysFunc func(ctx context.Context, keys []*fleet.HostTagKey) error
+
+type DeleteHostTagKeyFunc func(ctx context.Context, name string) error
+
+type SetHostTagsFunc func(ctx context.Context, hostIDs []uint, tags fleet.HostTagValues) error
+
+type FindDuplicateHostsFunc func(ctx context.Context, hostID uint, hardwareSerial string, uuid string) ([]*fleet.Host, error)
+
+type NewHostDuplicateFunc func(ctx context.Context, hostID uint, duplicateHostID uint, matchedBy string) error
+
+type ListHostDuplicatesFunc func(ctx context.Context, filter fleet.TeamFilter) ([]*fleet.HostDuplicate, error)
+
+type HostDuplicateFunc func(ctx context.Context, id uint) (*fleet.HostDuplicate, error)
+
+type DeleteHostDuplicateFunc func(ctx context.Context, id uint) error
+
+type MergeHostsFunc func(ctx context.Context, fromHostID uint, toHostID uint) error
+
+type SetHostsLifecycleStateFunc func(ctx context.Context, hostIDs []uint, state fleet.HostLifecycleState, quarantineTeamID *uint) error
+
+type RecordHostVitalsFunc func(ctx context.Context, vitals *fleet.HostVitals, minInterval time.Duration) error
+
+type ListHostVitalsFunc func(ctx context.Context, hostID uint, opt fleet.HostVitalsListOptions) ([]*fleet.HostVitals, error)
+
+type CleanupHostVitalsHistoryFunc func(ctx context.Context, olderThan time.Time) error
+
 type ListOperatingSystemsFunc func(ctx context.Context) ([]fleet.OperatingSystem, error)
 
 type UpdateHostOperatingSystemFunc func(ctx context.Context, hostID uint, hostOS fleet.OperatingSystem) error
@@ -333,8 +441,28 @@ type CleanupHostOperatingSystemsFunc func(ctx context.Context) error
 
 type NewActivityFunc func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error
 
+type CreateActivityFunc func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) (*fleet.Activity, error)
+
 type ListActivitiesFunc func(ctx context.Context, opt fleet.ListOptions) ([]*fleet.Activity, error)
 
+type ActivityFunc func(ctx context.Context, id uint) (*fleet.Activity, error)
+
+type NewActivityWebhookFunc func(ctx context.Context, webhook *fleet.ActivityWebhook) (*fleet.ActivityWebhook, error)
+
+type ActivityWebhookFunc func(ctx context.Context, id uint) (*fleet.ActivityWebhook, error)
+
+type ListActivityWebhooksFunc func(ctx context.Context) ([]*fleet.ActivityWebhook, error)
+
+type SaveActivityWebhookFunc func(ctx context.Context, webhook *fleet.ActivityWebhook) error
+
+type DeleteActivityWebhookFunc func(ctx context.Context, id uint) error
+
+type NewActivityWebhookDeliveryFunc func(ctx context.Context, delivery *fleet.ActivityWebhookDelivery) error
+
+type ListActivityWebhookDeliveriesFunc func(ctx context.Context, webhookID uint, opt fleet.ListOptions) ([]*fleet.ActivityWebhookDelivery, error)
+
+type CleanupActivityWebhookDeliveriesFunc func(ctx context.Context, now time.Time) error
+
 type ShouldSendStatisticsFunc func(ctx context.Context, frequency time.Duration, config config.FleetConfig, license *fleet.LicenseInfo) (fleet.StatisticsPayload, bool, error)
 
 type RecordStatisticsSentFunc func(ctx context.Context) error
@@ -357,6 +485,26 @@ type DeleteGlobalPoliciesFunc func(ctx context.Context, ids []uint) ([]uint, err
 
 type PolicyQueriesForHostFunc func(ctx context.Context, host *fleet.Host) (map[string]string, error)
 
+type PolicyRemediationsForHostFunc func(ctx context.Context, host *fleet.Host) (map[uint]*fleet.Policy, error)
+
+type PolicyResultsBySeverityFunc func(ctx context.Context, filter fleet.TeamFilter, platform *string) ([]*fleet.PolicySeverityResults, error)
+
+type ListHostPolicyTransitionsFunc func(ctx context.Context, hostID uint, policyID *uint, opt fleet.ListOptions) ([]*fleet.PolicyMembershipTransition, error)
+
+type PolicyDailyCountsFunc func(ctx context.Context, policyID uint, from time.Time, to time.Time) ([]*fleet.PolicyDailyCount, error)
+
+type CleanupPolicyMembershipHistoryFunc func(ctx context.Context, olderThan time.Time) error
+
+type NewPolicyWaiverFunc func(ctx context.Context, waiver *fleet.PolicyWaiver) (*fleet.PolicyWaiver, error)
+
+type ListPolicyWaiversFunc func(ctx context.Context, policyID uint) ([]*fleet.PolicyWaiver, error)
+
+type DeletePolicyWaiverFunc func(ctx context.Context, policyID uint, waiverID uint) error
+
+type ListExpiredPolicyWaiverFailingHostsFunc func(ctx context.Context, now time.Time) ([]*fleet.PolicyWaiverFailingHost, error)
+
+type DeleteExpiredPolicyWaiversFunc func(ctx context.Context, now time.Time) error
+
 type AsyncBatchInsertPolicyMembershipFunc func(ctx context.Context, batch []fleet.PolicyMembershipResult) error
 
 type AsyncBatchUpdatePolicyTimestampFunc func(ctx context.Context, ids []uint, ts time.Time) error
@@ -387,6 +535,20 @@ type IncrementPolicyViolationDaysFunc func(ctx context.Context) error
 
 type InitializePolicyViolationDaysFunc func(ctx context.Context) error
 
+type NewHostScriptExecutionFunc func(ctx context.Context, exec *fleet.HostScriptExecution) (*fleet.HostScriptExecution, error)
+
+type NewHostScriptExecutionsFunc func(ctx context.Context, execs []*fleet.HostScriptExecution) ([]*fleet.HostScriptExecution, error)
+
+type HostScriptExecutionFunc func(ctx context.Context, id uint) (*fleet.HostScriptExecution, error)
+
+type ListHostScriptExecutionsFunc func(ctx context.Context, hostID uint, opt fleet.ListOptions) ([]*fleet.HostScriptExecution, error)
+
+type ListPendingHostScriptExecutionsFunc func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error)
+
+type MarkHostScriptExecutionsSentFunc func(ctx context.Context, ids []uint) error
+
+type SetHostScriptExecutionResultFunc func(ctx context.Context, res *fleet.HostScriptExecutionResult) (*fleet.HostScriptExecution, error)
+
 type LockFunc func(ctx context.Context, name string, owner string, expiration time.Duration) (bool, error)
 
 type UnlockFunc func(ctx context.Context, name string, owner string) error
@@ -403,12 +565,18 @@ type LoadHostByOrbitNodeKeyFunc func(ctx context.Context, nodeKey string) (*flee
 
 type HostLiteFunc func(ctx context.Context, hostID uint) (*fleet.Host, error)
 
+type ListHostsLiteByIDsFunc func(ctx context.Context, hostIDs []uint) ([]*fleet.Host, error)
+
 type UpdateHostOsqueryIntervalsFunc func(ctx context.Context, hostID uint, intervals fleet.HostOsqueryIntervals) error
 
 type TeamAgentOptionsFunc func(ctx context.Context, teamID uint) (*json.RawMessage, error)
 
 type TeamFeaturesFunc func(ctx context.Context, teamID uint) (*fleet.Features, error)
 
+type TeamScriptSettingsFunc func(ctx context.Context, teamID uint) (*fleet.ScriptSettings, error)
+
+type ListHostAssignmentRulesFunc func(ctx context.Context) (fleet.TeamHostAssignmentRules, error)
+
 type SaveHostPackStatsFunc func(ctx context.Context, hostID uint, stats []fleet.PackStats) error
 
 type AsyncBatchSaveHostsScheduledQueryStatsFunc func(ctx context.Context, stats map[uint][]fleet.ScheduledQueryStats, batchSize int) (int, error)
@@ -451,7 +619,7 @@ type SerialUpdateHostFunc func(ctx context.Context, host *fleet.Host) error
 
 type NewJobFunc func(ctx context.Context, job *fleet.Job) (*fleet.Job, error)
 
-type GetQueuedJobsFunc func(ctx context.Context, maxNumJobs int) ([]*fleet.Job, error)
+type GetQueuedJobsFunc func(ctx context.Context, maxNumJobs int, names []string) ([]*fleet.Job, error)
 
 type UpdateJobFunc func(ctx context.Context, id uint, job *fleet.Job) (*fleet.Job, error)
 
@@ -541,6 +709,36 @@ type DataStore struct {
 	ConfirmPendingEmailChangeFunc        ConfirmPendingEmailChangeFunc
 	ConfirmPendingEmailChangeFuncInvoked bool
 
+	ListSCIMUsersFunc        ListSCIMUsersFunc
+	ListSCIMUsersFuncInvoked bool
+
+	SCIMUserFunc        SCIMUserFunc
+	SCIMUserFuncInvoked bool
+
+	DeleteSCIMUserFunc        DeleteSCIMUserFunc
+	DeleteSCIMUserFuncInvoked bool
+
+	RestoreSCIMUserFunc        RestoreSCIMUserFunc
+	RestoreSCIMUserFuncInvoked bool
+
+	NewSCIMGroupFunc        NewSCIMGroupFunc
+	NewSCIMGroupFuncInvoked bool
+
+	SCIMGroupFunc        SCIMGroupFunc
+	SCIMGroupFuncInvoked bool
+
+	ListSCIMGroupsFunc        ListSCIMGroupsFunc
+	ListSCIMGroupsFuncInvoked bool
+
+	ListSCIMGroupsForUserFunc        ListSCIMGroupsForUserFunc
+	ListSCIMGroupsForUserFuncInvoked bool
+
+	SaveSCIMGroupFunc        SaveSCIMGroupFunc
+	SaveSCIMGroupFuncInvoked bool
+
+	DeleteSCIMGroupFunc        DeleteSCIMGroupFunc
+	DeleteSCIMGroupFuncInvoked bool
+
 	ApplyQueriesFunc        ApplyQueriesFunc
 	ApplyQueriesFuncInvoked bool
 
@@ -589,6 +787,45 @@ type DataStore struct {
 	DistributedQueryCampaignsForQueryFunc        DistributedQueryCampaignsForQueryFunc
 	DistributedQueryCampaignsForQueryFuncInvoked bool
 
+	NewDistributedQueryCampaignResultFunc        NewDistributedQueryCampaignResultFunc
+	NewDistributedQueryCampaignResultFuncInvoked bool
+
+	ListDistributedQueryCampaignResultsFunc        ListDistributedQueryCampaignResultsFunc
+	ListDistributedQueryCampaignResultsFuncInvoked bool
+
+	CleanupDistributedQueryCampaignResultsFunc        CleanupDistributedQueryCampaignResultsFunc
+	CleanupDistributedQueryCampaignResultsFuncInvoked bool
+
+	NewScheduledLiveQueryFunc        NewScheduledLiveQueryFunc
+	NewScheduledLiveQueryFuncInvoked bool
+
+	ScheduledLiveQueryFunc        ScheduledLiveQueryFunc
+	ScheduledLiveQueryFuncInvoked bool
+
+	ListScheduledLiveQueriesFunc        ListScheduledLiveQueriesFunc
+	ListScheduledLiveQueriesFuncInvoked bool
+
+	SaveScheduledLiveQueryFunc        SaveScheduledLiveQueryFunc
+	SaveScheduledLiveQueryFuncInvoked bool
+
+	DeleteScheduledLiveQueryFunc        DeleteScheduledLiveQueryFunc
+	DeleteScheduledLiveQueryFuncInvoked bool
+
+	ListDueScheduledLiveQueriesFunc        ListDueScheduledLiveQueriesFunc
+	ListDueScheduledLiveQueriesFuncInvoked bool
+
+	NewScheduledLiveQueryRunFunc        NewScheduledLiveQueryRunFunc
+	NewScheduledLiveQueryRunFuncInvoked bool
+
+	ScheduledLiveQueryRunByCampaignFunc        ScheduledLiveQueryRunByCampaignFunc
+	ScheduledLiveQueryRunByCampaignFuncInvoked bool
+
+	ListEndedScheduledLiveQueryRunsFunc        ListEndedScheduledLiveQueryRunsFunc
+	ListEndedScheduledLiveQueryRunsFuncInvoked bool
+
+	DeleteScheduledLiveQueryRunFunc        DeleteScheduledLiveQueryRunFunc
+	DeleteScheduledLiveQueryRunFuncInvoked bool
+
 	ApplyPackSpecsFunc        ApplyPackSpecsFunc
 	ApplyPackSpecsFuncInvoked bool
 
@@ -721,6 +958,12 @@ type DataStore struct {
 	AddHostsToTeamFunc        AddHostsToTeamFunc
 	AddHostsToTeamFuncInvoked bool
 
+	TransferHostsToTeamFunc        TransferHostsToTeamFunc
+	TransferHostsToTeamFuncInvoked bool
+
+	HostTeamSetManuallyFunc        HostTeamSetManuallyFunc
+	HostTeamSetManuallyFuncInvoked bool
+
 	TotalAndUnseenHostsSinceFunc        TotalAndUnseenHostsSinceFunc
 	TotalAndUnseenHostsSinceFuncInvoked bool
 
@@ -880,6 +1123,15 @@ type DataStore struct {
 	ScheduledQueryIDsByNameFunc        ScheduledQueryIDsByNameFunc
 	ScheduledQueryIDsByNameFuncInvoked bool
 
+	SaveScheduledQueryResultsFunc        SaveScheduledQueryResultsFunc
+	SaveScheduledQueryResultsFuncInvoked bool
+
+	ListScheduledQueryResultsFunc        ListScheduledQueryResultsFunc
+	ListScheduledQueryResultsFuncInvoked bool
+
+	CleanupScheduledQueryResultsFunc        CleanupScheduledQueryResultsFunc
+	CleanupScheduledQueryResultsFuncInvoked bool
+
 	NewTeamFunc        NewTeamFunc
 	NewTeamFuncInvoked bool
 
@@ -916,6 +1168,12 @@ type DataStore struct {
 	ListSoftwareVulnerabilitiesFunc        ListSoftwareVulnerabilitiesFunc
 	ListSoftwareVulnerabilitiesFuncInvoked bool
 
+	ListSoftwareBySourcesFunc        ListSoftwareBySourcesFunc
+	ListSoftwareBySourcesFuncInvoked bool
+
+	ListSoftwareVulnerabilitiesBySourceFunc        ListSoftwareVulnerabilitiesBySourceFunc
+	ListSoftwareVulnerabilitiesBySourceFuncInvoked bool
+
 	LoadHostSoftwareFunc        LoadHostSoftwareFunc
 	LoadHostSoftwareFuncInvoked bool
 
@@ -952,6 +1210,78 @@ type DataStore struct {
 	ListCVEsFunc        ListCVEsFunc
 	ListCVEsFuncInvoked bool
 
+	ListSoftwareChangesFunc        ListSoftwareChangesFunc
+	ListSoftwareChangesFuncInvoked bool
+
+	CleanupSoftwareChangesFunc        CleanupSoftwareChangesFunc
+	CleanupSoftwareChangesFuncInvoked bool
+
+	UnapprovedSoftwareWebhookCursorFunc        UnapprovedSoftwareWebhookCursorFunc
+	UnapprovedSoftwareWebhookCursorFuncInvoked bool
+
+	SetUnapprovedSoftwareWebhookCursorFunc        SetUnapprovedSoftwareWebhookCursorFunc
+	SetUnapprovedSoftwareWebhookCursorFuncInvoked bool
+
+	NewSoftwareRuleFunc        NewSoftwareRuleFunc
+	NewSoftwareRuleFuncInvoked bool
+
+	SoftwareRuleFunc        SoftwareRuleFunc
+	SoftwareRuleFuncInvoked bool
+
+	ListSoftwareRulesFunc        ListSoftwareRulesFunc
+	ListSoftwareRulesFuncInvoked bool
+
+	SaveSoftwareRuleFunc        SaveSoftwareRuleFunc
+	SaveSoftwareRuleFuncInvoked bool
+
+	DeleteSoftwareRuleFunc        DeleteSoftwareRuleFunc
+	DeleteSoftwareRuleFuncInvoked bool
+
+	UpdateSoftwareRuleViolationsFunc        UpdateSoftwareRuleViolationsFunc
+	UpdateSoftwareRuleViolationsFuncInvoked bool
+
+	ListHostTagKeysFunc        ListHostTagKeysFunc
+	ListHostTagKeysFuncInvoked bool
+
+	ApplyHostTagKeysFunc        ApplyHostTagKeysFunc
+	ApplyHostTagKeysFuncInvoked bool
+
+	DeleteHostTagKeyFunc        DeleteHostTagKeyFunc
+	DeleteHostTagKeyFuncInvoked bool
+
+	SetHostTagsFunc        SetHostTagsFunc
+	SetHostTagsFuncInvoked bool
+
+	FindDuplicateHostsFunc        FindDuplicateHostsFunc
+	FindDuplicateHostsFuncInvoked bool
+
+	NewHostDuplicateFunc        NewHostDuplicateFunc
+	NewHostDuplicateFuncInvoked bool
+
+	ListHostDuplicatesFunc        ListHostDuplicatesFunc
+	ListHostDuplicatesFuncInvoked bool
+
+	HostDuplicateFunc        HostDuplicateFunc
+	HostDuplicateFuncInvoked bool
+
+	DeleteHostDuplicateFunc        DeleteHostDuplicateFunc
+	DeleteHostDuplicateFuncInvoked bool
+
+	MergeHostsFunc        MergeHostsFunc
+	MergeHostsFuncInvoked bool
+
+	SetHostsLifecycleStateFunc        SetHostsLifecycleStateFunc
+	SetHostsLifecycleStateFuncInvoked bool
+
+	RecordHostVitalsFunc        RecordHostVitalsFunc
+	RecordHostVitalsFuncInvoked bool
+
+	ListHostVitalsFunc        ListHostVitalsFunc
+	ListHostVitalsFuncInvoked bool
+
+	CleanupHostVitalsHistoryFunc        CleanupHostVitalsHistoryFunc
+	CleanupHostVitalsHistoryFuncInvoked bool
+
 	ListOperatingSystemsFunc        ListOperatingSystemsFunc
 	ListOperatingSystemsFuncInvoked bool
 
@@ -964,9 +1294,39 @@ type DataStore struct {
 	NewActivityFunc        NewActivityFunc
 	NewActivityFuncInvoked bool
 
+	CreateActivityFunc        CreateActivityFunc
+	CreateActivityFuncInvoked bool
+
 	ListActivitiesFunc        ListActivitiesFunc
 	ListActivitiesFuncInvoked bool
 
+	ActivityFunc        ActivityFunc
+	ActivityFuncInvoked bool
+
+	NewActivityWebhookFunc        NewActivityWebhookFunc
+	NewActivityWebhookFuncInvoked bool
+
+	ActivityWebhookFunc        ActivityWebhookFunc
+	ActivityWebhookFuncInvoked bool
+
+	ListActivityWebhooksFunc        ListActivityWebhooksFunc
+	ListActivityWebhooksFuncInvoked bool
+
+	SaveActivityWebhookFunc        SaveActivityWebhookFunc
+	SaveActivityWebhookFuncInvoked bool
+
+	DeleteActivityWebhookFunc        DeleteActivityWebhookFunc
+	DeleteActivityWebhookFuncInvoked bool
+
+	NewActivityWebhookDeliveryFunc        NewActivityWebhookDeliveryFunc
+	NewActivityWebhookDeliveryFuncInvoked bool
+
+	ListActivityWebhookDeliveriesFunc        ListActivityWebhookDeliveriesFunc
+	ListActivityWebhookDeliveriesFuncInvoked bool
+
+	CleanupActivityWebhookDeliveriesFunc        CleanupActivityWebhookDeliveriesFunc
+	CleanupActivityWebhookDeliveriesFuncInvoked bool
+
 	ShouldSendStatisticsFunc        ShouldSendStatisticsFunc
 	ShouldSendStatisticsFuncInvoked bool
 
@@ -1000,6 +1360,36 @@ type DataStore struct {
 	PolicyQueriesForHostFunc        PolicyQueriesForHostFunc
 	PolicyQueriesForHostFuncInvoked bool
 
+	PolicyRemediationsForHostFunc        PolicyRemediationsForHostFunc
+	PolicyRemediationsForHostFuncInvoked bool
+
+	PolicyResultsBySeverityFunc        PolicyResultsBySeverityFunc
+	PolicyResultsBySeverityFuncInvoked bool
+
+	ListHostPolicyTransitionsFunc        ListHostPolicyTransitionsFunc
+	ListHostPolicyTransitionsFuncInvoked bool
+
+	PolicyDailyCountsFunc        PolicyDailyCountsFunc
+	PolicyDailyCountsFuncInvoked bool
+
+	CleanupPolicyMembershipHistoryFunc        CleanupPolicyMembershipHistoryFunc
+	CleanupPolicyMembershipHistoryFuncInvoked bool
+
+	NewPolicyWaiverFunc        NewPolicyWaiverFunc
+	NewPolicyWaiverFuncInvoked bool
+
+	ListPolicyWaiversFunc        ListPolicyWaiversFunc
+	ListPolicyWaiversFuncInvoked bool
+
+	DeletePolicyWaiverFunc        DeletePolicyWaiverFunc
+	DeletePolicyWaiverFuncInvoked bool
+
+	ListExpiredPolicyWaiverFailingHostsFunc        ListExpiredPolicyWaiverFailingHostsFunc
+	ListExpiredPolicyWaiverFailingHostsFuncInvoked bool
+
+	DeleteExpiredPolicyWaiversFunc        DeleteExpiredPolicyWaiversFunc
+	DeleteExpiredPolicyWaiversFuncInvoked bool
+
 	AsyncBatchInsertPolicyMembershipFunc        AsyncBatchInsertPolicyMembershipFunc
 	AsyncBatchInsertPolicyMembershipFuncInvoked bool
 
@@ -1045,6 +1435,27 @@ type DataStore struct {
 	InitializePolicyViolationDaysFunc        InitializePolicyViolationDaysFunc
 	InitializePolicyViolationDaysFuncInvoked bool
 
+	NewHostScriptExecutionFunc        NewHostScriptExecutionFunc
+	NewHostScriptExecutionFuncInvoked bool
+
+	NewHostScriptExecutionsFunc        NewHostScriptExecutionsFunc
+	NewHostScriptExecutionsFuncInvoked bool
+
+	HostScriptExecutionFunc        HostScriptExecutionFunc
+	HostScriptExecutionFuncInvoked bool
+
+	ListHostScriptExecutionsFunc        ListHostScriptExecutionsFunc
+	ListHostScriptExecutionsFuncInvoked bool
+
+	ListPendingHostScriptExecutionsFunc        ListPendingHostScriptExecutionsFunc
+	ListPendingHostScriptExecutionsFuncInvoked bool
+
+	MarkHostScriptExecutionsSentFunc        MarkHostScriptExecutionsSentFunc
+	MarkHostScriptExecutionsSentFuncInvoked bool
+
+	SetHostScriptExecutionResultFunc        SetHostScriptExecutionResultFunc
+	SetHostScriptExecutionResultFuncInvoked bool
+
 	LockFunc        LockFunc
 	LockFuncInvoked bool
 
@@ -1069,6 +1480,9 @@ type DataStore struct {
 	HostLiteFunc        HostLiteFunc
 	HostLiteFuncInvoked bool
 
+	ListHostsLiteByIDsFunc        ListHostsLiteByIDsFunc
+	ListHostsLiteByIDsFuncInvoked bool
+
 	UpdateHostOsqueryIntervalsFunc        UpdateHostOsqueryIntervalsFunc
 	UpdateHostOsqueryIntervalsFuncInvoked bool
 
@@ -1078,6 +1492,12 @@ type DataStore struct {
 	TeamFeaturesFunc        TeamFeaturesFunc
 	TeamFeaturesFuncInvoked bool
 
+	TeamScriptSettingsFunc        TeamScriptSettingsFunc
+	TeamScriptSettingsFuncInvoked bool
+
+	ListHostAssignmentRulesFunc        ListHostAssignmentRulesFunc
+	ListHostAssignmentRulesFuncInvoked bool
+
 	SaveHostPackStatsFunc        SaveHostPackStatsFunc
 	SaveHostPackStatsFuncInvoked bool
 
@@ -1285,6 +1705,56 @@ func (s *DataStore) ConfirmPendingEmailChange(ctx context.Context, userID uint,
 	return s.ConfirmPendingEmailChangeFunc(ctx, userID, token)
 }
 
+func (s *DataStore) ListSCIMUsers(ctx context.Context, opt fleet.SCIMUserListOptions) (users []*fleet.User, total int, err error) {
+	s.ListSCIMUsersFuncInvoked = true
+	return s.ListSCIMUsersFunc(ctx, opt)
+}
+
+func (s *DataStore) SCIMUser(ctx context.Context, id uint) (*fleet.User, error) {
+	s.SCIMUserFuncInvoked = true
+	return s.SCIMUserFunc(ctx, id)
+}
+
+func (s *DataStore) DeleteSCIMUser(ctx context.Context, id uint) error {
+	s.DeleteSCIMUserFuncInvoked = true
+	return s.DeleteSCIMUserFunc(ctx, id)
+}
+
+func (s *DataStore) RestoreSCIMUser(ctx context.Context, id uint) error {
+	s.RestoreSCIMUserFuncInvoked = true
+	return s.RestoreSCIMUserFunc(ctx, id)
+}
+
+func (s *DataStore) NewSCIMGroup(ctx context.Context, group *fleet.SCIMGroup) (*fleet.SCIMGroup, error) {
+	s.NewSCIMGroupFuncInvoked = true
+	return s.NewSCIMGroupFunc(ctx, group)
+}
+
+func (s *DataStore) SCIMGroup(ctx context.Context, id uint) (*fleet.SCIMGroup, error) {
+	s.SCIMGroupFuncInvoked = true
+	return s.SCIMGroupFunc(ctx, id)
+}
+
+func (s *DataStore) ListSCIMGroups(ctx context.Context) ([]*fleet.SCIMGroup, error) {
+	s.ListSCIMGroupsFuncInvoked = true
+	return s.ListSCIMGroupsFunc(ctx)
+}
+
+func (s *DataStore) ListSCIMGroupsForUser(ctx context.Context, userID uint) ([]*fleet.SCIMGroup, error) {
+	s.ListSCIMGroupsForUserFuncInvoked = true
+	return s.ListSCIMGroupsForUserFunc(ctx, userID)
+}
+
+func (s *DataStore) SaveSCIMGroup(ctx context.Context, group *fleet.SCIMGroup) error {
+	s.SaveSCIMGroupFuncInvoked = true
+	return s.SaveSCIMGroupFunc(ctx, group)
+}
+
+func (s *DataStore) DeleteSCIMGroup(ctx context.Context, id uint) error {
+	s.DeleteSCIMGroupFuncInvoked = true
+	return s.DeleteSCIMGroupFunc(ctx, id)
+}
+
 func (s *DataStore) ApplyQueries(ctx context.Context, authorID uint, queries []*fleet.Query) error {
 	s.ApplyQueriesFuncInvoked = true
 	return s.ApplyQueriesFunc(ctx, authorID, queries)
@@ -1365,6 +1835,71 @@ func (s *DataStore) DistributedQueryCampaignsForQuery(ctx context.Context, query
 	return s.DistributedQueryCampaignsForQueryFunc(ctx, queryID)
 }
 
+func (s *DataStore) NewDistributedQueryCampaignResult(ctx context.Context, result *fleet.DistributedQueryCampaignResult) error {
+	s.NewDistributedQueryCampaignResultFuncInvoked = true
+	return s.NewDistributedQueryCampaignResultFunc(ctx, result)
+}
+
+func (s *DataStore) ListDistributedQueryCampaignResults(ctx context.Context, filter fleet.TeamFilter, campaignID uint) ([]*fleet.DistributedQueryCampaignResult, error) {
+	s.ListDistributedQueryCampaignResultsFuncInvoked = true
+	return s.ListDistributedQueryCampaignResultsFunc(ctx, filter, campaignID)
+}
+
+func (s *DataStore) CleanupDistributedQueryCampaignResults(ctx context.Context, olderThan time.Time) error {
+	s.CleanupDistributedQueryCampaignResultsFuncInvoked = true
+	return s.CleanupDistributedQueryCampaignResultsFunc(ctx, olderThan)
+}
+
+func (s *DataStore) NewScheduledLiveQuery(ctx context.Context, query *fleet.ScheduledLiveQuery) (*fleet.ScheduledLiveQuery, error) {
+	s.NewScheduledLiveQueryFuncInvoked = true
+	return s.NewScheduledLiveQueryFunc(ctx, query)
+}
+
+func (s *DataStore) ScheduledLiveQuery(ctx context.Context, id uint) (*fleet.ScheduledLiveQuery, error) {
+	s.ScheduledLiveQueryFuncInvoked = true
+	return s.ScheduledLiveQueryFunc(ctx, id)
+}
+
+func (s *DataStore) ListScheduledLiveQueries(ctx context.Context, opt fleet.ListOptions) ([]*fleet.ScheduledLiveQuery, error) {
+	s.ListScheduledLiveQueriesFuncInvoked = true
+	return s.ListScheduledLiveQueriesFunc(ctx, opt)
+}
+
+func (s *DataStore) SaveScheduledLiveQuery(ctx context.Context, query *fleet.ScheduledLiveQuery) error {
+	s.SaveScheduledLiveQueryFuncInvoked = true
+	return s.SaveScheduledLiveQueryFunc(ctx, query)
+}
+
+func (s *DataStore) DeleteScheduledLiveQuery(ctx context.Context, id uint) error {
+	s.DeleteScheduledLiveQueryFuncInvoked = true
+	return s.DeleteScheduledLiveQueryFunc(ctx, id)
+}
+
+func (s *DataStore) ListDueScheduledLiveQueries(ctx context.Context, now time.Time) ([]*fleet.ScheduledLiveQuery, error) {
+	s.ListDueScheduledLiveQueriesFuncInvoked = true
+	return s.ListDueScheduledLiveQueriesFunc(ctx, now)
+}
+
+func (s *DataStore) NewScheduledLiveQueryRun(ctx context.Context, run *fleet.ScheduledLiveQueryRun) error {
+	s.NewScheduledLiveQueryRunFuncInvoked = true
+	return s.NewScheduledLiveQueryRunFunc(ctx, run)
+}
+
+func (s *DataStore) ScheduledLiveQueryRunByCampaign(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error) {
+	s.ScheduledLiveQueryRunByCampaignFuncInvoked = true
+	return s.ScheduledLiveQueryRunByCampaignFunc(ctx, campaignID)
+}
+
+func (s *DataStore) ListEndedScheduledLiveQueryRuns(ctx context.Context, now time.Time) ([]*fleet.ScheduledLiveQueryRun, error) {
+	s.ListEndedScheduledLiveQueryRunsFuncInvoked = true
+	return s.ListEndedScheduledLiveQueryRunsFunc(ctx, now)
+}
+
+func (s *DataStore) DeleteScheduledLiveQueryRun(ctx context.Context, campaignID uint) error {
+	s.DeleteScheduledLiveQueryRunFuncInvoked = true
+	return s.DeleteScheduledLiveQueryRunFunc(ctx, campaignID)
+}
+
 func (s *DataStore) ApplyPackSpecs(ctx context.Context, specs []*fleet.PackSpec) error {
 	s.ApplyPackSpecsFuncInvoked = true
 	return s.ApplyPackSpecsFunc(ctx, specs)
@@ -1585,6 +2120,16 @@ func (s *DataStore) AddHostsToTeam(ctx context.Context, teamID *uint, hostIDs []
 	return s.AddHostsToTeamFunc(ctx, teamID, hostIDs)
 }
 
+func (s *DataStore) TransferHostsToTeam(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	s.TransferHostsToTeamFuncInvoked = true
+	return s.TransferHostsToTeamFunc(ctx, teamID, hostIDs)
+}
+
+func (s *DataStore) HostTeamSetManually(ctx context.Context, hostID uint) (bool, error) {
+	s.HostTeamSetManuallyFuncInvoked = true
+	return s.HostTeamSetManuallyFunc(ctx, hostID)
+}
+
 func (s *DataStore) TotalAndUnseenHostsSince(ctx context.Context, daysCount int) (total int, unseen int, err error) {
 	s.TotalAndUnseenHostsSinceFuncInvoked = true
 	return s.TotalAndUnseenHostsSinceFunc(ctx, daysCount)
@@ -1850,6 +2395,21 @@ func (s *DataStore) ScheduledQueryIDsByName(ctx context.Context, batchSize int,
 	return s.ScheduledQueryIDsByNameFunc(ctx, batchSize, packAndSchedQueryNames...)
 }
 
+func (s *DataStore) SaveScheduledQueryResults(ctx context.Context, results []*fleet.ScheduledQueryResult, maxPerHost int) error {
+	s.SaveScheduledQueryResultsFuncInvoked = true
+	return s.SaveScheduledQueryResultsFunc(ctx, results, maxPerHost)
+}
+
+func (s *DataStore) ListScheduledQueryResults(ctx context.Context, filter fleet.TeamFilter, opts fleet.ScheduledQueryResultListOptions) ([]*fleet.ScheduledQueryResult, error) {
+	s.ListScheduledQueryResultsFuncInvoked = true
+	return s.ListScheduledQueryResultsFunc(ctx, filter, opts)
+}
+
+func (s *DataStore) CleanupScheduledQueryResults(ctx context.Context, olderThan time.Time) error {
+	s.CleanupScheduledQueryResultsFuncInvoked = true
+	return s.CleanupScheduledQueryResultsFunc(ctx, olderThan)
+}
+
 func (s *DataStore) NewTeam(ctx context.Context, team *fleet.Team) (*fleet.Team, error) {
 	s.NewTeamFuncInvoked = true
 	return s.NewTeamFunc(ctx, team)
@@ -1910,6 +2470,16 @@ func (s *DataStore) ListSoftwareVulnerabilities(ctx context.Context, hostIDs []u
 	return s.ListSoftwareVulnerabilitiesFunc(ctx, hostIDs)
 }
 
+func (s *DataStore) ListSoftwareBySources(ctx context.Context, sources []string) ([]fleet.Software, error) {
+	s.ListSoftwareBySourcesFuncInvoked = true
+	return s.ListSoftwareBySourcesFunc(ctx, sources)
+}
+
+func (s *DataStore) ListSoftwareVulnerabilitiesBySource(ctx context.Context, source fleet.VulnerabilitySource) ([]fleet.SoftwareVulnerability, error) {
+	s.ListSoftwareVulnerabilitiesBySourceFuncInvoked = true
+	return s.ListSoftwareVulnerabilitiesBySourceFunc(ctx, source)
+}
+
 func (s *DataStore) LoadHostSoftware(ctx context.Context, host *fleet.Host, includeCVEScores bool) error {
 	s.LoadHostSoftwareFuncInvoked = true
 	return s.LoadHostSoftwareFunc(ctx, host, includeCVEScores)
@@ -1970,6 +2540,126 @@ func (s *DataStore) ListCVEs(ctx context.Context, maxAge time.Duration) ([]fleet
 	return s.ListCVEsFunc(ctx, maxAge)
 }
 
+func (s *DataStore) ListSoftwareChanges(ctx context.Context, opt fleet.SoftwareChangeListOptions) ([]*fleet.SoftwareChange, error) {
+	s.ListSoftwareChangesFuncInvoked = true
+	return s.ListSoftwareChangesFunc(ctx, opt)
+}
+
+func (s *DataStore) CleanupSoftwareChanges(ctx context.Context, olderThan time.Time) error {
+	s.CleanupSoftwareChangesFuncInvoked = true
+	return s.CleanupSoftwareChangesFunc(ctx, olderThan)
+}
+
+func (s *DataStore) UnapprovedSoftwareWebhookCursor(ctx context.Context) (*uint, error) {
+	s.UnapprovedSoftwareWebhookCursorFuncInvoked = true
+	return s.UnapprovedSoftwareWebhookCursorFunc(ctx)
+}
+
+func (s *DataStore) SetUnapprovedSoftwareWebhookCursor(ctx context.Context, id uint) error {
+	s.SetUnapprovedSoftwareWebhookCursorFuncInvoked = true
+	return s.SetUnapprovedSoftwareWebhookCursorFunc(ctx, id)
+}
+
+func (s *DataStore) NewSoftwareRule(ctx context.Context, rule *fleet.SoftwareRule) (*fleet.SoftwareRule, error) {
+	s.NewSoftwareRuleFuncInvoked = true
+	return s.NewSoftwareRuleFunc(ctx, rule)
+}
+
+func (s *DataStore) SoftwareRule(ctx context.Context, id uint) (*fleet.SoftwareRule, error) {
+	s.SoftwareRuleFuncInvoked = true
+	return s.SoftwareRuleFunc(ctx, id)
+}
+
+func (s *DataStore) ListSoftwareRules(ctx context.Context, teamID *uint) ([]*fleet.SoftwareRule, error) {
+	s.ListSoftwareRulesFuncInvoked = true
+	return s.ListSoftwareRulesFunc(ctx, teamID)
+}
+
+func (s *DataStore) SaveSoftwareRule(ctx context.Context, rule *fleet.SoftwareRule) error {
+	s.SaveSoftwareRuleFuncInvoked = true
+	return s.SaveSoftwareRuleFunc(ctx, rule)
+}
+
+func (s *DataStore) DeleteSoftwareRule(ctx context.Context, id uint) error {
+	s.DeleteSoftwareRuleFuncInvoked = true
+	return s.DeleteSoftwareRuleFunc(ctx, id)
+}
+
+func (s *DataStore) UpdateSoftwareRuleViolations(ctx context.Context) ([]*fleet.SoftwareRuleViolation, error) {
+	s.UpdateSoftwareRuleViolationsFuncInvoked = true
+	return s.UpdateSoftwareRuleViolationsFunc(ctx)
+}
+
+func (s *DataStore) ListHostTagKeys(ctx context.Context) ([]*fleet.HostTagKey, error) {
+	s.ListHostTagKeysFuncInvoked = true
+	return s.ListHostTagKeysFunc(ctx)
+}
+
+func (s *DataStore) ApplyHostTagKeys(ctx context.Context, keys []*fleet.HostTagKey) error {
+	s.ApplyHostTagKeysFuncInvoked = true
+	return s.ApplyHostTagKeysFunc(ctx, keys)
+}
+
+func (s *DataStore) DeleteHostTagKey(ctx context.Context, name string) error {
+	s.DeleteHostTagKeyFuncInvoked = true
+	return s.DeleteHostTagKeyFunc(ctx, name)
+}
+
+func (s *DataStore) SetHostTags(ctx context.Context, hostIDs []uint, tags fleet.HostTagValues) error {
+	s.SetHostTagsFuncInvoked = true
+	return s.SetHostTagsFunc(ctx, hostIDs, tags)
+}
+
+func (s *DataStore) FindDuplicateHosts(ctx context.Context, hostID uint, hardwareSerial string, uuid string) ([]*fleet.Host, error) {
+	s.FindDuplicateHostsFuncInvoked = true
+	return s.FindDuplicateHostsFunc(ctx, hostID, hardwareSerial, uuid)
+}
+
+func (s *DataStore) NewHostDuplicate(ctx context.Context, hostID uint, duplicateHostID uint, matchedBy string) error {
+	s.NewHostDuplicateFuncInvoked = true
+	return s.NewHostDuplicateFunc(ctx, hostID, duplicateHostID, matchedBy)
+}
+
+func (s *DataStore) ListHostDuplicates(ctx context.Context, filter fleet.TeamFilter) ([]*fleet.HostDuplicate, error) {
+	s.ListHostDuplicatesFuncInvoked = true
+	return s.ListHostDuplicatesFunc(ctx, filter)
+}
+
+func (s *DataStore) HostDuplicate(ctx context.Context, id uint) (*fleet.HostDuplicate, error) {
+	s.HostDuplicateFuncInvoked = true
+	return s.HostDuplicateFunc(ctx, id)
+}
+
+func (s *DataStore) DeleteHostDuplicate(ctx context.Context, id uint) error {
+	s.DeleteHostDuplicateFuncInvoked = true
+	return s.DeleteHostDuplicateFunc(ctx, id)
+}
+
+func (s *DataStore) MergeHosts(ctx context.Context, fromHostID uint, toHostID uint) error {
+	s.MergeHostsFuncInvoked = true
+	return s.MergeHostsFunc(ctx, fromHostID, toHostID)
+}
+
+func (s *DataStore) SetHostsLifecycleState(ctx context.Context, hostIDs []uint, state fleet.HostLifecycleState, quarantineTeamID *uint) error {
+	s.SetHostsLifecycleStateFuncInvoked = true
+	return s.SetHostsLifecycleStateFunc(ctx, hostIDs, state, quarantineTeamID)
+}
+
+func (s *DataStore) RecordHostVitals(ctx context.Context, vitals *fleet.HostVitals, minInterval time.Duration) error {
+	s.RecordHostVitalsFuncInvoked = true
+	return s.RecordHostVitalsFunc(ctx, vitals, minInterval)
+}
+
+func (s *DataStore) ListHostVitals(ctx context.Context, hostID uint, opt fleet.HostVitalsListOptions) ([]*fleet.HostVitals, error) {
+	s.ListHostVitalsFuncInvoked = true
+	return s.ListHostVitalsFunc(ctx, hostID, opt)
+}
+
+func (s *DataStore) CleanupHostVitalsHistory(ctx context.Context, olderThan time.Time) error {
+	s.CleanupHostVitalsHistoryFuncInvoked = true
+	return s.CleanupHostVitalsHistoryFunc(ctx, olderThan)
+}
+
 func (s *DataStore) ListOperatingSystems(ctx context.Context) ([]fleet.OperatingSystem, error) {
 	s.ListOperatingSystemsFuncInvoked = true
 	return s.ListOperatingSystemsFunc(ctx)
@@ -1990,11 +2680,61 @@ func (s *DataStore) NewActivity(ctx context.Context, user *fleet.User, activityT
 	return s.NewActivityFunc(ctx, user, activityType, details)
 }
 
+func (s *DataStore) CreateActivity(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) (*fleet.Activity, error) {
+	s.CreateActivityFuncInvoked = true
+	return s.CreateActivityFunc(ctx, user, activityType, details)
+}
+
 func (s *DataStore) ListActivities(ctx context.Context, opt fleet.ListOptions) ([]*fleet.Activity, error) {
 	s.ListActivitiesFuncInvoked = true
 	return s.ListActivitiesFunc(ctx, opt)
 }
 
+func (s *DataStore) Activity(ctx context.Context, id uint) (*fleet.Activity, error) {
+	s.ActivityFuncInvoked = true
+	return s.ActivityFunc(ctx, id)
+}
+
+func (s *DataStore) NewActivityWebhook(ctx context.Context, webhook *fleet.ActivityWebhook) (*fleet.ActivityWebhook, error) {
+	s.NewActivityWebhookFuncInvoked = true
+	return s.NewActivityWebhookFunc(ctx, webhook)
+}
+
+func (s *DataStore) ActivityWebhook(ctx context.Context, id uint) (*fleet.ActivityWebhook, error) {
+	s.ActivityWebhookFuncInvoked = true
+	return s.ActivityWebhookFunc(ctx, id)
+}
+
+func (s *DataStore) ListActivityWebhooks(ctx context.Context) ([]*fleet.ActivityWebhook, error) {
+	s.ListActivityWebhooksFuncInvoked = true
+	return s.ListActivityWebhooksFunc(ctx)
+}
+
+func (s *DataStore) SaveActivityWebhook(ctx context.Context, webhook *fleet.ActivityWebhook) error {
+	s.SaveActivityWebhookFuncInvoked = true
+	return s.SaveActivityWebhookFunc(ctx, webhook)
+}
+
+func (s *DataStore) DeleteActivityWebhook(ctx context.Context, id uint) error {
+	s.DeleteActivityWebhookFuncInvoked = true
+	return s.DeleteActivityWebhookFunc(ctx, id)
+}
+
+func (s *DataStore) NewActivityWebhookDelivery(ctx context.Context, delivery *fleet.ActivityWebhookDelivery) error {
+	s.NewActivityWebhookDeliveryFuncInvoked = true
+	return s.NewActivityWebhookDeliveryFunc(ctx, delivery)
+}
+
+func (s *DataStore) ListActivityWebhookDeliveries(ctx context.Context, webhookID uint, opt fleet.ListOptions) ([]*fleet.ActivityWebhookDelivery, error) {
+	s.ListActivityWebhookDeliveriesFuncInvoked = true
+	return s.ListActivityWebhookDeliveriesFunc(ctx, webhookID, opt)
+}
+
+func (s *DataStore) CleanupActivityWebhookDeliveries(ctx context.Context, now time.Time) error {
+	s.CleanupActivityWebhookDeliveriesFuncInvoked = true
+	return s.CleanupActivityWebhookDeliveriesFunc(ctx, now)
+}
+
 func (s *DataStore) ShouldSendStatistics(ctx context.Context, frequency time.Duration, config config.FleetConfig, license *fleet.LicenseInfo) (fleet.StatisticsPayload, bool, error) {
 	s.ShouldSendStatisticsFuncInvoked = true
 	return s.ShouldSendStatisticsFunc(ctx, frequency, config, license)
@@ -2050,6 +2790,56 @@ func (s *DataStore) PolicyQueriesForHost(ctx context.Context, host *fleet.Host)
 	return s.PolicyQueriesForHostFunc(ctx, host)
 }
 
+func (s *DataStore) PolicyRemediationsForHost(ctx context.Context, host *fleet.Host) (map[uint]*fleet.Policy, error) {
+	s.PolicyRemediationsForHostFuncInvoked = true
+	return s.PolicyRemediationsForHostFunc(ctx, host)
+}
+
+func (s *DataStore) PolicyResultsBySeverity(ctx context.Context, filter fleet.TeamFilter, platform *string) ([]*fleet.PolicySeverityResults, error) {
+	s.PolicyResultsBySeverityFuncInvoked = true
+	return s.PolicyResultsBySeverityFunc(ctx, filter, platform)
+}
+
+func (s *DataStore) ListHostPolicyTransitions(ctx context.Context, hostID uint, policyID *uint, opt fleet.ListOptions) ([]*fleet.PolicyMembershipTransition, error) {
+	s.ListHostPolicyTransitionsFuncInvoked = true
+	return s.ListHostPolicyTransitionsFunc(ctx, hostID, policyID, opt)
+}
+
+func (s *DataStore) PolicyDailyCounts(ctx context.Context, policyID uint, from time.Time, to time.Time) ([]*fleet.PolicyDailyCount, error) {
+	s.PolicyDailyCountsFuncInvoked = true
+	return s.PolicyDailyCountsFunc(ctx, policyID, from, to)
+}
+
+func (s *DataStore) CleanupPolicyMembershipHistory(ctx context.Context, olderThan time.Time) error {
+	s.CleanupPolicyMembershipHistoryFuncInvoked = true
+	return s.CleanupPolicyMembershipHistoryFunc(ctx, olderThan)
+}
+
+func (s *DataStore) NewPolicyWaiver(ctx context.Context, waiver *fleet.PolicyWaiver) (*fleet.PolicyWaiver, error) {
+	s.NewPolicyWaiverFuncInvoked = true
+	return s.NewPolicyWaiverFunc(ctx, waiver)
+}
+
+func (s *DataStore) ListPolicyWaivers(ctx context.Context, policyID uint) ([]*fleet.PolicyWaiver, error) {
+	s.ListPolicyWaiversFuncInvoked = true
+	return s.ListPolicyWaiversFunc(ctx, policyID)
+}
+
+func (s *DataStore) DeletePolicyWaiver(ctx context.Context, policyID uint, waiverID uint) error {
+	s.DeletePolicyWaiverFuncInvoked = true
+	return s.DeletePolicyWaiverFunc(ctx, policyID, waiverID)
+}
+
+func (s *DataStore) ListExpiredPolicyWaiverFailingHosts(ctx context.Context, now time.Time) ([]*fleet.PolicyWaiverFailingHost, error) {
+	s.ListExpiredPolicyWaiverFailingHostsFuncInvoked = true
+	return s.ListExpiredPolicyWaiverFailingHostsFunc(ctx, now)
+}
+
+func (s *DataStore) DeleteExpiredPolicyWaivers(ctx context.Context, now time.Time) error {
+	s.DeleteExpiredPolicyWaiversFuncInvoked = true
+	return s.DeleteExpiredPolicyWaiversFunc(ctx, now)
+}
+
 func (s *DataStore) AsyncBatchInsertPolicyMembership(ctx context.Context, batch []fleet.PolicyMembershipResult) error {
 	s.AsyncBatchInsertPolicyMembershipFuncInvoked = true
 	return s.AsyncBatchInsertPolicyMembershipFunc(ctx, batch)
@@ -2125,6 +2915,41 @@ func (s *DataStore) InitializePolicyViolationDays(ctx context.Context) error {
 	return s.InitializePolicyViolationDaysFunc(ctx)
 }
 
+func (s *DataStore) NewHostScriptExecution(ctx context.Context, exec *fleet.HostScriptExecution) (*fleet.HostScriptExecution, error) {
+	s.NewHostScriptExecutionFuncInvoked = true
+	return s.NewHostScriptExecutionFunc(ctx, exec)
+}
+
+func (s *DataStore) NewHostScriptExecutions(ctx context.Context, execs []*fleet.HostScriptExecution) ([]*fleet.HostScriptExecution, error) {
+	s.NewHostScriptExecutionsFuncInvoked = true
+	return s.NewHostScriptExecutionsFunc(ctx, execs)
+}
+
+func (s *DataStore) HostScriptExecution(ctx context.Context, id uint) (*fleet.HostScriptExecution, error) {
+	s.HostScriptExecutionFuncInvoked = true
+	return s.HostScriptExecutionFunc(ctx, id)
+}
+
+func (s *DataStore) ListHostScriptExecutions(ctx context.Context, hostID uint, opt fleet.ListOptions) ([]*fleet.HostScriptExecution, error) {
+	s.ListHostScriptExecutionsFuncInvoked = true
+	return s.ListHostScriptExecutionsFunc(ctx, hostID, opt)
+}
+
+func (s *DataStore) ListPendingHostScriptExecutions(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+	s.ListPendingHostScriptExecutionsFuncInvoked = true
+	return s.ListPendingHostScriptExecutionsFunc(ctx, hostID, execType)
+}
+
+func (s *DataStore) MarkHostScriptExecutionsSent(ctx context.Context, ids []uint) error {
+	s.MarkHostScriptExecutionsSentFuncInvoked = true
+	return s.MarkHostScriptExecutionsSentFunc(ctx, ids)
+}
+
+func (s *DataStore) SetHostScriptExecutionResult(ctx context.Context, res *fleet.HostScriptExecutionResult) (*fleet.HostScriptExecution, error) {
+	s.SetHostScriptExecutionResultFuncInvoked = true
+	return s.SetHostScriptExecutionResultFunc(ctx, res)
+}
+
 func (s *DataStore) Lock(ctx context.Context, name string, owner string, expiration time.Duration) (bool, error) {
 	s.LockFuncInvoked = true
 	return s.LockFunc(ctx, name, owner, expiration)
@@ -2165,6 +2990,11 @@ func (s *DataStore) HostLite(ctx context.Context, hostID uint) (*fleet.Host, err
 	return s.HostLiteFunc(ctx, hostID)
 }
 
+func (s *DataStore) ListHostsLiteByIDs(ctx context.Context, hostIDs []uint) ([]*fleet.Host, error) {
+	s.ListHostsLiteByIDsFuncInvoked = true
+	return s.ListHostsLiteByIDsFunc(ctx, hostIDs)
+}
+
 func (s *DataStore) UpdateHostOsqueryIntervals(ctx context.Context, hostID uint, intervals fleet.HostOsqueryIntervals) error {
 	s.UpdateHostOsqueryIntervalsFuncInvoked = true
 	return s.UpdateHostOsqueryIntervalsFunc(ctx, hostID, intervals)
@@ -2180,6 +3010,16 @@ func (s *DataStore) TeamFeatures(ctx context.Context, teamID uint) (*fleet.Featu
 	return s.TeamFeaturesFunc(ctx, teamID)
 }
 
+func (s *DataStore) TeamScriptSettings(ctx context.Context, teamID uint) (*fleet.ScriptSettings, error) {
+	s.TeamScriptSettingsFuncInvoked = true
+	return s.TeamScriptSettingsFunc(ctx, teamID)
+}
+
+func (s *DataStore) ListHostAssignmentRules(ctx context.Context) (fleet.TeamHostAssignmentRules, error) {
+	s.ListHostAssignmentRulesFuncInvoked = true
+	return s.ListHostAssignmentRulesFunc(ctx)
+}
+
 func (s *DataStore) SaveHostPackStats(ctx context.Context, hostID uint, stats []fleet.PackStats) error {
 	s.SaveHostPackStatsFuncInvoked = true
 	return s.SaveHostPackStatsFunc(ctx, hostID, stats)
@@ -2285,9 +3125,9 @@ func (s *DataStore) NewJob(ctx context.Context, job *fleet.Job) (*fleet.Job, err
 	return s.NewJobFunc(ctx, job)
 }
 
-func (s *DataStore) GetQueuedJobs(ctx context.Context, maxNumJobs int) ([]*fleet.Job, error) {
+func (s *DataStore) GetQueuedJobs(ctx context.Context, maxNumJobs int, names []string) ([]*fleet.Job, error) {
 	s.GetQueuedJobsFuncInvoked = true
-	return s.GetQueuedJobsFunc(ctx, maxNumJobs)
+	return s.GetQueuedJobsFunc(ctx, maxNumJobs, names)
 }
 
 func (s *DataStore) UpdateJob(ctx context.Context, id uint, job *fleet.Job) (*fleet.Job, error) {
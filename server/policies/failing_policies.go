@@ -130,6 +130,10 @@ func TriggerFailingPoliciesAutomation(
 				continue
 			}
 
+			if err := removeRetiredHosts(ctx, ds, failingPoliciesSet, policy.ID); err != nil {
+				level.Error(logger).Log("msg", "failed to remove retired hosts from set", "policyID", policy.ID, "err", err)
+				continue
+			}
 			if err := sendFunc(policy, teamCfg); err != nil {
 				level.Error(logger).Log("msg", "failed to send failing policies", "policyID", policy.ID, "err", err)
 			}
@@ -145,6 +149,10 @@ func TriggerFailingPoliciesAutomation(
 			continue
 		}
 
+		if err := removeRetiredHosts(ctx, ds, failingPoliciesSet, policy.ID); err != nil {
+			level.Error(logger).Log("msg", "failed to remove retired hosts from set", "policyID", policy.ID, "err", err)
+			continue
+		}
 		if err := sendFunc(policy, globalCfg); err != nil {
 			level.Error(logger).Log("msg", "failed to send failing policies", "policyID", policy.ID, "err", err)
 		}
@@ -153,6 +161,45 @@ func TriggerFailingPoliciesAutomation(
 	return nil
 }
 
+// removeRetiredHosts removes the retired hosts from the set of the policy, so
+// that they are excluded from the automations. The hosts may have been retired
+// after they were added to the set.
+func removeRetiredHosts(ctx context.Context, ds fleet.Datastore, failingPoliciesSet fleet.FailingPolicySet, policyID uint) error {
+	hosts, err := failingPoliciesSet.ListHosts(policyID)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list hosts of policy set")
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	hostIDs := make([]uint, 0, len(hosts))
+	for _, host := range hosts {
+		hostIDs = append(hostIDs, host.ID)
+	}
+	liteHosts, err := ds.ListHostsLiteByIDs(ctx, hostIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list hosts of policy set")
+	}
+	retired := make(map[uint]bool)
+	for _, host := range liteHosts {
+		if host.LifecycleState == fleet.HostLifecycleStateRetired {
+			retired[host.ID] = true
+		}
+	}
+	if len(retired) == 0 {
+		return nil
+	}
+
+	var toRemove []fleet.PolicySetHost
+	for _, host := range hosts {
+		if retired[host.ID] {
+			toRemove = append(toRemove, host)
+		}
+	}
+	return failingPoliciesSet.RemoveHosts(policyID, toRemove)
+}
+
 func makeTeamConfigCache(ds fleet.Datastore, globalIntgs fleet.Integrations) func(ctx context.Context, teamID uint) (FailingPolicyAutomationConfig, error) {
 	teamCfgs := make(map[uint]FailingPolicyAutomationConfig)
 
@@ -144,6 +144,19 @@ func TestTriggerFailingPolicies(t *testing.T) {
 		return ac, nil
 	}
 
+	// the host 101 is retired, it is never sent to the automations
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, ids []uint) ([]*fleet.Host, error) {
+		hosts := make([]*fleet.Host, 0, len(ids))
+		for _, id := range ids {
+			state := fleet.HostLifecycleStateActive
+			if id == 101 {
+				state = fleet.HostLifecycleStateRetired
+			}
+			hosts = append(hosts, &fleet.Host{ID: id, LifecycleState: state})
+		}
+		return hosts, nil
+	}
+
 	// add a failing policy host for every known policy
 	failingPolicySet := service.NewMemFailingPolicySet()
 	for polID := range pols {
@@ -153,8 +166,13 @@ func TestTriggerFailingPolicies(t *testing.T) {
 		})
 		require.NoError(t, err)
 	}
+	err := failingPolicySet.AddHost(1, fleet.PolicySetHost{
+		ID:       101,
+		Hostname: "host101.example",
+	})
+	require.NoError(t, err)
 	// add a failing policy for the unknown one
-	err := failingPolicySet.AddHost(11, fleet.PolicySetHost{
+	err = failingPolicySet.AddHost(11, fleet.PolicySetHost{
 		ID:       11,
 		Hostname: "host11.example",
 	})
@@ -170,6 +188,9 @@ func TestTriggerFailingPolicies(t *testing.T) {
 
 		hosts, err := failingPolicySet.ListHosts(pol.ID)
 		require.NoError(t, err)
+		for _, h := range hosts {
+			require.NotEqual(t, uint(101), h.ID)
+		}
 		err = failingPolicySet.RemoveHosts(pol.ID, hosts)
 		require.NoError(t, err)
 
@@ -0,0 +1,45 @@
+package policies
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// DeleteExpiredPolicyWaivers deletes the policy waivers that expired at now.
+// The hosts that fail a policy they were exempted from are added to the
+// failing policies set of that policy before the waivers are deleted, as the
+// expiry of the waiver is a flip of the policy from passing to failing for
+// those hosts.
+func DeleteExpiredPolicyWaivers(
+	ctx context.Context,
+	ds fleet.Datastore,
+	logger kitlog.Logger,
+	failingPoliciesSet fleet.FailingPolicySet,
+	now time.Time,
+) error {
+	hosts, err := ds.ListExpiredPolicyWaiverFailingHosts(ctx, now)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list failing hosts of expired policy waivers")
+	}
+
+	for _, h := range hosts {
+		level.Debug(logger).Log("msg", "policy waiver expired", "policyID", h.PolicyID, "hostID", h.HostID)
+		if err := failingPoliciesSet.AddHost(h.PolicyID, fleet.PolicySetHost{
+			ID:          h.HostID,
+			Hostname:    h.Hostname,
+			DisplayName: h.DisplayName,
+		}); err != nil {
+			return ctxerr.Wrapf(ctx, err, "add host %d to failing policy set %d", h.HostID, h.PolicyID)
+		}
+	}
+
+	if err := ds.DeleteExpiredPolicyWaivers(ctx, now); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete expired policy waivers")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package policies
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/service"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteExpiredPolicyWaivers(t *testing.T) {
+	ds := new(mock.Store)
+	now := time.Now()
+
+	ds.ListExpiredPolicyWaiverFailingHostsFunc = func(ctx context.Context, expiredAt time.Time) ([]*fleet.PolicyWaiverFailingHost, error) {
+		require.Equal(t, now, expiredAt)
+		return []*fleet.PolicyWaiverFailingHost{
+			{PolicyID: 1, HostID: 1, Hostname: "host1.example", DisplayName: "host1"},
+			{PolicyID: 1, HostID: 2, Hostname: "host2.example", DisplayName: "host2.example"},
+			{PolicyID: 2, HostID: 1, Hostname: "host1.example", DisplayName: "host1"},
+		}, nil
+	}
+	ds.DeleteExpiredPolicyWaiversFunc = func(ctx context.Context, expiredAt time.Time) error {
+		require.Equal(t, now, expiredAt)
+		return nil
+	}
+
+	// the failing hosts of the expired waivers are processed by the failing
+	// policies automations
+	failingPolicySet := service.NewMemFailingPolicySet()
+	require.NoError(t, DeleteExpiredPolicyWaivers(context.Background(), ds, kitlog.NewNopLogger(), failingPolicySet, now))
+	require.True(t, ds.DeleteExpiredPolicyWaiversFuncInvoked)
+
+	hosts, err := failingPolicySet.ListHosts(1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []fleet.PolicySetHost{
+		{ID: 1, Hostname: "host1.example", DisplayName: "host1"},
+		{ID: 2, Hostname: "host2.example", DisplayName: "host2.example"},
+	}, hosts)
+	hosts, err = failingPolicySet.ListHosts(2)
+	require.NoError(t, err)
+	require.Equal(t, []fleet.PolicySetHost{{ID: 1, Hostname: "host1.example", DisplayName: "host1"}}, hosts)
+
+	// the waivers are not deleted if their failing hosts could not be listed,
+	// so that they are processed at the next run
+	ds.DeleteExpiredPolicyWaiversFuncInvoked = false
+	ds.ListExpiredPolicyWaiverFailingHostsFunc = func(ctx context.Context, expiredAt time.Time) ([]*fleet.PolicyWaiverFailingHost, error) {
+		return nil, errors.New("list failed")
+	}
+	require.Error(t, DeleteExpiredPolicyWaivers(context.Background(), ds, kitlog.NewNopLogger(), failingPolicySet, now))
+	require.False(t, ds.DeleteExpiredPolicyWaiversFuncInvoked)
+}
@@ -0,0 +1,41 @@
+package policies
+
+import (
+	"context"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// UpdateSoftwareRuleViolations recomputes the hosts violating the software
+// rules. The hosts that started violating a rule linked to a policy are added
+// to the failing policies set of that policy, so that they are processed by
+// the failing policies automations configured for the policy.
+func UpdateSoftwareRuleViolations(
+	ctx context.Context,
+	ds fleet.Datastore,
+	logger kitlog.Logger,
+	failingPoliciesSet fleet.FailingPolicySet,
+) error {
+	violations, err := ds.UpdateSoftwareRuleViolations(ctx)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "update software rule violations")
+	}
+
+	for _, v := range violations {
+		if v.PolicyID == nil {
+			continue
+		}
+		level.Debug(logger).Log("msg", "host violates software rule", "ruleID", v.RuleID, "policyID", *v.PolicyID, "hostID", v.HostID)
+		if err := failingPoliciesSet.AddHost(*v.PolicyID, fleet.PolicySetHost{
+			ID:          v.HostID,
+			Hostname:    v.Hostname,
+			DisplayName: v.DisplayName,
+		}); err != nil {
+			return ctxerr.Wrapf(ctx, err, "add host %d to failing policy set %d", v.HostID, *v.PolicyID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package sbom
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+// CycloneDXSpecVersion is the version of the CycloneDX specification of the
+// documents.
+const CycloneDXSpecVersion = "1.4"
+
+// CycloneDXBOM is a CycloneDX JSON document, see
+// https://cyclonedx.org/docs/1.4/json/. Only the fields used by Fleet are
+// defined.
+type CycloneDXBOM struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	SerialNumber    string                   `json:"serialNumber"`
+	Version         int                      `json:"version"`
+	Metadata        CycloneDXMetadata        `json:"metadata"`
+	Components      []CycloneDXComponent     `json:"components"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+type CycloneDXMetadata struct {
+	Timestamp  string              `json:"timestamp"`
+	Tools      []CycloneDXTool     `json:"tools"`
+	Component  *CycloneDXComponent `json:"component,omitempty"`
+	Properties []CycloneDXProperty `json:"properties,omitempty"`
+}
+
+type CycloneDXTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type CycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref"`
+	Publisher  string              `json:"publisher,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	CPE        string              `json:"cpe,omitempty"`
+	Properties []CycloneDXProperty `json:"properties,omitempty"`
+}
+
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CycloneDXVulnerability struct {
+	BOMRef     string              `json:"bom-ref"`
+	ID         string              `json:"id"`
+	Source     CycloneDXSource     `json:"source"`
+	Ratings    []CycloneDXRating   `json:"ratings,omitempty"`
+	Affects    []CycloneDXAffect   `json:"affects"`
+	Properties []CycloneDXProperty `json:"properties,omitempty"`
+}
+
+type CycloneDXSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type CycloneDXRating struct {
+	Source CycloneDXSource `json:"source"`
+	Score  float64         `json:"score"`
+	Method string          `json:"method"`
+}
+
+type CycloneDXAffect struct {
+	Ref string `json:"ref"`
+}
+
+// NewCycloneDX creates the CycloneDX document of the software. The known
+// vulnerabilities of the software are listed in the document's
+// vulnerabilities, with a reference to the affected software.
+func NewCycloneDX(meta Metadata, software []fleet.Software) *CycloneDXBOM {
+	bom := &CycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  CycloneDXSpecVersion,
+		SerialNumber: "urn:uuid:" + meta.ID,
+		Version:      1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: meta.Timestamp.UTC().Format(time.RFC3339),
+			Tools:     []CycloneDXTool{{Vendor: toolVendor, Name: toolName, Version: meta.ToolVersion}},
+		},
+		Components: make([]CycloneDXComponent, 0, len(software)),
+	}
+
+	if h := meta.Subject.Host; h != nil {
+		bom.Metadata.Component = &CycloneDXComponent{
+			Type:   "device",
+			BOMRef: fmt.Sprintf("host-%d", h.ID),
+			Name:   meta.Subject.Name,
+			Properties: nonEmptyProperties(
+				"fleet:host_uuid", h.UUID,
+				"fleet:hardware_serial", h.HardwareSerial,
+				"fleet:platform", h.Platform,
+				"fleet:os_version", h.OSVersion,
+			),
+		}
+	} else {
+		bom.Metadata.Properties = []CycloneDXProperty{{Name: "fleet:scope", Value: meta.Subject.Name}}
+	}
+
+	vulnsByCVE := make(map[string]*CycloneDXVulnerability)
+	for _, s := range software {
+		ref := softwareRef(s)
+		bom.Components = append(bom.Components, CycloneDXComponent{
+			Type:      "application",
+			BOMRef:    ref,
+			Publisher: s.Vendor,
+			Name:      s.Name,
+			Version:   s.Version,
+			CPE:       s.GenerateCPE,
+			Properties: nonEmptyProperties(
+				"fleet:source", s.Source,
+				"fleet:bundle_identifier", s.BundleIdentifier,
+				"fleet:release", s.Release,
+				"fleet:arch", s.Arch,
+			),
+		})
+
+		for _, cve := range s.Vulnerabilities {
+			v, ok := vulnsByCVE[cve.CVE]
+			if !ok {
+				v = &CycloneDXVulnerability{
+					BOMRef: "vulnerability-" + cve.CVE,
+					ID:     cve.CVE,
+					Source: CycloneDXSource{Name: "NVD", URL: cveDetailsLink(cve)},
+				}
+				if cve.CVSSScore != nil && *cve.CVSSScore != nil {
+					v.Ratings = []CycloneDXRating{{Source: CycloneDXSource{Name: "NVD"}, Score: **cve.CVSSScore, Method: "CVSSv3"}}
+				}
+				if cve.EPSSProbability != nil && *cve.EPSSProbability != nil {
+					v.Properties = append(v.Properties, CycloneDXProperty{Name: "fleet:epss_probability", Value: fmt.Sprint(**cve.EPSSProbability)})
+				}
+				if cve.CISAKnownExploit != nil && *cve.CISAKnownExploit != nil {
+					v.Properties = append(v.Properties, CycloneDXProperty{Name: "fleet:cisa_known_exploit", Value: fmt.Sprint(**cve.CISAKnownExploit)})
+				}
+				vulnsByCVE[cve.CVE] = v
+			}
+			v.Affects = append(v.Affects, CycloneDXAffect{Ref: ref})
+		}
+	}
+
+	cves := make([]string, 0, len(vulnsByCVE))
+	for cve := range vulnsByCVE {
+		cves = append(cves, cve)
+	}
+	sort.Strings(cves)
+	for _, cve := range cves {
+		bom.Vulnerabilities = append(bom.Vulnerabilities, *vulnsByCVE[cve])
+	}
+	return bom
+}
+
+// nonEmptyProperties returns the properties of the name and value pairs
+// whose value is not empty.
+func nonEmptyProperties(namesAndValues ...string) []CycloneDXProperty {
+	var props []CycloneDXProperty
+	for i := 0; i+1 < len(namesAndValues); i += 2 {
+		if namesAndValues[i+1] != "" {
+			props = append(props, CycloneDXProperty{Name: namesAndValues[i], Value: namesAndValues[i+1]})
+		}
+	}
+	return props
+}
+
+func softwareRef(s fleet.Software) string {
+	return fmt.Sprintf("software-%d", s.ID)
+}
@@ -0,0 +1,74 @@
+// Package sbom serializes software inventories into software bill of
+// materials (SBOM) documents, in the CycloneDX and SPDX formats.
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+const (
+	toolVendor = "Fleet Device Management Inc."
+	toolName   = "fleet"
+)
+
+// Subject is what the SBOM describes: a host, a team or the whole fleet.
+type Subject struct {
+	// Name is the name of the host, team or fleet.
+	Name string
+	// Host is set if the SBOM describes a host.
+	Host *fleet.Host
+}
+
+// Metadata holds the information about the SBOM document itself.
+type Metadata struct {
+	Subject Subject
+	// ID uniquely identifies the document, it is a UUID.
+	ID string
+	// Timestamp is the time at which the document was created.
+	Timestamp time.Time
+	// ToolVersion is the version of Fleet that created the document.
+	ToolVersion string
+}
+
+// ContentType returns the media type of the documents of the format.
+func ContentType(format fleet.SBOMFormat) string {
+	switch format {
+	case fleet.SBOMFormatCycloneDX:
+		return "application/vnd.cyclonedx+json"
+	case fleet.SBOMFormatSPDX:
+		return "application/spdx+json"
+	default:
+		return "application/json"
+	}
+}
+
+// Generate serializes the software into a JSON SBOM document of the format.
+func Generate(format fleet.SBOMFormat, meta Metadata, software []fleet.Software) ([]byte, error) {
+	var doc interface{}
+	switch format {
+	case fleet.SBOMFormatCycloneDX:
+		doc = NewCycloneDX(meta, software)
+	case fleet.SBOMFormatSPDX:
+		doc = NewSPDX(meta, software)
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format: %s", format)
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s document: %w", format, err)
+	}
+	return b, nil
+}
+
+// cveDetailsLink returns the link to the details of the CVE in the National
+// Vulnerability Database.
+func cveDetailsLink(cve fleet.CVE) string {
+	if cve.DetailsLink != "" {
+		return cve.DetailsLink
+	}
+	return "https://nvd.nist.gov/vuln/detail/" + cve.CVE
+}
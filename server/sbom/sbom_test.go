@@ -0,0 +1,159 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/santhosh-tekuri/jsonschema"
+	"github.com/stretchr/testify/require"
+)
+
+func testSoftware() []fleet.Software {
+	return []fleet.Software{
+		{
+			ID:          1,
+			Name:        "Google Chrome.app",
+			Version:     "106.0.5249.119",
+			Source:      "apps",
+			Vendor:      "Google LLC",
+			GenerateCPE: "cpe:2.3:a:google:chrome:106.0.5249.119:*:*:*:*:macos:*:*",
+			Vulnerabilities: fleet.Vulnerabilities{
+				{CVE: "CVE-2022-3445", CVSSScore: ptr.Float64Ptr(8.8), EPSSProbability: ptr.Float64Ptr(0.01), CISAKnownExploit: ptr.BoolPtr(false)},
+				{CVE: "CVE-2022-3307"},
+			},
+			BundleIdentifier: "com.google.Chrome",
+		},
+		{
+			ID:          2,
+			Name:        "curl",
+			Version:     "7.81.0-1ubuntu1.4",
+			Source:      "deb_packages",
+			Vendor:      "Ubuntu Developers <ubuntu-devel-discuss@lists.ubuntu.com>",
+			GenerateCPE: "cpe:2.3:a:haxx:curl:7.81.0:*:*:*:*:*:*:*",
+			Vulnerabilities: fleet.Vulnerabilities{
+				{CVE: "CVE-2022-3307", DetailsLink: "https://nvd.nist.gov/vuln/detail/CVE-2022-3307"},
+			},
+		},
+		{
+			ID:      3,
+			Name:    "bash",
+			Version: "5.1",
+			Source:  "deb_packages",
+			Arch:    "amd64",
+		},
+	}
+}
+
+func testMetadata(host *fleet.Host) Metadata {
+	meta := Metadata{
+		Subject:     Subject{Name: "Workstations"},
+		ID:          "0b1f6f0a-37a4-4f57-8f43-64e7e2d7d0c4",
+		Timestamp:   time.Date(2022, 10, 24, 12, 0, 0, 0, time.UTC),
+		ToolVersion: "4.22.0",
+	}
+	if host != nil {
+		meta.Subject = Subject{Name: host.Hostname, Host: host}
+	}
+	return meta
+}
+
+// validateSchema validates the document against the schema of the testdata
+// file. The testdata schemas are hand-written subsets of the CycloneDX and
+// SPDX schemas covering the fields generated by Fleet, not the official
+// schemas.
+func validateSchema(t *testing.T, schemaFile string, doc []byte) {
+	schema, err := jsonschema.Compile(filepath.Join("testdata", schemaFile))
+	require.NoError(t, err)
+	require.NoError(t, schema.Validate(bytes.NewReader(doc)))
+}
+
+func TestGenerateCycloneDX(t *testing.T) {
+	host := &fleet.Host{ID: 7, Hostname: "mbp.local", UUID: "a1b2", HardwareSerial: "C02XYZ", Platform: "darwin", OSVersion: "macOS 12.6"}
+
+	for _, h := range []*fleet.Host{nil, host} {
+		doc, err := Generate(fleet.SBOMFormatCycloneDX, testMetadata(h), testSoftware())
+		require.NoError(t, err)
+		validateSchema(t, "cyclonedx-1.4-subset.schema.json", doc)
+
+		var bom CycloneDXBOM
+		require.NoError(t, json.Unmarshal(doc, &bom))
+		require.Equal(t, "urn:uuid:0b1f6f0a-37a4-4f57-8f43-64e7e2d7d0c4", bom.SerialNumber)
+		require.Equal(t, "2022-10-24T12:00:00Z", bom.Metadata.Timestamp)
+		require.Len(t, bom.Components, 3)
+		require.Equal(t, "cpe:2.3:a:google:chrome:106.0.5249.119:*:*:*:*:macos:*:*", bom.Components[0].CPE)
+		require.Equal(t, "Google LLC", bom.Components[0].Publisher)
+		require.Contains(t, bom.Components[2].Properties, CycloneDXProperty{Name: "fleet:arch", Value: "amd64"})
+
+		// vulnerabilities are grouped by CVE and reference the affected software
+		require.Len(t, bom.Vulnerabilities, 2)
+		require.Equal(t, "CVE-2022-3307", bom.Vulnerabilities[0].ID)
+		require.Equal(t, []CycloneDXAffect{{Ref: "software-1"}, {Ref: "software-2"}}, bom.Vulnerabilities[0].Affects)
+		require.Empty(t, bom.Vulnerabilities[0].Ratings)
+		require.Equal(t, "CVE-2022-3445", bom.Vulnerabilities[1].ID)
+		require.Equal(t, "https://nvd.nist.gov/vuln/detail/CVE-2022-3445", bom.Vulnerabilities[1].Source.URL)
+		require.Equal(t, []CycloneDXRating{{Source: CycloneDXSource{Name: "NVD"}, Score: 8.8, Method: "CVSSv3"}}, bom.Vulnerabilities[1].Ratings)
+
+		if h == nil {
+			require.Nil(t, bom.Metadata.Component)
+			require.Equal(t, []CycloneDXProperty{{Name: "fleet:scope", Value: "Workstations"}}, bom.Metadata.Properties)
+		} else {
+			require.NotNil(t, bom.Metadata.Component)
+			require.Equal(t, "device", bom.Metadata.Component.Type)
+			require.Equal(t, "mbp.local", bom.Metadata.Component.Name)
+			require.Contains(t, bom.Metadata.Component.Properties, CycloneDXProperty{Name: "fleet:hardware_serial", Value: "C02XYZ"})
+		}
+	}
+}
+
+func TestGenerateSPDX(t *testing.T) {
+	host := &fleet.Host{ID: 7, Hostname: "mbp.local", UUID: "a1b2", HardwareSerial: "C02XYZ", Platform: "darwin", OSVersion: "macOS 12.6"}
+
+	doc, err := Generate(fleet.SBOMFormatSPDX, testMetadata(nil), testSoftware())
+	require.NoError(t, err)
+	validateSchema(t, "spdx-2.3-subset.schema.json", doc)
+
+	var spdx SPDXDocument
+	require.NoError(t, json.Unmarshal(doc, &spdx))
+	require.Equal(t, "SPDX-2.3", spdx.SPDXVersion)
+	require.Equal(t, "https://fleetdm.com/spdxdocs/Workstations-0b1f6f0a-37a4-4f57-8f43-64e7e2d7d0c4", spdx.DocumentNamespace)
+	require.Equal(t, []string{"Organization: Fleet Device Management Inc.", "Tool: fleet-4.22.0"}, spdx.CreationInfo.Creators)
+	require.Len(t, spdx.Packages, 3)
+	require.Equal(t, "Organization: Google LLC", spdx.Packages[0].Supplier)
+	require.Equal(t, "Organization: Ubuntu Developers ubuntu-devel-discuss@lists.ubuntu.com", spdx.Packages[1].Supplier)
+	require.Equal(t, "NOASSERTION", spdx.Packages[2].Supplier)
+	require.Equal(t, []SPDXExternalRef{
+		{ReferenceCategory: "SECURITY", ReferenceType: "cpe23Type", ReferenceLocator: "cpe:2.3:a:google:chrome:106.0.5249.119:*:*:*:*:macos:*:*"},
+		{ReferenceCategory: "SECURITY", ReferenceType: "advisory", ReferenceLocator: "https://nvd.nist.gov/vuln/detail/CVE-2022-3445"},
+		{ReferenceCategory: "SECURITY", ReferenceType: "advisory", ReferenceLocator: "https://nvd.nist.gov/vuln/detail/CVE-2022-3307"},
+	}, spdx.Packages[0].ExternalRefs)
+	require.Empty(t, spdx.Packages[2].ExternalRefs)
+	require.Len(t, spdx.Relationships, 3)
+	for _, rel := range spdx.Relationships {
+		require.Equal(t, "SPDXRef-DOCUMENT", rel.SPDXElementID)
+		require.Equal(t, "DESCRIBES", rel.RelationshipType)
+	}
+
+	// the host is the root package that contains the software
+	doc, err = Generate(fleet.SBOMFormatSPDX, testMetadata(host), testSoftware())
+	require.NoError(t, err)
+	validateSchema(t, "spdx-2.3-subset.schema.json", doc)
+
+	spdx = SPDXDocument{}
+	require.NoError(t, json.Unmarshal(doc, &spdx))
+	require.Len(t, spdx.Packages, 4)
+	require.Equal(t, "SPDXRef-Host-7", spdx.Packages[0].SPDXID)
+	require.Equal(t, "DEVICE", spdx.Packages[0].PrimaryPackagePurpose)
+	require.Len(t, spdx.Relationships, 4)
+	require.Equal(t, SPDXRelationship{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Host-7"}, spdx.Relationships[0])
+	require.Equal(t, SPDXRelationship{SPDXElementID: "SPDXRef-Host-7", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-Package-3"}, spdx.Relationships[3])
+}
+
+func TestGenerateUnsupportedFormat(t *testing.T) {
+	_, err := Generate("swid", testMetadata(nil), testSoftware())
+	require.ErrorContains(t, err, "unsupported SBOM format")
+}
@@ -0,0 +1,147 @@
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+// SPDXVersion is the version of the SPDX specification of the documents.
+const SPDXVersion = "SPDX-2.3"
+
+const (
+	spdxDocumentID = "SPDXRef-DOCUMENT"
+	spdxNoAssert   = "NOASSERTION"
+)
+
+// SPDXDocument is a SPDX JSON document, see
+// https://spdx.github.io/spdx-spec/v2.3/. Only the fields used by Fleet are
+// defined.
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	Supplier              string            `json:"supplier"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs          []SPDXExternalRef `json:"externalRefs,omitempty"`
+	Comment               string            `json:"comment,omitempty"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxSupplierRegexp matches the characters that are not allowed in the
+// supplier's organization name (which cannot contain the email delimiters).
+var spdxSupplierRegexp = regexp.MustCompile(`[()<>]`)
+
+// NewSPDX creates the SPDX document of the software. The generated CPE and the
+// known vulnerabilities of the software are listed in the security external
+// references of its package. If the document describes a host, the host is
+// the root package that contains the software packages.
+func NewSPDX(meta Metadata, software []fleet.Software) *SPDXDocument {
+	doc := &SPDXDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              meta.Subject.Name,
+		DocumentNamespace: fmt.Sprintf("https://fleetdm.com/spdxdocs/%s-%s", url.PathEscape(meta.Subject.Name), meta.ID),
+		CreationInfo: SPDXCreationInfo{
+			Created: meta.Timestamp.UTC().Format(time.RFC3339),
+			Creators: []string{
+				"Organization: " + toolVendor,
+				fmt.Sprintf("Tool: %s-%s", toolName, meta.ToolVersion),
+			},
+		},
+		Packages:      make([]SPDXPackage, 0, len(software)+1),
+		Relationships: make([]SPDXRelationship, 0, len(software)+1),
+	}
+
+	rootID := ""
+	if h := meta.Subject.Host; h != nil {
+		rootID = fmt.Sprintf("SPDXRef-Host-%d", h.ID)
+		doc.Packages = append(doc.Packages, SPDXPackage{
+			SPDXID:                rootID,
+			Name:                  meta.Subject.Name,
+			VersionInfo:           h.OSVersion,
+			Supplier:              spdxNoAssert,
+			DownloadLocation:      spdxNoAssert,
+			PrimaryPackagePurpose: "DEVICE",
+			Comment:               fmt.Sprintf("uuid: %s, hardware serial: %s, platform: %s", h.UUID, h.HardwareSerial, h.Platform),
+		})
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{
+			SPDXElementID:      spdxDocumentID,
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: rootID,
+		})
+	}
+
+	for _, s := range software {
+		pkg := SPDXPackage{
+			SPDXID:                fmt.Sprintf("SPDXRef-Package-%d", s.ID),
+			Name:                  s.Name,
+			VersionInfo:           s.Version,
+			Supplier:              spdxNoAssert,
+			DownloadLocation:      spdxNoAssert,
+			PrimaryPackagePurpose: "APPLICATION",
+			Comment:               "source: " + s.Source,
+		}
+		if vendor := spdxSupplierRegexp.ReplaceAllString(s.Vendor, ""); vendor != "" {
+			pkg.Supplier = "Organization: " + vendor
+		}
+		if s.BundleIdentifier != "" {
+			pkg.Comment += ", bundle identifier: " + s.BundleIdentifier
+		}
+		if s.GenerateCPE != "" {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{
+				ReferenceCategory: "SECURITY",
+				ReferenceType:     "cpe23Type",
+				ReferenceLocator:  s.GenerateCPE,
+			})
+		}
+		for _, cve := range s.Vulnerabilities {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{
+				ReferenceCategory: "SECURITY",
+				ReferenceType:     "advisory",
+				ReferenceLocator:  cveDetailsLink(cve),
+			})
+		}
+		doc.Packages = append(doc.Packages, pkg)
+
+		rel := SPDXRelationship{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: pkg.SPDXID}
+		if rootID != "" {
+			rel = SPDXRelationship{SPDXElementID: rootID, RelationshipType: "CONTAINS", RelatedSPDXElement: pkg.SPDXID}
+		}
+		doc.Relationships = append(doc.Relationships, rel)
+	}
+	return doc
+}
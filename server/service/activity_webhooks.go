@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/fleetdm/fleet/v4/server"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Create activity webhook
+////////////////////////////////////////////////////////////////////////////////
+
+type createActivityWebhookRequest struct {
+	fleet.ActivityWebhookPayload
+}
+
+type activityWebhookResponse struct {
+	ActivityWebhook *fleet.ActivityWebhook `json:"activity_webhook,omitempty"`
+	Err             error                  `json:"error,omitempty"`
+}
+
+func (r activityWebhookResponse) error() error { return r.Err }
+
+func createActivityWebhookEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*createActivityWebhookRequest)
+	webhook, err := svc.NewActivityWebhook(ctx, req.ActivityWebhookPayload)
+	if err != nil {
+		return activityWebhookResponse{Err: err}, nil
+	}
+	return activityWebhookResponse{ActivityWebhook: webhook}, nil
+}
+
+func (svc *Service) NewActivityWebhook(ctx context.Context, p fleet.ActivityWebhookPayload) (*fleet.ActivityWebhook, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ActivityWebhook{}, fleet.ActionWrite); err != nil {
+		return nil, err
+	}
+
+	webhook := &fleet.ActivityWebhook{
+		ActivityTypes: fleet.ActivityTypeList{},
+		Enabled:       true,
+	}
+	if err := svc.applyActivityWebhookPayload(ctx, webhook, p); err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		secret, err := server.GenerateRandomText(24)
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "generate activity webhook secret")
+		}
+		webhook.Secret = secret
+	}
+
+	// the secret is only returned on creation
+	return svc.ds.NewActivityWebhook(ctx, webhook)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List activity webhooks
+////////////////////////////////////////////////////////////////////////////////
+
+type listActivityWebhooksResponse struct {
+	ActivityWebhooks []*fleet.ActivityWebhook `json:"activity_webhooks"`
+	Err              error                    `json:"error,omitempty"`
+}
+
+func (r listActivityWebhooksResponse) error() error { return r.Err }
+
+func listActivityWebhooksEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	webhooks, err := svc.ListActivityWebhooks(ctx)
+	if err != nil {
+		return listActivityWebhooksResponse{Err: err}, nil
+	}
+	return listActivityWebhooksResponse{ActivityWebhooks: webhooks}, nil
+}
+
+func (svc *Service) ListActivityWebhooks(ctx context.Context) ([]*fleet.ActivityWebhook, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ActivityWebhook{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := svc.ds.ListActivityWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range webhooks {
+		w.Secret = fleet.MaskedPassword
+	}
+	return webhooks, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Get activity webhook
+////////////////////////////////////////////////////////////////////////////////
+
+type getActivityWebhookRequest struct {
+	ID uint `url:"id"`
+}
+
+func getActivityWebhookEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*getActivityWebhookRequest)
+	webhook, err := svc.GetActivityWebhook(ctx, req.ID)
+	if err != nil {
+		return activityWebhookResponse{Err: err}, nil
+	}
+	return activityWebhookResponse{ActivityWebhook: webhook}, nil
+}
+
+func (svc *Service) GetActivityWebhook(ctx context.Context, id uint) (*fleet.ActivityWebhook, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ActivityWebhook{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	webhook, err := svc.ds.ActivityWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = fleet.MaskedPassword
+	return webhook, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Modify activity webhook
+////////////////////////////////////////////////////////////////////////////////
+
+type modifyActivityWebhookRequest struct {
+	ID uint `url:"id"`
+	fleet.ActivityWebhookPayload
+}
+
+func modifyActivityWebhookEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*modifyActivityWebhookRequest)
+	webhook, err := svc.ModifyActivityWebhook(ctx, req.ID, req.ActivityWebhookPayload)
+	if err != nil {
+		return activityWebhookResponse{Err: err}, nil
+	}
+	return activityWebhookResponse{ActivityWebhook: webhook}, nil
+}
+
+func (svc *Service) ModifyActivityWebhook(ctx context.Context, id uint, p fleet.ActivityWebhookPayload) (*fleet.ActivityWebhook, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ActivityWebhook{}, fleet.ActionWrite); err != nil {
+		return nil, err
+	}
+
+	webhook, err := svc.ds.ActivityWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// keep the stored secret if the masked one is sent back
+	if p.Secret != nil && *p.Secret == fleet.MaskedPassword {
+		p.Secret = nil
+	}
+	if err := svc.applyActivityWebhookPayload(ctx, webhook, p); err != nil {
+		return nil, err
+	}
+	if err := svc.ds.SaveActivityWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = fleet.MaskedPassword
+	return webhook, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete activity webhook
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteActivityWebhookRequest struct {
+	ID uint `url:"id"`
+}
+
+type deleteActivityWebhookResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteActivityWebhookResponse) error() error { return r.Err }
+
+func deleteActivityWebhookEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*deleteActivityWebhookRequest)
+	if err := svc.DeleteActivityWebhook(ctx, req.ID); err != nil {
+		return deleteActivityWebhookResponse{Err: err}, nil
+	}
+	return deleteActivityWebhookResponse{}, nil
+}
+
+func (svc *Service) DeleteActivityWebhook(ctx context.Context, id uint) error {
+	if err := svc.authz.Authorize(ctx, &fleet.ActivityWebhook{}, fleet.ActionWrite); err != nil {
+		return err
+	}
+	return svc.ds.DeleteActivityWebhook(ctx, id)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List activity webhook deliveries
+////////////////////////////////////////////////////////////////////////////////
+
+type listActivityWebhookDeliveriesRequest struct {
+	ID          uint              `url:"id"`
+	ListOptions fleet.ListOptions `url:"list_options"`
+}
+
+type listActivityWebhookDeliveriesResponse struct {
+	Deliveries []*fleet.ActivityWebhookDelivery `json:"deliveries"`
+	Err        error                            `json:"error,omitempty"`
+}
+
+func (r listActivityWebhookDeliveriesResponse) error() error { return r.Err }
+
+func listActivityWebhookDeliveriesEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listActivityWebhookDeliveriesRequest)
+	deliveries, err := svc.ListActivityWebhookDeliveries(ctx, req.ID, req.ListOptions)
+	if err != nil {
+		return listActivityWebhookDeliveriesResponse{Err: err}, nil
+	}
+	return listActivityWebhookDeliveriesResponse{Deliveries: deliveries}, nil
+}
+
+func (svc *Service) ListActivityWebhookDeliveries(ctx context.Context, id uint, opt fleet.ListOptions) ([]*fleet.ActivityWebhookDelivery, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ActivityWebhook{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	// return a not found error if the webhook does not exist
+	if _, err := svc.ds.ActivityWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	return svc.ds.ListActivityWebhookDeliveries(ctx, id, opt)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////////////
+
+// applyActivityWebhookPayload validates and applies the non-nil fields of the
+// payload to the webhook.
+func (svc *Service) applyActivityWebhookPayload(ctx context.Context, webhook *fleet.ActivityWebhook, p fleet.ActivityWebhookPayload) error {
+	if p.Name != nil {
+		webhook.Name = strings.TrimSpace(*p.Name)
+	}
+	if p.URL != nil {
+		webhook.URL = strings.TrimSpace(*p.URL)
+	}
+	if p.Secret != nil {
+		webhook.Secret = *p.Secret
+	}
+	if p.ActivityTypes != nil {
+		webhook.ActivityTypes = fleet.ActivityTypeList(*p.ActivityTypes)
+	}
+	if p.Enabled != nil {
+		webhook.Enabled = *p.Enabled
+	}
+	if p.TeamID != nil {
+		if *p.TeamID == 0 {
+			webhook.TeamID = nil
+		} else {
+			if _, err := svc.ds.Team(ctx, *p.TeamID); err != nil {
+				if fleet.IsNotFound(err) {
+					return fleet.NewInvalidArgumentError("team_id", "team does not exist")
+				}
+				return ctxerr.Wrap(ctx, err, "get team")
+			}
+			webhook.TeamID = p.TeamID
+		}
+	}
+
+	invalid := &fleet.InvalidArgumentError{}
+	if webhook.Name == "" {
+		invalid.Append("name", "cannot be empty")
+	}
+	if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		invalid.Append("url", "must be a valid http or https URL")
+	}
+	for _, typ := range webhook.ActivityTypes {
+		if strings.TrimSpace(typ) == "" {
+			invalid.Append("activity_types", "cannot contain empty activity types")
+			break
+		}
+	}
+	if invalid.HasErrors() {
+		return ctxerr.Wrap(ctx, invalid)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityWebhooksAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.ListActivityWebhooksFunc = func(ctx context.Context) ([]*fleet.ActivityWebhook, error) {
+		return nil, nil
+	}
+	ds.ActivityWebhookFunc = func(ctx context.Context, id uint) (*fleet.ActivityWebhook, error) {
+		return &fleet.ActivityWebhook{ID: id, Name: "w", URL: "https://example.com", Secret: "s"}, nil
+	}
+	ds.NewActivityWebhookFunc = func(ctx context.Context, webhook *fleet.ActivityWebhook) (*fleet.ActivityWebhook, error) {
+		return webhook, nil
+	}
+	ds.SaveActivityWebhookFunc = func(ctx context.Context, webhook *fleet.ActivityWebhook) error {
+		return nil
+	}
+	ds.DeleteActivityWebhookFunc = func(ctx context.Context, id uint) error {
+		return nil
+	}
+	ds.ListActivityWebhookDeliveriesFunc = func(ctx context.Context, webhookID uint, opt fleet.ListOptions) ([]*fleet.ActivityWebhookDelivery, error) {
+		return nil, nil
+	}
+
+	testCases := []struct {
+		name       string
+		user       *fleet.User
+		shouldFail bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, true},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, true},
+		{"team admin", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.ListActivityWebhooks(ctx)
+			checkAuthErr(t, tt.shouldFail, err)
+			_, err = svc.GetActivityWebhook(ctx, 1)
+			checkAuthErr(t, tt.shouldFail, err)
+			_, err = svc.NewActivityWebhook(ctx, fleet.ActivityWebhookPayload{Name: ptr.String("w"), URL: ptr.String("https://example.com")})
+			checkAuthErr(t, tt.shouldFail, err)
+			_, err = svc.ModifyActivityWebhook(ctx, 1, fleet.ActivityWebhookPayload{Enabled: ptr.Bool(false)})
+			checkAuthErr(t, tt.shouldFail, err)
+			err = svc.DeleteActivityWebhook(ctx, 1)
+			checkAuthErr(t, tt.shouldFail, err)
+			_, err = svc.ListActivityWebhookDeliveries(ctx, 1, fleet.ListOptions{})
+			checkAuthErr(t, tt.shouldFail, err)
+		})
+	}
+}
+
+func TestActivityWebhooksPayload(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}})
+
+	ds.TeamFunc = func(ctx context.Context, tid uint) (*fleet.Team, error) {
+		if tid != 1 {
+			return nil, notFoundError{}
+		}
+		return &fleet.Team{ID: tid}, nil
+	}
+	ds.NewActivityWebhookFunc = func(ctx context.Context, webhook *fleet.ActivityWebhook) (*fleet.ActivityWebhook, error) {
+		w := *webhook
+		w.ID = 1
+		return &w, nil
+	}
+	stored := &fleet.ActivityWebhook{ID: 1, Name: "w", URL: "https://example.com", Secret: "s3cr3t", TeamID: ptr.Uint(1), Enabled: true}
+	ds.ActivityWebhookFunc = func(ctx context.Context, id uint) (*fleet.ActivityWebhook, error) {
+		w := *stored
+		return &w, nil
+	}
+	var saved *fleet.ActivityWebhook
+	ds.SaveActivityWebhookFunc = func(ctx context.Context, webhook *fleet.ActivityWebhook) error {
+		w := *webhook
+		saved = &w
+		return nil
+	}
+
+	invalid := []struct {
+		name    string
+		payload fleet.ActivityWebhookPayload
+		errMsg  string
+	}{
+		{"no name", fleet.ActivityWebhookPayload{URL: ptr.String("https://example.com")}, "name"},
+		{"no url", fleet.ActivityWebhookPayload{Name: ptr.String("w")}, "url"},
+		{"bad scheme", fleet.ActivityWebhookPayload{Name: ptr.String("w"), URL: ptr.String("ftp://example.com")}, "url"},
+		{"empty type", fleet.ActivityWebhookPayload{Name: ptr.String("w"), URL: ptr.String("https://example.com"), ActivityTypes: &[]string{""}}, "activity_types"},
+		{"unknown team", fleet.ActivityWebhookPayload{Name: ptr.String("w"), URL: ptr.String("https://example.com"), TeamID: ptr.Uint(2)}, "team_id"},
+	}
+	for _, c := range invalid {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := svc.NewActivityWebhook(ctx, c.payload)
+			var invalidErr *fleet.InvalidArgumentError
+			require.ErrorAs(t, err, &invalidErr)
+			require.Contains(t, err.Error(), c.errMsg)
+		})
+	}
+
+	// a secret is generated and returned on creation
+	webhook, err := svc.NewActivityWebhook(ctx, fleet.ActivityWebhookPayload{
+		Name:          ptr.String(" siem "),
+		URL:           ptr.String("https://example.com/hook"),
+		ActivityTypes: &[]string{fleet.ActivityTypeCreatedPack},
+		TeamID:        ptr.Uint(1),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "siem", webhook.Name)
+	assert.True(t, webhook.Enabled)
+	assert.NotEmpty(t, webhook.Secret)
+	assert.NotEqual(t, fleet.MaskedPassword, webhook.Secret)
+	assert.Equal(t, fleet.ActivityTypeList{fleet.ActivityTypeCreatedPack}, webhook.ActivityTypes)
+	assert.Equal(t, uint(1), *webhook.TeamID)
+
+	// the secret is masked afterwards
+	webhook, err = svc.GetActivityWebhook(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, fleet.MaskedPassword, webhook.Secret)
+
+	// sending back the masked secret keeps the stored one, a zero team id
+	// removes the team filter
+	webhook, err = svc.ModifyActivityWebhook(ctx, 1, fleet.ActivityWebhookPayload{
+		Secret:  ptr.String(fleet.MaskedPassword),
+		TeamID:  ptr.Uint(0),
+		Enabled: ptr.Bool(false),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, "s3cr3t", saved.Secret)
+	assert.Nil(t, saved.TeamID)
+	assert.False(t, saved.Enabled)
+	assert.Equal(t, fleet.MaskedPassword, webhook.Secret)
+
+	_, err = svc.ModifyActivityWebhook(ctx, 1, fleet.ActivityWebhookPayload{Secret: ptr.String("new")})
+	require.NoError(t, err)
+	assert.Equal(t, "new", saved.Secret)
+}
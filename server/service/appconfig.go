@@ -160,6 +160,10 @@ func (svc *Service) AppConfig(ctx context.Context) (*fleet.AppConfig, error) {
 		ac.SMTPSettings.SMTPPassword = fleet.MaskedPassword
 	}
 
+	if ac.SSOSettings.OIDCClientSecret != "" {
+		ac.SSOSettings.OIDCClientSecret = fleet.MaskedPassword
+	}
+
 	for _, jiraIntegration := range ac.Integrations.Jira {
 		jiraIntegration.APIToken = fleet.MaskedPassword
 	}
@@ -236,6 +240,7 @@ func (svc *Service) ModifyAppConfig(ctx context.Context, p []byte, applyOpts fle
 	}
 
 	oldSmtpSettings := appConfig.SMTPSettings
+	oldOIDCClientSecret := appConfig.SSOSettings.OIDCClientSecret
 	oldAgentOptions := ""
 	if appConfig.AgentOptions != nil {
 		oldAgentOptions = string(*appConfig.AgentOptions)
@@ -271,7 +276,22 @@ func (svc *Service) ModifyAppConfig(ctx context.Context, p []byte, applyOpts fle
 		}
 	}
 
+	if teamID := newAppConfig.HostLifecycleSettings.QuarantineTeamID; teamID != nil {
+		if !license.IsPremium() {
+			invalid.Append("host_lifecycle_settings.quarantine_team_id", ErrMissingLicense.Error())
+			return nil, ctxerr.Wrap(ctx, invalid)
+		}
+		if _, err := svc.ds.Team(ctx, *teamID); err != nil {
+			if fleet.IsNotFound(err) {
+				invalid.Append("host_lifecycle_settings.quarantine_team_id", fmt.Sprintf("team %d not found", *teamID))
+				return nil, ctxerr.Wrap(ctx, invalid)
+			}
+			return nil, ctxerr.Wrap(ctx, err, "get quarantine team")
+		}
+	}
+
 	validateSSOSettings(newAppConfig, appConfig, invalid, license)
+	validateSCIMGroupMappings(newAppConfig.SSOSettings.SCIMGroupMappings, invalid, license)
 	if invalid.HasErrors() {
 		return nil, ctxerr.Wrap(ctx, invalid)
 	}
@@ -282,6 +302,11 @@ func (svc *Service) ModifyAppConfig(ctx context.Context, p []byte, applyOpts fle
 		err = fleet.NewUserMessageError(err, http.StatusBadRequest)
 		return nil, ctxerr.Wrap(ctx, err)
 	}
+	// the obfuscated client secret is sent back as-is by clients that did not
+	// change it, keep the stored one in that case.
+	if appConfig.SSOSettings.OIDCClientSecret == fleet.MaskedPassword {
+		appConfig.SSOSettings.OIDCClientSecret = oldOIDCClientSecret
+	}
 	var legacyUsedWarning error
 	if legacyKeys := appConfig.DidUnmarshalLegacySettings(); len(legacyKeys) > 0 {
 		// this "warning" is returned only in dry-run mode, and if no other errors
@@ -317,6 +342,10 @@ func (svc *Service) ModifyAppConfig(ctx context.Context, p []byte, applyOpts fle
 	fleet.ValidateEnabledVulnerabilitiesIntegrations(appConfig.WebhookSettings.VulnerabilitiesWebhook, appConfig.Integrations, invalid)
 	fleet.ValidateEnabledFailingPoliciesIntegrations(appConfig.WebhookSettings.FailingPoliciesWebhook, appConfig.Integrations, invalid)
 	fleet.ValidateEnabledHostStatusIntegrations(appConfig.WebhookSettings.HostStatusWebhook, invalid)
+	fleet.ValidateEnabledUnapprovedSoftwareIntegrations(appConfig.WebhookSettings.UnapprovedSoftwareWebhook, invalid)
+	fleet.ValidateScriptSettings(appConfig.Scripts, invalid)
+	fleet.ValidateHostDeduplicationSettings(appConfig.HostDeduplicationSettings, invalid)
+	fleet.ValidateEnabledHostQuarantineIntegrations(appConfig.WebhookSettings.HostQuarantineWebhook, invalid)
 	if invalid.HasErrors() {
 		return nil, ctxerr.Wrap(ctx, invalid)
 	}
@@ -408,6 +437,44 @@ func (svc *Service) ModifyAppConfig(ctx context.Context, p []byte, applyOpts fle
 }
 
 func validateSSOSettings(p fleet.AppConfig, existing *fleet.AppConfig, invalid *fleet.InvalidArgumentError, license *fleet.LicenseInfo) {
+	protocol := p.SSOSettings.Protocol
+	switch protocol {
+	case "":
+		protocol = existing.SSOSettings.Protocol
+	case fleet.SSOProtocolSAML, fleet.SSOProtocolOIDC:
+	default:
+		invalid.Append("protocol", fmt.Sprintf("must be one of %q or %q", fleet.SSOProtocolSAML, fleet.SSOProtocolOIDC))
+		return
+	}
+
+	if p.SSOSettings.EnableSSO && protocol == fleet.SSOProtocolOIDC {
+		if p.SSOSettings.OIDCIssuerURL == "" {
+			if existing.SSOSettings.OIDCIssuerURL == "" {
+				invalid.Append("oidc_issuer_url", "required")
+			}
+		} else if u, err := url.Parse(p.SSOSettings.OIDCIssuerURL); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid.Append("oidc_issuer_url", "must be a valid URL")
+		}
+		if p.SSOSettings.OIDCClientID == "" && existing.SSOSettings.OIDCClientID == "" {
+			invalid.Append("oidc_client_id", "required")
+		}
+		if p.SSOSettings.OIDCClientSecret == "" && existing.SSOSettings.OIDCClientSecret == "" {
+			invalid.Append("oidc_client_secret", "required")
+		}
+		if p.SSOSettings.IDPName == "" {
+			if existing.SSOSettings.IDPName == "" {
+				invalid.Append("idp_name", "required")
+			}
+		}
+		if p.SSOSettings.EnableSSOIdPLogin {
+			invalid.Append("enable_sso_idp_login", "not supported with the oidc protocol")
+		}
+		if !license.IsPremium() && p.SSOSettings.EnableJITProvisioning {
+			invalid.Append("enable_jit_provisioning", ErrMissingLicense.Error())
+		}
+		return
+	}
+
 	if p.SSOSettings.EnableSSO {
 		if p.SSOSettings.Metadata == "" && p.SSOSettings.MetadataURL == "" {
 			if existing.SSOSettings.Metadata == "" && existing.SSOSettings.MetadataURL == "" {
@@ -437,6 +504,28 @@ func validateSSOSettings(p fleet.AppConfig, existing *fleet.AppConfig, invalid *
 	}
 }
 
+func validateSCIMGroupMappings(mappings []fleet.SCIMGroupMapping, invalid *fleet.InvalidArgumentError, license *fleet.LicenseInfo) {
+	for _, m := range mappings {
+		if m.Group == "" {
+			invalid.Append("scim_group_mappings", "group cannot be empty")
+			continue
+		}
+		if m.Team == "" {
+			if !fleet.ValidGlobalRole(m.Role) {
+				invalid.Append("scim_group_mappings", fmt.Sprintf("invalid global role %q for group %q", m.Role, m.Group))
+			}
+			continue
+		}
+		if !license.IsPremium() {
+			invalid.Append("scim_group_mappings", ErrMissingLicense.Error())
+			return
+		}
+		if !fleet.ValidTeamRole(m.Role) {
+			invalid.Append("scim_group_mappings", fmt.Sprintf("invalid team role %q for group %q", m.Role, m.Group))
+		}
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Apply enroll secret spec
 ////////////////////////////////////////////////////////////////////////////////
@@ -346,6 +346,121 @@ func TestJITProvisioning(t *testing.T) {
 	})
 }
 
+func TestSCIMGroupMappings(t *testing.T) {
+	cases := []struct {
+		desc     string
+		mappings []fleet.SCIMGroupMapping
+		premium  bool
+		errMsg   string
+	}{
+		{"no mappings", nil, false, ""},
+		{"global role", []fleet.SCIMGroupMapping{{Group: "admins", Role: fleet.RoleAdmin}}, false, ""},
+		{"empty group", []fleet.SCIMGroupMapping{{Role: fleet.RoleAdmin}}, false, "group cannot be empty"},
+		{"invalid global role", []fleet.SCIMGroupMapping{{Group: "g", Role: "nope"}}, false, `invalid global role "nope"`},
+		{"team role without license", []fleet.SCIMGroupMapping{{Group: "g", Team: "t", Role: fleet.RoleAdmin}}, false, ErrMissingLicense.Error()},
+		{"team role", []fleet.SCIMGroupMapping{{Group: "g", Team: "t", Role: fleet.RoleMaintainer}}, true, ""},
+		{"invalid team role", []fleet.SCIMGroupMapping{{Group: "g", Team: "t", Role: "nope"}}, true, `invalid team role "nope"`},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			license := &fleet.LicenseInfo{}
+			if c.premium {
+				license.Tier = fleet.TierPremium
+			}
+			invalid := &fleet.InvalidArgumentError{}
+			validateSCIMGroupMappings(c.mappings, invalid, license)
+			if c.errMsg == "" {
+				require.False(t, invalid.HasErrors())
+			} else {
+				require.ErrorContains(t, invalid, c.errMsg)
+			}
+		})
+	}
+}
+
+func TestOIDCSettings(t *testing.T) {
+	config := fleet.AppConfig{
+		SSOSettings: fleet.SSOSettings{
+			Protocol:         fleet.SSOProtocolOIDC,
+			EnableSSO:        true,
+			IDPName:          "okta",
+			OIDCIssuerURL:    "https://issuer.idp.com",
+			OIDCClientID:     "fleet",
+			OIDCClientSecret: "secret",
+		},
+	}
+
+	t.Run("valid settings don't require saml metadata", func(t *testing.T) {
+		invalid := &fleet.InvalidArgumentError{}
+		validateSSOSettings(config, &fleet.AppConfig{}, invalid, &fleet.LicenseInfo{})
+		require.False(t, invalid.HasErrors())
+	})
+
+	t.Run("missing oidc fields", func(t *testing.T) {
+		invalid := &fleet.InvalidArgumentError{}
+		cfg := config
+		cfg.SSOSettings.OIDCIssuerURL = ""
+		cfg.SSOSettings.OIDCClientSecret = ""
+		validateSSOSettings(cfg, &fleet.AppConfig{}, invalid, &fleet.LicenseInfo{})
+		require.True(t, invalid.HasErrors())
+		var names []string
+		for _, i := range invalid.Invalid() {
+			names = append(names, i["name"])
+		}
+		assert.Contains(t, names, "oidc_issuer_url")
+		assert.Contains(t, names, "oidc_client_secret")
+		assert.NotContains(t, names, "oidc_client_id")
+	})
+
+	t.Run("existing oidc fields are used", func(t *testing.T) {
+		invalid := &fleet.InvalidArgumentError{}
+		cfg := fleet.AppConfig{SSOSettings: fleet.SSOSettings{EnableSSO: true}}
+		validateSSOSettings(cfg, &config, invalid, &fleet.LicenseInfo{})
+		require.False(t, invalid.HasErrors())
+	})
+
+	t.Run("invalid issuer url", func(t *testing.T) {
+		invalid := &fleet.InvalidArgumentError{}
+		cfg := config
+		cfg.SSOSettings.OIDCIssuerURL = "not a url"
+		validateSSOSettings(cfg, &fleet.AppConfig{}, invalid, &fleet.LicenseInfo{})
+		require.True(t, invalid.HasErrors())
+		assert.Contains(t, invalid.Error(), "must be a valid URL")
+	})
+
+	t.Run("invalid protocol", func(t *testing.T) {
+		invalid := &fleet.InvalidArgumentError{}
+		cfg := config
+		cfg.SSOSettings.Protocol = "ws-fed"
+		validateSSOSettings(cfg, &fleet.AppConfig{}, invalid, &fleet.LicenseInfo{})
+		require.True(t, invalid.HasErrors())
+		assert.Contains(t, invalid.Error(), "protocol")
+	})
+
+	t.Run("idp-initiated login is not supported", func(t *testing.T) {
+		invalid := &fleet.InvalidArgumentError{}
+		cfg := config
+		cfg.SSOSettings.EnableSSOIdPLogin = true
+		validateSSOSettings(cfg, &fleet.AppConfig{}, invalid, &fleet.LicenseInfo{})
+		require.True(t, invalid.HasErrors())
+		assert.Contains(t, invalid.Error(), "enable_sso_idp_login")
+	})
+
+	t.Run("JIT provisioning requires premium", func(t *testing.T) {
+		cfg := config
+		cfg.SSOSettings.EnableJITProvisioning = true
+
+		invalid := &fleet.InvalidArgumentError{}
+		validateSSOSettings(cfg, &fleet.AppConfig{}, invalid, &fleet.LicenseInfo{})
+		require.True(t, invalid.HasErrors())
+		assert.Contains(t, invalid.Error(), "enable_jit_provisioning")
+
+		invalid = &fleet.InvalidArgumentError{}
+		validateSSOSettings(cfg, &fleet.AppConfig{}, invalid, &fleet.LicenseInfo{Tier: fleet.TierPremium})
+		require.False(t, invalid.HasErrors())
+	})
+}
+
 func TestAppConfigSecretsObfuscated(t *testing.T) {
 	ds := new(mock.Store)
 	svc := newTestService(t, ds, nil, nil)
@@ -358,6 +473,7 @@ func TestAppConfigSecretsObfuscated(t *testing.T) {
 	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
 		return &fleet.AppConfig{
 			SMTPSettings: fleet.SMTPSettings{SMTPPassword: "smtppassword"},
+			SSOSettings:  fleet.SSOSettings{OIDCClientSecret: "oidcsecret"},
 			Integrations: fleet.Integrations{
 				Jira: []*fleet.JiraIntegration{
 					{APIToken: "jiratoken"},
@@ -409,6 +525,7 @@ func TestAppConfigSecretsObfuscated(t *testing.T) {
 			ac, err := svc.AppConfig(ctx)
 			require.NoError(t, err)
 			require.Equal(t, ac.SMTPSettings.SMTPPassword, fleet.MaskedPassword)
+			require.Equal(t, ac.SSOSettings.OIDCClientSecret, fleet.MaskedPassword)
 			require.Equal(t, ac.Integrations.Jira[0].APIToken, fleet.MaskedPassword)
 			require.Equal(t, ac.Integrations.Zendesk[0].APIToken, fleet.MaskedPassword)
 		})
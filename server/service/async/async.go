@@ -24,6 +24,8 @@ type Task struct {
 	clock       clock.Clock
 	taskConfigs map[config.AsyncTaskName]config.AsyncProcessingConfig
 	seenHostSet seenHostSet
+
+	scheduledQueryResultsMaxPerHost int
 }
 
 // NewTask configures and returns a Task.
@@ -38,6 +40,8 @@ func NewTask(ds fleet.Datastore, pool fleet.RedisPool, clck clock.Clock, conf co
 		pool:        pool,
 		clock:       clck,
 		taskConfigs: taskCfgs,
+
+		scheduledQueryResultsMaxPerHost: conf.ScheduledQueryResultsMaxPerHost,
 	}
 }
 
@@ -55,7 +59,7 @@ func (t *Task) StartCollectors(ctx context.Context, logger kitlog.Logger) {
 		config.AsyncTaskLabelMembership:     t.collectLabelQueryExecutions,
 		config.AsyncTaskPolicyMembership:    t.collectPolicyQueryExecutions,
 		config.AsyncTaskHostLastSeen:        t.collectHostsLastSeen,
-		config.AsyncTaskScheduledQueryStats: t.collectScheduledQueryStats,
+		config.AsyncTaskScheduledQueryStats: t.collectScheduledQueryStatsAndResults,
 	}
 	for task, cfg := range t.taskConfigs {
 		if !cfg.Enabled {
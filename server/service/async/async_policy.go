@@ -120,7 +120,7 @@ func (t *Task) collectPolicyQueryExecutions(ctx context.Context, ds fleet.Datast
     return res
   `)
 
-	getKeyTuples := func(hostID uint) (inserts []fleet.PolicyMembershipResult, err error) {
+	getKeyTuples := func(hostID uint, reportedAt time.Time) (inserts []fleet.PolicyMembershipResult, err error) {
 		keyList := fmt.Sprintf(policyPassHostKey, hostID)
 		conn := redis.ConfigureDoer(pool, pool.Get())
 		defer conn.Close()
@@ -143,6 +143,7 @@ func (t *Task) collectPolicyQueryExecutions(ctx context.Context, ds fleet.Datast
 			if id, _ := strconv.ParseUint(parts[0], 10, 32); id > 0 {
 				tup.HostID = hostID
 				tup.PolicyID = uint(id)
+				tup.ReportedAt = reportedAt
 				switch parts[1] {
 				case "1":
 					tup.Passes = ptr.Bool(true)
@@ -172,7 +173,7 @@ func (t *Task) collectPolicyQueryExecutions(ctx context.Context, ds fleet.Datast
 	insertBatch := make([]fleet.PolicyMembershipResult, 0, cfg.InsertBatch)
 	for _, host := range hosts {
 		hid := host.HostID
-		ins, err := getKeyTuples(hid)
+		ins, err := getKeyTuples(hid, time.Unix(host.LastReported, 0))
 		if err != nil {
 			return err
 		}
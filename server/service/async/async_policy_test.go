@@ -259,6 +259,21 @@ func testCollectPolicyQueryExecutions(t *testing.T, ds *mysql.Datastore, pool fl
 		}()
 	}
 
+	// only the transitions of the results were recorded in the history
+	selectHistory := func(t *testing.T, hostID, policyID int) []bool {
+		var history []bool
+		mysql.ExecAdhocSQL(t, ds, func(tx sqlx.ExtContext) error {
+			return sqlx.SelectContext(ctx, tx, &history, `SELECT passes FROM policy_membership_history
+        WHERE host_id = ? AND policy_id = ? ORDER BY id`, hostID, policyID)
+		})
+		return history
+	}
+	require.Equal(t, []bool{true, false, true}, selectHistory(t, hid(1), pid(1)))
+	require.Equal(t, []bool{true, false}, selectHistory(t, hid(1), pid(3)))
+	require.Equal(t, []bool{true, false}, selectHistory(t, hid(1), pid(4)))
+	// policies that did not run are not recorded
+	require.Empty(t, selectHistory(t, hid(2), pid(4)))
+
 	// after all cases, run one last upsert (an update) to make sure that the
 	// updated at column is properly updated. First we need to ensure that this
 	// runs in a distinct second, because the mysql resolution is not precise.
@@ -0,0 +1,203 @@
+package async
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/config"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/datastore/redis"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	scheduledQueryResultsHostKey     = "scheduled_query_results:{%d}" // the list of scheduled query results for a given host
+	scheduledQueryResultsHostIDsKey  = "scheduled_query_results:active_host_ids"
+	scheduledQueryResultsKeyMinTTL   = 7 * 24 * time.Hour // 1 week
+	scheduledQueryResultsMaxHostList = 10000              // max number of results kept in redis for a host
+)
+
+// RecordScheduledQueryResults records the results of scheduled queries for a
+// given host. The results are identified by their pack and scheduled query
+// names, the scheduled query IDs are resolved when they are saved. It uses
+// the configuration of the scheduled query stats task to decide whether the
+// results are saved asynchronously.
+func (t *Task) RecordScheduledQueryResults(ctx context.Context, hostID uint, results []*fleet.ScheduledQueryResult, ts time.Time) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	cfg := t.taskConfigs[config.AsyncTaskScheduledQueryStats]
+	if !cfg.Enabled {
+		for _, r := range results {
+			r.HostID = hostID
+		}
+		_, err := saveScheduledQueryResults(ctx, t.datastore, results, t.scheduledQueryResultsMaxPerHost)
+		return err
+	}
+
+	// same TTL logic as for the scheduled query stats: 1 week or 10 * the
+	// collector interval, whichever is biggest.
+	ttl := scheduledQueryResultsKeyMinTTL
+	if maxTTL := 10 * cfg.CollectInterval; maxTTL > ttl {
+		ttl = maxTTL
+	}
+
+	// the redis key is a list of the json-marshaled results, in the order they
+	// were received. The list is capped so that a host reporting many results
+	// while the collector is not running does not use an unbounded amount of
+	// redis memory (only the latest results are kept in mysql anyway).
+
+	// keys and arguments passed to the script are:
+	// KEYS[1]: list key (scheduledQueryResultsHostKey)
+	// ARGV[1]: ttl for the key
+	// ARGV[2]: max length of the list
+	// ARGV[3:]: results to append to the list
+	script := redigo.NewScript(1, `
+    redis.call('RPUSH', KEYS[1], unpack(ARGV, 3))
+    redis.call('LTRIM', KEYS[1], -tonumber(ARGV[2]), -1)
+    return redis.call('EXPIRE', KEYS[1], ARGV[1])
+  `)
+
+	key := fmt.Sprintf(scheduledQueryResultsHostKey, hostID)
+	args := redigo.Args{key, int(ttl.Seconds()), scheduledQueryResultsMaxHostList}
+	for _, r := range results {
+		r.HostID = hostID
+		b, err := json.Marshal(r)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "marshal scheduled query result")
+		}
+		args = args.Add(string(b))
+	}
+
+	conn := t.pool.Get()
+	defer conn.Close()
+	if err := redis.BindConn(t.pool, conn, key); err != nil {
+		return ctxerr.Wrap(ctx, err, "bind redis connection")
+	}
+	if _, err := script.Do(conn, args...); err != nil {
+		return ctxerr.Wrap(ctx, err, "run redis script")
+	}
+
+	// Storing the host id in the set of active host IDs outside of the redis
+	// script because in Redis Cluster mode the key may not live on the same node
+	// as the host's keys. At the same time, purge any entry in the set that is
+	// older than now - TTL.
+	if _, err := storePurgeActiveHostID(t.pool, scheduledQueryResultsHostIDsKey, hostID, ts, ts.Add(-ttl)); err != nil {
+		return ctxerr.Wrap(ctx, err, "store active host id")
+	}
+	return nil
+}
+
+func (t *Task) collectScheduledQueryResults(ctx context.Context, ds fleet.Datastore, pool fleet.RedisPool, stats *collectorExecStats) error {
+	cfg := t.taskConfigs[config.AsyncTaskScheduledQueryStats]
+
+	hosts, err := loadActiveHostIDs(pool, scheduledQueryResultsHostIDsKey, cfg.RedisScanKeysCount)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "load active host ids")
+	}
+	stats.Keys += len(hosts)
+
+	getHostResults := func(hostID uint) ([]*fleet.ScheduledQueryResult, error) {
+		key := fmt.Sprintf(scheduledQueryResultsHostKey, hostID)
+		conn := redis.ConfigureDoer(pool, pool.Get())
+		defer conn.Close()
+
+		var results []*fleet.ScheduledQueryResult
+		for {
+			stats.RedisCmds++
+
+			// read the oldest items and remove them from the list, the new items
+			// are appended at the end of the list so they are never removed
+			// before being read.
+			items, err := redigo.Strings(conn.Do("LRANGE", key, 0, cfg.RedisPopCount-1))
+			if err != nil {
+				return nil, ctxerr.Wrap(ctx, err, "redis LRANGE")
+			}
+			if len(items) > 0 {
+				stats.RedisCmds++
+				if _, err := conn.Do("LTRIM", key, len(items), -1); err != nil {
+					return nil, ctxerr.Wrap(ctx, err, "redis LTRIM")
+				}
+			}
+			stats.Items += len(items)
+
+			for _, item := range items {
+				var r fleet.ScheduledQueryResult
+				if err := json.Unmarshal([]byte(item), &r); err != nil {
+					return nil, ctxerr.Wrap(ctx, err, "unmarshal scheduled query result")
+				}
+				results = append(results, &r)
+			}
+			if len(items) < cfg.RedisPopCount {
+				return results, nil
+			}
+		}
+	}
+
+	var batch []*fleet.ScheduledQueryResult
+	for _, host := range hosts {
+		results, err := getHostResults(host.HostID)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, results...)
+	}
+
+	count, err := saveScheduledQueryResults(ctx, ds, batch, t.scheduledQueryResultsMaxPerHost)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "save scheduled query results")
+	}
+	stats.Inserts += count
+
+	if len(hosts) > 0 {
+		if _, err := removeProcessedHostIDs(pool, scheduledQueryResultsHostIDsKey, hosts); err != nil {
+			return ctxerr.Wrap(ctx, err, "remove processed host ids")
+		}
+	}
+	return nil
+}
+
+// saveScheduledQueryResults resolves the scheduled query IDs of the results
+// from their pack and scheduled query names and saves them, ignoring the
+// results of non-existing scheduled queries. It returns the number of saved
+// results.
+func saveScheduledQueryResults(ctx context.Context, ds fleet.Datastore, results []*fleet.ScheduledQueryResult, maxPerHost int) (int, error) {
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	// as for the stats, the results are likely to be for a small set of
+	// scheduled queries, load their IDs once.
+	uniqueSchedQueries := make(map[[2]string]uint)
+	for _, r := range results {
+		uniqueSchedQueries[[2]string{r.PackName, r.ScheduledQueryName}] = 0
+	}
+	schedNames := make([][2]string, 0, len(uniqueSchedQueries))
+	for k := range uniqueSchedQueries {
+		schedNames = append(schedNames, k)
+	}
+	schedIDs, err := ds.ScheduledQueryIDsByName(ctx, fleet.DefaultScheduledQueryIDsByNameBatchSize, schedNames...)
+	if err != nil {
+		return 0, ctxerr.Wrap(ctx, err, "batch-load scheduled query ids from names")
+	}
+	for i, nm := range schedNames {
+		uniqueSchedQueries[nm] = schedIDs[i]
+	}
+
+	toSave := results[:0]
+	for _, r := range results {
+		r.ScheduledQueryID = uniqueSchedQueries[[2]string{r.PackName, r.ScheduledQueryName}]
+		// ignore if the scheduled query does not exist
+		if r.ScheduledQueryID != 0 {
+			toSave = append(toSave, r)
+		}
+	}
+	if err := ds.SaveScheduledQueryResults(ctx, toSave, maxPerHost); err != nil {
+		return 0, ctxerr.Wrap(ctx, err, "save scheduled query results")
+	}
+	return len(toSave), nil
+}
@@ -0,0 +1,184 @@
+package async
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/fleetdm/fleet/v4/server/config"
+	"github.com/fleetdm/fleet/v4/server/datastore/mysql"
+	"github.com/fleetdm/fleet/v4/server/datastore/redis"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/test"
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func testCollectScheduledQueryResults(t *testing.T, ds *mysql.Datastore, pool fleet.RedisPool) {
+	ctx := context.Background()
+
+	user := test.NewUser(t, ds, "user", "user@example.com", true)
+	p1 := test.NewPack(t, ds, "p1")
+	q1 := test.NewQuery(t, ds, "q1", "select 1", user.ID, true)
+	q2 := test.NewQuery(t, ds, "q2", "select 2", user.ID, true)
+	sq1 := test.NewScheduledQuery(t, ds, p1.ID, q1.ID, 60, false, false, "sq1")
+	sq2 := test.NewScheduledQuery(t, ds, p1.ID, q2.ID, 60, true, false, "sq2")
+
+	hostIDs := createHosts(t, ds, 2, time.Now())
+
+	newResult := func(schedName, value string) *fleet.ScheduledQueryResult {
+		return &fleet.ScheduledQueryResult{
+			PackName:           p1.Name,
+			ScheduledQueryName: schedName,
+			Action:             "added",
+			Data:               json.RawMessage(`{"value":"` + value + `"}`),
+			LastFetched:        time.Now(),
+		}
+	}
+
+	task := NewTask(ds, pool, clock.C, config.OsqueryConfig{
+		EnableAsyncHostProcessing:       "true",
+		AsyncHostInsertBatch:            3,
+		AsyncHostRedisPopCount:          2,
+		AsyncHostRedisScanKeysCount:     10,
+		ScheduledQueryResultsMaxPerHost: 2,
+	})
+
+	err := task.RecordScheduledQueryResults(ctx, hostIDs[0], []*fleet.ScheduledQueryResult{
+		newResult(sq1.Name, "a"), newResult(sq1.Name, "b"), newResult(sq1.Name, "c"),
+		newResult(sq2.Name, "d"), newResult("no-such-query", "e"),
+	}, time.Now())
+	require.NoError(t, err)
+	err = task.RecordScheduledQueryResults(ctx, hostIDs[1], []*fleet.ScheduledQueryResult{newResult(sq2.Name, "f")}, time.Now())
+	require.NoError(t, err)
+
+	var stats collectorExecStats
+	err = task.collectScheduledQueryResults(ctx, ds, pool, &stats)
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Keys)
+	require.Equal(t, 6, stats.Items)
+	require.Equal(t, 5, stats.Inserts)
+
+	type row struct {
+		HostID           uint   `db:"host_id"`
+		ScheduledQueryID uint   `db:"scheduled_query_id"`
+		Value            string `db:"value"`
+	}
+	var rows []row
+	mysql.ExecAdhocSQL(t, ds, func(tx sqlx.ExtContext) error {
+		return sqlx.SelectContext(ctx, tx, &rows,
+			`SELECT host_id, scheduled_query_id, data->>'$.value' AS value FROM scheduled_query_results ORDER BY host_id, id`)
+	})
+	require.Equal(t, []row{
+		{hostIDs[0], sq1.ID, "b"},
+		{hostIDs[0], sq1.ID, "c"},
+		{hostIDs[0], sq2.ID, "d"},
+		{hostIDs[1], sq2.ID, "f"},
+	}, rows)
+
+	// the collected results are removed from redis
+	stats = collectorExecStats{}
+	err = task.collectScheduledQueryResults(ctx, ds, pool, &stats)
+	require.NoError(t, err)
+	require.Equal(t, 0, stats.Keys)
+	require.Equal(t, 0, stats.Items)
+}
+
+func testRecordScheduledQueryResultsSync(t *testing.T, ds *mock.Store, pool fleet.RedisPool) {
+	ctx := context.Background()
+	host := &fleet.Host{ID: 1}
+	key := fmt.Sprintf(scheduledQueryResultsHostKey, host.ID)
+
+	ds.ScheduledQueryIDsByNameFunc = func(ctx context.Context, batchSize int, names ...[2]string) ([]uint, error) {
+		ids := make([]uint, len(names))
+		for i, nm := range names {
+			if nm == [2]string{"p1", "sq1"} {
+				ids[i] = 10
+			}
+		}
+		return ids, nil
+	}
+	var saved []*fleet.ScheduledQueryResult
+	var savedMax int
+	ds.SaveScheduledQueryResultsFunc = func(ctx context.Context, results []*fleet.ScheduledQueryResult, maxPerHost int) error {
+		saved, savedMax = results, maxPerHost
+		return nil
+	}
+
+	task := NewTask(ds, pool, clock.C, config.OsqueryConfig{ScheduledQueryResultsMaxPerHost: 5})
+	err := task.RecordScheduledQueryResults(ctx, host.ID, []*fleet.ScheduledQueryResult{
+		{PackName: "p1", ScheduledQueryName: "sq1", Action: "snapshot"},
+		{PackName: "p1", ScheduledQueryName: "sq2", Action: "snapshot"},
+	}, time.Now())
+	require.NoError(t, err)
+	require.True(t, ds.SaveScheduledQueryResultsFuncInvoked)
+	ds.SaveScheduledQueryResultsFuncInvoked = false
+	require.Equal(t, 5, savedMax)
+	require.Len(t, saved, 1)
+	require.Equal(t, uint(10), saved[0].ScheduledQueryID)
+	require.Equal(t, host.ID, saved[0].HostID)
+
+	conn := redis.ConfigureDoer(pool, pool.Get())
+	defer conn.Close()
+
+	n, err := redigo.Int(conn.Do("EXISTS", key))
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
+
+func testRecordScheduledQueryResultsAsync(t *testing.T, ds *mock.Store, pool fleet.RedisPool) {
+	ctx := context.Background()
+	now := time.Now()
+	host := &fleet.Host{ID: 1}
+	key := fmt.Sprintf(scheduledQueryResultsHostKey, host.ID)
+
+	task := NewTask(ds, pool, clock.C, config.OsqueryConfig{
+		EnableAsyncHostProcessing:   "true",
+		AsyncHostInsertBatch:        3,
+		AsyncHostRedisPopCount:      3,
+		AsyncHostRedisScanKeysCount: 10,
+	})
+
+	err := task.RecordScheduledQueryResults(ctx, host.ID, []*fleet.ScheduledQueryResult{
+		{PackName: "p1", ScheduledQueryName: "sq1", Action: "added", Data: json.RawMessage(`{"a":"b"}`)},
+		{PackName: "p1", ScheduledQueryName: "sq1", Action: "removed", Data: json.RawMessage(`{"a":"c"}`)},
+	}, now)
+	require.NoError(t, err)
+	require.False(t, ds.SaveScheduledQueryResultsFuncInvoked)
+
+	conn := redis.ConfigureDoer(pool, pool.Get())
+	defer conn.Close()
+	defer conn.Do("DEL", key)
+
+	items, err := redigo.Strings(conn.Do("LRANGE", key, 0, -1))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	var r fleet.ScheduledQueryResult
+	require.NoError(t, json.Unmarshal([]byte(items[1]), &r))
+	require.Equal(t, "removed", r.Action)
+	require.Equal(t, host.ID, r.HostID)
+	require.JSONEq(t, `{"a":"c"}`, string(r.Data))
+
+	tsActive, err := redigo.Int64(conn.Do("ZSCORE", scheduledQueryResultsHostIDsKey, host.ID))
+	require.NoError(t, err)
+	require.Equal(t, now.Unix(), tsActive)
+
+	// running the collector removes the host from the active set
+	var collStats collectorExecStats
+	err = task.collectScheduledQueryResults(ctx, ds, pool, &collStats)
+	require.NoError(t, err)
+	require.Equal(t, 1, collStats.Keys)
+	require.Equal(t, 2, collStats.Items)
+
+	count, err := redigo.Int(conn.Do("ZCARD", scheduledQueryResultsHostIDsKey))
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+	n, err := redigo.Int(conn.Do("LLEN", key))
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
@@ -90,6 +90,16 @@ func (t *Task) RecordScheduledQueryStats(ctx context.Context, hostID uint, stats
 	return nil
 }
 
+// collectScheduledQueryStatsAndResults collects the scheduled query stats and
+// the scheduled query results, which are recorded with the same task
+// configuration.
+func (t *Task) collectScheduledQueryStatsAndResults(ctx context.Context, ds fleet.Datastore, pool fleet.RedisPool, stats *collectorExecStats) error {
+	if err := t.collectScheduledQueryStats(ctx, ds, pool, stats); err != nil {
+		return err
+	}
+	return t.collectScheduledQueryResults(ctx, ds, pool, stats)
+}
+
 func (t *Task) collectScheduledQueryStats(ctx context.Context, ds fleet.Datastore, pool fleet.RedisPool, stats *collectorExecStats) error {
 	cfg := t.taskConfigs[config.AsyncTaskScheduledQueryStats]
 
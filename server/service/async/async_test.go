@@ -77,6 +77,20 @@ func TestCollect(t *testing.T) {
 			testCollectScheduledQueryStats(t, ds, pool)
 		})
 	})
+
+	t.Run("Scheduled Query Results", func(t *testing.T) {
+		t.Run("standalone", func(t *testing.T) {
+			defer mysql.TruncateTables(t, ds)
+			pool := redistest.SetupRedis(t, "scheduled_query_results", false, false, false)
+			testCollectScheduledQueryResults(t, ds, pool)
+		})
+
+		t.Run("cluster", func(t *testing.T) {
+			defer mysql.TruncateTables(t, ds)
+			pool := redistest.SetupRedis(t, "scheduled_query_results", true, true, false)
+			testCollectScheduledQueryResults(t, ds, pool)
+		})
+	})
 }
 
 func TestRecord(t *testing.T) {
@@ -161,6 +175,20 @@ func TestRecord(t *testing.T) {
 			t.Run("async", func(t *testing.T) { testRecordScheduledQueryStatsAsync(t, ds, pool) })
 		})
 	})
+
+	t.Run("Scheduled Query Results", func(t *testing.T) {
+		t.Run("standalone", func(t *testing.T) {
+			pool := redistest.SetupRedis(t, "scheduled_query_results", false, false, false)
+			t.Run("sync", func(t *testing.T) { testRecordScheduledQueryResultsSync(t, ds, pool) })
+			t.Run("async", func(t *testing.T) { testRecordScheduledQueryResultsAsync(t, ds, pool) })
+		})
+
+		t.Run("cluster", func(t *testing.T) {
+			pool := redistest.SetupRedis(t, "scheduled_query_results", true, true, false)
+			t.Run("sync", func(t *testing.T) { testRecordScheduledQueryResultsSync(t, ds, pool) })
+			t.Run("async", func(t *testing.T) { testRecordScheduledQueryResultsAsync(t, ds, pool) })
+		})
+	})
 }
 
 func TestActiveHostIDsSet(t *testing.T) {
@@ -105,40 +105,8 @@ func (svc *Service) NewDistributedQueryCampaign(ctx context.Context, queryString
 		logging.WithExtras(ctx, "sql", queryString, "query_id", queryID, "numHosts", numHosts)
 	}()
 
-	// Add host targets
-	for _, hid := range targets.HostIDs {
-		_, err = svc.ds.NewDistributedQueryCampaignTarget(ctx, &fleet.DistributedQueryCampaignTarget{
-			Type:                       fleet.TargetHost,
-			DistributedQueryCampaignID: campaign.ID,
-			TargetID:                   hid,
-		})
-		if err != nil {
-			return nil, ctxerr.Wrap(ctx, err, "adding host target")
-		}
-	}
-
-	// Add label targets
-	for _, lid := range targets.LabelIDs {
-		_, err = svc.ds.NewDistributedQueryCampaignTarget(ctx, &fleet.DistributedQueryCampaignTarget{
-			Type:                       fleet.TargetLabel,
-			DistributedQueryCampaignID: campaign.ID,
-			TargetID:                   lid,
-		})
-		if err != nil {
-			return nil, ctxerr.Wrap(ctx, err, "adding label target")
-		}
-	}
-
-	// Add team targets
-	for _, tid := range targets.TeamIDs {
-		_, err = svc.ds.NewDistributedQueryCampaignTarget(ctx, &fleet.DistributedQueryCampaignTarget{
-			Type:                       fleet.TargetTeam,
-			DistributedQueryCampaignID: campaign.ID,
-			TargetID:                   tid,
-		})
-		if err != nil {
-			return nil, ctxerr.Wrap(ctx, err, "adding team target")
-		}
+	if err := svc.newDistributedQueryCampaignTargets(ctx, campaign.ID, targets); err != nil {
+		return nil, err
 	}
 
 	hostIDs, err := svc.ds.HostIDsInTargets(ctx, filter, targets)
@@ -166,13 +134,54 @@ func (svc *Service) NewDistributedQueryCampaign(ctx context.Context, queryString
 		ctx,
 		authz.UserFromContext(ctx),
 		fleet.ActivityTypeLiveQuery,
-		&map[string]interface{}{"targets_count": campaign.Metrics.TotalHosts},
+		&map[string]interface{}{"targets_count": campaign.Metrics.TotalHosts, "campaign_id": campaign.ID},
 	); err != nil {
 		return nil, err
 	}
 	return campaign, nil
 }
 
+// newDistributedQueryCampaignTargets adds the hosts, labels and teams targets
+// to the campaign.
+func (svc *Service) newDistributedQueryCampaignTargets(ctx context.Context, campaignID uint, targets fleet.HostTargets) error {
+	// Add host targets
+	for _, hid := range targets.HostIDs {
+		_, err := svc.ds.NewDistributedQueryCampaignTarget(ctx, &fleet.DistributedQueryCampaignTarget{
+			Type:                       fleet.TargetHost,
+			DistributedQueryCampaignID: campaignID,
+			TargetID:                   hid,
+		})
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "adding host target")
+		}
+	}
+
+	// Add label targets
+	for _, lid := range targets.LabelIDs {
+		_, err := svc.ds.NewDistributedQueryCampaignTarget(ctx, &fleet.DistributedQueryCampaignTarget{
+			Type:                       fleet.TargetLabel,
+			DistributedQueryCampaignID: campaignID,
+			TargetID:                   lid,
+		})
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "adding label target")
+		}
+	}
+
+	// Add team targets
+	for _, tid := range targets.TeamIDs {
+		_, err := svc.ds.NewDistributedQueryCampaignTarget(ctx, &fleet.DistributedQueryCampaignTarget{
+			Type:                       fleet.TargetTeam,
+			DistributedQueryCampaignID: campaignID,
+			TargetID:                   tid,
+		})
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "adding team target")
+		}
+	}
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Create Distributed Query Campaign By Names
 ////////////////////////////////////////////////////////////////////////////////
@@ -217,3 +226,70 @@ func (svc *Service) NewDistributedQueryCampaignByNames(ctx context.Context, quer
 	targets := fleet.HostTargets{HostIDs: hostIDs, LabelIDs: labelIDs}
 	return svc.NewDistributedQueryCampaign(ctx, queryString, queryID, targets)
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Get Distributed Query Campaign Results
+////////////////////////////////////////////////////////////////////////////////
+
+type getDistributedQueryCampaignResultsRequest struct {
+	ID uint `url:"id"`
+}
+
+type getDistributedQueryCampaignResultsResponse struct {
+	Campaign *fleet.DistributedQueryCampaign         `json:"campaign,omitempty"`
+	Results  []*fleet.DistributedQueryCampaignResult `json:"results"`
+	Err      error                                   `json:"error,omitempty"`
+}
+
+func (r getDistributedQueryCampaignResultsResponse) error() error { return r.Err }
+
+func getDistributedQueryCampaignResultsEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*getDistributedQueryCampaignResultsRequest)
+	campaign, results, err := svc.GetDistributedQueryCampaignResults(ctx, req.ID)
+	if err != nil {
+		return getDistributedQueryCampaignResultsResponse{Err: err}, nil
+	}
+	return getDistributedQueryCampaignResultsResponse{Campaign: campaign, Results: results}, nil
+}
+
+func (svc *Service) GetDistributedQueryCampaignResults(ctx context.Context, campaignID uint) (*fleet.DistributedQueryCampaign, []*fleet.DistributedQueryCampaignResult, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Query{}, fleet.ActionRead); err != nil {
+		return nil, nil, err
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, nil, fleet.ErrNoContext
+	}
+
+	campaign, err := svc.ds.DistributedQueryCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, nil, ctxerr.Wrap(ctx, err, "get campaign")
+	}
+
+	filter, err := svc.campaignResultsFilter(ctx, vc.User, campaign)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := svc.ds.ListDistributedQueryCampaignResults(ctx, filter, campaign.ID)
+	if err != nil {
+		return nil, nil, ctxerr.Wrap(ctx, err, "list campaign results")
+	}
+	return campaign, results, nil
+}
+
+// campaignResultsFilter returns the filter of the stored results of the
+// campaign visible to the user. As for the live results, observers only see
+// the results of the hosts they could have run the query on.
+func (svc *Service) campaignResultsFilter(ctx context.Context, user *fleet.User, campaign *fleet.DistributedQueryCampaign) (fleet.TeamFilter, error) {
+	var observerCanRun bool
+	query, err := svc.ds.Query(ctx, campaign.QueryID)
+	switch {
+	case err == nil:
+		observerCanRun = query.ObserverCanRun
+	case !fleet.IsNotFound(err):
+		return fleet.TeamFilter{}, ctxerr.Wrap(ctx, err, "get query")
+	}
+	return fleet.TeamFilter{User: user, IncludeObserver: observerCanRun}, nil
+}
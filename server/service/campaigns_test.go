@@ -2,14 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/fleetdm/fleet/v4/server/config"
 	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/fleetdm/fleet/v4/server/mock"
 	"github.com/fleetdm/fleet/v4/server/ptr"
 	"github.com/fleetdm/fleet/v4/server/pubsub"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type nopLiveQuery struct{}
@@ -232,3 +237,179 @@ func TestLiveQueryAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestStoreCampaignResults(t *testing.T) {
+	ds := new(mock.Store)
+	cfg := config.TestConfig()
+	cfg.Osquery.EnableLiveQueryResults = true
+	cfg.Osquery.LiveQueryResultsMaxRows = 3
+	svc := newTestServiceWithConfig(t, ds, cfg, pubsub.NewInmemQueryResults(), nopLiveQuery{})
+
+	// Hack to get at the service internals
+	serv := ((svc.(validationMiddleware)).Service).(*Service)
+
+	var stored []*fleet.DistributedQueryCampaignResult
+	ds.NewDistributedQueryCampaignResultFunc = func(ctx context.Context, result *fleet.DistributedQueryCampaignResult) error {
+		stored = append(stored, result)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	readChan := make(chan interface{})
+	outChan := serv.storeCampaignResults(ctx, 1, readChan)
+
+	errMsg := "failed"
+	msgs := []interface{}{
+		fleet.DistributedQueryResult{
+			DistributedQueryCampaignID: 1,
+			Host:                       &fleet.HostResponse{Host: &fleet.Host{ID: 1, Hostname: "h1"}, DisplayName: "host1"},
+			Rows:                       []map[string]string{{"a": "1"}, {"a": "2"}},
+		},
+		fleet.DistributedQueryResult{
+			DistributedQueryCampaignID: 1,
+			Host:                       &fleet.HostResponse{Host: &fleet.Host{ID: 2, Hostname: "h2"}},
+			Error:                      &errMsg,
+		},
+		errors.New("not a result"),
+		// truncated to the max number of rows
+		fleet.DistributedQueryResult{
+			DistributedQueryCampaignID: 1,
+			Host:                       &fleet.HostResponse{Host: &fleet.Host{ID: 3, Hostname: "h3"}},
+			Rows:                       []map[string]string{{"a": "3"}, {"a": "4"}},
+		},
+		// not stored, the max number of rows is reached
+		fleet.DistributedQueryResult{
+			DistributedQueryCampaignID: 1,
+			Host:                       &fleet.HostResponse{Host: &fleet.Host{ID: 4, Hostname: "h4"}},
+			Rows:                       []map[string]string{{"a": "5"}},
+		},
+	}
+	go func() {
+		for _, msg := range msgs {
+			readChan <- msg
+		}
+		close(readChan)
+	}()
+
+	// all messages are forwarded as-is
+	var got []interface{}
+	for msg := range outChan {
+		got = append(got, msg)
+	}
+	require.Equal(t, msgs, got)
+
+	require.Len(t, stored, 3)
+	assert.Equal(t, &fleet.DistributedQueryCampaignResult{
+		DistributedQueryCampaignID: 1,
+		HostID:                     1,
+		Hostname:                   "h1",
+		HostDisplayName:            "host1",
+		Rows:                       []map[string]string{{"a": "1"}, {"a": "2"}},
+	}, stored[0])
+	assert.Equal(t, uint(2), stored[1].HostID)
+	assert.Equal(t, &errMsg, stored[1].Error)
+	assert.Equal(t, uint(3), stored[2].HostID)
+	assert.Equal(t, []map[string]string{{"a": "3"}}, stored[2].Rows)
+}
+
+func TestGetStoredCampaignReader(t *testing.T) {
+	ds := new(mock.Store)
+	cfg := config.TestConfig()
+	cfg.Osquery.EnableLiveQueryResults = true
+	svc := newTestServiceWithConfig(t, ds, cfg, pubsub.NewInmemQueryResults(), nopLiveQuery{})
+
+	ds.QueryFunc = func(ctx context.Context, id uint) (*fleet.Query, error) {
+		return &fleet.Query{ID: id, ObserverCanRun: id == 1}, nil
+	}
+	var gotFilter fleet.TeamFilter
+	ds.ListDistributedQueryCampaignResultsFunc = func(ctx context.Context, filter fleet.TeamFilter, campaignID uint) ([]*fleet.DistributedQueryCampaignResult, error) {
+		gotFilter = filter
+		return []*fleet.DistributedQueryCampaignResult{
+			{DistributedQueryCampaignID: campaignID, HostID: 1, Hostname: "h1", Rows: []map[string]string{{"a": "1"}}},
+			{DistributedQueryCampaignID: campaignID, HostID: 2, Hostname: "h2", HostDisplayName: "host2"},
+		}, nil
+	}
+
+	// observers only see the results of the hosts they could run the query on
+	_, cancel, err := svc.GetCampaignReader(test.UserContext(test.UserObserver), &fleet.DistributedQueryCampaign{ID: 2, QueryID: 2, Status: fleet.QueryComplete})
+	require.NoError(t, err)
+	cancel()
+	assert.False(t, gotFilter.IncludeObserver)
+
+	ctx := test.UserContext(test.UserAdmin)
+	readChan, cancel, err := svc.GetCampaignReader(ctx, &fleet.DistributedQueryCampaign{ID: 1, QueryID: 1, Status: fleet.QueryComplete})
+	require.NoError(t, err)
+	defer cancel()
+	assert.True(t, gotFilter.IncludeObserver)
+
+	res := (<-readChan).(fleet.DistributedQueryResult)
+	assert.Equal(t, uint(1), res.DistributedQueryCampaignID)
+	assert.Equal(t, uint(1), res.Host.ID)
+	assert.Equal(t, "h1", res.Host.Hostname)
+	assert.Equal(t, []map[string]string{{"a": "1"}}, res.Rows)
+	res = (<-readChan).(fleet.DistributedQueryResult)
+	assert.Equal(t, uint(2), res.Host.ID)
+	assert.Equal(t, "host2", res.Host.DisplayName)
+
+	// the channel stays open until the reader is done
+	select {
+	case msg := <-readChan:
+		t.Fatalf("unexpected message: %v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	cancel()
+	_, ok := <-readChan
+	require.False(t, ok)
+
+	// the campaign is not updated
+	require.False(t, ds.SaveDistributedQueryCampaignFuncInvoked)
+}
+
+func TestGetDistributedQueryCampaignResults(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.DistributedQueryCampaignFunc = func(ctx context.Context, id uint) (*fleet.DistributedQueryCampaign, error) {
+		return &fleet.DistributedQueryCampaign{ID: id, QueryID: id, Status: fleet.QueryComplete}, nil
+	}
+	ds.QueryFunc = func(ctx context.Context, id uint) (*fleet.Query, error) {
+		switch id {
+		case 1:
+			return &fleet.Query{ID: id, ObserverCanRun: true}, nil
+		case 2:
+			return &fleet.Query{ID: id}, nil
+		}
+		return nil, notFoundError{}
+	}
+	var gotFilter fleet.TeamFilter
+	ds.ListDistributedQueryCampaignResultsFunc = func(ctx context.Context, filter fleet.TeamFilter, campaignID uint) ([]*fleet.DistributedQueryCampaignResult, error) {
+		gotFilter = filter
+		return []*fleet.DistributedQueryCampaignResult{{DistributedQueryCampaignID: campaignID, HostID: 1}}, nil
+	}
+
+	observer := &fleet.User{ID: 1, GlobalRole: ptr.String(fleet.RoleObserver)}
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: observer})
+
+	campaign, results, err := svc.GetDistributedQueryCampaignResults(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), campaign.ID)
+	require.Len(t, results, 1)
+	assert.Equal(t, observer, gotFilter.User)
+	assert.True(t, gotFilter.IncludeObserver)
+
+	// observers only see the results of the hosts they could run the query on
+	_, _, err = svc.GetDistributedQueryCampaignResults(ctx, 2)
+	require.NoError(t, err)
+	assert.False(t, gotFilter.IncludeObserver)
+
+	// the query of the campaign was deleted
+	_, _, err = svc.GetDistributedQueryCampaignResults(ctx, 3)
+	require.NoError(t, err)
+	assert.False(t, gotFilter.IncludeObserver)
+
+	// unauthenticated
+	_, _, err = svc.GetDistributedQueryCampaignResults(context.Background(), 1)
+	require.Error(t, err)
+}
@@ -238,6 +238,21 @@ func (c *Client) ApplyGroup(ctx context.Context, specs *spec.Group, logf func(fo
 		}
 	}
 
+	// host tags are applied before the labels, as host_tag labels must refer
+	// to existing host tag keys.
+	if len(specs.HostTags) > 0 {
+		if opts.DryRun {
+			logfn("[!] ignoring host tags, dry run mode only supported for 'config' and 'team' specs\n")
+		} else {
+			for _, hostTags := range specs.HostTags {
+				if err := c.ApplyHostTagsSpec(hostTags); err != nil {
+					return fmt.Errorf("applying host tags: %w", err)
+				}
+			}
+			logfn("[+] applied %d host tags specs\n", len(specs.HostTags))
+		}
+	}
+
 	if len(specs.Labels) > 0 {
 		if opts.DryRun {
 			logfn("[!] ignoring labels, dry run mode only supported for 'config' and 'team' specs\n")
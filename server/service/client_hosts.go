@@ -117,3 +117,40 @@ func (c *Client) TransferHosts(hosts []string, label string, status, searchQuery
 	}{MatchQuery: searchQuery, Status: fleet.HostStatus(status), LabelID: labelIDPtr}}
 	return c.authenticatedRequest(params, verb, path, &responseBody)
 }
+
+// ApplyHostTagsSpec sends the host tags spec to be applied to the Fleet
+// instance.
+func (c *Client) ApplyHostTagsSpec(spec *fleet.HostTagsSpec) error {
+	req := applyHostTagsSpecRequest{Spec: spec}
+	verb, path := "POST", "/api/latest/fleet/spec/host_tags"
+	var responseBody applyHostTagsSpecResponse
+	return c.authenticatedRequest(req, verb, path, &responseBody)
+}
+
+// SetHostsLifecycleState sets the lifecycle state of the hosts identified by
+// their hostname, UUID, osquery host ID or node key.
+func (c *Client) SetHostsLifecycleState(hosts []string, state fleet.HostLifecycleState) error {
+	translatePayloads := make([]fleet.TranslatePayload, 0, len(hosts))
+	for _, host := range hosts {
+		translatedPayload, err := encodeTranslatedPayload(fleet.TranslatorTypeHost, host)
+		if err != nil {
+			return err
+		}
+		translatePayloads = append(translatePayloads, translatedPayload)
+	}
+
+	var translated translatorResponse
+	err := c.authenticatedRequest(translatorRequest{List: translatePayloads}, "POST", "/api/latest/fleet/translate", &translated)
+	if err != nil {
+		return err
+	}
+	hostIDs := make([]uint, 0, len(translated.List))
+	for _, payload := range translated.List {
+		hostIDs = append(hostIDs, payload.Payload.ID)
+	}
+
+	verb, path := "POST", "/api/latest/fleet/hosts/lifecycle_state"
+	var responseBody setHostsLifecycleStateResponse
+	params := setHostsLifecycleStateRequest{HostIDs: hostIDs, LifecycleState: state}
+	return c.authenticatedRequest(params, verb, path, &responseBody)
+}
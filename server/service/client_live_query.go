@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"net/http"
 	"sync/atomic"
 	"time"
@@ -175,3 +176,12 @@ func (c *Client) LiveQueryWithContext(ctx context.Context, query string, labels
 
 	return resHandler, nil
 }
+
+// GetLiveQueryCampaignResults retrieves the stored results of a live query
+// campaign.
+func (c *Client) GetLiveQueryCampaignResults(campaignID uint) ([]*fleet.DistributedQueryCampaignResult, error) {
+	verb, path := "GET", fmt.Sprintf("/api/latest/fleet/queries/campaigns/%d/results", campaignID)
+	var responseBody getDistributedQueryCampaignResultsResponse
+	err := c.authenticatedRequest(nil, verb, path, &responseBody)
+	return responseBody.Results, err
+}
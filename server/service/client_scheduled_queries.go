@@ -0,0 +1,31 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+// ListScheduledQueryResults retrieves the stored results of a scheduled query,
+// optionally only those of a host.
+func (c *Client) ListScheduledQueryResults(scheduledQueryID uint, hostID *uint) ([]*fleet.ScheduledQueryResult, error) {
+	verb, path := "GET", fmt.Sprintf("/api/latest/fleet/schedule/%d/results", scheduledQueryID)
+	query := url.Values{}
+	if hostID != nil {
+		query.Set("host_id", strconv.FormatUint(uint64(*hostID), 10))
+	}
+	var responseBody listScheduledQueryResultsResponse
+	err := c.authenticatedRequestWithQuery(nil, verb, path, &responseBody, query.Encode())
+	return responseBody.Results, err
+}
+
+// ListHostScheduledQueryResults retrieves the stored scheduled query results of
+// a host.
+func (c *Client) ListHostScheduledQueryResults(hostID uint) ([]*fleet.ScheduledQueryResult, error) {
+	verb, path := "GET", fmt.Sprintf("/api/latest/fleet/hosts/%d/schedule/results", hostID)
+	var responseBody listScheduledQueryResultsResponse
+	err := c.authenticatedRequest(nil, verb, path, &responseBody)
+	return responseBody.Results, err
+}
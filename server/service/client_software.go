@@ -1,6 +1,9 @@
 package service
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/fleetdm/fleet/v4/server/fleet"
 )
 
@@ -14,3 +17,23 @@ func (c *Client) ListSoftware(query string) ([]fleet.Software, error) {
 	}
 	return responseBody.Software, nil
 }
+
+// GetSBOM retrieves the software bill of materials document of the software
+// inventory selected by the query.
+func (c *Client) GetSBOM(query string) ([]byte, error) {
+	verb, path := "GET", "/api/latest/fleet/software/sbom"
+	response, err := c.AuthenticatedDo(verb, path, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", verb, path, err)
+	}
+	defer response.Body.Close()
+
+	if err := c.parseResponse(verb, path, response, nil); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s %s response body: %w", verb, path, err)
+	}
+	return body, nil
+}
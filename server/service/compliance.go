@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Get Compliance Summary
+////////////////////////////////////////////////////////////////////////////////
+
+type getComplianceSummaryRequest struct {
+	TeamID   *uint   `query:"team_id,optional"`
+	Platform *string `query:"platform,optional"`
+}
+
+type getComplianceSummaryResponse struct {
+	fleet.ComplianceSummary
+	Err error `json:"error,omitempty"`
+}
+
+func (r getComplianceSummaryResponse) error() error { return r.Err }
+
+func getComplianceSummaryEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*getComplianceSummaryRequest)
+	summary, err := svc.GetComplianceSummary(ctx, req.TeamID, req.Platform)
+	if err != nil {
+		return getComplianceSummaryResponse{Err: err}, nil
+	}
+	return getComplianceSummaryResponse{ComplianceSummary: *summary}, nil
+}
+
+func (svc *Service) GetComplianceSummary(ctx context.Context, teamID *uint, platform *string) (*fleet.ComplianceSummary, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{TeamID: teamID}, fleet.ActionList); err != nil {
+		return nil, err
+	}
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, fleet.ErrNoContext
+	}
+	filter := fleet.TeamFilter{User: vc.User, IncludeObserver: true, TeamID: teamID}
+
+	results, err := svc.ds.PolicyResultsBySeverity(ctx, filter, platform)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []*fleet.PolicySeverityResults{}
+	}
+
+	return &fleet.ComplianceSummary{
+		TeamID:     teamID,
+		Score:      fleet.ComplianceScore(results),
+		Severities: results,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/authz"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetComplianceSummary(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	var gotFilter fleet.TeamFilter
+	var gotPlatform *string
+	ds.PolicyResultsBySeverityFunc = func(ctx context.Context, filter fleet.TeamFilter, platform *string) ([]*fleet.PolicySeverityResults, error) {
+		gotFilter = filter
+		gotPlatform = platform
+		if filter.TeamID != nil {
+			return nil, nil
+		}
+		return []*fleet.PolicySeverityResults{
+			{Severity: fleet.PolicySeverityHigh, PassingCount: 1, FailingCount: 0},
+			{Severity: fleet.PolicySeverityLow, PassingCount: 0, FailingCount: 1},
+		}, nil
+	}
+
+	summary, err := svc.GetComplianceSummary(test.UserContext(test.UserAdmin), nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, summary.TeamID)
+	require.NotNil(t, summary.Score)
+	require.Equal(t, 80.0, *summary.Score)
+	require.Len(t, summary.Severities, 2)
+	require.Nil(t, gotFilter.TeamID)
+	require.True(t, gotFilter.IncludeObserver)
+
+	// no policy results for the team
+	summary, err = svc.GetComplianceSummary(test.UserContext(test.UserAdmin), ptr.Uint(1), nil)
+	require.NoError(t, err)
+	require.Equal(t, ptr.Uint(1), summary.TeamID)
+	require.Nil(t, summary.Score)
+	require.NotNil(t, summary.Severities)
+	require.Empty(t, summary.Severities)
+	require.Equal(t, ptr.Uint(1), gotFilter.TeamID)
+	require.Nil(t, gotPlatform)
+
+	// the results can be filtered by platform
+	_, err = svc.GetComplianceSummary(test.UserContext(test.UserAdmin), nil, ptr.String("darwin"))
+	require.NoError(t, err)
+	require.Equal(t, ptr.String("darwin"), gotPlatform)
+
+	// any user can get a summary, the results are filtered by the teams of the user
+	_, err = svc.GetComplianceSummary(test.UserContext(test.UserNoRoles), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, test.UserNoRoles, gotFilter.User)
+
+	// a user is required
+	_, err = svc.GetComplianceSummary(context.Background(), nil, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), authz.ForbiddenErrorMessage)
+}
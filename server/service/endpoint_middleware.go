@@ -247,5 +247,8 @@ func authViewer(ctx context.Context, sessionKey string, svc fleet.Service) (*vie
 	if err != nil {
 		return nil, fleet.NewAuthRequiredError(err.Error())
 	}
+	if user.Disabled {
+		return nil, fleet.NewAuthRequiredError("user is disabled")
+	}
 	return &viewer.Viewer{User: user, Session: session}, nil
 }
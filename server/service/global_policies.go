@@ -17,12 +17,18 @@ import (
 /////////////////////////////////////////////////////////////////////////////////
 
 type globalPolicyRequest struct {
-	QueryID     *uint  `json:"query_id"`
-	Query       string `json:"query"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Resolution  string `json:"resolution"`
-	Platform    string `json:"platform"`
+	QueryID          *uint    `json:"query_id"`
+	Query            string   `json:"query"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Resolution       string   `json:"resolution"`
+	Platform         string   `json:"platform"`
+	RemediationType  string   `json:"remediation_type"`
+	Remediation      string   `json:"remediation"`
+	Severity         string   `json:"severity"`
+	Tags             []string `json:"tags"`
+	LabelsIncludeAny []string `json:"labels_include_any"`
+	LabelsExcludeAny []string `json:"labels_exclude_any"`
 }
 
 type globalPolicyResponse struct {
@@ -35,12 +41,18 @@ func (r globalPolicyResponse) error() error { return r.Err }
 func globalPolicyEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
 	req := request.(*globalPolicyRequest)
 	resp, err := svc.NewGlobalPolicy(ctx, fleet.PolicyPayload{
-		QueryID:     req.QueryID,
-		Query:       req.Query,
-		Name:        req.Name,
-		Description: req.Description,
-		Resolution:  req.Resolution,
-		Platform:    req.Platform,
+		QueryID:          req.QueryID,
+		Query:            req.Query,
+		Name:             req.Name,
+		Description:      req.Description,
+		Resolution:       req.Resolution,
+		Platform:         req.Platform,
+		RemediationType:  req.RemediationType,
+		Remediation:      req.Remediation,
+		Severity:         req.Severity,
+		Tags:             req.Tags,
+		LabelsIncludeAny: req.LabelsIncludeAny,
+		LabelsExcludeAny: req.LabelsExcludeAny,
 	})
 	if err != nil {
 		return globalPolicyResponse{Err: err}, nil
@@ -52,6 +64,11 @@ func (svc Service) NewGlobalPolicy(ctx context.Context, p fleet.PolicyPayload) (
 	if err := svc.authz.Authorize(ctx, &fleet.Policy{}, fleet.ActionWrite); err != nil {
 		return nil, err
 	}
+	if p.RemediationType != "" || p.Remediation != "" {
+		if err := svc.authorizePolicyRemediation(ctx, nil); err != nil {
+			return nil, err
+		}
+	}
 	vc, ok := viewer.FromContext(ctx)
 	if !ok {
 		return nil, errors.New("user must be authenticated to create team policies")
@@ -102,7 +119,15 @@ func (svc Service) ListGlobalPolicies(ctx context.Context) ([]*fleet.Policy, err
 		return nil, err
 	}
 
-	return svc.ds.ListGlobalPolicies(ctx)
+	policies, err := svc.ds.ListGlobalPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	redact := svc.policyRemediationRedactor(ctx)
+	for _, p := range policies {
+		redact(&p.PolicyData)
+	}
+	return policies, nil
 }
 
 /////////////////////////////////////////////////////////////////////////////////
@@ -138,6 +163,7 @@ func (svc Service) GetPolicyByIDQueries(ctx context.Context, policyID uint) (*fl
 	if err != nil {
 		return nil, err
 	}
+	svc.policyRemediationRedactor(ctx)(&policy.PolicyData)
 
 	return policy, nil
 }
@@ -300,12 +326,15 @@ func applyPolicySpecsEndpoint(ctx context.Context, request interface{}, svc flee
 // TODO: add tests for activities?
 func (svc *Service) ApplyPolicySpecs(ctx context.Context, policies []*fleet.PolicySpec) error {
 	checkGlobalPolicyAuth := false
+	checkGlobalRemediationAuth := false
 	for _, policy := range policies {
 		if err := policy.Verify(); err != nil {
 			return ctxerr.Wrap(ctx, &fleet.BadRequestError{
 				Message: fmt.Sprintf("policy spec payload verification: %s", err),
 			})
 		}
+		// the remediation of the policy is set (or overwritten) by the spec
+		hasRemediation := policy.RemediationType != "" || policy.Remediation != ""
 		if policy.Team != "" {
 			team, err := svc.ds.TeamByName(ctx, policy.Team)
 			if err != nil {
@@ -318,8 +347,14 @@ func (svc *Service) ApplyPolicySpecs(ctx context.Context, policies []*fleet.Poli
 			}, fleet.ActionWrite); err != nil {
 				return err
 			}
+			if hasRemediation {
+				if err := svc.authorizePolicyRemediation(ctx, &team.ID); err != nil {
+					return err
+				}
+			}
 		} else {
 			checkGlobalPolicyAuth = true
+			checkGlobalRemediationAuth = checkGlobalRemediationAuth || hasRemediation
 		}
 	}
 	if checkGlobalPolicyAuth {
@@ -327,6 +362,11 @@ func (svc *Service) ApplyPolicySpecs(ctx context.Context, policies []*fleet.Poli
 			return err
 		}
 	}
+	if checkGlobalRemediationAuth {
+		if err := svc.authorizePolicyRemediation(ctx, nil); err != nil {
+			return err
+		}
+	}
 	vc, ok := viewer.FromContext(ctx)
 	if !ok {
 		return errors.New("user must be authenticated to apply policies")
@@ -336,10 +376,18 @@ func (svc *Service) ApplyPolicySpecs(ctx context.Context, policies []*fleet.Poli
 	}
 	// Note: Issue #4191 proposes that we move to SQL transactions for actions so that we can
 	// rollback an action in the event of an error writing the associated activity
+	// the activities are visible to all users, the remediations are not
+	// included in the applied specs.
+	activitySpecs := make([]*fleet.PolicySpec, 0, len(policies))
+	for _, policy := range policies {
+		spec := *policy
+		spec.Remediation = ""
+		activitySpecs = append(activitySpecs, &spec)
+	}
 	return svc.ds.NewActivity(
 		ctx,
 		authz.UserFromContext(ctx),
 		fleet.ActivityTypeAppliedSpecPolicy,
-		&map[string]interface{}{"policies": policies},
+		&map[string]interface{}{"policies": activitySpecs},
 	)
 }
@@ -270,6 +270,22 @@ func attachFleetAPIRoutes(r *mux.Router, svc fleet.Service, config config.FleetC
 	ue.DELETE("/api/_version_/fleet/users/{id:[0-9]+}/sessions", deleteSessionsForUserEndpoint, deleteSessionsForUserRequest{})
 	ue.POST("/api/_version_/fleet/change_password", changePasswordEndpoint, changePasswordRequest{})
 
+	// the SCIM endpoints render their errors as defined by the SCIM protocol.
+	scimOpts := append(append([]kithttp.ServerOption{}, opts...), kithttp.ServerErrorEncoder(encodeSCIMError))
+	se := newUserAuthenticatedEndpointer(svc, scimOpts, r, apiVersions...)
+	se.GET("/api/_version_/fleet/scim/v2/Users", listSCIMUsersEndpoint, listSCIMRequest{})
+	se.POST("/api/_version_/fleet/scim/v2/Users", createSCIMUserEndpoint, createSCIMUserRequest{})
+	se.GET("/api/_version_/fleet/scim/v2/Users/{id:[0-9]+}", getSCIMUserEndpoint, getSCIMRequest{})
+	se.PUT("/api/_version_/fleet/scim/v2/Users/{id:[0-9]+}", replaceSCIMUserEndpoint, replaceSCIMUserRequest{})
+	se.PATCH("/api/_version_/fleet/scim/v2/Users/{id:[0-9]+}", patchSCIMUserEndpoint, patchSCIMRequest{})
+	se.DELETE("/api/_version_/fleet/scim/v2/Users/{id:[0-9]+}", deleteSCIMUserEndpoint, getSCIMRequest{})
+	se.GET("/api/_version_/fleet/scim/v2/Groups", listSCIMGroupsEndpoint, listSCIMRequest{})
+	se.POST("/api/_version_/fleet/scim/v2/Groups", createSCIMGroupEndpoint, createSCIMGroupRequest{})
+	se.GET("/api/_version_/fleet/scim/v2/Groups/{id:[0-9]+}", getSCIMGroupEndpoint, getSCIMRequest{})
+	se.PUT("/api/_version_/fleet/scim/v2/Groups/{id:[0-9]+}", replaceSCIMGroupEndpoint, replaceSCIMGroupRequest{})
+	se.PATCH("/api/_version_/fleet/scim/v2/Groups/{id:[0-9]+}", patchSCIMGroupEndpoint, patchSCIMRequest{})
+	se.DELETE("/api/_version_/fleet/scim/v2/Groups/{id:[0-9]+}", deleteSCIMGroupEndpoint, getSCIMRequest{})
+
 	ue.GET("/api/_version_/fleet/email/change/{token}", changeEmailEndpoint, changeEmailRequest{})
 	// TODO: searchTargetsEndpoint will be removed in Fleet 5.0
 	ue.POST("/api/_version_/fleet/targets", searchTargetsEndpoint, searchTargetsRequest{})
@@ -302,6 +318,11 @@ func attachFleetAPIRoutes(r *mux.Router, svc fleet.Service, config config.FleetC
 		POST("/api/_version_/fleet/teams/{team_id}/policies/delete", deleteTeamPoliciesEndpoint, deleteTeamPoliciesRequest{})
 	ue.PATCH("/api/_version_/fleet/teams/{team_id}/policies/{policy_id}", modifyTeamPolicyEndpoint, modifyTeamPolicyRequest{})
 	ue.POST("/api/_version_/fleet/spec/policies", applyPolicySpecsEndpoint, applyPolicySpecsRequest{})
+	ue.GET("/api/_version_/fleet/compliance", getComplianceSummaryEndpoint, getComplianceSummaryRequest{})
+	ue.GET("/api/_version_/fleet/policies/{policy_id}/daily_counts", getPolicyDailyCountsEndpoint, getPolicyDailyCountsRequest{})
+	ue.GET("/api/_version_/fleet/policies/{policy_id}/waivers", listPolicyWaiversEndpoint, listPolicyWaiversRequest{})
+	ue.POST("/api/_version_/fleet/policies/{policy_id}/waivers", createPolicyWaiverEndpoint, createPolicyWaiverRequest{})
+	ue.DELETE("/api/_version_/fleet/policies/{policy_id}/waivers/{waiver_id}", deletePolicyWaiverEndpoint, deletePolicyWaiverRequest{})
 
 	ue.GET("/api/_version_/fleet/queries/{id:[0-9]+}", getQueryEndpoint, getQueryRequest{})
 	ue.GET("/api/_version_/fleet/queries", listQueriesEndpoint, listQueriesRequest{})
@@ -327,6 +348,12 @@ func attachFleetAPIRoutes(r *mux.Router, svc fleet.Service, config config.FleetC
 	ue.GET("/api/_version_/fleet/software", listSoftwareEndpoint, listSoftwareRequest{})
 	ue.GET("/api/_version_/fleet/software/{id:[0-9]+}", getSoftwareEndpoint, getSoftwareRequest{})
 	ue.GET("/api/_version_/fleet/software/count", countSoftwareEndpoint, countSoftwareRequest{})
+	ue.GET("/api/_version_/fleet/software/changes", listSoftwareChangesEndpoint, listSoftwareChangesRequest{})
+	ue.GET("/api/_version_/fleet/software/sbom", getSBOMEndpoint, getSBOMRequest{})
+	ue.GET("/api/_version_/fleet/software/rules", listSoftwareRulesEndpoint, listSoftwareRulesRequest{})
+	ue.POST("/api/_version_/fleet/software/rules", createSoftwareRuleEndpoint, createSoftwareRuleRequest{})
+	ue.PATCH("/api/_version_/fleet/software/rules/{id:[0-9]+}", modifySoftwareRuleEndpoint, modifySoftwareRuleRequest{})
+	ue.DELETE("/api/_version_/fleet/software/rules/{id:[0-9]+}", deleteSoftwareRuleEndpoint, deleteSoftwareRuleRequest{})
 
 	ue.GET("/api/_version_/fleet/host_summary", getHostSummaryEndpoint, getHostSummaryRequest{})
 	ue.GET("/api/_version_/fleet/hosts", listHostsEndpoint, listHostsRequest{})
@@ -340,6 +367,21 @@ func attachFleetAPIRoutes(r *mux.Router, svc fleet.Service, config config.FleetC
 	ue.POST("/api/_version_/fleet/hosts/transfer/filter", addHostsToTeamByFilterEndpoint, addHostsToTeamByFilterRequest{})
 	ue.POST("/api/_version_/fleet/hosts/{id:[0-9]+}/refetch", refetchHostEndpoint, refetchHostRequest{})
 	ue.GET("/api/_version_/fleet/hosts/{id:[0-9]+}/device_mapping", listHostDeviceMappingEndpoint, listHostDeviceMappingRequest{})
+	ue.GET("/api/_version_/fleet/hosts/{id:[0-9]+}/schedule/results", listHostScheduledQueryResultsEndpoint, listHostScheduledQueryResultsRequest{})
+	ue.GET("/api/_version_/fleet/hosts/{id:[0-9]+}/script_executions", listHostScriptExecutionsEndpoint, listHostScriptExecutionsRequest{})
+	ue.GET("/api/_version_/fleet/hosts/{id:[0-9]+}/policy_timeline", listHostPolicyTimelineEndpoint, listHostPolicyTimelineRequest{})
+	ue.GET("/api/_version_/fleet/hosts/{id:[0-9]+}/vitals_timeline", listHostVitalsTimelineEndpoint, listHostVitalsTimelineRequest{})
+	ue.PATCH("/api/_version_/fleet/hosts/{id:[0-9]+}/tags", setHostTagsEndpoint, setHostTagsRequest{})
+	ue.POST("/api/_version_/fleet/hosts/tags/filter", setHostTagsByFilterEndpoint, setHostTagsByFilterRequest{})
+	ue.GET("/api/_version_/fleet/host_tags", listHostTagKeysEndpoint, nil)
+	ue.DELETE("/api/_version_/fleet/host_tags/{name}", deleteHostTagKeyEndpoint, deleteHostTagKeyRequest{})
+	ue.POST("/api/_version_/fleet/spec/host_tags", applyHostTagsSpecEndpoint, applyHostTagsSpecRequest{})
+	ue.GET("/api/_version_/fleet/hosts/duplicates", listHostDuplicatesEndpoint, nil)
+	ue.DELETE("/api/_version_/fleet/hosts/duplicates/{id:[0-9]+}", deleteHostDuplicateEndpoint, deleteHostDuplicateRequest{})
+	ue.POST("/api/_version_/fleet/hosts/{id:[0-9]+}/merge", mergeHostsEndpoint, mergeHostsRequest{})
+	ue.POST("/api/_version_/fleet/hosts/lifecycle_state", setHostsLifecycleStateEndpoint, setHostsLifecycleStateRequest{})
+	ue.POST("/api/_version_/fleet/scripts/run", runScriptEndpoint, runScriptRequest{})
+	ue.GET("/api/_version_/fleet/scripts/executions/{id:[0-9]+}", getScriptExecutionEndpoint, getScriptExecutionRequest{})
 	ue.GET("/api/_version_/fleet/hosts/report", hostsReportEndpoint, hostsReportRequest{})
 	ue.GET("/api/_version_/fleet/os_versions", osVersionsEndpoint, osVersionsRequest{})
 
@@ -358,9 +400,23 @@ func attachFleetAPIRoutes(r *mux.Router, svc fleet.Service, config config.FleetC
 	ue.GET("/api/_version_/fleet/queries/run", runLiveQueryEndpoint, runLiveQueryRequest{})
 	ue.POST("/api/_version_/fleet/queries/run", createDistributedQueryCampaignEndpoint, createDistributedQueryCampaignRequest{})
 	ue.POST("/api/_version_/fleet/queries/run_by_names", createDistributedQueryCampaignByNamesEndpoint, createDistributedQueryCampaignByNamesRequest{})
+	ue.GET("/api/_version_/fleet/queries/campaigns/{id:[0-9]+}/results", getDistributedQueryCampaignResultsEndpoint, getDistributedQueryCampaignResultsRequest{})
+
+	ue.GET("/api/_version_/fleet/scheduled_live_queries", listScheduledLiveQueriesEndpoint, listScheduledLiveQueriesRequest{})
+	ue.POST("/api/_version_/fleet/scheduled_live_queries", createScheduledLiveQueryEndpoint, createScheduledLiveQueryRequest{})
+	ue.GET("/api/_version_/fleet/scheduled_live_queries/{id:[0-9]+}", getScheduledLiveQueryEndpoint, getScheduledLiveQueryRequest{})
+	ue.PATCH("/api/_version_/fleet/scheduled_live_queries/{id:[0-9]+}", modifyScheduledLiveQueryEndpoint, modifyScheduledLiveQueryRequest{})
+	ue.DELETE("/api/_version_/fleet/scheduled_live_queries/{id:[0-9]+}", deleteScheduledLiveQueryEndpoint, deleteScheduledLiveQueryRequest{})
 
 	ue.GET("/api/_version_/fleet/activities", listActivitiesEndpoint, listActivitiesRequest{})
 
+	ue.GET("/api/_version_/fleet/activity_webhooks", listActivityWebhooksEndpoint, nil)
+	ue.POST("/api/_version_/fleet/activity_webhooks", createActivityWebhookEndpoint, createActivityWebhookRequest{})
+	ue.GET("/api/_version_/fleet/activity_webhooks/{id:[0-9]+}", getActivityWebhookEndpoint, getActivityWebhookRequest{})
+	ue.PATCH("/api/_version_/fleet/activity_webhooks/{id:[0-9]+}", modifyActivityWebhookEndpoint, modifyActivityWebhookRequest{})
+	ue.DELETE("/api/_version_/fleet/activity_webhooks/{id:[0-9]+}", deleteActivityWebhookEndpoint, deleteActivityWebhookRequest{})
+	ue.GET("/api/_version_/fleet/activity_webhooks/{id:[0-9]+}/deliveries", listActivityWebhookDeliveriesEndpoint, listActivityWebhookDeliveriesRequest{})
+
 	ue.POST("/api/_version_/fleet/download_installer/{kind}", getInstallerEndpoint, getInstallerRequest{})
 	ue.HEAD("/api/_version_/fleet/download_installer/{kind}", checkInstallerEndpoint, checkInstallerRequest{})
 
@@ -368,6 +424,7 @@ func attachFleetAPIRoutes(r *mux.Router, svc fleet.Service, config config.FleetC
 	ue.EndingAtVersion("v1").POST("/api/_version_/fleet/schedule", scheduleQueryEndpoint, scheduleQueryRequest{})
 	ue.StartingAtVersion("2022-04").POST("/api/_version_/fleet/packs/schedule", scheduleQueryEndpoint, scheduleQueryRequest{})
 	ue.GET("/api/_version_/fleet/schedule/{id:[0-9]+}", getScheduledQueryEndpoint, getScheduledQueryRequest{})
+	ue.GET("/api/_version_/fleet/schedule/{id:[0-9]+}/results", listScheduledQueryResultsEndpoint, listScheduledQueryResultsRequest{})
 	ue.EndingAtVersion("v1").PATCH("/api/_version_/fleet/schedule/{id:[0-9]+}", modifyScheduledQueryEndpoint, modifyScheduledQueryRequest{})
 	ue.StartingAtVersion("2022-04").PATCH("/api/_version_/fleet/packs/schedule/{id:[0-9]+}", modifyScheduledQueryEndpoint, modifyScheduledQueryRequest{})
 	ue.EndingAtVersion("v1").DELETE("/api/_version_/fleet/schedule/{id:[0-9]+}", deleteScheduledQueryEndpoint, deleteScheduledQueryRequest{})
@@ -469,6 +526,8 @@ func attachFleetAPIRoutes(r *mux.Router, svc fleet.Service, config config.FleetC
 	oe := newOrbitAuthenticatedEndpointer(svc, logger, opts, r, apiVersions...)
 	oe.POST("/api/fleet/orbit/device_token", setOrUpdateDeviceTokenEndpoint, setOrUpdateDeviceTokenRequest{})
 	oe.POST("/api/fleet/orbit/config", getOrbitConfigEndpoint, orbitGetConfigRequest{})
+	oe.POST("/api/fleet/orbit/scripts", getOrbitScriptsEndpoint, orbitGetScriptsRequest{})
+	oe.POST("/api/fleet/orbit/scripts/result", postOrbitScriptResultEndpoint, orbitPostScriptResultRequest{})
 
 	// unauthenticated endpoints - most of those are either login-related,
 	// invite-related or host-enrolling. So they typically do some kind of
@@ -499,6 +558,7 @@ func attachFleetAPIRoutes(r *mux.Router, svc fleet.Service, config config.FleetC
 	ne.POST("/api/_version_/fleet/logout", logoutEndpoint, nil)
 	ne.POST("/api/v1/fleet/sso", initiateSSOEndpoint, initiateSSORequest{})
 	ne.POST("/api/v1/fleet/sso/callback", makeCallbackSSOEndpoint(config.Server.URLPrefix), callbackSSORequest{})
+	ne.GET("/api/v1/fleet/sso/callback", makeCallbackSSOEndpoint(config.Server.URLPrefix), callbackOIDCRequest{})
 	ne.GET("/api/v1/fleet/sso", settingsSSOEndpoint, nil)
 
 	// the websocket distributed query results endpoint is a bit different - the
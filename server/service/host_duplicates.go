@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fleetdm/fleet/v4/server/authz"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/logging"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+// deduplicateHost looks for the hosts with the same hardware serial or UUID as
+// the host that enrolled, and merges them into the host or flags them
+// depending on the strategy. The placeholder serials and UUIDs are ignored,
+// and the hosts that were seen since the host enrolled are flagged instead of
+// merged as they are still running, e.g. cloned VMs. The host gets the team
+// of a merged host if it has none. Failing to deduplicate is logged but does
+// not fail the enrollment.
+func (svc *Service) deduplicateHost(ctx context.Context, strategy fleet.HostDeduplicationStrategy, host *fleet.Host) {
+	if strategy != fleet.HostDeduplicationStrategyMerge && strategy != fleet.HostDeduplicationStrategyFlag {
+		return
+	}
+
+	hardwareSerial, uuid := host.HardwareSerial, host.UUID
+	if fleet.IsPlaceholderHostIdentifier(hardwareSerial) {
+		hardwareSerial = ""
+	}
+	if fleet.IsPlaceholderHostIdentifier(uuid) {
+		uuid = ""
+	}
+	if hardwareSerial == "" && uuid == "" {
+		return
+	}
+
+	dups, err := svc.ds.FindDuplicateHosts(ctx, host.ID, hardwareSerial, uuid)
+	if err != nil {
+		logging.WithErr(ctx, ctxerr.Wrap(ctx, err, "find duplicate hosts"))
+		return
+	}
+
+	for _, dup := range dups {
+		matchedBy := fleet.HostDuplicateMatch(host, dup)
+
+		if strategy == fleet.HostDeduplicationStrategyFlag || !dup.SeenTime.Before(host.LastEnrolledAt) {
+			if err := svc.ds.NewHostDuplicate(ctx, host.ID, dup.ID, matchedBy); err != nil {
+				logging.WithErr(ctx, ctxerr.Wrap(ctx, err, "flag duplicate host"))
+			}
+			continue
+		}
+
+		if err := svc.ds.MergeHosts(ctx, dup.ID, host.ID); err != nil {
+			logging.WithErr(ctx, ctxerr.Wrap(ctx, err, "merge duplicate host"))
+			continue
+		}
+		if host.TeamID == nil {
+			host.TeamID = dup.TeamID
+		}
+		// the merge is not done by a user
+		if err := svc.ds.NewActivity(ctx, nil, fleet.ActivityTypeMergedHosts, &map[string]interface{}{
+			"host_id":                  host.ID,
+			"host_display_name":        host.DisplayName(),
+			"merged_host_id":           dup.ID,
+			"merged_host_display_name": dup.DisplayName(),
+			"matched_by":               matchedBy,
+		}); err != nil {
+			logging.WithErr(ctx, ctxerr.Wrap(ctx, err, "create activity for merged hosts"))
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List host duplicates
+////////////////////////////////////////////////////////////////////////////////
+
+type listHostDuplicatesResponse struct {
+	Duplicates []*fleet.HostDuplicate `json:"duplicates"`
+	Err        error                  `json:"error,omitempty"`
+}
+
+func (r listHostDuplicatesResponse) error() error { return r.Err }
+
+func listHostDuplicatesEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	dups, err := svc.ListHostDuplicates(ctx)
+	if err != nil {
+		return listHostDuplicatesResponse{Err: err}, nil
+	}
+	if dups == nil {
+		dups = []*fleet.HostDuplicate{}
+	}
+	return listHostDuplicatesResponse{Duplicates: dups}, nil
+}
+
+func (svc *Service) ListHostDuplicates(ctx context.Context) ([]*fleet.HostDuplicate, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return nil, err
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, fleet.ErrNoContext
+	}
+	filter := fleet.TeamFilter{User: vc.User, IncludeObserver: true}
+
+	return svc.ds.ListHostDuplicates(ctx, filter)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete host duplicate
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteHostDuplicateRequest struct {
+	ID uint `url:"id"`
+}
+
+type deleteHostDuplicateResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteHostDuplicateResponse) error() error { return r.Err }
+
+func deleteHostDuplicateEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*deleteHostDuplicateRequest)
+	if err := svc.DeleteHostDuplicate(ctx, req.ID); err != nil {
+		return deleteHostDuplicateResponse{Err: err}, nil
+	}
+	return deleteHostDuplicateResponse{}, nil
+}
+
+func (svc *Service) DeleteHostDuplicate(ctx context.Context, id uint) error {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return err
+	}
+
+	dup, err := svc.ds.HostDuplicate(ctx, id)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "get host duplicate")
+	}
+	if _, _, err := svc.authorizeHostsWrite(ctx, dup.HostID, dup.DuplicateHostID); err != nil {
+		return err
+	}
+
+	return svc.ds.DeleteHostDuplicate(ctx, id)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Merge hosts
+////////////////////////////////////////////////////////////////////////////////
+
+type mergeHostsRequest struct {
+	ID              uint `url:"id"`
+	DuplicateHostID uint `json:"duplicate_host_id"`
+}
+
+type mergeHostsResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r mergeHostsResponse) error() error { return r.Err }
+
+func mergeHostsEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*mergeHostsRequest)
+	if err := svc.MergeHosts(ctx, req.ID, req.DuplicateHostID); err != nil {
+		return mergeHostsResponse{Err: err}, nil
+	}
+	return mergeHostsResponse{}, nil
+}
+
+func (svc *Service) MergeHosts(ctx context.Context, hostID, duplicateHostID uint) error {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return err
+	}
+	if duplicateHostID == 0 {
+		return fleet.NewInvalidArgumentError("duplicate_host_id", "missing required argument")
+	}
+	if hostID == duplicateHostID {
+		return fleet.NewInvalidArgumentError("duplicate_host_id", "cannot merge a host into itself")
+	}
+
+	host, dup, err := svc.authorizeHostsWrite(ctx, hostID, duplicateHostID)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.ds.MergeHosts(ctx, dup.ID, host.ID); err != nil {
+		return ctxerr.Wrap(ctx, err, "merge hosts")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeMergedHosts,
+		&map[string]interface{}{
+			"host_id":                  host.ID,
+			"host_display_name":        host.DisplayName(),
+			"merged_host_id":           dup.ID,
+			"merged_host_display_name": dup.DisplayName(),
+		},
+	); err != nil {
+		return ctxerr.Wrap(ctx, err, "create activity for merged hosts")
+	}
+	return nil
+}
+
+// authorizeHostsWrite loads the host and the duplicate host and authorizes the
+// user to modify both.
+func (svc *Service) authorizeHostsWrite(ctx context.Context, hostID, duplicateHostID uint) (host, dup *fleet.Host, err error) {
+	host, err = svc.ds.Host(ctx, hostID)
+	if err != nil {
+		return nil, nil, ctxerr.Wrap(ctx, err, "get host")
+	}
+	if err := svc.authz.Authorize(ctx, host, fleet.ActionWrite); err != nil {
+		return nil, nil, err
+	}
+	dup, err = svc.ds.Host(ctx, duplicateHostID)
+	if err != nil {
+		return nil, nil, ctxerr.Wrap(ctx, err, "get duplicate host")
+	}
+	if err := svc.authz.Authorize(ctx, dup, fleet.ActionWrite); err != nil {
+		return nil, nil, err
+	}
+	return host, dup, nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostDuplicatesAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.ListHostDuplicatesFunc = func(ctx context.Context, filter fleet.TeamFilter) ([]*fleet.HostDuplicate, error) {
+		return nil, nil
+	}
+	ds.HostDuplicateFunc = func(ctx context.Context, id uint) (*fleet.HostDuplicate, error) {
+		return &fleet.HostDuplicate{ID: id, HostID: 1, DuplicateHostID: 2}, nil
+	}
+	ds.DeleteHostDuplicateFunc = func(ctx context.Context, id uint) error {
+		return nil
+	}
+	ds.HostFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		// the duplicate host is on another team
+		if id == 2 {
+			return &fleet.Host{ID: id, TeamID: ptr.Uint(2)}, nil
+		}
+		return &fleet.Host{ID: id, TeamID: ptr.Uint(1)}, nil
+	}
+	ds.MergeHostsFunc = func(ctx context.Context, fromHostID, toHostID uint) error {
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		return nil
+	}
+
+	testCases := []struct {
+		name               string
+		user               *fleet.User
+		shouldFailRead     bool
+		shouldFailWrite    bool
+		shouldFailSameTeam bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, false, false, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, false, true, true},
+		{"team admin, both teams", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}, {Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, false, false, false},
+		{"team admin, host team only", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, false, true, false},
+		{"team observer, both teams", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleObserver}, {Team: fleet.Team{ID: 2}, Role: fleet.RoleObserver}}}, false, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.ListHostDuplicates(ctx)
+			checkAuthErr(t, tt.shouldFailRead, err)
+
+			// both hosts must be writable
+			err = svc.DeleteHostDuplicate(ctx, 1)
+			checkAuthErr(t, tt.shouldFailWrite, err)
+			err = svc.MergeHosts(ctx, 1, 2)
+			checkAuthErr(t, tt.shouldFailWrite, err)
+			err = svc.MergeHosts(ctx, 1, 3)
+			checkAuthErr(t, tt.shouldFailSameTeam, err)
+		})
+	}
+}
+
+func TestMergeHosts(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.HostFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		return &fleet.Host{ID: id, Hostname: "host"}, nil
+	}
+	ds.MergeHostsFunc = func(ctx context.Context, fromHostID, toHostID uint) error {
+		require.Equal(t, uint(1), fromHostID)
+		require.Equal(t, uint(2), toHostID)
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.NotNil(t, user)
+		require.Equal(t, fleet.ActivityTypeMergedHosts, activityType)
+		require.Equal(t, uint(1), (*details)["merged_host_id"])
+		return nil
+	}
+
+	// the duplicate host is merged into the host
+	require.NoError(t, svc.MergeHosts(ctx, 2, 1))
+	require.True(t, ds.MergeHostsFuncInvoked)
+	require.True(t, ds.NewActivityFuncInvoked)
+
+	ds.MergeHostsFuncInvoked = false
+	var iae *fleet.InvalidArgumentError
+	err := svc.MergeHosts(ctx, 2, 2)
+	require.ErrorAs(t, err, &iae)
+	err = svc.MergeHosts(ctx, 2, 0)
+	require.ErrorAs(t, err, &iae)
+	require.False(t, ds.MergeHostsFuncInvoked)
+}
+
+func TestEnrollAgentDeduplicateHost(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	var strategy fleet.HostDeduplicationStrategy
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{HostDeduplicationSettings: fleet.HostDeduplicationSettings{Strategy: strategy}}, nil
+	}
+	ds.VerifyEnrollSecretFunc = func(ctx context.Context, secret string) (*fleet.EnrollSecret, error) {
+		return &fleet.EnrollSecret{Secret: secret}, nil
+	}
+	enrolledAt := time.Now().UTC()
+	ds.EnrollHostFunc = func(ctx context.Context, osqueryHostId, nodeKey string, teamID *uint, cooldown time.Duration) (*fleet.Host, error) {
+		return &fleet.Host{ID: 3, OsqueryHostID: osqueryHostId, NodeKey: nodeKey, LastEnrolledAt: enrolledAt}, nil
+	}
+	ds.FindDuplicateHostsFunc = func(ctx context.Context, hostID uint, hardwareSerial, uuid string) ([]*fleet.Host, error) {
+		require.Equal(t, uint(3), hostID)
+		require.Equal(t, "C02ABC", hardwareSerial)
+		return []*fleet.Host{
+			{ID: 1, HardwareSerial: "C02ABC", TeamID: ptr.Uint(5), SeenTime: enrolledAt.Add(-24 * time.Hour)},
+			{ID: 2, UUID: "uuid-1", SeenTime: enrolledAt.Add(-time.Hour)},
+			// still running, it is flagged instead of merged
+			{ID: 4, HardwareSerial: "C02ABC", SeenTime: enrolledAt.Add(time.Minute)},
+		}, nil
+	}
+	var flagged []string
+	ds.NewHostDuplicateFunc = func(ctx context.Context, hostID, duplicateHostID uint, matchedBy string) error {
+		require.Equal(t, uint(3), hostID)
+		flagged = append(flagged, matchedBy)
+		return nil
+	}
+	var merged []uint
+	ds.MergeHostsFunc = func(ctx context.Context, fromHostID, toHostID uint) error {
+		require.Equal(t, uint(3), toHostID)
+		merged = append(merged, fromHostID)
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.Nil(t, user)
+		require.Equal(t, fleet.ActivityTypeMergedHosts, activityType)
+		return nil
+	}
+	var saved *fleet.Host
+	ds.UpdateHostFunc = func(ctx context.Context, host *fleet.Host) error {
+		saved = host
+		return nil
+	}
+
+	details := map[string](map[string]string){
+		"system_info": {"hostname": "mbp", "uuid": "uuid-1", "hardware_serial": "C02ABC"},
+	}
+
+	// disabled by default
+	_, err := svc.EnrollAgent(context.Background(), "secret", "host3", details)
+	require.NoError(t, err)
+	require.False(t, ds.FindDuplicateHostsFuncInvoked)
+
+	strategy = fleet.HostDeduplicationStrategyFlag
+	_, err = svc.EnrollAgent(context.Background(), "secret", "host3", details)
+	require.NoError(t, err)
+	require.Equal(t, []string{fleet.HostDuplicateMatchHardwareSerial, fleet.HostDuplicateMatchUUID, fleet.HostDuplicateMatchHardwareSerial}, flagged)
+	require.False(t, ds.MergeHostsFuncInvoked)
+
+	// the host gets the team of the first merged host
+	flagged = nil
+	strategy = fleet.HostDeduplicationStrategyMerge
+	_, err = svc.EnrollAgent(context.Background(), "secret", "host3", details)
+	require.NoError(t, err)
+	require.Equal(t, []uint{1, 2}, merged)
+	require.Equal(t, []string{fleet.HostDuplicateMatchHardwareSerial}, flagged)
+	require.NotNil(t, saved)
+	require.Equal(t, ptr.Uint(5), saved.TeamID)
+
+	// the placeholder identifiers are not used to find duplicates
+	ds.FindDuplicateHostsFuncInvoked = false
+	details["system_info"] = map[string]string{"hostname": "pc", "uuid": "03000200-0400-0500-0006-000700080009", "hardware_serial": "To Be Filled By O.E.M."}
+	_, err = svc.EnrollAgent(context.Background(), "secret", "host3", details)
+	require.NoError(t, err)
+	require.False(t, ds.FindDuplicateHostsFuncInvoked)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fleetdm/fleet/v4/server/authz"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/logging"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/worker"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Set hosts lifecycle state
+////////////////////////////////////////////////////////////////////////////////
+
+type setHostsLifecycleStateRequest struct {
+	HostIDs        []uint                   `json:"hosts"`
+	LifecycleState fleet.HostLifecycleState `json:"lifecycle_state"`
+}
+
+type setHostsLifecycleStateResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r setHostsLifecycleStateResponse) error() error { return r.Err }
+
+func setHostsLifecycleStateEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*setHostsLifecycleStateRequest)
+	if err := svc.SetHostsLifecycleState(ctx, req.HostIDs, req.LifecycleState); err != nil {
+		return setHostsLifecycleStateResponse{Err: err}, nil
+	}
+	return setHostsLifecycleStateResponse{}, nil
+}
+
+func (svc *Service) SetHostsLifecycleState(ctx context.Context, hostIDs []uint, state fleet.HostLifecycleState) error {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return err
+	}
+	if err := fleet.ValidateHostLifecycleState(state); err != nil {
+		return ctxerr.Wrap(ctx, err, "validate lifecycle state")
+	}
+	if len(hostIDs) == 0 {
+		return fleet.NewInvalidArgumentError("hosts", "at least one host is required")
+	}
+
+	hosts, err := svc.ds.ListHostsLiteByIDs(ctx, hostIDs)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list hosts")
+	}
+	found := make(map[uint]bool, len(hosts))
+	for _, host := range hosts {
+		found[host.ID] = true
+	}
+	for _, id := range hostIDs {
+		if !found[id] {
+			return fleet.NewInvalidArgumentError("hosts", fmt.Sprintf("host %d not found", id))
+		}
+	}
+
+	// the user must be allowed to modify all the hosts, the hosts that are
+	// already in the state are left untouched.
+	var changed []*fleet.Host
+	for _, host := range hosts {
+		if err := svc.authz.Authorize(ctx, host, fleet.ActionWrite); err != nil {
+			return err
+		}
+		if host.LifecycleState != state {
+			changed = append(changed, host)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	changedIDs := make([]uint, 0, len(changed))
+	for _, host := range changed {
+		changedIDs = append(changedIDs, host.ID)
+	}
+
+	var quarantineWebhook bool
+	var quarantineTeamID *uint
+	if state == fleet.HostLifecycleStateQuarantined {
+		appConfig, err := svc.ds.AppConfig(ctx)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "get app config")
+		}
+		quarantineWebhook = appConfig.WebhookSettings.HostQuarantineWebhook.Enable
+
+		// moving the hosts to another team is a premium feature
+		if teamID := appConfig.HostLifecycleSettings.QuarantineTeamID; teamID != nil && svc.license.IsPremium() {
+			_, err := svc.ds.Team(ctx, *teamID)
+			switch {
+			case fleet.IsNotFound(err):
+				// the quarantine team was deleted, the hosts keep their team
+				logging.WithErr(ctx, ctxerr.Wrap(ctx, err, "get quarantine team"))
+			case err != nil:
+				return ctxerr.Wrap(ctx, err, "get quarantine team")
+			default:
+				quarantineTeamID = teamID
+			}
+		}
+	}
+
+	// the quarantined hosts are moved to the quarantine team with the state
+	// change, and back to their previous team when they leave the quarantine.
+	if err := svc.ds.SetHostsLifecycleState(ctx, changedIDs, state, quarantineTeamID); err != nil {
+		return ctxerr.Wrap(ctx, err, "set hosts lifecycle state")
+	}
+
+	for _, host := range changed {
+		if err := svc.ds.NewActivity(
+			ctx,
+			authz.UserFromContext(ctx),
+			fleet.ActivityTypeChangedHostLifecycleState,
+			&map[string]interface{}{
+				"host_id":                  host.ID,
+				"host_display_name":        host.DisplayName(),
+				"lifecycle_state":          state,
+				"previous_lifecycle_state": host.LifecycleState,
+			},
+		); err != nil {
+			return ctxerr.Wrap(ctx, err, "create activity for changed host lifecycle state")
+		}
+
+		if quarantineWebhook {
+			if err := worker.QueueHostQuarantineWebhookJob(ctx, svc.ds, host.ID, host.LifecycleState); err != nil {
+				return ctxerr.Wrap(ctx, err, "queue host quarantine webhook")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetHostsLifecycleStateAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, hostIDs []uint) ([]*fleet.Host, error) {
+		return []*fleet.Host{{ID: 1, TeamID: ptr.Uint(1), LifecycleState: fleet.HostLifecycleStateActive}}, nil
+	}
+	ds.SetHostsLifecycleStateFunc = func(ctx context.Context, hostIDs []uint, state fleet.HostLifecycleState, quarantineTeamID *uint) error {
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		return nil
+	}
+
+	testCases := []struct {
+		name            string
+		user            *fleet.User
+		shouldFailWrite bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, true},
+		{"team maintainer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}}, false},
+		{"team observer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleObserver}}}, true},
+		{"team admin, different team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			err := svc.SetHostsLifecycleState(ctx, []uint{1}, fleet.HostLifecycleStateInRepair)
+			checkAuthErr(t, tt.shouldFailWrite, err)
+		})
+	}
+}
+
+func TestSetHostsLifecycleState(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil, &TestServerOpts{License: &fleet.LicenseInfo{Tier: fleet.TierPremium}})
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, hostIDs []uint) ([]*fleet.Host, error) {
+		var hosts []*fleet.Host
+		for _, id := range hostIDs {
+			switch id {
+			case 1:
+				hosts = append(hosts, &fleet.Host{ID: 1, Hostname: "h1", LifecycleState: fleet.HostLifecycleStateActive})
+			case 2:
+				hosts = append(hosts, &fleet.Host{ID: 2, Hostname: "h2", LifecycleState: fleet.HostLifecycleStateQuarantined})
+			}
+		}
+		return hosts, nil
+	}
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{
+			HostLifecycleSettings: fleet.HostLifecycleSettings{QuarantineTeamID: ptr.Uint(9)},
+			WebhookSettings: fleet.WebhookSettings{
+				HostQuarantineWebhook: fleet.HostQuarantineWebhookSettings{Enable: true, DestinationURL: "https://example.com"},
+			},
+		}, nil
+	}
+	ds.TeamFunc = func(ctx context.Context, tid uint) (*fleet.Team, error) {
+		return &fleet.Team{ID: tid}, nil
+	}
+	ds.SetHostsLifecycleStateFunc = func(ctx context.Context, hostIDs []uint, state fleet.HostLifecycleState, quarantineTeamID *uint) error {
+		require.Equal(t, []uint{1}, hostIDs)
+		require.Equal(t, fleet.HostLifecycleStateQuarantined, state)
+		require.Equal(t, ptr.Uint(9), quarantineTeamID)
+		return nil
+	}
+	var activities []map[string]interface{}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.Equal(t, fleet.ActivityTypeChangedHostLifecycleState, activityType)
+		activities = append(activities, *details)
+		return nil
+	}
+	var jobs []*fleet.Job
+	ds.NewJobFunc = func(ctx context.Context, job *fleet.Job) (*fleet.Job, error) {
+		jobs = append(jobs, job)
+		return job, nil
+	}
+
+	// the host already quarantined is left untouched, the other is moved to
+	// the quarantine team and notified to the webhook
+	err := svc.SetHostsLifecycleState(ctx, []uint{1, 2}, fleet.HostLifecycleStateQuarantined)
+	require.NoError(t, err)
+	require.True(t, ds.SetHostsLifecycleStateFuncInvoked)
+	require.Len(t, activities, 1)
+	require.Equal(t, uint(1), activities[0]["host_id"])
+	require.Equal(t, fleet.HostLifecycleStateActive, activities[0]["previous_lifecycle_state"])
+	require.Len(t, jobs, 1)
+	require.Equal(t, fleet.HostQuarantineWebhookJobName, jobs[0].Name)
+	var args map[string]interface{}
+	require.NoError(t, json.Unmarshal(*jobs[0].Args, &args))
+	require.Equal(t, map[string]interface{}{"host_id": float64(1), "previous_lifecycle_state": "active"}, args)
+
+	// nothing is done if all the hosts are in the state
+	ds.SetHostsLifecycleStateFuncInvoked = false
+	err = svc.SetHostsLifecycleState(ctx, []uint{2}, fleet.HostLifecycleStateQuarantined)
+	require.NoError(t, err)
+	require.False(t, ds.SetHostsLifecycleStateFuncInvoked)
+
+	// the state and hosts are validated
+	var iae *fleet.InvalidArgumentError
+	err = svc.SetHostsLifecycleState(ctx, []uint{1}, "decommissioned")
+	require.ErrorAs(t, err, &iae)
+	err = svc.SetHostsLifecycleState(ctx, nil, fleet.HostLifecycleStateRetired)
+	require.ErrorAs(t, err, &iae)
+	err = svc.SetHostsLifecycleState(ctx, []uint{1, 3}, fleet.HostLifecycleStateRetired)
+	require.ErrorAs(t, err, &iae)
+	require.ErrorContains(t, err, "host 3 not found")
+	require.False(t, ds.SetHostsLifecycleStateFuncInvoked)
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fleetdm/fleet/v4/server/authz"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List host tag keys
+////////////////////////////////////////////////////////////////////////////////
+
+type listHostTagKeysResponse struct {
+	Keys []*fleet.HostTagKey `json:"keys"`
+	Err  error               `json:"error,omitempty"`
+}
+
+func (r listHostTagKeysResponse) error() error { return r.Err }
+
+func listHostTagKeysEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	keys, err := svc.ListHostTagKeys(ctx)
+	if err != nil {
+		return listHostTagKeysResponse{Err: err}, nil
+	}
+	if keys == nil {
+		keys = []*fleet.HostTagKey{}
+	}
+	return listHostTagKeysResponse{Keys: keys}, nil
+}
+
+func (svc *Service) ListHostTagKeys(ctx context.Context) ([]*fleet.HostTagKey, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.HostTagKey{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+	return svc.ds.ListHostTagKeys(ctx)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete host tag key
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteHostTagKeyRequest struct {
+	Name string `url:"name"`
+}
+
+type deleteHostTagKeyResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteHostTagKeyResponse) error() error { return r.Err }
+
+func deleteHostTagKeyEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*deleteHostTagKeyRequest)
+	if err := svc.DeleteHostTagKey(ctx, req.Name); err != nil {
+		return deleteHostTagKeyResponse{Err: err}, nil
+	}
+	return deleteHostTagKeyResponse{}, nil
+}
+
+func (svc *Service) DeleteHostTagKey(ctx context.Context, name string) error {
+	if err := svc.authz.Authorize(ctx, &fleet.HostTagKey{}, fleet.ActionWrite); err != nil {
+		return err
+	}
+
+	if err := svc.ds.DeleteHostTagKey(ctx, name); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete host tag key")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeDeletedHostTagKey,
+		&map[string]interface{}{"key_name": name},
+	); err != nil {
+		return ctxerr.Wrap(ctx, err, "create activity for host tag key deletion")
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Apply host tags spec
+////////////////////////////////////////////////////////////////////////////////
+
+type applyHostTagsSpecRequest struct {
+	Spec *fleet.HostTagsSpec `json:"spec"`
+}
+
+type applyHostTagsSpecResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r applyHostTagsSpecResponse) error() error { return r.Err }
+
+func applyHostTagsSpecEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*applyHostTagsSpecRequest)
+	if err := svc.ApplyHostTagsSpec(ctx, req.Spec); err != nil {
+		return applyHostTagsSpecResponse{Err: err}, nil
+	}
+	return applyHostTagsSpecResponse{}, nil
+}
+
+func (svc *Service) ApplyHostTagsSpec(ctx context.Context, spec *fleet.HostTagsSpec) error {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return err
+	}
+	if spec == nil {
+		return fleet.NewInvalidArgumentError("spec", "missing required argument")
+	}
+	if len(spec.Keys) > 0 {
+		if err := svc.authz.Authorize(ctx, &fleet.HostTagKey{}, fleet.ActionWrite); err != nil {
+			return err
+		}
+	}
+
+	existing, err := svc.ds.ListHostTagKeys(ctx)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list host tag keys")
+	}
+	keysByName := make(map[string]*fleet.HostTagKey, len(existing))
+	for _, k := range existing {
+		keysByName[k.Name] = k
+	}
+
+	// validate everything before applying anything
+	keys := make([]*fleet.HostTagKey, 0, len(spec.Keys))
+	keyNames := make([]string, 0, len(spec.Keys))
+	seen := make(map[string]bool, len(spec.Keys))
+	for _, ks := range spec.Keys {
+		key := &fleet.HostTagKey{Name: ks.Name, Type: ks.Type, Description: ks.Description}
+		if err := key.Verify(); err != nil {
+			return fleet.NewInvalidArgumentError("keys", err.Error())
+		}
+		if seen[key.Name] {
+			return fleet.NewInvalidArgumentError("keys", fmt.Sprintf("duplicate host tag key %q", key.Name))
+		}
+		seen[key.Name] = true
+
+		// the values of a key are not converted, so its type can only change
+		// while it is not set on any host.
+		if prev := keysByName[key.Name]; prev != nil && prev.Type != key.Type && prev.HostCount > 0 {
+			return fleet.NewInvalidArgumentError("keys",
+				fmt.Sprintf("cannot change the type of host tag key %q as it is set on %d hosts", key.Name, prev.HostCount))
+		}
+		keysByName[key.Name] = key
+		keys = append(keys, key)
+		keyNames = append(keyNames, key.Name)
+	}
+
+	allKeys := make([]*fleet.HostTagKey, 0, len(keysByName))
+	for _, k := range keysByName {
+		allKeys = append(allKeys, k)
+	}
+	hostIDs := make([]uint, 0, len(spec.Hosts))
+	hostTags := make([]fleet.HostTagValues, 0, len(spec.Hosts))
+	for _, hs := range spec.Hosts {
+		host, err := svc.ds.HostByIdentifier(ctx, hs.Host)
+		if err != nil {
+			if fleet.IsNotFound(err) {
+				return fleet.NewInvalidArgumentError("hosts", fmt.Sprintf("host %q not found", hs.Host))
+			}
+			return ctxerr.Wrap(ctx, err, "get host by identifier")
+		}
+		if err := svc.authz.Authorize(ctx, host, fleet.ActionWrite); err != nil {
+			return err
+		}
+		tags, err := hs.Tags.Normalize(allKeys)
+		if err != nil {
+			return err
+		}
+		hostIDs = append(hostIDs, host.ID)
+		hostTags = append(hostTags, tags)
+	}
+
+	if err := svc.ds.ApplyHostTagKeys(ctx, keys); err != nil {
+		return ctxerr.Wrap(ctx, err, "apply host tag keys")
+	}
+	for i, hostID := range hostIDs {
+		if err := svc.ds.SetHostTags(ctx, []uint{hostID}, hostTags[i]); err != nil {
+			return ctxerr.Wrap(ctx, err, "set host tags")
+		}
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeAppliedSpecHostTags,
+		&map[string]interface{}{"keys": keyNames, "host_count": len(hostIDs)},
+	); err != nil {
+		return ctxerr.Wrap(ctx, err, "create activity for host tags spec")
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Set host tags
+////////////////////////////////////////////////////////////////////////////////
+
+type setHostTagsRequest struct {
+	ID   uint                `url:"id"`
+	Tags fleet.HostTagValues `json:"tags"`
+}
+
+type setHostTagsResponse struct {
+	HostID uint              `json:"host_id"`
+	Tags   map[string]string `json:"tags"`
+	Err    error             `json:"error,omitempty"`
+}
+
+func (r setHostTagsResponse) error() error { return r.Err }
+
+func setHostTagsEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*setHostTagsRequest)
+	tags, err := svc.SetHostTags(ctx, req.ID, req.Tags)
+	if err != nil {
+		return setHostTagsResponse{Err: err}, nil
+	}
+	return setHostTagsResponse{HostID: req.ID, Tags: tags}, nil
+}
+
+func (svc *Service) SetHostTags(ctx context.Context, hostID uint, tags fleet.HostTagValues) (map[string]string, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return nil, err
+	}
+
+	host, err := svc.ds.Host(ctx, hostID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get host")
+	}
+	if err := svc.authz.Authorize(ctx, host, fleet.ActionWrite); err != nil {
+		return nil, err
+	}
+
+	normalized, err := svc.normalizeHostTags(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.ds.SetHostTags(ctx, []uint{host.ID}, normalized); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "set host tags")
+	}
+
+	result := make(map[string]string)
+	if host.Tags != nil {
+		if err := json.Unmarshal(*host.Tags, &result); err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "unmarshal host tags")
+		}
+	}
+	for name, value := range normalized {
+		if value == nil {
+			delete(result, name)
+		} else {
+			result[name] = *value
+		}
+	}
+	return result, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Set host tags by filter
+////////////////////////////////////////////////////////////////////////////////
+
+type setHostTagsByFilterRequest struct {
+	Tags    fleet.HostTagValues `json:"tags"`
+	Filters struct {
+		MatchQuery string           `json:"query"`
+		Status     fleet.HostStatus `json:"status"`
+		LabelID    *uint            `json:"label_id"`
+		TeamID     *uint            `json:"team_id"`
+		// HostTags filters the hosts by host tags, in the key or key:value form.
+		HostTags []string `json:"host_tags"`
+	} `json:"filters"`
+}
+
+type setHostTagsByFilterResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r setHostTagsByFilterResponse) error() error { return r.Err }
+
+func setHostTagsByFilterEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*setHostTagsByFilterRequest)
+	listOpt := fleet.HostListOptions{
+		ListOptions: fleet.ListOptions{
+			MatchQuery: req.Filters.MatchQuery,
+		},
+		StatusFilter: req.Filters.Status,
+		TeamFilter:   req.Filters.TeamID,
+	}
+	for _, s := range req.Filters.HostTags {
+		f, err := fleet.ParseHostTagFilter(s)
+		if err != nil {
+			return setHostTagsByFilterResponse{Err: fleet.NewInvalidArgumentError("host_tags", err.Error())}, nil
+		}
+		listOpt.HostTagFilters = append(listOpt.HostTagFilters, f)
+	}
+	if err := svc.SetHostTagsByFilter(ctx, req.Tags, listOpt, req.Filters.LabelID); err != nil {
+		return setHostTagsByFilterResponse{Err: err}, nil
+	}
+	return setHostTagsByFilterResponse{}, nil
+}
+
+func (svc *Service) SetHostTagsByFilter(ctx context.Context, tags fleet.HostTagValues, opt fleet.HostListOptions, lid *uint) error {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return fleet.NewInvalidArgumentError("tags", "at least one host tag must be set or unset")
+	}
+	normalized, err := svc.normalizeHostTags(ctx, tags)
+	if err != nil {
+		return err
+	}
+
+	hostIDs, err := svc.hostIDsFromFilters(ctx, opt, lid)
+	if err != nil {
+		return err
+	}
+	if len(hostIDs) == 0 {
+		return nil
+	}
+	if err := svc.checkWriteForHostIDs(ctx, hostIDs); err != nil {
+		return err
+	}
+
+	return svc.ds.SetHostTags(ctx, hostIDs, normalized)
+}
+
+// normalizeHostTags verifies the host tags against the types of their keys
+// and returns them in their canonical form.
+func (svc *Service) normalizeHostTags(ctx context.Context, tags fleet.HostTagValues) (fleet.HostTagValues, error) {
+	if len(tags) == 0 {
+		return tags, nil
+	}
+	keys, err := svc.ds.ListHostTagKeys(ctx)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list host tag keys")
+	}
+	return tags.Normalize(keys)
+}
+
+// normalizeLabelHostTag verifies the host tag criteria of a label with the
+// host_tag membership type, and returns its value in its canonical form.
+func (svc *Service) normalizeLabelHostTag(ctx context.Context, key, value *string) (*string, error) {
+	if key == nil || *key == "" {
+		return nil, fleet.NewInvalidArgumentError("host_tag_key", "missing required argument for a host_tag label")
+	}
+	normalized, err := svc.normalizeHostTags(ctx, fleet.HostTagValues{*key: value})
+	if err != nil {
+		return nil, err
+	}
+	return normalized[*key], nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostTagsAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.ListHostTagKeysFunc = func(ctx context.Context) ([]*fleet.HostTagKey, error) {
+		return []*fleet.HostTagKey{{Name: "owner", Type: fleet.HostTagTypeString}}, nil
+	}
+	ds.ApplyHostTagKeysFunc = func(ctx context.Context, keys []*fleet.HostTagKey) error {
+		return nil
+	}
+	ds.DeleteHostTagKeyFunc = func(ctx context.Context, name string) error {
+		return nil
+	}
+	ds.SetHostTagsFunc = func(ctx context.Context, hostIDs []uint, tags fleet.HostTagValues) error {
+		return nil
+	}
+	ds.HostFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		return &fleet.Host{ID: id, TeamID: ptr.Uint(1)}, nil
+	}
+	ds.HostByIdentifierFunc = func(ctx context.Context, identifier string) (*fleet.Host, error) {
+		return &fleet.Host{ID: 1, TeamID: ptr.Uint(1)}, nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		return nil
+	}
+
+	tags := fleet.HostTagValues{"owner": ptr.String("alice")}
+
+	testCases := []struct {
+		name                string
+		user                *fleet.User
+		shouldFailKeysWrite bool
+		shouldFailHostWrite bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, false, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, true, true},
+		{"team admin, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, true, false},
+		{"team maintainer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}}, true, false},
+		{"team observer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleObserver}}}, true, true},
+		{"team admin, different team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			// all users can list the host tag keys
+			_, err := svc.ListHostTagKeys(ctx)
+			checkAuthErr(t, false, err)
+
+			err = svc.DeleteHostTagKey(ctx, "owner")
+			checkAuthErr(t, tt.shouldFailKeysWrite, err)
+			err = svc.ApplyHostTagsSpec(ctx, &fleet.HostTagsSpec{
+				Keys: []*fleet.HostTagKeySpec{{Name: "owner", Type: fleet.HostTagTypeString}},
+			})
+			checkAuthErr(t, tt.shouldFailKeysWrite, err)
+
+			err = svc.ApplyHostTagsSpec(ctx, &fleet.HostTagsSpec{
+				Hosts: []*fleet.HostTagsHostSpec{{Host: "host1", Tags: tags}},
+			})
+			checkAuthErr(t, tt.shouldFailHostWrite, err)
+			_, err = svc.SetHostTags(ctx, 1, tags)
+			checkAuthErr(t, tt.shouldFailHostWrite, err)
+		})
+	}
+}
+
+func TestSetHostTags(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.ListHostTagKeysFunc = func(ctx context.Context) ([]*fleet.HostTagKey, error) {
+		return []*fleet.HostTagKey{
+			{Name: "owner", Type: fleet.HostTagTypeString},
+			{Name: "cost_center", Type: fleet.HostTagTypeNumber},
+			{Name: "critical", Type: fleet.HostTagTypeBoolean},
+		}, nil
+	}
+	ds.HostFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		tags := json.RawMessage(`{"owner":"alice","critical":"true"}`)
+		return &fleet.Host{ID: id, Tags: &tags}, nil
+	}
+	var gotTags fleet.HostTagValues
+	ds.SetHostTagsFunc = func(ctx context.Context, hostIDs []uint, tags fleet.HostTagValues) error {
+		require.Equal(t, []uint{1}, hostIDs)
+		gotTags = tags
+		return nil
+	}
+
+	// the values are normalized and merged with the existing tags
+	result, err := svc.SetHostTags(ctx, 1, fleet.HostTagValues{
+		"cost_center": ptr.String("1234.50"),
+		"critical":    nil,
+	})
+	require.NoError(t, err)
+	require.True(t, ds.SetHostTagsFuncInvoked)
+	require.Equal(t, fleet.HostTagValues{"cost_center": ptr.String("1234.5"), "critical": nil}, gotTags)
+	require.Equal(t, map[string]string{"owner": "alice", "cost_center": "1234.5"}, result)
+
+	// invalid values and unknown keys are rejected
+	ds.SetHostTagsFuncInvoked = false
+	_, err = svc.SetHostTags(ctx, 1, fleet.HostTagValues{"cost_center": ptr.String("a lot")})
+	var iae *fleet.InvalidArgumentError
+	require.ErrorAs(t, err, &iae)
+	_, err = svc.SetHostTags(ctx, 1, fleet.HostTagValues{"env": ptr.String("prod")})
+	require.ErrorAs(t, err, &iae)
+	require.False(t, ds.SetHostTagsFuncInvoked)
+}
+
+func TestApplyHostTagsSpec(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.ListHostTagKeysFunc = func(ctx context.Context) ([]*fleet.HostTagKey, error) {
+		return []*fleet.HostTagKey{{Name: "owner", Type: fleet.HostTagTypeString, HostCount: 2}}, nil
+	}
+	ds.HostByIdentifierFunc = func(ctx context.Context, identifier string) (*fleet.Host, error) {
+		if identifier == "unknown" {
+			return nil, &notFoundError{}
+		}
+		return &fleet.Host{ID: 7}, nil
+	}
+	var gotKeys []*fleet.HostTagKey
+	ds.ApplyHostTagKeysFunc = func(ctx context.Context, keys []*fleet.HostTagKey) error {
+		gotKeys = keys
+		return nil
+	}
+	var gotTags fleet.HostTagValues
+	ds.SetHostTagsFunc = func(ctx context.Context, hostIDs []uint, tags fleet.HostTagValues) error {
+		require.Equal(t, []uint{7}, hostIDs)
+		gotTags = tags
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.Equal(t, fleet.ActivityTypeAppliedSpecHostTags, activityType)
+		return nil
+	}
+
+	// the hosts can be tagged with the keys of the spec
+	err := svc.ApplyHostTagsSpec(ctx, &fleet.HostTagsSpec{
+		Keys:  []*fleet.HostTagKeySpec{{Name: "critical", Type: fleet.HostTagTypeBoolean}},
+		Hosts: []*fleet.HostTagsHostSpec{{Host: "host1", Tags: fleet.HostTagValues{"owner": ptr.String("bob"), "critical": ptr.String("1")}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, gotKeys, 1)
+	require.Equal(t, "critical", gotKeys[0].Name)
+	require.Equal(t, fleet.HostTagValues{"owner": ptr.String("bob"), "critical": ptr.String("true")}, gotTags)
+	require.True(t, ds.NewActivityFuncInvoked)
+
+	ds.ApplyHostTagKeysFuncInvoked = false
+	cases := []struct {
+		name    string
+		spec    *fleet.HostTagsSpec
+		wantErr string
+	}{
+		{
+			"type change of a key in use",
+			&fleet.HostTagsSpec{Keys: []*fleet.HostTagKeySpec{{Name: "owner", Type: fleet.HostTagTypeNumber}}},
+			"cannot change the type",
+		},
+		{
+			"duplicate key",
+			&fleet.HostTagsSpec{Keys: []*fleet.HostTagKeySpec{{Name: "env", Type: fleet.HostTagTypeString}, {Name: "env", Type: fleet.HostTagTypeString}}},
+			"duplicate host tag key",
+		},
+		{
+			"invalid key type",
+			&fleet.HostTagsSpec{Keys: []*fleet.HostTagKeySpec{{Name: "env", Type: "date"}}},
+			"invalid type",
+		},
+		{
+			"unknown host",
+			&fleet.HostTagsSpec{
+				Keys:  []*fleet.HostTagKeySpec{{Name: "env", Type: fleet.HostTagTypeString}},
+				Hosts: []*fleet.HostTagsHostSpec{{Host: "unknown", Tags: fleet.HostTagValues{"env": ptr.String("prod")}}},
+			},
+			`host "unknown" not found`,
+		},
+		{
+			"unknown key",
+			&fleet.HostTagsSpec{Hosts: []*fleet.HostTagsHostSpec{{Host: "host1", Tags: fleet.HostTagValues{"env": ptr.String("prod")}}}},
+			`unknown host tag key "env"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := svc.ApplyHostTagsSpec(ctx, c.spec)
+			require.ErrorContains(t, err, c.wantErr)
+			// nothing is applied if the spec is invalid
+			require.False(t, ds.ApplyHostTagKeysFuncInvoked)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List host vitals timeline
+////////////////////////////////////////////////////////////////////////////////
+
+type listHostVitalsTimelineRequest struct {
+	ID          uint              `url:"id"`
+	ListOptions fleet.ListOptions `url:"list_options"`
+	From        string            `query:"from,optional"`
+	To          string            `query:"to,optional"`
+}
+
+type listHostVitalsTimelineResponse struct {
+	Timeline []*fleet.HostVitals `json:"timeline"`
+	Err      error               `json:"error,omitempty"`
+}
+
+func (r listHostVitalsTimelineResponse) error() error { return r.Err }
+
+func listHostVitalsTimelineEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listHostVitalsTimelineRequest)
+
+	opt := fleet.HostVitalsListOptions{ListOptions: req.ListOptions}
+	var err error
+	if opt.From, err = parseTimestampParam("from", req.From); err != nil {
+		return listHostVitalsTimelineResponse{Err: err}, nil
+	}
+	if opt.To, err = parseTimestampParam("to", req.To); err != nil {
+		return listHostVitalsTimelineResponse{Err: err}, nil
+	}
+
+	timeline, err := svc.ListHostVitalsTimeline(ctx, req.ID, opt)
+	if err != nil {
+		return listHostVitalsTimelineResponse{Err: err}, nil
+	}
+	if timeline == nil {
+		timeline = []*fleet.HostVitals{}
+	}
+	return listHostVitalsTimelineResponse{Timeline: timeline}, nil
+}
+
+func (svc *Service) ListHostVitalsTimeline(ctx context.Context, hostID uint, opt fleet.HostVitalsListOptions) ([]*fleet.HostVitals, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return nil, err
+	}
+
+	host, err := svc.ds.HostLite(ctx, hostID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "find host for vitals timeline")
+	}
+	if err := svc.authz.Authorize(ctx, host, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	return svc.ds.ListHostVitals(ctx, hostID, opt)
+}
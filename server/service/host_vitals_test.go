@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/fleetdm/fleet/v4/server/config"
+	hostctx "github.com/fleetdm/fleet/v4/server/contexts/host"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListHostVitalsTimelineAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.HostLiteFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		if id == 1 {
+			return &fleet.Host{ID: id, TeamID: ptr.Uint(1)}, nil
+		}
+		return &fleet.Host{ID: id}, nil
+	}
+	ds.ListHostVitalsFunc = func(ctx context.Context, hostID uint, opt fleet.HostVitalsListOptions) ([]*fleet.HostVitals, error) {
+		return nil, nil
+	}
+
+	testCases := []struct {
+		name             string
+		user             *fleet.User
+		shouldFailTeam   bool
+		shouldFailGlobal bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, false, false},
+		{"team observer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleObserver}}}, false, true},
+		{"team admin, different team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.ListHostVitalsTimeline(ctx, 1, fleet.HostVitalsListOptions{})
+			checkAuthErr(t, tt.shouldFailTeam, err)
+			_, err = svc.ListHostVitalsTimeline(ctx, 2, fleet.HostVitalsListOptions{})
+			checkAuthErr(t, tt.shouldFailGlobal, err)
+		})
+	}
+}
+
+func TestRecordHostVitals(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	cfg := config.TestConfig()
+	cfg.Osquery.HostVitalsHistoryInterval = 6 * time.Hour
+	svc := newTestServiceWithConfig(t, ds, cfg, nil, nil, &TestServerOpts{Clock: mockClock})
+
+	host := &fleet.Host{ID: 1, Platform: "darwin", OSVersion: "macOS 12.5", GigsDiskSpaceAvailable: 300}
+	ctx := hostctx.NewContext(context.Background(), host)
+
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{}, nil
+	}
+	ds.SetOrUpdateHostDisksSpaceFunc = func(ctx context.Context, hostID uint, gigsAvailable, percentAvailable float64) error {
+		return nil
+	}
+	ds.UpdateHostFunc = func(ctx context.Context, host *fleet.Host) error {
+		return nil
+	}
+	var gotVitals *fleet.HostVitals
+	ds.RecordHostVitalsFunc = func(ctx context.Context, vitals *fleet.HostVitals, minInterval time.Duration) error {
+		require.Equal(t, 6*time.Hour, minInterval)
+		gotVitals = vitals
+		return nil
+	}
+
+	var results fleet.OsqueryDistributedQueryResults
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"fleet_detail_query_uptime": [{"total_seconds": "3600"}],
+		"fleet_detail_query_disk_space_unix": [{"percent_disk_space_available": "56", "gigs_disk_space_available": "277.0"}]
+	}`), &results))
+
+	// the sample has the vitals that were just reported
+	require.NoError(t, svc.SubmitDistributedQueryResults(ctx, results, map[string]fleet.OsqueryStatus{}, map[string]string{}))
+	require.NotNil(t, gotVitals)
+	require.Equal(t, uint(1), gotVitals.HostID)
+	require.Equal(t, time.Hour, gotVitals.Uptime)
+	require.Equal(t, 277.0, gotVitals.GigsDiskSpaceAvailable)
+	require.Equal(t, 56.0, gotVitals.PercentDiskSpaceAvailable)
+	require.Equal(t, "macOS 12.5", gotVitals.OSVersion)
+	require.Equal(t, mockClock.Now(), gotVitals.RecordedAt)
+
+	// no sample is recorded if no detail query was ingested
+	ds.RecordHostVitalsFuncInvoked = false
+	require.NoError(t, svc.SubmitDistributedQueryResults(ctx, fleet.OsqueryDistributedQueryResults{}, map[string]fleet.OsqueryStatus{}, map[string]string{}))
+	require.False(t, ds.RecordHostVitalsFuncInvoked)
+
+	// no sample is recorded if the history is disabled
+	svc = newTestServiceWithClock(t, ds, nil, nil, mockClock)
+	require.NoError(t, svc.SubmitDistributedQueryResults(ctx, results, map[string]fleet.OsqueryStatus{}, map[string]string{}))
+	require.False(t, ds.RecordHostVitalsFuncInvoked)
+}
@@ -510,6 +510,12 @@ func (svc *Service) GetHostSummary(ctx context.Context, teamID *uint, platform *
 	}
 	hostSummary.BuiltinLabels = builtinLabels
 
+	severityResults, err := svc.ds.PolicyResultsBySeverity(ctx, filter, platform)
+	if err != nil {
+		return nil, err
+	}
+	hostSummary.ComplianceScore = fleet.ComplianceScore(severityResults)
+
 	return hostSummary, nil
 }
 
@@ -640,7 +646,7 @@ func (svc *Service) AddHostsToTeam(ctx context.Context, teamID *uint, hostIDs []
 		return err
 	}
 
-	return svc.ds.AddHostsToTeam(ctx, teamID, hostIDs)
+	return svc.ds.TransferHostsToTeam(ctx, teamID, hostIDs)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -695,7 +701,7 @@ func (svc *Service) AddHostsToTeamByFilter(ctx context.Context, teamID *uint, op
 	}
 
 	// Apply the team to the selected hosts.
-	return svc.ds.AddHostsToTeam(ctx, teamID, hostIDs)
+	return svc.ds.TransferHostsToTeam(ctx, teamID, hostIDs)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -779,6 +785,7 @@ func (svc *Service) getHostDetails(ctx context.Context, host *fleet.Host, opts f
 	}
 
 	var policies *[]*fleet.HostPolicy
+	var complianceScore *float64
 	if opts.IncludePolicies {
 		hp, err := svc.ds.ListPoliciesForHost(ctx, host)
 		if err != nil {
@@ -788,16 +795,22 @@ func (svc *Service) getHostDetails(ctx context.Context, host *fleet.Host, opts f
 		if hp == nil {
 			hp = []*fleet.HostPolicy{}
 		}
+		redact := svc.policyRemediationRedactor(ctx)
+		for _, p := range hp {
+			redact(&p.PolicyData)
+		}
 
 		policies = &hp
+		complianceScore = fleet.HostComplianceScore(hp)
 	}
 
 	return &fleet.HostDetail{
-		Host:      *host,
-		Labels:    labels,
-		Packs:     packs,
-		Policies:  policies,
-		Batteries: &bats,
+		Host:            *host,
+		Labels:          labels,
+		Packs:           packs,
+		Policies:        policies,
+		Batteries:       &bats,
+		ComplianceScore: complianceScore,
 	}, nil
 }
 
@@ -1062,7 +1075,7 @@ type hostsReportResponse struct {
 func (r hostsReportResponse) error() error { return r.Err }
 
 func (r hostsReportResponse) hijackRender(ctx context.Context, w http.ResponseWriter) {
-	// post-process the Device Mappings for CSV rendering
+	// post-process the Device Mappings and Tags for CSV rendering
 	for _, h := range r.Hosts {
 		if h.DeviceMapping != nil {
 			// return the list of emails, comma-separated, as part of that single CSV field
@@ -1084,6 +1097,16 @@ func (r hostsReportResponse) hijackRender(ctx context.Context, w http.ResponseWr
 			}
 			h.CSVDeviceMapping = sb.String()
 		}
+
+		if h.Tags != nil {
+			tags, err := fleet.FormatHostTags(h.Tags)
+			if err != nil {
+				// log the error but keep going
+				logging.WithErr(ctx, err)
+				continue
+			}
+			h.CSVTags = tags
+		}
 	}
 
 	var buf bytes.Buffer
@@ -1167,6 +1190,7 @@ func hostsReportEndpoint(ctx context.Context, request interface{}, svc fleet.Ser
 	req.Opts.PerPage = 0 // explicitly disable any limit, we want all matching hosts
 	req.Opts.After = ""
 	req.Opts.DeviceMapping = false
+	req.Opts.IncludeTags = false
 
 	rawCols := strings.Split(req.Columns, ",")
 	var cols []string
@@ -1176,11 +1200,15 @@ func hostsReportEndpoint(ctx context.Context, request interface{}, svc fleet.Ser
 			if rawCol == "device_mapping" {
 				req.Opts.DeviceMapping = true
 			}
+			if rawCol == "tags" {
+				req.Opts.IncludeTags = true
+			}
 		}
 	}
 	if len(cols) == 0 {
-		// enable device_mapping retrieval, as no column means all columns
+		// enable device_mapping and tags retrieval, as no column means all columns
 		req.Opts.DeviceMapping = true
+		req.Opts.IncludeTags = true
 	}
 
 	var (
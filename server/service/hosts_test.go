@@ -67,6 +67,24 @@ func TestHostDetails(t *testing.T) {
 	assert.Equal(t, expectedPacks, hostDetail.Packs)
 	require.NotNil(t, hostDetail.Batteries)
 	assert.Equal(t, expectedBats, *hostDetail.Batteries)
+	assert.Nil(t, hostDetail.Policies)
+	assert.Nil(t, hostDetail.ComplianceScore)
+
+	// the compliance score is computed from the policies of the host
+	ds.ListPoliciesForHostFunc = func(ctx context.Context, host *fleet.Host) ([]*fleet.HostPolicy, error) {
+		return []*fleet.HostPolicy{
+			{PolicyData: fleet.PolicyData{ID: 1, Severity: fleet.PolicySeverityHigh}, Response: "pass"},
+			{PolicyData: fleet.PolicyData{ID: 2, Severity: fleet.PolicySeverityLow}, Response: "fail"},
+			{PolicyData: fleet.PolicyData{ID: 3, Severity: fleet.PolicySeverityCritical}, Response: ""},
+		}, nil
+	}
+	opts.IncludePolicies = true
+	hostDetail, err = svc.getHostDetails(test.UserContext(test.UserAdmin), host, opts)
+	require.NoError(t, err)
+	require.NotNil(t, hostDetail.Policies)
+	require.Len(t, *hostDetail.Policies, 3)
+	require.NotNil(t, hostDetail.ComplianceScore)
+	assert.Equal(t, 80.0, *hostDetail.ComplianceScore)
 }
 
 func TestHostAuth(t *testing.T) {
@@ -109,7 +127,7 @@ func TestHostAuth(t *testing.T) {
 	ds.ListPacksForHostFunc = func(ctx context.Context, hid uint) (packs []*fleet.Pack, err error) {
 		return nil, nil
 	}
-	ds.AddHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	ds.TransferHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
 		return nil
 	}
 	ds.ListPoliciesForHostFunc = func(ctx context.Context, host *fleet.Host) ([]*fleet.HostPolicy, error) {
@@ -283,6 +301,12 @@ func TestGetHostSummary(t *testing.T) {
 	ds.LabelsSummaryFunc = func(ctx context.Context) ([]*fleet.LabelSummary, error) {
 		return []*fleet.LabelSummary{{ID: 1, Name: "All hosts", Description: "All hosts enrolled in Fleet", LabelType: fleet.LabelTypeBuiltIn}, {ID: 10, Name: "Other label", Description: "Not a builtin label", LabelType: fleet.LabelTypeRegular}}, nil
 	}
+	ds.PolicyResultsBySeverityFunc = func(ctx context.Context, filter fleet.TeamFilter, platform *string) ([]*fleet.PolicySeverityResults, error) {
+		return []*fleet.PolicySeverityResults{
+			{Severity: fleet.PolicySeverityCritical, PassingCount: 3, FailingCount: 1},
+			{Severity: "", PassingCount: 0, FailingCount: 4},
+		}, nil
+	}
 
 	summary, err := svc.GetHostSummary(test.UserContext(test.UserAdmin), nil, nil, nil)
 	require.NoError(t, err)
@@ -297,6 +321,9 @@ func TestGetHostSummary(t *testing.T) {
 	require.Nil(t, summary.LowDiskSpaceCount)
 	require.Len(t, summary.BuiltinLabels, 1)
 	require.Equal(t, "All hosts", summary.BuiltinLabels[0].Name)
+	// (3*8 + 0*1) / (4*8 + 4*1)
+	require.NotNil(t, summary.ComplianceScore)
+	require.Equal(t, 66.7, *summary.ComplianceScore)
 
 	_, err = svc.GetHostSummary(test.UserContext(test.UserNoRoles), nil, nil, nil)
 	require.NoError(t, err)
@@ -340,7 +367,7 @@ func TestAddHostsToTeamByFilter(t *testing.T) {
 		}
 		return hosts, nil
 	}
-	ds.AddHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	ds.TransferHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
 		assert.Equal(t, expectedTeam, teamID)
 		assert.Equal(t, expectedHostIDs, hostIDs)
 		return nil
@@ -348,7 +375,7 @@ func TestAddHostsToTeamByFilter(t *testing.T) {
 
 	require.NoError(t, svc.AddHostsToTeamByFilter(test.UserContext(test.UserAdmin), expectedTeam, fleet.HostListOptions{}, nil))
 	assert.True(t, ds.ListHostsFuncInvoked)
-	assert.True(t, ds.AddHostsToTeamFuncInvoked)
+	assert.True(t, ds.TransferHostsToTeamFuncInvoked)
 }
 
 func TestAddHostsToTeamByFilterLabel(t *testing.T) {
@@ -367,14 +394,14 @@ func TestAddHostsToTeamByFilterLabel(t *testing.T) {
 		}
 		return hosts, nil
 	}
-	ds.AddHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	ds.TransferHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
 		assert.Equal(t, expectedHostIDs, hostIDs)
 		return nil
 	}
 
 	require.NoError(t, svc.AddHostsToTeamByFilter(test.UserContext(test.UserAdmin), expectedTeam, fleet.HostListOptions{}, expectedLabel))
 	assert.True(t, ds.ListHostsInLabelFuncInvoked)
-	assert.True(t, ds.AddHostsToTeamFuncInvoked)
+	assert.True(t, ds.TransferHostsToTeamFuncInvoked)
 }
 
 func TestAddHostsToTeamByFilterEmptyHosts(t *testing.T) {
@@ -384,13 +411,13 @@ func TestAddHostsToTeamByFilterEmptyHosts(t *testing.T) {
 	ds.ListHostsFunc = func(ctx context.Context, filter fleet.TeamFilter, opt fleet.HostListOptions) ([]*fleet.Host, error) {
 		return []*fleet.Host{}, nil
 	}
-	ds.AddHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
+	ds.TransferHostsToTeamFunc = func(ctx context.Context, teamID *uint, hostIDs []uint) error {
 		return nil
 	}
 
 	require.NoError(t, svc.AddHostsToTeamByFilter(test.UserContext(test.UserAdmin), nil, fleet.HostListOptions{}, nil))
 	assert.True(t, ds.ListHostsFuncInvoked)
-	assert.False(t, ds.AddHostsToTeamFuncInvoked)
+	assert.False(t, ds.TransferHostsToTeamFuncInvoked)
 }
 
 func TestRefetchHost(t *testing.T) {
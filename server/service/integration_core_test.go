@@ -26,7 +26,9 @@ import (
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/fleetdm/fleet/v4/server/ptr"
 	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/fleetdm/fleet/v4/server/worker"
 	"github.com/ghodss/yaml"
+	kitlog "github.com/go-kit/kit/log"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
@@ -5513,14 +5515,14 @@ func (s *integrationTestSuite) TestHostsReportDownload() {
 	res.Body.Close()
 	require.NoError(t, err)
 	require.Len(t, rows, len(hosts)+1) // all hosts + header row
-	require.Len(t, rows[0], 45)        // total number of cols
+	require.Len(t, rows[0], 47)        // total number of cols
 	t.Log(rows[0])
 
 	const (
 		idCol       = 2
-		issuesCol   = 40
-		gigsDiskCol = 38
-		pctDiskCol  = 39
+		issuesCol   = 41
+		gigsDiskCol = 39
+		pctDiskCol  = 40
 	)
 
 	// find the row for hosts[1], it should have issues=1 (1 failing policy) and the expected disk space
@@ -5778,6 +5780,429 @@ func (s *integrationTestSuite) TestAPIVersion_v1_2022_04() {
 	s.DoJSON("DELETE", fmt.Sprintf("/api/v1/fleet/global/schedule/%d", createResp.Scheduled.ID), nil, http.StatusOK, &delResp)
 }
 
+func (s *integrationTestSuite) TestActivityWebhooks() {
+	t := s.T()
+	ctx := context.Background()
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var requests []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, received{header: r.Header, body: body})
+	}))
+	defer srv.Close()
+
+	// invalid webhooks are rejected
+	s.Do("POST", "/api/latest/fleet/activity_webhooks", fleet.ActivityWebhookPayload{
+		Name: ptr.String("siem"), URL: ptr.String("not a url"),
+	}, http.StatusUnprocessableEntity)
+
+	var createResp activityWebhookResponse
+	s.DoJSON("POST", "/api/latest/fleet/activity_webhooks", fleet.ActivityWebhookPayload{
+		Name:          ptr.String("siem"),
+		URL:           ptr.String(srv.URL),
+		ActivityTypes: &[]string{fleet.ActivityTypeCreatedPolicy},
+	}, http.StatusOK, &createResp)
+	require.NotNil(t, createResp.ActivityWebhook)
+	webhook := createResp.ActivityWebhook
+	require.NotEmpty(t, webhook.Secret)
+	require.NotEqual(t, fleet.MaskedPassword, webhook.Secret)
+	defer s.Do("DELETE", fmt.Sprintf("/api/latest/fleet/activity_webhooks/%d", webhook.ID), nil, http.StatusOK)
+
+	s.Do("POST", "/api/latest/fleet/activity_webhooks", fleet.ActivityWebhookPayload{
+		Name: ptr.String("siem"), URL: ptr.String(srv.URL),
+	}, http.StatusConflict)
+
+	var listResp listActivityWebhooksResponse
+	s.DoJSON("GET", "/api/latest/fleet/activity_webhooks", nil, http.StatusOK, &listResp)
+	require.Len(t, listResp.ActivityWebhooks, 1)
+	require.Equal(t, fleet.MaskedPassword, listResp.ActivityWebhooks[0].Secret)
+
+	// create a policy and a query, only the policy is sent to the webhook
+	var gpResp globalPolicyResponse
+	s.DoJSON("POST", "/api/latest/fleet/policies", globalPolicyRequest{
+		Name:  "activity webhook policy",
+		Query: "select 1;",
+	}, http.StatusOK, &gpResp)
+	require.NotNil(t, gpResp.Policy)
+	defer s.Do("POST", "/api/latest/fleet/policies/delete", deleteGlobalPoliciesRequest{IDs: []uint{gpResp.Policy.ID}}, http.StatusOK)
+
+	var queryResp createQueryResponse
+	s.DoJSON("POST", "/api/latest/fleet/queries", fleet.QueryPayload{
+		Name:  ptr.String("activity webhook query"),
+		Query: ptr.String("select 1;"),
+	}, http.StatusOK, &queryResp)
+	defer s.Do("DELETE", fmt.Sprintf("/api/latest/fleet/queries/id/%d", queryResp.Query.ID), nil, http.StatusOK)
+
+	w := worker.NewRegisteredOnlyWorker(s.ds, kitlog.NewNopLogger())
+	w.Register(&worker.ActivityWebhook{Datastore: s.ds, Log: kitlog.NewNopLogger()})
+	require.NoError(t, w.ProcessJobs(ctx))
+
+	require.Len(t, requests, 1)
+	req := requests[0]
+	require.Equal(t, fleet.ActivityTypeCreatedPolicy, req.header.Get(fleet.ActivityWebhookEventHeader))
+	require.Equal(t, (&fleet.ActivityWebhook{Secret: webhook.Secret}).Sign(req.body), req.header.Get(fleet.ActivityWebhookSignatureHeader))
+	var msg fleet.ActivityWebhookMessage
+	require.NoError(t, json.Unmarshal(req.body, &msg))
+	require.Equal(t, fleet.ActivityTypeCreatedPolicy, msg.Activity.Type)
+	var details map[string]interface{}
+	require.NoError(t, json.Unmarshal(*msg.Activity.Details, &details))
+	require.Equal(t, "activity webhook policy", details["policy_name"])
+
+	var deliveriesResp listActivityWebhookDeliveriesResponse
+	s.DoJSON("GET", fmt.Sprintf("/api/latest/fleet/activity_webhooks/%d/deliveries", webhook.ID), nil, http.StatusOK, &deliveriesResp)
+	require.Len(t, deliveriesResp.Deliveries, 1)
+	require.Equal(t, msg.Activity.ID, deliveriesResp.Deliveries[0].ActivityID)
+	require.NotNil(t, deliveriesResp.Deliveries[0].StatusCode)
+	require.Equal(t, http.StatusOK, *deliveriesResp.Deliveries[0].StatusCode)
+
+	// disable the webhook, nothing is queued anymore
+	var modResp activityWebhookResponse
+	s.DoJSON("PATCH", fmt.Sprintf("/api/latest/fleet/activity_webhooks/%d", webhook.ID), fleet.ActivityWebhookPayload{
+		Enabled: ptr.Bool(false),
+	}, http.StatusOK, &modResp)
+	require.False(t, modResp.ActivityWebhook.Enabled)
+	require.Equal(t, fleet.MaskedPassword, modResp.ActivityWebhook.Secret)
+
+	s.DoJSON("POST", "/api/latest/fleet/policies", globalPolicyRequest{
+		Name:  "activity webhook policy 2",
+		Query: "select 2;",
+	}, http.StatusOK, &gpResp)
+	defer s.Do("POST", "/api/latest/fleet/policies/delete", deleteGlobalPoliciesRequest{IDs: []uint{gpResp.Policy.ID}}, http.StatusOK)
+	require.NoError(t, w.ProcessJobs(ctx))
+	require.Len(t, requests, 1)
+
+	s.Do("GET", "/api/latest/fleet/activity_webhooks/999999/deliveries", nil, http.StatusNotFound)
+}
+
+func (s *integrationTestSuite) TestHostDeduplication() {
+	t := s.T()
+	ctx := context.Background()
+
+	res := s.Do("PATCH", "/api/latest/fleet/config", json.RawMessage(`{"host_deduplication_settings": {"strategy": "delete"}}`), http.StatusUnprocessableEntity)
+	require.Contains(t, extractServerErrorText(res.Body), "invalid strategy")
+	defer s.Do("PATCH", "/api/latest/fleet/config", json.RawMessage(`{"host_deduplication_settings": {"strategy": ""}}`), http.StatusOK)
+
+	require.NoError(t, s.ds.ApplyEnrollSecrets(ctx, nil, []*fleet.EnrollSecret{{Secret: t.Name()}}))
+	serial := t.Name() + "serial"
+	enroll := func(identifier string) *fleet.Host {
+		j, err := json.Marshal(&enrollAgentRequest{
+			EnrollSecret:   t.Name(),
+			HostIdentifier: identifier,
+			HostDetails: map[string](map[string]string){
+				"os_version":  {"platform": "darwin"},
+				"system_info": {"hostname": identifier, "uuid": identifier, "hardware_serial": serial},
+			},
+		})
+		require.NoError(t, err)
+		var enrollResp enrollAgentResponse
+		hres := s.DoRawNoAuth("POST", "/api/osquery/enroll", j, http.StatusOK)
+		defer hres.Body.Close()
+		require.NoError(t, json.NewDecoder(hres.Body).Decode(&enrollResp))
+		host, err := s.ds.LoadHostByNodeKey(ctx, enrollResp.NodeKey)
+		require.NoError(t, err)
+		return host
+	}
+	lastActivity := func() *fleet.Activity {
+		var listActivities listActivitiesResponse
+		s.DoJSON("GET", "/api/latest/fleet/activities", nil, http.StatusOK, &listActivities, "order_key", "id", "order_direction", "desc")
+		require.NotEmpty(t, listActivities.Activities)
+		return listActivities.Activities[0]
+	}
+
+	// the duplicates are ignored by default
+	old := enroll(t.Name() + "old")
+
+	s.Do("PATCH", "/api/latest/fleet/config", json.RawMessage(`{"host_deduplication_settings": {"strategy": "flag"}}`), http.StatusOK)
+	host := enroll(t.Name() + "new")
+
+	var listResp listHostDuplicatesResponse
+	s.DoJSON("GET", "/api/latest/fleet/hosts/duplicates", nil, http.StatusOK, &listResp)
+	require.Len(t, listResp.Duplicates, 1)
+	dup := listResp.Duplicates[0]
+	require.Equal(t, host.ID, dup.HostID)
+	require.Equal(t, old.ID, dup.DuplicateHostID)
+	require.Equal(t, fleet.HostDuplicateMatchHardwareSerial, dup.MatchedBy)
+
+	// the flag can be dismissed
+	s.Do("DELETE", fmt.Sprintf("/api/latest/fleet/hosts/duplicates/%d", dup.ID), nil, http.StatusOK)
+	s.Do("DELETE", fmt.Sprintf("/api/latest/fleet/hosts/duplicates/%d", dup.ID), nil, http.StatusNotFound)
+	listResp = listHostDuplicatesResponse{}
+	s.DoJSON("GET", "/api/latest/fleet/hosts/duplicates", nil, http.StatusOK, &listResp)
+	require.Empty(t, listResp.Duplicates)
+
+	// the hosts are merged by an admin
+	mergeURL := fmt.Sprintf("/api/latest/fleet/hosts/%d/merge", host.ID)
+	res = s.Do("POST", mergeURL, json.RawMessage(fmt.Sprintf(`{"duplicate_host_id": %d}`, host.ID)), http.StatusUnprocessableEntity)
+	require.Contains(t, extractServerErrorText(res.Body), "cannot merge a host into itself")
+	s.Do("POST", mergeURL, json.RawMessage(fmt.Sprintf(`{"duplicate_host_id": %d}`, old.ID)), http.StatusOK)
+	s.Do("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d", old.ID), nil, http.StatusNotFound)
+	s.Do("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d", host.ID), nil, http.StatusOK)
+	activity := lastActivity()
+	assert.Equal(t, fleet.ActivityTypeMergedHosts, activity.Type)
+	assert.NotNil(t, activity.ActorID)
+
+	// the hosts are merged automatically at enrollment if they were not seen
+	// since then
+	s.Do("PATCH", "/api/latest/fleet/config", json.RawMessage(`{"host_deduplication_settings": {"strategy": "merge"}}`), http.StatusOK)
+	require.NoError(t, s.ds.MarkHostsSeen(ctx, []uint{host.ID}, time.Now().Add(-time.Hour)))
+	newest := enroll(t.Name() + "newest")
+	s.Do("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d", host.ID), nil, http.StatusNotFound)
+	s.Do("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d", newest.ID), nil, http.StatusOK)
+	activity = lastActivity()
+	assert.Equal(t, fleet.ActivityTypeMergedHosts, activity.Type)
+	assert.Nil(t, activity.ActorID)
+	require.NotNil(t, activity.Details)
+	var details map[string]interface{}
+	require.NoError(t, json.Unmarshal(*activity.Details, &details))
+	assert.EqualValues(t, newest.ID, details["host_id"])
+	assert.EqualValues(t, host.ID, details["merged_host_id"])
+	assert.Equal(t, fleet.HostDuplicateMatchHardwareSerial, details["matched_by"])
+
+	// a duplicate that is still running is flagged instead
+	require.NoError(t, s.ds.MarkHostsSeen(ctx, []uint{newest.ID}, time.Now().Add(time.Minute)))
+	clone := enroll(t.Name() + "clone")
+	s.Do("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d", newest.ID), nil, http.StatusOK)
+	listResp = listHostDuplicatesResponse{}
+	s.DoJSON("GET", "/api/latest/fleet/hosts/duplicates", nil, http.StatusOK, &listResp)
+	require.Len(t, listResp.Duplicates, 1)
+	require.Equal(t, clone.ID, listResp.Duplicates[0].HostID)
+	require.Equal(t, newest.ID, listResp.Duplicates[0].DuplicateHostID)
+}
+
+func (s *integrationTestSuite) TestHostLifecycleStates() {
+	t := s.T()
+
+	hosts := s.createHosts(t)
+
+	res := s.Do("POST", "/api/latest/fleet/hosts/lifecycle_state", json.RawMessage(fmt.Sprintf(`{"hosts": [%d], "lifecycle_state": "decommissioned"}`, hosts[0].ID)), http.StatusUnprocessableEntity)
+	require.Contains(t, extractServerErrorText(res.Body), "invalid lifecycle state")
+	res = s.Do("POST", "/api/latest/fleet/hosts/lifecycle_state", json.RawMessage(`{"hosts": [], "lifecycle_state": "retired"}`), http.StatusUnprocessableEntity)
+	require.Contains(t, extractServerErrorText(res.Body), "at least one host is required")
+
+	s.Do("POST", "/api/latest/fleet/hosts/lifecycle_state", json.RawMessage(fmt.Sprintf(`{"hosts": [%d], "lifecycle_state": "retired"}`, hosts[0].ID)), http.StatusOK)
+	s.Do("POST", "/api/latest/fleet/hosts/lifecycle_state", json.RawMessage(fmt.Sprintf(`{"hosts": [%d], "lifecycle_state": "in_repair"}`, hosts[1].ID)), http.StatusOK)
+
+	var listActivities listActivitiesResponse
+	s.DoJSON("GET", "/api/latest/fleet/activities", nil, http.StatusOK, &listActivities, "order_key", "id", "order_direction", "desc")
+	require.NotEmpty(t, listActivities.Activities)
+	activity := listActivities.Activities[0]
+	assert.Equal(t, fleet.ActivityTypeChangedHostLifecycleState, activity.Type)
+	require.NotNil(t, activity.Details)
+	var details map[string]interface{}
+	require.NoError(t, json.Unmarshal(*activity.Details, &details))
+	assert.EqualValues(t, hosts[1].ID, details["host_id"])
+	assert.Equal(t, "in_repair", details["lifecycle_state"])
+	assert.Equal(t, "active", details["previous_lifecycle_state"])
+
+	// the hosts can be filtered by lifecycle state
+	var listResp listHostsResponse
+	s.DoJSON("GET", "/api/latest/fleet/hosts", nil, http.StatusOK, &listResp, "lifecycle_state", "retired")
+	require.Len(t, listResp.Hosts, 1)
+	assert.Equal(t, hosts[0].ID, listResp.Hosts[0].ID)
+	assert.Equal(t, fleet.HostLifecycleStateRetired, listResp.Hosts[0].LifecycleState)
+
+	// the retired hosts are only counted by lifecycle state in the summary
+	var summaryResp getHostSummaryResponse
+	s.DoJSON("GET", "/api/latest/fleet/host_summary", nil, http.StatusOK, &summaryResp)
+	assert.Equal(t, uint(len(hosts)-1), summaryResp.TotalsHostsCount)
+	assert.Equal(t, uint(1), summaryResp.RetiredCount)
+	assert.Equal(t, uint(1), summaryResp.InRepairCount)
+	assert.Zero(t, summaryResp.QuarantinedCount)
+
+	res = s.Do("PATCH", "/api/latest/fleet/config", json.RawMessage(`{
+		"webhook_settings": {"host_quarantine_webhook": {"enable_host_quarantine_webhook": true}}
+	}`), http.StatusUnprocessableEntity)
+	require.Contains(t, extractServerErrorText(res.Body), "destination_url is required")
+	res = s.Do("PATCH", "/api/latest/fleet/config", json.RawMessage(`{
+		"host_lifecycle_settings": {"quarantine_team_id": 1}
+	}`), http.StatusUnprocessableEntity)
+	require.Contains(t, extractServerErrorText(res.Body), fleet.ErrMissingLicense.Error())
+}
+
+func (s *integrationTestSuite) TestHostVitalsTimeline() {
+	t := s.T()
+	ctx := context.Background()
+
+	hosts := s.createHosts(t)
+	host := hosts[0]
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, osVersion := range []string{"Ubuntu 20.04", "Ubuntu 22.04", "Ubuntu 22.04"} {
+		require.NoError(t, s.ds.RecordHostVitals(ctx, &fleet.HostVitals{
+			HostID:                 host.ID,
+			GigsDiskSpaceAvailable: float64(100 - i),
+			Uptime:                 time.Duration(i) * time.Hour,
+			OSVersion:              osVersion,
+			RecordedAt:             now.Add(time.Duration(i-3) * 24 * time.Hour),
+		}, time.Hour))
+	}
+
+	var listResp listHostVitalsTimelineResponse
+	s.DoJSON("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d/vitals_timeline", host.ID), nil, http.StatusOK, &listResp)
+	require.Len(t, listResp.Timeline, 3)
+	assert.Equal(t, now.Add(-24*time.Hour), listResp.Timeline[0].RecordedAt.UTC())
+	assert.Equal(t, 98.0, listResp.Timeline[0].GigsDiskSpaceAvailable)
+	assert.Equal(t, 2*time.Hour, listResp.Timeline[0].Uptime)
+	assert.Equal(t, "Ubuntu 20.04", listResp.Timeline[2].OSVersion)
+
+	listResp = listHostVitalsTimelineResponse{}
+	s.DoJSON("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d/vitals_timeline", host.ID), nil, http.StatusOK, &listResp,
+		"from", now.Add(-60*time.Hour).Format(time.RFC3339), "order_key", "recorded_at", "order_direction", "asc")
+	require.Len(t, listResp.Timeline, 2)
+	assert.Equal(t, "Ubuntu 22.04", listResp.Timeline[0].OSVersion)
+	assert.Equal(t, 99.0, listResp.Timeline[0].GigsDiskSpaceAvailable)
+
+	// the timeline of a host without samples is empty
+	listResp = listHostVitalsTimelineResponse{}
+	s.DoJSON("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d/vitals_timeline", hosts[1].ID), nil, http.StatusOK, &listResp)
+	require.NotNil(t, listResp.Timeline)
+	require.Empty(t, listResp.Timeline)
+
+	res := s.Do("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d/vitals_timeline", host.ID), nil, http.StatusUnprocessableEntity, "from", "yesterday")
+	require.Contains(t, extractServerErrorText(res.Body), "must be a RFC3339 timestamp")
+	s.Do("GET", fmt.Sprintf("/api/latest/fleet/hosts/%d/vitals_timeline", hosts[2].ID+1000), nil, http.StatusNotFound)
+}
+
+func (s *integrationTestSuite) TestSCIMProvisioning() {
+	t := s.T()
+	ctx := context.Background()
+
+	s.DoRaw("PATCH", "/api/latest/fleet/config", []byte(`{
+		"sso_settings": {
+			"scim_group_mappings": [{"group": "Fleet Admins", "role": "admin"}]
+		}
+	}`), http.StatusOK)
+	defer s.DoRaw("PATCH", "/api/latest/fleet/config", []byte(`{
+		"sso_settings": {"scim_group_mappings": []}
+	}`), http.StatusOK)
+
+	// create a user
+	var user fleet.SCIMUserResource
+	s.DoJSON("POST", "/api/latest/fleet/scim/v2/Users", fleet.SCIMUserResource{
+		Schemas:  []string{fleet.SCIMSchemaUser},
+		UserName: "scim_user@example.com",
+		Name:     &fleet.SCIMName{GivenName: "Scim", FamilyName: "User"},
+		Active:   ptr.Bool(true),
+	}, http.StatusCreated, &user)
+	require.NotEmpty(t, user.ID)
+	require.Equal(t, "scim_user@example.com", user.UserName)
+	require.Equal(t, "Scim User", user.DisplayName)
+	require.True(t, *user.Active)
+	userID, err := strconv.ParseUint(user.ID, 10, 64)
+	require.NoError(t, err)
+	defer func() { _ = s.ds.DeleteUser(ctx, uint(userID)) }()
+
+	dbUser, err := s.ds.UserByID(ctx, uint(userID))
+	require.NoError(t, err)
+	require.True(t, dbUser.SSOEnabled)
+	require.Equal(t, fleet.RoleObserver, *dbUser.GlobalRole)
+
+	// creating it again fails
+	s.Do("POST", "/api/latest/fleet/scim/v2/Users", fleet.SCIMUserResource{UserName: "scim_user@example.com"}, http.StatusConflict)
+
+	// find it by userName
+	var listUsers struct {
+		TotalResults int                      `json:"totalResults"`
+		Resources    []fleet.SCIMUserResource `json:"Resources"`
+	}
+	s.DoJSON("GET", "/api/latest/fleet/scim/v2/Users", nil, http.StatusOK, &listUsers, "filter", `userName eq "scim_user@example.com"`)
+	require.Equal(t, 1, listUsers.TotalResults)
+	require.Equal(t, user.ID, listUsers.Resources[0].ID)
+	s.DoJSON("GET", "/api/latest/fleet/scim/v2/Users", nil, http.StatusOK, &listUsers, "filter", `userName eq "nobody@example.com"`)
+	require.Zero(t, listUsers.TotalResults)
+	s.Do("GET", "/api/latest/fleet/scim/v2/Users", nil, http.StatusBadRequest, "filter", `title eq "x"`)
+
+	// add it to a mapped group, it becomes an admin
+	var group fleet.SCIMGroupResource
+	s.DoJSON("POST", "/api/latest/fleet/scim/v2/Groups", fleet.SCIMGroupResource{
+		Schemas:     []string{fleet.SCIMSchemaGroup},
+		DisplayName: "Fleet Admins",
+		Members:     []fleet.SCIMMember{{Value: user.ID}},
+	}, http.StatusCreated, &group)
+	require.Len(t, group.Members, 1)
+
+	dbUser, err = s.ds.UserByID(ctx, uint(userID))
+	require.NoError(t, err)
+	require.Equal(t, fleet.RoleAdmin, *dbUser.GlobalRole)
+
+	s.DoJSON("GET", "/api/latest/fleet/scim/v2/Users/"+user.ID, nil, http.StatusOK, &user)
+	require.Len(t, user.Groups, 1)
+	require.Equal(t, "Fleet Admins", user.Groups[0].Display)
+
+	// remove it from the group, it becomes an observer again
+	s.DoJSON("PATCH", "/api/latest/fleet/scim/v2/Groups/"+group.ID, map[string]interface{}{
+		"schemas":    []string{fleet.SCIMSchemaPatchOp},
+		"Operations": []map[string]interface{}{{"op": "remove", "path": fmt.Sprintf(`members[value eq "%s"]`, user.ID)}},
+	}, http.StatusOK, &group)
+	require.Empty(t, group.Members)
+
+	dbUser, err = s.ds.UserByID(ctx, uint(userID))
+	require.NoError(t, err)
+	require.Equal(t, fleet.RoleObserver, *dbUser.GlobalRole)
+
+	// update the user
+	user.UserName = "scim_user2@example.com"
+	s.DoJSON("PUT", "/api/latest/fleet/scim/v2/Users/"+user.ID, user, http.StatusOK, &user)
+	require.Equal(t, "scim_user2@example.com", user.UserName)
+
+	// deactivate the user, it is disabled and its sessions are destroyed
+	ssn := createSession(t, uint(userID), s.ds)
+	s.DoJSON("PATCH", "/api/latest/fleet/scim/v2/Users/"+user.ID, map[string]interface{}{
+		"schemas":    []string{fleet.SCIMSchemaPatchOp},
+		"Operations": []map[string]interface{}{{"op": "replace", "value": map[string]interface{}{"active": false}}},
+	}, http.StatusOK, &user)
+	require.False(t, *user.Active)
+	dbUser, err = s.ds.UserByID(ctx, uint(userID))
+	require.NoError(t, err)
+	require.True(t, dbUser.Disabled)
+	_, err = s.ds.SessionByKey(ctx, ssn.Key)
+	require.True(t, fleet.IsNotFound(err))
+	s.DoJSON("GET", "/api/latest/fleet/scim/v2/Users/"+user.ID, nil, http.StatusOK, &user)
+	require.False(t, *user.Active)
+
+	// a disabled user cannot use a session
+	ssn = createSession(t, uint(userID), s.ds)
+	s.token = ssn.Key
+	s.Do("GET", "/api/latest/fleet/me", nil, http.StatusUnauthorized)
+	s.token = s.getTestAdminToken()
+
+	// reactivate the user
+	s.DoJSON("PATCH", "/api/latest/fleet/scim/v2/Users/"+user.ID, map[string]interface{}{
+		"schemas":    []string{fleet.SCIMSchemaPatchOp},
+		"Operations": []map[string]interface{}{{"op": "replace", "path": "active", "value": true}},
+	}, http.StatusOK, &user)
+	require.True(t, *user.Active)
+
+	// delete the user, it is disabled and not returned by the SCIM API anymore
+	// but it is kept in Fleet
+	s.Do("DELETE", "/api/latest/fleet/scim/v2/Users/"+user.ID, nil, http.StatusNoContent)
+	res := s.Do("GET", "/api/latest/fleet/scim/v2/Users/"+user.ID, nil, http.StatusNotFound)
+	var scimErr scimErrorResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&scimErr))
+	require.Equal(t, []string{fleet.SCIMSchemaError}, scimErr.Schemas)
+	require.Equal(t, "404", scimErr.Status)
+	s.DoJSON("GET", "/api/latest/fleet/scim/v2/Users", nil, http.StatusOK, &listUsers, "filter", `userName eq "scim_user2@example.com"`)
+	require.Zero(t, listUsers.TotalResults)
+	dbUser, err = s.ds.UserByID(ctx, uint(userID))
+	require.NoError(t, err)
+	require.True(t, dbUser.Disabled)
+
+	// creating it again restores it
+	s.DoJSON("POST", "/api/latest/fleet/scim/v2/Users", fleet.SCIMUserResource{
+		Schemas:  []string{fleet.SCIMSchemaUser},
+		UserName: "scim_user2@example.com",
+	}, http.StatusCreated, &user)
+	require.Equal(t, fmt.Sprint(userID), user.ID)
+	require.True(t, *user.Active)
+
+	s.Do("DELETE", "/api/latest/fleet/scim/v2/Groups/"+group.ID, nil, http.StatusNoContent)
+	s.Do("GET", "/api/latest/fleet/scim/v2/Groups/"+group.ID, nil, http.StatusNotFound)
+}
+
 // creates a session and returns it, its key is to be passed as authorization header.
 func createSession(t *testing.T, uid uint, ds fleet.Datastore) *fleet.Session {
 	key := make([]byte, 64)
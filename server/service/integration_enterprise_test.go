@@ -16,6 +16,7 @@ import (
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/fleetdm/fleet/v4/server/live_query/live_query_mock"
 	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/sso/oidctest"
 	"github.com/fleetdm/fleet/v4/server/test"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -1441,6 +1442,54 @@ func (s *integrationEnterpriseTestSuite) TestSSOJITProvisioning() {
 	})
 }
 
+func (s *integrationEnterpriseTestSuite) TestOIDCJITProvisioning() {
+	t := s.T()
+
+	idp := oidctest.NewProvider(t)
+	idp.SetClaims(oidctest.Claims{Email: "oidc_jit_user@example.com", Name: "OIDC JIT User"})
+
+	acResp := appConfigResponse{}
+	s.DoJSON("PATCH", "/api/latest/fleet/config", json.RawMessage(fmt.Sprintf(`{
+		"sso_settings": {
+			"protocol": "oidc",
+			"enable_sso": true,
+			"idp_name": "Mock OIDC",
+			"oidc_issuer_url": %q,
+			"oidc_client_id": %q,
+			"oidc_client_secret": %q,
+			"enable_jit_provisioning": true
+		}
+	}`, idp.URL(), oidctest.ClientID, oidctest.ClientSecret)), http.StatusOK, &acResp)
+	require.True(t, acResp.SSOSettings.EnableJITProvisioning)
+	t.Cleanup(func() {
+		ac, err := s.ds.AppConfig(context.Background())
+		require.NoError(t, err)
+		ac.SSOSettings = fleet.SSOSettings{}
+		require.NoError(t, s.ds.SaveAppConfig(context.Background(), ac))
+	})
+
+	// a new user is created and redirected accordingly
+	body := s.LoginOIDCUser(idp)
+	require.Contains(t, body, "Redirecting to Fleet at  ...")
+	user, err := s.ds.UserByEmail(context.Background(), "oidc_jit_user@example.com")
+	require.NoError(t, err)
+	require.Equal(t, "OIDC JIT User", user.Name)
+	require.True(t, user.SSOEnabled)
+
+	// a new activity item is created
+	activitiesResp := listActivitiesResponse{}
+	s.DoJSON("GET", "/api/latest/fleet/activities", nil, http.StatusOK, &activitiesResp)
+	require.NoError(t, activitiesResp.Err)
+	require.Condition(t, func() bool {
+		for _, a := range activitiesResp.Activities {
+			if a.Type == fleet.ActivityTypeUserAddedBySSO && *a.ActorEmail == user.Email {
+				return true
+			}
+		}
+		return false
+	})
+}
+
 func (s *integrationEnterpriseTestSuite) TestDistributedReadWithFeatures() {
 	t := s.T()
 
@@ -1610,3 +1659,125 @@ func (s *integrationEnterpriseTestSuite) TestListHosts() {
 	require.Equal(t, uint(0), summaryResp.TotalsHostsCount)
 	require.Nil(t, summaryResp.LowDiskSpaceCount)
 }
+
+func (s *integrationEnterpriseTestSuite) TestHostAssignmentRules() {
+	t := s.T()
+	ctx := context.Background()
+
+	// invalid rules are rejected
+	badRules := []fleet.HostAssignmentRule{{Priority: 1}}
+	teamSpecs := applyTeamSpecsRequest{Specs: []*fleet.TeamSpec{{Name: t.Name() + "team1", HostAssignmentRules: &badRules}}}
+	res := s.Do("POST", "/api/latest/fleet/spec/teams", teamSpecs, http.StatusUnprocessableEntity)
+	require.Contains(t, extractServerErrorText(res.Body), "at least one of platform")
+	badRules = []fleet.HostAssignmentRule{{PublicIPCIDR: "10.0.0.0"}}
+	res = s.Do("POST", "/api/latest/fleet/spec/teams", teamSpecs, http.StatusUnprocessableEntity)
+	require.Contains(t, extractServerErrorText(res.Body), "invalid public_ip_cidr")
+
+	// all macOS hosts go to team1, except the web servers that go to team2
+	// as its rule has a lower priority.
+	rules1 := []fleet.HostAssignmentRule{{Priority: 10, Platform: "darwin"}}
+	rules2 := []fleet.HostAssignmentRule{{Priority: 0, Platform: "darwin", HostnameRegex: `^web-\d+$`}}
+	teamSpecs = applyTeamSpecsRequest{Specs: []*fleet.TeamSpec{
+		{Name: t.Name() + "team1", HostAssignmentRules: &rules1},
+		{Name: t.Name() + "team2", HostAssignmentRules: &rules2},
+	}}
+	s.Do("POST", "/api/latest/fleet/spec/teams", teamSpecs, http.StatusOK)
+	team1, err := s.ds.TeamByName(ctx, t.Name()+"team1")
+	require.NoError(t, err)
+	require.Equal(t, rules1, team1.Config.HostAssignmentRules)
+	team2, err := s.ds.TeamByName(ctx, t.Name()+"team2")
+	require.NoError(t, err)
+
+	// the rules are left unchanged if not provided in the spec
+	teamSpecs = applyTeamSpecsRequest{Specs: []*fleet.TeamSpec{{Name: t.Name() + "team1"}}}
+	s.Do("POST", "/api/latest/fleet/spec/teams", teamSpecs, http.StatusOK)
+	team1, err = s.ds.TeamByName(ctx, t.Name()+"team1")
+	require.NoError(t, err)
+	require.Equal(t, rules1, team1.Config.HostAssignmentRules)
+
+	// enroll a web server with the global enroll secret
+	require.NoError(t, s.ds.ApplyEnrollSecrets(ctx, nil, []*fleet.EnrollSecret{{Secret: t.Name()}}))
+	j, err := json.Marshal(&enrollAgentRequest{
+		EnrollSecret:   t.Name(),
+		HostIdentifier: t.Name(),
+		HostDetails: map[string](map[string]string){
+			"os_version":  {"platform": "darwin"},
+			"system_info": {"hostname": "web-1", "uuid": t.Name()},
+		},
+	})
+	require.NoError(t, err)
+	var enrollResp enrollAgentResponse
+	hres := s.DoRawNoAuth("POST", "/api/osquery/enroll", j, http.StatusOK)
+	defer hres.Body.Close()
+	require.NoError(t, json.NewDecoder(hres.Body).Decode(&enrollResp))
+	require.NotEmpty(t, enrollResp.NodeKey)
+
+	host, err := s.ds.LoadHostByNodeKey(ctx, enrollResp.NodeKey)
+	require.NoError(t, err)
+	require.NotNil(t, host.TeamID)
+	require.Equal(t, team2.ID, *host.TeamID)
+
+	var listActivities listActivitiesResponse
+	s.DoJSON("GET", "/api/latest/fleet/activities", nil, http.StatusOK, &listActivities, "order_key", "id", "order_direction", "desc")
+	require.NotEmpty(t, listActivities.Activities)
+	assert.Equal(t, fleet.ActivityTypeTransferredHostByRule, listActivities.Activities[0].Type)
+	assert.Nil(t, listActivities.Activities[0].ActorID)
+	assert.JSONEq(t, fmt.Sprintf(
+		`{"host_id": %d, "host_display_name": "web-1", "from_team_id": null, "team_id": %d, "team_name": %q, "rule_index": 0}`,
+		host.ID, team2.ID, team2.Name,
+	), string(*listActivities.Activities[0].Details))
+
+	// the host is renamed, it moves to team1 when its details are refreshed
+	distributedReq := submitDistributedQueryResultsRequestShim{
+		NodeKey: enrollResp.NodeKey,
+		Results: map[string]json.RawMessage{
+			hostDetailQueryPrefix + "system_info": json.RawMessage(fmt.Sprintf(`[{"hostname": "laptop-1", "uuid": %q}]`, t.Name())),
+		},
+		Statuses: map[string]interface{}{
+			hostDetailQueryPrefix + "system_info": 0,
+		},
+	}
+	s.DoJSON("POST", "/api/osquery/distributed/write", distributedReq, http.StatusOK, &submitDistributedQueryResultsResponse{})
+
+	host, err = s.ds.Host(ctx, host.ID)
+	require.NoError(t, err)
+	require.NotNil(t, host.TeamID)
+	require.Equal(t, team1.ID, *host.TeamID)
+
+	listActivities = listActivitiesResponse{}
+	s.DoJSON("GET", "/api/latest/fleet/activities", nil, http.StatusOK, &listActivities, "order_key", "id", "order_direction", "desc")
+	require.NotEmpty(t, listActivities.Activities)
+	assert.Equal(t, fleet.ActivityTypeTransferredHostByRule, listActivities.Activities[0].Type)
+	assert.JSONEq(t, fmt.Sprintf(
+		`{"host_id": %d, "host_display_name": "laptop-1", "from_team_id": %d, "team_id": %d, "team_name": %q, "rule_index": 0}`,
+		host.ID, team2.ID, team1.ID, team1.Name,
+	), string(*listActivities.Activities[0].Details))
+
+	// the host stays on its team if it is refreshed again
+	lastActivityID := listActivities.Activities[0].ID
+	s.DoJSON("POST", "/api/osquery/distributed/write", distributedReq, http.StatusOK, &submitDistributedQueryResultsResponse{})
+	listActivities = listActivitiesResponse{}
+	s.DoJSON("GET", "/api/latest/fleet/activities", nil, http.StatusOK, &listActivities, "order_key", "id", "order_direction", "desc")
+	assert.Equal(t, lastActivityID, listActivities.Activities[0].ID)
+
+	// the host is transferred manually, the rules do not move it back
+	s.DoJSON("POST", "/api/latest/fleet/hosts/transfer", addHostsToTeamRequest{
+		TeamID: &team2.ID, HostIDs: []uint{host.ID},
+	}, http.StatusOK, &addHostsToTeamResponse{})
+	s.DoJSON("POST", "/api/osquery/distributed/write", distributedReq, http.StatusOK, &submitDistributedQueryResultsResponse{})
+	host, err = s.ds.Host(ctx, host.ID)
+	require.NoError(t, err)
+	require.NotNil(t, host.TeamID)
+	require.Equal(t, team2.ID, *host.TeamID)
+	listActivities = listActivitiesResponse{}
+	s.DoJSON("GET", "/api/latest/fleet/activities", nil, http.StatusOK, &listActivities, "order_key", "id", "order_direction", "desc")
+	assert.Equal(t, lastActivityID, listActivities.Activities[0].ID)
+
+	// the rules apply again once the team it was transferred to is deleted
+	s.Do("DELETE", fmt.Sprintf("/api/latest/fleet/teams/%d", team2.ID), nil, http.StatusOK)
+	s.DoJSON("POST", "/api/osquery/distributed/write", distributedReq, http.StatusOK, &submitDistributedQueryResultsResponse{})
+	host, err = s.ds.Host(ctx, host.ID)
+	require.NoError(t, err)
+	require.NotNil(t, host.TeamID)
+	require.Equal(t, team1.ID, *host.TeamID)
+}
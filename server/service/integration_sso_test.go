@@ -7,6 +7,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -16,6 +18,7 @@ import (
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/fleetdm/fleet/v4/server/ptr"
 	"github.com/fleetdm/fleet/v4/server/sso"
+	"github.com/fleetdm/fleet/v4/server/sso/oidctest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -133,6 +136,91 @@ func (s *integrationSSOTestSuite) TestSSOLogin() {
 	require.Contains(t, body, "Redirecting to Fleet at  ...")
 }
 
+func (s *integrationSSOTestSuite) TestOIDCLogin() {
+	t := s.T()
+
+	idp := oidctest.NewProvider(t)
+	idp.SetClaims(oidctest.Claims{Email: "oidc_user@example.com", Name: "OIDC User"})
+
+	// the oidc client secret is obfuscated once saved
+	acResp := appConfigResponse{}
+	s.DoJSON("PATCH", "/api/latest/fleet/config", json.RawMessage(fmt.Sprintf(`{
+		"sso_settings": {
+			"protocol": "oidc",
+			"enable_sso": true,
+			"idp_name": "Mock OIDC",
+			"oidc_issuer_url": %q,
+			"oidc_client_id": %q,
+			"oidc_client_secret": %q
+		}
+	}`, idp.URL(), oidctest.ClientID, oidctest.ClientSecret)), http.StatusOK, &acResp)
+	require.Equal(t, fleet.MaskedPassword, acResp.SSOSettings.OIDCClientSecret)
+	t.Cleanup(func() {
+		ac, err := s.ds.AppConfig(context.Background())
+		require.NoError(t, err)
+		ac.SSOSettings = fleet.SSOSettings{}
+		require.NoError(t, s.ds.SaveAppConfig(context.Background(), ac))
+	})
+
+	// sending back the obfuscated secret does not overwrite it
+	s.DoJSON("PATCH", "/api/latest/fleet/config", json.RawMessage(`{
+		"sso_settings": {"oidc_client_secret": "********"}
+	}`), http.StatusOK, &acResp)
+	ac, err := s.ds.AppConfig(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, oidctest.ClientSecret, ac.SSOSettings.OIDCClientSecret)
+
+	// the authorization request uses PKCE
+	var resIni initiateSSOResponse
+	s.DoJSON("POST", "/api/v1/fleet/sso", map[string]string{}, http.StatusOK, &resIni)
+	parsed, err := url.Parse(resIni.URL)
+	require.NoError(t, err)
+	require.Equal(t, idp.URL()+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	require.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	require.NotEmpty(t, parsed.Query().Get("code_challenge"))
+
+	// users can't login if they don't have an account on free plans
+	body := s.LoginOIDCUser(idp)
+	require.Contains(t, body, "/login?status=account_invalid")
+
+	// an user created by an admin with SSOEnabled is able to log-in
+	params := fleet.UserPayload{
+		Name:       ptr.String("OIDC User"),
+		Email:      ptr.String("oidc_user@example.com"),
+		GlobalRole: ptr.String(fleet.RoleObserver),
+		SSOEnabled: ptr.Bool(true),
+	}
+	var createResp createUserResponse
+	s.DoJSON("POST", "/api/latest/fleet/users/admin", &params, http.StatusOK, &createResp)
+	t.Cleanup(func() {
+		require.NoError(t, s.ds.DeleteUser(context.Background(), createResp.User.ID))
+	})
+	body = s.LoginOIDCUser(idp)
+	require.Contains(t, body, "Redirecting to Fleet at  ...")
+	require.NotContains(t, body, "/login?status=")
+
+	// the state can only be used once
+	s.DoJSON("POST", "/api/v1/fleet/sso", map[string]string{}, http.StatusOK, &resIni)
+	callback := idp.Authorize(t, resIni.URL)
+	res := s.DoRawNoAuth("GET", "/api/v1/fleet/sso/callback?"+callback.Encode(), nil, http.StatusOK)
+	res.Body.Close()
+	res = s.DoRawNoAuth("GET", "/api/v1/fleet/sso/callback?"+callback.Encode(), nil, http.StatusOK)
+	b, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	require.Contains(t, string(b), "/login?status=error")
+
+	// a SAML response is rejected when oidc is configured
+	res = s.DoRawNoAuth("POST", "/api/v1/fleet/sso/callback?SAMLResponse="+url.QueryEscape(base64.StdEncoding.EncodeToString([]byte("<Response></Response>"))), nil, http.StatusOK)
+	b, err = io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	require.Contains(t, string(b), "/login?status=org_disabled")
+
+	// errors returned by the identity provider are rejected
+	s.DoRawNoAuth("GET", "/api/v1/fleet/sso/callback?error=access_denied", nil, http.StatusBadRequest)
+}
+
 func inflate(t *testing.T, s string) *sso.AuthnRequest {
 	t.Helper()
 
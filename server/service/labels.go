@@ -51,10 +51,27 @@ func (svc *Service) NewLabel(ctx context.Context, p fleet.LabelPayload) (*fleet.
 	}
 	label.Name = *p.Name
 
-	if p.Query == nil {
-		return nil, fleet.NewInvalidArgumentError("query", "missing required argument")
+	if p.HostTagKey != nil {
+		// a host_tag label is made of the tagged hosts, it has no query
+		if p.Query != nil && *p.Query != "" {
+			return nil, fleet.NewInvalidArgumentError("query", "cannot be set with host_tag_key")
+		}
+		if p.Platform != nil && *p.Platform != "" {
+			return nil, fleet.NewInvalidArgumentError("platform", "cannot be set with host_tag_key")
+		}
+		value, err := svc.normalizeLabelHostTag(ctx, p.HostTagKey, p.HostTagValue)
+		if err != nil {
+			return nil, err
+		}
+		label.LabelMembershipType = fleet.LabelMembershipTypeHostTag
+		label.HostTagKey = p.HostTagKey
+		label.HostTagValue = value
+	} else {
+		if p.Query == nil {
+			return nil, fleet.NewInvalidArgumentError("query", "missing required argument")
+		}
+		label.Query = *p.Query
 	}
-	label.Query = *p.Query
 
 	if p.Platform != nil {
 		label.Platform = *p.Platform
@@ -386,6 +403,19 @@ func (svc *Service) ApplyLabelSpecs(ctx context.Context, specs []*fleet.LabelSpe
 			// Hosts list doesn't need to contain anything, but it should at least not be nil.
 			return ctxerr.Errorf(ctx, "label %s is declared as manual but contains no `hosts key`", spec.Name)
 		}
+		if spec.LabelMembershipType != fleet.LabelMembershipTypeHostTag && (spec.HostTagKey != nil || spec.HostTagValue != nil) {
+			return ctxerr.Errorf(ctx, "label %s is not declared as host_tag but contains `host_tag_key` or `host_tag_value` keys", spec.Name)
+		}
+		if spec.LabelMembershipType == fleet.LabelMembershipTypeHostTag {
+			if len(spec.Hosts) > 0 || spec.Query != "" || spec.Platform != "" {
+				return ctxerr.Errorf(ctx, "label %s is declared as host_tag but contains `hosts`, `query` or `platform` keys", spec.Name)
+			}
+			value, err := svc.normalizeLabelHostTag(ctx, spec.HostTagKey, spec.HostTagValue)
+			if err != nil {
+				return ctxerr.Wrapf(ctx, err, "label %s", spec.Name)
+			}
+			spec.HostTagValue = value
+		}
 	}
 	return svc.ds.ApplyLabelSpecs(ctx, specs)
 }
@@ -10,8 +10,10 @@ import (
 
 	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
 	"github.com/fleetdm/fleet/v4/server/contexts/logging"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/go-kit/kit/log/level"
 )
 
 type runLiveQueryRequest struct {
@@ -136,6 +138,11 @@ func (svc *Service) RunLiveQueryDeadline(ctx context.Context, queryIDs []uint, h
 }
 
 func (svc *Service) GetCampaignReader(ctx context.Context, campaign *fleet.DistributedQueryCampaign) (<-chan interface{}, context.CancelFunc, error) {
+	if svc.config.Osquery.EnableLiveQueryResults && campaign.Status == fleet.QueryComplete {
+		// the campaign already ran, read its stored results
+		return svc.getStoredCampaignReader(ctx, campaign)
+	}
+
 	// Open the channel from which we will receive incoming query results
 	// (probably from the redis pubsub implementation)
 	cancelCtx, cancelFunc := context.WithCancel(ctx)
@@ -152,6 +159,88 @@ func (svc *Service) GetCampaignReader(ctx context.Context, campaign *fleet.Distr
 		return nil, nil, ctxerr.Wrap(ctx, err, "error saving campaign state")
 	}
 
+	if svc.config.Osquery.EnableLiveQueryResults {
+		readChan = svc.storeCampaignResults(cancelCtx, campaign.ID, readChan)
+	}
+	return readChan, cancelFunc, nil
+}
+
+// storeCampaignResults returns a channel that forwards the messages read from
+// readChan, storing the results of the campaign along the way until the
+// configured maximum number of rows is reached.
+func (svc *Service) storeCampaignResults(ctx context.Context, campaignID uint, readChan <-chan interface{}) <-chan interface{} {
+	outChan := make(chan interface{})
+	maxRows := svc.config.Osquery.LiveQueryResultsMaxRows
+
+	go func() {
+		defer close(outChan)
+
+		var storedRows int
+		for msg := range readChan {
+			if res, ok := msg.(fleet.DistributedQueryResult); ok && res.Host != nil && (maxRows <= 0 || storedRows < maxRows) {
+				rows := res.Rows
+				if maxRows > 0 && storedRows+len(rows) > maxRows {
+					rows = rows[:maxRows-storedRows]
+				}
+				// the result must be stored before it is forwarded, as the reader
+				// may modify the rows.
+				err := svc.ds.NewDistributedQueryCampaignResult(ctx, &fleet.DistributedQueryCampaignResult{
+					DistributedQueryCampaignID: campaignID,
+					HostID:                     res.Host.ID,
+					Hostname:                   res.Host.Hostname,
+					HostDisplayName:            res.Host.DisplayName,
+					Rows:                       rows,
+					Error:                      res.Error,
+				})
+				if err != nil {
+					level.Error(svc.logger).Log("msg", "store live query result", "campaign_id", campaignID, "err", err)
+				} else {
+					storedRows += len(rows)
+				}
+			}
+
+			select {
+			case outChan <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return outChan
+}
+
+// getStoredCampaignReader returns a channel from which the stored results of
+// the completed campaign can be read, as if they were received from the hosts.
+func (svc *Service) getStoredCampaignReader(ctx context.Context, campaign *fleet.DistributedQueryCampaign) (<-chan interface{}, context.CancelFunc, error) {
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, nil, fleet.ErrNoContext
+	}
+	filter, err := svc.campaignResultsFilter(ctx, vc.User, campaign)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := svc.ds.ListDistributedQueryCampaignResults(ctx, filter, campaign.ID)
+	if err != nil {
+		return nil, nil, ctxerr.Wrap(ctx, err, "list stored campaign results")
+	}
+
+	cancelCtx, cancelFunc := context.WithCancel(ctx)
+	readChan := make(chan interface{})
+	go func() {
+		// as for the result store, the channel is only closed when the context
+		// is done.
+		defer close(readChan)
+		for _, res := range results {
+			select {
+			case readChan <- res.DistributedQueryResult():
+			case <-cancelCtx.Done():
+				return
+			}
+		}
+		<-cancelCtx.Done()
+	}()
 	return readChan, cancelFunc, nil
 }
 
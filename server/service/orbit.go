@@ -235,3 +235,137 @@ func (svc *Service) SetOrUpdateDeviceAuthToken(ctx context.Context, deviceAuthTo
 
 	return nil
 }
+
+/////////////////////////////////////////////////////////////////////////////////
+// Get orbit scripts endpoint
+/////////////////////////////////////////////////////////////////////////////////
+
+type orbitGetScriptsRequest struct {
+	OrbitNodeKey string `json:"orbit_node_key"`
+}
+
+func (r *orbitGetScriptsRequest) setOrbitNodeKey(nodeKey string) {
+	r.OrbitNodeKey = nodeKey
+}
+
+func (r *orbitGetScriptsRequest) orbitHostNodeKey() string {
+	return r.OrbitNodeKey
+}
+
+type orbitGetScriptsResponse struct {
+	Scripts []*fleet.HostScriptExecution `json:"scripts"`
+	Err     error                        `json:"error,omitempty"`
+}
+
+func (r orbitGetScriptsResponse) error() error { return r.Err }
+
+func getOrbitScriptsEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	scripts, err := svc.GetOrbitScripts(ctx)
+	if err != nil {
+		return orbitGetScriptsResponse{Err: err}, nil
+	}
+	return orbitGetScriptsResponse{Scripts: scripts}, nil
+}
+
+func (svc *Service) GetOrbitScripts(ctx context.Context) ([]*fleet.HostScriptExecution, error) {
+	// this is not a user-authenticated endpoint
+	svc.authz.SkipAuthorization(ctx)
+
+	host, ok := hostctx.FromContext(ctx)
+	if !ok {
+		return nil, orbitError{message: "internal error: missing host from request context"}
+	}
+
+	pending, err := svc.ds.ListPendingHostScriptExecutions(ctx, host.ID, fleet.ScriptExecutionTypeScript)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list pending scripts")
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	// the settings may have changed since the scripts were queued, the scripts
+	// that are not allowed anymore are marked as failed instead of being sent.
+	settings, err := svc.scriptSettings(ctx, host.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	scripts := make([]*fleet.HostScriptExecution, 0, len(pending))
+	ids := make([]uint, 0, len(pending))
+	for _, script := range pending {
+		if err := settings.Allows(script.Contents); err != nil {
+			if _, err := svc.ds.SetHostScriptExecutionResult(ctx, &fleet.HostScriptExecutionResult{
+				HostID:      host.ID,
+				ExecutionID: script.ID,
+				Output:      err.Error(),
+				Status:      fleet.ScriptExecutionFailed,
+			}); err != nil {
+				return nil, ctxerr.Wrap(ctx, err, "mark script not allowed")
+			}
+			continue
+		}
+		scripts = append(scripts, script)
+		ids = append(ids, script.ID)
+	}
+	if err := svc.ds.MarkHostScriptExecutionsSent(ctx, ids); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "mark scripts sent")
+	}
+	return scripts, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////
+// Post orbit script result endpoint
+/////////////////////////////////////////////////////////////////////////////////
+
+type orbitPostScriptResultRequest struct {
+	OrbitNodeKey string `json:"orbit_node_key"`
+	fleet.HostScriptExecutionResult
+}
+
+func (r *orbitPostScriptResultRequest) setOrbitNodeKey(nodeKey string) {
+	r.OrbitNodeKey = nodeKey
+}
+
+func (r *orbitPostScriptResultRequest) orbitHostNodeKey() string {
+	return r.OrbitNodeKey
+}
+
+type orbitPostScriptResultResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r orbitPostScriptResultResponse) error() error { return r.Err }
+
+func postOrbitScriptResultEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*orbitPostScriptResultRequest)
+	if err := svc.SaveOrbitScriptResult(ctx, req.HostScriptExecutionResult); err != nil {
+		return orbitPostScriptResultResponse{Err: err}, nil
+	}
+	return orbitPostScriptResultResponse{}, nil
+}
+
+func (svc *Service) SaveOrbitScriptResult(ctx context.Context, result fleet.HostScriptExecutionResult) error {
+	// this is not a user-authenticated endpoint
+	svc.authz.SkipAuthorization(ctx)
+
+	host, ok := hostctx.FromContext(ctx)
+	if !ok {
+		return orbitError{message: "internal error: missing host from request context"}
+	}
+
+	result.HostID = host.ID
+	result.Status = scriptExecutionStatus(result.ExitCode)
+	exec, err := svc.ds.SetHostScriptExecutionResult(ctx, &result)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "save script result")
+	}
+
+	if exec.PolicyID != nil {
+		// the script was the remediation of a failing policy, request a refetch
+		// of the host so that the policy is evaluated again.
+		if err := svc.ds.UpdateHostRefetchRequested(ctx, host.ID, true); err != nil {
+			return ctxerr.Wrap(ctx, err, "request host refetch after remediation")
+		}
+	}
+	return nil
+}
@@ -120,6 +120,30 @@ func (oc *OrbitClient) SetOrUpdateDeviceToken(deviceAuthToken string) error {
 	return nil
 }
 
+// GetScripts returns the scripts that Fleet queued for execution on this
+// host. The returned scripts are marked as sent by the server.
+func (oc *OrbitClient) GetScripts() ([]*fleet.HostScriptExecution, error) {
+	verb, path := "POST", "/api/fleet/orbit/scripts"
+	var resp orbitGetScriptsResponse
+	if err := oc.authenticatedRequest(verb, path, &orbitGetScriptsRequest{}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Scripts, nil
+}
+
+// SaveScriptResult sends the result of a script execution to the server.
+func (oc *OrbitClient) SaveScriptResult(result *fleet.HostScriptExecutionResult) error {
+	verb, path := "POST", "/api/fleet/orbit/scripts/result"
+	params := orbitPostScriptResultRequest{
+		HostScriptExecutionResult: *result,
+	}
+	var resp orbitPostScriptResultResponse
+	if err := oc.authenticatedRequest(verb, path, &params, &resp); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Ping sends a ping request to the orbit/ping endpoint.
 func (oc *OrbitClient) Ping() error {
 	verb, path := "HEAD", "/api/fleet/orbit/ping"
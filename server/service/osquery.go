@@ -174,6 +174,9 @@ func (svc *Service) EnrollAgent(ctx context.Context, enrollSecret, hostIdentifie
 		save = true
 	}
 
+	svc.deduplicateHost(ctx, appConfig.HostDeduplicationSettings.Strategy, host)
+	svc.applyHostAssignmentRules(ctx, host)
+
 	if save {
 		if appConfig.ServerSettings.DeferredSaveHost {
 			go svc.serialUpdateHost(host)
@@ -187,6 +190,36 @@ func (svc *Service) EnrollAgent(ctx context.Context, enrollSecret, hostIdentifie
 	return nodeKey, nil
 }
 
+// recordHostVitals stores a sample of the vitals of the host that were just
+// reported, if the vitals history is enabled. Errors are logged, they do not
+// fail the ingestion of the results.
+func (svc *Service) recordHostVitals(ctx context.Context, host *fleet.Host) {
+	interval := svc.config.Osquery.HostVitalsHistoryInterval
+	if interval <= 0 {
+		return
+	}
+	if err := svc.ds.RecordHostVitals(ctx, fleet.HostVitalsFromHost(host, svc.clock.Now()), interval); err != nil {
+		logging.WithErr(ctx, ctxerr.Wrap(ctx, err, "record host vitals"))
+	}
+}
+
+// applyHostAssignmentRules transfers the host to the team of the host
+// assignment rule it matches, if any. The rules are a premium feature, they
+// are only applied if the enterprise overrides are set. Failing to apply them
+// is logged but does not fail the request of the host. The quarantined hosts
+// stay on their team.
+func (svc *Service) applyHostAssignmentRules(ctx context.Context, host *fleet.Host) {
+	if svc.EnterpriseOverrides == nil || svc.EnterpriseOverrides.ApplyHostAssignmentRules == nil {
+		return
+	}
+	if host.LifecycleState == fleet.HostLifecycleStateQuarantined {
+		return
+	}
+	if err := svc.EnterpriseOverrides.ApplyHostAssignmentRules(ctx, host); err != nil {
+		logging.WithErr(ctx, err)
+	}
+}
+
 var counter = int64(0)
 
 func (svc *Service) serialUpdateHost(host *fleet.Host) {
@@ -552,6 +585,15 @@ func (svc *Service) GetDistributedQueries(ctx context.Context) (queries map[stri
 		queries[hostPolicyQueryPrefix+name] = query
 	}
 
+	if remediationQueries, err := svc.remediationQueriesForHost(ctx, host); err != nil {
+		// Same as live queries, the host should still receive the other queries.
+		level.Error(svc.logger).Log("op", "remediationQueriesForHost", "err", err)
+	} else {
+		for name, query := range remediationQueries {
+			queries[hostRemediationQueryPrefix+name] = query
+		}
+	}
+
 	accelerate = uint(0)
 	if host.Hostname == "" || host.Platform == "" {
 		// Assume this host is just enrolling, and accelerate checkins
@@ -653,6 +695,10 @@ func (svc *Service) labelQueriesForHost(ctx context.Context, host *fleet.Host) (
 }
 
 func (svc *Service) policyQueriesForHost(ctx context.Context, host *fleet.Host) (map[string]string, error) {
+	if host.LifecycleState == fleet.HostLifecycleStateRetired {
+		// the retired hosts do not run the policies
+		return nil, nil
+	}
 	policyReportedAt := svc.task.GetHostPolicyReportedAt(ctx, host)
 	if !svc.shouldUpdate(policyReportedAt, svc.config.Osquery.PolicyUpdateInterval, host.ID) && !host.RefetchRequested {
 		return nil, nil
@@ -775,6 +821,10 @@ const (
 	// osqueryd writes the distributed query results.
 	hostPolicyQueryPrefix = "fleet_policy_query_"
 
+	// hostRemediationQueryPrefix is appended before the script execution ID
+	// when the osquery remediation of a failing policy is sent to a host.
+	hostRemediationQueryPrefix = "fleet_remediation_query_"
+
 	// hostDistributedQueryPrefix is appended before the query name when a query is
 	// run from a distributed query campaign
 	hostDistributedQueryPrefix = "fleet_distributed_query_"
@@ -799,6 +849,7 @@ func (svc *Service) SubmitDistributedQueryResults(
 	additionalUpdated := false
 	labelResults := map[uint]*bool{}
 	policyResults := map[uint]*bool{}
+	remediationCompleted := false
 
 	svc.maybeDebugHost(ctx, host, results, statuses, messages)
 
@@ -831,6 +882,10 @@ func (svc *Service) SubmitDistributedQueryResults(
 			err = ingestMembershipQuery(hostPolicyQueryPrefix, query, rows, policyResults, failed)
 		case strings.HasPrefix(query, hostDistributedQueryPrefix):
 			err = svc.ingestDistributedQuery(ctx, *host, query, rows, failed, messages[query])
+		case strings.HasPrefix(query, hostRemediationQueryPrefix):
+			var forPolicy bool
+			forPolicy, err = svc.ingestRemediationQuery(ctx, host, query, rows, failed, messages[query])
+			remediationCompleted = remediationCompleted || forPolicy
 		default:
 			err = osqueryError{message: "unknown query prefix: " + query}
 		}
@@ -871,17 +926,37 @@ func (svc *Service) SubmitDistributedQueryResults(
 			}
 		}
 
+		// the policies that are failing also need the flipped results, to run
+		// the remediations on the hosts that start failing them. The
+		// remediations are only loaded if the host started failing a policy.
 		filteredResults := filterPolicyResults(policyResults, policyIDs)
+		for policyID, passes := range policyResults {
+			if passes != nil && !*passes {
+				filteredResults[policyID] = passes
+			}
+		}
 		if len(filteredResults) > 0 {
 			if failingPolicies, passingPolicies, err := svc.ds.FlippingPoliciesForHost(ctx, host.ID, filteredResults); err != nil {
 				logging.WithErr(ctx, err)
 			} else {
-				// Register the flipped policies on a goroutine to not block the hosts on redis requests.
-				go func() {
-					if err := svc.registerFlippedPolicies(ctx, host.ID, host.Hostname, host.DisplayName(), failingPolicies, passingPolicies); err != nil {
+				if len(failingPolicies) > 0 {
+					if remediations, err := svc.ds.PolicyRemediationsForHost(ctx, host); err != nil {
 						logging.WithErr(ctx, err)
+					} else {
+						svc.queuePolicyRemediations(ctx, host, failingPolicies, remediations)
 					}
-				}()
+				}
+
+				failingPolicies = filterPolicyIDs(failingPolicies, policyIDs)
+				passingPolicies = filterPolicyIDs(passingPolicies, policyIDs)
+				if len(failingPolicies) > 0 || len(passingPolicies) > 0 {
+					// Register the flipped policies on a goroutine to not block the hosts on redis requests.
+					go func() {
+						if err := svc.registerFlippedPolicies(ctx, host.ID, host.Hostname, host.DisplayName(), failingPolicies, passingPolicies); err != nil {
+							logging.WithErr(ctx, err)
+						}
+					}()
+				}
 			}
 		}
 		// NOTE(mna): currently, failing policies webhook wouldn't see the new
@@ -910,14 +985,24 @@ func (svc *Service) SubmitDistributedQueryResults(
 
 	if detailUpdated {
 		host.DetailUpdatedAt = svc.clock.Now()
+		svc.recordHostVitals(ctx, host)
 	}
 
 	refetchRequested := host.RefetchRequested
 	if refetchRequested {
 		host.RefetchRequested = false
 	}
+	if remediationCompleted {
+		// Request a refetch so that the policies are evaluated again after the
+		// remediation ran.
+		host.RefetchRequested = true
+	}
 
 	if refetchRequested || detailUpdated {
+		svc.applyHostAssignmentRules(ctx, host)
+	}
+
+	if refetchRequested || detailUpdated || remediationCompleted {
 		appConfig, err := svc.ds.AppConfig(ctx)
 		if err != nil {
 			logging.WithErr(ctx, err)
@@ -996,44 +1081,56 @@ func (svc *Service) ingestDistributedQuery(ctx context.Context, host fleet.Host,
 			return osqueryError{message: "writing results: " + err.Error()}
 		}
 
-		// If there are no subscribers, the campaign is "orphaned"
-		// and should be closed so that we don't continue trying to
-		// execute that query when we can't write to any subscriber
-		campaign, err := svc.ds.DistributedQueryCampaign(ctx, uint(campaignID))
+		// The campaigns of the scheduled live queries have no subscribers,
+		// their results are stored as they are received.
+		stored, err := svc.storeScheduledLiveQueryResult(ctx, res)
 		if err != nil {
-			if err := svc.liveQueryStore.StopQuery(strconv.Itoa(campaignID)); err != nil {
-				return osqueryError{message: "stop orphaned campaign after load failure: " + err.Error()}
-			}
-			return osqueryError{message: "loading orphaned campaign: " + err.Error()}
+			return osqueryError{message: "storing scheduled live query result: " + err.Error()}
 		}
-
-		if campaign.CreatedAt.After(svc.clock.Now().Add(-1 * time.Minute)) {
-			// Give the client a minute to connect before considering the
-			// campaign orphaned
-			return osqueryError{message: "campaign waiting for listener (please retry)"}
+		if !stored {
+			return svc.stopOrphanedCampaign(ctx, campaignID)
 		}
+	}
 
-		if campaign.Status != fleet.QueryComplete {
-			campaign.Status = fleet.QueryComplete
-			if err := svc.ds.SaveDistributedQueryCampaign(ctx, campaign); err != nil {
-				return osqueryError{message: "closing orphaned campaign: " + err.Error()}
-			}
-		}
+	err = svc.liveQueryStore.QueryCompletedByHost(strconv.Itoa(campaignID), host.ID)
+	if err != nil {
+		return osqueryError{message: "record query completion: " + err.Error()}
+	}
 
+	return nil
+}
+
+// stopOrphanedCampaign closes the campaign without subscribers, so that we
+// don't continue trying to execute that query when we can't write to any
+// subscriber.
+func (svc *Service) stopOrphanedCampaign(ctx context.Context, campaignID int) error {
+	campaign, err := svc.ds.DistributedQueryCampaign(ctx, uint(campaignID))
+	if err != nil {
 		if err := svc.liveQueryStore.StopQuery(strconv.Itoa(campaignID)); err != nil {
-			return osqueryError{message: "stopping orphaned campaign: " + err.Error()}
+			return osqueryError{message: "stop orphaned campaign after load failure: " + err.Error()}
 		}
+		return osqueryError{message: "loading orphaned campaign: " + err.Error()}
+	}
 
-		// No need to record query completion in this case
-		return osqueryError{message: "campaign stopped"}
+	if campaign.CreatedAt.After(svc.clock.Now().Add(-1 * time.Minute)) {
+		// Give the client a minute to connect before considering the
+		// campaign orphaned
+		return osqueryError{message: "campaign waiting for listener (please retry)"}
 	}
 
-	err = svc.liveQueryStore.QueryCompletedByHost(strconv.Itoa(campaignID), host.ID)
-	if err != nil {
-		return osqueryError{message: "record query completion: " + err.Error()}
+	if campaign.Status != fleet.QueryComplete {
+		campaign.Status = fleet.QueryComplete
+		if err := svc.ds.SaveDistributedQueryCampaign(ctx, campaign); err != nil {
+			return osqueryError{message: "closing orphaned campaign: " + err.Error()}
+		}
 	}
 
-	return nil
+	if err := svc.liveQueryStore.StopQuery(strconv.Itoa(campaignID)); err != nil {
+		return osqueryError{message: "stopping orphaned campaign: " + err.Error()}
+	}
+
+	// No need to record query completion in this case
+	return osqueryError{message: "campaign stopped"}
 }
 
 // ingestMembershipQuery records the results of label queries run by a host
@@ -1102,6 +1199,21 @@ func filterPolicyResults(incoming map[uint]*bool, webhookPolicies []uint) map[ui
 	return filtered
 }
 
+// filterPolicyIDs filters out the policy IDs that aren't configured for webhook automation.
+func filterPolicyIDs(policyIDs []uint, webhookPolicies []uint) []uint {
+	wp := make(map[uint]struct{})
+	for _, policyID := range webhookPolicies {
+		wp[policyID] = struct{}{}
+	}
+	var filtered []uint
+	for _, policyID := range policyIDs {
+		if _, ok := wp[policyID]; ok {
+			filtered = append(filtered, policyID)
+		}
+	}
+	return filtered
+}
+
 func (svc *Service) registerFlippedPolicies(ctx context.Context, hostID uint, hostname, displayName string, newFailing, newPassing []uint) error {
 	host := fleet.PolicySetHost{
 		ID:          hostID,
@@ -1210,5 +1322,19 @@ func (svc *Service) SubmitResultLogs(ctx context.Context, logs []json.RawMessage
 	if err := svc.osqueryLogWriter.Result.Write(ctx, logs); err != nil {
 		return osqueryError{message: "error writing result logs: " + err.Error()}
 	}
+
+	if svc.config.Osquery.EnableScheduledQueryResults {
+		// the logs were written to the log destinations, so failing to store
+		// the results must not fail the request (osquery would send the logs
+		// again).
+		host, ok := hostctx.FromContext(ctx)
+		if !ok {
+			return osqueryError{message: "internal error: missing host from request context"}
+		}
+		results := scheduledQueryResultsFromLogs(logs, svc.clock.Now())
+		if err := svc.task.RecordScheduledQueryResults(ctx, host.ID, results, svc.clock.Now()); err != nil {
+			logging.WithErr(ctx, ctxerr.Wrap(ctx, err, "record scheduled query results"))
+		}
+	}
 	return nil
 }
@@ -628,6 +628,9 @@ func TestQueriesAndHostFeatures(t *testing.T) {
 		return map[string]string{}, nil
 	}
 
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
 	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
@@ -730,6 +733,9 @@ func TestLabelQueries(t *testing.T) {
 	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
 		return &fleet.AppConfig{Features: fleet.Features{EnableHostUsers: true}}, nil
 	}
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
 	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
@@ -888,6 +894,9 @@ func TestDetailQueriesWithEmptyStrings(t *testing.T) {
 	ds.LabelQueriesForHostFunc = func(context.Context, *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
 	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
@@ -1092,6 +1101,9 @@ func TestDetailQueries(t *testing.T) {
 	ds.LabelQueriesForHostFunc = func(context.Context, *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
 	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
@@ -1477,6 +1489,9 @@ func TestDistributedQueryResults(t *testing.T) {
 	ds.LabelQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
 	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
@@ -1611,6 +1626,9 @@ func TestIngestDistributedQueryOrphanedCampaignLoadError(t *testing.T) {
 		clock:          mockClock,
 	}
 
+	ds.ScheduledLiveQueryRunByCampaignFunc = func(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error) {
+		return nil, notFoundError{}
+	}
 	ds.DistributedQueryCampaignFunc = func(ctx context.Context, id uint) (*fleet.DistributedQueryCampaign, error) {
 		return nil, errors.New("missing campaign")
 	}
@@ -1646,6 +1664,9 @@ func TestIngestDistributedQueryOrphanedCampaignWaitListener(t *testing.T) {
 		},
 	}
 
+	ds.ScheduledLiveQueryRunByCampaignFunc = func(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error) {
+		return nil, notFoundError{}
+	}
 	ds.DistributedQueryCampaignFunc = func(ctx context.Context, id uint) (*fleet.DistributedQueryCampaign, error) {
 		return campaign, nil
 	}
@@ -1679,6 +1700,9 @@ func TestIngestDistributedQueryOrphanedCloseError(t *testing.T) {
 		},
 	}
 
+	ds.ScheduledLiveQueryRunByCampaignFunc = func(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error) {
+		return nil, notFoundError{}
+	}
 	ds.DistributedQueryCampaignFunc = func(ctx context.Context, id uint) (*fleet.DistributedQueryCampaign, error) {
 		return campaign, nil
 	}
@@ -1715,6 +1739,9 @@ func TestIngestDistributedQueryOrphanedStopError(t *testing.T) {
 		},
 	}
 
+	ds.ScheduledLiveQueryRunByCampaignFunc = func(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error) {
+		return nil, notFoundError{}
+	}
 	ds.DistributedQueryCampaignFunc = func(ctx context.Context, id uint) (*fleet.DistributedQueryCampaign, error) {
 		return campaign, nil
 	}
@@ -1752,6 +1779,9 @@ func TestIngestDistributedQueryOrphanedStop(t *testing.T) {
 		},
 	}
 
+	ds.ScheduledLiveQueryRunByCampaignFunc = func(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error) {
+		return nil, notFoundError{}
+	}
 	ds.DistributedQueryCampaignFunc = func(ctx context.Context, id uint) (*fleet.DistributedQueryCampaign, error) {
 		return campaign, nil
 	}
@@ -1768,6 +1798,48 @@ func TestIngestDistributedQueryOrphanedStop(t *testing.T) {
 	lq.AssertExpectations(t)
 }
 
+func TestIngestDistributedQueryScheduledLiveQueryRun(t *testing.T) {
+	mockClock := clock.NewMockClock()
+	ds := new(mock.Store)
+	rs := pubsub.NewInmemQueryResults()
+	lq := live_query_mock.New(t)
+	svc := &Service{
+		ds:             ds,
+		resultStore:    rs,
+		liveQueryStore: lq,
+		logger:         log.NewNopLogger(),
+		clock:          mockClock,
+	}
+
+	endsAt := mockClock.Now().Add(time.Minute)
+	ds.ScheduledLiveQueryRunByCampaignFunc = func(ctx context.Context, campaignID uint) (*fleet.ScheduledLiveQueryRun, error) {
+		return &fleet.ScheduledLiveQueryRun{CampaignID: campaignID, ScheduledLiveQueryID: 1, EndsAt: endsAt}, nil
+	}
+	var stored []*fleet.DistributedQueryCampaignResult
+	ds.NewDistributedQueryCampaignResultFunc = func(ctx context.Context, result *fleet.DistributedQueryCampaignResult) error {
+		stored = append(stored, result)
+		return nil
+	}
+	lq.On("QueryCompletedByHost", "42", uint(1)).Return(nil)
+
+	// the result of the run without subscriber is stored
+	host := fleet.Host{ID: 1, Hostname: "h1"}
+	err := svc.ingestDistributedQuery(context.Background(), host, "fleet_distributed_query_42", []map[string]string{{"a": "1"}}, false, "")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, uint(42), stored[0].DistributedQueryCampaignID)
+	assert.Equal(t, "h1", stored[0].Hostname)
+	assert.Equal(t, []map[string]string{{"a": "1"}}, stored[0].Rows)
+	assert.False(t, ds.DistributedQueryCampaignFuncInvoked)
+
+	// the results received after the end of the window are dropped
+	mockClock.AddTime(2 * time.Minute)
+	err = svc.ingestDistributedQuery(context.Background(), host, "fleet_distributed_query_42", []map[string]string{{"a": "2"}}, false, "")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	lq.AssertExpectations(t)
+}
+
 func TestIngestDistributedQueryRecordCompletionError(t *testing.T) {
 	mockClock := clock.NewMockClock()
 	ds := new(mock.Store)
@@ -2336,6 +2408,9 @@ func TestPolicyQueries(t *testing.T) {
 
 	lq.On("QueriesForHost", uint(0)).Return(map[string]string{}, nil)
 
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
 	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{"1": "select 1", "2": "select 42;"}, nil
 	}
@@ -2349,6 +2424,10 @@ func TestPolicyQueries(t *testing.T) {
 		return nil, nil, nil
 	}
 
+	ds.PolicyRemediationsForHostFunc = func(ctx context.Context, host *fleet.Host) (map[uint]*fleet.Policy, error) {
+		return nil, nil
+	}
+
 	ctx := hostctx.NewContext(context.Background(), host)
 
 	queries, discovery, _, err := svc.GetDistributedQueries(ctx)
@@ -2542,6 +2621,9 @@ func TestPolicyWebhooks(t *testing.T) {
 		}, nil
 	}
 
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
 	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{
 			"1": "select 1;",                       // passing policy
@@ -2555,6 +2637,9 @@ func TestPolicyWebhooks(t *testing.T) {
 		host = gotHost
 		return nil
 	}
+	ds.PolicyRemediationsForHostFunc = func(ctx context.Context, host *fleet.Host) (map[uint]*fleet.Policy, error) {
+		return nil, nil
+	}
 	ctx := hostctx.NewContext(context.Background(), host)
 
 	queries, discovery, _, err := svc.GetDistributedQueries(ctx)
@@ -2799,6 +2884,9 @@ func TestLiveQueriesFailing(t *testing.T) {
 	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
 		return &fleet.AppConfig{Features: fleet.Features{EnableHostUsers: true}}, nil
 	}
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
 	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
@@ -2834,3 +2922,152 @@ func osqueryMapKeys(m map[string]osquery_utils.DetailQuery) []string {
 	sort.Strings(keys)
 	return keys
 }
+
+func TestPolicyRemediations(t *testing.T) {
+	mockClock := clock.NewMockClock()
+	ds := new(mock.Store)
+	lq := live_query_mock.New(t)
+	svc := newTestServiceWithClock(t, ds, nil, lq, mockClock)
+
+	host := &fleet.Host{
+		ID:       5,
+		Platform: "darwin",
+	}
+
+	lq.On("QueriesForHost", uint(5)).Return(map[string]string{}, nil)
+	ds.LabelQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
+		return map[string]string{}, nil
+	}
+	ds.HostLiteFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		return host, nil
+	}
+	ds.UpdateHostFunc = func(ctx context.Context, gotHost *fleet.Host) error {
+		host = gotHost
+		return nil
+	}
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{
+			Features: fleet.Features{EnableHostUsers: true},
+			Scripts:  fleet.ScriptSettings{Enabled: true, AllowAny: true},
+		}, nil
+	}
+	ds.PolicyQueriesForHostFunc = func(ctx context.Context, host *fleet.Host) (map[string]string, error) {
+		return map[string]string{"1": "select 1", "2": "select 2", "3": "select 3"}, nil
+	}
+	ds.RecordPolicyQueryExecutionsFunc = func(ctx context.Context, gotHost *fleet.Host, results map[uint]*bool, updated time.Time, deferred bool) error {
+		host = gotHost
+		return nil
+	}
+	ds.PolicyRemediationsForHostFunc = func(ctx context.Context, host *fleet.Host) (map[uint]*fleet.Policy, error) {
+		return map[uint]*fleet.Policy{
+			2: {PolicyData: fleet.PolicyData{ID: 2, RemediationType: fleet.ScriptExecutionTypeScript, Remediation: ptr.String("echo fix")}},
+			3: {PolicyData: fleet.PolicyData{ID: 3, RemediationType: fleet.ScriptExecutionTypeOsquery, Remediation: ptr.String("select fix")}},
+		}, nil
+	}
+	var flipIncoming map[uint]*bool
+	ds.FlippingPoliciesForHostFunc = func(ctx context.Context, hostID uint, incomingResults map[uint]*bool) (newFailing []uint, newPassing []uint, err error) {
+		flipIncoming = incomingResults
+		return []uint{2, 3}, nil, nil
+	}
+	var queued []*fleet.HostScriptExecution
+	ds.NewHostScriptExecutionFunc = func(ctx context.Context, exec *fleet.HostScriptExecution) (*fleet.HostScriptExecution, error) {
+		queued = append(queued, exec)
+		return exec, nil
+	}
+
+	ctx := hostctx.NewContext(context.Background(), host)
+
+	// the remediations are queued for the policies the host starts failing
+	err := svc.SubmitDistributedQueryResults(
+		ctx,
+		map[string][]map[string]string{
+			hostPolicyQueryPrefix + "1": {{"col1": "val1"}},
+			hostPolicyQueryPrefix + "2": {},
+			hostPolicyQueryPrefix + "3": {},
+		},
+		map[string]fleet.OsqueryStatus{},
+		map[string]string{},
+	)
+	require.NoError(t, err)
+	require.Len(t, flipIncoming, 2)
+	require.Contains(t, flipIncoming, uint(2))
+	require.Contains(t, flipIncoming, uint(3))
+	require.Len(t, queued, 2)
+	sort.Slice(queued, func(i, j int) bool { return *queued[i].PolicyID < *queued[j].PolicyID })
+	require.Equal(t, uint(5), queued[0].HostID)
+	require.Equal(t, fleet.ScriptExecutionTypeScript, queued[0].Type)
+	require.Equal(t, "echo fix", queued[0].Contents)
+	require.Equal(t, fleet.ScriptExecutionTypeOsquery, queued[1].Type)
+	require.Equal(t, "select fix", queued[1].Contents)
+
+	// the remediations are not loaded if the host did not start failing a
+	// policy
+	ds.PolicyRemediationsForHostFuncInvoked = false
+	ds.FlippingPoliciesForHostFunc = func(ctx context.Context, hostID uint, incomingResults map[uint]*bool) (newFailing []uint, newPassing []uint, err error) {
+		return nil, nil, nil
+	}
+	err = svc.SubmitDistributedQueryResults(
+		ctx,
+		map[string][]map[string]string{
+			hostPolicyQueryPrefix + "2": {},
+			hostPolicyQueryPrefix + "3": {},
+		},
+		map[string]fleet.OsqueryStatus{},
+		map[string]string{},
+	)
+	require.NoError(t, err)
+	require.False(t, ds.PolicyRemediationsForHostFuncInvoked)
+	require.Len(t, queued, 2)
+
+	// the pending osquery remediations are sent with the distributed queries
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		require.Equal(t, fleet.ScriptExecutionTypeOsquery, execType)
+		return []*fleet.HostScriptExecution{{ID: 7, HostID: hostID, PolicyID: ptr.Uint(3), Type: execType, Contents: "select fix"}}, nil
+	}
+	var sentIDs []uint
+	ds.MarkHostScriptExecutionsSentFunc = func(ctx context.Context, ids []uint) error {
+		sentIDs = ids
+		return nil
+	}
+	queries, _, _, err := svc.GetDistributedQueries(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "select fix", queries[hostRemediationQueryPrefix+"7"])
+	require.Equal(t, []uint{7}, sentIDs)
+
+	// the result is stored and the host is refetched to evaluate the policy again
+	var gotResult *fleet.HostScriptExecutionResult
+	ds.SetHostScriptExecutionResultFunc = func(ctx context.Context, res *fleet.HostScriptExecutionResult) (*fleet.HostScriptExecution, error) {
+		gotResult = res
+		return &fleet.HostScriptExecution{ID: res.ExecutionID, HostID: res.HostID, PolicyID: ptr.Uint(3), Status: res.Status}, nil
+	}
+	host.RefetchRequested = false
+	err = svc.SubmitDistributedQueryResults(
+		ctx,
+		map[string][]map[string]string{
+			hostRemediationQueryPrefix + "7": {{"fixed": "1"}},
+		},
+		map[string]fleet.OsqueryStatus{},
+		map[string]string{},
+	)
+	require.NoError(t, err)
+	require.NotNil(t, gotResult)
+	require.Equal(t, uint(7), gotResult.ExecutionID)
+	require.Equal(t, uint(5), gotResult.HostID)
+	require.Equal(t, fleet.ScriptExecutionCompleted, gotResult.Status)
+	require.JSONEq(t, `[{"fixed":"1"}]`, gotResult.Output)
+	require.True(t, ds.UpdateHostFuncInvoked)
+	require.True(t, host.RefetchRequested)
+
+	// a failed remediation query stores the error message
+	err = svc.SubmitDistributedQueryResults(
+		ctx,
+		map[string][]map[string]string{
+			hostRemediationQueryPrefix + "7": {},
+		},
+		map[string]fleet.OsqueryStatus{hostRemediationQueryPrefix + "7": 1},
+		map[string]string{hostRemediationQueryPrefix + "7": "no such table: fix"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, fleet.ScriptExecutionFailed, gotResult.Status)
+	require.Equal(t, "no such table: fix", gotResult.Output)
+}
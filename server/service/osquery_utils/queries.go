@@ -1153,7 +1153,14 @@ func directIngestDiskSpace(ctx context.Context, logger log.Logger, host *fleet.H
 		return err
 	}
 
-	return ds.SetOrUpdateHostDisksSpace(ctx, host.ID, gigsAvailable, percentAvailable)
+	if err := ds.SetOrUpdateHostDisksSpace(ctx, host.ID, gigsAvailable, percentAvailable); err != nil {
+		return err
+	}
+	// the disk space is also set on the host, for the sample of its vitals
+	// history that is recorded once all the detail queries are ingested.
+	host.GigsDiskSpaceAvailable = gigsAvailable
+	host.PercentDiskSpaceAvailable = percentAvailable
+	return nil
 }
 
 func directIngestMDM(ctx context.Context, logger log.Logger, host *fleet.Host, ds fleet.Datastore, rows []map[string]string, failed bool) error {
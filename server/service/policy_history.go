@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+// defaultPolicyDailyCountsDays and maxPolicyDailyCountsDays are the default
+// and maximum number of days of the policy daily counts.
+const (
+	defaultPolicyDailyCountsDays = 30
+	maxPolicyDailyCountsDays     = 365
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List host policy timeline
+////////////////////////////////////////////////////////////////////////////////
+
+type listHostPolicyTimelineRequest struct {
+	ID          uint              `url:"id"`
+	PolicyID    *uint             `query:"policy_id,optional"`
+	ListOptions fleet.ListOptions `url:"list_options"`
+}
+
+type listHostPolicyTimelineResponse struct {
+	Timeline []*fleet.PolicyMembershipTransition `json:"timeline"`
+	Err      error                               `json:"error,omitempty"`
+}
+
+func (r listHostPolicyTimelineResponse) error() error { return r.Err }
+
+func listHostPolicyTimelineEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listHostPolicyTimelineRequest)
+	timeline, err := svc.ListHostPolicyTimeline(ctx, req.ID, req.PolicyID, req.ListOptions)
+	if err != nil {
+		return listHostPolicyTimelineResponse{Err: err}, nil
+	}
+	if timeline == nil {
+		timeline = []*fleet.PolicyMembershipTransition{}
+	}
+	return listHostPolicyTimelineResponse{Timeline: timeline}, nil
+}
+
+func (svc *Service) ListHostPolicyTimeline(ctx context.Context, hostID uint, policyID *uint, opt fleet.ListOptions) ([]*fleet.PolicyMembershipTransition, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return nil, err
+	}
+
+	host, err := svc.ds.HostLite(ctx, hostID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "find host for policy timeline")
+	}
+	if err := svc.authz.Authorize(ctx, host, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	return svc.ds.ListHostPolicyTransitions(ctx, hostID, policyID, opt)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Get policy daily counts
+////////////////////////////////////////////////////////////////////////////////
+
+type getPolicyDailyCountsRequest struct {
+	PolicyID uint `url:"policy_id"`
+	Days     *int `query:"days,optional"`
+}
+
+type getPolicyDailyCountsResponse struct {
+	DailyCounts []*fleet.PolicyDailyCount `json:"daily_counts"`
+	Err         error                     `json:"error,omitempty"`
+}
+
+func (r getPolicyDailyCountsResponse) error() error { return r.Err }
+
+func getPolicyDailyCountsEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*getPolicyDailyCountsRequest)
+	days := defaultPolicyDailyCountsDays
+	if req.Days != nil {
+		days = *req.Days
+	}
+	counts, err := svc.GetPolicyDailyCounts(ctx, req.PolicyID, days)
+	if err != nil {
+		return getPolicyDailyCountsResponse{Err: err}, nil
+	}
+	return getPolicyDailyCountsResponse{DailyCounts: counts}, nil
+}
+
+func (svc *Service) GetPolicyDailyCounts(ctx context.Context, policyID uint, days int) ([]*fleet.PolicyDailyCount, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Policy{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	if days < 1 || days > maxPolicyDailyCountsDays {
+		return nil, ctxerr.Wrap(ctx, fleet.NewInvalidArgumentError("days", "must be between 1 and 365"))
+	}
+
+	policy, err := svc.ds.Policy(ctx, policyID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get policy for daily counts")
+	}
+	if err := svc.authz.Authorize(ctx, policy, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	now := svc.clock.Now()
+	return svc.ds.PolicyDailyCounts(ctx, policyID, now.AddDate(0, 0, -(days-1)), now)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyHistoryAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.HostLiteFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		if id == 1 {
+			return &fleet.Host{ID: id, TeamID: ptr.Uint(1)}, nil
+		}
+		return &fleet.Host{ID: id}, nil
+	}
+	ds.ListHostPolicyTransitionsFunc = func(ctx context.Context, hostID uint, policyID *uint, opt fleet.ListOptions) ([]*fleet.PolicyMembershipTransition, error) {
+		return nil, nil
+	}
+	ds.PolicyFunc = func(ctx context.Context, id uint) (*fleet.Policy, error) {
+		if id == 1 {
+			return &fleet.Policy{PolicyData: fleet.PolicyData{ID: id, TeamID: ptr.Uint(1)}}, nil
+		}
+		return &fleet.Policy{PolicyData: fleet.PolicyData{ID: id}}, nil
+	}
+	ds.PolicyDailyCountsFunc = func(ctx context.Context, policyID uint, from, to time.Time) ([]*fleet.PolicyDailyCount, error) {
+		return nil, nil
+	}
+
+	testCases := []struct {
+		name             string
+		user             *fleet.User
+		shouldFailTeam   bool
+		shouldFailGlobal bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, false, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, false, false},
+		{"team admin, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, false, true},
+		{"team observer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleObserver}}}, false, true},
+		{"team admin, different team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.ListHostPolicyTimeline(ctx, 1, nil, fleet.ListOptions{})
+			checkAuthErr(t, tt.shouldFailTeam, err)
+			_, err = svc.ListHostPolicyTimeline(ctx, 2, nil, fleet.ListOptions{})
+			checkAuthErr(t, tt.shouldFailGlobal, err)
+
+			_, err = svc.GetPolicyDailyCounts(ctx, 1, 30)
+			checkAuthErr(t, tt.shouldFailTeam, err)
+			// team users can read the global policies
+			_, err = svc.GetPolicyDailyCounts(ctx, 2, 30)
+			checkAuthErr(t, false, err)
+		})
+	}
+}
+
+func TestGetPolicyDailyCounts(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.PolicyFunc = func(ctx context.Context, id uint) (*fleet.Policy, error) {
+		return &fleet.Policy{PolicyData: fleet.PolicyData{ID: id}}, nil
+	}
+	var gotFrom, gotTo time.Time
+	ds.PolicyDailyCountsFunc = func(ctx context.Context, policyID uint, from, to time.Time) ([]*fleet.PolicyDailyCount, error) {
+		gotFrom, gotTo = from, to
+		return []*fleet.PolicyDailyCount{{Date: "2022-10-19", PassingHostCount: 1}}, nil
+	}
+
+	counts, err := svc.GetPolicyDailyCounts(ctx, 1, 7)
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	require.Equal(t, gotTo.AddDate(0, 0, -6), gotFrom)
+
+	for _, days := range []int{0, -1, 366} {
+		_, err = svc.GetPolicyDailyCounts(ctx, 1, days)
+		var invalidErr *fleet.InvalidArgumentError
+		require.ErrorAs(t, err, &invalidErr)
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fleetdm/fleet/v4/server/authz"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Create policy waiver
+////////////////////////////////////////////////////////////////////////////////
+
+type createPolicyWaiverRequest struct {
+	PolicyID uint `url:"policy_id"`
+	fleet.PolicyWaiverPayload
+}
+
+type createPolicyWaiverResponse struct {
+	Waiver *fleet.PolicyWaiver `json:"waiver,omitempty"`
+	Err    error               `json:"error,omitempty"`
+}
+
+func (r createPolicyWaiverResponse) error() error { return r.Err }
+
+func createPolicyWaiverEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*createPolicyWaiverRequest)
+	waiver, err := svc.NewPolicyWaiver(ctx, req.PolicyID, req.PolicyWaiverPayload)
+	if err != nil {
+		return createPolicyWaiverResponse{Err: err}, nil
+	}
+	return createPolicyWaiverResponse{Waiver: waiver}, nil
+}
+
+func (svc *Service) NewPolicyWaiver(ctx context.Context, policyID uint, p fleet.PolicyWaiverPayload) (*fleet.PolicyWaiver, error) {
+	policy, err := svc.authorizePolicyWaivers(ctx, policyID, fleet.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, fleet.ErrNoContext
+	}
+
+	if err := p.Verify(svc.clock.Now()); err != nil {
+		return nil, ctxerr.Wrap(ctx, &fleet.BadRequestError{
+			Message: fmt.Sprintf("policy waiver payload verification: %s", err),
+		})
+	}
+
+	waiver, err := svc.ds.NewPolicyWaiver(ctx, &fleet.PolicyWaiver{
+		PolicyID:  policy.ID,
+		HostIDs:   p.HostIDs,
+		LabelID:   p.LabelID,
+		Reason:    p.Reason,
+		Approver:  p.Approver,
+		ExpiresAt: p.ExpiresAt,
+		AuthorID:  ptr.Uint(vc.UserID()),
+	})
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "create policy waiver")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeCreatedPolicyWaiver,
+		&map[string]interface{}{"policy_id": policy.ID, "policy_name": policy.Name, "waiver_id": waiver.ID},
+	); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "create activity for policy waiver creation")
+	}
+	return waiver, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List policy waivers
+////////////////////////////////////////////////////////////////////////////////
+
+type listPolicyWaiversRequest struct {
+	PolicyID uint `url:"policy_id"`
+}
+
+type listPolicyWaiversResponse struct {
+	Waivers []*fleet.PolicyWaiver `json:"waivers"`
+	Err     error                 `json:"error,omitempty"`
+}
+
+func (r listPolicyWaiversResponse) error() error { return r.Err }
+
+func listPolicyWaiversEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listPolicyWaiversRequest)
+	waivers, err := svc.ListPolicyWaivers(ctx, req.PolicyID)
+	if err != nil {
+		return listPolicyWaiversResponse{Err: err}, nil
+	}
+	if waivers == nil {
+		waivers = []*fleet.PolicyWaiver{}
+	}
+	return listPolicyWaiversResponse{Waivers: waivers}, nil
+}
+
+func (svc *Service) ListPolicyWaivers(ctx context.Context, policyID uint) ([]*fleet.PolicyWaiver, error) {
+	if _, err := svc.authorizePolicyWaivers(ctx, policyID, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+	return svc.ds.ListPolicyWaivers(ctx, policyID)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete policy waiver
+////////////////////////////////////////////////////////////////////////////////
+
+type deletePolicyWaiverRequest struct {
+	PolicyID uint `url:"policy_id"`
+	WaiverID uint `url:"waiver_id"`
+}
+
+type deletePolicyWaiverResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deletePolicyWaiverResponse) error() error { return r.Err }
+
+func deletePolicyWaiverEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*deletePolicyWaiverRequest)
+	if err := svc.DeletePolicyWaiver(ctx, req.PolicyID, req.WaiverID); err != nil {
+		return deletePolicyWaiverResponse{Err: err}, nil
+	}
+	return deletePolicyWaiverResponse{}, nil
+}
+
+func (svc *Service) DeletePolicyWaiver(ctx context.Context, policyID, waiverID uint) error {
+	policy, err := svc.authorizePolicyWaivers(ctx, policyID, fleet.ActionWrite)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.ds.DeletePolicyWaiver(ctx, policyID, waiverID); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete policy waiver")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeDeletedPolicyWaiver,
+		&map[string]interface{}{"policy_id": policy.ID, "policy_name": policy.Name, "waiver_id": waiverID},
+	); err != nil {
+		return ctxerr.Wrap(ctx, err, "create activity for policy waiver deletion")
+	}
+	return nil
+}
+
+// authorizePolicyWaivers checks that the user can perform the action on the
+// policy, which is what is required to manage its waivers, and returns the
+// policy.
+func (svc *Service) authorizePolicyWaivers(ctx context.Context, policyID uint, action string) (*fleet.Policy, error) {
+	// First make sure the user can read the policies.
+	if err := svc.authz.Authorize(ctx, &fleet.Policy{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+	policy, err := svc.ds.Policy(ctx, policyID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get policy for waivers")
+	}
+	if err := svc.authz.Authorize(ctx, policy, action); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyWaiversAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.PolicyFunc = func(ctx context.Context, id uint) (*fleet.Policy, error) {
+		if id == 1 {
+			return &fleet.Policy{PolicyData: fleet.PolicyData{ID: id, TeamID: ptr.Uint(1)}}, nil
+		}
+		return &fleet.Policy{PolicyData: fleet.PolicyData{ID: id}}, nil
+	}
+	ds.NewPolicyWaiverFunc = func(ctx context.Context, waiver *fleet.PolicyWaiver) (*fleet.PolicyWaiver, error) {
+		return waiver, nil
+	}
+	ds.ListPolicyWaiversFunc = func(ctx context.Context, policyID uint) ([]*fleet.PolicyWaiver, error) {
+		return nil, nil
+	}
+	ds.DeletePolicyWaiverFunc = func(ctx context.Context, policyID, waiverID uint) error {
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		return nil
+	}
+
+	payload := fleet.PolicyWaiverPayload{
+		HostIDs:   []uint{1},
+		Reason:    "build server",
+		Approver:  "alice",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name            string
+		user            *fleet.User
+		shouldFailWrite bool
+		shouldFailRead  bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, false, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, true, false},
+		{"team admin, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, false, false},
+		{"team maintainer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}}, false, false},
+		{"team observer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleObserver}}}, true, false},
+		{"team admin, different team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.NewPolicyWaiver(ctx, 1, payload)
+			checkAuthErr(t, tt.shouldFailWrite, err)
+			_, err = svc.ListPolicyWaivers(ctx, 1)
+			checkAuthErr(t, tt.shouldFailRead, err)
+			err = svc.DeletePolicyWaiver(ctx, 1, 1)
+			checkAuthErr(t, tt.shouldFailWrite, err)
+
+			// only global admins and maintainers can manage the waivers of the
+			// global policies
+			globalWrite := tt.user.GlobalRole == nil || *tt.user.GlobalRole == fleet.RoleObserver
+			_, err = svc.NewPolicyWaiver(ctx, 2, payload)
+			checkAuthErr(t, globalWrite, err)
+			_, err = svc.ListPolicyWaivers(ctx, 2)
+			checkAuthErr(t, false, err)
+		})
+	}
+}
+
+func TestNewPolicyWaiver(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.PolicyFunc = func(ctx context.Context, id uint) (*fleet.Policy, error) {
+		return &fleet.Policy{PolicyData: fleet.PolicyData{ID: id, Name: "disk encryption"}}, nil
+	}
+	ds.NewPolicyWaiverFunc = func(ctx context.Context, waiver *fleet.PolicyWaiver) (*fleet.PolicyWaiver, error) {
+		waiver.ID = 5
+		return waiver, nil
+	}
+	var activityDetails map[string]interface{}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.Equal(t, fleet.ActivityTypeCreatedPolicyWaiver, activityType)
+		activityDetails = *details
+		return nil
+	}
+
+	labelID := uint(3)
+	waiver, err := svc.NewPolicyWaiver(ctx, 1, fleet.PolicyWaiverPayload{
+		LabelID:   &labelID,
+		Reason:    "build servers",
+		Approver:  "alice",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint(1), waiver.PolicyID)
+	require.Equal(t, labelID, *waiver.LabelID)
+	require.Equal(t, test.UserAdmin.ID, *waiver.AuthorID)
+	require.True(t, ds.NewPolicyWaiverFuncInvoked)
+	require.Equal(t, map[string]interface{}{"policy_id": uint(1), "policy_name": "disk encryption", "waiver_id": uint(5)}, activityDetails)
+
+	ds.NewPolicyWaiverFuncInvoked = false
+	_, err = svc.NewPolicyWaiver(ctx, 1, fleet.PolicyWaiverPayload{
+		HostIDs:   []uint{1},
+		Reason:    "build servers",
+		Approver:  "alice",
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+	var badRequestErr *fleet.BadRequestError
+	require.ErrorAs(t, err, &badRequestErr)
+	require.False(t, ds.NewPolicyWaiverFuncInvoked)
+}
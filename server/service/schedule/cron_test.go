@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{
+		"* * * * *",
+		"*/15 * * * *",
+		"0 9-17 * * mon-fri",
+		"0,30 1 1,15 jan,jul *",
+		"5-55/10 */2 * * 7",
+		"@daily",
+		" @Hourly ",
+	} {
+		_, err := ParseCron(expr)
+		require.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@every",
+	} {
+		_, err := ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	start := time.Date(2022, 10, 12, 14, 7, 30, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2022, 10, 12, 14, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2022, 10, 12, 14, 15, 0, 0, time.UTC)},
+		{"7 14 * * *", time.Date(2022, 10, 13, 14, 7, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2022, 10, 12, 15, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2022, 10, 13, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2022, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2022, 10, 13, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2022, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 8 29 2 *", time.Date(2024, 2, 29, 8, 30, 0, 0, time.UTC)},
+		// day of month or day of week when both are restricted
+		{"0 0 20 * fri", time.Date(2022, 10, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * sun", time.Date(2022, 10, 13, 0, 0, 0, 0, time.UTC)},
+		// never matches
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			cron, err := ParseCron(c.expr)
+			require.NoError(t, err)
+			require.Equal(t, c.want, cron.Next(start))
+		})
+	}
+
+	// the next time is strictly after the provided time
+	cron, err := ParseCron("0 * * * *")
+	require.NoError(t, err)
+	hour := time.Date(2022, 10, 12, 14, 0, 0, 0, time.UTC)
+	require.Equal(t, hour.Add(time.Hour), cron.Next(hour))
+}
@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/service/schedule"
+	"github.com/fleetdm/fleet/v4/server/worker"
+	"github.com/go-kit/kit/log/level"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Create scheduled live query
+////////////////////////////////////////////////////////////////////////////////
+
+type createScheduledLiveQueryRequest struct {
+	fleet.ScheduledLiveQueryPayload
+}
+
+type scheduledLiveQueryResponse struct {
+	ScheduledLiveQuery *fleet.ScheduledLiveQuery `json:"scheduled_live_query,omitempty"`
+	Err                error                     `json:"error,omitempty"`
+}
+
+func (r scheduledLiveQueryResponse) error() error { return r.Err }
+
+func createScheduledLiveQueryEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*createScheduledLiveQueryRequest)
+	query, err := svc.NewScheduledLiveQuery(ctx, req.ScheduledLiveQueryPayload)
+	if err != nil {
+		return scheduledLiveQueryResponse{Err: err}, nil
+	}
+	return scheduledLiveQueryResponse{ScheduledLiveQuery: query}, nil
+}
+
+func (svc *Service) NewScheduledLiveQuery(ctx context.Context, p fleet.ScheduledLiveQueryPayload) (*fleet.ScheduledLiveQuery, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ScheduledLiveQuery{}, fleet.ActionWrite); err != nil {
+		return nil, err
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, fleet.ErrNoContext
+	}
+
+	query := &fleet.ScheduledLiveQuery{
+		ResultsWindow: uint(fleet.ScheduledLiveQueryDefaultResultsWindow.Seconds()),
+		Enabled:       true,
+		AuthorID:      &vc.User.ID,
+	}
+	if err := svc.applyScheduledLiveQueryPayload(ctx, query, p, time.Now()); err != nil {
+		return nil, err
+	}
+	return svc.ds.NewScheduledLiveQuery(ctx, query)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List scheduled live queries
+////////////////////////////////////////////////////////////////////////////////
+
+type listScheduledLiveQueriesRequest struct {
+	ListOptions fleet.ListOptions `url:"list_options"`
+}
+
+type listScheduledLiveQueriesResponse struct {
+	ScheduledLiveQueries []*fleet.ScheduledLiveQuery `json:"scheduled_live_queries"`
+	Err                  error                       `json:"error,omitempty"`
+}
+
+func (r listScheduledLiveQueriesResponse) error() error { return r.Err }
+
+func listScheduledLiveQueriesEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listScheduledLiveQueriesRequest)
+	queries, err := svc.ListScheduledLiveQueries(ctx, req.ListOptions)
+	if err != nil {
+		return listScheduledLiveQueriesResponse{Err: err}, nil
+	}
+	return listScheduledLiveQueriesResponse{ScheduledLiveQueries: queries}, nil
+}
+
+func (svc *Service) ListScheduledLiveQueries(ctx context.Context, opt fleet.ListOptions) ([]*fleet.ScheduledLiveQuery, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ScheduledLiveQuery{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+	return svc.ds.ListScheduledLiveQueries(ctx, opt)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Get scheduled live query
+////////////////////////////////////////////////////////////////////////////////
+
+type getScheduledLiveQueryRequest struct {
+	ID uint `url:"id"`
+}
+
+func getScheduledLiveQueryEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*getScheduledLiveQueryRequest)
+	query, err := svc.GetScheduledLiveQuery(ctx, req.ID)
+	if err != nil {
+		return scheduledLiveQueryResponse{Err: err}, nil
+	}
+	return scheduledLiveQueryResponse{ScheduledLiveQuery: query}, nil
+}
+
+func (svc *Service) GetScheduledLiveQuery(ctx context.Context, id uint) (*fleet.ScheduledLiveQuery, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ScheduledLiveQuery{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+	return svc.ds.ScheduledLiveQuery(ctx, id)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Modify scheduled live query
+////////////////////////////////////////////////////////////////////////////////
+
+type modifyScheduledLiveQueryRequest struct {
+	ID uint `url:"id"`
+	fleet.ScheduledLiveQueryPayload
+}
+
+func modifyScheduledLiveQueryEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*modifyScheduledLiveQueryRequest)
+	query, err := svc.ModifyScheduledLiveQuery(ctx, req.ID, req.ScheduledLiveQueryPayload)
+	if err != nil {
+		return scheduledLiveQueryResponse{Err: err}, nil
+	}
+	return scheduledLiveQueryResponse{ScheduledLiveQuery: query}, nil
+}
+
+func (svc *Service) ModifyScheduledLiveQuery(ctx context.Context, id uint, p fleet.ScheduledLiveQueryPayload) (*fleet.ScheduledLiveQuery, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.ScheduledLiveQuery{}, fleet.ActionWrite); err != nil {
+		return nil, err
+	}
+
+	query, err := svc.ds.ScheduledLiveQuery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.applyScheduledLiveQueryPayload(ctx, query, p, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := svc.ds.SaveScheduledLiveQuery(ctx, query); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete scheduled live query
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteScheduledLiveQueryRequest struct {
+	ID uint `url:"id"`
+}
+
+type deleteScheduledLiveQueryResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteScheduledLiveQueryResponse) error() error { return r.Err }
+
+func deleteScheduledLiveQueryEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*deleteScheduledLiveQueryRequest)
+	if err := svc.DeleteScheduledLiveQuery(ctx, req.ID); err != nil {
+		return deleteScheduledLiveQueryResponse{Err: err}, nil
+	}
+	return deleteScheduledLiveQueryResponse{}, nil
+}
+
+func (svc *Service) DeleteScheduledLiveQuery(ctx context.Context, id uint) error {
+	if err := svc.authz.Authorize(ctx, &fleet.ScheduledLiveQuery{}, fleet.ActionWrite); err != nil {
+		return err
+	}
+	return svc.ds.DeleteScheduledLiveQuery(ctx, id)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Run due scheduled live queries
+////////////////////////////////////////////////////////////////////////////////
+
+func (svc *Service) RunDueScheduledLiveQueries(ctx context.Context, now time.Time) error {
+	// called by a cron job, there is no user to authorize
+	svc.authz.SkipAuthorization(ctx)
+
+	queries, err := svc.ds.ListDueScheduledLiveQueries(ctx, now)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list due scheduled live queries")
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	appConfig, err := svc.ds.AppConfig(ctx)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "retrieve app config")
+	}
+
+	for _, query := range queries {
+		// the runs that are due while live queries are disabled are skipped,
+		// they are not all run when live queries are enabled again.
+		if appConfig.ServerSettings.LiveQueryDisabled {
+			level.Info(svc.logger).Log("msg", "live queries disabled, skipping scheduled live query", "scheduled_live_query_id", query.ID)
+		} else {
+			campaign, err := svc.runScheduledLiveQuery(ctx, query, now)
+			if err != nil {
+				level.Error(svc.logger).Log("msg", "run scheduled live query", "scheduled_live_query_id", query.ID, "err", err)
+			} else {
+				query.LastCampaignID = &campaign.ID
+			}
+		}
+
+		query.LastRunAt = &now
+		query.NextRunAt = scheduledLiveQueryNextRun(query, now)
+		if err := svc.ds.SaveScheduledLiveQuery(ctx, query); err != nil {
+			return ctxerr.Wrap(ctx, err, "save scheduled live query")
+		}
+	}
+	return nil
+}
+
+// runScheduledLiveQuery starts the campaign of the scheduled live query on the
+// targeted hosts visible by its author. The results of the campaign are stored
+// as they are received until the end of the results window, see
+// storeScheduledLiveQueryResult.
+func (svc *Service) runScheduledLiveQuery(ctx context.Context, sq *fleet.ScheduledLiveQuery, now time.Time) (*fleet.DistributedQueryCampaign, error) {
+	if sq.AuthorID == nil {
+		return nil, ctxerr.New(ctx, "scheduled live query has no author")
+	}
+	author, err := svc.ds.UserByID(ctx, *sq.AuthorID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get scheduled live query author")
+	}
+	query, err := svc.ds.Query(ctx, sq.QueryID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get query")
+	}
+
+	filter := fleet.TeamFilter{User: author, IncludeObserver: query.ObserverCanRun}
+	hostIDs, err := svc.ds.HostIDsInTargets(ctx, filter, sq.Targets)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get target IDs")
+	}
+	if len(hostIDs) == 0 {
+		return nil, ctxerr.New(ctx, "no hosts targeted")
+	}
+
+	campaign, err := svc.ds.NewDistributedQueryCampaign(ctx, &fleet.DistributedQueryCampaign{
+		QueryID: query.ID,
+		Status:  fleet.QueryRunning,
+		UserID:  author.ID,
+	})
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "new campaign")
+	}
+	if err := svc.newDistributedQueryCampaignTargets(ctx, campaign.ID, sq.Targets); err != nil {
+		return nil, err
+	}
+
+	// the run must be recorded before the query is sent to the hosts so that
+	// no result is missed.
+	if err := svc.ds.NewScheduledLiveQueryRun(ctx, &fleet.ScheduledLiveQueryRun{
+		CampaignID:           campaign.ID,
+		ScheduledLiveQueryID: sq.ID,
+		EndsAt:               now.Add(time.Duration(sq.ResultsWindow) * time.Second),
+	}); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "new scheduled live query run")
+	}
+	if err := svc.liveQueryStore.RunQuery(strconv.Itoa(int(campaign.ID)), query.Query, hostIDs); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "run query")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		author,
+		fleet.ActivityTypeLiveQuery,
+		&map[string]interface{}{"targets_count": len(hostIDs), "campaign_id": campaign.ID, "scheduled_live_query_id": sq.ID},
+	); err != nil {
+		level.Error(svc.logger).Log("msg", "create scheduled live query activity", "scheduled_live_query_id", sq.ID, "err", err)
+	}
+	return campaign, nil
+}
+
+// storeScheduledLiveQueryResult stores the result if its campaign is the
+// campaign of a scheduled live query run, as nobody subscribes to the results
+// of those campaigns. It returns false if the campaign is not the campaign of
+// a run. The results received after the end of the results window are
+// dropped.
+func (svc *Service) storeScheduledLiveQueryResult(ctx context.Context, res fleet.DistributedQueryResult) (bool, error) {
+	run, err := svc.ds.ScheduledLiveQueryRunByCampaign(ctx, res.DistributedQueryCampaignID)
+	if err != nil {
+		if fleet.IsNotFound(err) {
+			return false, nil
+		}
+		return false, ctxerr.Wrap(ctx, err, "get scheduled live query run")
+	}
+	if svc.clock.Now().After(run.EndsAt) {
+		return true, nil
+	}
+
+	rows := res.Rows
+	if maxRows := svc.config.Osquery.LiveQueryResultsMaxRows; maxRows > 0 && len(rows) > maxRows {
+		rows = rows[:maxRows]
+	}
+	if err := svc.ds.NewDistributedQueryCampaignResult(ctx, &fleet.DistributedQueryCampaignResult{
+		DistributedQueryCampaignID: res.DistributedQueryCampaignID,
+		HostID:                     res.Host.ID,
+		Hostname:                   res.Host.Hostname,
+		HostDisplayName:            res.Host.DisplayName,
+		Rows:                       rows,
+		Error:                      res.Error,
+	}); err != nil {
+		return false, ctxerr.Wrap(ctx, err, "store scheduled live query result")
+	}
+	return true, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Complete ended scheduled live query runs
+////////////////////////////////////////////////////////////////////////////////
+
+func (svc *Service) CompleteEndedScheduledLiveQueryRuns(ctx context.Context, now time.Time) error {
+	// called by a cron job, there is no user to authorize
+	svc.authz.SkipAuthorization(ctx)
+
+	runs, err := svc.ds.ListEndedScheduledLiveQueryRuns(ctx, now)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list ended scheduled live query runs")
+	}
+
+	// the runs are completed by whichever Fleet instance runs the cron job, so
+	// the runs started by an instance that stopped are completed too.
+	for _, run := range runs {
+		campaign, err := svc.ds.DistributedQueryCampaign(ctx, run.CampaignID)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "get scheduled live query campaign")
+		}
+		if err := svc.CompleteCampaign(ctx, campaign); err != nil {
+			return ctxerr.Wrap(ctx, err, "complete scheduled live query campaign")
+		}
+
+		sq, err := svc.ds.ScheduledLiveQuery(ctx, run.ScheduledLiveQueryID)
+		switch {
+		case err == nil:
+			if sq.WebhookURL != "" {
+				if err := worker.QueueScheduledLiveQueryWebhookJob(ctx, svc.ds, sq.ID, campaign.ID); err != nil {
+					return ctxerr.Wrap(ctx, err, "queue scheduled live query webhook")
+				}
+			}
+		case !fleet.IsNotFound(err):
+			return ctxerr.Wrap(ctx, err, "get scheduled live query")
+		}
+
+		if err := svc.ds.DeleteScheduledLiveQueryRun(ctx, run.CampaignID); err != nil {
+			return ctxerr.Wrap(ctx, err, "delete scheduled live query run")
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////////////
+
+// applyScheduledLiveQueryPayload validates and applies the non-nil fields of
+// the payload to the scheduled live query, and computes its next run time.
+func (svc *Service) applyScheduledLiveQueryPayload(ctx context.Context, sq *fleet.ScheduledLiveQuery, p fleet.ScheduledLiveQueryPayload, now time.Time) error {
+	invalid := &fleet.InvalidArgumentError{}
+
+	if p.Name != nil {
+		sq.Name = strings.TrimSpace(*p.Name)
+	}
+	if p.QueryID != nil {
+		if _, err := svc.ds.Query(ctx, *p.QueryID); err != nil {
+			if fleet.IsNotFound(err) {
+				return fleet.NewInvalidArgumentError("query_id", "query does not exist")
+			}
+			return ctxerr.Wrap(ctx, err, "get query")
+		}
+		sq.QueryID = *p.QueryID
+	}
+	if p.Targets != nil {
+		sq.Targets = *p.Targets
+	}
+	if p.RunAt != nil && p.Cron != nil && *p.Cron != "" {
+		invalid.Append("cron", "cannot be set with run_at")
+	}
+	if p.RunAt != nil {
+		runAt := p.RunAt.UTC()
+		sq.RunAt = &runAt
+		sq.Cron = ""
+	}
+	if p.Cron != nil {
+		sq.Cron = strings.TrimSpace(*p.Cron)
+		if sq.Cron != "" {
+			sq.RunAt = nil
+		}
+	}
+	if p.ResultsWindow != nil {
+		sq.ResultsWindow = *p.ResultsWindow
+		if sq.ResultsWindow == 0 {
+			sq.ResultsWindow = uint(fleet.ScheduledLiveQueryDefaultResultsWindow.Seconds())
+		}
+	}
+	if p.WebhookURL != nil {
+		sq.WebhookURL = strings.TrimSpace(*p.WebhookURL)
+	}
+	if p.Enabled != nil {
+		sq.Enabled = *p.Enabled
+	}
+
+	if sq.Name == "" {
+		invalid.Append("name", "cannot be empty")
+	}
+	if sq.QueryID == 0 {
+		invalid.Append("query_id", "is required")
+	}
+	if len(sq.Targets.HostIDs) == 0 && len(sq.Targets.LabelIDs) == 0 && len(sq.Targets.TeamIDs) == 0 {
+		invalid.Append("targets", "at least one host, label or team must be targeted")
+	}
+	if sq.RunAt == nil && sq.Cron == "" {
+		invalid.Append("run_at", "one of run_at or cron is required")
+	}
+	if sq.Cron != "" {
+		if _, err := schedule.ParseCron(sq.Cron); err != nil {
+			invalid.Append("cron", err.Error())
+		}
+	}
+	if sq.ResultsWindow > uint(fleet.ScheduledLiveQueryMaxResultsWindow.Seconds()) {
+		invalid.Append("results_window", "cannot be more than "+strconv.Itoa(int(fleet.ScheduledLiveQueryMaxResultsWindow.Seconds()))+" seconds")
+	}
+	if sq.WebhookURL != "" {
+		if u, err := url.Parse(sq.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid.Append("webhook_url", "must be a valid http or https URL")
+		}
+	}
+	if invalid.HasErrors() {
+		return ctxerr.Wrap(ctx, invalid)
+	}
+
+	sq.NextRunAt = scheduledLiveQueryNextRun(sq, now)
+	return nil
+}
+
+// scheduledLiveQueryNextRun returns the next run time of the scheduled live
+// query after now, or nil if it will not run anymore. A one-shot scheduled
+// live query runs only once unless its run time is changed.
+func scheduledLiveQueryNextRun(sq *fleet.ScheduledLiveQuery, now time.Time) *time.Time {
+	if sq.Cron == "" {
+		if sq.RunAt == nil || (sq.LastRunAt != nil && !sq.RunAt.After(*sq.LastRunAt)) {
+			return nil
+		}
+		return sq.RunAt
+	}
+
+	cron, err := schedule.ParseCron(sq.Cron)
+	if err != nil {
+		return nil
+	}
+	next := cron.Next(now.UTC())
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
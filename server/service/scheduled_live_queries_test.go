@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/config"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledLiveQueriesAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.QueryFunc = func(ctx context.Context, id uint) (*fleet.Query, error) {
+		return &fleet.Query{ID: id}, nil
+	}
+	ds.ListScheduledLiveQueriesFunc = func(ctx context.Context, opt fleet.ListOptions) ([]*fleet.ScheduledLiveQuery, error) {
+		return nil, nil
+	}
+	ds.ScheduledLiveQueryFunc = func(ctx context.Context, id uint) (*fleet.ScheduledLiveQuery, error) {
+		return &fleet.ScheduledLiveQuery{ID: id, Name: "s", QueryID: 1, Targets: fleet.HostTargets{HostIDs: []uint{1}}, Cron: "@daily"}, nil
+	}
+	ds.NewScheduledLiveQueryFunc = func(ctx context.Context, query *fleet.ScheduledLiveQuery) (*fleet.ScheduledLiveQuery, error) {
+		return query, nil
+	}
+	ds.SaveScheduledLiveQueryFunc = func(ctx context.Context, query *fleet.ScheduledLiveQuery) error {
+		return nil
+	}
+	ds.DeleteScheduledLiveQueryFunc = func(ctx context.Context, id uint) error {
+		return nil
+	}
+
+	testCases := []struct {
+		name       string
+		user       *fleet.User
+		shouldFail bool
+	}{
+		{"global admin", &fleet.User{ID: 1, GlobalRole: ptr.String(fleet.RoleAdmin)}, false},
+		{"global maintainer", &fleet.User{ID: 1, GlobalRole: ptr.String(fleet.RoleMaintainer)}, false},
+		{"global observer", &fleet.User{ID: 1, GlobalRole: ptr.String(fleet.RoleObserver)}, true},
+		{"team maintainer", &fleet.User{ID: 1, Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}}, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.ListScheduledLiveQueries(ctx, fleet.ListOptions{})
+			checkAuthErr(t, tt.shouldFail, err)
+			_, err = svc.GetScheduledLiveQuery(ctx, 1)
+			checkAuthErr(t, tt.shouldFail, err)
+			_, err = svc.NewScheduledLiveQuery(ctx, fleet.ScheduledLiveQueryPayload{
+				Name:    ptr.String("s"),
+				QueryID: ptr.Uint(1),
+				Targets: &fleet.HostTargets{LabelIDs: []uint{1}},
+				Cron:    ptr.String("0 * * * *"),
+			})
+			checkAuthErr(t, tt.shouldFail, err)
+			_, err = svc.ModifyScheduledLiveQuery(ctx, 1, fleet.ScheduledLiveQueryPayload{Enabled: ptr.Bool(false)})
+			checkAuthErr(t, tt.shouldFail, err)
+			err = svc.DeleteScheduledLiveQuery(ctx, 1)
+			checkAuthErr(t, tt.shouldFail, err)
+		})
+	}
+}
+
+func TestScheduledLiveQueriesPayload(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	user := &fleet.User{ID: 3, GlobalRole: ptr.String(fleet.RoleAdmin)}
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: user})
+
+	ds.QueryFunc = func(ctx context.Context, id uint) (*fleet.Query, error) {
+		if id != 1 {
+			return nil, notFoundError{}
+		}
+		return &fleet.Query{ID: id}, nil
+	}
+	ds.NewScheduledLiveQueryFunc = func(ctx context.Context, query *fleet.ScheduledLiveQuery) (*fleet.ScheduledLiveQuery, error) {
+		q := *query
+		q.ID = 1
+		return &q, nil
+	}
+	runAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	stored := &fleet.ScheduledLiveQuery{
+		ID: 1, Name: "s", QueryID: 1, Targets: fleet.HostTargets{HostIDs: []uint{1}},
+		RunAt: &runAt, ResultsWindow: 300, Enabled: true, NextRunAt: &runAt,
+	}
+	ds.ScheduledLiveQueryFunc = func(ctx context.Context, id uint) (*fleet.ScheduledLiveQuery, error) {
+		q := *stored
+		return &q, nil
+	}
+	ds.SaveScheduledLiveQueryFunc = func(ctx context.Context, query *fleet.ScheduledLiveQuery) error {
+		return nil
+	}
+
+	targets := &fleet.HostTargets{TeamIDs: []uint{1}}
+	invalid := []struct {
+		name    string
+		payload fleet.ScheduledLiveQueryPayload
+		errMsg  string
+	}{
+		{"no name", fleet.ScheduledLiveQueryPayload{QueryID: ptr.Uint(1), Targets: targets, Cron: ptr.String("@daily")}, "name"},
+		{"no query", fleet.ScheduledLiveQueryPayload{Name: ptr.String("s"), Targets: targets, Cron: ptr.String("@daily")}, "query_id"},
+		{"unknown query", fleet.ScheduledLiveQueryPayload{Name: ptr.String("s"), QueryID: ptr.Uint(2), Targets: targets, Cron: ptr.String("@daily")}, "query_id"},
+		{"no targets", fleet.ScheduledLiveQueryPayload{Name: ptr.String("s"), QueryID: ptr.Uint(1), Cron: ptr.String("@daily")}, "targets"},
+		{"no schedule", fleet.ScheduledLiveQueryPayload{Name: ptr.String("s"), QueryID: ptr.Uint(1), Targets: targets}, "run_at"},
+		{"run_at and cron", fleet.ScheduledLiveQueryPayload{Name: ptr.String("s"), QueryID: ptr.Uint(1), Targets: targets, Cron: ptr.String("@daily"), RunAt: &runAt}, "cron"},
+		{"bad cron", fleet.ScheduledLiveQueryPayload{Name: ptr.String("s"), QueryID: ptr.Uint(1), Targets: targets, Cron: ptr.String("0 25 * * *")}, "cron"},
+		{"window too long", fleet.ScheduledLiveQueryPayload{Name: ptr.String("s"), QueryID: ptr.Uint(1), Targets: targets, Cron: ptr.String("@daily"), ResultsWindow: ptr.Uint(7200)}, "results_window"},
+		{"bad webhook", fleet.ScheduledLiveQueryPayload{Name: ptr.String("s"), QueryID: ptr.Uint(1), Targets: targets, Cron: ptr.String("@daily"), WebhookURL: ptr.String("ftp://example.com")}, "webhook_url"},
+	}
+	for _, c := range invalid {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := svc.NewScheduledLiveQuery(ctx, c.payload)
+			var invalidErr *fleet.InvalidArgumentError
+			require.ErrorAs(t, err, &invalidErr)
+			require.Contains(t, err.Error(), c.errMsg)
+		})
+	}
+
+	query, err := svc.NewScheduledLiveQuery(ctx, fleet.ScheduledLiveQueryPayload{
+		Name:       ptr.String(" hourly "),
+		QueryID:    ptr.Uint(1),
+		Targets:    targets,
+		Cron:       ptr.String("0 * * * *"),
+		WebhookURL: ptr.String("https://example.com/results"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hourly", query.Name)
+	assert.True(t, query.Enabled)
+	assert.Equal(t, uint(300), query.ResultsWindow)
+	assert.Equal(t, user.ID, *query.AuthorID)
+	require.NotNil(t, query.NextRunAt)
+	assert.Zero(t, query.NextRunAt.Minute())
+	assert.True(t, query.NextRunAt.After(time.Now()))
+
+	// switching a one-shot scheduled live query to a cron schedule
+	query, err = svc.ModifyScheduledLiveQuery(ctx, 1, fleet.ScheduledLiveQueryPayload{Cron: ptr.String("30 2 * * *"), ResultsWindow: ptr.Uint(0)})
+	require.NoError(t, err)
+	assert.Nil(t, query.RunAt)
+	assert.Equal(t, "30 2 * * *", query.Cron)
+	assert.Equal(t, uint(300), query.ResultsWindow)
+	require.NotNil(t, query.NextRunAt)
+	assert.Equal(t, 2, query.NextRunAt.Hour())
+	assert.Equal(t, 30, query.NextRunAt.Minute())
+}
+
+func TestScheduledLiveQueryNextRun(t *testing.T) {
+	now := time.Date(2022, 10, 14, 10, 15, 0, 0, time.UTC)
+	runAt := now.Add(-time.Minute)
+
+	// one-shot, not run yet
+	next := scheduledLiveQueryNextRun(&fleet.ScheduledLiveQuery{RunAt: &runAt}, now)
+	require.NotNil(t, next)
+	assert.Equal(t, runAt, *next)
+
+	// one-shot, already run
+	next = scheduledLiveQueryNextRun(&fleet.ScheduledLiveQuery{RunAt: &runAt, LastRunAt: &now}, now)
+	assert.Nil(t, next)
+
+	// one-shot, run time changed after the last run
+	later := now.Add(time.Hour)
+	next = scheduledLiveQueryNextRun(&fleet.ScheduledLiveQuery{RunAt: &later, LastRunAt: &now}, now)
+	require.NotNil(t, next)
+	assert.Equal(t, later, *next)
+
+	// recurring
+	next = scheduledLiveQueryNextRun(&fleet.ScheduledLiveQuery{Cron: "*/30 * * * *", LastRunAt: &now}, now)
+	require.NotNil(t, next)
+	assert.Equal(t, time.Date(2022, 10, 14, 10, 30, 0, 0, time.UTC), *next)
+
+	// recurring, never matches
+	next = scheduledLiveQueryNextRun(&fleet.ScheduledLiveQuery{Cron: "0 0 30 2 *"}, now)
+	assert.Nil(t, next)
+}
+
+type recordingLiveQuery struct {
+	nopLiveQuery
+	mu      sync.Mutex
+	queries map[string][]uint
+}
+
+func (r *recordingLiveQuery) RunQuery(name, sql string, hostIDs []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries[name] = hostIDs
+	return nil
+}
+
+func TestRunDueScheduledLiveQueries(t *testing.T) {
+	ds := new(mock.Store)
+	rs := pubsub.NewInmemQueryResults()
+	lq := &recordingLiveQuery{queries: make(map[string][]uint)}
+	svc := newTestServiceWithConfig(t, ds, config.TestConfig(), rs, lq)
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	author := &fleet.User{ID: 1, GlobalRole: ptr.String(fleet.RoleMaintainer)}
+	due := []*fleet.ScheduledLiveQuery{
+		{
+			ID: 1, Name: "hourly", QueryID: 2, Targets: fleet.HostTargets{LabelIDs: []uint{3}}, Cron: "0 * * * *",
+			ResultsWindow: 1, WebhookURL: "https://example.com", Enabled: true, AuthorID: &author.ID, NextRunAt: &now,
+		},
+		{
+			ID: 2, Name: "no author", QueryID: 2, Targets: fleet.HostTargets{LabelIDs: []uint{3}}, RunAt: &now,
+			ResultsWindow: 1, Enabled: true, NextRunAt: &now,
+		},
+	}
+
+	var liveQueryDisabled bool
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{ServerSettings: fleet.ServerSettings{LiveQueryDisabled: liveQueryDisabled}}, nil
+	}
+	ds.ListDueScheduledLiveQueriesFunc = func(ctx context.Context, now time.Time) ([]*fleet.ScheduledLiveQuery, error) {
+		return due, nil
+	}
+	ds.UserByIDFunc = func(ctx context.Context, id uint) (*fleet.User, error) {
+		return author, nil
+	}
+	ds.QueryFunc = func(ctx context.Context, id uint) (*fleet.Query, error) {
+		return &fleet.Query{ID: id, Query: "select 1"}, nil
+	}
+	ds.HostIDsInTargetsFunc = func(ctx context.Context, filter fleet.TeamFilter, targets fleet.HostTargets) ([]uint, error) {
+		require.Equal(t, author, filter.User)
+		return []uint{4, 5}, nil
+	}
+	ds.NewDistributedQueryCampaignFunc = func(ctx context.Context, camp *fleet.DistributedQueryCampaign) (*fleet.DistributedQueryCampaign, error) {
+		camp.ID = 10
+		return camp, nil
+	}
+	ds.NewDistributedQueryCampaignTargetFunc = func(ctx context.Context, target *fleet.DistributedQueryCampaignTarget) (*fleet.DistributedQueryCampaignTarget, error) {
+		return target, nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		return nil
+	}
+	var runs []*fleet.ScheduledLiveQueryRun
+	ds.NewScheduledLiveQueryRunFunc = func(ctx context.Context, run *fleet.ScheduledLiveQueryRun) error {
+		// the run is recorded before the query is sent to the hosts
+		require.Empty(t, lq.queries)
+		runs = append(runs, run)
+		return nil
+	}
+	saved := make(map[uint]fleet.ScheduledLiveQuery)
+	ds.SaveScheduledLiveQueryFunc = func(ctx context.Context, query *fleet.ScheduledLiveQuery) error {
+		saved[query.ID] = *query
+		return nil
+	}
+
+	require.NoError(t, svc.RunDueScheduledLiveQueries(context.Background(), now))
+
+	// the recurring query ran and is scheduled for the next hour
+	assert.Equal(t, []uint{4, 5}, lq.queries["10"])
+	require.Contains(t, saved, uint(1))
+	assert.Equal(t, uint(10), *saved[1].LastCampaignID)
+	assert.Equal(t, now, *saved[1].LastRunAt)
+	assert.Equal(t, now.Truncate(time.Hour).Add(time.Hour), *saved[1].NextRunAt)
+
+	// the one-shot query without author failed and will not run again
+	require.Contains(t, saved, uint(2))
+	assert.Nil(t, saved[2].LastCampaignID)
+	assert.Nil(t, saved[2].NextRunAt)
+
+	// the run collects the results during the results window
+	require.Len(t, runs, 1)
+	assert.Equal(t, fleet.ScheduledLiveQueryRun{CampaignID: 10, ScheduledLiveQueryID: 1, EndsAt: now.Add(time.Second)}, *runs[0])
+
+	// nothing runs while live queries are disabled, but the schedule advances
+	liveQueryDisabled = true
+	lq.queries = make(map[string][]uint)
+	due = []*fleet.ScheduledLiveQuery{{ID: 3, Name: "once", QueryID: 2, RunAt: &now, Enabled: true, AuthorID: &author.ID, NextRunAt: &now}}
+	require.NoError(t, svc.RunDueScheduledLiveQueries(context.Background(), now))
+	assert.Empty(t, lq.queries)
+	require.Contains(t, saved, uint(3))
+	assert.Nil(t, saved[3].NextRunAt)
+}
+
+func TestCompleteEndedScheduledLiveQueryRuns(t *testing.T) {
+	ds := new(mock.Store)
+	lq := &recordingLiveQuery{queries: make(map[string][]uint)}
+	svc := newTestServiceWithConfig(t, ds, config.TestConfig(), nil, lq)
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	ds.ListEndedScheduledLiveQueryRunsFunc = func(ctx context.Context, now time.Time) ([]*fleet.ScheduledLiveQueryRun, error) {
+		return []*fleet.ScheduledLiveQueryRun{
+			{CampaignID: 10, ScheduledLiveQueryID: 1, EndsAt: now},
+			{CampaignID: 11, ScheduledLiveQueryID: 2, EndsAt: now},
+			{CampaignID: 12, ScheduledLiveQueryID: 3, EndsAt: now},
+		}, nil
+	}
+	ds.DistributedQueryCampaignFunc = func(ctx context.Context, id uint) (*fleet.DistributedQueryCampaign, error) {
+		return &fleet.DistributedQueryCampaign{ID: id, Status: fleet.QueryRunning}, nil
+	}
+	var completed []uint
+	ds.SaveDistributedQueryCampaignFunc = func(ctx context.Context, camp *fleet.DistributedQueryCampaign) error {
+		require.Equal(t, fleet.QueryComplete, camp.Status)
+		completed = append(completed, camp.ID)
+		return nil
+	}
+	ds.ScheduledLiveQueryFunc = func(ctx context.Context, id uint) (*fleet.ScheduledLiveQuery, error) {
+		switch id {
+		case 1:
+			return &fleet.ScheduledLiveQuery{ID: id, WebhookURL: "https://example.com"}, nil
+		case 2:
+			return &fleet.ScheduledLiveQuery{ID: id}, nil
+		}
+		return nil, notFoundError{}
+	}
+	var jobs []*fleet.Job
+	ds.NewJobFunc = func(ctx context.Context, job *fleet.Job) (*fleet.Job, error) {
+		jobs = append(jobs, job)
+		return job, nil
+	}
+	var deleted []uint
+	ds.DeleteScheduledLiveQueryRunFunc = func(ctx context.Context, campaignID uint) error {
+		deleted = append(deleted, campaignID)
+		return nil
+	}
+
+	require.NoError(t, svc.CompleteEndedScheduledLiveQueryRuns(context.Background(), now))
+
+	// all the campaigns are completed, including the one of the deleted
+	// scheduled live query, but only the one with a webhook is delivered
+	assert.Equal(t, []uint{10, 11, 12}, completed)
+	assert.Equal(t, []uint{10, 11, 12}, deleted)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, fleet.ScheduledLiveQueryWebhookJobName, jobs[0].Name)
+	assert.JSONEq(t, `{"scheduled_live_query_id":1,"campaign_id":10}`, string(*jobs[0].Args))
+}
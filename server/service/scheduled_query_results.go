@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/spf13/cast"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List Scheduled Query Results
+////////////////////////////////////////////////////////////////////////////////
+
+type listScheduledQueryResultsRequest struct {
+	ID          uint              `url:"id"`
+	HostID      *uint             `query:"host_id,optional"`
+	ListOptions fleet.ListOptions `url:"list_options"`
+}
+
+type listScheduledQueryResultsResponse struct {
+	Results []*fleet.ScheduledQueryResult `json:"results"`
+	Err     error                         `json:"error,omitempty"`
+}
+
+func (r listScheduledQueryResultsResponse) error() error { return r.Err }
+
+func listScheduledQueryResultsEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listScheduledQueryResultsRequest)
+	results, err := svc.ListScheduledQueryResults(ctx, fleet.ScheduledQueryResultListOptions{
+		ListOptions:      req.ListOptions,
+		ScheduledQueryID: &req.ID,
+		HostID:           req.HostID,
+	})
+	if err != nil {
+		return listScheduledQueryResultsResponse{Err: err}, nil
+	}
+	return listScheduledQueryResultsResponse{Results: results}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List Host Scheduled Query Results
+////////////////////////////////////////////////////////////////////////////////
+
+type listHostScheduledQueryResultsRequest struct {
+	ID               uint              `url:"id"`
+	ScheduledQueryID *uint             `query:"scheduled_query_id,optional"`
+	ListOptions      fleet.ListOptions `url:"list_options"`
+}
+
+func listHostScheduledQueryResultsEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listHostScheduledQueryResultsRequest)
+	results, err := svc.ListScheduledQueryResults(ctx, fleet.ScheduledQueryResultListOptions{
+		ListOptions:      req.ListOptions,
+		ScheduledQueryID: req.ScheduledQueryID,
+		HostID:           &req.ID,
+	})
+	if err != nil {
+		return listScheduledQueryResultsResponse{Err: err}, nil
+	}
+	return listScheduledQueryResultsResponse{Results: results}, nil
+}
+
+func (svc *Service) ListScheduledQueryResults(ctx context.Context, opts fleet.ScheduledQueryResultListOptions) ([]*fleet.ScheduledQueryResult, error) {
+	if opts.ScheduledQueryID == nil && opts.HostID == nil {
+		// bad request, the endpoints always set one of them
+		svc.authz.SkipAuthorization(ctx)
+		return nil, ctxerr.Wrap(ctx, fleet.NewInvalidArgumentError("scheduled_query_id", "scheduled query or host is required"))
+	}
+
+	if opts.HostID != nil {
+		if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+			return nil, err
+		}
+		host, err := svc.ds.HostLite(ctx, *opts.HostID)
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "get host")
+		}
+		if err := svc.authz.Authorize(ctx, host, fleet.ActionRead); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.ScheduledQueryID != nil {
+		// Scheduled queries are currently authorized the same as packs, the
+		// pack must be loaded to authorize the team users on their team's pack.
+		sq, err := svc.ds.ScheduledQuery(ctx, *opts.ScheduledQueryID)
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "get scheduled query")
+		}
+		pack, err := svc.ds.Pack(ctx, sq.PackID)
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "get pack")
+		}
+		if err := svc.authz.Authorize(ctx, pack, fleet.ActionRead); err != nil {
+			return nil, err
+		}
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, fleet.ErrNoContext
+	}
+	filter := fleet.TeamFilter{User: vc.User, IncludeObserver: true}
+
+	return svc.ds.ListScheduledQueryResults(ctx, filter, opts)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////////////
+
+// scheduledQueryResultLog holds the fields of an osquery result log used to
+// store the scheduled query results.
+type scheduledQueryResultLog struct {
+	Name        string          `json:"name"`
+	UnixTime    interface{}     `json:"unixTime"` // a number or a string depending on the osquery version
+	Action      string          `json:"action"`
+	Columns     json.RawMessage `json:"columns"`
+	Snapshot    json.RawMessage `json:"snapshot"`
+	DiffResults json.RawMessage `json:"diffResults"`
+}
+
+// scheduledQueryResultsFromLogs returns the scheduled query results of the
+// result logs, ignoring the logs that are not results of scheduled queries.
+func scheduledQueryResultsFromLogs(logs []json.RawMessage, now time.Time) []*fleet.ScheduledQueryResult {
+	var results []*fleet.ScheduledQueryResult
+	for _, raw := range logs {
+		var log scheduledQueryResultLog
+		if err := json.Unmarshal(raw, &log); err != nil {
+			continue
+		}
+		packName, schedQueryName, ok := fleet.SplitScheduledQueryLogName(log.Name)
+		if !ok {
+			continue
+		}
+
+		result := &fleet.ScheduledQueryResult{
+			PackName:           packName,
+			ScheduledQueryName: schedQueryName,
+			Action:             log.Action,
+			LastFetched:        now,
+		}
+		switch {
+		case len(log.Snapshot) > 0:
+			result.Action = "snapshot"
+			result.Data = log.Snapshot
+		case len(log.DiffResults) > 0:
+			result.Action = "batch"
+			result.Data = log.DiffResults
+		case len(log.Columns) > 0:
+			result.Data = log.Columns
+		default:
+			continue
+		}
+		if ts := cast.ToInt64(log.UnixTime); ts > 0 {
+			result.LastFetched = time.Unix(ts, 0).UTC()
+		}
+		results = append(results, result)
+	}
+	return results
+}
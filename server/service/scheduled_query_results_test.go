@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListScheduledQueryResultsAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	const (
+		globalSchedQueryID = 1
+		teamSchedQueryID   = 2
+		noTeamHostID       = 1
+		teamHostID         = 2
+	)
+	ds.ScheduledQueryFunc = func(ctx context.Context, id uint) (*fleet.ScheduledQuery, error) {
+		return &fleet.ScheduledQuery{ID: id, PackID: id}, nil
+	}
+	ds.PackFunc = func(ctx context.Context, id uint) (*fleet.Pack, error) {
+		if id == teamSchedQueryID {
+			return &fleet.Pack{ID: id, Type: ptr.String("team-1"), TeamIDs: []uint{1}}, nil
+		}
+		return &fleet.Pack{ID: id, Type: ptr.String("global")}, nil
+	}
+	ds.HostLiteFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		if id == teamHostID {
+			return &fleet.Host{ID: id, TeamID: ptr.Uint(1)}, nil
+		}
+		return &fleet.Host{ID: id}, nil
+	}
+	ds.ListScheduledQueryResultsFunc = func(ctx context.Context, filter fleet.TeamFilter, opts fleet.ScheduledQueryResultListOptions) ([]*fleet.ScheduledQueryResult, error) {
+		return nil, nil
+	}
+
+	testCases := []struct {
+		name                   string
+		user                   *fleet.User
+		shouldFailGlobalSQ     bool
+		shouldFailTeamSQ       bool
+		shouldFailNoTeamHost   bool
+		shouldFailTeamHostRead bool
+	}{
+		{
+			"global admin",
+			&fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)},
+			false,
+			false,
+			false,
+			false,
+		},
+		{
+			"global observer",
+			&fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)},
+			false,
+			true,
+			false,
+			false,
+		},
+		{
+			"team maintainer",
+			&fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}},
+			false,
+			false,
+			true,
+			false,
+		},
+		{
+			"team observer of another team",
+			&fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleObserver}}},
+			false,
+			true,
+			true,
+			true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.ListScheduledQueryResults(ctx, fleet.ScheduledQueryResultListOptions{ScheduledQueryID: ptr.Uint(globalSchedQueryID)})
+			checkAuthErr(t, tt.shouldFailGlobalSQ, err)
+
+			_, err = svc.ListScheduledQueryResults(ctx, fleet.ScheduledQueryResultListOptions{ScheduledQueryID: ptr.Uint(teamSchedQueryID)})
+			checkAuthErr(t, tt.shouldFailTeamSQ, err)
+
+			_, err = svc.ListScheduledQueryResults(ctx, fleet.ScheduledQueryResultListOptions{HostID: ptr.Uint(noTeamHostID)})
+			checkAuthErr(t, tt.shouldFailNoTeamHost, err)
+
+			_, err = svc.ListScheduledQueryResults(ctx, fleet.ScheduledQueryResultListOptions{HostID: ptr.Uint(teamHostID)})
+			checkAuthErr(t, tt.shouldFailTeamHostRead, err)
+		})
+	}
+
+	// one of the scheduled query or the host is required
+	_, err := svc.ListScheduledQueryResults(test.UserContext(test.UserAdmin), fleet.ScheduledQueryResultListOptions{})
+	require.Error(t, err)
+	var iae *fleet.InvalidArgumentError
+	require.ErrorAs(t, err, &iae)
+}
+
+func TestScheduledQueryResultsFromLogs(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	ts := now.Add(-time.Minute)
+
+	logs := []json.RawMessage{
+		json.RawMessage(`{"name":"pack/Global/processes","unixTime":` + strconv.FormatInt(ts.Unix(), 10) + `,"action":"added","columns":{"pid":"1"}}`),
+		json.RawMessage(`{"name":"pack/Team: A/users","unixTime":"` + strconv.FormatInt(ts.Unix(), 10) + `","action":"snapshot","snapshot":[{"uid":"0"}]}`),
+		json.RawMessage(`{"name":"pack/Global/uptime","action":"","diffResults":{"added":[{"days":"1"}],"removed":[]}}`),
+		json.RawMessage(`{"name":"not_a_pack_query","action":"added","columns":{"a":"b"}}`),
+		json.RawMessage(`{"name":"pack/Global/empty","action":"added"}`),
+		json.RawMessage(`not json`),
+	}
+
+	results := scheduledQueryResultsFromLogs(logs, now)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "Global", results[0].PackName)
+	assert.Equal(t, "processes", results[0].ScheduledQueryName)
+	assert.Equal(t, "added", results[0].Action)
+	assert.JSONEq(t, `{"pid":"1"}`, string(results[0].Data))
+	assert.Equal(t, ts, results[0].LastFetched)
+
+	assert.Equal(t, "Team: A", results[1].PackName)
+	assert.Equal(t, "users", results[1].ScheduledQueryName)
+	assert.Equal(t, "snapshot", results[1].Action)
+	assert.JSONEq(t, `[{"uid":"0"}]`, string(results[1].Data))
+	assert.Equal(t, ts, results[1].LastFetched)
+
+	assert.Equal(t, "uptime", results[2].ScheduledQueryName)
+	assert.Equal(t, "batch", results[2].Action)
+	assert.JSONEq(t, `{"added":[{"days":"1"}],"removed":[]}`, string(results[2].Data))
+	assert.Equal(t, now, results[2].LastFetched)
+}
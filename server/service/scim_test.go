@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSCIMAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.SCIMUserFunc = func(ctx context.Context, id uint) (*fleet.User, error) {
+		return &fleet.User{ID: id, Email: "user@example.com", GlobalRole: ptr.String(fleet.RoleObserver)}, nil
+	}
+	ds.ListSCIMGroupsForUserFunc = func(ctx context.Context, userID uint) ([]*fleet.SCIMGroup, error) {
+		return nil, nil
+	}
+	ds.ListSCIMGroupsFunc = func(ctx context.Context) ([]*fleet.SCIMGroup, error) {
+		return nil, nil
+	}
+	ds.ListSCIMUsersFunc = func(ctx context.Context, opt fleet.SCIMUserListOptions) ([]*fleet.User, int, error) {
+		return nil, 0, nil
+	}
+	ds.DeleteSCIMUserFunc = func(ctx context.Context, id uint) error {
+		return nil
+	}
+
+	testCases := []struct {
+		name       string
+		user       *fleet.User
+		shouldFail bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, true},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, true},
+		{"team admin", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.GetSCIMUser(ctx, 1)
+			checkAuthErr(t, tt.shouldFail, err)
+			_, _, err = svc.ListSCIMUsers(ctx, fleet.SCIMListOptions{})
+			checkAuthErr(t, tt.shouldFail, err)
+			_, _, err = svc.ListSCIMGroups(ctx, fleet.SCIMListOptions{})
+			checkAuthErr(t, tt.shouldFail, err)
+			err = svc.DeleteSCIMUser(ctx, 1)
+			checkAuthErr(t, tt.shouldFail, err)
+		})
+	}
+}
+
+func TestSCIMSyncUserRoles(t *testing.T) {
+	ds := new(mock.Store)
+	svc := &Service{ds: ds, logger: kitlog.NewNopLogger(), license: fleet.LicenseInfo{Tier: fleet.TierPremium}}
+	ctx := context.Background()
+
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{SSOSettings: fleet.SSOSettings{SCIMGroupMappings: []fleet.SCIMGroupMapping{
+			{Group: "Fleet Admins", Role: fleet.RoleAdmin},
+			{Group: "Fleet Observers", Role: fleet.RoleObserver},
+			{Group: "Team1 Maintainers", Team: "team1", Role: fleet.RoleMaintainer},
+			{Group: "Team1 Observers", Team: "team1", Role: fleet.RoleObserver},
+			{Group: "Team2 Admins", Team: "team2", Role: fleet.RoleAdmin},
+			{Group: "Unknown Team", Team: "nope", Role: fleet.RoleAdmin},
+		}}}, nil
+	}
+	ds.TeamByNameFunc = func(ctx context.Context, name string) (*fleet.Team, error) {
+		switch name {
+		case "team1":
+			return &fleet.Team{ID: 1, Name: name}, nil
+		case "team2":
+			return &fleet.Team{ID: 2, Name: name}, nil
+		}
+		return nil, notFoundError{}
+	}
+
+	var groups []string
+	ds.ListSCIMGroupsForUserFunc = func(ctx context.Context, userID uint) ([]*fleet.SCIMGroup, error) {
+		var res []*fleet.SCIMGroup
+		for _, g := range groups {
+			res = append(res, &fleet.SCIMGroup{DisplayName: g})
+		}
+		return res, nil
+	}
+	var user *fleet.User
+	ds.UserByIDFunc = func(ctx context.Context, id uint) (*fleet.User, error) {
+		return user, nil
+	}
+	var saved *fleet.User
+	ds.SaveUserFunc = func(ctx context.Context, u *fleet.User) error {
+		saved = u
+		return nil
+	}
+
+	teamRoles := func(u *fleet.User) map[uint]string {
+		roles := make(map[uint]string)
+		for _, t := range u.Teams {
+			roles[t.ID] = t.Role
+		}
+		return roles
+	}
+
+	cases := []struct {
+		desc       string
+		groups     []string
+		globalRole *string
+		teamRoles  map[uint]string
+	}{
+		{"no groups", nil, ptr.String(fleet.RoleObserver), map[uint]string{}},
+		{"unmapped group", []string{"Other"}, ptr.String(fleet.RoleObserver), map[uint]string{}},
+		{"global admin", []string{"Fleet Admins"}, ptr.String(fleet.RoleAdmin), map[uint]string{}},
+		{"case insensitive group name", []string{"fleet admins"}, ptr.String(fleet.RoleAdmin), map[uint]string{}},
+		{"highest global role", []string{"Fleet Observers", "Fleet Admins"}, ptr.String(fleet.RoleAdmin), map[uint]string{}},
+		{"global role takes precedence", []string{"Team1 Maintainers", "Fleet Observers"}, ptr.String(fleet.RoleObserver), map[uint]string{}},
+		{"team role", []string{"Team1 Observers"}, nil, map[uint]string{1: fleet.RoleObserver}},
+		{"highest team role", []string{"Team1 Observers", "Team1 Maintainers"}, nil, map[uint]string{1: fleet.RoleMaintainer}},
+		{"multiple teams", []string{"Team1 Observers", "Team2 Admins"}, nil, map[uint]string{1: fleet.RoleObserver, 2: fleet.RoleAdmin}},
+		{"unknown team", []string{"Unknown Team"}, ptr.String(fleet.RoleObserver), map[uint]string{}},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			groups = c.groups
+			user = &fleet.User{ID: 1, GlobalRole: ptr.String(fleet.RoleMaintainer)}
+			saved = nil
+
+			require.NoError(t, svc.syncSCIMUserRoles(ctx, []uint{1}))
+			require.NotNil(t, saved)
+			assert.Equal(t, c.globalRole, saved.GlobalRole)
+			assert.Equal(t, c.teamRoles, teamRoles(saved))
+		})
+	}
+
+	t.Run("unchanged role is not saved", func(t *testing.T) {
+		groups = []string{"Fleet Admins"}
+		user = &fleet.User{ID: 1, GlobalRole: ptr.String(fleet.RoleAdmin)}
+		saved = nil
+
+		require.NoError(t, svc.syncSCIMUserRoles(ctx, []uint{1, 1}))
+		assert.Nil(t, saved)
+	})
+
+	t.Run("team mappings ignored without premium license", func(t *testing.T) {
+		svc.license = fleet.LicenseInfo{Tier: fleet.TierFree}
+		defer func() { svc.license = fleet.LicenseInfo{Tier: fleet.TierPremium} }()
+		groups = []string{"Team1 Maintainers", "Team2 Admins"}
+		user = &fleet.User{ID: 1, GlobalRole: ptr.String(fleet.RoleMaintainer)}
+		saved = nil
+
+		require.NoError(t, svc.syncSCIMUserRoles(ctx, []uint{1}))
+		require.NotNil(t, saved)
+		assert.Equal(t, ptr.String(fleet.RoleObserver), saved.GlobalRole)
+		assert.Empty(t, saved.Teams)
+	})
+}
+
+func TestEncodeSCIMError(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		desc     string
+		err      error
+		status   int
+		scimType string
+	}{
+		{"not found", ctxerr.Wrap(ctx, notFoundError{}), http.StatusNotFound, ""},
+		{"conflict", fleet.NewInvalidArgumentError("userName", "a user with this email already exists").WithStatus(http.StatusConflict), http.StatusConflict, "uniqueness"},
+		{"bad request", &fleet.BadRequestError{Message: "unsupported filter"}, http.StatusBadRequest, "invalidValue"},
+		{"auth required", fleet.NewAuthRequiredError("no session"), http.StatusUnauthorized, ""},
+		{"internal", errors.New("boom"), http.StatusInternalServerError, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			encodeSCIMError(ctx, c.err, rec)
+			require.Equal(t, c.status, rec.Code)
+			require.Equal(t, scimContentType, rec.Header().Get("Content-Type"))
+
+			var res scimErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, []string{fleet.SCIMSchemaError}, res.Schemas)
+			assert.Equal(t, fmt.Sprint(c.status), res.Status)
+			assert.Equal(t, c.scimType, res.SCIMType)
+			assert.NotEmpty(t, res.Detail)
+		})
+	}
+}
+
+func TestParseSCIMFilter(t *testing.T) {
+	attr, value, err := parseSCIMFilter("", "userName")
+	require.NoError(t, err)
+	assert.Empty(t, attr)
+	assert.Empty(t, value)
+
+	attr, value, err = parseSCIMFilter(`username EQ "john@example.com"`, "userName")
+	require.NoError(t, err)
+	assert.Equal(t, "userName", attr)
+	assert.Equal(t, "john@example.com", value)
+
+	attr, value, err = parseSCIMFilter(`displayName eq "a \"quoted\" name"`, "displayName", "externalId")
+	require.NoError(t, err)
+	assert.Equal(t, "displayName", attr)
+	assert.Equal(t, `a "quoted" name`, value)
+
+	_, _, err = parseSCIMFilter(`title eq "x"`, "userName")
+	require.ErrorContains(t, err, "unsupported filter attribute")
+
+	_, _, err = parseSCIMFilter(`userName sw "j"`, "userName")
+	require.ErrorContains(t, err, "unsupported filter")
+}
+
+func TestPaginateSCIM(t *testing.T) {
+	cases := []struct {
+		total, startIndex, count int
+		start, end               int
+	}{
+		{0, 0, 0, 0, 0},
+		{10, 0, 0, 0, 10},
+		{10, 1, 0, 0, 10},
+		{10, 3, 5, 2, 7},
+		{10, 8, 5, 7, 10},
+		{10, 20, 5, 10, 10},
+	}
+	for _, c := range cases {
+		start, end := paginateSCIM(c.total, fleet.SCIMListOptions{StartIndex: c.startIndex, Count: c.count})
+		assert.Equal(t, c.start, start, "%+v", c)
+		assert.Equal(t, c.end, end, "%+v", c)
+	}
+}
+
+func TestSCIMUserAttributes(t *testing.T) {
+	name, email, err := scimUserAttributes(fleet.SCIMUserResource{UserName: "a@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", name)
+	assert.Equal(t, "a@example.com", email)
+
+	name, email, err = scimUserAttributes(fleet.SCIMUserResource{
+		UserName: "jdoe",
+		Name:     &fleet.SCIMName{GivenName: "John", FamilyName: "Doe"},
+		Emails: []fleet.SCIMMultiValue{
+			{Value: "home@example.com", Type: "home"},
+			{Value: "work@example.com", Type: "work", Primary: true},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", name)
+	assert.Equal(t, "work@example.com", email)
+
+	name, _, err = scimUserAttributes(fleet.SCIMUserResource{UserName: "a@example.com", DisplayName: "Alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", name)
+
+	_, _, err = scimUserAttributes(fleet.SCIMUserResource{UserName: "jdoe"})
+	require.Error(t, err)
+}
+
+func TestApplySCIMUserPatch(t *testing.T) {
+	current := &fleet.SCIMUserResource{
+		Schemas:     []string{fleet.SCIMSchemaUser},
+		ID:          "1",
+		UserName:    "a@example.com",
+		Name:        &fleet.SCIMName{Formatted: "Alice"},
+		DisplayName: "Alice",
+		Active:      ptr.Bool(true),
+	}
+
+	var patched fleet.SCIMUserResource
+	err := applySCIMUserPatch(current, []fleet.SCIMPatchOperation{
+		{Op: "Replace", Value: json.RawMessage(`{"active": false}`)},
+	}, &patched)
+	require.NoError(t, err)
+	require.NotNil(t, patched.Active)
+	assert.False(t, *patched.Active)
+	assert.Equal(t, "a@example.com", patched.UserName)
+
+	patched = fleet.SCIMUserResource{}
+	err = applySCIMUserPatch(current, []fleet.SCIMPatchOperation{
+		{Op: "replace", Path: "userName", Value: json.RawMessage(`"b@example.com"`)},
+		{Op: "add", Path: "name.givenName", Value: json.RawMessage(`"Bob"`)},
+		{Op: "remove", Path: "displayName"},
+	}, &patched)
+	require.NoError(t, err)
+	assert.Equal(t, "b@example.com", patched.UserName)
+	assert.Equal(t, "Bob", patched.Name.GivenName)
+	assert.Empty(t, patched.DisplayName)
+	assert.True(t, *patched.Active)
+
+	err = applySCIMUserPatch(current, []fleet.SCIMPatchOperation{{Op: "move", Path: "active"}}, &patched)
+	require.ErrorContains(t, err, "unsupported operation")
+	err = applySCIMUserPatch(current, []fleet.SCIMPatchOperation{{Op: "replace", Value: json.RawMessage(`"x"`)}}, &patched)
+	require.ErrorContains(t, err, "requires an object value")
+}
+
+func TestApplySCIMGroupPatch(t *testing.T) {
+	group := &fleet.SCIMGroup{DisplayName: "g", UserIDs: []uint{1, 2}}
+
+	apply := func(op fleet.SCIMPatchOperation) {
+		require.NoError(t, applySCIMGroupPatch(group, op))
+	}
+
+	apply(fleet.SCIMPatchOperation{Op: "add", Path: "members", Value: json.RawMessage(`[{"value": "3"}, {"value": "1"}]`)})
+	assert.Equal(t, []uint{1, 2, 3}, group.UserIDs)
+
+	apply(fleet.SCIMPatchOperation{Op: "remove", Path: `members[value eq "2"]`})
+	assert.Equal(t, []uint{1, 3}, group.UserIDs)
+
+	apply(fleet.SCIMPatchOperation{Op: "Remove", Path: "members", Value: json.RawMessage(`[{"value": "1"}]`)})
+	assert.Equal(t, []uint{3}, group.UserIDs)
+
+	apply(fleet.SCIMPatchOperation{Op: "replace", Value: json.RawMessage(`{"displayName": "new", "members": [{"value": "4"}]}`)})
+	assert.Equal(t, "new", group.DisplayName)
+	assert.Equal(t, []uint{4}, group.UserIDs)
+
+	apply(fleet.SCIMPatchOperation{Op: "replace", Path: "externalId", Value: json.RawMessage(`"ext"`)})
+	assert.Equal(t, "ext", group.ExternalID)
+
+	apply(fleet.SCIMPatchOperation{Op: "remove", Path: "members"})
+	assert.Empty(t, group.UserIDs)
+
+	require.Error(t, applySCIMGroupPatch(group, fleet.SCIMPatchOperation{Op: "replace", Path: "displayName", Value: json.RawMessage(`""`)}))
+	require.Error(t, applySCIMGroupPatch(group, fleet.SCIMPatchOperation{Op: "add", Path: "members", Value: json.RawMessage(`[{"value": "abc"}]`)}))
+	require.Error(t, applySCIMGroupPatch(group, fleet.SCIMPatchOperation{Op: "add", Path: `members[value eq "1"]`}))
+	require.Error(t, applySCIMGroupPatch(group, fleet.SCIMPatchOperation{Op: "replace", Path: "unknown", Value: json.RawMessage(`"x"`)}))
+}
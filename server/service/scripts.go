@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fleetdm/fleet/v4/server/authz"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/service/osquery_utils"
+	"github.com/go-kit/kit/log/level"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List host script executions
+////////////////////////////////////////////////////////////////////////////////
+
+type listHostScriptExecutionsRequest struct {
+	ID          uint              `url:"id"`
+	ListOptions fleet.ListOptions `url:"list_options"`
+}
+
+type listHostScriptExecutionsResponse struct {
+	ScriptExecutions []*fleet.HostScriptExecution `json:"script_executions"`
+	Err              error                        `json:"error,omitempty"`
+}
+
+func (r listHostScriptExecutionsResponse) error() error { return r.Err }
+
+func listHostScriptExecutionsEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listHostScriptExecutionsRequest)
+	execs, err := svc.ListHostScriptExecutions(ctx, req.ID, req.ListOptions)
+	if err != nil {
+		return listHostScriptExecutionsResponse{Err: err}, nil
+	}
+	return listHostScriptExecutionsResponse{ScriptExecutions: execs}, nil
+}
+
+func (svc *Service) ListHostScriptExecutions(ctx context.Context, hostID uint, opt fleet.ListOptions) ([]*fleet.HostScriptExecution, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return nil, err
+	}
+
+	host, err := svc.ds.HostLite(ctx, hostID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "find host for script executions")
+	}
+	if err := svc.authz.Authorize(ctx, &fleet.HostScriptExecution{TeamID: host.TeamID}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	return svc.ds.ListHostScriptExecutions(ctx, hostID, opt)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Run script
+////////////////////////////////////////////////////////////////////////////////
+
+type runScriptRequest struct {
+	fleet.RunScriptPayload
+}
+
+type runScriptResponse struct {
+	ScriptExecutions []*fleet.HostScriptExecution `json:"script_executions"`
+	Err              error                        `json:"error,omitempty"`
+}
+
+func (r runScriptResponse) error() error { return r.Err }
+
+func runScriptEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*runScriptRequest)
+	execs, err := svc.RunScript(ctx, req.RunScriptPayload)
+	if err != nil {
+		return runScriptResponse{Err: err}, nil
+	}
+	return runScriptResponse{ScriptExecutions: execs}, nil
+}
+
+func (svc *Service) RunScript(ctx context.Context, payload fleet.RunScriptPayload) ([]*fleet.HostScriptExecution, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return nil, err
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, fleet.ErrNoContext
+	}
+
+	if strings.TrimSpace(payload.Contents) == "" {
+		return nil, fleet.NewInvalidArgumentError("contents", "script contents cannot be empty")
+	}
+	if len(payload.HostIDs) == 0 && len(payload.LabelIDs) == 0 {
+		return nil, fleet.NewInvalidArgumentError("host_ids", "at least one host or label must be targeted")
+	}
+
+	filter := fleet.TeamFilter{User: vc.User}
+	hostIDs, err := svc.ds.HostIDsInTargets(ctx, filter, fleet.HostTargets{HostIDs: payload.HostIDs, LabelIDs: payload.LabelIDs})
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get target IDs")
+	}
+	hosts, err := svc.ds.ListHostsLiteByIDs(ctx, hostIDs)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list targeted hosts")
+	}
+	if len(hosts) == 0 {
+		return nil, &fleet.BadRequestError{
+			Message: "no hosts targeted",
+		}
+	}
+
+	// the user must be allowed to run scripts and the script must be allowed
+	// on the team of each targeted host, otherwise no script is queued.
+	checkedTeams := make(map[uint]bool)
+	for _, host := range hosts {
+		var teamID uint
+		if host.TeamID != nil {
+			teamID = *host.TeamID
+		}
+		if checkedTeams[teamID] {
+			continue
+		}
+		if err := svc.authz.Authorize(ctx, &fleet.HostScriptExecution{TeamID: host.TeamID}, fleet.ActionWrite); err != nil {
+			return nil, err
+		}
+		settings, err := svc.scriptSettings(ctx, host.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		if err := settings.Allows(payload.Contents); err != nil {
+			msg := err.Error() + " for hosts without a team"
+			if host.TeamID != nil {
+				msg = fmt.Sprintf("%s for team %d", err, *host.TeamID)
+			}
+			return nil, fleet.NewInvalidArgumentError("contents", msg)
+		}
+		checkedTeams[teamID] = true
+	}
+
+	execs := make([]*fleet.HostScriptExecution, 0, len(hosts))
+	for _, host := range hosts {
+		execs = append(execs, &fleet.HostScriptExecution{
+			HostID:   host.ID,
+			Type:     fleet.ScriptExecutionTypeScript,
+			Contents: payload.Contents,
+		})
+	}
+	execs, err = svc.ds.NewHostScriptExecutions(ctx, execs)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "queue script executions")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeRanScript,
+		&map[string]interface{}{"targets_count": len(execs), "script_hash": fleet.ScriptHash(payload.Contents)},
+	); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "create ran script activity")
+	}
+	return execs, nil
+}
+
+// scriptSettings returns the settings of the scripts that can be run on the
+// hosts of the team, or on the hosts without a team if teamID is nil.
+func (svc *Service) scriptSettings(ctx context.Context, teamID *uint) (*fleet.ScriptSettings, error) {
+	if teamID != nil {
+		settings, err := svc.ds.TeamScriptSettings(ctx, *teamID)
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "get team script settings")
+		}
+		return settings, nil
+	}
+
+	appConfig, err := svc.ds.AppConfig(ctx)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get app config")
+	}
+	return &appConfig.Scripts, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Get script execution
+////////////////////////////////////////////////////////////////////////////////
+
+type getScriptExecutionRequest struct {
+	ID uint `url:"id"`
+}
+
+type getScriptExecutionResponse struct {
+	ScriptExecution *fleet.HostScriptExecution `json:"script_execution"`
+	Err             error                      `json:"error,omitempty"`
+}
+
+func (r getScriptExecutionResponse) error() error { return r.Err }
+
+func getScriptExecutionEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*getScriptExecutionRequest)
+	exec, err := svc.GetScriptExecution(ctx, req.ID)
+	if err != nil {
+		return getScriptExecutionResponse{Err: err}, nil
+	}
+	return getScriptExecutionResponse{ScriptExecution: exec}, nil
+}
+
+func (svc *Service) GetScriptExecution(ctx context.Context, id uint) (*fleet.HostScriptExecution, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+		return nil, err
+	}
+
+	exec, err := svc.ds.HostScriptExecution(ctx, id)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get script execution")
+	}
+	host, err := svc.ds.HostLite(ctx, exec.HostID)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "find host for script execution")
+	}
+	exec.TeamID = host.TeamID
+	if err := svc.authz.Authorize(ctx, exec, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+	return exec, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Policy remediations
+////////////////////////////////////////////////////////////////////////////////
+
+// authorizePolicyRemediation checks that the user can set the remediation of a
+// policy of the team (or of a global policy if teamID is nil), which requires
+// being allowed to run scripts on the team's hosts.
+func (svc *Service) authorizePolicyRemediation(ctx context.Context, teamID *uint) error {
+	return svc.authz.Authorize(ctx, &fleet.HostScriptExecution{TeamID: teamID}, fleet.ActionWrite)
+}
+
+// policyRemediationRedactor returns a function that removes the contents of
+// the remediation of a policy, keeping its type, if the user cannot set the
+// remediations of the policy's team. The remediations may contain secrets
+// while the policies are visible to all the users of the team.
+func (svc *Service) policyRemediationRedactor(ctx context.Context) func(p *fleet.PolicyData) {
+	// the authorization only depends on the team, 0 is for global policies
+	allowed := make(map[uint]bool)
+	return func(p *fleet.PolicyData) {
+		if p.Remediation == nil {
+			return
+		}
+		var teamID uint
+		if p.TeamID != nil {
+			teamID = *p.TeamID
+		}
+		ok, checked := allowed[teamID]
+		if !checked {
+			ok = svc.authorizePolicyRemediation(ctx, p.TeamID) == nil
+			allowed[teamID] = ok
+		}
+		if !ok {
+			p.Remediation = nil
+		}
+	}
+}
+
+// queuePolicyRemediations queues the execution of the remediations of the
+// policies that the host started failing.
+//
+// The script remediations that are not allowed by the script settings of the
+// host's team are skipped.
+func (svc *Service) queuePolicyRemediations(ctx context.Context, host *fleet.Host, newFailing []uint, remediations map[uint]*fleet.Policy) {
+	var settings *fleet.ScriptSettings
+	for _, policyID := range newFailing {
+		policy, ok := remediations[policyID]
+		if !ok || policy.Remediation == nil {
+			continue
+		}
+		policyID := policyID
+
+		if policy.RemediationType == fleet.ScriptExecutionTypeScript {
+			if settings == nil {
+				var err error
+				if settings, err = svc.scriptSettings(ctx, host.TeamID); err != nil {
+					level.Error(svc.logger).Log("msg", "get script settings for policy remediation", "host_id", host.ID, "err", err)
+					return
+				}
+			}
+			if err := settings.Allows(*policy.Remediation); err != nil {
+				level.Info(svc.logger).Log("msg", "skip policy remediation", "host_id", host.ID, "policy_id", policyID, "reason", err)
+				continue
+			}
+		}
+
+		if _, err := svc.ds.NewHostScriptExecution(ctx, &fleet.HostScriptExecution{
+			HostID:   host.ID,
+			PolicyID: &policyID,
+			Type:     policy.RemediationType,
+			Contents: *policy.Remediation,
+		}); err != nil {
+			level.Error(svc.logger).Log("msg", "queue policy remediation", "host_id", host.ID, "policy_id", policyID, "err", err)
+		}
+	}
+}
+
+// remediationQueriesForHost returns the pending osquery remediations of the
+// host, keyed by execution ID, and marks them as sent.
+func (svc *Service) remediationQueriesForHost(ctx context.Context, host *fleet.Host) (map[string]string, error) {
+	execs, err := svc.ds.ListPendingHostScriptExecutions(ctx, host.ID, fleet.ScriptExecutionTypeOsquery)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "list pending remediation queries")
+	}
+	if len(execs) == 0 {
+		return nil, nil
+	}
+
+	queries := make(map[string]string, len(execs))
+	ids := make([]uint, 0, len(execs))
+	for _, exec := range execs {
+		queries[strconv.FormatUint(uint64(exec.ID), 10)] = exec.Contents
+		ids = append(ids, exec.ID)
+	}
+	if err := svc.ds.MarkHostScriptExecutionsSent(ctx, ids); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "mark remediation queries sent")
+	}
+	return queries, nil
+}
+
+// ingestRemediationQuery stores the result of an osquery remediation. It
+// returns true if the remediation was run for a policy, which must then be
+// re-evaluated.
+func (svc *Service) ingestRemediationQuery(ctx context.Context, host *fleet.Host, name string, rows []map[string]string, failed bool, errMsg string) (bool, error) {
+	trimmedQuery := strings.TrimPrefix(name, hostRemediationQueryPrefix)
+
+	execID, err := strconv.Atoi(osquery_utils.EmptyToZero(trimmedQuery))
+	if err != nil {
+		return false, osqueryError{message: "unable to parse remediation execution ID: " + trimmedQuery}
+	}
+
+	res := fleet.HostScriptExecutionResult{
+		HostID:      host.ID,
+		ExecutionID: uint(execID),
+		Status:      fleet.ScriptExecutionCompleted,
+	}
+	if failed {
+		res.Status = fleet.ScriptExecutionFailed
+		res.Output = errMsg
+	} else {
+		output, err := json.Marshal(rows)
+		if err != nil {
+			return false, osqueryError{message: "marshal remediation query results: " + err.Error()}
+		}
+		res.Output = string(output)
+	}
+
+	exec, err := svc.ds.SetHostScriptExecutionResult(ctx, &res)
+	if err != nil {
+		return false, osqueryError{message: "save remediation query result: " + err.Error()}
+	}
+	return exec.PolicyID != nil, nil
+}
+
+// scriptExecutionStatus returns the status of a script execution based on the
+// exit code reported by the host.
+func scriptExecutionStatus(exitCode *int) fleet.ScriptExecutionStatus {
+	if exitCode != nil && *exitCode == 0 {
+		return fleet.ScriptExecutionCompleted
+	}
+	return fleet.ScriptExecutionFailed
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	hostctx "github.com/fleetdm/fleet/v4/server/contexts/host"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListHostScriptExecutionsAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.HostLiteFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		if id == 1 {
+			return &fleet.Host{ID: id, TeamID: ptr.Uint(1)}, nil
+		}
+		return &fleet.Host{ID: id}, nil
+	}
+	ds.ListHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, opt fleet.ListOptions) ([]*fleet.HostScriptExecution, error) {
+		return nil, nil
+	}
+
+	testCases := []struct {
+		name             string
+		user             *fleet.User
+		shouldFailTeam   bool
+		shouldFailGlobal bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, false, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, true, true},
+		{"team admin, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, false, true},
+		{"team maintainer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}}, false, true},
+		{"team observer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleObserver}}}, true, true},
+		{"team admin, different team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.ListHostScriptExecutions(ctx, 1, fleet.ListOptions{})
+			checkAuthErr(t, tt.shouldFailTeam, err)
+			_, err = svc.ListHostScriptExecutions(ctx, 2, fleet.ListOptions{})
+			checkAuthErr(t, tt.shouldFailGlobal, err)
+		})
+	}
+}
+
+func TestOrbitScripts(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	h := &fleet.Host{ID: 3, Platform: "darwin"}
+	ctx := hostctx.NewContext(context.Background(), h)
+
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{Scripts: fleet.ScriptSettings{Enabled: true, AllowAny: true}}, nil
+	}
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		require.Equal(t, h.ID, hostID)
+		require.Equal(t, fleet.ScriptExecutionTypeScript, execType)
+		return []*fleet.HostScriptExecution{{ID: 1, HostID: hostID, Type: execType, Contents: "echo 1"}, {ID: 2, HostID: hostID, Type: execType, Contents: "echo 2"}}, nil
+	}
+	var sentIDs []uint
+	ds.MarkHostScriptExecutionsSentFunc = func(ctx context.Context, ids []uint) error {
+		sentIDs = ids
+		return nil
+	}
+
+	scripts, err := svc.GetOrbitScripts(ctx)
+	require.NoError(t, err)
+	require.Len(t, scripts, 2)
+	require.Equal(t, []uint{1, 2}, sentIDs)
+
+	var gotResult *fleet.HostScriptExecutionResult
+	var policyID *uint
+	ds.SetHostScriptExecutionResultFunc = func(ctx context.Context, res *fleet.HostScriptExecutionResult) (*fleet.HostScriptExecution, error) {
+		gotResult = res
+		return &fleet.HostScriptExecution{ID: res.ExecutionID, HostID: res.HostID, PolicyID: policyID, Status: res.Status}, nil
+	}
+	ds.UpdateHostRefetchRequestedFunc = func(ctx context.Context, hostID uint, value bool) error {
+		require.Equal(t, h.ID, hostID)
+		require.True(t, value)
+		return nil
+	}
+
+	// a script that is not a remediation does not trigger a refetch
+	err = svc.SaveOrbitScriptResult(ctx, fleet.HostScriptExecutionResult{ExecutionID: 1, ExitCode: ptr.Int(0), Output: "1"})
+	require.NoError(t, err)
+	require.Equal(t, h.ID, gotResult.HostID)
+	require.Equal(t, fleet.ScriptExecutionCompleted, gotResult.Status)
+	require.False(t, ds.UpdateHostRefetchRequestedFuncInvoked)
+
+	// a remediation script triggers a refetch to evaluate the policy again
+	policyID = ptr.Uint(4)
+	err = svc.SaveOrbitScriptResult(ctx, fleet.HostScriptExecutionResult{ExecutionID: 2, ExitCode: ptr.Int(1), Output: "oops"})
+	require.NoError(t, err)
+	require.Equal(t, fleet.ScriptExecutionFailed, gotResult.Status)
+	require.True(t, ds.UpdateHostRefetchRequestedFuncInvoked)
+
+	// no exit code means the script could not run
+	err = svc.SaveOrbitScriptResult(ctx, fleet.HostScriptExecutionResult{ExecutionID: 2, Output: "timed out"})
+	require.NoError(t, err)
+	require.Equal(t, fleet.ScriptExecutionFailed, gotResult.Status)
+}
+
+func TestOrbitScriptsNotAllowed(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	h := &fleet.Host{ID: 3, Platform: "darwin", TeamID: ptr.Uint(1)}
+	ctx := hostctx.NewContext(context.Background(), h)
+
+	ds.TeamScriptSettingsFunc = func(ctx context.Context, teamID uint) (*fleet.ScriptSettings, error) {
+		require.Equal(t, uint(1), teamID)
+		return &fleet.ScriptSettings{Enabled: true, Allowlist: []string{fleet.ScriptHash("echo allowed")}}, nil
+	}
+	ds.ListPendingHostScriptExecutionsFunc = func(ctx context.Context, hostID uint, execType string) ([]*fleet.HostScriptExecution, error) {
+		return []*fleet.HostScriptExecution{
+			{ID: 1, HostID: hostID, Type: execType, Contents: "echo allowed"},
+			{ID: 2, HostID: hostID, Type: execType, Contents: "echo denied"},
+		}, nil
+	}
+	var sentIDs []uint
+	ds.MarkHostScriptExecutionsSentFunc = func(ctx context.Context, ids []uint) error {
+		sentIDs = ids
+		return nil
+	}
+	var gotResult *fleet.HostScriptExecutionResult
+	ds.SetHostScriptExecutionResultFunc = func(ctx context.Context, res *fleet.HostScriptExecutionResult) (*fleet.HostScriptExecution, error) {
+		gotResult = res
+		return &fleet.HostScriptExecution{ID: res.ExecutionID, HostID: res.HostID, Status: res.Status}, nil
+	}
+
+	scripts, err := svc.GetOrbitScripts(ctx)
+	require.NoError(t, err)
+	require.Len(t, scripts, 1)
+	require.Equal(t, uint(1), scripts[0].ID)
+	require.Equal(t, []uint{1}, sentIDs)
+
+	// the script that is not allowed anymore is marked as failed
+	require.NotNil(t, gotResult)
+	require.Equal(t, uint(2), gotResult.ExecutionID)
+	require.Equal(t, fleet.ScriptExecutionFailed, gotResult.Status)
+	require.Contains(t, gotResult.Output, "not in the allowlist")
+}
+
+func TestRunScriptAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.HostIDsInTargetsFunc = func(ctx context.Context, filter fleet.TeamFilter, targets fleet.HostTargets) ([]uint, error) {
+		return targets.HostIDs, nil
+	}
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, ids []uint) ([]*fleet.Host, error) {
+		hosts := make([]*fleet.Host, 0, len(ids))
+		for _, id := range ids {
+			h := &fleet.Host{ID: id}
+			if id == 1 {
+				h.TeamID = ptr.Uint(1)
+			}
+			hosts = append(hosts, h)
+		}
+		return hosts, nil
+	}
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{Scripts: fleet.ScriptSettings{Enabled: true, AllowAny: true}}, nil
+	}
+	ds.TeamScriptSettingsFunc = func(ctx context.Context, teamID uint) (*fleet.ScriptSettings, error) {
+		return &fleet.ScriptSettings{Enabled: true, AllowAny: true}, nil
+	}
+	ds.NewHostScriptExecutionsFunc = func(ctx context.Context, execs []*fleet.HostScriptExecution) ([]*fleet.HostScriptExecution, error) {
+		return execs, nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		return nil
+	}
+	ds.HostScriptExecutionFunc = func(ctx context.Context, id uint) (*fleet.HostScriptExecution, error) {
+		return &fleet.HostScriptExecution{ID: id, HostID: id}, nil
+	}
+	ds.HostLiteFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		if id == 1 {
+			return &fleet.Host{ID: id, TeamID: ptr.Uint(1)}, nil
+		}
+		return &fleet.Host{ID: id}, nil
+	}
+
+	testCases := []struct {
+		name             string
+		user             *fleet.User
+		shouldFailTeam   bool
+		shouldFailGlobal bool
+		shouldFailRead   bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, true, true, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, true, true, true},
+		{"team admin, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, false, true, false},
+		{"team maintainer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}}, true, true, false},
+		{"team admin, different team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, true, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 1", HostIDs: []uint{1}})
+			checkAuthErr(t, tt.shouldFailTeam, err)
+			_, err = svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 1", HostIDs: []uint{2}})
+			checkAuthErr(t, tt.shouldFailGlobal, err)
+			// targeting hosts of both teams requires being allowed on both
+			_, err = svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 1", HostIDs: []uint{1, 2}})
+			checkAuthErr(t, tt.shouldFailTeam || tt.shouldFailGlobal, err)
+
+			// reading an execution follows the rules of the host script executions
+			_, err = svc.GetScriptExecution(ctx, 1)
+			checkAuthErr(t, tt.shouldFailRead, err)
+		})
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	user := &fleet.User{ID: 3, GlobalRole: ptr.String(fleet.RoleAdmin)}
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: user})
+
+	var gotTargets fleet.HostTargets
+	ds.HostIDsInTargetsFunc = func(ctx context.Context, filter fleet.TeamFilter, targets fleet.HostTargets) ([]uint, error) {
+		gotTargets = targets
+		require.Equal(t, user, filter.User)
+		require.False(t, filter.IncludeObserver)
+		return []uint{1, 2, 3}, nil
+	}
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, ids []uint) ([]*fleet.Host, error) {
+		return []*fleet.Host{{ID: 1}, {ID: 2, TeamID: ptr.Uint(1)}, {ID: 3, TeamID: ptr.Uint(1)}}, nil
+	}
+	appConfig := &fleet.AppConfig{Scripts: fleet.ScriptSettings{Enabled: true, AllowAny: true}}
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return appConfig, nil
+	}
+	teamSettings := &fleet.ScriptSettings{Enabled: true, AllowAny: true}
+	ds.TeamScriptSettingsFunc = func(ctx context.Context, teamID uint) (*fleet.ScriptSettings, error) {
+		return teamSettings, nil
+	}
+	var queued []*fleet.HostScriptExecution
+	ds.NewHostScriptExecutionsFunc = func(ctx context.Context, execs []*fleet.HostScriptExecution) ([]*fleet.HostScriptExecution, error) {
+		queued = execs
+		return execs, nil
+	}
+	var activityDetails map[string]interface{}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.Equal(t, fleet.ActivityTypeRanScript, activityType)
+		activityDetails = *details
+		return nil
+	}
+
+	// invalid payloads
+	_, err := svc.RunScript(ctx, fleet.RunScriptPayload{Contents: " ", HostIDs: []uint{1}})
+	require.ErrorContains(t, err, "script contents cannot be empty")
+	_, err = svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 1"})
+	require.ErrorContains(t, err, "at least one host or label must be targeted")
+	require.False(t, ds.HostIDsInTargetsFuncInvoked)
+
+	execs, err := svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 1", LabelIDs: []uint{4}})
+	require.NoError(t, err)
+	require.Equal(t, fleet.HostTargets{LabelIDs: []uint{4}}, gotTargets)
+	require.Len(t, execs, 3)
+	require.Len(t, queued, 3)
+	for i, exec := range queued {
+		require.Equal(t, uint(i+1), exec.HostID)
+		require.Equal(t, fleet.ScriptExecutionTypeScript, exec.Type)
+		require.Equal(t, "echo 1", exec.Contents)
+		require.Nil(t, exec.PolicyID)
+	}
+	require.Equal(t, 3, activityDetails["targets_count"])
+	require.Equal(t, fleet.ScriptHash("echo 1"), activityDetails["script_hash"])
+
+	// scripts disabled for a team of the targeted hosts, nothing is queued
+	queued = nil
+	teamSettings.Enabled = false
+	_, err = svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 1", LabelIDs: []uint{4}})
+	require.ErrorContains(t, err, "scripts are disabled for team 1")
+	require.Empty(t, queued)
+
+	// script not in the allowlist of the hosts without a team
+	teamSettings.Enabled = true
+	appConfig.Scripts.AllowAny = false
+	appConfig.Scripts.Allowlist = []string{fleet.ScriptHash("echo 2")}
+	_, err = svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 1", LabelIDs: []uint{4}})
+	require.ErrorContains(t, err, "is not in the allowlist for hosts without a team")
+	require.Empty(t, queued)
+
+	_, err = svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 2", LabelIDs: []uint{4}})
+	require.NoError(t, err)
+	require.Len(t, queued, 3)
+
+	// no activity is created if the executions cannot be queued
+	ds.NewActivityFuncInvoked = false
+	ds.NewHostScriptExecutionsFunc = func(ctx context.Context, execs []*fleet.HostScriptExecution) ([]*fleet.HostScriptExecution, error) {
+		return nil, errors.New("insert failed")
+	}
+	_, err = svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 2", LabelIDs: []uint{4}})
+	require.ErrorContains(t, err, "insert failed")
+	require.False(t, ds.NewActivityFuncInvoked)
+
+	// no targeted hosts
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, ids []uint) ([]*fleet.Host, error) {
+		return nil, nil
+	}
+	_, err = svc.RunScript(ctx, fleet.RunScriptPayload{Contents: "echo 2", LabelIDs: []uint{4}})
+	require.ErrorContains(t, err, "no hosts targeted")
+}
+
+func TestPolicyRemediationsAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.NewTeamPolicyFunc = func(ctx context.Context, teamID uint, authorID *uint, args fleet.PolicyPayload) (*fleet.Policy, error) {
+		return &fleet.Policy{PolicyData: fleet.PolicyData{ID: 1, TeamID: ptr.Uint(teamID)}}, nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		return nil
+	}
+	ds.TeamFunc = func(ctx context.Context, tid uint) (*fleet.Team, error) {
+		return &fleet.Team{ID: tid}, nil
+	}
+	ds.ListTeamPoliciesFunc = func(ctx context.Context, teamID uint) (tpol, ipol []*fleet.Policy, err error) {
+		return []*fleet.Policy{{PolicyData: fleet.PolicyData{
+				ID:              1,
+				TeamID:          ptr.Uint(teamID),
+				RemediationType: fleet.ScriptExecutionTypeScript,
+				Remediation:     ptr.String("echo secret"),
+			}}},
+			[]*fleet.Policy{{PolicyData: fleet.PolicyData{
+				ID:              2,
+				RemediationType: fleet.ScriptExecutionTypeScript,
+				Remediation:     ptr.String("echo secret"),
+			}}},
+			nil
+	}
+
+	testCases := []struct {
+		name             string
+		user             *fleet.User
+		shouldFailTeam   bool
+		shouldFailGlobal bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, true, true},
+		{"team admin", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, false, true},
+		{"team maintainer", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}}, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			// setting a remediation requires being allowed to run scripts
+			_, err := svc.NewTeamPolicy(ctx, 1, fleet.PolicyPayload{
+				Name:            "p",
+				Query:           "select 1",
+				RemediationType: fleet.ScriptExecutionTypeScript,
+				Remediation:     "echo 1",
+			})
+			checkAuthErr(t, tt.shouldFailTeam, err)
+			_, err = svc.NewTeamPolicy(ctx, 1, fleet.PolicyPayload{Name: "p", Query: "select 1"})
+			require.NoError(t, err)
+
+			// the remediations are only returned to the users that can set them
+			tpol, ipol, err := svc.ListTeamPolicies(ctx, 1)
+			require.NoError(t, err)
+			require.Len(t, tpol, 1)
+			require.Len(t, ipol, 1)
+			require.Equal(t, fleet.ScriptExecutionTypeScript, tpol[0].RemediationType)
+			require.Equal(t, tt.shouldFailTeam, tpol[0].Remediation == nil)
+			require.Equal(t, tt.shouldFailGlobal, ipol[0].Remediation == nil)
+		})
+	}
+}
@@ -182,6 +182,10 @@ func (svc *Service) Login(ctx context.Context, email, password string) (*fleet.U
 		return nil, nil, fleet.NewAuthFailedError("password login disabled for sso users")
 	}
 
+	if user.Disabled {
+		return nil, nil, fleet.NewAuthFailedError("user is disabled")
+	}
+
 	session, err := svc.makeSession(ctx, user.ID)
 	if err != nil {
 		return nil, nil, fleet.NewAuthFailedError(err.Error())
@@ -282,6 +286,20 @@ func (svc *Service) InitiateSSO(ctx context.Context, redirectURL string) (string
 		return "", ctxerr.Wrap(ctx, ssoError{err: err, code: ssoOrgDisabled}, "callback sso")
 	}
 
+	if appConfig.SSOSettings.IsOIDC() {
+		settings, err := svc.getOIDCSettings(ctx, appConfig)
+		if err != nil {
+			return "", ctxerr.Wrap(ctx, err, "InitiateSSO getting oidc settings")
+		}
+		settings.OriginalURL = redirectURL
+
+		idpURL, err := sso.CreateOIDCAuthorizationRequest(settings)
+		if err != nil {
+			return "", ctxerr.Wrap(ctx, err, "InitiateSSO creating oidc authorization")
+		}
+		return idpURL, nil
+	}
+
 	metadata, err := svc.getMetadata(appConfig)
 	if err != nil {
 		return "", ctxerr.Wrap(ctx, err, "InitiateSSO getting metadata")
@@ -335,6 +353,18 @@ func (callbackSSORequest) DecodeRequest(ctx context.Context, r *http.Request) (i
 	return authResponse, nil
 }
 
+// callbackOIDCRequest decodes the OpenID Connect authorization response, which
+// is sent by the identity provider as query parameters of a GET request.
+type callbackOIDCRequest struct{}
+
+func (callbackOIDCRequest) DecodeRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	authResponse, err := sso.DecodeOIDCAuthResponse(r.URL.Query())
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, &fleet.BadRequestError{Message: err.Error()}, "decoding oidc callback")
+	}
+	return authResponse, nil
+}
+
 type callbackSSOResponse struct {
 	content string
 	Err     error `json:"error,omitempty"`
@@ -421,6 +451,15 @@ func (svc *Service) InitSSOCallback(ctx context.Context, auth fleet.Auth) (strin
 		return "", ctxerr.Wrap(ctx, ssoError{err: err, code: ssoOrgDisabled}, "callback sso")
 	}
 
+	oidcAuth, isOIDC := auth.(*sso.OIDCAuthResponse)
+	if isOIDC != appConfig.SSOSettings.IsOIDC() {
+		err := ctxerr.New(ctx, "sso response does not match the configured sso protocol")
+		return "", ctxerr.Wrap(ctx, ssoError{err: err, code: ssoOrgDisabled}, "callback sso")
+	}
+	if isOIDC {
+		return svc.initOIDCCallback(ctx, appConfig, oidcAuth)
+	}
+
 	// Load the request metadata if available
 
 	// localhost:9080/simplesaml/saml2/idp/SSOService.php?spentityid=https://localhost:8080
@@ -474,6 +513,29 @@ func (svc *Service) InitSSOCallback(ctx context.Context, auth fleet.Auth) (strin
 	return redirectURL, nil
 }
 
+// initOIDCCallback completes the OpenID Connect flow: the authorization code
+// is exchanged for an ID token using the state stored when the flow was
+// initiated, and the validated claims are attached to auth.
+func (svc *Service) initOIDCCallback(ctx context.Context, appConfig *fleet.AppConfig, auth *sso.OIDCAuthResponse) (string, error) {
+	session, err := svc.ssoSessionStore.Get(auth.RequestID())
+	if err != nil {
+		return "", ctxerr.Wrap(ctx, err, "sso request invalid")
+	}
+	// Remove session to so that is can't be reused before it expires.
+	if err := svc.ssoSessionStore.Expire(auth.RequestID()); err != nil {
+		return "", ctxerr.Wrap(ctx, err, "remove sso request")
+	}
+
+	settings, err := svc.getOIDCSettings(ctx, appConfig)
+	if err != nil {
+		return "", ctxerr.Wrap(ctx, err, "get oidc settings")
+	}
+	if err := sso.ExchangeOIDCCode(ctx, settings, session, auth); err != nil {
+		return "", ctxerr.Wrap(ctx, err, "oidc token validation failed")
+	}
+	return session.OriginalURL, nil
+}
+
 func (svc *Service) GetSSOUser(ctx context.Context, auth fleet.Auth) (*fleet.User, error) {
 	user, err := svc.ds.UserByEmail(ctx, auth.UserID())
 	if err != nil {
@@ -494,6 +556,10 @@ func (svc *Service) LoginSSOUser(ctx context.Context, user *fleet.User, redirect
 		err := ctxerr.New(ctx, "user not configured to use sso")
 		return nil, ctxerr.Wrap(ctx, ssoError{err: err, code: ssoAccountDisabled})
 	}
+	if user.Disabled {
+		err := ctxerr.New(ctx, "user is disabled")
+		return nil, ctxerr.Wrap(ctx, ssoError{err: err, code: ssoAccountDisabled})
+	}
 	session, err := svc.makeSession(ctx, user.ID)
 	if err != nil {
 		return nil, ctxerr.Wrap(ctx, err, "make session in sso callback")
@@ -582,6 +648,21 @@ func (svc *Service) getMetadata(config *fleet.AppConfig) (*sso.Metadata, error)
 	return nil, fmt.Errorf("missing metadata for idp %s", config.SSOSettings.IDPName)
 }
 
+func (svc *Service) getOIDCSettings(ctx context.Context, config *fleet.AppConfig) (*sso.OIDCSettings, error) {
+	provider, err := sso.GetOIDCProviderMetadata(ctx, config.SSOSettings.OIDCIssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	return &sso.OIDCSettings{
+		Provider:     provider,
+		ClientID:     config.SSOSettings.OIDCClientID,
+		ClientSecret: config.SSOSettings.OIDCClientSecret,
+		RedirectURL:  config.ServerSettings.ServerURL + svc.config.Server.URLPrefix + "/api/v1/fleet/sso/callback",
+		Scopes:       config.SSOSettings.OIDCScopes,
+		SessionStore: svc.ssoSessionStore,
+	}, nil
+}
+
 func (svc *Service) GetSessionByKey(ctx context.Context, key string) (*fleet.Session, error) {
 	session, err := svc.ds.SessionByKey(ctx, key)
 	if err != nil {
@@ -2,9 +2,15 @@ package service
 
 import (
 	"context"
+	"net/http"
 	"time"
 
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/logging"
 	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/sbom"
+	"github.com/google/uuid"
+	"github.com/kolide/kit/version"
 )
 
 /////////////////////////////////////////////////////////////////////////////////
@@ -139,3 +145,203 @@ func (svc Service) CountSoftware(ctx context.Context, opt fleet.SoftwareListOpti
 
 	return svc.ds.CountSoftware(ctx, opt)
 }
+
+/////////////////////////////////////////////////////////////////////////////////
+// List changes
+/////////////////////////////////////////////////////////////////////////////////
+
+type listSoftwareChangesRequest struct {
+	ListOptions fleet.ListOptions `url:"list_options"`
+	TeamID      *uint             `query:"team_id,optional"`
+	HostID      *uint             `query:"host_id,optional"`
+	Action      string            `query:"action,optional"`
+	From        string            `query:"from,optional"`
+	To          string            `query:"to,optional"`
+}
+
+type listSoftwareChangesResponse struct {
+	Changes []*fleet.SoftwareChange `json:"changes"`
+	Err     error                   `json:"error,omitempty"`
+}
+
+func (r listSoftwareChangesResponse) error() error { return r.Err }
+
+func listSoftwareChangesEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listSoftwareChangesRequest)
+
+	opt := fleet.SoftwareChangeListOptions{
+		ListOptions: req.ListOptions,
+		TeamID:      req.TeamID,
+		HostID:      req.HostID,
+		Action:      fleet.SoftwareChangeAction(req.Action),
+	}
+	var err error
+	if opt.From, err = parseTimestampParam("from", req.From); err != nil {
+		return listSoftwareChangesResponse{Err: err}, nil
+	}
+	if opt.To, err = parseTimestampParam("to", req.To); err != nil {
+		return listSoftwareChangesResponse{Err: err}, nil
+	}
+
+	changes, err := svc.ListSoftwareChanges(ctx, opt)
+	if err != nil {
+		return listSoftwareChangesResponse{Err: err}, nil
+	}
+	if changes == nil {
+		changes = []*fleet.SoftwareChange{}
+	}
+	return listSoftwareChangesResponse{Changes: changes}, nil
+}
+
+func (svc *Service) ListSoftwareChanges(ctx context.Context, opt fleet.SoftwareChangeListOptions) ([]*fleet.SoftwareChange, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.AuthzSoftwareInventory{
+		TeamID: opt.TeamID,
+	}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+
+	if opt.Action != "" && !opt.Action.IsValid() {
+		return nil, ctxerr.Wrap(ctx, fleet.NewInvalidArgumentError("action", `must be "installed" or "uninstalled"`))
+	}
+	if opt.From != nil && opt.To != nil && !opt.From.Before(*opt.To) {
+		return nil, ctxerr.Wrap(ctx, fleet.NewInvalidArgumentError("from", "must be before to"))
+	}
+
+	return svc.ds.ListSoftwareChanges(ctx, opt)
+}
+
+/////////////////////////////////////////////////////////////////////////////////
+// SBOM
+/////////////////////////////////////////////////////////////////////////////////
+
+type getSBOMRequest struct {
+	fleet.SBOMOptions
+	Format string `query:"format,optional"`
+}
+
+type getSBOMResponse struct {
+	Format   fleet.SBOMFormat `json:"-"` // used to set the content type, see the hijackRender method
+	Document []byte           `json:"-"` // rendered explicitly, see the hijackRender method
+	Err      error            `json:"error,omitempty"`
+}
+
+func (r getSBOMResponse) error() error { return r.Err }
+
+func (r getSBOMResponse) hijackRender(ctx context.Context, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", sbom.ContentType(r.Format))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := w.Write(r.Document); err != nil {
+		logging.WithErr(ctx, err)
+	}
+}
+
+func getSBOMEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*getSBOMRequest)
+	format := fleet.SBOMFormat(req.Format)
+	if format == "" {
+		format = fleet.SBOMFormatCycloneDX
+	}
+	doc, err := svc.GenerateSBOM(ctx, format, req.SBOMOptions)
+	if err != nil {
+		return getSBOMResponse{Err: err}, nil
+	}
+	return getSBOMResponse{Format: format, Document: doc}, nil
+}
+
+// sbomSoftwarePageSize is the number of software loaded at once to generate
+// an SBOM document.
+const sbomSoftwarePageSize = 1000
+
+func (svc *Service) GenerateSBOM(ctx context.Context, format fleet.SBOMFormat, opt fleet.SBOMOptions) ([]byte, error) {
+	var subject sbom.Subject
+	if opt.HostID != nil {
+		// First ensure the user has access to list hosts, then check the specific
+		// host once team_id is loaded.
+		if err := svc.authz.Authorize(ctx, &fleet.Host{}, fleet.ActionList); err != nil {
+			return nil, err
+		}
+		host, err := svc.ds.Host(ctx, *opt.HostID)
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "get host")
+		}
+		if err := svc.authz.Authorize(ctx, host, fleet.ActionRead); err != nil {
+			return nil, err
+		}
+		subject = sbom.Subject{Name: host.DisplayName(), Host: host}
+		// the software of the host is listed regardless of its team
+		opt.TeamID = nil
+	} else {
+		if err := svc.authz.Authorize(ctx, &fleet.AuthzSoftwareInventory{
+			TeamID: opt.TeamID,
+		}, fleet.ActionRead); err != nil {
+			return nil, err
+		}
+		if opt.TeamID != nil {
+			team, err := svc.ds.Team(ctx, *opt.TeamID)
+			if err != nil {
+				return nil, ctxerr.Wrap(ctx, err, "get team")
+			}
+			subject = sbom.Subject{Name: team.Name}
+		} else {
+			config, err := svc.ds.AppConfig(ctx)
+			if err != nil {
+				return nil, ctxerr.Wrap(ctx, err, "get app config")
+			}
+			subject = sbom.Subject{Name: config.OrgInfo.OrgName}
+			if subject.Name == "" {
+				subject.Name = "Fleet"
+			}
+		}
+	}
+
+	if !format.IsValid() {
+		return nil, ctxerr.Wrap(ctx, fleet.NewInvalidArgumentError("format", `must be "cyclonedx" or "spdx"`))
+	}
+
+	listOpt := fleet.SoftwareListOptions{
+		ListOptions: fleet.ListOptions{
+			PerPage:        sbomSoftwarePageSize,
+			OrderKey:       "id",
+			OrderDirection: fleet.OrderAscending,
+		},
+		HostID:           opt.HostID,
+		TeamID:           opt.TeamID,
+		IncludeCVEScores: svc.license.IsPremium(),
+	}
+	var software []fleet.Software
+	for {
+		page, err := svc.ds.ListSoftware(ctx, listOpt)
+		if err != nil {
+			return nil, ctxerr.Wrap(ctx, err, "list software")
+		}
+		software = append(software, page...)
+		if len(page) < sbomSoftwarePageSize {
+			break
+		}
+		listOpt.Page++
+	}
+
+	doc, err := sbom.Generate(format, sbom.Metadata{
+		Subject:     subject,
+		ID:          uuid.NewString(),
+		Timestamp:   svc.clock.Now(),
+		ToolVersion: version.Version().Version,
+	}, software)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "generate SBOM")
+	}
+	return doc, nil
+}
+
+// parseTimestampParam parses the RFC3339 timestamp of the query parameter, it
+// returns nil if the parameter is not set.
+func parseTimestampParam(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fleet.NewInvalidArgumentError(name, "must be a RFC3339 timestamp")
+	}
+	return &ts, nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fleetdm/fleet/v4/server/authz"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Create software rule
+////////////////////////////////////////////////////////////////////////////////
+
+type createSoftwareRuleRequest struct {
+	fleet.SoftwareRulePayload
+}
+
+type createSoftwareRuleResponse struct {
+	Rule *fleet.SoftwareRule `json:"rule,omitempty"`
+	Err  error               `json:"error,omitempty"`
+}
+
+func (r createSoftwareRuleResponse) error() error { return r.Err }
+
+func createSoftwareRuleEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*createSoftwareRuleRequest)
+	rule, err := svc.NewSoftwareRule(ctx, req.SoftwareRulePayload)
+	if err != nil {
+		return createSoftwareRuleResponse{Err: err}, nil
+	}
+	return createSoftwareRuleResponse{Rule: rule}, nil
+}
+
+func (svc *Service) NewSoftwareRule(ctx context.Context, p fleet.SoftwareRulePayload) (*fleet.SoftwareRule, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.SoftwareRule{TeamID: p.TeamID}, fleet.ActionWrite); err != nil {
+		return nil, err
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, fleet.ErrNoContext
+	}
+
+	rule := &fleet.SoftwareRule{
+		TeamID:            p.TeamID,
+		Name:              p.Name,
+		Description:       p.Description,
+		Action:            p.Action,
+		SoftwareName:      p.SoftwareName,
+		SoftwareNameRegex: p.SoftwareNameRegex,
+		BundleIdentifier:  p.BundleIdentifier,
+		VersionRange:      p.VersionRange,
+		PolicyID:          p.PolicyID,
+		AuthorID:          ptr.Uint(vc.UserID()),
+	}
+	if err := svc.verifySoftwareRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	rule, err := svc.ds.NewSoftwareRule(ctx, rule)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "create software rule")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeCreatedSoftwareRule,
+		&map[string]interface{}{"rule_id": rule.ID, "rule_name": rule.Name, "team_id": rule.TeamID},
+	); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "create activity for software rule creation")
+	}
+	return rule, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List software rules
+////////////////////////////////////////////////////////////////////////////////
+
+type listSoftwareRulesRequest struct {
+	TeamID *uint `query:"team_id,optional"`
+}
+
+type listSoftwareRulesResponse struct {
+	Rules []*fleet.SoftwareRule `json:"rules"`
+	Err   error                 `json:"error,omitempty"`
+}
+
+func (r listSoftwareRulesResponse) error() error { return r.Err }
+
+func listSoftwareRulesEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*listSoftwareRulesRequest)
+	rules, err := svc.ListSoftwareRules(ctx, req.TeamID)
+	if err != nil {
+		return listSoftwareRulesResponse{Err: err}, nil
+	}
+	if rules == nil {
+		rules = []*fleet.SoftwareRule{}
+	}
+	return listSoftwareRulesResponse{Rules: rules}, nil
+}
+
+func (svc *Service) ListSoftwareRules(ctx context.Context, teamID *uint) ([]*fleet.SoftwareRule, error) {
+	if err := svc.authz.Authorize(ctx, &fleet.SoftwareRule{TeamID: teamID}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+	return svc.ds.ListSoftwareRules(ctx, teamID)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Modify software rule
+////////////////////////////////////////////////////////////////////////////////
+
+type modifySoftwareRuleRequest struct {
+	ID uint `url:"id"`
+	fleet.ModifySoftwareRulePayload
+}
+
+type modifySoftwareRuleResponse struct {
+	Rule *fleet.SoftwareRule `json:"rule,omitempty"`
+	Err  error               `json:"error,omitempty"`
+}
+
+func (r modifySoftwareRuleResponse) error() error { return r.Err }
+
+func modifySoftwareRuleEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*modifySoftwareRuleRequest)
+	rule, err := svc.ModifySoftwareRule(ctx, req.ID, req.ModifySoftwareRulePayload)
+	if err != nil {
+		return modifySoftwareRuleResponse{Err: err}, nil
+	}
+	return modifySoftwareRuleResponse{Rule: rule}, nil
+}
+
+func (svc *Service) ModifySoftwareRule(ctx context.Context, id uint, p fleet.ModifySoftwareRulePayload) (*fleet.SoftwareRule, error) {
+	rule, err := svc.authorizeSoftwareRule(ctx, id, fleet.ActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Name != nil {
+		rule.Name = *p.Name
+	}
+	if p.Description != nil {
+		rule.Description = *p.Description
+	}
+	if p.Action != nil {
+		rule.Action = *p.Action
+	}
+	if p.SoftwareName != nil {
+		rule.SoftwareName = *p.SoftwareName
+	}
+	if p.SoftwareNameRegex != nil {
+		rule.SoftwareNameRegex = *p.SoftwareNameRegex
+	}
+	if p.BundleIdentifier != nil {
+		rule.BundleIdentifier = *p.BundleIdentifier
+	}
+	if p.VersionRange != nil {
+		rule.VersionRange = *p.VersionRange
+	}
+	if p.PolicyID != nil {
+		rule.PolicyID = p.PolicyID
+		if *p.PolicyID == 0 {
+			rule.PolicyID = nil
+		}
+	}
+	if err := svc.verifySoftwareRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	if err := svc.ds.SaveSoftwareRule(ctx, rule); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "save software rule")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeEditedSoftwareRule,
+		&map[string]interface{}{"rule_id": rule.ID, "rule_name": rule.Name, "team_id": rule.TeamID},
+	); err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "create activity for software rule modification")
+	}
+	return rule, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete software rule
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteSoftwareRuleRequest struct {
+	ID uint `url:"id"`
+}
+
+type deleteSoftwareRuleResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteSoftwareRuleResponse) error() error { return r.Err }
+
+func deleteSoftwareRuleEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
+	req := request.(*deleteSoftwareRuleRequest)
+	if err := svc.DeleteSoftwareRule(ctx, req.ID); err != nil {
+		return deleteSoftwareRuleResponse{Err: err}, nil
+	}
+	return deleteSoftwareRuleResponse{}, nil
+}
+
+func (svc *Service) DeleteSoftwareRule(ctx context.Context, id uint) error {
+	rule, err := svc.authorizeSoftwareRule(ctx, id, fleet.ActionWrite)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.ds.DeleteSoftwareRule(ctx, id); err != nil {
+		return ctxerr.Wrap(ctx, err, "delete software rule")
+	}
+
+	if err := svc.ds.NewActivity(
+		ctx,
+		authz.UserFromContext(ctx),
+		fleet.ActivityTypeDeletedSoftwareRule,
+		&map[string]interface{}{"rule_id": rule.ID, "rule_name": rule.Name, "team_id": rule.TeamID},
+	); err != nil {
+		return ctxerr.Wrap(ctx, err, "create activity for software rule deletion")
+	}
+	return nil
+}
+
+// authorizeSoftwareRule checks that the user can perform the action on the
+// software rule and returns the rule.
+func (svc *Service) authorizeSoftwareRule(ctx context.Context, id uint, action string) (*fleet.SoftwareRule, error) {
+	// First make sure the user can read the global software rules, which team
+	// users can.
+	if err := svc.authz.Authorize(ctx, &fleet.SoftwareRule{}, fleet.ActionRead); err != nil {
+		return nil, err
+	}
+	rule, err := svc.ds.SoftwareRule(ctx, id)
+	if err != nil {
+		return nil, ctxerr.Wrap(ctx, err, "get software rule")
+	}
+	if err := svc.authz.Authorize(ctx, rule, action); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// verifySoftwareRule verifies the rule is valid and that its policy, if any,
+// belongs to the same team as the rule (or is a global policy for a global
+// rule).
+func (svc *Service) verifySoftwareRule(ctx context.Context, rule *fleet.SoftwareRule) error {
+	if err := rule.Verify(); err != nil {
+		return ctxerr.Wrap(ctx, &fleet.BadRequestError{
+			Message: fmt.Sprintf("software rule payload verification: %s", err),
+		})
+	}
+	if rule.PolicyID == nil {
+		return nil
+	}
+	policy, err := svc.ds.Policy(ctx, *rule.PolicyID)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "get software rule policy")
+	}
+	sameTeam := (policy.TeamID == nil && rule.TeamID == nil) ||
+		(policy.TeamID != nil && rule.TeamID != nil && *policy.TeamID == *rule.TeamID)
+	if !sameTeam {
+		return ctxerr.Wrap(ctx, fleet.NewInvalidArgumentError("policy_id", "policy must belong to the same team as the software rule"))
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftwareRulesAuth(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+
+	ds.SoftwareRuleFunc = func(ctx context.Context, id uint) (*fleet.SoftwareRule, error) {
+		if id == 1 {
+			return &fleet.SoftwareRule{ID: id, TeamID: ptr.Uint(1), Name: "r", Action: fleet.SoftwareRuleDeny, SoftwareName: "foo"}, nil
+		}
+		return &fleet.SoftwareRule{ID: id, Name: "r", Action: fleet.SoftwareRuleDeny, SoftwareName: "foo"}, nil
+	}
+	ds.NewSoftwareRuleFunc = func(ctx context.Context, rule *fleet.SoftwareRule) (*fleet.SoftwareRule, error) {
+		return rule, nil
+	}
+	ds.ListSoftwareRulesFunc = func(ctx context.Context, teamID *uint) ([]*fleet.SoftwareRule, error) {
+		return nil, nil
+	}
+	ds.SaveSoftwareRuleFunc = func(ctx context.Context, rule *fleet.SoftwareRule) error {
+		return nil
+	}
+	ds.DeleteSoftwareRuleFunc = func(ctx context.Context, id uint) error {
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		return nil
+	}
+
+	payload := func(teamID *uint) fleet.SoftwareRulePayload {
+		return fleet.SoftwareRulePayload{TeamID: teamID, Name: "r", Action: fleet.SoftwareRuleDeny, SoftwareName: "foo"}
+	}
+
+	testCases := []struct {
+		name            string
+		user            *fleet.User
+		shouldFailWrite bool
+		shouldFailRead  bool
+	}{
+		{"global admin", &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}, false, false},
+		{"global maintainer", &fleet.User{GlobalRole: ptr.String(fleet.RoleMaintainer)}, false, false},
+		{"global observer", &fleet.User{GlobalRole: ptr.String(fleet.RoleObserver)}, true, false},
+		{"team admin, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleAdmin}}}, false, false},
+		{"team maintainer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleMaintainer}}}, false, false},
+		{"team observer, same team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 1}, Role: fleet.RoleObserver}}}, true, false},
+		{"team admin, different team", &fleet.User{Teams: []fleet.UserTeam{{Team: fleet.Team{ID: 2}, Role: fleet.RoleAdmin}}}, true, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: tt.user})
+
+			_, err := svc.NewSoftwareRule(ctx, payload(ptr.Uint(1)))
+			checkAuthErr(t, tt.shouldFailWrite, err)
+			_, err = svc.ListSoftwareRules(ctx, ptr.Uint(1))
+			checkAuthErr(t, tt.shouldFailRead, err)
+			_, err = svc.ModifySoftwareRule(ctx, 1, fleet.ModifySoftwareRulePayload{Name: ptr.String("r2")})
+			checkAuthErr(t, tt.shouldFailWrite, err)
+			err = svc.DeleteSoftwareRule(ctx, 1)
+			checkAuthErr(t, tt.shouldFailWrite, err)
+
+			// only global admins and maintainers can manage the global rules,
+			// all users can read them
+			globalWrite := tt.user.GlobalRole == nil || *tt.user.GlobalRole == fleet.RoleObserver
+			_, err = svc.NewSoftwareRule(ctx, payload(nil))
+			checkAuthErr(t, globalWrite, err)
+			_, err = svc.ModifySoftwareRule(ctx, 2, fleet.ModifySoftwareRulePayload{Name: ptr.String("r2")})
+			checkAuthErr(t, globalWrite, err)
+			_, err = svc.ListSoftwareRules(ctx, nil)
+			checkAuthErr(t, false, err)
+		})
+	}
+}
+
+func TestNewSoftwareRule(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.PolicyFunc = func(ctx context.Context, id uint) (*fleet.Policy, error) {
+		if id == 1 {
+			return &fleet.Policy{PolicyData: fleet.PolicyData{ID: id}}, nil
+		}
+		return &fleet.Policy{PolicyData: fleet.PolicyData{ID: id, TeamID: ptr.Uint(1)}}, nil
+	}
+	ds.NewSoftwareRuleFunc = func(ctx context.Context, rule *fleet.SoftwareRule) (*fleet.SoftwareRule, error) {
+		rule.ID = 5
+		return rule, nil
+	}
+	var activityDetails map[string]interface{}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.Equal(t, fleet.ActivityTypeCreatedSoftwareRule, activityType)
+		activityDetails = *details
+		return nil
+	}
+
+	rule, err := svc.NewSoftwareRule(ctx, fleet.SoftwareRulePayload{
+		Name:              "no torrents",
+		Action:            fleet.SoftwareRuleDeny,
+		SoftwareNameRegex: "(?i)torrent",
+		PolicyID:          ptr.Uint(1),
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint(5), rule.ID)
+	require.Equal(t, test.UserAdmin.ID, *rule.AuthorID)
+	require.Equal(t, map[string]interface{}{"rule_id": uint(5), "rule_name": "no torrents", "team_id": (*uint)(nil)}, activityDetails)
+
+	// invalid payload
+	ds.NewSoftwareRuleFuncInvoked = false
+	_, err = svc.NewSoftwareRule(ctx, fleet.SoftwareRulePayload{
+		Name:              "no torrents",
+		Action:            fleet.SoftwareRuleDeny,
+		SoftwareNameRegex: "torrent(",
+	})
+	var badRequestErr *fleet.BadRequestError
+	require.ErrorAs(t, err, &badRequestErr)
+	require.False(t, ds.NewSoftwareRuleFuncInvoked)
+
+	// the policy must belong to the rule's team
+	_, err = svc.NewSoftwareRule(ctx, fleet.SoftwareRulePayload{
+		Name:         "no torrents",
+		Action:       fleet.SoftwareRuleDeny,
+		SoftwareName: "uTorrent",
+		PolicyID:     ptr.Uint(2),
+	})
+	var invalidErr *fleet.InvalidArgumentError
+	require.ErrorAs(t, err, &invalidErr)
+	require.False(t, ds.NewSoftwareRuleFuncInvoked)
+}
+
+func TestModifySoftwareRule(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.SoftwareRuleFunc = func(ctx context.Context, id uint) (*fleet.SoftwareRule, error) {
+		return &fleet.SoftwareRule{
+			ID: id, Name: "old zoom", Action: fleet.SoftwareRuleDeny, BundleIdentifier: "us.zoom.xos", VersionRange: "< 5.0", PolicyID: ptr.Uint(1),
+		}, nil
+	}
+	var saved *fleet.SoftwareRule
+	ds.SaveSoftwareRuleFunc = func(ctx context.Context, rule *fleet.SoftwareRule) error {
+		saved = rule
+		return nil
+	}
+	ds.NewActivityFunc = func(ctx context.Context, user *fleet.User, activityType string, details *map[string]interface{}) error {
+		require.Equal(t, fleet.ActivityTypeEditedSoftwareRule, activityType)
+		return nil
+	}
+
+	// a policy ID of 0 removes the policy
+	rule, err := svc.ModifySoftwareRule(ctx, 3, fleet.ModifySoftwareRulePayload{
+		VersionRange: ptr.String("< 5.5"),
+		PolicyID:     ptr.Uint(0),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "< 5.5", rule.VersionRange)
+	require.Nil(t, rule.PolicyID)
+	require.Equal(t, "old zoom", saved.Name)
+
+	// an allow rule cannot have a policy
+	ds.SaveSoftwareRuleFuncInvoked = false
+	allow := fleet.SoftwareRuleAllow
+	_, err = svc.ModifySoftwareRule(ctx, 3, fleet.ModifySoftwareRulePayload{Action: &allow})
+	var badRequestErr *fleet.BadRequestError
+	require.ErrorAs(t, err, &badRequestErr)
+	require.False(t, ds.SaveSoftwareRuleFuncInvoked)
+}
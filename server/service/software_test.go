@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/fleetdm/fleet/v4/server/contexts/viewer"
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/fleetdm/fleet/v4/server/mock"
 	"github.com/fleetdm/fleet/v4/server/ptr"
+	"github.com/fleetdm/fleet/v4/server/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -62,6 +65,18 @@ func TestServiceSoftwareInventoryAuth(t *testing.T) {
 	ds.CountSoftwareFunc = func(ctx context.Context, opt fleet.SoftwareListOptions) (int, error) {
 		return 0, nil
 	}
+	ds.ListSoftwareChangesFunc = func(ctx context.Context, opt fleet.SoftwareChangeListOptions) ([]*fleet.SoftwareChange, error) {
+		return nil, nil
+	}
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{}, nil
+	}
+	ds.TeamFunc = func(ctx context.Context, tid uint) (*fleet.Team, error) {
+		return &fleet.Team{ID: tid, Name: "team1"}, nil
+	}
+	ds.HostFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		return &fleet.Host{ID: id, TeamID: ptr.Uint(1)}, nil
+	}
 	svc := newTestService(t, ds, nil, nil)
 
 	for _, tc := range []struct {
@@ -192,6 +207,114 @@ func TestServiceSoftwareInventoryAuth(t *testing.T) {
 				TeamID: ptr.Uint(1),
 			})
 			checkAuthErr(t, tc.shouldFailTeamRead, err)
+
+			// List all software changes.
+			_, err = svc.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{})
+			checkAuthErr(t, tc.shouldFailGlobalRead, err)
+
+			// List software changes for a team.
+			_, err = svc.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{
+				TeamID: ptr.Uint(1),
+			})
+			checkAuthErr(t, tc.shouldFailTeamRead, err)
+
+			// Export the SBOM of all software.
+			_, err = svc.GenerateSBOM(ctx, fleet.SBOMFormatCycloneDX, fleet.SBOMOptions{})
+			checkAuthErr(t, tc.shouldFailGlobalRead, err)
+
+			// Export the SBOM of a team.
+			_, err = svc.GenerateSBOM(ctx, fleet.SBOMFormatSPDX, fleet.SBOMOptions{TeamID: ptr.Uint(1)})
+			checkAuthErr(t, tc.shouldFailTeamRead, err)
+
+			// Export the SBOM of a host of the team.
+			_, err = svc.GenerateSBOM(ctx, fleet.SBOMFormatSPDX, fleet.SBOMOptions{HostID: ptr.Uint(1)})
+			checkAuthErr(t, tc.shouldFailTeamRead, err)
 		})
 	}
 }
+
+func TestListSoftwareChanges(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	var calledWithOpt fleet.SoftwareChangeListOptions
+	ds.ListSoftwareChangesFunc = func(ctx context.Context, opt fleet.SoftwareChangeListOptions) ([]*fleet.SoftwareChange, error) {
+		calledWithOpt = opt
+		return []*fleet.SoftwareChange{{ID: 1, Action: fleet.SoftwareChangeInstalled}}, nil
+	}
+
+	from := time.Now().Add(-time.Hour)
+	to := time.Now()
+	changes, err := svc.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{
+		Action: fleet.SoftwareChangeInstalled,
+		From:   &from,
+		To:     &to,
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, fleet.SoftwareChangeInstalled, calledWithOpt.Action)
+	require.Equal(t, &from, calledWithOpt.From)
+
+	ds.ListSoftwareChangesFuncInvoked = false
+	for _, opt := range []fleet.SoftwareChangeListOptions{
+		{Action: "updated"},
+		{From: &to, To: &from},
+	} {
+		_, err = svc.ListSoftwareChanges(ctx, opt)
+		var invalidErr *fleet.InvalidArgumentError
+		require.ErrorAs(t, err, &invalidErr)
+	}
+	require.False(t, ds.ListSoftwareChangesFuncInvoked)
+}
+
+func TestGenerateSBOM(t *testing.T) {
+	ds := new(mock.Store)
+	svc := newTestService(t, ds, nil, nil)
+	ctx := test.UserContext(test.UserAdmin)
+
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return &fleet.AppConfig{OrgInfo: fleet.OrgInfo{OrgName: "Acme"}}, nil
+	}
+	var calledWithOpts []fleet.SoftwareListOptions
+	ds.ListSoftwareFunc = func(ctx context.Context, opt fleet.SoftwareListOptions) ([]fleet.Software, error) {
+		calledWithOpts = append(calledWithOpts, opt)
+		// return a full page then a partial page
+		n := opt.PerPage
+		if opt.Page > 0 {
+			n = 1
+		}
+		software := make([]fleet.Software, 0, n)
+		for i := uint(0); i < n; i++ {
+			software = append(software, fleet.Software{ID: opt.Page*opt.PerPage + i + 1, Name: "foo", Version: "1.0", Source: "apps"})
+		}
+		return software, nil
+	}
+
+	doc, err := svc.GenerateSBOM(ctx, fleet.SBOMFormatCycloneDX, fleet.SBOMOptions{})
+	require.NoError(t, err)
+	require.Len(t, calledWithOpts, 2)
+	require.Equal(t, uint(1), calledWithOpts[1].Page)
+	require.Equal(t, "id", calledWithOpts[1].OrderKey)
+	require.Nil(t, calledWithOpts[1].TeamID)
+
+	var bom struct {
+		Metadata struct {
+			Properties []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"properties"`
+		} `json:"metadata"`
+		Components []json.RawMessage `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(doc, &bom))
+	require.Len(t, bom.Components, sbomSoftwarePageSize+1)
+	require.Len(t, bom.Metadata.Properties, 1)
+	require.Equal(t, "Acme", bom.Metadata.Properties[0].Value)
+
+	ds.ListSoftwareFuncInvoked = false
+	_, err = svc.GenerateSBOM(ctx, "swid", fleet.SBOMOptions{})
+	var invalidErr *fleet.InvalidArgumentError
+	require.ErrorAs(t, err, &invalidErr)
+	require.False(t, ds.ListSoftwareFuncInvoked)
+}
@@ -19,13 +19,19 @@ import (
 /////////////////////////////////////////////////////////////////////////////////
 
 type teamPolicyRequest struct {
-	TeamID      uint   `url:"team_id"`
-	QueryID     *uint  `json:"query_id"`
-	Query       string `json:"query"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Resolution  string `json:"resolution"`
-	Platform    string `json:"platform"`
+	TeamID           uint     `url:"team_id"`
+	QueryID          *uint    `json:"query_id"`
+	Query            string   `json:"query"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Resolution       string   `json:"resolution"`
+	Platform         string   `json:"platform"`
+	RemediationType  string   `json:"remediation_type"`
+	Remediation      string   `json:"remediation"`
+	Severity         string   `json:"severity"`
+	Tags             []string `json:"tags"`
+	LabelsIncludeAny []string `json:"labels_include_any"`
+	LabelsExcludeAny []string `json:"labels_exclude_any"`
 }
 
 type teamPolicyResponse struct {
@@ -38,12 +44,18 @@ func (r teamPolicyResponse) error() error { return r.Err }
 func teamPolicyEndpoint(ctx context.Context, request interface{}, svc fleet.Service) (interface{}, error) {
 	req := request.(*teamPolicyRequest)
 	resp, err := svc.NewTeamPolicy(ctx, req.TeamID, fleet.PolicyPayload{
-		QueryID:     req.QueryID,
-		Name:        req.Name,
-		Query:       req.Query,
-		Description: req.Description,
-		Resolution:  req.Resolution,
-		Platform:    req.Platform,
+		QueryID:          req.QueryID,
+		Name:             req.Name,
+		Query:            req.Query,
+		Description:      req.Description,
+		Resolution:       req.Resolution,
+		Platform:         req.Platform,
+		RemediationType:  req.RemediationType,
+		Remediation:      req.Remediation,
+		Severity:         req.Severity,
+		Tags:             req.Tags,
+		LabelsIncludeAny: req.LabelsIncludeAny,
+		LabelsExcludeAny: req.LabelsExcludeAny,
 	})
 	if err != nil {
 		return teamPolicyResponse{Err: err}, nil
@@ -59,6 +71,11 @@ func (svc Service) NewTeamPolicy(ctx context.Context, teamID uint, p fleet.Polic
 	}, fleet.ActionWrite); err != nil {
 		return nil, err
 	}
+	if p.RemediationType != "" || p.Remediation != "" {
+		if err := svc.authorizePolicyRemediation(ctx, ptr.Uint(teamID)); err != nil {
+			return nil, err
+		}
+	}
 
 	vc, ok := viewer.FromContext(ctx)
 	if !ok {
@@ -80,7 +97,7 @@ func (svc Service) NewTeamPolicy(ctx context.Context, teamID uint, p fleet.Polic
 		ctx,
 		authz.UserFromContext(ctx),
 		fleet.ActivityTypeCreatedPolicy,
-		&map[string]interface{}{"policy_id": policy.ID, "policy_name": policy.Name},
+		&map[string]interface{}{"policy_id": policy.ID, "policy_name": policy.Name, "team_id": policy.TeamID},
 	); err != nil {
 		return nil, err
 	}
@@ -125,7 +142,18 @@ func (svc *Service) ListTeamPolicies(ctx context.Context, teamID uint) (teamPoli
 		return nil, nil, ctxerr.Wrapf(ctx, err, "loading team %d", teamID)
 	}
 
-	return svc.ds.ListTeamPolicies(ctx, teamID)
+	teamPolicies, inheritedPolicies, err = svc.ds.ListTeamPolicies(ctx, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	redact := svc.policyRemediationRedactor(ctx)
+	for _, p := range teamPolicies {
+		redact(&p.PolicyData)
+	}
+	for _, p := range inheritedPolicies {
+		redact(&p.PolicyData)
+	}
+	return teamPolicies, inheritedPolicies, nil
 }
 
 /////////////////////////////////////////////////////////////////////////////////
@@ -166,6 +194,7 @@ func (svc Service) GetTeamPolicyByIDQueries(ctx context.Context, teamID uint, po
 	if err != nil {
 		return nil, err
 	}
+	svc.policyRemediationRedactor(ctx)(&teamPolicy.PolicyData)
 
 	return teamPolicy, nil
 }
@@ -243,7 +272,7 @@ func (svc Service) DeleteTeamPolicies(ctx context.Context, teamID uint, ids []ui
 			ctx,
 			authz.UserFromContext(ctx),
 			fleet.ActivityTypeDeletedPolicy,
-			&map[string]interface{}{"policy_id": id, "policy_name": policiesByID[id].Name},
+			&map[string]interface{}{"policy_id": id, "policy_name": policiesByID[id].Name, "team_id": policiesByID[id].TeamID},
 		); err != nil {
 			return nil, ctxerr.Wrap(ctx, err, "adding new activity for deleted policy")
 		}
@@ -321,6 +350,46 @@ func (svc *Service) modifyPolicy(ctx context.Context, teamID *uint, id uint, p f
 	if p.Platform != nil {
 		policy.Platform = *p.Platform
 	}
+	if p.RemediationType != nil || p.Remediation != nil {
+		var current string
+		if policy.Remediation != nil {
+			current = *policy.Remediation
+		}
+		if (p.RemediationType != nil && *p.RemediationType != policy.RemediationType) ||
+			(p.Remediation != nil && *p.Remediation != current) {
+			if err := svc.authorizePolicyRemediation(ctx, policy.TeamID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.RemediationType != nil {
+		policy.RemediationType = *p.RemediationType
+	}
+	if p.Remediation != nil {
+		policy.Remediation = p.Remediation
+	}
+	if p.Severity != nil {
+		policy.Severity = *p.Severity
+	}
+	if p.Tags != nil {
+		policy.Tags = fleet.PolicyTags(*p.Tags)
+	}
+	if p.LabelsIncludeAny != nil {
+		policy.LabelsIncludeAny = *p.LabelsIncludeAny
+	}
+	if p.LabelsExcludeAny != nil {
+		policy.LabelsExcludeAny = *p.LabelsExcludeAny
+	}
+	if err := policy.VerifyRemediation(); err != nil {
+		return nil, ctxerr.Wrap(ctx, &fleet.BadRequestError{
+			Message: fmt.Sprintf("policy payload verification: %s", err),
+		})
+	}
+	if err := policy.VerifyLabels(); err != nil {
+		return nil, ctxerr.Wrap(ctx, &fleet.BadRequestError{
+			Message: fmt.Sprintf("policy payload verification: %s", err),
+		})
+	}
 	logging.WithExtras(ctx, "name", policy.Name, "sql", policy.Query)
 
 	err = svc.ds.SavePolicy(ctx, policy)
@@ -333,10 +402,11 @@ func (svc *Service) modifyPolicy(ctx context.Context, teamID *uint, id uint, p f
 		ctx,
 		authz.UserFromContext(ctx),
 		fleet.ActivityTypeEditedPolicy,
-		&map[string]interface{}{"policy_id": policy.ID, "policy_name": policy.Name},
+		&map[string]interface{}{"policy_id": policy.ID, "policy_name": policy.Name, "team_id": policy.TeamID},
 	); err != nil {
 		return nil, err
 	}
+	svc.policyRemediationRedactor(ctx)(&policy.PolicyData)
 
 	return policy, nil
 }
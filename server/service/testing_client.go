@@ -20,6 +20,7 @@ import (
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/fleetdm/fleet/v4/server/pubsub"
 	"github.com/fleetdm/fleet/v4/server/sso"
+	"github.com/fleetdm/fleet/v4/server/sso/oidctest"
 	"github.com/fleetdm/fleet/v4/server/test"
 	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/assert"
@@ -299,3 +300,21 @@ func (ts *withServer) LoginSSOUser(username, password string) (fleet.Auth, strin
 	require.NoError(t, err)
 	return auth, string(body)
 }
+
+// LoginOIDCUser runs the OpenID Connect SSO flow against the mock identity
+// provider and returns the HTML body of the Fleet callback response.
+func (ts *withServer) LoginOIDCUser(idp *oidctest.Provider) string {
+	t := ts.s.T()
+
+	var resIni initiateSSOResponse
+	ts.DoJSON("POST", "/api/v1/fleet/sso", map[string]string{}, http.StatusOK, &resIni)
+	require.NotEmpty(t, resIni.URL)
+
+	params := idp.Authorize(t, resIni.URL)
+	res := ts.DoRawNoAuth("GET", "/api/v1/fleet/sso/callback?"+params.Encode(), nil, http.StatusOK)
+
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body)
+}
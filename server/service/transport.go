@@ -333,6 +333,50 @@ func hostListOptionsFromRequest(r *http.Request) (fleet.HostListOptions, error)
 		hopt.LowDiskSpaceFilter = &v
 	}
 
+	softwareStatus := r.URL.Query().Get("software_status")
+	if softwareStatus != "" {
+		if !fleet.HostSoftwareStatus(softwareStatus).IsValid() {
+			return hopt, ctxerr.Errorf(r.Context(), "invalid software_status %s", softwareStatus)
+		}
+		hopt.SoftwareStatusFilter = fleet.HostSoftwareStatus(softwareStatus)
+	}
+
+	softwareRuleID := r.URL.Query().Get("software_rule_id")
+	if softwareRuleID != "" {
+		id, err := strconv.Atoi(softwareRuleID)
+		if err != nil {
+			return hopt, err
+		}
+		rid := uint(id)
+		hopt.SoftwareRuleIDFilter = &rid
+	}
+
+	includeTags := r.URL.Query().Get("include_tags")
+	if includeTags != "" {
+		boolVal, err := strconv.ParseBool(includeTags)
+		if err != nil {
+			return hopt, err
+		}
+		hopt.IncludeTags = boolVal
+	}
+
+	for _, tag := range r.URL.Query()["host_tag"] {
+		f, err := fleet.ParseHostTagFilter(tag)
+		if err != nil {
+			return hopt, ctxerr.Wrap(r.Context(), err, "parse host_tag")
+		}
+		hopt.HostTagFilters = append(hopt.HostTagFilters, f)
+	}
+
+	lifecycleState := r.URL.Query().Get("lifecycle_state")
+	if lifecycleState != "" {
+		if !fleet.HostLifecycleState(lifecycleState).IsValid() {
+			return hopt, ctxerr.Errorf(r.Context(), "invalid lifecycle_state %s", lifecycleState)
+		}
+		state := fleet.HostLifecycleState(lifecycleState)
+		hopt.LifecycleStateFilter = &state
+	}
+
 	return hopt, nil
 }
 
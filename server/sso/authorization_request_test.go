@@ -86,6 +86,11 @@ func (s *mockStore) create(requestID, originalURL, metadata string, lifetimeSecs
 	return nil
 }
 
+func (s *mockStore) createOIDC(state, originalURL, nonce, codeVerifier string, lifetimeSecs uint) error {
+	s.session = &Session{OriginalURL: originalURL, Nonce: nonce, CodeVerifier: codeVerifier}
+	return nil
+}
+
 func (s *mockStore) Get(requestID string) (*Session, error) {
 	if s.session == nil {
 		return nil, ErrSessionNotFound
@@ -0,0 +1,403 @@
+package sso
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/pkg/fleethttp"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// DefaultOIDCScopes are the scopes requested from the OpenID provider when
+// none are configured. The email claim is required to match Fleet users.
+var DefaultOIDCScopes = []string{"openid", "email", "profile"}
+
+// OIDCProviderMetadata is the subset of the OpenID Provider configuration
+// that Fleet needs to run the authorization code flow.
+// See https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type OIDCProviderMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// OIDCSettings holds the information needed to initiate and complete an
+// OpenID Connect authorization code flow with PKCE.
+type OIDCSettings struct {
+	Provider     *OIDCProviderMetadata
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback on the service provider (Fleet) where the
+	// user agent is sent back with the authorization code.
+	RedirectURL  string
+	Scopes       []string
+	SessionStore SessionStore
+	OriginalURL  string
+}
+
+// GetOIDCProviderMetadata retrieves the OpenID Provider configuration using
+// the discovery document published under the issuer URL.
+func GetOIDCProviderMetadata(ctx context.Context, issuerURL string) (*OIDCProviderMetadata, error) {
+	discoveryURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+
+	var md OIDCProviderMetadata
+	if err := getOIDCJSON(ctx, discoveryURL, &md); err != nil {
+		return nil, fmt.Errorf("get openid configuration: %w", err)
+	}
+	// the issuer in the document must exactly match the one used to retrieve
+	// it, see section 4.3 of the discovery spec.
+	if strings.TrimSuffix(md.Issuer, "/") != strings.TrimSuffix(issuerURL, "/") {
+		return nil, fmt.Errorf("openid configuration issuer %q does not match %q", md.Issuer, issuerURL)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("openid configuration is missing required endpoints")
+	}
+	return &md, nil
+}
+
+// CreateOIDCAuthorizationRequest generates the state, nonce and PKCE code
+// verifier for a new authorization request, stores them in the session store
+// and returns the URL of the OpenID provider's authorization endpoint the
+// user agent must be redirected to.
+func CreateOIDCAuthorizationRequest(settings *OIDCSettings) (string, error) {
+	if settings.Provider == nil {
+		return "", errors.New("missing openid provider metadata")
+	}
+
+	state, err := generateOIDCToken()
+	if err != nil {
+		return "", fmt.Errorf("creating oidc state: %w", err)
+	}
+	nonce, err := generateOIDCToken()
+	if err != nil {
+		return "", fmt.Errorf("creating oidc nonce: %w", err)
+	}
+	verifier, err := generateOIDCToken()
+	if err != nil {
+		return "", fmt.Errorf("creating oidc code verifier: %w", err)
+	}
+
+	if err := settings.SessionStore.createOIDC(state, settings.OriginalURL, nonce, verifier, cacheLifetime); err != nil {
+		return "", fmt.Errorf("caching state while creating oidc auth request: %w", err)
+	}
+
+	u, err := url.Parse(settings.Provider.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("parsing authorization endpoint: %w", err)
+	}
+
+	scopes := settings.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultOIDCScopes
+	}
+	qry := u.Query()
+	qry.Set("response_type", "code")
+	qry.Set("client_id", settings.ClientID)
+	qry.Set("redirect_uri", settings.RedirectURL)
+	qry.Set("scope", strings.Join(scopes, " "))
+	qry.Set("state", state)
+	qry.Set("nonce", nonce)
+	qry.Set("code_challenge", codeChallengeS256(verifier))
+	qry.Set("code_challenge_method", "S256")
+	u.RawQuery = qry.Encode()
+
+	return u.String(), nil
+}
+
+// OIDCAuthResponse is the authorization response sent by the OpenID provider
+// to the redirect URL. It implements fleet.Auth; the user information is only
+// available after the authorization code was exchanged with
+// ExchangeOIDCCode.
+type OIDCAuthResponse struct {
+	Code   string
+	State  string
+	claims *IDTokenClaims
+}
+
+// DecodeOIDCAuthResponse extracts the authorization code and state from the
+// query parameters of the callback request.
+func DecodeOIDCAuthResponse(values url.Values) (*OIDCAuthResponse, error) {
+	if e := values.Get("error"); e != "" {
+		if desc := values.Get("error_description"); desc != "" {
+			return nil, fmt.Errorf("oidc authorization failed: %s: %s", e, desc)
+		}
+		return nil, fmt.Errorf("oidc authorization failed: %s", e)
+	}
+	resp := &OIDCAuthResponse{
+		Code:  values.Get("code"),
+		State: values.Get("state"),
+	}
+	if resp.Code == "" || resp.State == "" {
+		return nil, errors.New("oidc authorization response is missing code or state")
+	}
+	return resp, nil
+}
+
+func (r *OIDCAuthResponse) UserID() string {
+	if r.claims != nil {
+		return r.claims.Email
+	}
+	return ""
+}
+
+func (r *OIDCAuthResponse) UserDisplayName() string {
+	if r.claims != nil {
+		if r.claims.Name != "" {
+			return r.claims.Name
+		}
+		return r.claims.PreferredUsername
+	}
+	return ""
+}
+
+func (r *OIDCAuthResponse) RequestID() string {
+	return r.State
+}
+
+// IDTokenClaims are the claims of the ID token that are used by Fleet.
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// ExchangeOIDCCode exchanges the authorization code of resp for tokens at the
+// provider's token endpoint, using the PKCE code verifier stored in session.
+// The ID token is then validated (signature, issuer, audience, expiration and
+// nonce) and its claims are attached to resp.
+func ExchangeOIDCCode(ctx context.Context, settings *OIDCSettings, session *Session, resp *OIDCAuthResponse) error {
+	if settings.Provider == nil {
+		return errors.New("missing openid provider metadata")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", resp.Code)
+	form.Set("redirect_uri", settings.RedirectURL)
+	form.Set("code_verifier", session.CodeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, settings.Provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(settings.ClientID), url.QueryEscape(settings.ClientSecret))
+
+	client := fleethttp.NewClient(fleethttp.WithTimeout(10 * time.Second))
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("token request: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read token response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("token endpoint returned %s: %s", res.Status, body)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return fmt.Errorf("decode token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return errors.New("token response is missing id_token")
+	}
+
+	keys, err := getOIDCSigningKeys(ctx, settings.Provider.JWKSURI)
+	if err != nil {
+		return err
+	}
+	claims, err := validateIDToken(tokens.IDToken, keys, settings.Provider.Issuer, settings.ClientID, session.Nonce)
+	if err != nil {
+		return err
+	}
+	resp.claims = claims
+	return nil
+}
+
+func validateIDToken(rawToken string, keys map[string]crypto.PublicKey, issuer, clientID, nonce string) (*IDTokenClaims, error) {
+	var claims IDTokenClaims
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+	_, err := parser.ParseWithClaims(rawToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		// a provider with a single key is allowed to omit the key ID
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("validate id token: %w", err)
+	}
+
+	if !claims.VerifyIssuer(issuer, true) {
+		return nil, fmt.Errorf("id token issuer %q does not match %q", claims.Issuer, issuer)
+	}
+	if !claims.VerifyAudience(clientID, true) {
+		return nil, errors.New("id token was not issued for this client")
+	}
+	if !claims.VerifyExpiresAt(time.Now(), true) {
+		return nil, errors.New("id token is missing an expiration time")
+	}
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+	if claims.Email == "" {
+		return nil, errors.New("id token is missing the email claim")
+	}
+	if claims.EmailVerified != nil && !*claims.EmailVerified {
+		return nil, errors.New("id token email is not verified")
+	}
+	return &claims, nil
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// getOIDCSigningKeys retrieves the provider's JSON Web Key Set and returns
+// the signing keys indexed by key ID. Unsupported keys are ignored.
+func getOIDCSigningKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getOIDCJSON(ctx, jwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("get json web key set: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("json web key set does not contain any supported signing key")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func getOIDCJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := fleethttp.NewClient(fleethttp.WithTimeout(5 * time.Second))
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", u, res.Status)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v)
+}
+
+// generateOIDCToken returns a random URL-safe string, suitable for the state,
+// nonce and PKCE code verifier (43 characters, see RFC 7636 section 4.1).
+func generateOIDCToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func codeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
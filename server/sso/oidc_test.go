@@ -0,0 +1,150 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/sso/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCFlow(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.NewProvider(t)
+
+	provider, err := GetOIDCProviderMetadata(ctx, idp.URL())
+	require.NoError(t, err)
+	assert.Equal(t, idp.URL()+"/token", provider.TokenEndpoint)
+
+	start := func(t *testing.T) (*OIDCSettings, *mockStore, *OIDCAuthResponse) {
+		store := &mockStore{}
+		settings := &OIDCSettings{
+			Provider:     provider,
+			ClientID:     oidctest.ClientID,
+			ClientSecret: oidctest.ClientSecret,
+			RedirectURL:  "https://fleet.example.com/api/v1/fleet/sso/callback",
+			SessionStore: store,
+			OriginalURL:  "/redir",
+		}
+		authURL, err := CreateOIDCAuthorizationRequest(settings)
+		require.NoError(t, err)
+
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		q := parsed.Query()
+		assert.Equal(t, "code", q.Get("response_type"))
+		assert.Equal(t, oidctest.ClientID, q.Get("client_id"))
+		assert.Equal(t, "S256", q.Get("code_challenge_method"))
+		assert.Equal(t, "openid email profile", q.Get("scope"))
+		require.NotNil(t, store.session)
+		assert.Equal(t, "/redir", store.session.OriginalURL)
+		assert.Equal(t, q.Get("nonce"), store.session.Nonce)
+		assert.Equal(t, codeChallengeS256(store.session.CodeVerifier), q.Get("code_challenge"))
+
+		resp, err := DecodeOIDCAuthResponse(idp.Authorize(t, authURL))
+		require.NoError(t, err)
+		assert.Equal(t, q.Get("state"), resp.RequestID())
+		assert.Empty(t, resp.UserID())
+		return settings, store, resp
+	}
+
+	t.Run("success", func(t *testing.T) {
+		settings, store, resp := start(t)
+		err := ExchangeOIDCCode(ctx, settings, store.session, resp)
+		require.NoError(t, err)
+		assert.Equal(t, "sso_user@example.com", resp.UserID())
+		assert.Equal(t, "SSO User", resp.UserDisplayName())
+	})
+
+	t.Run("code cannot be reused", func(t *testing.T) {
+		settings, store, resp := start(t)
+		require.NoError(t, ExchangeOIDCCode(ctx, settings, store.session, resp))
+		err := ExchangeOIDCCode(ctx, settings, store.session, resp)
+		require.ErrorContains(t, err, "400")
+	})
+
+	t.Run("invalid code verifier", func(t *testing.T) {
+		settings, store, resp := start(t)
+		sess := *store.session
+		sess.CodeVerifier = "not-the-verifier"
+		err := ExchangeOIDCCode(ctx, settings, &sess, resp)
+		require.ErrorContains(t, err, "400")
+	})
+
+	t.Run("invalid nonce", func(t *testing.T) {
+		settings, store, resp := start(t)
+		sess := *store.session
+		sess.Nonce = "not-the-nonce"
+		err := ExchangeOIDCCode(ctx, settings, &sess, resp)
+		require.ErrorContains(t, err, "nonce")
+		assert.Empty(t, resp.UserID())
+	})
+
+	t.Run("invalid client secret", func(t *testing.T) {
+		settings, store, resp := start(t)
+		settings.ClientSecret = "wrong"
+		err := ExchangeOIDCCode(ctx, settings, store.session, resp)
+		require.ErrorContains(t, err, "401")
+	})
+
+	t.Run("invalid issuer", func(t *testing.T) {
+		idp.SetClaims(oidctest.Claims{Email: "sso_user@example.com", Issuer: "https://evil.example.com"})
+		defer idp.SetClaims(oidctest.Claims{Email: "sso_user@example.com"})
+
+		settings, store, resp := start(t)
+		err := ExchangeOIDCCode(ctx, settings, store.session, resp)
+		require.ErrorContains(t, err, "issuer")
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		verified := false
+		idp.SetClaims(oidctest.Claims{Email: "sso_user@example.com", EmailVerified: &verified})
+		defer idp.SetClaims(oidctest.Claims{Email: "sso_user@example.com"})
+
+		settings, store, resp := start(t)
+		err := ExchangeOIDCCode(ctx, settings, store.session, resp)
+		require.ErrorContains(t, err, "not verified")
+	})
+
+	t.Run("missing email", func(t *testing.T) {
+		idp.SetClaims(oidctest.Claims{Name: "No Email"})
+		defer idp.SetClaims(oidctest.Claims{Email: "sso_user@example.com"})
+
+		settings, store, resp := start(t)
+		err := ExchangeOIDCCode(ctx, settings, store.session, resp)
+		require.ErrorContains(t, err, "email")
+	})
+}
+
+func TestDecodeOIDCAuthResponse(t *testing.T) {
+	_, err := DecodeOIDCAuthResponse(url.Values{"error": {"access_denied"}, "error_description": {"denied by user"}})
+	require.ErrorContains(t, err, "access_denied: denied by user")
+
+	_, err = DecodeOIDCAuthResponse(url.Values{"code": {"abc"}})
+	require.Error(t, err)
+
+	resp, err := DecodeOIDCAuthResponse(url.Values{"code": {"abc"}, "state": {"def"}})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", resp.Code)
+	assert.Equal(t, "def", resp.RequestID())
+}
+
+func TestGetOIDCProviderMetadataIssuerMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(OIDCProviderMetadata{
+			Issuer:                "https://other.example.com",
+			AuthorizationEndpoint: "https://other.example.com/authorize",
+			TokenEndpoint:         "https://other.example.com/token",
+			JWKSURI:               "https://other.example.com/jwks",
+		})
+	}))
+	defer srv.Close()
+
+	_, err := GetOIDCProviderMetadata(context.Background(), srv.URL)
+	require.ErrorContains(t, err, "does not match")
+}
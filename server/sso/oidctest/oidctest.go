@@ -0,0 +1,213 @@
+// Package oidctest provides a minimal OpenID Connect identity provider to test
+// the OIDC single sign-on flow.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ClientID     = "fleet-client"
+	ClientSecret = "fleet-secret"
+	keyID        = "test-key"
+)
+
+// Claims are the user claims included in the ID tokens issued by the
+// Provider.
+type Claims struct {
+	Email         string
+	EmailVerified *bool
+	Name          string
+	// Issuer overrides the issuer of the ID token if set.
+	Issuer string
+}
+
+// Provider is an OpenID provider that implements discovery, the JSON Web Key
+// Set, the authorization and the token endpoints of the authorization code
+// flow with PKCE. The authorization endpoint authenticates the user
+// identified by the current claims without any user interaction.
+type Provider struct {
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims Claims
+	// codes maps an issued authorization code to the authorization request
+	// parameters.
+	codes map[string]url.Values
+}
+
+// NewProvider starts a new Provider that is stopped when the test completes.
+func NewProvider(t *testing.T) *Provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &Provider{
+		key:   key,
+		codes: make(map[string]url.Values),
+		claims: Claims{
+			Email: "sso_user@example.com",
+			Name:  "SSO User",
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+// URL returns the issuer URL of the provider.
+func (p *Provider) URL() string {
+	return p.srv.URL
+}
+
+// SetClaims sets the claims of the user authenticated by the provider.
+func (p *Provider) SetClaims(claims Claims) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claims = claims
+}
+
+// Authorize sends the request to the authorization URL, which must have been
+// created by Fleet, and returns the parameters of the authorization response
+// sent to the redirect URL.
+func (p *Provider) Authorize(t *testing.T, authURL string) url.Values {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	loc, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+	return loc.Query()
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                           p.srv.URL,
+		"authorization_endpoint":           p.srv.URL + "/authorize",
+		"token_endpoint":                   p.srv.URL + "/token",
+		"jwks_uri":                         p.srv.URL + "/jwks",
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": keyID,
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	code := base64.RawURLEncoding.EncodeToString(b)
+
+	p.mu.Lock()
+	p.codes[code] = r.URL.Query()
+	p.mu.Unlock()
+
+	redir, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := redir.Query()
+	q.Set("code", code)
+	q.Set("state", r.URL.Query().Get("state"))
+	redir.RawQuery = q.Encode()
+	http.Redirect(w, r, redir.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != ClientID || secret != ClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	code := r.PostForm.Get("code")
+	authReq, ok := p.codes[code]
+	delete(p.codes, code)
+	claims := p.claims
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != authReq.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != authReq.Get("code_challenge") {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	issuer := claims.Issuer
+	if issuer == "" {
+		issuer = p.srv.URL
+	}
+	tokenClaims := jwt.MapClaims{
+		"iss":   issuer,
+		"sub":   claims.Email,
+		"aud":   ClientID,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": authReq.Get("nonce"),
+		"email": claims.Email,
+		"name":  claims.Name,
+	}
+	if claims.EmailVerified != nil {
+		tokenClaims["email_verified"] = *claims.EmailVerified
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, tokenClaims)
+	token.Header["kid"] = keyID
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     signed,
+	})
+}
@@ -21,6 +21,10 @@ type Session struct {
 	// ExpiresAt session will be removed after this time.
 	ExpiresAt time.Time `json:"expires_at"`
 	Metadata  string    `json:"metadata"`
+	// Nonce and CodeVerifier are only set for OpenID Connect sessions, they
+	// are used to validate the ID token and to redeem the authorization code.
+	Nonce        string `json:"nonce,omitempty"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
 }
 
 // SessionStore persists state of a sso session across process boundries and
@@ -30,6 +34,7 @@ type Session struct {
 // a reasonable amount of time, it automatically expires and is removed.
 type SessionStore interface {
 	create(requestID, originalURL, metadata string, lifetimeSecs uint) error
+	createOIDC(state, originalURL, nonce, codeVerifier string, lifetimeSecs uint) error
 	Get(requestID string) (*Session, error)
 	Expire(requestID string) error
 }
@@ -44,12 +49,19 @@ type store struct {
 }
 
 func (s *store) create(requestID, originalURL, metadata string, lifetimeSecs uint) error {
+	return s.set(requestID, Session{OriginalURL: originalURL, Metadata: metadata}, lifetimeSecs)
+}
+
+func (s *store) createOIDC(state, originalURL, nonce, codeVerifier string, lifetimeSecs uint) error {
+	return s.set(state, Session{OriginalURL: originalURL, Nonce: nonce, CodeVerifier: codeVerifier}, lifetimeSecs)
+}
+
+func (s *store) set(requestID string, sess Session, lifetimeSecs uint) error {
 	if len(requestID) < 8 {
 		return errors.New("request id must be 8 or more characters in length")
 	}
 	conn := redis.ConfigureDoer(s.pool, s.pool.Get())
 	defer conn.Close()
-	sess := Session{OriginalURL: originalURL, Metadata: metadata}
 	var writer bytes.Buffer
 	err := json.NewEncoder(&writer).Encode(sess)
 	if err != nil {
@@ -43,6 +43,18 @@ func TestSessionStore(t *testing.T) {
 		assert.Equal(t, ErrSessionNotFound, err)
 		assert.Nil(t, sess)
 
+		// Create an OpenID Connect session
+		err = store.createOIDC("state789abc", "https://originalurl.com", "nonce", "verifier", 1)
+		require.NoError(t, err)
+
+		sess, err = store.Get("state789abc")
+		require.NoError(t, err)
+		require.NotNil(t, sess)
+		assert.Equal(t, "https://originalurl.com", sess.OriginalURL)
+		assert.Equal(t, "nonce", sess.Nonce)
+		assert.Equal(t, "verifier", sess.CodeVerifier)
+		assert.Empty(t, sess.Metadata)
+
 		// Expire a session that does not exist is fine
 		err = store.Expire("requestNOSUCH")
 		require.NoError(t, err)
@@ -0,0 +1,137 @@
+package osv
+
+import (
+	"sort"
+)
+
+// Range types of the affected versions, see
+// https://ossf.github.io/osv-schema/#affectedrangestype-field. GIT ranges are
+// not supported as the software inventory has no commit hashes, the affected
+// versions they resolve to are listed in the advisory's versions.
+const (
+	RangeTypeSemver    = "SEMVER"
+	RangeTypeEcosystem = "ECOSYSTEM"
+)
+
+// Event is an event of a range of affected versions, only one of its fields
+// is set.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// version returns the version of the event.
+func (e Event) version() string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	default:
+		return e.LastAffected
+	}
+}
+
+// Range is a range of affected versions, described by the versions at which
+// the vulnerability was introduced and fixed.
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Advisory is the subset of an OSV vulnerability needed to detect the
+// vulnerable versions of a package.
+type Advisory struct {
+	// ID is the OSV identifier of the vulnerability (e.g. GHSA-xxxx-xxxx-xxxx
+	// or PYSEC-2021-1).
+	ID string `json:"id"`
+	// CVEs are the CVE identifiers of the vulnerability, from its ID and its
+	// aliases.
+	CVEs []string `json:"cves"`
+	// Package is the normalized name of the affected package.
+	Package  string   `json:"package"`
+	Ranges   []Range  `json:"ranges,omitempty"`
+	Versions []string `json:"versions,omitempty"`
+}
+
+// Affects returns true if the version of the package is affected by the
+// vulnerability.
+func (a *Advisory) Affects(ecosystem Ecosystem, version string) bool {
+	for _, v := range a.Versions {
+		if v == version {
+			return true
+		}
+	}
+	for _, r := range a.Ranges {
+		if r.Type != RangeTypeSemver && r.Type != RangeTypeEcosystem {
+			continue
+		}
+		if rangeAffects(ecosystem, r.Events, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeAffects evaluates the events of the range in version order, as
+// specified in https://ossf.github.io/osv-schema/#evaluation. Versions that
+// cannot be compared are considered not affected.
+func rangeAffects(ecosystem Ecosystem, events []Event, version string) bool {
+	cmp := func(a, b string) (int, bool) {
+		// the version 0 of an introduced event is before all versions
+		switch {
+		case a == "0" && b == "0":
+			return 0, true
+		case a == "0":
+			return -1, true
+		case b == "0":
+			return 1, true
+		}
+		return ecosystem.compareVersions(a, b)
+	}
+
+	sorted := make([]Event, 0, len(events))
+	for _, e := range events {
+		if _, ok := cmp(e.version(), e.version()); ok {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		c, _ := cmp(sorted[i].version(), sorted[j].version())
+		return c < 0
+	})
+
+	var affected bool
+	for _, e := range sorted {
+		c, ok := cmp(version, e.version())
+		if !ok {
+			return false
+		}
+		switch {
+		case e.Introduced != "" && c >= 0:
+			affected = true
+		case e.Fixed != "" && c >= 0:
+			affected = false
+		case e.LastAffected != "" && c > 0:
+			affected = false
+		}
+	}
+	return affected
+}
+
+// Database is the set of advisories of an ecosystem.
+type Database struct {
+	Ecosystem  Ecosystem  `json:"ecosystem"`
+	Advisories []Advisory `json:"advisories"`
+}
+
+// ByPackage indexes the advisories by their package name.
+func (db *Database) ByPackage() map[string][]*Advisory {
+	idx := make(map[string][]*Advisory)
+	for i := range db.Advisories {
+		a := &db.Advisories[i]
+		idx[a.Package] = append(idx[a.Package], a)
+	}
+	return idx
+}
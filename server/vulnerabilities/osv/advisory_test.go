@@ -0,0 +1,68 @@
+package osv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryAffects(t *testing.T) {
+	adv := Advisory{
+		ID:   "GHSA-x84v-xcm2-53pg",
+		CVEs: []string{"CVE-2018-18074"},
+		Ranges: []Range{
+			{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "0"}, {Fixed: "2.20.0"}}},
+			// events are evaluated in version order
+			{Type: RangeTypeEcosystem, Events: []Event{{LastAffected: "3.1"}, {Introduced: "3.0"}}},
+			// GIT ranges are ignored
+			{Type: "GIT", Events: []Event{{Introduced: "0"}}},
+		},
+		Versions: []string{"4.0-custom"},
+	}
+	cases := []struct {
+		version  string
+		affected bool
+	}{
+		{"1.0", true},
+		{"2.19.1", true},
+		{"2.20.0rc1", true},
+		{"2.20.0", false},
+		{"2.31.0", false},
+		{"3.0", true},
+		{"3.1", true},
+		{"3.1.1", false},
+		{"4.0-custom", true},
+		{"not a version", false},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.affected, adv.Affects(EcosystemPyPI, c.version), c.version)
+	}
+
+	adv = Advisory{
+		Ranges: []Range{
+			{Type: RangeTypeSemver, Events: []Event{{Introduced: "0"}, {Fixed: "4.17.19"}}},
+			{Type: RangeTypeSemver, Events: []Event{{Introduced: "5.0.0-beta.1"}, {Fixed: "5.0.1"}}},
+		},
+	}
+	cases = []struct {
+		version  string
+		affected bool
+	}{
+		{"4.17.15", true},
+		{"4.17.19", false},
+		{"4.17.21", false},
+		{"5.0.0-alpha", false},
+		{"5.0.0", true},
+		{"5.0.1", false},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.affected, adv.Affects(EcosystemNPM, c.version), c.version)
+	}
+}
+
+func TestNormalizePackageName(t *testing.T) {
+	require.Equal(t, "zope-interface", EcosystemPyPI.NormalizePackageName("Zope.Interface"))
+	require.Equal(t, "typing-extensions", EcosystemPyPI.NormalizePackageName("typing__extensions"))
+	require.Equal(t, "@babel/core", EcosystemNPM.NormalizePackageName("@Babel/core"))
+	require.Equal(t, "lodash.merge", EcosystemNPM.NormalizePackageName("lodash.merge"))
+}
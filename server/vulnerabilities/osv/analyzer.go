@@ -0,0 +1,177 @@
+package osv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+)
+
+const vulnBatchSize = 500
+
+// Analyze matches the software of the supported sources against the OSV
+// databases of their ecosystem, inserting any new vulnerabilities and deleting
+// the vulnerabilities previously detected with OSV that are not found anymore
+// (e.g. the software was upgraded or the advisory was withdrawn). If
+// collectVulns is true, returns the newly inserted vulnerabilities.
+func Analyze(
+	ctx context.Context,
+	ds fleet.Datastore,
+	vulnPath string,
+	collectVulns bool,
+) ([]fleet.SoftwareVulnerability, error) {
+	software, err := ds.ListSoftwareBySources(ctx, SupportedSoftwareSources)
+	if err != nil {
+		return nil, err
+	}
+
+	softwareByEcosystem := make(map[Ecosystem][]fleet.Software)
+	for _, s := range software {
+		if e, ok := EcosystemForSource(s.Source); ok {
+			softwareByEcosystem[e] = append(softwareByEcosystem[e], s)
+		}
+	}
+
+	found := make(map[string]fleet.SoftwareVulnerability)
+	for _, ecosystem := range Ecosystems() {
+		if len(softwareByEcosystem[ecosystem]) == 0 {
+			continue
+		}
+		db, err := loadDB(ecosystem, vulnPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range Match(db, softwareByEcosystem[ecosystem]) {
+			found[v.Key()] = v
+		}
+	}
+
+	existing, err := ds.ListSoftwareVulnerabilitiesBySource(ctx, fleet.OSVSource)
+	if err != nil {
+		return nil, err
+	}
+	existingSet := make(map[string]bool, len(existing))
+	var toDelete []fleet.SoftwareVulnerability
+	for _, e := range existing {
+		existingSet[e.Key()] = true
+		if _, ok := found[e.Key()]; !ok {
+			toDelete = append(toDelete, e)
+		}
+	}
+	var toInsert []fleet.SoftwareVulnerability
+	for k, f := range found {
+		if !existingSet[k] {
+			toInsert = append(toInsert, f)
+		}
+	}
+
+	for start := 0; start < len(toDelete); start += vulnBatchSize {
+		end := start + vulnBatchSize
+		if end > len(toDelete) {
+			end = len(toDelete)
+		}
+		if err := ds.DeleteSoftwareVulnerabilities(ctx, toDelete[start:end]); err != nil {
+			return nil, err
+		}
+	}
+
+	var inserted []fleet.SoftwareVulnerability
+	for start := 0; start < len(toInsert); start += vulnBatchSize {
+		end := start + vulnBatchSize
+		if end > len(toInsert) {
+			end = len(toInsert)
+		}
+		n, err := ds.InsertVulnerabilities(ctx, toInsert[start:end], fleet.OSVSource)
+		if err != nil {
+			return nil, err
+		}
+		if collectVulns && n > 0 {
+			inserted = append(inserted, toInsert[start:end]...)
+		}
+	}
+
+	return inserted, nil
+}
+
+// Match returns the vulnerabilities of the software (of the database's
+// ecosystem) found in the database.
+func Match(db *Database, software []fleet.Software) []fleet.SoftwareVulnerability {
+	byPackage := db.ByPackage()
+
+	var vulns []fleet.SoftwareVulnerability
+	for _, s := range software {
+		seen := make(map[string]bool)
+		for _, adv := range byPackage[db.Ecosystem.NormalizePackageName(s.Name)] {
+			if !adv.Affects(db.Ecosystem, s.Version) {
+				continue
+			}
+			for _, cve := range adv.CVEs {
+				if !seen[cve] {
+					seen[cve] = true
+					vulns = append(vulns, fleet.SoftwareVulnerability{SoftwareID: s.ID, CVE: cve})
+				}
+			}
+		}
+	}
+	return vulns
+}
+
+// loadDB returns the latest OSV database of the ecosystem.
+func loadDB(ecosystem Ecosystem, vulnPath string) (*Database, error) {
+	latest, err := latestDBFor(ecosystem, vulnPath, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	payload, err := os.ReadFile(latest)
+	if err != nil {
+		return nil, err
+	}
+	var db Database
+	if err := json.Unmarshal(payload, &db); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", latest, err)
+	}
+	return &db, nil
+}
+
+// latestDBFor returns the path of the OSV database of the ecosystem in
+// 'vulnPath' for the given 'date'. If not found, returns the most up to date
+// database of the ecosystem.
+func latestDBFor(ecosystem Ecosystem, vulnPath string, date time.Time) (string, error) {
+	target := filepath.Join(vulnPath, ecosystem.ToFilename(date))
+	switch _, err := os.Stat(target); {
+	case err == nil:
+		return target, nil
+	case errors.Is(err, fs.ErrNotExist):
+		files, err := os.ReadDir(vulnPath)
+		if err != nil {
+			return "", err
+		}
+
+		prefix := FilePrefix + strings.ToLower(string(ecosystem)) + "-"
+		var latest os.FileInfo
+		for _, f := range files {
+			if strings.HasPrefix(f.Name(), prefix) && strings.HasSuffix(f.Name(), ".json") {
+				info, err := f.Info()
+				if err != nil {
+					continue
+				}
+				if latest == nil || info.ModTime().After(latest.ModTime()) {
+					latest = info
+				}
+			}
+		}
+		if latest == nil {
+			return "", fmt.Errorf("OSV database not found for ecosystem '%s' in '%s'", ecosystem, vulnPath)
+		}
+		return filepath.Join(vulnPath, latest.Name()), nil
+	default:
+		return "", fmt.Errorf("failed to stat %q: %w", target, err)
+	}
+}
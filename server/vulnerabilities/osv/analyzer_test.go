@@ -0,0 +1,105 @@
+package osv
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestDB(t *testing.T, vulnPath string, db *Database) {
+	payload, err := json.Marshal(db)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(vulnPath, db.Ecosystem.ToFilename(time.Now())), payload, 0o644))
+}
+
+func TestAnalyze(t *testing.T) {
+	ctx := context.Background()
+	vulnPath := t.TempDir()
+
+	writeTestDB(t, vulnPath, &Database{
+		Ecosystem: EcosystemPyPI,
+		Advisories: []Advisory{
+			{
+				ID:      "GHSA-x84v-xcm2-53pg",
+				CVEs:    []string{"CVE-2018-18074"},
+				Package: "requests",
+				Ranges:  []Range{{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "0"}, {Fixed: "2.20.0"}}}},
+			},
+			{
+				ID:      "PYSEC-2018-28",
+				CVEs:    []string{"CVE-2018-18074"},
+				Package: "requests",
+				Ranges:  []Range{{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "0"}, {Fixed: "2.20.0"}}}},
+			},
+		},
+	})
+	writeTestDB(t, vulnPath, &Database{
+		Ecosystem: EcosystemNPM,
+		Advisories: []Advisory{
+			{
+				ID:      "GHSA-p6mc-m468-83gw",
+				CVEs:    []string{"CVE-2020-8203"},
+				Package: "lodash",
+				Ranges:  []Range{{Type: RangeTypeSemver, Events: []Event{{Introduced: "0"}, {Fixed: "4.17.19"}}}},
+			},
+		},
+	})
+
+	ds := new(mock.Store)
+	ds.ListSoftwareBySourcesFunc = func(ctx context.Context, sources []string) ([]fleet.Software, error) {
+		require.ElementsMatch(t, SupportedSoftwareSources, sources)
+		return []fleet.Software{
+			{ID: 1, Name: "requests", Version: "2.19.1", Source: "python_packages"},
+			{ID: 2, Name: "requests", Version: "2.28.1", Source: "python_packages"},
+			{ID: 3, Name: "lodash", Version: "4.17.15", Source: "npm_packages"},
+			{ID: 4, Name: "lodash", Version: "4.17.21", Source: "npm_packages"},
+		}, nil
+	}
+	ds.ListSoftwareVulnerabilitiesBySourceFunc = func(ctx context.Context, source fleet.VulnerabilitySource) ([]fleet.SoftwareVulnerability, error) {
+		require.Equal(t, fleet.OSVSource, source)
+		return []fleet.SoftwareVulnerability{
+			{SoftwareID: 3, CVE: "CVE-2020-8203"},
+			// lodash 4.17.21 is not vulnerable anymore
+			{SoftwareID: 4, CVE: "CVE-2020-8203"},
+		}, nil
+	}
+	var deleted []fleet.SoftwareVulnerability
+	ds.DeleteSoftwareVulnerabilitiesFunc = func(ctx context.Context, vulns []fleet.SoftwareVulnerability) error {
+		deleted = append(deleted, vulns...)
+		return nil
+	}
+	var inserted []fleet.SoftwareVulnerability
+	ds.InsertVulnerabilitiesFunc = func(ctx context.Context, vulns []fleet.SoftwareVulnerability, source fleet.VulnerabilitySource) (int64, error) {
+		require.Equal(t, fleet.OSVSource, source)
+		inserted = append(inserted, vulns...)
+		return int64(len(vulns)), nil
+	}
+
+	newVulns, err := Analyze(ctx, ds, vulnPath, true)
+	require.NoError(t, err)
+	require.Equal(t, []fleet.SoftwareVulnerability{{SoftwareID: 1, CVE: "CVE-2018-18074"}}, inserted)
+	require.Equal(t, inserted, newVulns)
+	require.Equal(t, []fleet.SoftwareVulnerability{{SoftwareID: 4, CVE: "CVE-2020-8203"}}, deleted)
+
+	// the new vulnerabilities are only returned if collected
+	inserted, deleted = nil, nil
+	newVulns, err = Analyze(ctx, ds, vulnPath, false)
+	require.NoError(t, err)
+	require.Len(t, inserted, 1)
+	require.Nil(t, newVulns)
+
+	// a missing database fails the analysis before any change
+	require.NoError(t, os.Remove(filepath.Join(vulnPath, EcosystemNPM.ToFilename(time.Now()))))
+	inserted, deleted = nil, nil
+	_, err = Analyze(ctx, ds, vulnPath, true)
+	require.ErrorContains(t, err, "OSV database not found")
+	require.Empty(t, inserted)
+	require.Empty(t, deleted)
+}
@@ -0,0 +1,89 @@
+// Package osv detects the vulnerabilities of the software of language package
+// ecosystems (e.g. Python and npm packages) using the advisories published by
+// OSV (https://osv.dev), which aggregates the GitHub security advisories and
+// the advisory databases of the ecosystems.
+//
+// The software is matched by ecosystem, package name and version range, which
+// is more accurate than the CPE guessing of the NVD detection for those
+// sources. Software sources without an OSV ecosystem (e.g. browser extensions
+// and Homebrew packages) are left to the NVD detection.
+package osv
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FilePrefix is the prefix of the OSV databases stored in the vulnerabilities
+// directory.
+const FilePrefix = "osv-"
+
+// Ecosystem is an OSV ecosystem, see
+// https://ossf.github.io/osv-schema/#affectedpackage-field.
+type Ecosystem string
+
+const (
+	EcosystemPyPI Ecosystem = "PyPI"
+	EcosystemNPM  Ecosystem = "npm"
+)
+
+// ecosystemsBySource maps the software sources to the OSV ecosystem of their
+// packages.
+var ecosystemsBySource = map[string]Ecosystem{
+	"python_packages": EcosystemPyPI,
+	"npm_packages":    EcosystemNPM,
+}
+
+// SupportedSoftwareSources are the software sources for which we are using OSV
+// for vulnerability detection.
+var SupportedSoftwareSources = []string{"npm_packages", "python_packages"}
+
+// EcosystemForSource returns the OSV ecosystem of the software source, it
+// returns false if the source is not supported.
+func EcosystemForSource(source string) (Ecosystem, bool) {
+	e, ok := ecosystemsBySource[source]
+	return e, ok
+}
+
+// Ecosystems returns the supported ecosystems, sorted by name.
+func Ecosystems() []Ecosystem {
+	ecosystems := make([]Ecosystem, 0, len(ecosystemsBySource))
+	for _, e := range ecosystemsBySource {
+		ecosystems = append(ecosystems, e)
+	}
+	sort.Slice(ecosystems, func(i, j int) bool { return ecosystems[i] < ecosystems[j] })
+	return ecosystems
+}
+
+// pypiNameSeparators matches the runs of separators that are equivalent in a
+// Python package name, see https://peps.python.org/pep-0503/#normalized-names.
+var pypiNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizePackageName returns the name of the package as compared by the
+// ecosystem's package registry.
+func (e Ecosystem) NormalizePackageName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if e == EcosystemPyPI {
+		name = pypiNameSeparators.ReplaceAllString(name, "-")
+	}
+	return name
+}
+
+// ToFilename returns the name of the OSV database of the ecosystem for the
+// given date.
+func (e Ecosystem) ToFilename(date time.Time) string {
+	return fmt.Sprintf("%s%s-%d_%02d_%02d.json", FilePrefix, strings.ToLower(string(e)), date.Year(), date.Month(), date.Day())
+}
+
+// compareVersions compares two versions of a package of the ecosystem, it
+// returns -1, 0 or 1 if a is respectively older than, the same as or newer
+// than b. It returns false if either version cannot be parsed.
+func (e Ecosystem) compareVersions(a, b string) (int, bool) {
+	if e == EcosystemPyPI {
+		return comparePEP440(a, b)
+	}
+	return compareSemver(a, b)
+}
@@ -0,0 +1,110 @@
+package osv
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// record is the subset of an OSV vulnerability record that is parsed, see
+// https://ossf.github.io/osv-schema/.
+type record struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases"`
+	Withdrawn string   `json:"withdrawn"`
+	Affected  []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges   []Range  `json:"ranges"`
+		Versions []string `json:"versions"`
+	} `json:"affected"`
+}
+
+// cves returns the CVE identifiers of the vulnerability.
+func (r *record) cves() []string {
+	var cves []string
+	seen := make(map[string]bool)
+	for _, id := range append([]string{r.ID}, r.Aliases...) {
+		if strings.HasPrefix(id, "CVE-") && !seen[id] {
+			seen[id] = true
+			cves = append(cves, id)
+		}
+	}
+	return cves
+}
+
+// ParseDump parses the OSV data dump of the ecosystem, a zip archive of OSV
+// vulnerability records (see https://google.github.io/osv.dev/data/#data-dumps).
+//
+// Only the vulnerabilities with a CVE identifier are kept, as the detected
+// vulnerabilities are stored, reported and scored by CVE. Withdrawn
+// vulnerabilities are skipped.
+func ParseDump(zipPath string, ecosystem Ecosystem) (*Database, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("open OSV dump: %w", err)
+	}
+	defer zr.Close()
+
+	db := &Database{Ecosystem: ecosystem}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || path.Ext(f.Name) != ".json" {
+			continue
+		}
+		advisories, err := parseRecord(f, ecosystem)
+		if err != nil {
+			return nil, fmt.Errorf("parse OSV record %s: %w", f.Name, err)
+		}
+		db.Advisories = append(db.Advisories, advisories...)
+	}
+	return db, nil
+}
+
+// parseRecord parses the OSV record into the advisories of the packages of the
+// ecosystem that it affects.
+func parseRecord(f *zip.File, ecosystem Ecosystem) ([]Advisory, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var r record
+	if err := json.NewDecoder(rc).Decode(&r); err != nil {
+		return nil, err
+	}
+	if r.Withdrawn != "" {
+		return nil, nil
+	}
+	cves := r.cves()
+	if len(cves) == 0 {
+		return nil, nil
+	}
+
+	var advisories []Advisory
+	for _, aff := range r.Affected {
+		if Ecosystem(aff.Package.Ecosystem) != ecosystem || aff.Package.Name == "" {
+			continue
+		}
+		adv := Advisory{
+			ID:       r.ID,
+			CVEs:     cves,
+			Package:  ecosystem.NormalizePackageName(aff.Package.Name),
+			Versions: aff.Versions,
+		}
+		for _, rng := range aff.Ranges {
+			if rng.Type == RangeTypeSemver || rng.Type == RangeTypeEcosystem {
+				adv.Ranges = append(adv.Ranges, rng)
+			}
+		}
+		if len(adv.Ranges) == 0 && len(adv.Versions) == 0 {
+			continue
+		}
+		advisories = append(advisories, adv)
+	}
+	return advisories, nil
+}
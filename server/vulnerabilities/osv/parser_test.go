@@ -0,0 +1,113 @@
+package osv
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testPyPIRecords are OSV records of the PyPI data dump, by file name.
+var testPyPIRecords = map[string]string{
+	"GHSA-x84v-xcm2-53pg.json": `{
+		"id": "GHSA-x84v-xcm2-53pg",
+		"aliases": ["CVE-2018-18074", "PYSEC-2018-28"],
+		"affected": [{
+			"package": {"ecosystem": "PyPI", "name": "Requests"},
+			"ranges": [
+				{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "2.20.0"}]},
+				{"type": "GIT", "repo": "https://github.com/psf/requests", "events": [{"introduced": "0"}]}
+			],
+			"versions": ["2.19.1"]
+		}]
+	}`,
+	"PYSEC-2021-1.json": `{
+		"id": "PYSEC-2021-1",
+		"affected": [{
+			"package": {"ecosystem": "PyPI", "name": "nocve"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}]}]
+		}]
+	}`,
+	"GHSA-withdrawn.json": `{
+		"id": "GHSA-withdrawn",
+		"aliases": ["CVE-2020-0001"],
+		"withdrawn": "2021-01-01T00:00:00Z",
+		"affected": [{
+			"package": {"ecosystem": "PyPI", "name": "withdrawn"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}]}]
+		}]
+	}`,
+	"CVE-2021-2.json": `{
+		"id": "CVE-2021-2",
+		"affected": [
+			{
+				"package": {"ecosystem": "PyPI", "name": "Zope.Interface"},
+				"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "5.0"}, {"last_affected": "5.1"}]}]
+			},
+			{
+				"package": {"ecosystem": "npm", "name": "zope"},
+				"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]
+			},
+			{
+				"package": {"ecosystem": "PyPI", "name": "git-only"},
+				"ranges": [{"type": "GIT", "repo": "https://example.com", "events": [{"introduced": "0"}]}]
+			}
+		]
+	}`,
+}
+
+// writeTestDump writes the records in a zip archive, as an OSV data dump.
+func writeTestDump(t *testing.T, path string, records map[string]string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range records {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+}
+
+func TestParseDump(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "all.zip")
+	writeTestDump(t, zipPath, testPyPIRecords)
+
+	db, err := ParseDump(zipPath, EcosystemPyPI)
+	require.NoError(t, err)
+	require.Equal(t, EcosystemPyPI, db.Ecosystem)
+	require.ElementsMatch(t, []Advisory{
+		{
+			ID:       "GHSA-x84v-xcm2-53pg",
+			CVEs:     []string{"CVE-2018-18074"},
+			Package:  "requests",
+			Ranges:   []Range{{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "0"}, {Fixed: "2.20.0"}}}},
+			Versions: []string{"2.19.1"},
+		},
+		{
+			ID:      "CVE-2021-2",
+			CVEs:    []string{"CVE-2021-2"},
+			Package: "zope-interface",
+			Ranges:  []Range{{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "5.0"}, {LastAffected: "5.1"}}}},
+		},
+	}, db.Advisories)
+
+	db, err = ParseDump(zipPath, EcosystemNPM)
+	require.NoError(t, err)
+	require.Len(t, db.Advisories, 1)
+	require.Equal(t, "zope", db.Advisories[0].Package)
+
+	// invalid records fail the parsing
+	writeTestDump(t, zipPath, map[string]string{"bad.json": "{"})
+	_, err = ParseDump(zipPath, EcosystemPyPI)
+	require.ErrorContains(t, err, "bad.json")
+
+	require.NoError(t, os.WriteFile(zipPath, []byte("not found"), 0o644))
+	_, err = ParseDump(zipPath, EcosystemPyPI)
+	require.ErrorContains(t, err, "open OSV dump")
+}
@@ -0,0 +1,98 @@
+package osv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/pkg/download"
+)
+
+// DumpsURL is the base URL of the OSV data dumps, the dump of an ecosystem is
+// at <DumpsURL>/<ecosystem>/all.zip.
+const DumpsURL = "https://osv-vulnerabilities.storage.googleapis.com"
+
+// removeOldDBs walks 'path' removing any old OSV databases, returns a set
+// containing the databases that are up to date according to 'date'.
+func removeOldDBs(date time.Time, path string) (map[string]bool, error) {
+	dateSuffix := fmt.Sprintf("-%d_%02d_%02d.json", date.Year(), date.Month(), date.Day())
+	upToDate := make(map[string]bool)
+
+	err := filepath.WalkDir(path, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasPrefix(d.Name(), FilePrefix) || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		if strings.HasSuffix(d.Name(), dateSuffix) {
+			upToDate[d.Name()] = true
+			return nil
+		}
+		return os.Remove(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return upToDate, nil
+}
+
+// Refresh checks all local OSV databases contained in 'vulnPath', deleting the
+// old ones and downloading the missing ones based on today's date. Returns the
+// ecosystems of the newly downloaded databases.
+func Refresh(ctx context.Context, client *http.Client, vulnPath string) ([]Ecosystem, error) {
+	return refresh(ctx, client, DumpsURL, vulnPath, time.Now())
+}
+
+func refresh(ctx context.Context, client *http.Client, dumpsURL, vulnPath string, now time.Time) ([]Ecosystem, error) {
+	existing, err := removeOldDBs(now, vulnPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var downloaded []Ecosystem
+	for _, ecosystem := range Ecosystems() {
+		if existing[ecosystem.ToFilename(now)] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return downloaded, err
+		}
+		if err := Sync(client, dumpsURL, vulnPath, ecosystem, now); err != nil {
+			return downloaded, err
+		}
+		downloaded = append(downloaded, ecosystem)
+	}
+	return downloaded, nil
+}
+
+// Sync downloads the OSV data dump of the ecosystem from 'dumpsURL' and stores
+// its parsed database in 'dstDir', for the given date.
+func Sync(client *http.Client, dumpsURL string, dstDir string, ecosystem Ecosystem, date time.Time) error {
+	u, err := url.Parse(fmt.Sprintf("%s/%s/all.zip", strings.TrimSuffix(dumpsURL, "/"), url.PathEscape(string(ecosystem))))
+	if err != nil {
+		return err
+	}
+
+	zipPath := filepath.Join(dstDir, FilePrefix+strings.ToLower(string(ecosystem))+".zip")
+	if err := download.Download(client, u, zipPath); err != nil {
+		return fmt.Errorf("download %s OSV dump: %w", ecosystem, err)
+	}
+	defer os.Remove(zipPath)
+
+	db, err := ParseDump(zipPath, ecosystem)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ecosystem, err)
+	}
+	payload, err := json.Marshal(db)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dstDir, ecosystem.ToFilename(date)), payload, 0o644)
+}
@@ -0,0 +1,55 @@
+package osv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 10, 24, 12, 0, 0, 0, time.UTC)
+	vulnPath := t.TempDir()
+
+	dumpPath := filepath.Join(t.TempDir(), "all.zip")
+	writeTestDump(t, dumpPath, testPyPIRecords)
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		http.ServeFile(w, r, dumpPath)
+	}))
+	defer srv.Close()
+
+	// an old database and an unrelated file
+	old := filepath.Join(vulnPath, EcosystemPyPI.ToFilename(now.AddDate(0, 0, -1)))
+	require.NoError(t, os.WriteFile(old, []byte("{}"), 0o644))
+	other := filepath.Join(vulnPath, "cpe.sqlite")
+	require.NoError(t, os.WriteFile(other, []byte("x"), 0o644))
+
+	downloaded, err := refresh(ctx, srv.Client(), srv.URL, vulnPath, now)
+	require.NoError(t, err)
+	require.Equal(t, []Ecosystem{EcosystemPyPI, EcosystemNPM}, downloaded)
+	require.ElementsMatch(t, []string{"/npm/all.zip", "/PyPI/all.zip"}, requested)
+
+	require.NoFileExists(t, old)
+	require.FileExists(t, other)
+	require.NoFileExists(t, filepath.Join(vulnPath, "osv-pypi.zip"))
+
+	db, err := loadDB(EcosystemPyPI, vulnPath)
+	require.NoError(t, err)
+	require.Equal(t, EcosystemPyPI, db.Ecosystem)
+	require.Len(t, db.Advisories, 2)
+
+	// the databases are up to date
+	requested = nil
+	downloaded, err = refresh(ctx, srv.Client(), srv.URL, vulnPath, now)
+	require.NoError(t, err)
+	require.Empty(t, downloaded)
+	require.Empty(t, requested)
+}
@@ -0,0 +1,137 @@
+package osv
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// compareSemver compares two semantic versions, as used by npm.
+func compareSemver(a, b string) (int, bool) {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return 0, false
+	}
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return 0, false
+	}
+	return va.Compare(vb), true
+}
+
+// pep440Regexp matches a Python package version, see
+// https://peps.python.org/pep-0440/#appendix-b-parsing-version-strings-with-regular-expressions.
+var pep440Regexp = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|alpha|b|beta|c|rc|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?$`)
+
+// pep440Version is a parsed Python package version. The pre-release, post
+// release and development release are converted to keys that sort the
+// versions as specified by PEP 440.
+type pep440Version struct {
+	epoch   int
+	release []int
+	// preKey sorts the pre-releases (alpha, beta and release candidate) before
+	// the final release, and a development release without pre-release before
+	// the pre-releases.
+	preKey [2]int
+	// postKey sorts the post releases after the release.
+	postKey int
+	// devKey sorts the development releases before the release.
+	devKey int
+}
+
+var pep440PrePhases = map[string]int{
+	"a": 0, "alpha": 0,
+	"b": 1, "beta": 1,
+	"c": 2, "rc": 2, "pre": 2, "preview": 2,
+}
+
+func parsePEP440(s string) (*pep440Version, bool) {
+	m := pep440Regexp.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return nil, false
+	}
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	v := &pep440Version{epoch: atoi(m[1]), postKey: -1, devKey: math.MaxInt}
+	for _, part := range strings.Split(m[2], ".") {
+		v.release = append(v.release, atoi(part))
+	}
+	hasPost := m[5] != "" || m[6] != ""
+	if hasPost {
+		v.postKey = atoi(m[5] + m[7])
+	}
+	hasDev := m[8] != ""
+	if hasDev {
+		v.devKey = atoi(m[9])
+	}
+	switch {
+	case m[3] != "":
+		v.preKey = [2]int{pep440PrePhases[m[3]], atoi(m[4])}
+	case hasDev && !hasPost:
+		v.preKey = [2]int{-1, 0}
+	default:
+		v.preKey = [2]int{math.MaxInt, 0}
+	}
+	return v, true
+}
+
+// comparePEP440 compares two Python package versions.
+func comparePEP440(a, b string) (int, bool) {
+	va, ok := parsePEP440(a)
+	if !ok {
+		return 0, false
+	}
+	vb, ok := parsePEP440(b)
+	if !ok {
+		return 0, false
+	}
+
+	if c := compareInts(va.epoch, vb.epoch); c != 0 {
+		return c, true
+	}
+	// trailing zeros are not significant, 1.0 == 1.0.0
+	for i := 0; i < len(va.release) || i < len(vb.release); i++ {
+		var ra, rb int
+		if i < len(va.release) {
+			ra = va.release[i]
+		}
+		if i < len(vb.release) {
+			rb = vb.release[i]
+		}
+		if c := compareInts(ra, rb); c != 0 {
+			return c, true
+		}
+	}
+	for _, c := range []int{
+		compareInts(va.preKey[0], vb.preKey[0]),
+		compareInts(va.preKey[1], vb.preKey[1]),
+		compareInts(va.postKey, vb.postKey),
+		compareInts(va.devKey, vb.devKey),
+	} {
+		if c != 0 {
+			return c, true
+		}
+	}
+	return 0, true
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
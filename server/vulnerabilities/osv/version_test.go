@@ -0,0 +1,70 @@
+package osv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparePEP440(t *testing.T) {
+	// each version is older than the next one
+	ordered := []string{
+		"1.0.dev456",
+		"1.0a1",
+		"1.0a2.dev456",
+		"1.0a12.dev456",
+		"1.0a12",
+		"1.0b1.dev456",
+		"1.0b2",
+		"1.0b2.post345.dev456",
+		"1.0b2.post345",
+		"1.0rc1.dev456",
+		"1.0rc1",
+		"1.0",
+		"1.0.post456.dev34",
+		"1.0.post456",
+		"1.1.dev1",
+		"1.2",
+		"1.10",
+		"2!0.1",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		c, ok := comparePEP440(ordered[i], ordered[i+1])
+		require.True(t, ok, ordered[i])
+		require.Equal(t, -1, c, "%s < %s", ordered[i], ordered[i+1])
+		c, ok = comparePEP440(ordered[i+1], ordered[i])
+		require.True(t, ok)
+		require.Equal(t, 1, c, "%s > %s", ordered[i+1], ordered[i])
+	}
+
+	for _, eq := range [][2]string{
+		{"1.0", "1.0.0"},
+		{"1.0.0", "v1.0"},
+		{"1.0rc1", "1.0c1"},
+		{"1.0-1", "1.0.post1"},
+		{"1.0.post", "1.0.post0"},
+		{"1.0+local.1", "1.0"},
+		{"1.0ALPHA1", "1.0a1"},
+	} {
+		c, ok := comparePEP440(eq[0], eq[1])
+		require.True(t, ok)
+		require.Zero(t, c, "%s == %s", eq[0], eq[1])
+	}
+
+	_, ok := comparePEP440("1.0", "not a version")
+	require.False(t, ok)
+}
+
+func TestCompareSemver(t *testing.T) {
+	c, ok := compareSemver("4.17.15", "4.17.19")
+	require.True(t, ok)
+	require.Equal(t, -1, c)
+	c, ok = compareSemver("1.0.0-beta.1", "1.0.0")
+	require.True(t, ok)
+	require.Equal(t, -1, c)
+	c, ok = compareSemver("2.0.0", "2.0.0")
+	require.True(t, ok)
+	require.Zero(t, c)
+	_, ok = compareSemver("latest", "1.0.0")
+	require.False(t, ok)
+}
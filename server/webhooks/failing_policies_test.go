@@ -73,6 +73,13 @@ func TestTriggerFailingPoliciesWebhookBasic(t *testing.T) {
 		},
 	}
 
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, ids []uint) ([]*fleet.Host, error) {
+		hosts := make([]*fleet.Host, 0, len(ids))
+		for _, id := range ids {
+			hosts = append(hosts, &fleet.Host{ID: id, LifecycleState: fleet.HostLifecycleStateActive})
+		}
+		return hosts, nil
+	}
 	ds.AppConfigFunc = func(context.Context) (*fleet.AppConfig, error) {
 		return ac, nil
 	}
@@ -118,6 +125,9 @@ func TestTriggerFailingPoliciesWebhookBasic(t *testing.T) {
         "team_id": null,
         "resolution": "policy1 resolution",
         "platform": "darwin",
+        "remediation_type": "",
+        "severity": "",
+        "tags": null,
         "created_at": "0001-01-01T00:00:00Z",
         "updated_at": "0001-01-01T00:00:00Z",
         "passing_host_count": 0,
@@ -253,6 +263,13 @@ func TestTriggerFailingPoliciesWebhookTeam(t *testing.T) {
 		},
 	}
 
+	ds.ListHostsLiteByIDsFunc = func(ctx context.Context, ids []uint) ([]*fleet.Host, error) {
+		hosts := make([]*fleet.Host, 0, len(ids))
+		for _, id := range ids {
+			hosts = append(hosts, &fleet.Host{ID: id, LifecycleState: fleet.HostLifecycleStateActive})
+		}
+		return hosts, nil
+	}
 	ds.AppConfigFunc = func(context.Context) (*fleet.AppConfig, error) {
 		return ac, nil
 	}
@@ -301,6 +318,9 @@ func TestTriggerFailingPoliciesWebhookTeam(t *testing.T) {
         "team_id": 1,
         "resolution": "policy1 resolution",
         "platform": "darwin",
+        "remediation_type": "",
+        "severity": "",
+        "tags": null,
         "created_at": "0001-01-01T00:00:00Z",
         "updated_at": "0001-01-01T00:00:00Z",
         "passing_host_count": 0,
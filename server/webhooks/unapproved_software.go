@@ -0,0 +1,172 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// unapprovedSoftwareBatchSize is the maximum number of installs of unapproved
+// software sent in a single request.
+const unapprovedSoftwareBatchSize = 1000
+
+// TriggerUnapprovedSoftwareWebhook sends the installs of unapproved software
+// detected since the last install delivered to the unapproved software
+// webhook. The first time it runs, it sends the installs detected during the
+// interval of the webhooks that ended at now.
+func TriggerUnapprovedSoftwareWebhook(
+	ctx context.Context,
+	ds fleet.Datastore,
+	logger kitlog.Logger,
+	now time.Time,
+) error {
+	appConfig, err := ds.AppConfig(ctx)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "getting app config")
+	}
+
+	settings := appConfig.WebhookSettings.UnapprovedSoftwareWebhook
+	if !settings.Enable || len(settings.Software) == 0 {
+		return nil
+	}
+
+	level.Debug(logger).Log("enabled", "true")
+
+	serverURL, err := url.Parse(appConfig.ServerSettings.ServerURL)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "parsing server url")
+	}
+
+	cursor, err := ds.UnapprovedSoftwareWebhookCursor(ctx)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "getting unapproved software webhook cursor")
+	}
+	if cursor == nil {
+		// start after the last change detected before the interval, so that the
+		// changes detected during the interval are sent.
+		from := now.Add(-appConfig.WebhookSettings.Interval.ValueOr(24 * time.Hour))
+		last, err := ds.ListSoftwareChanges(ctx, fleet.SoftwareChangeListOptions{
+			ListOptions: fleet.ListOptions{
+				OrderKey:       "id",
+				OrderDirection: fleet.OrderDescending,
+				PerPage:        1,
+			},
+			To: &from,
+		})
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "getting last software change before interval")
+		}
+		var lastID uint
+		if len(last) > 0 {
+			lastID = last[0].ID
+		}
+		if err := ds.SetUnapprovedSoftwareWebhookCursor(ctx, lastID); err != nil {
+			return ctxerr.Wrap(ctx, err, "initializing unapproved software webhook cursor")
+		}
+		cursor = &lastID
+	}
+
+	opt := fleet.SoftwareChangeListOptions{
+		ListOptions: fleet.ListOptions{
+			OrderKey: "id",
+			PerPage:  unapprovedSoftwareBatchSize,
+			After:    strconv.FormatUint(uint64(*cursor), 10),
+		},
+		Action:              fleet.SoftwareChangeInstalled,
+		MatchRules:          settings.Software,
+		ExcludeRetiredHosts: true,
+	}
+	for {
+		changes, err := ds.ListSoftwareChanges(ctx, opt)
+		if err != nil {
+			return ctxerr.Wrap(ctx, err, "listing unapproved software installs")
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		installs := make([]unapprovedSoftwareInstall, len(changes))
+		hosts := make(map[uint]bool)
+		for i, c := range changes {
+			installs[i] = makeUnapprovedSoftwareInstall(c, settings.Software, serverURL)
+			hosts[c.HostID] = true
+		}
+		payload := unapprovedSoftwarePayload{
+			Text: fmt.Sprintf(
+				"Unapproved software was installed on %d hosts. "+
+					"You've been sent this message because the Unapproved software webhook is enabled in your Fleet instance.",
+				len(hosts),
+			),
+			Timestamp: now,
+			Installs:  installs,
+		}
+		level.Debug(logger).Log("url", settings.DestinationURL, "installs", len(installs))
+		if err := server.PostJSONWithTimeout(ctx, settings.DestinationURL, &payload); err != nil {
+			return ctxerr.Wrapf(ctx, err, "posting to %s", settings.DestinationURL)
+		}
+
+		// the cursor only moves past the installs that were delivered, the others
+		// are sent at the next run.
+		lastID := changes[len(changes)-1].ID
+		if err := ds.SetUnapprovedSoftwareWebhookCursor(ctx, lastID); err != nil {
+			return ctxerr.Wrap(ctx, err, "updating unapproved software webhook cursor")
+		}
+
+		if len(changes) < unapprovedSoftwareBatchSize {
+			return nil
+		}
+		opt.After = strconv.FormatUint(uint64(lastID), 10)
+	}
+}
+
+type unapprovedSoftwarePayload struct {
+	Text      string                      `json:"text"`
+	Timestamp time.Time                   `json:"timestamp"`
+	Installs  []unapprovedSoftwareInstall `json:"unapproved_software"`
+}
+
+type unapprovedSoftwareInstall struct {
+	HostID           uint                    `json:"host_id"`
+	Hostname         string                  `json:"hostname"`
+	HostURL          string                  `json:"host_url"`
+	Name             string                  `json:"name"`
+	Version          string                  `json:"version"`
+	Source           string                  `json:"source"`
+	BundleIdentifier string                  `json:"bundle_identifier,omitempty"`
+	Vendor           string                  `json:"vendor,omitempty"`
+	InstalledAt      time.Time               `json:"installed_at"`
+	Rule             fleet.SoftwareMatchRule `json:"matched_rule"`
+}
+
+func makeUnapprovedSoftwareInstall(change *fleet.SoftwareChange, rules []fleet.SoftwareMatchRule, serverURL *url.URL) unapprovedSoftwareInstall {
+	u := *serverURL
+	u.Path = path.Join(serverURL.Path, "hosts", strconv.FormatUint(uint64(change.HostID), 10))
+
+	install := unapprovedSoftwareInstall{
+		HostID:           change.HostID,
+		Hostname:         change.Hostname,
+		HostURL:          u.String(),
+		Name:             change.Name,
+		Version:          change.Version,
+		Source:           change.Source,
+		BundleIdentifier: change.BundleIdentifier,
+		Vendor:           change.Vendor,
+		InstalledAt:      change.CreatedAt,
+	}
+	for _, rule := range rules {
+		if rule.Matches(change.Name, change.BundleIdentifier, change.Vendor) {
+			install.Rule = rule
+			break
+		}
+	}
+	return install
+}
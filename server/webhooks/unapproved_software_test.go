@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerUnapprovedSoftwareWebhook(t *testing.T) {
+	ds := new(mock.Store)
+
+	var requestBodies []string
+	statusCode := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestBodyBytes, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requestBodies = append(requestBodies, string(requestBodyBytes))
+		w.WriteHeader(statusCode)
+	}))
+	defer ts.Close()
+
+	rules := []fleet.SoftwareMatchRule{
+		{Name: "Dropbox"},
+		{Vendor: "BitTorrent Inc."},
+	}
+	ac := &fleet.AppConfig{
+		ServerSettings: fleet.ServerSettings{ServerURL: "https://fleet.example.com"},
+		WebhookSettings: fleet.WebhookSettings{
+			UnapprovedSoftwareWebhook: fleet.UnapprovedSoftwareWebhookSettings{
+				Enable:         true,
+				DestinationURL: ts.URL,
+				Software:       rules,
+			},
+			Interval: fleet.Duration{Duration: time.Hour},
+		},
+	}
+	ds.AppConfigFunc = func(context.Context) (*fleet.AppConfig, error) {
+		return ac, nil
+	}
+
+	var cursor *uint
+	ds.UnapprovedSoftwareWebhookCursorFunc = func(ctx context.Context) (*uint, error) {
+		return cursor, nil
+	}
+	ds.SetUnapprovedSoftwareWebhookCursorFunc = func(ctx context.Context, id uint) error {
+		cursor = &id
+		return nil
+	}
+
+	now := time.Date(2022, 10, 22, 12, 0, 0, 0, time.UTC)
+	installedAt := now.Add(-time.Minute)
+	ds.ListSoftwareChangesFunc = func(ctx context.Context, opt fleet.SoftwareChangeListOptions) ([]*fleet.SoftwareChange, error) {
+		if opt.Action == "" {
+			// the last change before the interval, used when the webhook runs for
+			// the first time
+			assert.Equal(t, now.Add(-time.Hour), *opt.To)
+			assert.Equal(t, fleet.OrderDescending, opt.OrderDirection)
+			assert.Equal(t, uint(1), opt.PerPage)
+			return []*fleet.SoftwareChange{{ID: 10}}, nil
+		}
+		assert.Equal(t, fleet.SoftwareChangeInstalled, opt.Action)
+		assert.Nil(t, opt.From)
+		assert.Nil(t, opt.To)
+		assert.Equal(t, rules, opt.MatchRules)
+		assert.True(t, opt.ExcludeRetiredHosts)
+		if opt.After != "10" {
+			return nil, nil
+		}
+		return []*fleet.SoftwareChange{
+			{ID: 11, HostID: 1, Hostname: "host1", Name: "Dropbox", Version: "1.0", Source: "apps", BundleIdentifier: "com.getdropbox.dropbox", CreatedAt: installedAt},
+			{ID: 12, HostID: 2, Hostname: "host2", Name: "torrent", Version: "2.0", Source: "programs", Vendor: "BitTorrent Inc.", CreatedAt: installedAt},
+		}, nil
+	}
+
+	require.NoError(t, TriggerUnapprovedSoftwareWebhook(context.Background(), ds, kitlog.NewNopLogger(), now))
+	require.Len(t, requestBodies, 1)
+	assert.JSONEq(t, `{
+		"text": "Unapproved software was installed on 2 hosts. You've been sent this message because the Unapproved software webhook is enabled in your Fleet instance.",
+		"timestamp": "2022-10-22T12:00:00Z",
+		"unapproved_software": [
+			{
+				"host_id": 1,
+				"hostname": "host1",
+				"host_url": "https://fleet.example.com/hosts/1",
+				"name": "Dropbox",
+				"version": "1.0",
+				"source": "apps",
+				"bundle_identifier": "com.getdropbox.dropbox",
+				"installed_at": "2022-10-22T11:59:00Z",
+				"matched_rule": {"name": "Dropbox"}
+			},
+			{
+				"host_id": 2,
+				"hostname": "host2",
+				"host_url": "https://fleet.example.com/hosts/2",
+				"name": "torrent",
+				"version": "2.0",
+				"source": "programs",
+				"vendor": "BitTorrent Inc.",
+				"installed_at": "2022-10-22T11:59:00Z",
+				"matched_rule": {"vendor": "BitTorrent Inc."}
+			}
+		]
+	}`, requestBodies[0])
+	require.NotNil(t, cursor)
+	assert.Equal(t, uint(12), *cursor)
+
+	// the installs that were delivered are not sent again
+	requestBodies = nil
+	require.NoError(t, TriggerUnapprovedSoftwareWebhook(context.Background(), ds, kitlog.NewNopLogger(), now.Add(time.Hour)))
+	assert.Empty(t, requestBodies)
+	assert.Equal(t, uint(12), *cursor)
+
+	// the cursor is not moved if the installs could not be delivered
+	cursor = ptr.Uint(10)
+	statusCode = http.StatusInternalServerError
+	require.Error(t, TriggerUnapprovedSoftwareWebhook(context.Background(), ds, kitlog.NewNopLogger(), now))
+	assert.Len(t, requestBodies, 1)
+	assert.Equal(t, uint(10), *cursor)
+
+	// nothing is done if the webhook is disabled
+	ac.WebhookSettings.UnapprovedSoftwareWebhook.Enable = false
+	ds.ListSoftwareChangesFuncInvoked = false
+	require.NoError(t, TriggerUnapprovedSoftwareWebhook(context.Background(), ds, kitlog.NewNopLogger(), now))
+	assert.False(t, ds.ListSoftwareChangesFuncInvoked)
+}
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/pkg/fleethttp"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// activityWebhookTimeout is the timeout of the requests sent to activity
+// webhooks.
+const activityWebhookTimeout = 30 * time.Second
+
+// ActivityWebhook is the job processor that delivers activities to the
+// activity webhooks. The jobs are queued by the datastore when an activity is
+// created.
+type ActivityWebhook struct {
+	Datastore fleet.Datastore
+	Log       kitlog.Logger
+	// Client is the HTTP client used to send the requests, a client with
+	// the activityWebhookTimeout is used if it is nil.
+	Client *http.Client
+}
+
+// activityWebhookArgs are the arguments for the activity webhook job.
+type activityWebhookArgs struct {
+	WebhookID  uint `json:"webhook_id"`
+	ActivityID uint `json:"activity_id"`
+}
+
+// Name returns the name of the job.
+func (a *ActivityWebhook) Name() string {
+	return fleet.ActivityWebhookJobName
+}
+
+// Run delivers the activity to the webhook and records the delivery attempt.
+func (a *ActivityWebhook) Run(ctx context.Context, argsJSON json.RawMessage) error {
+	var args activityWebhookArgs
+	if err := json.Unmarshal(argsJSON, &args); err != nil {
+		return ctxerr.Wrap(ctx, err, "unmarshal args")
+	}
+
+	webhook, err := a.Datastore.ActivityWebhook(ctx, args.WebhookID)
+	if err != nil {
+		if fleet.IsNotFound(err) {
+			// the webhook was deleted after the job was queued, nothing to do
+			return nil
+		}
+		return ctxerr.Wrap(ctx, err, "get activity webhook")
+	}
+	if !webhook.Enabled {
+		level.Debug(a.Log).Log("msg", "activity webhook disabled, skipping delivery", "webhook_id", webhook.ID)
+		return nil
+	}
+
+	activity, err := a.Datastore.Activity(ctx, args.ActivityID)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "get activity")
+	}
+
+	body, err := json.Marshal(fleet.ActivityWebhookMessage{
+		Timestamp: time.Now().UTC(),
+		Activity:  activity,
+	})
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "marshal activity webhook message")
+	}
+
+	delivery := &fleet.ActivityWebhookDelivery{
+		WebhookID:    webhook.ID,
+		ActivityID:   activity.ID,
+		ActivityType: activity.Type,
+	}
+	start := time.Now()
+	statusCode, sendErr := a.send(ctx, webhook, activity.Type, body)
+	delivery.DurationMs = uint(time.Since(start).Milliseconds())
+	if statusCode != 0 {
+		delivery.StatusCode = &statusCode
+	}
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := a.Datastore.NewActivityWebhookDelivery(ctx, delivery); err != nil {
+		if fleet.IsNotFound(err) {
+			// the webhook was deleted while the request was being sent
+			return nil
+		}
+		return ctxerr.Wrap(ctx, err, "record activity webhook delivery")
+	}
+
+	// returning the error makes the worker retry the job
+	return sendErr
+}
+
+// send posts the body to the webhook, returning the status code of the
+// response (0 if no response was received) and an error if the delivery
+// failed.
+func (a *ActivityWebhook) send(ctx context.Context, webhook *fleet.ActivityWebhook, activityType string, body []byte) (int, error) {
+	client := a.Client
+	if client == nil {
+		client = fleethttp.NewClient(fleethttp.WithTimeout(activityWebhookTimeout))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(fleet.ActivityWebhookEventHeader, activityType)
+	req.Header.Set(fleet.ActivityWebhookSignatureHeader, webhook.Sign(body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to POST to %s: %w", webhook.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("error posting to %s: %d. %s", webhook.URL, resp.StatusCode, string(respBody))
+	}
+	return resp.StatusCode, nil
+}
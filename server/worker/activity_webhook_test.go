@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+type notFoundError struct{}
+
+func (e notFoundError) Error() string    { return "not found" }
+func (e notFoundError) IsNotFound() bool { return true }
+
+func TestActivityWebhookRun(t *testing.T) {
+	ds := new(mock.Store)
+
+	var failRequests bool
+	var gotBody []byte
+	var gotHeaders http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		if failRequests {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	webhook := &fleet.ActivityWebhook{ID: 1, URL: srv.URL, Secret: "s3cr3t", Enabled: true}
+	ds.ActivityWebhookFunc = func(ctx context.Context, id uint) (*fleet.ActivityWebhook, error) {
+		if id != webhook.ID {
+			return nil, notFoundError{}
+		}
+		return webhook, nil
+	}
+	details := json.RawMessage(`{"pack_id":1,"pack_name":"p"}`)
+	ds.ActivityFunc = func(ctx context.Context, id uint) (*fleet.Activity, error) {
+		return &fleet.Activity{ID: id, Type: fleet.ActivityTypeCreatedPack, ActorFullName: "admin", Details: &details}, nil
+	}
+	var deliveries []*fleet.ActivityWebhookDelivery
+	ds.NewActivityWebhookDeliveryFunc = func(ctx context.Context, delivery *fleet.ActivityWebhookDelivery) error {
+		deliveries = append(deliveries, delivery)
+		return nil
+	}
+
+	job := &ActivityWebhook{Datastore: ds, Log: kitlog.NewNopLogger()}
+
+	err := job.Run(context.Background(), json.RawMessage(`{"webhook_id":1,"activity_id":2}`))
+	require.NoError(t, err)
+
+	require.Equal(t, "application/json", gotHeaders.Get("Content-Type"))
+	require.Equal(t, fleet.ActivityTypeCreatedPack, gotHeaders.Get(fleet.ActivityWebhookEventHeader))
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write(gotBody)
+	require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), gotHeaders.Get(fleet.ActivityWebhookSignatureHeader))
+
+	var msg fleet.ActivityWebhookMessage
+	require.NoError(t, json.Unmarshal(gotBody, &msg))
+	require.NotZero(t, msg.Timestamp)
+	require.Equal(t, uint(2), msg.Activity.ID)
+	require.Equal(t, "admin", msg.Activity.ActorFullName)
+	require.JSONEq(t, string(details), string(*msg.Activity.Details))
+
+	require.Len(t, deliveries, 1)
+	require.Equal(t, uint(1), deliveries[0].WebhookID)
+	require.Equal(t, uint(2), deliveries[0].ActivityID)
+	require.Equal(t, fleet.ActivityTypeCreatedPack, deliveries[0].ActivityType)
+	require.NotNil(t, deliveries[0].StatusCode)
+	require.Equal(t, http.StatusNoContent, *deliveries[0].StatusCode)
+	require.Empty(t, deliveries[0].Error)
+
+	// a failed delivery is recorded and returns an error so that it is retried
+	failRequests = true
+	err = job.Run(context.Background(), json.RawMessage(`{"webhook_id":1,"activity_id":2}`))
+	require.Error(t, err)
+	require.Len(t, deliveries, 2)
+	require.Equal(t, http.StatusServiceUnavailable, *deliveries[1].StatusCode)
+	require.Contains(t, deliveries[1].Error, "503")
+
+	// a deleted or disabled webhook is skipped
+	gotBody = nil
+	err = job.Run(context.Background(), json.RawMessage(`{"webhook_id":99,"activity_id":2}`))
+	require.NoError(t, err)
+	webhook.Enabled = false
+	err = job.Run(context.Background(), json.RawMessage(`{"webhook_id":1,"activity_id":2}`))
+	require.NoError(t, err)
+	require.Nil(t, gotBody)
+	require.Len(t, deliveries, 2)
+}
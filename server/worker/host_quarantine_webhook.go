@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/server"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// HostQuarantineWebhook is the job processor that notifies the host
+// quarantine webhook. The jobs are queued when hosts are quarantined.
+type HostQuarantineWebhook struct {
+	Datastore fleet.Datastore
+	Log       kitlog.Logger
+}
+
+// hostQuarantineWebhookArgs are the arguments for the host quarantine webhook
+// job.
+type hostQuarantineWebhookArgs struct {
+	HostID                 uint                     `json:"host_id"`
+	PreviousLifecycleState fleet.HostLifecycleState `json:"previous_lifecycle_state"`
+}
+
+// QueueHostQuarantineWebhookJob queues the notification of the quarantine of
+// the host to the host quarantine webhook.
+func QueueHostQuarantineWebhookJob(ctx context.Context, ds fleet.Datastore, hostID uint, previousState fleet.HostLifecycleState) error {
+	_, err := QueueJob(ctx, ds, fleet.HostQuarantineWebhookJobName, hostQuarantineWebhookArgs{
+		HostID:                 hostID,
+		PreviousLifecycleState: previousState,
+	})
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "queueing job")
+	}
+	return nil
+}
+
+// Name returns the name of the job.
+func (h *HostQuarantineWebhook) Name() string {
+	return fleet.HostQuarantineWebhookJobName
+}
+
+type hostQuarantinePayload struct {
+	Text                   string                   `json:"text"`
+	Timestamp              time.Time                `json:"timestamp"`
+	Host                   hostQuarantineHost       `json:"host"`
+	PreviousLifecycleState fleet.HostLifecycleState `json:"previous_lifecycle_state"`
+}
+
+type hostQuarantineHost struct {
+	ID          uint    `json:"id"`
+	Hostname    string  `json:"hostname"`
+	DisplayName string  `json:"display_name"`
+	URL         string  `json:"url"`
+	TeamID      *uint   `json:"team_id"`
+	TeamName    *string `json:"team_name"`
+}
+
+// Run sends the quarantined host to the host quarantine webhook.
+func (h *HostQuarantineWebhook) Run(ctx context.Context, argsJSON json.RawMessage) error {
+	var args hostQuarantineWebhookArgs
+	if err := json.Unmarshal(argsJSON, &args); err != nil {
+		return ctxerr.Wrap(ctx, err, "unmarshal args")
+	}
+
+	appConfig, err := h.Datastore.AppConfig(ctx)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "get app config")
+	}
+	settings := appConfig.WebhookSettings.HostQuarantineWebhook
+	if !settings.Enable || settings.DestinationURL == "" {
+		level.Debug(h.Log).Log("msg", "host quarantine webhook is disabled, skipping delivery", "host_id", args.HostID)
+		return nil
+	}
+
+	host, err := h.Datastore.Host(ctx, args.HostID)
+	if err != nil {
+		if fleet.IsNotFound(err) {
+			// the host was deleted after the job was queued, nothing to do
+			return nil
+		}
+		return ctxerr.Wrap(ctx, err, "get host")
+	}
+	if host.LifecycleState != fleet.HostLifecycleStateQuarantined {
+		level.Debug(h.Log).Log("msg", "host is no longer quarantined, skipping delivery", "host_id", host.ID)
+		return nil
+	}
+
+	serverURL, err := url.Parse(appConfig.ServerSettings.ServerURL)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "parsing server url")
+	}
+	serverURL.Path = path.Join(serverURL.Path, "hosts", strconv.FormatUint(uint64(host.ID), 10))
+
+	payload := hostQuarantinePayload{
+		Text: fmt.Sprintf(
+			"Host %s was quarantined. "+
+				"You've been sent this message because the Host quarantine webhook is enabled in your Fleet instance.",
+			host.DisplayName(),
+		),
+		Timestamp: time.Now().UTC(),
+		Host: hostQuarantineHost{
+			ID:          host.ID,
+			Hostname:    host.Hostname,
+			DisplayName: host.DisplayName(),
+			URL:         serverURL.String(),
+			TeamID:      host.TeamID,
+			TeamName:    host.TeamName,
+		},
+		PreviousLifecycleState: args.PreviousLifecycleState,
+	}
+
+	// returning the error makes the worker retry the job
+	if err := server.PostJSONWithTimeout(ctx, settings.DestinationURL, &payload); err != nil {
+		return ctxerr.Wrapf(ctx, err, "posting to %s", settings.DestinationURL)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostQuarantineWebhookRun(t *testing.T) {
+	ds := new(mock.Store)
+
+	var failRequests bool
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		if failRequests {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	appConfig := &fleet.AppConfig{
+		ServerSettings: fleet.ServerSettings{ServerURL: "https://fleet.example.com"},
+		WebhookSettings: fleet.WebhookSettings{
+			HostQuarantineWebhook: fleet.HostQuarantineWebhookSettings{Enable: true, DestinationURL: srv.URL},
+		},
+	}
+	ds.AppConfigFunc = func(ctx context.Context) (*fleet.AppConfig, error) {
+		return appConfig, nil
+	}
+	host := &fleet.Host{ID: 1, Hostname: "h1", ComputerName: "Alice's MacBook", TeamID: ptr.Uint(2), TeamName: ptr.String("Quarantine"), LifecycleState: fleet.HostLifecycleStateQuarantined}
+	ds.HostFunc = func(ctx context.Context, id uint) (*fleet.Host, error) {
+		if id != host.ID {
+			return nil, notFoundError{}
+		}
+		return host, nil
+	}
+
+	job := &HostQuarantineWebhook{Datastore: ds, Log: kitlog.NewNopLogger()}
+	args := json.RawMessage(`{"host_id":1,"previous_lifecycle_state":"active"}`)
+
+	err := job.Run(context.Background(), args)
+	require.NoError(t, err)
+
+	var payload hostQuarantinePayload
+	require.NoError(t, json.Unmarshal(gotBody, &payload))
+	require.NotZero(t, payload.Timestamp)
+	require.Contains(t, payload.Text, "Alice's MacBook")
+	require.Equal(t, fleet.HostLifecycleStateActive, payload.PreviousLifecycleState)
+	require.Equal(t, uint(1), payload.Host.ID)
+	require.Equal(t, "h1", payload.Host.Hostname)
+	require.Equal(t, "https://fleet.example.com/hosts/1", payload.Host.URL)
+	require.Equal(t, ptr.Uint(2), payload.Host.TeamID)
+	require.Equal(t, ptr.String("Quarantine"), payload.Host.TeamName)
+
+	// a failed delivery returns an error so that it is retried
+	failRequests = true
+	err = job.Run(context.Background(), args)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "502")
+
+	// a deleted host, a host no longer quarantined or a disabled webhook are
+	// skipped
+	gotBody = nil
+	err = job.Run(context.Background(), json.RawMessage(`{"host_id":99,"previous_lifecycle_state":"active"}`))
+	require.NoError(t, err)
+	host.LifecycleState = fleet.HostLifecycleStateActive
+	err = job.Run(context.Background(), args)
+	require.NoError(t, err)
+	host.LifecycleState = fleet.HostLifecycleStateQuarantined
+	appConfig.WebhookSettings.HostQuarantineWebhook.Enable = false
+	err = job.Run(context.Background(), args)
+	require.NoError(t, err)
+	require.Nil(t, gotBody)
+}
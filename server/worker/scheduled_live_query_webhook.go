@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/fleetdm/fleet/v4/pkg/fleethttp"
+	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/ptr"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// scheduledLiveQueryWebhookTimeout is the timeout of the requests sent to
+// the webhooks of scheduled live queries.
+const scheduledLiveQueryWebhookTimeout = 30 * time.Second
+
+// ScheduledLiveQueryWebhook is the job processor that delivers the results of
+// a scheduled live query run to its webhook. The jobs are queued when the
+// results window of a run ends.
+type ScheduledLiveQueryWebhook struct {
+	Datastore fleet.Datastore
+	Log       kitlog.Logger
+	// Client is the HTTP client used to send the requests, a client with
+	// the scheduledLiveQueryWebhookTimeout is used if it is nil.
+	Client *http.Client
+}
+
+// scheduledLiveQueryWebhookArgs are the arguments for the scheduled live
+// query webhook job.
+type scheduledLiveQueryWebhookArgs struct {
+	ScheduledLiveQueryID uint `json:"scheduled_live_query_id"`
+	CampaignID           uint `json:"campaign_id"`
+}
+
+// QueueScheduledLiveQueryWebhookJob queues the delivery of the results of the
+// campaign to the webhook of the scheduled live query.
+func QueueScheduledLiveQueryWebhookJob(ctx context.Context, ds fleet.Datastore, scheduledLiveQueryID, campaignID uint) error {
+	_, err := QueueJob(ctx, ds, fleet.ScheduledLiveQueryWebhookJobName, scheduledLiveQueryWebhookArgs{
+		ScheduledLiveQueryID: scheduledLiveQueryID,
+		CampaignID:           campaignID,
+	})
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "queueing job")
+	}
+	return nil
+}
+
+// Name returns the name of the job.
+func (s *ScheduledLiveQueryWebhook) Name() string {
+	return fleet.ScheduledLiveQueryWebhookJobName
+}
+
+// Run delivers the results of the campaign to the webhook of the scheduled
+// live query.
+func (s *ScheduledLiveQueryWebhook) Run(ctx context.Context, argsJSON json.RawMessage) error {
+	var args scheduledLiveQueryWebhookArgs
+	if err := json.Unmarshal(argsJSON, &args); err != nil {
+		return ctxerr.Wrap(ctx, err, "unmarshal args")
+	}
+
+	query, err := s.Datastore.ScheduledLiveQuery(ctx, args.ScheduledLiveQueryID)
+	if err != nil {
+		if fleet.IsNotFound(err) {
+			// the scheduled live query was deleted after the job was queued,
+			// nothing to do
+			return nil
+		}
+		return ctxerr.Wrap(ctx, err, "get scheduled live query")
+	}
+	if query.WebhookURL == "" {
+		level.Debug(s.Log).Log("msg", "scheduled live query has no webhook, skipping delivery", "scheduled_live_query_id", query.ID)
+		return nil
+	}
+
+	// the targeted hosts were already filtered based on the author's
+	// permissions when the query ran, all the stored results are sent.
+	filter := fleet.TeamFilter{User: &fleet.User{GlobalRole: ptr.String(fleet.RoleAdmin)}}
+	results, err := s.Datastore.ListDistributedQueryCampaignResults(ctx, filter, args.CampaignID)
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "list campaign results")
+	}
+
+	body, err := json.Marshal(fleet.ScheduledLiveQueryWebhookMessage{
+		Timestamp:          time.Now().UTC(),
+		ScheduledLiveQuery: query,
+		CampaignID:         args.CampaignID,
+		Results:            results,
+	})
+	if err != nil {
+		return ctxerr.Wrap(ctx, err, "marshal scheduled live query webhook message")
+	}
+
+	// returning the error makes the worker retry the job
+	return s.send(ctx, query.WebhookURL, body)
+}
+
+func (s *ScheduledLiveQueryWebhook) send(ctx context.Context, url string, body []byte) error {
+	client := s.Client
+	if client == nil {
+		client = fleethttp.NewClient(fleethttp.WithTimeout(scheduledLiveQueryWebhookTimeout))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to POST to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("error posting to %s: %d. %s", url, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fleetdm/fleet/v4/server/fleet"
+	"github.com/fleetdm/fleet/v4/server/mock"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledLiveQueryWebhookRun(t *testing.T) {
+	ds := new(mock.Store)
+
+	var failRequests bool
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		if failRequests {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	query := &fleet.ScheduledLiveQuery{ID: 1, Name: "hourly", QueryID: 2, Cron: "0 * * * *", WebhookURL: srv.URL, Enabled: true}
+	ds.ScheduledLiveQueryFunc = func(ctx context.Context, id uint) (*fleet.ScheduledLiveQuery, error) {
+		if id != query.ID {
+			return nil, notFoundError{}
+		}
+		return query, nil
+	}
+	ds.ListDistributedQueryCampaignResultsFunc = func(ctx context.Context, filter fleet.TeamFilter, campaignID uint) ([]*fleet.DistributedQueryCampaignResult, error) {
+		require.NotNil(t, filter.User.GlobalRole)
+		require.Equal(t, fleet.RoleAdmin, *filter.User.GlobalRole)
+		return []*fleet.DistributedQueryCampaignResult{
+			{DistributedQueryCampaignID: campaignID, HostID: 1, Hostname: "h1", Rows: []map[string]string{{"a": "1"}}},
+		}, nil
+	}
+
+	job := &ScheduledLiveQueryWebhook{Datastore: ds, Log: kitlog.NewNopLogger()}
+
+	err := job.Run(context.Background(), json.RawMessage(`{"scheduled_live_query_id":1,"campaign_id":3}`))
+	require.NoError(t, err)
+
+	var msg fleet.ScheduledLiveQueryWebhookMessage
+	require.NoError(t, json.Unmarshal(gotBody, &msg))
+	require.NotZero(t, msg.Timestamp)
+	require.Equal(t, uint(3), msg.CampaignID)
+	require.Equal(t, "hourly", msg.ScheduledLiveQuery.Name)
+	require.Len(t, msg.Results, 1)
+	require.Equal(t, "h1", msg.Results[0].Hostname)
+	require.Equal(t, []map[string]string{{"a": "1"}}, msg.Results[0].Rows)
+
+	// a failed delivery returns an error so that it is retried
+	failRequests = true
+	err = job.Run(context.Background(), json.RawMessage(`{"scheduled_live_query_id":1,"campaign_id":3}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "502")
+
+	// a deleted scheduled live query or one without webhook is skipped
+	gotBody = nil
+	err = job.Run(context.Background(), json.RawMessage(`{"scheduled_live_query_id":99,"campaign_id":3}`))
+	require.NoError(t, err)
+	query.WebhookURL = ""
+	err = job.Run(context.Background(), json.RawMessage(`{"scheduled_live_query_id":1,"campaign_id":3}`))
+	require.NoError(t, err)
+	require.Nil(t, gotBody)
+}
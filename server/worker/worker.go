@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/fleetdm/fleet/v4/server/contexts/ctxerr"
 	"github.com/fleetdm/fleet/v4/server/fleet"
@@ -13,6 +15,9 @@ import (
 
 const (
 	maxRetries = 5
+	// retryBackoff is the delay before the first retry of a failed job, it is
+	// doubled for each subsequent retry.
+	retryBackoff = time.Minute
 	// nvdCVEURL is the base link to a CVE on the NVD website, only the CVE code
 	// needs to be appended to make it a valid link.
 	nvdCVEURL = "https://nvd.nist.gov/vuln/detail/"
@@ -49,6 +54,10 @@ type Worker struct {
 	log kitlog.Logger
 
 	registry map[string]Job
+
+	// if true, only the jobs registered in this worker are processed, the
+	// others are left in the queue for another worker.
+	registeredOnly bool
 }
 
 func NewWorker(ds fleet.Datastore, log kitlog.Logger) *Worker {
@@ -59,6 +68,15 @@ func NewWorker(ds fleet.Datastore, log kitlog.Logger) *Worker {
 	}
 }
 
+// NewRegisteredOnlyWorker returns a worker that only processes the jobs
+// registered with it, so that it can process them at a different pace than
+// the other jobs.
+func NewRegisteredOnlyWorker(ds fleet.Datastore, log kitlog.Logger) *Worker {
+	w := NewWorker(ds, log)
+	w.registeredOnly = true
+	return w
+}
+
 func (w *Worker) Register(jobs ...Job) {
 	for _, j := range jobs {
 		name := j.Name()
@@ -90,10 +108,18 @@ func QueueJob(ctx context.Context, ds fleet.Datastore, name string, args interfa
 func (w *Worker) ProcessJobs(ctx context.Context) error {
 	const maxNumJobs = 100
 
+	var names []string
+	if w.registeredOnly {
+		for name := range w.registry {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
 	// process jobs until there are none left or the context is cancelled
 	seen := make(map[uint]struct{})
 	for {
-		jobs, err := w.ds.GetQueuedJobs(ctx, maxNumJobs)
+		jobs, err := w.ds.GetQueuedJobs(ctx, maxNumJobs, names)
 		if err != nil {
 			return ctxerr.Wrap(ctx, err, "get queued jobs")
 		}
@@ -124,6 +150,7 @@ func (w *Worker) ProcessJobs(ctx context.Context) error {
 				job.Error = err.Error()
 				if job.Retries < maxRetries {
 					level.Debug(log).Log("msg", "will retry job")
+					job.NotBefore = time.Now().Add(retryBackoff << job.Retries)
 					job.Retries += 1
 				} else {
 					job.State = fleet.JobStateFailure
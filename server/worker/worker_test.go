@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/fleetdm/fleet/v4/server/fleet"
 	"github.com/fleetdm/fleet/v4/server/mock"
@@ -32,7 +33,7 @@ func TestWorker(t *testing.T) {
 
 	// set up mocks
 	getQueuedJobsCalled := 0
-	ds.GetQueuedJobsFunc = func(ctx context.Context, maxNumJobs int) ([]*fleet.Job, error) {
+	ds.GetQueuedJobsFunc = func(ctx context.Context, maxNumJobs int, names []string) ([]*fleet.Job, error) {
 		if getQueuedJobsCalled > 0 {
 			return nil, nil
 		}
@@ -90,7 +91,7 @@ func TestWorkerRetries(t *testing.T) {
 		State:   fleet.JobStateQueued,
 		Retries: 0,
 	}
-	ds.GetQueuedJobsFunc = func(ctx context.Context, maxNumJobs int) ([]*fleet.Job, error) {
+	ds.GetQueuedJobsFunc = func(ctx context.Context, maxNumJobs int, names []string) ([]*fleet.Job, error) {
 		if theJob.State == fleet.JobStateQueued {
 			return []*fleet.Job{theJob}, nil
 		}
@@ -170,7 +171,7 @@ func TestWorkerMiddleJobFails(t *testing.T) {
 			Retries: 0,
 		},
 	}
-	ds.GetQueuedJobsFunc = func(ctx context.Context, maxNumJobs int) ([]*fleet.Job, error) {
+	ds.GetQueuedJobsFunc = func(ctx context.Context, maxNumJobs int, names []string) ([]*fleet.Job, error) {
 		var queued []*fleet.Job
 		for _, j := range jobs {
 			if j.State == fleet.JobStateQueued {
@@ -234,3 +235,51 @@ func TestWorkerMiddleJobFails(t *testing.T) {
 	require.Equal(t, 2, jobs[1].Retries)
 	require.Equal(t, 4, jobCallCount)
 }
+
+func TestWorkerRegisteredOnlyRetryBackoff(t *testing.T) {
+	ds := new(mock.Store)
+
+	argsJSON := json.RawMessage(`{}`)
+	theJob := &fleet.Job{
+		ID:      1,
+		Name:    "test",
+		Args:    &argsJSON,
+		State:   fleet.JobStateQueued,
+		Retries: 2,
+	}
+	ds.GetQueuedJobsFunc = func(ctx context.Context, maxNumJobs int, names []string) ([]*fleet.Job, error) {
+		require.Equal(t, []string{"other", "test"}, names)
+		if theJob.NotBefore.After(time.Now()) {
+			return nil, nil
+		}
+		return []*fleet.Job{theJob}, nil
+	}
+	ds.UpdateJobFunc = func(ctx context.Context, id uint, job *fleet.Job) (*fleet.Job, error) {
+		return job, nil
+	}
+
+	w := NewRegisteredOnlyWorker(ds, kitlog.NewNopLogger())
+	w.Register(
+		testJob{name: "test", run: func(ctx context.Context, argsJSON json.RawMessage) error {
+			return errors.New("unknown error")
+		}},
+		testJob{name: "other", run: func(ctx context.Context, argsJSON json.RawMessage) error {
+			return nil
+		}},
+	)
+
+	start := time.Now()
+	err := w.ProcessJobs(context.Background())
+	require.NoError(t, err)
+	require.True(t, ds.UpdateJobFuncInvoked)
+	require.Equal(t, 3, theJob.Retries)
+	require.Equal(t, fleet.JobStateQueued, theJob.State)
+	// third failure, the retry is delayed by 4 times the base backoff
+	require.WithinDuration(t, start.Add(4*retryBackoff), theJob.NotBefore, 10*time.Second)
+
+	// the job is not returned until its backoff delay is over
+	ds.UpdateJobFuncInvoked = false
+	err = w.ProcessJobs(context.Background())
+	require.NoError(t, err)
+	require.False(t, ds.UpdateJobFuncInvoked)
+}